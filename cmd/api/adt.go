@@ -0,0 +1,626 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/MdHasib01/hms_server/internal/mrn"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type admissionKey string
+
+const admissionCtx admissionKey = "admission"
+
+var (
+	errBedUnavailable  = errors.New("bed is not available")
+	errBedOccupied     = errors.New("an occupied bed is freed by transferring or discharging its patient")
+	errAlreadyAdmitted = errors.New("patient is already admitted")
+	errNotAdmitted     = errors.New("admission is already discharged")
+)
+
+type CreateWardPayload struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// createWardHandler godoc
+//
+//	@Summary	Creates a ward
+//	@Tags		adt
+//	@Accept		json
+//	@Produce	json
+//	@Param		payload	body		CreateWardPayload	true	"Ward"
+//	@Success	201		{object}	store.Ward
+//	@Failure	400		{object}	error
+//	@Failure	403		{object}	error
+//	@Failure	409		{object}	error
+//	@Failure	500		{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/wards [post]
+func (app *application) createWardHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateWardPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ward := &store.Ward{Name: payload.Name}
+
+	if err := app.store.ADT.CreateWard(r.Context(), ward); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, ward); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getWardsHandler godoc
+//
+//	@Summary	Lists wards
+//	@Tags		adt
+//	@Produce	json
+//	@Success	200	{array}		store.Ward
+//	@Failure	403	{object}	error
+//	@Failure	500	{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/wards [get]
+func (app *application) getWardsHandler(w http.ResponseWriter, r *http.Request) {
+	wards, err := app.store.ADT.GetWards(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, wards); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type CreateRoomPayload struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// createRoomHandler godoc
+//
+//	@Summary	Adds a room to a ward
+//	@Tags		adt
+//	@Accept		json
+//	@Produce	json
+//	@Param		wardID	path		string				true	"Ward ID"
+//	@Param		payload	body		CreateRoomPayload	true	"Room"
+//	@Success	201		{object}	store.Room
+//	@Failure	400		{object}	error
+//	@Failure	403		{object}	error
+//	@Failure	404		{object}	error
+//	@Failure	409		{object}	error
+//	@Failure	500		{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/wards/{wardID}/rooms [post]
+func (app *application) createRoomHandler(w http.ResponseWriter, r *http.Request) {
+	wardID, err := uuid.Parse(chi.URLParam(r, "wardID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload CreateRoomPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	room := &store.Room{WardID: wardID, Name: payload.Name}
+
+	if err := app.store.ADT.CreateRoom(r.Context(), room); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, room); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type CreateBedPayload struct {
+	Label string `json:"label" validate:"required,max=50"`
+}
+
+// createBedHandler godoc
+//
+//	@Summary		Adds a bed to a room
+//	@Description	Adds a bed to a room. New beds are available.
+//	@Tags			adt
+//	@Accept			json
+//	@Produce		json
+//	@Param			roomID	path		string				true	"Room ID"
+//	@Param			payload	body		CreateBedPayload	true	"Bed"
+//	@Success		201		{object}	store.Bed
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/rooms/{roomID}/beds [post]
+func (app *application) createBedHandler(w http.ResponseWriter, r *http.Request) {
+	roomID, err := uuid.Parse(chi.URLParam(r, "roomID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload CreateBedPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	bed := &store.Bed{RoomID: roomID, Label: payload.Label}
+
+	if err := app.store.ADT.CreateBed(r.Context(), bed); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, bed); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type SetBedStatusPayload struct {
+	Status store.BedStatus `json:"status" validate:"required,oneof=available cleaning out_of_service"`
+}
+
+// setBedStatusHandler godoc
+//
+//	@Summary		Changes a bed's status
+//	@Description	Marks a free bed available, cleaning or out of service. Beds become occupied and free only through admissions, transfers and discharges.
+//	@Tags			adt
+//	@Accept			json
+//	@Produce		json
+//	@Param			bedID	path		string				true	"Bed ID"
+//	@Param			payload	body		SetBedStatusPayload	true	"Status"
+//	@Success		200		{object}	store.Bed
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/beds/{bedID}/status [put]
+func (app *application) setBedStatusHandler(w http.ResponseWriter, r *http.Request) {
+	bedID, err := uuid.Parse(chi.URLParam(r, "bedID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload SetBedStatusPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	bed := &store.Bed{ID: bedID, Status: payload.Status}
+
+	if err := app.store.ADT.SetBedStatus(r.Context(), bed); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errBedOccupied)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, bed); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getBedBoardHandler godoc
+//
+//	@Summary		Fetches the bed board
+//	@Description	Lists wards, rooms and beds with their status and current patient, with bed counts by status per ward and overall
+//	@Tags			adt
+//	@Produce		json
+//	@Param			ward_id	query		string	false	"Only this ward"
+//	@Success		200		{object}	store.BedBoard
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/beds/board [get]
+func (app *application) getBedBoardHandler(w http.ResponseWriter, r *http.Request) {
+	wardID, err := parseOptionalUUID("ward_id", r.URL.Query().Get("ward_id"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	board, err := app.store.ADT.GetBoard(r.Context(), wardID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, board); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type AdmitPatientPayload struct {
+	PatientID         uuid.UUID  `json:"patient_id" validate:"required_without=PatientMRN"`
+	PatientMRN        string     `json:"patient_mrn" validate:"max=40"`
+	BedID             uuid.UUID  `json:"bed_id" validate:"required"`
+	AttendingDoctorID *uuid.UUID `json:"attending_doctor_id"`
+	Reason            string     `json:"reason" validate:"required,max=2000"`
+	Notes             string     `json:"notes" validate:"max=2000"`
+}
+
+// admitPatientHandler godoc
+//
+//	@Summary		Admits a patient
+//	@Description	Admits a patient given by ID or MRN to an available bed, which becomes occupied
+//	@Tags			adt
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		AdmitPatientPayload	true	"Admission"
+//	@Success		201		{object}	store.Admission
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admissions [post]
+func (app *application) admitPatientHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	var payload AdmitPatientPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	ref := payload.PatientMRN
+	if payload.PatientID != uuid.Nil {
+		ref = payload.PatientID.String()
+	}
+
+	patient, err := app.admissionPatient(ctx, ref)
+	if err != nil {
+		switch {
+		case errors.Is(err, mrn.ErrCheckDigit), errors.Is(err, store.ErrNotFound):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if payload.AttendingDoctorID != nil {
+		if _, err := app.store.Doctors.GetByID(ctx, *payload.AttendingDoctorID); err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.badRequestResponse(w, r, errors.New("attending doctor not found"))
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+	}
+
+	admission := &store.Admission{
+		PatientID:         patient.UserID,
+		BedID:             payload.BedID,
+		AttendingDoctorID: payload.AttendingDoctorID,
+		Reason:            payload.Reason,
+	}
+
+	if err := app.store.ADT.Admit(ctx, admission, user.ID, payload.Notes); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errBedUnavailable)
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errAlreadyAdmitted)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, admission); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// admissionPatient loads the patient named by ID or MRN.
+func (app *application) admissionPatient(ctx context.Context, ref string) (*store.Patient, error) {
+	id, err := app.resolvePatientID(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.store.Patients.GetByID(ctx, id)
+}
+
+// getAdmissionsHandler godoc
+//
+//	@Summary		Lists admissions
+//	@Description	Lists admissions, newest first, optionally only open or discharged ones in a ward
+//	@Tags			adt
+//	@Produce		json
+//	@Param			status	query		string	false	"admitted or discharged"
+//	@Param			ward_id	query		string	false	"Ward ID"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Success		200		{array}		store.Admission
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admissions [get]
+func (app *application) getAdmissionsHandler(w http.ResponseWriter, r *http.Request) {
+	app.listAdmissions(w, r, store.AdmissionQuery{Limit: 20})
+}
+
+// getPatientAdmissionsHandler godoc
+//
+//	@Summary	Lists a patient's admissions
+//	@Tags		adt
+//	@Produce	json
+//	@Param		patientID	path		string	true	"Patient ID or MRN"
+//	@Param		status		query		string	false	"admitted or discharged"
+//	@Param		limit		query		int		false	"Limit"
+//	@Param		offset		query		int		false	"Offset"
+//	@Success	200			{array}		store.Admission
+//	@Failure	400			{object}	error
+//	@Failure	403			{object}	error
+//	@Failure	404			{object}	error
+//	@Failure	500			{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/patients/{patientID}/admissions [get]
+func (app *application) getPatientAdmissionsHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	app.listAdmissions(w, r, store.AdmissionQuery{PatientID: &patient.UserID, Limit: 20})
+}
+
+func (app *application) listAdmissions(w http.ResponseWriter, r *http.Request, q store.AdmissionQuery) {
+	q, err := q.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(q); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	admissions, err := app.store.ADT.ListAdmissions(r.Context(), q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, admissions); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getAdmissionHandler godoc
+//
+//	@Summary	Fetches an admission
+//	@Tags		adt
+//	@Produce	json
+//	@Param		admissionID	path		string	true	"Admission ID"
+//	@Success	200			{object}	store.Admission
+//	@Failure	403			{object}	error
+//	@Failure	404			{object}	error
+//	@Failure	500			{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/admissions/{admissionID} [get]
+func (app *application) getAdmissionHandler(w http.ResponseWriter, r *http.Request) {
+	admission := getAdmissionFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, admission); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type TransferPatientPayload struct {
+	BedID uuid.UUID `json:"bed_id" validate:"required"`
+	Notes string    `json:"notes" validate:"max=2000"`
+}
+
+// transferPatientHandler godoc
+//
+//	@Summary		Transfers a patient to another bed
+//	@Description	Moves an admitted patient to an available bed. The bed they leave is marked for cleaning.
+//	@Tags			adt
+//	@Accept			json
+//	@Produce		json
+//	@Param			admissionID	path		string					true	"Admission ID"
+//	@Param			payload		body		TransferPatientPayload	true	"New bed"
+//	@Success		200			{object}	store.Admission
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admissions/{admissionID}/transfer [post]
+func (app *application) transferPatientHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	admission := getAdmissionFromCtx(r)
+
+	var payload TransferPatientPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.ADT.Transfer(r.Context(), admission, payload.BedID, user.ID, payload.Notes); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errBedUnavailable)
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errNotAdmitted)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, admission); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type DischargePatientPayload struct {
+	Notes string `json:"notes" validate:"max=2000"`
+}
+
+// dischargePatientHandler godoc
+//
+//	@Summary		Discharges a patient
+//	@Description	Ends an admission. The bed is marked for cleaning.
+//	@Tags			adt
+//	@Accept			json
+//	@Produce		json
+//	@Param			admissionID	path		string					true	"Admission ID"
+//	@Param			payload		body		DischargePatientPayload	true	"Discharge notes"
+//	@Success		200			{object}	store.Admission
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admissions/{admissionID}/discharge [post]
+func (app *application) dischargePatientHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	admission := getAdmissionFromCtx(r)
+
+	var payload DischargePatientPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.ADT.Discharge(r.Context(), admission, user.ID, payload.Notes); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errNotAdmitted)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, admission); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) admissionContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "admissionID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		admission, err := app.store.ADT.GetAdmission(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, admissionCtx, admission)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getAdmissionFromCtx(r *http.Request) *store.Admission {
+	admission, _ := r.Context().Value(admissionCtx).(*store.Admission)
+	return admission
+}
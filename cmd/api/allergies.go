@@ -0,0 +1,163 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type CreateAllergyPayload struct {
+	Substance string                `json:"substance" validate:"required,max=255"`
+	Type      store.AllergyType     `json:"type" validate:"required,oneof=allergy intolerance"`
+	Severity  store.AllergySeverity `json:"severity" validate:"omitempty,oneof=mild moderate severe unknown"`
+	Reaction  string                `json:"reaction" validate:"max=1000"`
+}
+
+// getPatientAllergiesHandler godoc
+//
+//	@Summary		Fetches allergies
+//	@Description	Fetches the allergies and intolerances recorded for a patient
+//	@Tags			patient
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Success		200			{array}		store.Allergy
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/allergies [get]
+func (app *application) getPatientAllergiesHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	allergies, err := app.store.Allergies.GetByPatient(r.Context(), patient.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, allergies); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// addPatientAllergyHandler godoc
+//
+//	@Summary		Records an allergy
+//	@Description	Records an allergy or intolerance for a patient. Substance may name a drug, a drug class such as penicillin, or anything else. Prescriptions are checked against the list. The patient and doctors can record allergies.
+//	@Tags			patient
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string					true	"Patient ID or MRN"
+//	@Param			payload		body		CreateAllergyPayload	true	"Allergy"
+//	@Success		201			{object}	store.Allergy
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/allergies [post]
+func (app *application) addPatientAllergyHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+
+	if !canRecordAllergies(user, patient.UserID) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload CreateAllergyPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	allergy := &store.Allergy{
+		PatientID:  patient.UserID,
+		Substance:  strings.TrimSpace(payload.Substance),
+		Type:       payload.Type,
+		Severity:   payload.Severity,
+		Reaction:   strings.TrimSpace(payload.Reaction),
+		RecordedBy: &user.ID,
+	}
+
+	if allergy.Severity == "" {
+		allergy.Severity = store.AllergySeverityUnknown
+	}
+
+	if err := app.store.Allergies.Create(r.Context(), allergy); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, allergy); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deletePatientAllergyHandler godoc
+//
+//	@Summary	Removes an allergy
+//	@Tags		patient
+//	@Param		patientID	path		string	true	"Patient ID or MRN"
+//	@Param		allergyID	path		string	true	"Allergy ID"
+//	@Success	204			{object}	string
+//	@Failure	400			{object}	error
+//	@Failure	403			{object}	error
+//	@Failure	404			{object}	error
+//	@Failure	500			{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/patients/{patientID}/allergies/{allergyID} [delete]
+func (app *application) deletePatientAllergyHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+
+	if !canRecordAllergies(user, patient.UserID) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	allergyID, err := uuid.Parse(chi.URLParam(r, "allergyID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Allergies.Delete(r.Context(), patient.UserID, allergyID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// canRecordAllergies reports whether the user may change a patient's allergy
+// list: the patient, their guardians or a clinician. Reception can read it
+// only.
+func canRecordAllergies(user *store.User, patientID uuid.UUID) bool {
+	return user.ActsFor(patientID) || isClinician(user)
+}
+
+// isClinician reports whether the user is a doctor or a nurse. Clinical
+// records are limited to these roles by name, since reception shares the
+// doctor's role level.
+func isClinician(user *store.User) bool {
+	return user.Role.Name == "doctor" || user.Role.Name == "nurse"
+}
@@ -12,7 +12,16 @@ import (
 
 	"github.com/MdHasib01/hms_server/docs"
 	"github.com/MdHasib01/hms_server/internal/auth"
+	"github.com/MdHasib01/hms_server/internal/blob"
+	"github.com/MdHasib01/hms_server/internal/claimfile"
+	"github.com/MdHasib01/hms_server/internal/eligibility"
+	"github.com/MdHasib01/hms_server/internal/interactions"
 	"github.com/MdHasib01/hms_server/internal/mailer"
+	"github.com/MdHasib01/hms_server/internal/mrn"
+	"github.com/MdHasib01/hms_server/internal/payments"
+	"github.com/MdHasib01/hms_server/internal/pdf"
+	"github.com/MdHasib01/hms_server/internal/scanner"
+	"github.com/MdHasib01/hms_server/internal/signing"
 	"github.com/MdHasib01/hms_server/internal/store"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 )
@@ -23,16 +32,90 @@ type application struct {
 	logger        *zap.SugaredLogger
 	mailer        mailer.Client
 	authenticator auth.Authenticator
+	blob          blob.Store
+	mrn           *mrn.Generator
+	signer        *signing.Signer
+	interactions  *interactions.Dataset
+	scanner       scanner.Scanner
+	payments      payments.Gateway
+	claimFormat   claimfile.Format
+	eligibility   eligibility.Checker
+	erFeed        *erFeed
 }
 
 type config struct {
-	addr        string
-	db          dbConfig
-	env         string
-	apiURL      string
-	mail        mailConfig
-	frontendURL string
-	auth        authConfig
+	addr         string
+	db           dbConfig
+	env          string
+	apiURL       string
+	mail         mailConfig
+	frontendURL  string
+	auth         authConfig
+	storage      storageConfig
+	mrn          mrn.Config
+	letterhead   pdf.Letterhead
+	signingKey   string
+	immunization immunizationConfig
+	patients     patientConfig
+	pharmacy     pharmacyConfig
+	billing      billingConfig
+	claims       claimsConfig
+	er           erConfig
+	roster       rosterConfig
+}
+
+type rosterConfig struct {
+	// minRest is the least time anyone has off between two shifts
+	minRest time.Duration
+}
+
+type erConfig struct {
+	// breachCheckInterval is how often waits are checked against their
+	// targets; zero turns the alerts off
+	breachCheckInterval time.Duration
+}
+
+type claimsConfig struct {
+	fileFormat         string
+	eligibilityChecker string
+}
+
+type billingConfig struct {
+	// currency is the ISO 4217 code every invoice is raised in
+	currency string
+	// consultationTaxRate is charged on consultations, in basis points
+	consultationTaxRate int
+	paymentGateway      string
+}
+
+type pharmacyConfig struct {
+	// expiryWarningDays is how far ahead batches are reported as expiring
+	expiryWarningDays int
+}
+
+type patientConfig struct {
+	// ageOfMajority is the age at which a dependent may take over their
+	// own account
+	ageOfMajority int
+}
+
+type immunizationConfig struct {
+	// reminderInterval is how often overdue reminders are sent; zero turns
+	// the background run off
+	reminderInterval time.Duration
+	resendAfterDays  int
+}
+
+type storageConfig struct {
+	localDir        string
+	publicURL       string
+	maxPhotoSize    int64
+	maxDocumentSize int64
+	// scanned paper reports run larger than credential documents
+	maxPatientDocumentSize int64
+	thumbnailSize          int
+	virusScanner           string
+	clamdAddr              string
 }
 
 type authConfig struct {
@@ -102,6 +185,7 @@ func (app *application) mount() http.Handler {
 
 		docsURL := fmt.Sprintf("%s/swagger/doc.json", app.config.addr)
 		r.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL(docsURL)))
+		r.Get("/files/*", app.serveFileHandler)
 
 		r.Route("/users", func(r chi.Router) {
 			r.Put("/activate/{token}", app.activateUserHandler)
@@ -110,6 +194,7 @@ func (app *application) mount() http.Handler {
 				r.Use(app.AuthTokenMiddleware)
 
 				r.Get("/", app.getUserHandler)
+				r.Put("/role", app.checkRole("admin", app.setUserRoleHandler))
 			})
 
 			r.Group(func(r chi.Router) {
@@ -126,19 +211,488 @@ func (app *application) mount() http.Handler {
 				r.Use(app.AuthTokenMiddleware)
 				r.Use(app.doctorContextMiddleware)
 				r.Get("/", app.GetByID)
+				r.Put("/photo", app.uploadDoctorPhotoHandler)
+
+				r.Route("/documents", func(r chi.Router) {
+					r.Get("/", app.getDoctorDocumentsHandler)
+					r.Post("/", app.uploadDoctorDocumentHandler)
+					r.Get("/{documentID}", app.downloadDoctorDocumentHandler)
+					r.Delete("/{documentID}", app.deleteDoctorDocumentHandler)
+				})
+
+				r.Route("/fees", func(r chi.Router) {
+					r.Get("/", app.getDoctorFeesHandler)
+					r.Get("/effective", app.getEffectiveDoctorFeeHandler)
+					r.Post("/", app.checkRole("admin", app.createDoctorFeeHandler))
+				})
+
+				r.Route("/availability", func(r chi.Router) {
+					r.Get("/", app.getAvailabilityHandler)
+					r.Post("/", app.CreateAvailablityHandler)
+					r.Get("/conflicts", app.checkRole("doctor", app.getAvailabilityConflictsHandler))
+				})
 			})
-			r.Route("/availability", func(r chi.Router) {
+
+		})
+		r.Route("/patients", func(r chi.Router) {
+			r.Post("/", app.createPatientHandler)
+
+			r.Group(func(r chi.Router) {
 				r.Use(app.AuthTokenMiddleware)
-				r.Post("/", app.activateUserHandler)
+
+				r.Get("/search", app.checkRole("receptionist", app.searchPatientsHandler))
+				r.Get("/lookup", app.checkRole("receptionist", app.lookupPatientHandler))
+
+				r.Route("/{patientID}", func(r chi.Router) {
+					r.Use(app.patientContextMiddleware)
+
+					r.Get("/", app.getPatientHandler)
+					r.Put("/", app.updatePatientHandler)
+
+					r.Route("/insurance", func(r chi.Router) {
+						r.Get("/", app.getPatientInsuranceHandler)
+						r.Post("/", app.addPatientInsuranceHandler)
+						r.Delete("/{policyID}", app.deletePatientInsuranceHandler)
+						r.Get("/{policyID}/eligibility", app.checkRole("receptionist", app.checkEligibilityHandler))
+					})
+
+					r.Route("/identifiers", func(r chi.Router) {
+						r.Get("/", app.getPatientIdentifiersHandler)
+						r.Post("/", app.addPatientIdentifierHandler)
+						r.Delete("/{identifierID}", app.deletePatientIdentifierHandler)
+					})
+
+					r.Route("/allergies", func(r chi.Router) {
+						r.Get("/", app.getPatientAllergiesHandler)
+						r.Post("/", app.addPatientAllergyHandler)
+						r.Delete("/{allergyID}", app.deletePatientAllergyHandler)
+					})
+
+					r.Route("/documents", func(r chi.Router) {
+						r.Get("/", app.getPatientDocumentsHandler)
+						r.Post("/", app.uploadPatientDocumentHandler)
+						r.Get("/{documentID}", app.downloadPatientDocumentHandler)
+						r.Delete("/{documentID}", app.deletePatientDocumentHandler)
+					})
+
+					r.Route("/immunizations", func(r chi.Router) {
+						r.Get("/", app.getPatientImmunizationsHandler)
+						r.Post("/", app.recordImmunizationHandler)
+					})
+
+					r.Get("/timeline", app.getPatientTimelineHandler)
+					r.Get("/encounters", app.getPatientEncountersHandler)
+					r.Get("/admissions", app.getPatientAdmissionsHandler)
+					r.Get("/discharge-summaries", app.getPatientDischargeSummariesHandler)
+					r.Get("/lab-orders", app.getPatientLabOrdersHandler)
+					r.Get("/invoices", app.getPatientInvoicesHandler)
+					r.Get("/balance", app.getPatientBalanceHandler)
+					r.Get("/claims", app.getPatientClaimsHandler)
+					r.Post("/charges", app.checkRole("receptionist", app.createChargeHandler))
+
+					r.Route("/referrals", func(r chi.Router) {
+						r.Get("/", app.getPatientReferralsHandler)
+						r.Post("/", app.checkRoleName("doctor", app.createReferralHandler))
+					})
+
+					r.Route("/crossmatch-requests", func(r chi.Router) {
+						r.Get("/", app.getPatientCrossmatchesHandler)
+						r.Post("/", app.checkRoleName("doctor", app.createCrossmatchHandler))
+					})
+
+					r.Route("/vitals", func(r chi.Router) {
+						r.Get("/", app.getVitalsSeriesHandler)
+						r.Post("/", app.recordVitalsHandler)
+					})
+
+					r.Route("/appointments", func(r chi.Router) {
+						r.Get("/", app.getPatientAppointmentsHandler)
+						r.Post("/", app.bookPatientAppointmentHandler)
+					})
+
+					r.Route("/guardians", func(r chi.Router) {
+						r.Get("/", app.getPatientGuardiansHandler)
+						r.Post("/", app.addPatientGuardianHandler)
+						r.Delete("/{guardianID}", app.removePatientGuardianHandler)
+					})
+					r.Route("/upgrade", func(r chi.Router) {
+						r.Post("/", app.upgradeDependentHandler)
+						r.Delete("/", app.cancelUpgradeHandler)
+					})
+				})
 			})
+		})
 
+		r.Route("/dependents", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.getDependentsHandler)
+			r.Post("/", app.createDependentHandler)
 		})
+
 		// Doctor routes
 		r.Route("/appointments", func(r chi.Router) {
-			// r.Use(app.AuthTokenMiddleware)
-			r.Post("/", app.CreateAppointmentHandler)
-			r.Get("/", app.GetAllAppointmentsHandler)
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Post("/", app.checkRole("receptionist", app.CreateAppointmentHandler))
+			r.Get("/", app.checkRole("receptionist", app.GetAllAppointmentsHandler))
+
+			r.Route("/{appointmentID}", func(r chi.Router) {
+				r.Use(app.appointmentContextMiddleware)
+
+				r.Put("/complete", app.completeAppointmentHandler)
+				r.Post("/cancel", app.cancelAppointmentHandler)
+			})
+
+		})
+
+		r.Route("/encounters/{encounterID}", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+			r.Use(app.encounterContextMiddleware)
+
+			r.Get("/", app.getEncounterHandler)
+			r.Put("/", app.updateEncounterNoteHandler)
+			r.Put("/diagnoses", app.setEncounterDiagnosesHandler)
+			r.Post("/sign", app.signEncounterHandler)
+			r.Post("/addenda", app.addEncounterAddendumHandler)
+
+			r.Route("/prescriptions", func(r chi.Router) {
+				r.Get("/", app.getEncounterPrescriptionsHandler)
+				r.Post("/", app.createPrescriptionHandler)
+				r.Post("/check", app.checkPrescriptionHandler)
+			})
+
+			r.Post("/lab-orders", app.createLabOrderHandler)
+		})
+
+		r.Route("/lab-tests", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.getLabTestsHandler)
+			r.Post("/", app.checkRole("admin", app.createLabTestHandler))
+		})
+
+		r.Route("/lab-orders", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.checkRoleName("lab", app.getLabOrdersHandler))
+
+			r.Route("/{labOrderID}", func(r chi.Router) {
+				r.Use(app.labOrderContextMiddleware)
+
+				r.Get("/", app.getLabOrderHandler)
+				r.Put("/results", app.checkRoleName("lab", app.recordLabResultsHandler))
+				r.Post("/release", app.releaseLabOrderHandler)
+			})
+		})
+
+		r.Route("/wards", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.checkRole("receptionist", app.getWardsHandler))
+			r.Post("/", app.checkRole("admin", app.createWardHandler))
+			r.Post("/{wardID}/rooms", app.checkRole("admin", app.createRoomHandler))
+		})
+
+		r.With(app.AuthTokenMiddleware).Post("/rooms/{roomID}/beds", app.checkRole("admin", app.createBedHandler))
+
+		r.Route("/beds", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/board", app.checkRole("receptionist", app.getBedBoardHandler))
+			r.Put("/{bedID}/status", app.checkRole("receptionist", app.setBedStatusHandler))
+		})
+
+		r.Route("/admissions", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.checkRole("receptionist", app.getAdmissionsHandler))
+			r.Post("/", app.checkRole("receptionist", app.admitPatientHandler))
+
+			r.Route("/{admissionID}", func(r chi.Router) {
+				r.Use(app.admissionContextMiddleware)
+
+				r.Get("/", app.checkRole("receptionist", app.getAdmissionHandler))
+				r.Post("/transfer", app.checkRole("receptionist", app.transferPatientHandler))
+				r.Post("/discharge", app.checkRole("receptionist", app.dischargePatientHandler))
+				r.Post("/discharge-summary", app.checkRoleName("doctor", app.createDischargeSummaryHandler))
+			})
+		})
+
+		r.Route("/discharge-summaries/{summaryID}", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+			r.Use(app.dischargeSummaryContextMiddleware)
+
+			r.Get("/", app.getDischargeSummaryHandler)
+			r.Put("/", app.checkRoleName("doctor", app.updateDischargeSummaryHandler))
+			r.Post("/finalize", app.checkRoleName("doctor", app.finalizeDischargeSummaryHandler))
+			r.Get("/pdf", app.downloadDischargeSummaryPDFHandler)
+			r.Post("/follow-up", app.bookDischargeFollowUpHandler)
+		})
+
+		r.Route("/vitals/thresholds", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.getVitalThresholdsHandler)
+			r.Put("/{type}", app.checkRole("admin", app.setVitalThresholdHandler))
+		})
+
+		r.Route("/immunization-schedule", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.getImmunizationScheduleHandler)
+			r.Put("/{vaccine}/{dose}", app.checkRole("admin", app.setScheduledDoseHandler))
+		})
+
+		r.With(app.AuthTokenMiddleware).Post("/immunizations/reminders", app.checkRole("admin", app.sendImmunizationRemindersHandler))
+
+		r.Route("/prescriptions/{prescriptionID}", func(r chi.Router) {
+			r.Get("/verify", app.verifyPrescriptionHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware)
+				r.Use(app.prescriptionContextMiddleware)
+
+				r.Get("/", app.getPrescriptionHandler)
+				r.Get("/pdf", app.downloadPrescriptionPDFHandler)
+				r.Post("/cancel", app.cancelPrescriptionHandler)
+
+				r.Route("/dispensations", func(r chi.Router) {
+					r.Get("/", app.getDispensationsHandler)
+					r.Post("/", app.checkRoleName("pharmacist", app.dispensePrescriptionHandler))
+				})
+			})
+		})
+
+		r.Route("/pharmacy", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/alerts", app.checkRole("doctor", app.getStockAlertsHandler))
+
+			r.Route("/drugs", func(r chi.Router) {
+				r.Get("/", app.checkRole("doctor", app.getDrugsHandler))
+				r.Post("/", app.checkRole("admin", app.createDrugHandler))
+
+				r.Route("/{drugID}", func(r chi.Router) {
+					r.Use(app.drugContextMiddleware)
+
+					r.Get("/", app.checkRole("doctor", app.getDrugHandler))
+					r.Post("/batches", app.checkRoleName("pharmacist", app.receiveBatchHandler))
+				})
+			})
+		})
+
+		r.Route("/billing/prices", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.checkRole("receptionist", app.getPricesHandler))
+			r.Put("/{kind}/{code}", app.checkRole("admin", app.setPriceHandler))
+		})
+
+		r.Route("/invoices/{invoiceID}", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+			r.Use(app.invoiceContextMiddleware)
+
+			r.Get("/", app.getInvoiceHandler)
+			r.Put("/items/{itemID}", app.checkRole("receptionist", app.setInvoiceItemDiscountHandler))
+			r.Delete("/items/{itemID}", app.checkRole("receptionist", app.deleteInvoiceItemHandler))
+			r.Post("/issue", app.checkRole("receptionist", app.issueInvoiceHandler))
+			r.Post("/void", app.checkRole("admin", app.voidInvoiceHandler))
+			r.Post("/payments", app.createPaymentHandler)
+			r.Post("/claims", app.checkRole("receptionist", app.createClaimHandler))
+		})
+
+		r.Route("/payments/{paymentID}", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+			r.Use(app.paymentContextMiddleware)
+
+			r.Get("/receipt", app.downloadReceiptHandler)
+			r.Post("/refunds", app.checkRole("admin", app.createRefundHandler))
+		})
+
+		r.Route("/claims", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.checkRole("receptionist", app.getClaimsHandler))
+			r.Get("/export", app.checkRole("receptionist", app.exportClaimsHandler))
+
+			r.Route("/{claimID}", func(r chi.Router) {
+				r.Use(app.claimContextMiddleware)
+
+				r.Get("/", app.getClaimHandler)
+				r.Put("/diagnoses", app.checkRole("receptionist", app.setClaimDiagnosesHandler))
+				r.Post("/submit", app.checkRole("receptionist", app.submitClaimHandler))
+				r.Post("/remittances", app.checkRole("receptionist", app.remitClaimHandler))
+			})
+		})
+
+		r.Route("/referrals", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.checkRole("doctor", app.getReferralsHandler))
+
+			r.Route("/{referralID}", func(r chi.Router) {
+				r.Use(app.referralContextMiddleware)
+
+				r.Get("/", app.getReferralHandler)
+				r.Post("/accept", app.checkRoleName("doctor", app.acceptReferralHandler))
+				r.Post("/decline", app.checkRoleName("doctor", app.declineReferralHandler))
+				r.Post("/appointment", app.checkRole("receptionist", app.bookReferralHandler))
+				r.Post("/close", app.checkRoleName("doctor", app.closeReferralHandler))
+			})
+		})
+
+		r.Route("/theatres", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.checkRole("doctor", app.getTheatresHandler))
+			r.Post("/", app.checkRole("admin", app.createTheatreHandler))
+			r.Get("/list", app.checkRole("doctor", app.getTheatreListHandler))
+		})
+
+		r.Route("/theatre-equipment", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.checkRole("doctor", app.getTheatreEquipmentHandler))
+			r.Post("/", app.checkRole("admin", app.createTheatreEquipmentHandler))
+		})
+
+		r.Route("/theatre-cases", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Post("/", app.checkRole("doctor", app.scheduleSurgicalCaseHandler))
+			r.Post("/conflicts", app.checkRole("doctor", app.checkSurgicalCaseConflictsHandler))
+
+			r.Route("/{caseID}", func(r chi.Router) {
+				r.Use(app.surgicalCaseContextMiddleware)
+
+				r.Get("/", app.checkRole("doctor", app.getSurgicalCaseHandler))
+				r.Put("/", app.checkRole("doctor", app.rescheduleSurgicalCaseHandler))
+				r.Post("/start", app.checkRole("doctor", app.startSurgicalCaseHandler))
+				r.Post("/complete", app.checkRole("doctor", app.completeSurgicalCaseHandler))
+				r.Post("/cancel", app.checkRole("doctor", app.cancelSurgicalCaseHandler))
+			})
+		})
+
+		r.Route("/roster", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/", app.checkRole("doctor", app.getRosterHandler))
+			r.Post("/generate", app.checkRole("admin", app.generateRosterHandler))
+			r.Get("/on-call", app.checkRole("doctor", app.getOnCallHandler))
+
+			r.Route("/templates", func(r chi.Router) {
+				r.Get("/", app.checkRole("doctor", app.getShiftTemplatesHandler))
+				r.Post("/", app.checkRole("admin", app.createShiftTemplateHandler))
+			})
+
+			r.Route("/shifts", func(r chi.Router) {
+				r.Post("/", app.checkRole("admin", app.addRosterShiftHandler))
+
+				r.Route("/{shiftID}", func(r chi.Router) {
+					r.Use(app.rosterShiftContextMiddleware)
+
+					r.Get("/", app.checkRole("doctor", app.getRosterShiftHandler))
+					r.Delete("/", app.checkRole("admin", app.deleteRosterShiftHandler))
+					r.Post("/swaps", app.checkRole("doctor", app.requestShiftSwapHandler))
+				})
+			})
+
+			r.Route("/swaps", func(r chi.Router) {
+				r.Get("/", app.checkRole("doctor", app.getShiftSwapsHandler))
+
+				r.Route("/{swapID}", func(r chi.Router) {
+					r.Use(app.shiftSwapContextMiddleware)
+
+					r.Get("/", app.checkRole("doctor", app.getShiftSwapHandler))
+					r.Post("/accept", app.checkRole("doctor", app.acceptShiftSwapHandler))
+					r.Post("/decline", app.checkRole("doctor", app.declineShiftSwapHandler))
+					r.Post("/cancel", app.checkRole("doctor", app.cancelShiftSwapHandler))
+					r.Post("/approve", app.checkRole("admin", app.approveShiftSwapHandler))
+					r.Post("/reject", app.checkRole("admin", app.rejectShiftSwapHandler))
+				})
+			})
+		})
+
+		r.Route("/blood-bank", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/availability", app.checkRole("doctor", app.getBloodAvailabilityHandler))
+
+			r.Route("/donors", func(r chi.Router) {
+				r.Get("/", app.checkRole("doctor", app.getBloodDonorsHandler))
+				r.Post("/", app.checkRoleName("lab", app.createBloodDonorHandler))
+
+				r.Route("/{donorID}", func(r chi.Router) {
+					r.Use(app.bloodDonorContextMiddleware)
+
+					r.Get("/", app.checkRole("doctor", app.getBloodDonorHandler))
+					r.Put("/deferral", app.checkRoleName("lab", app.deferBloodDonorHandler))
+					r.Post("/donations", app.checkRoleName("lab", app.recordDonationHandler))
+				})
+			})
+
+			r.Route("/units", func(r chi.Router) {
+				r.Get("/", app.checkRole("doctor", app.getBloodUnitsHandler))
+				r.Post("/", app.checkRoleName("lab", app.addBloodUnitHandler))
+
+				r.Route("/{unitID}", func(r chi.Router) {
+					r.Use(app.bloodUnitContextMiddleware)
+
+					r.Get("/", app.checkRole("doctor", app.getBloodUnitHandler))
+					r.Post("/discard", app.checkRoleName("lab", app.discardBloodUnitHandler))
+				})
+			})
+
+			r.Route("/crossmatches", func(r chi.Router) {
+				r.Get("/", app.checkRole("doctor", app.getCrossmatchesHandler))
+
+				r.Route("/{requestID}", func(r chi.Router) {
+					r.Use(app.crossmatchContextMiddleware)
+
+					r.Get("/", app.checkRole("doctor", app.getCrossmatchHandler))
+					r.Post("/tests", app.checkRoleName("lab", app.recordCrossmatchHandler))
+					r.Post("/issues", app.checkRoleName("lab", app.issueBloodUnitHandler))
+					r.Post("/close", app.checkRole("doctor", app.closeCrossmatchHandler))
+				})
+			})
+
+			r.Post("/issues/{issueID}/return", app.checkRoleName("lab", app.returnBloodUnitHandler))
+		})
+
+		r.Route("/er", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/board", app.checkRole("nurse", app.getERBoardHandler))
+			r.Get("/board/stream", app.checkRole("nurse", app.streamERBoardHandler))
+			r.Get("/alerts", app.checkRole("nurse", app.getERAlertsHandler))
+			r.Get("/wait-targets", app.checkRole("nurse", app.getERWaitTargetsHandler))
+			r.Put("/wait-targets/{acuity}", app.checkRole("admin", app.setERWaitTargetHandler))
+
+			r.Route("/visits", func(r chi.Router) {
+				r.Post("/", app.checkRoleName("nurse", app.registerERArrivalHandler))
+
+				r.Route("/{visitID}", func(r chi.Router) {
+					r.Use(app.erVisitContextMiddleware)
+
+					r.Get("/", app.checkRole("nurse", app.getERVisitHandler))
+					r.Put("/", app.checkRoleName("nurse", app.retriageERVisitHandler))
+					r.Post("/start", app.checkRole("nurse", app.startERTreatmentHandler))
+					r.Post("/depart", app.checkRole("nurse", app.departERVisitHandler))
+				})
+			})
+		})
+
+		r.Route("/codes", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
+
+			r.Get("/icd10", app.searchICD10Handler)
+		})
+
+		r.Route("/reports", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware)
 
+			r.Get("/diagnoses/top", app.checkRole("admin", app.topDiagnosesReportHandler))
 		})
 
 		// Public routes
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/MdHasib01/hms_server/internal/mrn"
 	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
@@ -12,23 +16,33 @@ type appointmentKey string
 
 const appointmentCtx appointmentKey = "appointment"
 
+// BookAppointmentPayload is the appointment itself, for a patient known from
+// the route.
+type BookAppointmentPayload struct {
+	DoctorID         uuid.UUID              `json:"doctor_id" validate:"required"`
+	AppointmentTime  time.Time              `json:"appointment_time" validate:"required"`
+	VisitType        store.VisitType        `json:"visit_type" validate:"omitempty,oneof=new_patient follow_up"`
+	ConsultationMode store.ConsultationMode `json:"consultation_mode" validate:"omitempty,oneof=in_person online"`
+}
+
 // CreateAppointmentPayload defines the expected request body
 type CreateAppointmentPayload struct {
-	PatientID       uuid.UUID `json:"patient_id" validate:"required"`
-	DoctorID        uuid.UUID `json:"doctor_id" validate:"required"`
-	AppointmentTime time.Time `json:"appointment_time" validate:"required"`
+	PatientID  uuid.UUID `json:"patient_id" validate:"required_without=PatientMRN"`
+	PatientMRN string    `json:"patient_mrn" validate:"max=40"`
+	BookAppointmentPayload
 }
 
 // CreateAppointmentHandler godoc
 //
 //	@Summary		Create new appointment
-//	@Description	Creates a new appointment
+//	@Description	Creates a new appointment for a patient given by ID or MRN. The doctor's fee in effect for the visit is copied onto the appointment. Staff only.
 //	@Tags			appointment
 //	@Accept			json
 //	@Produce		json
 //	@Param			payload	body		CreateAppointmentPayload	true	"Appointment Details"
 //	@Success		201		{object}	store.Appointment
 //	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/appointments [post]
@@ -45,13 +59,21 @@ func (app *application) CreateAppointmentHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	appointment := &store.Appointment{
-		PatientID:       payload.PatientID,
-		DoctorID:        payload.DoctorID,
-		AppointmentTime: payload.AppointmentTime,
+	if payload.PatientID == uuid.Nil {
+		id, err := app.resolvePatientID(r.Context(), payload.PatientMRN)
+		if err != nil {
+			switch {
+			case errors.Is(err, mrn.ErrCheckDigit), errors.Is(err, store.ErrNotFound):
+				app.badRequestResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		payload.PatientID = id
 	}
 
-	err := app.store.Appointments.Create(r.Context(), appointment)
+	appointment, err := app.bookAppointment(r.Context(), payload.PatientID, payload.BookAppointmentPayload)
 	if err != nil {
 		app.internalServerError(w, r, err)
 		return
@@ -62,14 +84,41 @@ func (app *application) CreateAppointmentHandler(w http.ResponseWriter, r *http.
 	}
 }
 
+// bookAppointment creates a scheduled appointment for the patient, defaulting
+// to an in-person visit of a new patient.
+func (app *application) bookAppointment(ctx context.Context, patientID uuid.UUID, payload BookAppointmentPayload) (*store.Appointment, error) {
+	if payload.VisitType == "" {
+		payload.VisitType = store.VisitTypeNewPatient
+	}
+
+	if payload.ConsultationMode == "" {
+		payload.ConsultationMode = store.ConsultationModeInPerson
+	}
+
+	appointment := &store.Appointment{
+		PatientID:        patientID,
+		DoctorID:         payload.DoctorID,
+		AppointmentTime:  payload.AppointmentTime,
+		VisitType:        payload.VisitType,
+		ConsultationMode: payload.ConsultationMode,
+	}
+
+	if err := app.store.Appointments.Create(ctx, appointment); err != nil {
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
 // GetAllAppointmentsHandler godoc
 //
 //	@Summary		Get all appointments with patient and doctor info
-//	@Description	Retrieves all appointments, showing patient and doctor names with appointment times
+//	@Description	Retrieves all appointments, showing patient and doctor names with appointment times. Staff only.
 //	@Tags			appointment
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{array}		store.Appointment
+//	@Failure		403	{object}	error
 //	@Failure		500	{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/appointments [get]
@@ -86,3 +135,144 @@ func (app *application) GetAllAppointmentsHandler(w http.ResponseWriter, r *http
 		app.internalServerError(w, r, err)
 	}
 }
+
+func (app *application) appointmentContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "appointmentID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		appointment, err := app.store.Appointments.GetByID(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, appointmentCtx, appointment)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getAppointmentFromCtx(r *http.Request) *store.Appointment {
+	appointment, _ := r.Context().Value(appointmentCtx).(*store.Appointment)
+	return appointment
+}
+
+// getPatientAppointmentsHandler godoc
+//
+//	@Summary		Lists a patient's appointments
+//	@Description	Lists the appointments of a patient, latest first. Available to the patient, their guardians and staff.
+//	@Tags			appointment
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Success		200			{array}		store.Appointment
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/appointments [get]
+func (app *application) getPatientAppointmentsHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	appointments, err := app.store.Appointments.GetByPatient(r.Context(), patient.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, appointments); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// bookPatientAppointmentHandler godoc
+//
+//	@Summary		Books an appointment for a patient
+//	@Description	Books an appointment for the patient in the path. Patients book for themselves and guardians for their dependents.
+//	@Tags			appointment
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string					true	"Patient ID or MRN"
+//	@Param			payload		body		BookAppointmentPayload	true	"Appointment"
+//	@Success		201			{object}	store.Appointment
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/appointments [post]
+func (app *application) bookPatientAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	var payload BookAppointmentPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	appointment, err := app.bookAppointment(r.Context(), patient.UserID, payload)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, appointment); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// cancelAppointmentHandler godoc
+//
+//	@Summary		Cancels an appointment
+//	@Description	Cancels a scheduled appointment. Available to the patient, their guardians and staff.
+//	@Tags			appointment
+//	@Produce		json
+//	@Param			appointmentID	path		string	true	"Appointment ID"
+//	@Success		200				{object}	store.Appointment
+//	@Failure		403				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		409				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/appointments/{appointmentID}/cancel [post]
+func (app *application) cancelAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	appointment := getAppointmentFromCtx(r)
+
+	allowed, err := app.canAccessPatient(r, appointment.PatientID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Appointments.Cancel(r.Context(), appointment); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errAppointmentNotScheduled)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, appointment); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
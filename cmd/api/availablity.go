@@ -1,60 +1,246 @@
 package main
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/MdHasib01/hms_server/internal/roster"
 	"github.com/MdHasib01/hms_server/internal/store"
 	"github.com/google/uuid"
 )
 
+// maxConflictDays caps how far ahead one availability check looks.
+const maxConflictDays = 92
+
+var errAvailabilityWindow = errors.New("ends_at must be after starts_from")
+
 type CreateAvailabilityPayload struct {
-	DoctorID     uuid.UUID `json:"doctor_id"`
-	AvailableDay string    `json:"available_day"`
-	StartsFrom   string    `json:"starts_from"`
-	EndsAt       string    `json:"ends_at"`
+	AvailableDay string `json:"available_day" validate:"required,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	StartsFrom   string `json:"starts_from" validate:"required,datetime=15:04"`
+	EndsAt       string `json:"ends_at" validate:"required,datetime=15:04"`
 }
 
 // CreateAvailabilityHandler godoc
 //
 //	@Summary		Creates a new availability entry
-//	@Description	Creates an availability entry for a doctor
+//	@Description	Adds a weekly outpatient slot for a doctor, times in UTC. Only the doctor or an admin may add one.
 //	@Tags			doctor
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		CreateAvailabilityPayload	true	"Availability details"
-//	@Success		201		{object}	store.Availability
-//	@Failure		400		{object}	error
-//	@Failure		500		{object}	error
+//	@Param			doctorID	path		string						true	"Doctor ID"
+//	@Param			payload		body		CreateAvailabilityPayload	true	"Availability details"
+//	@Success		201			{object}	store.Availability
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
 //	@Security		ApiKeyAuth
-//	@Router			/doctors/availability [post]
+//	@Router			/doctors/{doctorID}/availability [post]
 func (app *application) CreateAvailablityHandler(w http.ResponseWriter, r *http.Request) {
-	var payload CreateAvailabilityPayload
+	doctor := getDoctorFromCtx(r)
+	ctx := r.Context()
 
-	// Step 1: Read and validate input
+	allowed, err := app.canManageDoctor(r, doctor)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload CreateAvailabilityPayload
 	if err := readJSON(w, r, &payload); err != nil {
 		app.badRequestResponse(w, r, err)
 		return
 	}
 
+	payload.AvailableDay = strings.ToLower(strings.TrimSpace(payload.AvailableDay))
+
 	if err := Validate.Struct(payload); err != nil {
 		app.badRequestResponse(w, r, err)
 		return
 	}
-	// 🛑 Step 2: Validate DoctorID is a real UUID
 
-	ctx := r.Context()
+	// outpatient slots sit within a day; only shifts run overnight
+	if payload.EndsAt <= payload.StartsFrom {
+		app.badRequestResponse(w, r, errAvailabilityWindow)
+		return
+	}
 
-	// Step 3: Create Availability entry
 	availability := &store.Availability{
-		DoctorID:     payload.DoctorID,
+		DoctorID:     doctor.UserID,
 		AvailableDay: payload.AvailableDay,
 		StartsFrom:   payload.StartsFrom,
 		EndsAt:       payload.EndsAt,
 	}
 
-	err := app.store.Availability.Create(ctx, availability)
+	if err := app.store.Availability.Create(ctx, availability); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, availability); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// AvailabilitySlot is a weekly outpatient slot with the date it next falls
+// on and the fees in effect for a visit that day.
+type AvailabilitySlot struct {
+	store.Availability
+	NextDate string            `json:"next_date"`
+	Fees     []store.DoctorFee `json:"fees"`
+}
+
+// getAvailabilityHandler godoc
+//
+//	@Summary		Lists a doctor's weekly outpatient slots
+//	@Description	Lists the weekly slots, each with the date it next falls on (UTC, today included) and the fees for every visit type and consultation mode in effect on that date.
+//	@Tags			doctor
+//	@Produce		json
+//	@Param			doctorID	path		string	true	"Doctor ID"
+//	@Success		200			{array}		AvailabilitySlot
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/doctors/{doctorID}/availability [get]
+func (app *application) getAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	doctor := getDoctorFromCtx(r)
+	ctx := r.Context()
+
+	slots, err := app.store.Availability.GetByDoctor(ctx, doctor.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	suggestions := make([]AvailabilitySlot, 0, len(slots))
+	for _, slot := range slots {
+		next := today
+		for i := 0; i < 7 && strings.ToLower(next.Weekday().String()) != strings.ToLower(slot.AvailableDay); i++ {
+			next = next.AddDate(0, 0, 1)
+		}
+
+		fees := []store.DoctorFee{}
+		for _, visitType := range []store.VisitType{store.VisitTypeNewPatient, store.VisitTypeFollowUp} {
+			for _, mode := range []store.ConsultationMode{store.ConsultationModeInPerson, store.ConsultationModeOnline} {
+				fee, err := app.store.Fees.GetEffective(ctx, doctor.UserID, visitType, mode, next)
+				if err != nil {
+					if errors.Is(err, store.ErrNotFound) {
+						continue
+					}
+					app.internalServerError(w, r, err)
+					return
+				}
+				fees = append(fees, *fee)
+			}
+		}
+
+		suggestions = append(suggestions, AvailabilitySlot{
+			Availability: slot,
+			NextDate:     next.Format(store.DateLayout),
+			Fees:         fees,
+		})
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, suggestions); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// AvailabilityConflict is an outpatient slot on a date the doctor is
+// rostered to work a shift overlapping it.
+type AvailabilityConflict struct {
+	Availability store.Availability `json:"availability"`
+	Date         string             `json:"date"`
+	StartsAt     time.Time          `json:"starts_at"`
+	EndsAt       time.Time          `json:"ends_at"`
+	Shift        store.RosterShift  `json:"shift"`
+}
+
+// getAvailabilityConflictsHandler godoc
+//
+//	@Summary		Checks a doctor's outpatient slots against their roster
+//	@Description	Lists each date from from to to (UTC, the next four weeks by default, at most 92 days) on which one of the doctor's weekly slots overlaps a rostered shift, night duty running over from the day before included.
+//	@Tags			doctor
+//	@Produce		json
+//	@Param			doctorID	path		string	true	"Doctor ID"
+//	@Param			from		query		string	false	"First day, YYYY-MM-DD"
+//	@Param			to			query		string	false	"Last day, YYYY-MM-DD"
+//	@Success		200			{array}		AvailabilityConflict
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/doctors/{doctorID}/availability/conflicts [get]
+func (app *application) getAvailabilityConflictsHandler(w http.ResponseWriter, r *http.Request) {
+	doctor := getDoctorFromCtx(r)
+	ctx := r.Context()
+
+	from, to, err := parseRosterDays(r, 28)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if to.Sub(from) > maxConflictDays*24*time.Hour {
+		app.badRequestResponse(w, r, errRosterRangeTooLong)
+		return
+	}
+
+	slots, err := app.store.Availability.GetByDoctor(ctx, doctor.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// a slot ends on its own day, so shifts overlapping the range are enough
+	shifts, err := app.store.Roster.GetShifts(ctx, store.RosterQuery{
+		From:    from,
+		To:      to,
+		UserIDs: []uuid.UUID{doctor.UserID},
+	})
 	if err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
+
+	conflicts := []AvailabilityConflict{}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		weekday := strings.ToLower(day.Weekday().String())
+
+		for _, slot := range slots {
+			if strings.ToLower(slot.AvailableDay) != weekday {
+				continue
+			}
+
+			window, err := roster.Window(day, slot.StartsFrom, slot.EndsAt)
+			if err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+
+			for _, shift := range shifts {
+				if shift.StartsAt.Before(window.End) && shift.EndsAt.After(window.Start) {
+					conflicts = append(conflicts, AvailabilityConflict{
+						Availability: slot,
+						Date:         day.Format(store.DateLayout),
+						StartsAt:     window.Start,
+						EndsAt:       window.End,
+						Shift:        shift,
+					})
+				}
+			}
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, conflicts); err != nil {
+		app.internalServerError(w, r, err)
+	}
 }
@@ -0,0 +1,975 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/blob"
+	"github.com/MdHasib01/hms_server/internal/payments"
+	"github.com/MdHasib01/hms_server/internal/pdf"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type invoiceKey string
+
+const invoiceCtx invoiceKey = "invoice"
+
+type paymentKey string
+
+const paymentCtx paymentKey = "payment"
+
+var (
+	errInvoiceNotDraft     = errors.New("invoice has been issued and can no longer be changed")
+	errInvoiceNotIssued    = errors.New("invoice is not issued")
+	errInvoiceHoldsMoney   = errors.New("invoice has payments that were not refunded")
+	errPaymentNotSucceeded = errors.New("payment did not succeed")
+	errAlreadyCharged      = errors.New("already charged")
+	errDiscountTooLarge    = errors.New("discount exceeds the price")
+	errTokenRequired       = errors.New("token is required for card and wallet payments")
+	errNoReceipt           = errors.New("payment has no receipt")
+)
+
+type SetPricePayload struct {
+	Description string `json:"description" validate:"required,max=255"`
+	UnitPrice   int64  `json:"unit_price" validate:"gte=0"`
+	// TaxRate is in basis points: 1500 is 15%
+	TaxRate int `json:"tax_rate" validate:"gte=0,lte=10000"`
+}
+
+// setPriceHandler godoc
+//
+//	@Summary		Sets a price
+//	@Description	Creates or replaces a price list entry. Amounts are in minor units of the billing currency. Lab tests are priced by test code and drugs by drug ID; consultations are priced by the doctor's fee instead.
+//	@Tags			billing
+//	@Accept			json
+//	@Produce		json
+//	@Param			kind	path		string			true	"procedure, lab_test or drug"
+//	@Param			code	path		string			true	"Code"
+//	@Param			payload	body		SetPricePayload	true	"Price"
+//	@Success		200		{object}	store.Price
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/billing/prices/{kind}/{code} [put]
+func (app *application) setPriceHandler(w http.ResponseWriter, r *http.Request) {
+	kind := store.ChargeKind(chi.URLParam(r, "kind"))
+	if kind != store.ChargeProcedure && kind != store.ChargeLabTest && kind != store.ChargeDrug {
+		app.badRequestResponse(w, r, fmt.Errorf("unknown price kind %q", kind))
+		return
+	}
+
+	code := strings.TrimSpace(chi.URLParam(r, "code"))
+	switch kind {
+	case store.ChargeLabTest:
+		code = strings.ToUpper(code)
+	case store.ChargeDrug:
+		id, err := uuid.Parse(code)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		code = id.String()
+	}
+
+	if code == "" || len(code) > 100 {
+		app.badRequestResponse(w, r, errors.New("invalid code"))
+		return
+	}
+
+	var payload SetPricePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	price := &store.Price{
+		Kind:        kind,
+		Code:        code,
+		Description: strings.TrimSpace(payload.Description),
+		UnitPrice:   payload.UnitPrice,
+		TaxRate:     payload.TaxRate,
+	}
+
+	if err := app.store.Billing.SetPrice(r.Context(), price); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, price); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPricesHandler godoc
+//
+//	@Summary		Lists the price list
+//	@Description	Lists the price list, optionally of one kind
+//	@Tags			billing
+//	@Produce		json
+//	@Param			kind	query		string	false	"procedure, lab_test or drug"
+//	@Success		200		{array}		store.Price
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/billing/prices [get]
+func (app *application) getPricesHandler(w http.ResponseWriter, r *http.Request) {
+	prices, err := app.store.Billing.GetPrices(r.Context(), store.ChargeKind(r.URL.Query().Get("kind")))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, prices); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type CreateChargePayload struct {
+	Kind store.ChargeKind `json:"kind" validate:"required,oneof=procedure lab_test drug other"`
+	// Code names the price list entry; not used for other charges
+	Code     string `json:"code" validate:"required_unless=Kind other,max=100"`
+	Quantity int    `json:"quantity" validate:"omitempty,gt=0,lte=1000"`
+	Discount int64  `json:"discount" validate:"gte=0"`
+	// Description, UnitPrice and TaxRate are only used for other charges
+	Description string `json:"description" validate:"required_if=Kind other,max=255"`
+	UnitPrice   int64  `json:"unit_price" validate:"gte=0"`
+	TaxRate     int    `json:"tax_rate" validate:"gte=0,lte=10000"`
+}
+
+// createChargeHandler godoc
+//
+//	@Summary		Charges a patient
+//	@Description	Adds an item to the patient's draft invoice, opening one if needed. Priced items are charged at the price list; other charges carry their own description and price. Staff only.
+//	@Tags			billing
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string				true	"Patient ID or MRN"
+//	@Param			payload		body		CreateChargePayload	true	"Charge"
+//	@Success		201			{object}	store.InvoiceItem
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/charges [post]
+func (app *application) createChargeHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+	ctx := r.Context()
+
+	var payload CreateChargePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.Kind == store.ChargeLabTest {
+		payload.Code = strings.ToUpper(strings.TrimSpace(payload.Code))
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.Quantity == 0 {
+		payload.Quantity = 1
+	}
+
+	item := &store.InvoiceItem{
+		Kind:        payload.Kind,
+		Code:        strings.TrimSpace(payload.Code),
+		Description: strings.TrimSpace(payload.Description),
+		Quantity:    payload.Quantity,
+		UnitPrice:   payload.UnitPrice,
+		Discount:    payload.Discount,
+		TaxRate:     payload.TaxRate,
+		CreatedBy:   &user.ID,
+	}
+
+	if item.Kind != store.ChargeOther {
+		price, err := app.store.Billing.GetPrice(ctx, item.Kind, item.Code)
+		if err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.badRequestResponse(w, r, fmt.Errorf("%s %q is not on the price list", item.Kind, item.Code))
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		item.Description = price.Description
+		item.UnitPrice = price.UnitPrice
+		item.TaxRate = price.TaxRate
+	}
+
+	if item.Discount > int64(item.Quantity)*item.UnitPrice {
+		app.badRequestResponse(w, r, errDiscountTooLarge)
+		return
+	}
+
+	if err := app.store.Billing.Charge(ctx, patient.UserID, app.config.billing.currency, item); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, item); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPatientInvoicesHandler godoc
+//
+//	@Summary		Lists a patient's invoices
+//	@Description	Lists invoices with their totals, newest first. Patients and their guardians do not see the draft.
+//	@Tags			billing
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Success		200			{array}		store.Invoice
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/invoices [get]
+func (app *application) getPatientInvoicesHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+
+	invoices, err := app.store.Billing.GetByPatient(r.Context(), patient.UserID, !user.ActsFor(patient.UserID))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, invoices); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPatientBalanceHandler godoc
+//
+//	@Summary		Fetches a patient's outstanding balance
+//	@Description	Returns what the patient owes across issued invoices, with the invoices that are not settled
+//	@Tags			billing
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Success		200			{object}	store.PatientBalance
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/balance [get]
+func (app *application) getPatientBalanceHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	balance, err := app.store.Billing.GetBalance(r.Context(), patient.UserID, app.config.billing.currency)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, balance); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getInvoiceHandler godoc
+//
+//	@Summary		Fetches an invoice
+//	@Description	Fetches an invoice with its items and payments
+//	@Tags			billing
+//	@Produce		json
+//	@Param			invoiceID	path		string	true	"Invoice ID"
+//	@Success		200			{object}	store.Invoice
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/invoices/{invoiceID} [get]
+func (app *application) getInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	invoice := getInvoiceFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, invoice); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type SetDiscountPayload struct {
+	Discount int64 `json:"discount" validate:"gte=0"`
+}
+
+// setInvoiceItemDiscountHandler godoc
+//
+//	@Summary		Discounts an invoice item
+//	@Description	Sets the discount of an item of a draft invoice, in minor units. Tax is charged on the discounted price.
+//	@Tags			billing
+//	@Accept			json
+//	@Produce		json
+//	@Param			invoiceID	path		string				true	"Invoice ID"
+//	@Param			itemID		path		string				true	"Item ID"
+//	@Param			payload		body		SetDiscountPayload	true	"Discount"
+//	@Success		200			{object}	store.InvoiceItem
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/invoices/{invoiceID}/items/{itemID} [put]
+func (app *application) setInvoiceItemDiscountHandler(w http.ResponseWriter, r *http.Request) {
+	invoice := getInvoiceFromCtx(r)
+
+	itemID, err := uuid.Parse(chi.URLParam(r, "itemID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload SetDiscountPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var item *store.InvoiceItem
+	for i := range invoice.Items {
+		if invoice.Items[i].ID == itemID {
+			item = &invoice.Items[i]
+		}
+	}
+
+	if item == nil {
+		app.notFoundResponse(w, r, store.ErrNotFound)
+		return
+	}
+
+	if payload.Discount > int64(item.Quantity)*item.UnitPrice {
+		app.badRequestResponse(w, r, errDiscountTooLarge)
+		return
+	}
+
+	item.Discount = payload.Discount
+
+	if err := app.store.Billing.SetDiscount(r.Context(), item); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errInvoiceNotDraft)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, item); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deleteInvoiceItemHandler godoc
+//
+//	@Summary		Removes an invoice item
+//	@Description	Removes an item from a draft invoice
+//	@Tags			billing
+//	@Param			invoiceID	path	string	true	"Invoice ID"
+//	@Param			itemID		path	string	true	"Item ID"
+//	@Success		204
+//	@Failure		400	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/invoices/{invoiceID}/items/{itemID} [delete]
+func (app *application) deleteInvoiceItemHandler(w http.ResponseWriter, r *http.Request) {
+	invoice := getInvoiceFromCtx(r)
+
+	itemID, err := uuid.Parse(chi.URLParam(r, "itemID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Billing.DeleteItem(r.Context(), invoice.ID, itemID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errInvoiceNotDraft)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// issueInvoiceHandler godoc
+//
+//	@Summary		Issues an invoice
+//	@Description	Numbers the draft invoice and makes it payable. Its items can no longer change; later charges open a new draft.
+//	@Tags			billing
+//	@Produce		json
+//	@Param			invoiceID	path		string	true	"Invoice ID"
+//	@Success		200			{object}	store.Invoice
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/invoices/{invoiceID}/issue [post]
+func (app *application) issueInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	invoice := getInvoiceFromCtx(r)
+
+	if len(invoice.Items) == 0 {
+		app.conflictResponse(w, r, errors.New("invoice has no items"))
+		return
+	}
+
+	if err := app.store.Billing.Issue(r.Context(), invoice, user.ID); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errInvoiceNotDraft)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, invoice); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type VoidInvoicePayload struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// voidInvoiceHandler godoc
+//
+//	@Summary		Voids an invoice
+//	@Description	Cancels an issued invoice. Payments must be refunded first. Admin only.
+//	@Tags			billing
+//	@Accept			json
+//	@Produce		json
+//	@Param			invoiceID	path		string				true	"Invoice ID"
+//	@Param			payload		body		VoidInvoicePayload	true	"Reason"
+//	@Success		200			{object}	store.Invoice
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/invoices/{invoiceID}/void [post]
+func (app *application) voidInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	invoice := getInvoiceFromCtx(r)
+
+	var payload VoidInvoicePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Billing.Void(r.Context(), invoice, strings.TrimSpace(payload.Reason)); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errInvoiceNotIssued)
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errInvoiceHoldsMoney)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, invoice); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type CreatePaymentPayload struct {
+	Method store.PaymentMethod `json:"method" validate:"required,oneof=cash card bank_transfer mobile_wallet"`
+	Amount int64               `json:"amount" validate:"required,gt=0"`
+	// Token is the card or wallet token from the gateway's client library
+	Token string `json:"token" validate:"max=255"`
+}
+
+// createPaymentHandler godoc
+//
+//	@Summary		Pays an invoice
+//	@Description	Records a full or partial payment of an issued invoice. Card and wallet payments are charged through the payment gateway; cash and bank transfers are recorded by staff. Patients and their guardians can pay by card or wallet. A receipt is rendered for successful payments.
+//	@Tags			billing
+//	@Accept			json
+//	@Produce		json
+//	@Param			invoiceID	path		string					true	"Invoice ID"
+//	@Param			payload		body		CreatePaymentPayload	true	"Payment"
+//	@Success		201			{object}	store.Payment
+//	@Failure		400			{object}	error
+//	@Failure		402			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/invoices/{invoiceID}/payments [post]
+func (app *application) createPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	invoice := getInvoiceFromCtx(r)
+	ctx := r.Context()
+
+	var payload CreatePaymentPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if !payload.Method.ThroughGateway() {
+		staff, err := app.checkRolePrecedence(ctx, user, "receptionist")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !staff {
+			app.forbiddenResponse(w, r)
+			return
+		}
+	} else if strings.TrimSpace(payload.Token) == "" {
+		app.badRequestResponse(w, r, errTokenRequired)
+		return
+	}
+
+	payment := &store.Payment{
+		InvoiceID: invoice.ID,
+		Kind:      store.PaymentKindPayment,
+		Method:    payload.Method,
+		Amount:    payload.Amount,
+		Status:    store.PaymentSucceeded,
+		CreatedBy: &user.ID,
+	}
+
+	if payment.Method.ThroughGateway() {
+		payment.Status = store.PaymentPending
+	}
+
+	if err := app.store.Billing.CreatePayment(ctx, payment); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errInvoiceNotIssued)
+		case store.ErrOverpayment:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if payment.Status == store.PaymentPending {
+		ref, err := app.payments.Charge(ctx, payments.Charge{
+			Amount:    payment.Amount,
+			Currency:  payment.Currency,
+			Token:     payload.Token,
+			Reference: payment.ID.String(),
+		})
+		if !app.settlePayment(w, r, payment, ref, err) {
+			return
+		}
+	}
+
+	app.storeReceipt(ctx, payment)
+
+	if err := app.jsonResponse(w, http.StatusCreated, payment); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type CreateRefundPayload struct {
+	Amount int64  `json:"amount" validate:"required,gt=0"`
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// createRefundHandler godoc
+//
+//	@Summary		Refunds a payment
+//	@Description	Refunds all or part of a successful payment by the method it was paid with. Card and wallet refunds go through the payment gateway. Admin only.
+//	@Tags			billing
+//	@Accept			json
+//	@Produce		json
+//	@Param			paymentID	path		string				true	"Payment ID"
+//	@Param			payload		body		CreateRefundPayload	true	"Refund"
+//	@Success		201			{object}	store.Payment
+//	@Failure		400			{object}	error
+//	@Failure		402			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/payments/{paymentID}/refunds [post]
+func (app *application) createRefundHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	original := getPaymentFromCtx(r)
+	ctx := r.Context()
+
+	if original.Kind != store.PaymentKindPayment {
+		app.badRequestResponse(w, r, errors.New("only payments can be refunded"))
+		return
+	}
+
+	var payload CreateRefundPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	refund := &store.Payment{
+		InvoiceID: original.InvoiceID,
+		Kind:      store.PaymentKindRefund,
+		Amount:    payload.Amount,
+		Status:    store.PaymentSucceeded,
+		RefundOf:  &original.ID,
+		Reason:    strings.TrimSpace(payload.Reason),
+		CreatedBy: &user.ID,
+	}
+
+	if original.Method.ThroughGateway() {
+		refund.Status = store.PaymentPending
+	}
+
+	if err := app.store.Billing.CreatePayment(ctx, refund); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errPaymentNotSucceeded)
+		case store.ErrOverpayment:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if refund.Status == store.PaymentPending {
+		var chargeRef string
+		if original.GatewayRef != nil {
+			chargeRef = *original.GatewayRef
+		}
+
+		ref, err := app.payments.Refund(ctx, chargeRef, refund.Amount, refund.Currency)
+		if !app.settlePayment(w, r, refund, ref, err) {
+			return
+		}
+	}
+
+	app.storeReceipt(ctx, refund)
+
+	if err := app.jsonResponse(w, http.StatusCreated, refund); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// settlePayment records the gateway's answer for a pending payment. When it
+// failed the error response is written and false is returned.
+func (app *application) settlePayment(w http.ResponseWriter, r *http.Request, payment *store.Payment, ref string, gatewayErr error) bool {
+	if gatewayErr == nil {
+		if err := app.store.Billing.SettlePayment(r.Context(), payment, store.PaymentSucceeded, &ref, nil); err != nil {
+			// the money moved; the payment stays pending for reconciliation
+			app.logger.Errorw("error recording gateway payment", "payment", payment.ID, "gateway_ref", ref, "error", err.Error())
+			app.internalServerError(w, r, err)
+			return false
+		}
+		return true
+	}
+
+	failure := gatewayErr.Error()
+	if err := app.store.Billing.SettlePayment(r.Context(), payment, store.PaymentFailed, nil, &failure); err != nil {
+		app.logger.Errorw("error recording failed payment", "payment", payment.ID, "error", err.Error())
+	}
+
+	if errors.Is(gatewayErr, payments.ErrDeclined) {
+		app.paymentDeclinedResponse(w, r, gatewayErr)
+	} else {
+		app.internalServerError(w, r, gatewayErr)
+	}
+	return false
+}
+
+// storeReceipt renders the receipt of a successful payment or refund. The
+// payment stands if this fails; the receipt is rendered again when it is
+// downloaded.
+func (app *application) storeReceipt(ctx context.Context, payment *store.Payment) {
+	if payment.Status != store.PaymentSucceeded {
+		return
+	}
+
+	if err := app.renderReceipt(ctx, payment); err != nil {
+		app.logger.Errorw("error rendering receipt", "payment", payment.ID, "error", err.Error())
+	}
+}
+
+func (app *application) renderReceipt(ctx context.Context, payment *store.Payment) error {
+	invoice, err := app.store.Billing.GetInvoice(ctx, payment.InvoiceID)
+	if err != nil {
+		return err
+	}
+
+	patient, err := app.documentPatient(ctx, payment.PatientID)
+	if err != nil {
+		return err
+	}
+
+	doc := pdf.Receipt{
+		ID:           payment.ID.String(),
+		Refund:       payment.Kind == store.PaymentKindRefund,
+		PaidAt:       payment.CreatedAt.UTC().Truncate(time.Second),
+		Method:       strings.ReplaceAll(string(payment.Method), "_", " "),
+		Amount:       payment.Amount,
+		Currency:     payment.Currency,
+		InvoiceTotal: invoice.Total,
+		Balance:      invoice.Balance,
+		PatientName:  strings.TrimSpace(patient.FirstName + " " + patient.LastName),
+		PatientMRN:   patient.MRN,
+	}
+
+	if invoice.Number != nil {
+		doc.InvoiceNumber = *invoice.Number
+	}
+
+	if payment.GatewayRef != nil {
+		doc.GatewayRef = *payment.GatewayRef
+	}
+
+	document, err := pdf.RenderReceipt(app.config.letterhead, doc)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%sreceipts/%s.pdf", blob.PrivatePrefix, payment.ID)
+
+	if err := app.blob.Put(ctx, key, bytes.NewReader(document)); err != nil {
+		return err
+	}
+
+	return app.store.Billing.SetReceipt(ctx, payment, key)
+}
+
+// downloadReceiptHandler godoc
+//
+//	@Summary		Downloads a receipt
+//	@Description	Downloads the PDF receipt of a successful payment or refund
+//	@Tags			billing
+//	@Produce		application/pdf
+//	@Param			paymentID	path		string	true	"Payment ID"
+//	@Success		200			{file}		file
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/payments/{paymentID}/receipt [get]
+func (app *application) downloadReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	payment := getPaymentFromCtx(r)
+
+	if payment.Status != store.PaymentSucceeded {
+		app.conflictResponse(w, r, errNoReceipt)
+		return
+	}
+
+	if payment.ReceiptKey == nil {
+		if err := app.renderReceipt(r.Context(), payment); err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	fileName := fmt.Sprintf("receipt-%s.pdf", payment.ID)
+	app.writeAttachment(w, r, *payment.ReceiptKey, fileName, "application/pdf")
+}
+
+// chargePatient adds an item generated by the clinical workflow to the
+// patient's draft invoice. Billing never fails the clinical action: errors
+// are logged, and a source that was already charged is left alone.
+func (app *application) chargePatient(ctx context.Context, patientID uuid.UUID, item *store.InvoiceItem) {
+	err := app.store.Billing.Charge(ctx, patientID, app.config.billing.currency, item)
+	if err != nil && err != store.ErrConflict {
+		app.logger.Errorw("error charging patient", "patient", patientID, "kind", item.Kind, "code", item.Code, "error", err.Error())
+	}
+}
+
+// chargeFromPriceList charges a priced service. Services missing from the
+// price list are logged and not charged.
+func (app *application) chargeFromPriceList(ctx context.Context, patientID uuid.UUID, kind store.ChargeKind, code string, quantity int, sourceKey string, createdBy uuid.UUID) {
+	price, err := app.store.Billing.GetPrice(ctx, kind, code)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.logger.Warnw("no price for charge", "kind", kind, "code", code, "source", sourceKey)
+		default:
+			app.logger.Errorw("error fetching price", "kind", kind, "code", code, "error", err.Error())
+		}
+		return
+	}
+
+	app.chargePatient(ctx, patientID, &store.InvoiceItem{
+		Kind:        kind,
+		Code:        code,
+		Description: price.Description,
+		Quantity:    quantity,
+		UnitPrice:   price.UnitPrice,
+		TaxRate:     price.TaxRate,
+		SourceKey:   &sourceKey,
+		CreatedBy:   &createdBy,
+	})
+}
+
+// chargeConsultation charges the fee booked with a completed appointment.
+func (app *application) chargeConsultation(ctx context.Context, appointment *store.Appointment, completedBy uuid.UUID) {
+	if appointment.FeeAmount == nil || appointment.FeeCurrency == nil {
+		return
+	}
+
+	if *appointment.FeeCurrency != app.config.billing.currency {
+		app.logger.Warnw("consultation fee not in billing currency", "appointment", appointment.ID, "currency", *appointment.FeeCurrency)
+		return
+	}
+
+	sourceKey := fmt.Sprintf("appointment:%s", appointment.ID)
+
+	app.chargePatient(ctx, appointment.PatientID, &store.InvoiceItem{
+		Kind:        store.ChargeConsultation,
+		Code:        string(appointment.VisitType),
+		Description: "Consultation",
+		Quantity:    1,
+		UnitPrice:   *appointment.FeeAmount,
+		TaxRate:     app.config.billing.consultationTaxRate,
+		SourceKey:   &sourceKey,
+		CreatedBy:   &completedBy,
+	})
+}
+
+// invoiceContextMiddleware loads the invoice named by {invoiceID}. Drafts
+// are hidden from the patient and their guardians.
+func (app *application) invoiceContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "invoiceID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		invoice, err := app.store.Billing.GetInvoice(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		allowed, err := app.canAccessPatient(r, invoice.PatientID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		if getUserFromContext(r).ActsFor(invoice.PatientID) && invoice.Status == store.InvoiceDraft {
+			app.notFoundResponse(w, r, store.ErrNotFound)
+			return
+		}
+
+		ctx = context.WithValue(ctx, invoiceCtx, invoice)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getInvoiceFromCtx(r *http.Request) *store.Invoice {
+	invoice, _ := r.Context().Value(invoiceCtx).(*store.Invoice)
+	return invoice
+}
+
+// paymentContextMiddleware loads the payment named by {paymentID}, visible
+// to the patient, their guardians and staff.
+func (app *application) paymentContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "paymentID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		payment, err := app.store.Billing.GetPayment(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		allowed, err := app.canAccessPatient(r, payment.PatientID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		ctx = context.WithValue(ctx, paymentCtx, payment)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getPaymentFromCtx(r *http.Request) *store.Payment {
+	payment, _ := r.Context().Value(paymentCtx).(*store.Payment)
+	return payment
+}
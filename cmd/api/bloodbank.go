@@ -0,0 +1,1235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/bloodbank"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type bloodDonorKey string
+
+const bloodDonorCtx bloodDonorKey = "bloodDonor"
+
+type bloodUnitKey string
+
+const bloodUnitCtx bloodUnitKey = "bloodUnit"
+
+type crossmatchKey string
+
+const crossmatchCtx crossmatchKey = "crossmatch"
+
+var (
+	errBloodGroupUnknown     = errors.New("the patient's blood group is not recorded")
+	errBloodGroupInvalid     = errors.New("blood group must be one of A+ A- B+ B- AB+ AB- O+ O-")
+	errRecipientRequired     = errors.New("blood_group or patient_id is required")
+	errComponentRequired     = errors.New("component is required with compatible_with")
+	errUnitExpiry            = errors.New("expires_on must be after the day the unit was collected")
+	errDonationInFuture      = errors.New("donated_at must not be in the future")
+	errDonorAge              = fmt.Errorf("donors must be %d to %d years old", bloodbank.MinDonorAge, bloodbank.MaxDonorAge)
+	errCrossmatchClosed      = errors.New("crossmatch request is closed")
+	errCrossmatchHeld        = errors.New("unit was already crossmatched for the request, or the request already holds all its units")
+	errUnitNotReserved       = errors.New("unit is not reserved for the request or has expired")
+	errUnitNotInStock        = errors.New("unit has been issued or discarded")
+	errUnitReturned          = errors.New("unit was already returned")
+	errReturnReasonRequired  = errors.New("reason is required for a unit that cannot be reused")
+	errDeferralReasonMissing = errors.New("reason is required with deferred_until")
+)
+
+// parseBloodGroup reads a blood group from a query string, where an
+// unencoded + arrives as a space, accepting a typographic minus too.
+func parseBloodGroup(v string) (store.BloodGroup, error) {
+	v = strings.ReplaceAll(v, " ", "+")
+	v = strings.ReplaceAll(v, "−", "-")
+	v = strings.ToUpper(strings.TrimSpace(v))
+
+	for _, group := range bloodbank.Groups {
+		if v == group {
+			return store.BloodGroup(v), nil
+		}
+	}
+
+	return "", errBloodGroupInvalid
+}
+
+// donorGroups returns the groups a recipient may receive the component from.
+func donorGroups(component store.BloodComponent, recipient store.BloodGroup) []store.BloodGroup {
+	groups := []store.BloodGroup{}
+	for _, group := range bloodbank.DonorGroups(bloodbank.Component(component), string(recipient)) {
+		groups = append(groups, store.BloodGroup(group))
+	}
+	return groups
+}
+
+type CreateBloodDonorPayload struct {
+	Name        string           `json:"name" validate:"required,max=255"`
+	BloodGroup  store.BloodGroup `json:"blood_group" validate:"required,oneof=A+ A- B+ B- AB+ AB- O+ O-"`
+	DateOfBirth string           `json:"date_of_birth" validate:"required,datetime=2006-01-02"`
+	Phone       string           `json:"phone" validate:"max=30"`
+	Email       string           `json:"email" validate:"omitempty,email,max=255"`
+	Notes       string           `json:"notes" validate:"max=2000"`
+}
+
+// createBloodDonorHandler godoc
+//
+//	@Summary	Registers a blood donor
+//	@Tags		blood-bank
+//	@Accept		json
+//	@Produce	json
+//	@Param		payload	body		CreateBloodDonorPayload	true	"Donor"
+//	@Success	201		{object}	store.BloodDonor
+//	@Failure	400		{object}	error
+//	@Failure	403		{object}	error
+//	@Failure	500		{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/blood-bank/donors [post]
+func (app *application) createBloodDonorHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	var payload CreateBloodDonorPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Name = strings.TrimSpace(payload.Name)
+	payload.Phone = strings.TrimSpace(payload.Phone)
+	payload.Email = strings.TrimSpace(payload.Email)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	donor := &store.BloodDonor{
+		Name:        payload.Name,
+		BloodGroup:  payload.BloodGroup,
+		DateOfBirth: payload.DateOfBirth,
+		Phone:       payload.Phone,
+		Email:       payload.Email,
+		Notes:       strings.TrimSpace(payload.Notes),
+		CreatedBy:   &user.ID,
+	}
+
+	if err := app.store.BloodBank.CreateDonor(r.Context(), donor); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, donor); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getBloodDonorsHandler godoc
+//
+//	@Summary		Lists blood donors
+//	@Description	Lists donors by name, optionally those whose name starts with search or whose phone number is search. Staff only.
+//	@Tags			blood-bank
+//	@Produce		json
+//	@Param			search	query		string	false	"Name prefix or phone number"
+//	@Success		200		{array}		store.BloodDonor
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/blood-bank/donors [get]
+func (app *application) getBloodDonorsHandler(w http.ResponseWriter, r *http.Request) {
+	donors, err := app.store.BloodBank.GetDonors(r.Context(), strings.TrimSpace(r.URL.Query().Get("search")))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, donors); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getBloodDonorHandler godoc
+//
+//	@Summary	Fetches a blood donor with their donations
+//	@Tags		blood-bank
+//	@Produce	json
+//	@Param		donorID	path		string	true	"Donor ID"
+//	@Success	200		{object}	store.BloodDonor
+//	@Failure	400		{object}	error
+//	@Failure	403		{object}	error
+//	@Failure	404		{object}	error
+//	@Failure	500		{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/blood-bank/donors/{donorID} [get]
+func (app *application) getBloodDonorHandler(w http.ResponseWriter, r *http.Request) {
+	if err := app.jsonResponse(w, http.StatusOK, getBloodDonorFromCtx(r)); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type DeferBloodDonorPayload struct {
+	// DeferredUntil lifts the deferral when null
+	DeferredUntil *string `json:"deferred_until" validate:"omitempty,datetime=2006-01-02"`
+	Reason        string  `json:"reason" validate:"max=1000"`
+}
+
+// deferBloodDonorHandler godoc
+//
+//	@Summary		Defers a blood donor
+//	@Description	Keeps a donor from donating before a day, or lifts their deferral when deferred_until is null. Lab only.
+//	@Tags			blood-bank
+//	@Accept			json
+//	@Produce		json
+//	@Param			donorID	path		string					true	"Donor ID"
+//	@Param			payload	body		DeferBloodDonorPayload	true	"Deferral"
+//	@Success		200		{object}	store.BloodDonor
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/blood-bank/donors/{donorID}/deferral [put]
+func (app *application) deferBloodDonorHandler(w http.ResponseWriter, r *http.Request) {
+	donor := getBloodDonorFromCtx(r)
+
+	var payload DeferBloodDonorPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Reason = strings.TrimSpace(payload.Reason)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.DeferredUntil != nil && payload.Reason == "" {
+		app.badRequestResponse(w, r, errDeferralReasonMissing)
+		return
+	}
+
+	donor.DeferredUntil = payload.DeferredUntil
+	donor.DeferralReason = payload.Reason
+	if donor.DeferredUntil == nil {
+		donor.DeferralReason = ""
+	}
+
+	if err := app.store.BloodBank.DeferDonor(r.Context(), donor); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, donor); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type DonatedUnitPayload struct {
+	UnitNumber string               `json:"unit_number" validate:"required,max=50"`
+	Component  store.BloodComponent `json:"component" validate:"required,oneof=whole_blood red_cells platelets plasma cryoprecipitate"`
+	VolumeML   int                  `json:"volume_ml" validate:"required,min=1,max=1000"`
+	// ExpiresOn defaults to the component's shelf life from the donation
+	ExpiresOn string `json:"expires_on" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type RecordDonationPayload struct {
+	VolumeML int `json:"volume_ml" validate:"required,min=1,max=1000"`
+	// DonatedAt defaults to now
+	DonatedAt *time.Time           `json:"donated_at"`
+	Notes     string               `json:"notes" validate:"max=2000"`
+	Units     []DonatedUnitPayload `json:"units" validate:"required,min=1,max=5,dive"`
+}
+
+// recordDonationHandler godoc
+//
+//	@Summary		Records a blood donation
+//	@Description	Records a donation and the units separated from it, which take the donor's blood group. Whole blood, red cells and platelets keep 35, 42 and 5 days and plasma and cryoprecipitate a year unless expires_on is given. Donors must be 18 to 65, not deferred, and donations 56 days apart. Lab only.
+//	@Tags			blood-bank
+//	@Accept			json
+//	@Produce		json
+//	@Param			donorID	path		string					true	"Donor ID"
+//	@Param			payload	body		RecordDonationPayload	true	"Donation"
+//	@Success		201		{object}	store.BloodDonation
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/blood-bank/donors/{donorID}/donations [post]
+func (app *application) recordDonationHandler(w http.ResponseWriter, r *http.Request) {
+	donor := getBloodDonorFromCtx(r)
+	user := getUserFromContext(r)
+
+	var payload RecordDonationPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	for i := range payload.Units {
+		payload.Units[i].UnitNumber = strings.ToUpper(strings.TrimSpace(payload.Units[i].UnitNumber))
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	donatedAt := time.Now()
+	if payload.DonatedAt != nil {
+		if payload.DonatedAt.After(donatedAt) {
+			app.badRequestResponse(w, r, errDonationInFuture)
+			return
+		}
+		donatedAt = *payload.DonatedAt
+	}
+
+	dob, err := time.Parse(store.DateLayout, donor.DateOfBirth)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if age := store.AgeOn(dob, donatedAt); age < bloodbank.MinDonorAge || age > bloodbank.MaxDonorAge {
+		app.conflictResponse(w, r, errDonorAge)
+		return
+	}
+
+	collectedOn := donatedAt.UTC().Format(store.DateLayout)
+
+	donation := &store.BloodDonation{
+		DonorID:     donor.ID,
+		VolumeML:    payload.VolumeML,
+		CollectedBy: &user.ID,
+		Notes:       strings.TrimSpace(payload.Notes),
+		DonatedAt:   donatedAt,
+		Units:       make([]store.BloodUnit, len(payload.Units)),
+	}
+
+	for i, unit := range payload.Units {
+		expiresOn := unit.ExpiresOn
+		if expiresOn == "" {
+			days := bloodbank.ShelfLifeDays(bloodbank.Component(unit.Component))
+			expiresOn = donatedAt.UTC().AddDate(0, 0, days).Format(store.DateLayout)
+		}
+
+		if expiresOn <= collectedOn {
+			app.badRequestResponse(w, r, errUnitExpiry)
+			return
+		}
+
+		donation.Units[i] = store.BloodUnit{
+			UnitNumber: unit.UnitNumber,
+			Component:  unit.Component,
+			VolumeML:   unit.VolumeML,
+			ExpiresOn:  expiresOn,
+		}
+	}
+
+	if err := app.store.BloodBank.RecordDonation(r.Context(), donation, bloodbank.DonationIntervalDays); err != nil {
+		switch {
+		case errors.Is(err, store.ErrDonorNotEligible):
+			app.conflictResponse(w, r, err)
+		case errors.Is(err, store.ErrConflict):
+			app.conflictResponse(w, r, errors.New("unit number is already in use"))
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, donation); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type AddBloodUnitPayload struct {
+	UnitNumber  string               `json:"unit_number" validate:"required,max=50"`
+	Source      string               `json:"source" validate:"required,max=255"`
+	Component   store.BloodComponent `json:"component" validate:"required,oneof=whole_blood red_cells platelets plasma cryoprecipitate"`
+	BloodGroup  store.BloodGroup     `json:"blood_group" validate:"required,oneof=A+ A- B+ B- AB+ AB- O+ O-"`
+	VolumeML    int                  `json:"volume_ml" validate:"required,min=1,max=1000"`
+	CollectedOn string               `json:"collected_on" validate:"required,datetime=2006-01-02"`
+	ExpiresOn   string               `json:"expires_on" validate:"required,datetime=2006-01-02"`
+}
+
+// addBloodUnitHandler godoc
+//
+//	@Summary	Receives a blood unit from another bank
+//	@Tags		blood-bank
+//	@Accept		json
+//	@Produce	json
+//	@Param		payload	body		AddBloodUnitPayload	true	"Unit"
+//	@Success	201		{object}	store.BloodUnit
+//	@Failure	400		{object}	error
+//	@Failure	403		{object}	error
+//	@Failure	409		{object}	error
+//	@Failure	500		{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/blood-bank/units [post]
+func (app *application) addBloodUnitHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	var payload AddBloodUnitPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.UnitNumber = strings.ToUpper(strings.TrimSpace(payload.UnitNumber))
+	payload.Source = strings.TrimSpace(payload.Source)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.ExpiresOn <= payload.CollectedOn {
+		app.badRequestResponse(w, r, errUnitExpiry)
+		return
+	}
+
+	unit := &store.BloodUnit{
+		UnitNumber:  payload.UnitNumber,
+		Source:      payload.Source,
+		Component:   payload.Component,
+		BloodGroup:  payload.BloodGroup,
+		VolumeML:    payload.VolumeML,
+		CollectedOn: payload.CollectedOn,
+		ExpiresOn:   payload.ExpiresOn,
+		ReceivedBy:  &user.ID,
+	}
+
+	if err := app.store.BloodBank.AddUnit(r.Context(), unit); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errors.New("unit number is already in use"))
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, unit); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getBloodUnitsHandler godoc
+//
+//	@Summary		Lists blood units
+//	@Description	Lists units, the soonest to expire first. compatible_with, with a component, keeps the units of the groups a recipient of that group may receive. Staff only.
+//	@Tags			blood-bank
+//	@Produce		json
+//	@Param			status					query		string	false	"available, reserved, issued or discarded"
+//	@Param			component				query		string	false	"whole_blood, red_cells, platelets, plasma or cryoprecipitate"
+//	@Param			blood_group				query		string	false	"Unit blood group"
+//	@Param			compatible_with			query		string	false	"Recipient blood group"
+//	@Param			expiring_within_days	query		int		false	"Units expiring within this many days, expired ones included"
+//	@Success		200						{array}		store.BloodUnit
+//	@Failure		400						{object}	error
+//	@Failure		403						{object}	error
+//	@Failure		500						{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/blood-bank/units [get]
+func (app *application) getBloodUnitsHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	q := store.BloodUnitQuery{
+		Status:    store.BloodUnitStatus(qs.Get("status")),
+		Component: store.BloodComponent(qs.Get("component")),
+	}
+
+	if err := Validate.Var(q.Status, "omitempty,oneof=available reserved issued discarded"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Var(q.Component, "omitempty,oneof=whole_blood red_cells platelets plasma cryoprecipitate"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if v := qs.Get("blood_group"); v != "" {
+		group, err := parseBloodGroup(v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		q.BloodGroups = []store.BloodGroup{group}
+	}
+
+	if v := qs.Get("compatible_with"); v != "" {
+		if q.Component == "" {
+			app.badRequestResponse(w, r, errComponentRequired)
+			return
+		}
+
+		recipient, err := parseBloodGroup(v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		groups := donorGroups(q.Component, recipient)
+		if q.BloodGroups != nil {
+			if !containsBloodGroup(groups, q.BloodGroups[0]) {
+				groups = []store.BloodGroup{}
+			} else {
+				groups = q.BloodGroups
+			}
+		}
+		q.BloodGroups = groups
+	}
+
+	if v := qs.Get("expiring_within_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 || days > 365 {
+			app.badRequestResponse(w, r, errors.New("expiring_within_days must be 0 to 365"))
+			return
+		}
+		q.ExpiringWithinDays = &days
+	}
+
+	units, err := app.store.BloodBank.GetUnits(r.Context(), q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, units); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func containsBloodGroup(groups []store.BloodGroup, group store.BloodGroup) bool {
+	for _, g := range groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// getBloodUnitHandler godoc
+//
+//	@Summary	Fetches a blood unit
+//	@Tags		blood-bank
+//	@Produce	json
+//	@Param		unitID	path		string	true	"Unit ID"
+//	@Success	200		{object}	store.BloodUnit
+//	@Failure	400		{object}	error
+//	@Failure	403		{object}	error
+//	@Failure	404		{object}	error
+//	@Failure	500		{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/blood-bank/units/{unitID} [get]
+func (app *application) getBloodUnitHandler(w http.ResponseWriter, r *http.Request) {
+	if err := app.jsonResponse(w, http.StatusOK, getBloodUnitFromCtx(r)); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type DiscardBloodUnitPayload struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+// discardBloodUnitHandler godoc
+//
+//	@Summary		Discards a blood unit
+//	@Description	Takes an available or reserved unit out of stock, for example when expired or damaged. Lab only.
+//	@Tags			blood-bank
+//	@Accept			json
+//	@Produce		json
+//	@Param			unitID	path		string					true	"Unit ID"
+//	@Param			payload	body		DiscardBloodUnitPayload	true	"Reason"
+//	@Success		200		{object}	store.BloodUnit
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/blood-bank/units/{unitID}/discard [post]
+func (app *application) discardBloodUnitHandler(w http.ResponseWriter, r *http.Request) {
+	unit := getBloodUnitFromCtx(r)
+
+	var payload DiscardBloodUnitPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Reason = strings.TrimSpace(payload.Reason)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.BloodBank.DiscardUnit(r.Context(), unit, payload.Reason); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errUnitNotInStock)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, unit); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// ComponentAvailability is the usable stock of one component a recipient
+// may receive, per compatible donor group.
+type ComponentAvailability struct {
+	Component   store.BloodComponent `json:"component"`
+	DonorGroups []store.BloodGroup   `json:"donor_groups"`
+	Units       int                  `json:"units"`
+	Stock       []store.BloodStock   `json:"stock"`
+}
+
+type BloodAvailability struct {
+	Recipient  store.BloodGroup        `json:"recipient"`
+	Components []ComponentAvailability `json:"components"`
+}
+
+// getBloodAvailabilityHandler godoc
+//
+//	@Summary		Finds blood compatible with a recipient
+//	@Description	Counts the available unexpired units each component a recipient may receive, by donor group, their own group first. The recipient is a blood group, such as AB- (encode + as %2B), or a patient with a recorded blood group. Red cells follow ABO and RhD compatibility, whole blood must also be of the same ABO group, plasma and cryoprecipitate must carry no antibody against the recipient, and platelets follow RhD only. Staff only.
+//	@Tags			blood-bank
+//	@Produce		json
+//	@Param			blood_group	query		string	false	"Recipient blood group"
+//	@Param			patient_id	query		string	false	"Recipient patient"
+//	@Param			component	query		string	false	"Only this component"
+//	@Success		200			{object}	BloodAvailability
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/blood-bank/availability [get]
+func (app *application) getBloodAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	ctx := r.Context()
+
+	var recipient store.BloodGroup
+	switch {
+	case qs.Get("blood_group") != "":
+		group, err := parseBloodGroup(qs.Get("blood_group"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		recipient = group
+	case qs.Get("patient_id") != "":
+		patientID, err := uuid.Parse(qs.Get("patient_id"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		patient, err := app.store.Patients.GetByID(ctx, patientID)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		if patient.BloodGroup == "" {
+			app.badRequestResponse(w, r, errBloodGroupUnknown)
+			return
+		}
+		recipient = patient.BloodGroup
+	default:
+		app.badRequestResponse(w, r, errRecipientRequired)
+		return
+	}
+
+	components := bloodbank.Components
+	if v := qs.Get("component"); v != "" {
+		if err := Validate.Var(v, "oneof=whole_blood red_cells platelets plasma cryoprecipitate"); err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		components = []bloodbank.Component{bloodbank.Component(v)}
+	}
+
+	availability := BloodAvailability{Recipient: recipient, Components: []ComponentAvailability{}}
+
+	for _, c := range components {
+		component := store.BloodComponent(c)
+		groups := donorGroups(component, recipient)
+
+		stock, err := app.store.BloodBank.GetStock(ctx, component, groups)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		entry := ComponentAvailability{Component: component, DonorGroups: groups, Stock: []store.BloodStock{}}
+		for _, group := range groups {
+			for _, st := range stock {
+				if st.BloodGroup == group {
+					entry.Stock = append(entry.Stock, st)
+					entry.Units += st.Units
+				}
+			}
+		}
+
+		availability.Components = append(availability.Components, entry)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, availability); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type CreateCrossmatchPayload struct {
+	Component      store.BloodComponent    `json:"component" validate:"required,oneof=whole_blood red_cells platelets plasma cryoprecipitate"`
+	UnitsRequested int                     `json:"units_requested" validate:"required,min=1,max=20"`
+	Urgency        store.CrossmatchUrgency `json:"urgency" validate:"omitempty,oneof=routine urgent emergency"`
+	Indication     string                  `json:"indication" validate:"required,max=2000"`
+	RequiredBy     *time.Time              `json:"required_by"`
+}
+
+// createCrossmatchHandler godoc
+//
+//	@Summary		Requests blood for a patient
+//	@Description	Asks the blood bank to crossmatch units of a component for the patient, whose blood group must be recorded. Doctors only.
+//	@Tags			blood-bank
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string					true	"Patient ID"
+//	@Param			payload		body		CreateCrossmatchPayload	true	"Request"
+//	@Success		201			{object}	store.CrossmatchRequest
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/crossmatch-requests [post]
+func (app *application) createCrossmatchHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+
+	var payload CreateCrossmatchPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Indication = strings.TrimSpace(payload.Indication)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if patient.BloodGroup == "" {
+		app.badRequestResponse(w, r, errBloodGroupUnknown)
+		return
+	}
+
+	request := &store.CrossmatchRequest{
+		PatientID:      patient.UserID,
+		BloodGroup:     patient.BloodGroup,
+		Component:      payload.Component,
+		UnitsRequested: payload.UnitsRequested,
+		Urgency:        payload.Urgency,
+		Indication:     payload.Indication,
+		RequiredBy:     payload.RequiredBy,
+		RequestedBy:    user.ID,
+	}
+
+	if request.Urgency == "" {
+		request.Urgency = store.CrossmatchRoutine
+	}
+
+	if err := app.store.BloodBank.CreateCrossmatch(r.Context(), request); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, request); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPatientCrossmatchesHandler godoc
+//
+//	@Summary	Lists a patient's blood requests
+//	@Tags		blood-bank
+//	@Produce	json
+//	@Param		patientID	path		string	true	"Patient ID"
+//	@Param		status		query		string	false	"pending, ready or closed"
+//	@Param		limit		query		int		false	"Limit"
+//	@Param		offset		query		int		false	"Offset"
+//	@Success	200			{array}		store.CrossmatchRequest
+//	@Failure	400			{object}	error
+//	@Failure	403			{object}	error
+//	@Failure	500			{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/patients/{patientID}/crossmatch-requests [get]
+func (app *application) getPatientCrossmatchesHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	app.listCrossmatches(w, r, store.CrossmatchQuery{
+		PatientID: &patient.UserID,
+		Limit:     20,
+		Offset:    0,
+	})
+}
+
+// getCrossmatchesHandler godoc
+//
+//	@Summary		Lists blood requests
+//	@Description	The blood bank's worklist: open requests by urgency and then the oldest first, closed ones after them newest first. Staff only.
+//	@Tags			blood-bank
+//	@Produce		json
+//	@Param			status	query		string	false	"pending, ready or closed"
+//	@Param			open	query		bool	false	"Only pending and ready requests"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Success		200		{array}		store.CrossmatchRequest
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/blood-bank/crossmatches [get]
+func (app *application) getCrossmatchesHandler(w http.ResponseWriter, r *http.Request) {
+	app.listCrossmatches(w, r, store.CrossmatchQuery{
+		Limit:  20,
+		Offset: 0,
+	})
+}
+
+func (app *application) listCrossmatches(w http.ResponseWriter, r *http.Request, q store.CrossmatchQuery) {
+	q, err := q.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(q); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	requests, err := app.store.BloodBank.GetCrossmatches(r.Context(), q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, requests); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getCrossmatchHandler godoc
+//
+//	@Summary	Fetches a blood request with its tests, reserved units and issues
+//	@Tags		blood-bank
+//	@Produce	json
+//	@Param		requestID	path		string	true	"Request ID"
+//	@Success	200			{object}	store.CrossmatchRequest
+//	@Failure	400			{object}	error
+//	@Failure	403			{object}	error
+//	@Failure	404			{object}	error
+//	@Failure	500			{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/blood-bank/crossmatches/{requestID} [get]
+func (app *application) getCrossmatchHandler(w http.ResponseWriter, r *http.Request) {
+	if err := app.jsonResponse(w, http.StatusOK, getCrossmatchFromCtx(r)); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type RecordCrossmatchPayload struct {
+	UnitID uuid.UUID              `json:"unit_id" validate:"required"`
+	Result store.CrossmatchResult `json:"result" validate:"required,oneof=compatible incompatible"`
+	Notes  string                 `json:"notes" validate:"max=2000"`
+}
+
+// recordCrossmatchHandler godoc
+//
+//	@Summary		Records a crossmatch
+//	@Description	Records the result of crossmatching an available unit of the requested component and a group the patient may receive. A compatible unit is reserved for the request until it is issued or the request is closed. Lab only.
+//	@Tags			blood-bank
+//	@Accept			json
+//	@Produce		json
+//	@Param			requestID	path		string					true	"Request ID"
+//	@Param			payload		body		RecordCrossmatchPayload	true	"Result"
+//	@Success		201			{object}	store.CrossmatchRequest
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/blood-bank/crossmatches/{requestID}/tests [post]
+func (app *application) recordCrossmatchHandler(w http.ResponseWriter, r *http.Request) {
+	request := getCrossmatchFromCtx(r)
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	var payload RecordCrossmatchPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Notes = strings.TrimSpace(payload.Notes)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	test := &store.CrossmatchTest{
+		RequestID: request.ID,
+		UnitID:    payload.UnitID,
+		Result:    payload.Result,
+		Notes:     payload.Notes,
+		TestedBy:  &user.ID,
+	}
+
+	err := app.store.BloodBank.RecordCrossmatch(ctx, test, donorGroups(request.Component, request.BloodGroup))
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrIncompatibleUnit):
+			app.conflictResponse(w, r, err)
+		case errors.Is(err, store.ErrConflict):
+			app.conflictResponse(w, r, errCrossmatchHeld)
+		case errors.Is(err, store.ErrLocked):
+			app.conflictResponse(w, r, errCrossmatchClosed)
+		case errors.Is(err, store.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	fresh, err := app.store.BloodBank.GetCrossmatch(ctx, request.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, fresh); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type IssueBloodUnitPayload struct {
+	UnitID uuid.UUID `json:"unit_id" validate:"required"`
+	// IssuedTo is who collects the unit, such as the ward nurse
+	IssuedTo string `json:"issued_to" validate:"required,max=255"`
+}
+
+// issueBloodUnitHandler godoc
+//
+//	@Summary		Issues a blood unit
+//	@Description	Hands out a unit reserved for the request. Lab only.
+//	@Tags			blood-bank
+//	@Accept			json
+//	@Produce		json
+//	@Param			requestID	path		string					true	"Request ID"
+//	@Param			payload		body		IssueBloodUnitPayload	true	"Issue"
+//	@Success		201			{object}	store.BloodIssue
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/blood-bank/crossmatches/{requestID}/issues [post]
+func (app *application) issueBloodUnitHandler(w http.ResponseWriter, r *http.Request) {
+	request := getCrossmatchFromCtx(r)
+	user := getUserFromContext(r)
+
+	var payload IssueBloodUnitPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.IssuedTo = strings.TrimSpace(payload.IssuedTo)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	issue := &store.BloodIssue{
+		UnitID:    payload.UnitID,
+		RequestID: request.ID,
+		IssuedBy:  &user.ID,
+		IssuedTo:  payload.IssuedTo,
+	}
+
+	if err := app.store.BloodBank.IssueUnit(r.Context(), issue); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errUnitNotReserved)
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errCrossmatchClosed)
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, issue); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type ReturnBloodUnitPayload struct {
+	// Reusable puts the unit back into stock, if it has not expired;
+	// otherwise it is discarded
+	Reusable bool   `json:"reusable"`
+	Reason   string `json:"reason" validate:"max=1000"`
+}
+
+// returnBloodUnitHandler godoc
+//
+//	@Summary		Returns an issued blood unit
+//	@Description	Records an issued unit coming back unused. A reusable unit that has not expired goes back into stock, any other is discarded. Lab only.
+//	@Tags			blood-bank
+//	@Accept			json
+//	@Produce		json
+//	@Param			issueID	path		string					true	"Issue ID"
+//	@Param			payload	body		ReturnBloodUnitPayload	true	"Return"
+//	@Success		200		{object}	store.BloodIssue
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/blood-bank/issues/{issueID}/return [post]
+func (app *application) returnBloodUnitHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	id, err := uuid.Parse(chi.URLParam(r, "issueID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload ReturnBloodUnitPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Reason = strings.TrimSpace(payload.Reason)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if !payload.Reusable && payload.Reason == "" {
+		app.badRequestResponse(w, r, errReturnReasonRequired)
+		return
+	}
+
+	issue, err := app.store.BloodBank.GetIssue(ctx, id)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	issue.ReturnedBy = &user.ID
+	issue.Reusable = &payload.Reusable
+	issue.ReturnReason = payload.Reason
+
+	if err := app.store.BloodBank.ReturnUnit(ctx, issue); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errUnitReturned)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, issue); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// closeCrossmatchHandler godoc
+//
+//	@Summary		Closes a blood request
+//	@Description	Closes a request once it is met or no longer needed, putting the units still reserved for it back into stock. Staff only.
+//	@Tags			blood-bank
+//	@Produce		json
+//	@Param			requestID	path		string	true	"Request ID"
+//	@Success		200			{object}	store.CrossmatchRequest
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/blood-bank/crossmatches/{requestID}/close [post]
+func (app *application) closeCrossmatchHandler(w http.ResponseWriter, r *http.Request) {
+	request := getCrossmatchFromCtx(r)
+	user := getUserFromContext(r)
+
+	if err := app.store.BloodBank.CloseCrossmatch(r.Context(), request, user.ID); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errCrossmatchClosed)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, request); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) bloodDonorContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "donorID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		donor, err := app.store.BloodBank.GetDonor(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, bloodDonorCtx, donor)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getBloodDonorFromCtx(r *http.Request) *store.BloodDonor {
+	donor, _ := r.Context().Value(bloodDonorCtx).(*store.BloodDonor)
+	return donor
+}
+
+func (app *application) bloodUnitContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "unitID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		unit, err := app.store.BloodBank.GetUnit(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, bloodUnitCtx, unit)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getBloodUnitFromCtx(r *http.Request) *store.BloodUnit {
+	unit, _ := r.Context().Value(bloodUnitCtx).(*store.BloodUnit)
+	return unit
+}
+
+func (app *application) crossmatchContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "requestID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		request, err := app.store.BloodBank.GetCrossmatch(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		allowed, err := app.canAccessPatient(r, request.PatientID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		ctx = context.WithValue(ctx, crossmatchCtx, request)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getCrossmatchFromCtx(r *http.Request) *store.CrossmatchRequest {
+	request, _ := r.Context().Value(crossmatchCtx).(*store.CrossmatchRequest)
+	return request
+}
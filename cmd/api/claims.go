@@ -0,0 +1,678 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/claimfile"
+	"github.com/MdHasib01/hms_server/internal/eligibility"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type claimKey string
+
+const claimCtx claimKey = "claim"
+
+var (
+	errNoInsurance         = errors.New("patient has no insurance policy")
+	errPolicyNotInForce    = errors.New("policy was not in force when the invoice was issued")
+	errClaimOpen           = errors.New("invoice already has a claim in progress")
+	errClaimNotDraft       = errors.New("claim has been submitted and can no longer be changed")
+	errClaimNotSubmitted   = errors.New("claim is not awaiting the payer")
+	errClaimNoDiagnoses    = errors.New("claim has no diagnosis codes")
+	errUnknownDiagnosis    = errors.New("unknown diagnosis code")
+	errRemittanceTooLarge  = errors.New("remittance exceeds what was claimed or what the invoice still owes")
+	errDenialReasonMissing = errors.New("denial_reason is required when nothing is paid")
+)
+
+type CreateClaimPayload struct {
+	// PolicyID defaults to the patient's primary policy
+	PolicyID *uuid.UUID `json:"policy_id"`
+	// DiagnosisCodes default to the diagnoses of the encounters billed on
+	// the invoice
+	DiagnosisCodes []string `json:"diagnosis_codes" validate:"omitempty,max=12,unique,dive,required,max=10"`
+}
+
+// createClaimHandler godoc
+//
+//	@Summary		Drafts an insurance claim
+//	@Description	Drafts a claim for an issued invoice against one of the patient's policies, the primary one unless given. Each invoice item becomes a claim line. Diagnosis codes default to those of the signed encounters billed on the invoice. Staff only.
+//	@Tags			claims
+//	@Accept			json
+//	@Produce		json
+//	@Param			invoiceID	path		string				true	"Invoice ID"
+//	@Param			payload		body		CreateClaimPayload	true	"Claim"
+//	@Success		201			{object}	store.Claim
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/invoices/{invoiceID}/claims [post]
+func (app *application) createClaimHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	invoice := getInvoiceFromCtx(r)
+	ctx := r.Context()
+
+	var payload CreateClaimPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	for i, code := range payload.DiagnosisCodes {
+		payload.DiagnosisCodes[i] = strings.ToUpper(strings.TrimSpace(code))
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if invoice.Status != store.InvoiceIssued || invoice.IssuedAt == nil {
+		app.conflictResponse(w, r, errInvoiceNotIssued)
+		return
+	}
+
+	policies, err := app.store.Patients.GetInsurance(ctx, invoice.PatientID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	policy := choosePolicy(policies, payload.PolicyID)
+	if policy == nil {
+		if payload.PolicyID != nil {
+			app.badRequestResponse(w, r, errors.New("policy not found"))
+		} else {
+			app.conflictResponse(w, r, errNoInsurance)
+		}
+		return
+	}
+
+	issued := invoice.IssuedAt.Format(store.DateLayout)
+	if (policy.ValidFrom != "" && issued < policy.ValidFrom) || (policy.ValidTo != "" && issued > policy.ValidTo) {
+		app.conflictResponse(w, r, errPolicyNotInForce)
+		return
+	}
+
+	codes := payload.DiagnosisCodes
+	if codes == nil {
+		codes, err = app.store.Claims.PrefillDiagnoses(ctx, invoice.ID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	claim := &store.Claim{
+		InvoiceID:      invoice.ID,
+		PatientID:      invoice.PatientID,
+		PolicyID:       &policy.ID,
+		Payer:          policy.Payer,
+		PlanName:       policy.PlanName,
+		PolicyNumber:   policy.PolicyNumber,
+		MemberID:       policy.MemberID,
+		GroupNumber:    policy.GroupNumber,
+		DiagnosisCodes: codes,
+		CreatedBy:      &user.ID,
+	}
+
+	if err := app.store.Claims.Create(ctx, claim); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errInvoiceNotIssued)
+		case store.ErrNotFound:
+			app.badRequestResponse(w, r, errUnknownDiagnosis)
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errClaimOpen)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, claim); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// choosePolicy returns the policy with the given ID, or the primary policy
+// when id is nil. A patient's only policy counts as primary.
+func choosePolicy(policies []store.InsurancePolicy, id *uuid.UUID) *store.InsurancePolicy {
+	for i := range policies {
+		switch {
+		case id != nil && policies[i].ID == *id:
+			return &policies[i]
+		case id == nil && (policies[i].IsPrimary || len(policies) == 1):
+			return &policies[i]
+		}
+	}
+	return nil
+}
+
+// getClaimsHandler godoc
+//
+//	@Summary		Lists claims
+//	@Description	Lists claims oldest first, optionally by status and payer. Staff only.
+//	@Tags			claims
+//	@Produce		json
+//	@Param			status	query		string	false	"draft, submitted, approved, partially_paid or denied"
+//	@Param			payer	query		string	false	"Payer"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Success		200		{array}		store.Claim
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/claims [get]
+func (app *application) getClaimsHandler(w http.ResponseWriter, r *http.Request) {
+	q := store.ClaimQuery{
+		IncludeDrafts: true,
+		Limit:         20,
+		Offset:        0,
+	}
+
+	q, err := q.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(q); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	claims, err := app.store.Claims.List(r.Context(), q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, claims); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// exportClaimsHandler godoc
+//
+//	@Summary		Exports a claim file
+//	@Description	Downloads claims as a file for the payer in the format set by CLAIM_FILE_FORMAT. Exports submitted claims unless another status is given, up to 500 per file. Staff only.
+//	@Tags			claims
+//	@Produce		text/csv
+//	@Produce		json
+//	@Param			status	query		string	false	"Status, submitted by default"
+//	@Param			payer	query		string	false	"Payer"
+//	@Param			offset	query		int		false	"Offset"
+//	@Success		200		{file}		file
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/claims/export [get]
+func (app *application) exportClaimsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	q := store.ClaimQuery{
+		IncludeDrafts: true,
+		Status:        store.ClaimSubmitted,
+		Limit:         500,
+		Offset:        0,
+	}
+
+	q, err := q.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	q.Limit = 500
+
+	if err := Validate.Struct(q); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	claims, err := app.store.Claims.List(ctx, q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	ids := make([]uuid.UUID, len(claims))
+	for i, claim := range claims {
+		ids[i] = claim.ID
+	}
+
+	lines, err := app.store.Claims.GetLinesFor(ctx, ids)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	file, err := app.claimFile(ctx, claims, lines)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	format := app.claimFormat
+	fileName := fmt.Sprintf("claims-%s.%s", now.Format("20060102-150405"), format.Extension())
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if err := format.Write(w, app.config.letterhead.Name, now, file); err != nil {
+		// the status is already sent; the truncated file is only logged
+		app.logger.Errorw("error writing claim file", "error", err.Error())
+	}
+}
+
+// claimFile converts claims for export, with the patient details payers
+// need.
+func (app *application) claimFile(ctx context.Context, claims []*store.Claim, lines map[uuid.UUID][]store.ClaimLine) ([]claimfile.Claim, error) {
+	patients := make(map[uuid.UUID]*store.Patient)
+	file := make([]claimfile.Claim, 0, len(claims))
+
+	for _, c := range claims {
+		patient, ok := patients[c.PatientID]
+		if !ok {
+			var err error
+			patient, err = app.documentPatient(ctx, c.PatientID)
+			if err != nil {
+				return nil, err
+			}
+			patients[c.PatientID] = patient
+		}
+
+		fc := claimfile.Claim{
+			Number:         c.Number,
+			Payer:          c.Payer,
+			PlanName:       c.PlanName,
+			PolicyNumber:   c.PolicyNumber,
+			MemberID:       c.MemberID,
+			GroupNumber:    c.GroupNumber,
+			PatientName:    strings.TrimSpace(patient.FirstName + " " + patient.LastName),
+			PatientMRN:     patient.MRN,
+			PatientDOB:     patient.DateOfBirth,
+			PatientSex:     string(patient.Sex),
+			DiagnosisCodes: c.DiagnosisCodes,
+			Currency:       c.Currency,
+			TotalAmount:    c.TotalAmount,
+		}
+
+		for _, l := range lines[c.ID] {
+			fc.Lines = append(fc.Lines, claimfile.Line{
+				Position:    l.Position,
+				Kind:        string(l.Kind),
+				Code:        l.Code,
+				Description: l.Description,
+				Quantity:    l.Quantity,
+				Amount:      l.Amount,
+				ServiceDate: l.ServiceDate,
+			})
+		}
+
+		file = append(file, fc)
+	}
+
+	return file, nil
+}
+
+// getPatientClaimsHandler godoc
+//
+//	@Summary		Lists a patient's claims
+//	@Description	Lists the patient's insurance claims. Patients and their guardians do not see drafts.
+//	@Tags			claims
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Param			status		query		string	false	"Status"
+//	@Param			limit		query		int		false	"Limit"
+//	@Param			offset		query		int		false	"Offset"
+//	@Success		200			{array}		store.Claim
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/claims [get]
+func (app *application) getPatientClaimsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+
+	q := store.ClaimQuery{
+		PatientID:     &patient.UserID,
+		IncludeDrafts: !user.ActsFor(patient.UserID),
+		Limit:         20,
+		Offset:        0,
+	}
+
+	q, err := q.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(q); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	claims, err := app.store.Claims.List(r.Context(), q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, claims); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getClaimHandler godoc
+//
+//	@Summary		Fetches a claim
+//	@Description	Fetches a claim with its lines and remittances
+//	@Tags			claims
+//	@Produce		json
+//	@Param			claimID	path		string	true	"Claim ID"
+//	@Success		200		{object}	store.Claim
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/claims/{claimID} [get]
+func (app *application) getClaimHandler(w http.ResponseWriter, r *http.Request) {
+	claim := getClaimFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, claim); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type SetClaimDiagnosesPayload struct {
+	DiagnosisCodes []string `json:"diagnosis_codes" validate:"required,min=1,max=12,unique,dive,required,max=10"`
+}
+
+// setClaimDiagnosesHandler godoc
+//
+//	@Summary		Sets the diagnoses of a claim
+//	@Description	Replaces the ICD-10 codes of a draft claim, the principal diagnosis first. Staff only.
+//	@Tags			claims
+//	@Accept			json
+//	@Produce		json
+//	@Param			claimID	path		string						true	"Claim ID"
+//	@Param			payload	body		SetClaimDiagnosesPayload	true	"Diagnosis codes"
+//	@Success		200		{object}	store.Claim
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/claims/{claimID}/diagnoses [put]
+func (app *application) setClaimDiagnosesHandler(w http.ResponseWriter, r *http.Request) {
+	claim := getClaimFromCtx(r)
+
+	var payload SetClaimDiagnosesPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	for i, code := range payload.DiagnosisCodes {
+		payload.DiagnosisCodes[i] = strings.ToUpper(strings.TrimSpace(code))
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Claims.SetDiagnoses(r.Context(), claim, payload.DiagnosisCodes); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errClaimNotDraft)
+		case store.ErrNotFound:
+			app.badRequestResponse(w, r, errUnknownDiagnosis)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, claim); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// submitClaimHandler godoc
+//
+//	@Summary		Submits a claim
+//	@Description	Marks a draft claim as sent to the payer; it is then included in claim file exports. A claim needs at least one diagnosis code. Staff only.
+//	@Tags			claims
+//	@Produce		json
+//	@Param			claimID	path		string	true	"Claim ID"
+//	@Success		200		{object}	store.Claim
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/claims/{claimID}/submit [post]
+func (app *application) submitClaimHandler(w http.ResponseWriter, r *http.Request) {
+	claim := getClaimFromCtx(r)
+
+	if len(claim.DiagnosisCodes) == 0 {
+		app.conflictResponse(w, r, errClaimNoDiagnoses)
+		return
+	}
+
+	if err := app.store.Claims.Submit(r.Context(), claim); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errClaimNotDraft)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, claim); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type RemitClaimPayload struct {
+	// PaidAmount is in minor units; zero records a denial
+	PaidAmount   int64  `json:"paid_amount" validate:"gte=0"`
+	Reference    string `json:"reference" validate:"max=100"`
+	DenialReason string `json:"denial_reason" validate:"max=1000"`
+}
+
+// remitClaimHandler godoc
+//
+//	@Summary		Records a payer remittance
+//	@Description	Records what the payer paid on a submitted or partially paid claim and posts it to the invoice as an insurance payment. The claim is approved once paid in full, partially paid while short, and denied when nothing was paid. Staff only.
+//	@Tags			claims
+//	@Accept			json
+//	@Produce		json
+//	@Param			claimID	path		string				true	"Claim ID"
+//	@Param			payload	body		RemitClaimPayload	true	"Remittance"
+//	@Success		201		{object}	store.Claim
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/claims/{claimID}/remittances [post]
+func (app *application) remitClaimHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	claim := getClaimFromCtx(r)
+
+	var payload RemitClaimPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	remittance := &store.ClaimRemittance{
+		PaidAmount:   payload.PaidAmount,
+		Reference:    strings.TrimSpace(payload.Reference),
+		DenialReason: strings.TrimSpace(payload.DenialReason),
+		RecordedBy:   &user.ID,
+	}
+
+	if remittance.PaidAmount == 0 && remittance.DenialReason == "" {
+		app.badRequestResponse(w, r, errDenialReasonMissing)
+		return
+	}
+
+	if err := app.store.Claims.Remit(r.Context(), claim, remittance); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errClaimNotSubmitted)
+		case store.ErrOverpayment:
+			app.conflictResponse(w, r, errRemittanceTooLarge)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, claim); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// checkEligibilityHandler godoc
+//
+//	@Summary		Checks insurance eligibility
+//	@Description	Asks the payer whether the policy covers care on the given day, today by default. Staff only.
+//	@Tags			claims
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Param			policyID	path		string	true	"Policy ID"
+//	@Param			date		query		string	false	"Service date (YYYY-MM-DD)"
+//	@Success		200			{object}	eligibility.Result
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/insurance/{policyID}/eligibility [get]
+func (app *application) checkEligibilityHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+	ctx := r.Context()
+
+	policyID, err := uuid.Parse(chi.URLParam(r, "policyID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	date := time.Now().Format(store.DateLayout)
+	if d := r.URL.Query().Get("date"); d != "" {
+		if _, err := time.Parse(store.DateLayout, d); err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		date = d
+	}
+
+	policies, err := app.store.Patients.GetInsurance(ctx, patient.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	policy := choosePolicy(policies, &policyID)
+	if policy == nil {
+		app.notFoundResponse(w, r, store.ErrNotFound)
+		return
+	}
+
+	result, err := app.eligibility.Check(ctx, eligibility.Request{
+		Payer:        policy.Payer,
+		PolicyNumber: policy.PolicyNumber,
+		MemberID:     policy.MemberID,
+		GroupNumber:  policy.GroupNumber,
+		ValidFrom:    policy.ValidFrom,
+		ValidTo:      policy.ValidTo,
+		ServiceDate:  date,
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, result); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// claimContextMiddleware loads the claim named by {claimID}. Drafts are
+// hidden from the patient and their guardians.
+func (app *application) claimContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "claimID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		claim, err := app.store.Claims.GetByID(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		allowed, err := app.canAccessPatient(r, claim.PatientID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		if getUserFromContext(r).ActsFor(claim.PatientID) && claim.Status == store.ClaimDraft {
+			app.notFoundResponse(w, r, store.ErrNotFound)
+			return
+		}
+
+		ctx = context.WithValue(ctx, claimCtx, claim)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getClaimFromCtx(r *http.Request) *store.Claim {
+	claim, _ := r.Context().Value(claimCtx).(*store.Claim)
+	return claim
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// searchICD10Handler godoc
+//
+//	@Summary		Searches ICD-10 codes
+//	@Description	Typeahead search over ICD-10 codes by code prefix or description
+//	@Tags			codes
+//	@Produce		json
+//	@Param			q		query		string	true	"Code prefix or description text, at least 2 characters"
+//	@Param			limit	query		int		false	"Maximum results, 1-50 (default 10)"
+//	@Success		200		{array}		store.ICD10Code
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/codes/icd10 [get]
+func (app *application) searchICD10Handler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	q := strings.TrimSpace(qs.Get("q"))
+	if err := Validate.Var(q, "min=2,max=100"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	limit := 10
+	if l := qs.Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		limit = parsed
+	}
+
+	if err := Validate.Var(limit, "gte=1,lte=50"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	codes, err := app.store.Codes.SearchICD10(r.Context(), q, limit)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, codes); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
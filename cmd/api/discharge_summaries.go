@@ -0,0 +1,547 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/blob"
+	"github.com/MdHasib01/hms_server/internal/mailer"
+	"github.com/MdHasib01/hms_server/internal/pdf"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type dischargeSummaryKey string
+
+const dischargeSummaryCtx dischargeSummaryKey = "dischargeSummary"
+
+var (
+	errSummaryExists        = errors.New("admission already has a discharge summary")
+	errSummaryFinal         = errors.New("discharge summary is final and can no longer be changed")
+	errSummaryNotFinal      = errors.New("discharge summary is not final yet")
+	errPatientNotDischarged = errors.New("patient has not been discharged yet")
+	errFollowUpBooked       = errors.New("a follow-up appointment is already scheduled")
+)
+
+// createDischargeSummaryHandler godoc
+//
+//	@Summary		Starts a discharge summary
+//	@Description	Creates a draft discharge summary for an admission, pre-filled with the assessments and plan of the signed encounters and the prescriptions issued during the stay. Only the attending doctor can write it; if the admission has none, any doctor can.
+//	@Tags			discharge-summary
+//	@Produce		json
+//	@Param			admissionID	path		string	true	"Admission ID"
+//	@Success		201			{object}	store.DischargeSummary
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admissions/{admissionID}/discharge-summary [post]
+func (app *application) createDischargeSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	admission := getAdmissionFromCtx(r)
+	ctx := r.Context()
+
+	if admission.AttendingDoctorID != nil && *admission.AttendingDoctorID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	summary, err := app.store.DischargeSummaries.Prefill(ctx, admission)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	summary.AuthorID = user.ID
+
+	if err := app.store.DischargeSummaries.Create(ctx, summary); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errSummaryExists)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, summary); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getDischargeSummaryHandler godoc
+//
+//	@Summary		Fetches a discharge summary
+//	@Description	Patients and their guardians can read final summaries; staff can also read drafts
+//	@Tags			discharge-summary
+//	@Produce		json
+//	@Param			summaryID	path		string	true	"Discharge summary ID"
+//	@Success		200			{object}	store.DischargeSummary
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/discharge-summaries/{summaryID} [get]
+func (app *application) getDischargeSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	summary := getDischargeSummaryFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, summary); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type UpdateDischargeSummaryPayload struct {
+	AdmissionReason      string                    `json:"admission_reason" validate:"required,max=2000"`
+	HospitalCourse       string                    `json:"hospital_course" validate:"max=20000"`
+	Procedures           string                    `json:"procedures" validate:"max=5000"`
+	DischargeMedications []PrescriptionItemPayload `json:"discharge_medications" validate:"max=30,dive"`
+	FollowUpInstructions string                    `json:"follow_up_instructions" validate:"max=5000"`
+}
+
+// updateDischargeSummaryHandler godoc
+//
+//	@Summary		Edits a discharge summary
+//	@Description	Replaces the content of a draft summary. Only its author can edit it.
+//	@Tags			discharge-summary
+//	@Accept			json
+//	@Produce		json
+//	@Param			summaryID	path		string							true	"Discharge summary ID"
+//	@Param			payload		body		UpdateDischargeSummaryPayload	true	"Summary"
+//	@Success		200			{object}	store.DischargeSummary
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/discharge-summaries/{summaryID} [put]
+func (app *application) updateDischargeSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	summary := getDischargeSummaryFromCtx(r)
+
+	if summary.AuthorID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload UpdateDischargeSummaryPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	summary.AdmissionReason = payload.AdmissionReason
+	summary.HospitalCourse = payload.HospitalCourse
+	summary.Procedures = payload.Procedures
+	summary.FollowUpInstructions = payload.FollowUpInstructions
+	summary.DischargeMedications = make([]store.PrescriptionItem, 0, len(payload.DischargeMedications))
+	for _, item := range payload.DischargeMedications {
+		summary.DischargeMedications = append(summary.DischargeMedications, store.PrescriptionItem(item))
+	}
+
+	if err := app.store.DischargeSummaries.Update(r.Context(), summary); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errSummaryFinal)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, summary); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// finalizeDischargeSummaryHandler godoc
+//
+//	@Summary		Finalizes a discharge summary
+//	@Description	Locks the summary once the patient has been discharged, renders it as a PDF and emails it to the patient, or to their guardians for a dependent. Only the author can finalize it.
+//	@Tags			discharge-summary
+//	@Produce		json
+//	@Param			summaryID	path		string	true	"Discharge summary ID"
+//	@Success		200			{object}	store.DischargeSummary
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/discharge-summaries/{summaryID}/finalize [post]
+func (app *application) finalizeDischargeSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	summary := getDischargeSummaryFromCtx(r)
+	ctx := r.Context()
+
+	if summary.AuthorID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if summary.Status == store.DischargeSummaryFinal {
+		app.conflictResponse(w, r, errSummaryFinal)
+		return
+	}
+
+	admission, err := app.store.ADT.GetAdmission(ctx, summary.AdmissionID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if admission.Status != store.AdmissionDischarged || admission.DischargedAt == nil {
+		app.conflictResponse(w, r, errPatientNotDischarged)
+		return
+	}
+
+	patient, err := app.documentPatient(ctx, summary.PatientID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	doctor, err := app.store.Doctors.GetByID(ctx, summary.AuthorID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	ward, room, bed, err := app.store.ADT.GetBedLocation(ctx, admission.BedID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	doc := app.dischargeSummaryDocument(summary, admission, patient, doctor)
+	doc.FinalizedAt = time.Now().UTC().Truncate(time.Second)
+	doc.Ward = ward
+	doc.Bed = room + ", bed " + bed
+
+	document, err := pdf.RenderDischargeSummary(app.config.letterhead, doc)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	key := fmt.Sprintf("%sdischarge-summaries/%s.pdf", blob.PrivatePrefix, summary.ID)
+
+	if err := app.blob.Put(ctx, key, bytes.NewReader(document)); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.store.DischargeSummaries.Finalize(ctx, summary, key); err != nil {
+		switch err {
+		case store.ErrLocked:
+			// finalized concurrently; the PDF key is shared with the other
+			// request, so it is not deleted
+			app.conflictResponse(w, r, errSummaryFinal)
+		default:
+			app.deleteBlobs(r, key)
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	// the summary is final either way; a failed email is logged and can be
+	// retried by downloading the PDF
+	if err := app.emailDischargeSummary(ctx, summary, patient, doc.DoctorName, admission.DischargedAt, document); err != nil {
+		app.logger.Errorw("error sending discharge summary", "summary", summary.ID, "error", err.Error())
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, summary); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// emailDischargeSummary sends the PDF to the patient, or to the guardians of
+// a dependent, and records when it was sent.
+func (app *application) emailDischargeSummary(ctx context.Context, summary *store.DischargeSummary, patient *store.Patient, doctorName string, dischargedAt *time.Time, document []byte) error {
+	recipients := []store.Guardian{{Username: patient.Username, Email: patient.Email}}
+	if patient.Dependent {
+		var err error
+		recipients, err = app.store.Guardians.GetGuardians(ctx, patient.UserID)
+		if err != nil {
+			return err
+		}
+	}
+
+	isProdEnv := app.config.env == "production"
+	attachment := mailer.Attachment{
+		FileName:    fmt.Sprintf("discharge-summary-%s.pdf", summary.ID),
+		ContentType: "application/pdf",
+		Data:        document,
+	}
+
+	sent := false
+	for _, recipient := range recipients {
+		if recipient.Email == "" {
+			continue
+		}
+
+		vars := struct {
+			Username     string
+			PatientName  string
+			DoctorName   string
+			DischargedAt string
+			SummaryURL   string
+		}{
+			Username:     recipient.Username,
+			PatientName:  strings.TrimSpace(patient.FirstName + " " + patient.LastName),
+			DoctorName:   doctorName,
+			DischargedAt: dischargedAt.Format("2006-01-02"),
+			SummaryURL:   fmt.Sprintf("%s/discharge-summaries/%s", app.config.frontendURL, summary.ID),
+		}
+
+		status, err := app.mailer.Send(mailer.DischargeSummaryTemplate, recipient.Username, recipient.Email, vars, !isProdEnv, attachment)
+		if err != nil {
+			return err
+		}
+
+		app.logger.Infow("discharge summary sent", "summary", summary.ID, "to", recipient.Username, "status", status)
+		sent = true
+	}
+
+	if !sent {
+		return nil
+	}
+
+	return app.store.DischargeSummaries.MarkEmailed(ctx, summary)
+}
+
+func (app *application) dischargeSummaryDocument(s *store.DischargeSummary, admission *store.Admission, patient *store.Patient, doctor *store.Doctor) pdf.DischargeSummary {
+	doc := pdf.DischargeSummary{
+		ID:                   s.ID.String(),
+		PatientName:          strings.TrimSpace(patient.FirstName + " " + patient.LastName),
+		PatientMRN:           patient.MRN,
+		PatientDOB:           patient.DateOfBirth,
+		PatientSex:           string(patient.Sex),
+		AdmittedAt:           admission.AdmittedAt,
+		AdmissionReason:      s.AdmissionReason,
+		HospitalCourse:       s.HospitalCourse,
+		Procedures:           s.Procedures,
+		FollowUpInstructions: s.FollowUpInstructions,
+		DoctorName:           strings.TrimSpace("Dr. " + doctor.FirstName + " " + doctor.LastName),
+		DoctorSpecialization: doctor.Specialization,
+	}
+
+	if admission.DischargedAt != nil {
+		doc.DischargedAt = *admission.DischargedAt
+	}
+
+	for _, item := range s.DischargeMedications {
+		doc.Medications = append(doc.Medications, pdf.PrescriptionItem(item))
+	}
+
+	return doc
+}
+
+// downloadDischargeSummaryPDFHandler godoc
+//
+//	@Summary		Downloads a discharge summary PDF
+//	@Description	Downloads the PDF rendered when the summary was finalized
+//	@Tags			discharge-summary
+//	@Produce		application/pdf
+//	@Param			summaryID	path		string	true	"Discharge summary ID"
+//	@Success		200			{file}		file
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/discharge-summaries/{summaryID}/pdf [get]
+func (app *application) downloadDischargeSummaryPDFHandler(w http.ResponseWriter, r *http.Request) {
+	summary := getDischargeSummaryFromCtx(r)
+
+	if summary.PDFKey == nil {
+		app.conflictResponse(w, r, errSummaryNotFinal)
+		return
+	}
+
+	fileName := fmt.Sprintf("discharge-summary-%s.pdf", summary.ID)
+	app.writeAttachment(w, r, *summary.PDFKey, fileName, "application/pdf")
+}
+
+type BookFollowUpPayload struct {
+	// DoctorID defaults to the author of the summary
+	DoctorID         *uuid.UUID             `json:"doctor_id"`
+	AppointmentTime  time.Time              `json:"appointment_time" validate:"required"`
+	ConsultationMode store.ConsultationMode `json:"consultation_mode" validate:"omitempty,oneof=in_person online"`
+}
+
+// bookDischargeFollowUpHandler godoc
+//
+//	@Summary		Books the follow-up visit of a discharge
+//	@Description	Books a follow-up appointment from a final discharge summary, with the author unless another doctor is given. Available to the patient, their guardians and staff. Only one follow-up can be scheduled at a time.
+//	@Tags			discharge-summary
+//	@Accept			json
+//	@Produce		json
+//	@Param			summaryID	path		string				true	"Discharge summary ID"
+//	@Param			payload		body		BookFollowUpPayload	true	"Appointment"
+//	@Success		201			{object}	store.Appointment
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/discharge-summaries/{summaryID}/follow-up [post]
+func (app *application) bookDischargeFollowUpHandler(w http.ResponseWriter, r *http.Request) {
+	summary := getDischargeSummaryFromCtx(r)
+	ctx := r.Context()
+
+	if summary.Status != store.DischargeSummaryFinal {
+		app.conflictResponse(w, r, errSummaryNotFinal)
+		return
+	}
+
+	var payload BookFollowUpPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	appointment := &store.Appointment{
+		PatientID:        summary.PatientID,
+		DoctorID:         summary.AuthorID,
+		AppointmentTime:  payload.AppointmentTime,
+		VisitType:        store.VisitTypeFollowUp,
+		ConsultationMode: payload.ConsultationMode,
+	}
+
+	if appointment.ConsultationMode == "" {
+		appointment.ConsultationMode = store.ConsultationModeInPerson
+	}
+
+	if payload.DoctorID != nil {
+		if _, err := app.store.Doctors.GetByID(ctx, *payload.DoctorID); err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.badRequestResponse(w, r, errors.New("doctor not found"))
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		appointment.DoctorID = *payload.DoctorID
+	}
+
+	if err := app.store.DischargeSummaries.BookFollowUp(ctx, summary, appointment); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errFollowUpBooked)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, appointment); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPatientDischargeSummariesHandler godoc
+//
+//	@Summary		Lists a patient's discharge summaries
+//	@Description	Lists discharge summaries, newest first. Patients and their guardians only see final summaries.
+//	@Tags			patient
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Success		200			{array}		store.DischargeSummary
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/discharge-summaries [get]
+func (app *application) getPatientDischargeSummariesHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+
+	allowed, err := app.canAccessPatient(r, patient.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	summaries, err := app.store.DischargeSummaries.GetByPatient(r.Context(), patient.UserID, user.ActsFor(patient.UserID))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, summaries); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// dischargeSummaryContextMiddleware loads the summary named by {summaryID}.
+// Drafts are hidden from the patient and their guardians.
+func (app *application) dischargeSummaryContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "summaryID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		summary, err := app.store.DischargeSummaries.GetByID(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		user := getUserFromContext(r)
+		if user.ActsFor(summary.PatientID) {
+			if summary.Status != store.DischargeSummaryFinal {
+				app.notFoundResponse(w, r, store.ErrNotFound)
+				return
+			}
+		} else {
+			staff, err := app.checkRolePrecedence(ctx, user, "doctor")
+			if err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+			if !staff {
+				app.forbiddenResponse(w, r)
+				return
+			}
+		}
+
+		ctx = context.WithValue(ctx, dischargeSummaryCtx, summary)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getDischargeSummaryFromCtx(r *http.Request) *store.DischargeSummary {
+	summary, _ := r.Context().Value(dischargeSummaryCtx).(*store.DischargeSummary)
+	return summary
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MdHasib01/hms_server/internal/blob"
+	"github.com/MdHasib01/hms_server/internal/imaging"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (app *application) setDoctorPhotoURLs(doctor *store.Doctor) {
+	doctor.PhotoURL = app.blob.URL(doctor.PhotoKey)
+	doctor.PhotoThumbnailURL = app.blob.URL(doctor.PhotoThumbnailKey)
+}
+
+// canManageDoctor reports whether the authenticated user may change the
+// doctor's profile: the doctor themselves or an admin.
+func (app *application) canManageDoctor(r *http.Request, doctor *store.Doctor) (bool, error) {
+	user := getUserFromContext(r)
+	if user == nil {
+		return false, nil
+	}
+
+	if user.ID == doctor.UserID {
+		return true, nil
+	}
+
+	return app.checkRolePrecedence(r.Context(), user, "admin")
+}
+
+func (app *application) uploadErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errFileTooLarge):
+		writeJSONError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, errUnsupportedFileType):
+		writeJSONError(w, http.StatusUnsupportedMediaType, err.Error())
+	default:
+		app.badRequestResponse(w, r, err)
+	}
+}
+
+// uploadDoctorPhotoHandler godoc
+//
+//	@Summary		Uploads a doctor's profile photo
+//	@Description	Replaces the doctor's profile photo and generates a thumbnail. JPEG, PNG, GIF and WebP are accepted.
+//	@Tags			doctor
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			doctorID	path		string	true	"Doctor ID"
+//	@Param			photo		formData	file	true	"Profile photo"
+//	@Success		200			{object}	store.Doctor
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		413			{object}	error
+//	@Failure		415			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/doctors/{doctorID}/photo [put]
+func (app *application) uploadDoctorPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	doctor := getDoctorFromCtx(r)
+	ctx := r.Context()
+
+	allowed, err := app.canManageDoctor(r, doctor)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	photo, err := readUpload(w, r, "photo", app.config.storage.maxPhotoSize, imageContentTypes)
+	if err != nil {
+		app.uploadErrorResponse(w, r, err)
+		return
+	}
+
+	thumbnail, err := imaging.Thumbnail(photo.Data, app.config.storage.thumbnailSize)
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("photo could not be processed: %w", err))
+		return
+	}
+
+	version := uuid.New()
+	photoKey := fmt.Sprintf("%sdoctors/%s/photo-%s%s", blob.PublicPrefix, doctor.UserID, version, photo.Extension)
+	thumbnailKey := fmt.Sprintf("%sdoctors/%s/photo-%s-thumbnail.jpg", blob.PublicPrefix, doctor.UserID, version)
+
+	if err := app.blob.Put(ctx, photoKey, bytes.NewReader(photo.Data)); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.blob.Put(ctx, thumbnailKey, bytes.NewReader(thumbnail)); err != nil {
+		app.deleteBlobs(r, photoKey)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	oldPhotoKey, oldThumbnailKey, err := app.store.Doctors.UpdatePhoto(ctx, doctor.UserID, photoKey, thumbnailKey)
+	if err != nil {
+		app.deleteBlobs(r, photoKey, thumbnailKey)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.deleteBlobs(r, oldPhotoKey, oldThumbnailKey)
+
+	doctor.PhotoKey = photoKey
+	doctor.PhotoThumbnailKey = thumbnailKey
+	app.setDoctorPhotoURLs(doctor)
+
+	if err := app.jsonResponse(w, http.StatusOK, doctor); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deleteBlobs removes blobs that are no longer referenced. Failures only
+// leave orphaned files behind, so they are logged rather than returned.
+func (app *application) deleteBlobs(r *http.Request, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+
+		if err := app.blob.Delete(r.Context(), key); err != nil {
+			app.logger.Errorw("error deleting blob", "key", key, "error", err)
+		}
+	}
+}
+
+// uploadDoctorDocumentHandler godoc
+//
+//	@Summary		Uploads a doctor credential document
+//	@Description	Uploads a license, degree, certificate or other credential. PDF, JPEG and PNG are accepted.
+//	@Tags			doctor
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			doctorID	path		string	true	"Doctor ID"
+//	@Param			kind		formData	string	true	"license, degree, certificate or other"
+//	@Param			file		formData	file	true	"Document"
+//	@Success		201			{object}	store.DoctorDocument
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		413			{object}	error
+//	@Failure		415			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/doctors/{doctorID}/documents [post]
+func (app *application) uploadDoctorDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	doctor := getDoctorFromCtx(r)
+	ctx := r.Context()
+
+	allowed, err := app.canManageDoctor(r, doctor)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	file, err := readUpload(w, r, "file", app.config.storage.maxDocumentSize, documentContentTypes)
+	if err != nil {
+		app.uploadErrorResponse(w, r, err)
+		return
+	}
+
+	kind := store.DoctorDocumentKind(r.FormValue("kind"))
+	if err := Validate.Var(string(kind), "required,oneof=license degree certificate other"); err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("kind: %w", err))
+		return
+	}
+
+	user := getUserFromContext(r)
+	doc := &store.DoctorDocument{
+		DoctorID:    doctor.UserID,
+		Kind:        kind,
+		FileName:    file.FileName,
+		ContentType: file.ContentType,
+		SizeBytes:   int64(len(file.Data)),
+		StorageKey:  fmt.Sprintf("%sdoctors/%s/documents/%s%s", blob.PrivatePrefix, doctor.UserID, uuid.New(), file.Extension),
+		UploadedBy:  &user.ID,
+	}
+
+	if err := app.blob.Put(ctx, doc.StorageKey, bytes.NewReader(file.Data)); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.store.DoctorDocuments.Create(ctx, doc); err != nil {
+		app.deleteBlobs(r, doc.StorageKey)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, doc); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getDoctorDocumentsHandler godoc
+//
+//	@Summary		Lists a doctor's credential documents
+//	@Description	Lists the credential documents uploaded for a doctor
+//	@Tags			doctor
+//	@Produce		json
+//	@Param			doctorID	path		string	true	"Doctor ID"
+//	@Success		200			{array}		store.DoctorDocument
+//	@Failure		403			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/doctors/{doctorID}/documents [get]
+func (app *application) getDoctorDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	doctor := getDoctorFromCtx(r)
+
+	allowed, err := app.canManageDoctor(r, doctor)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	docs, err := app.store.DoctorDocuments.GetByDoctor(r.Context(), doctor.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, docs); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// downloadDoctorDocumentHandler godoc
+//
+//	@Summary		Downloads a doctor credential document
+//	@Description	Downloads the file of a credential document
+//	@Tags			doctor
+//	@Produce		octet-stream
+//	@Param			doctorID	path		string	true	"Doctor ID"
+//	@Param			documentID	path		string	true	"Document ID"
+//	@Success		200			{file}		file
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/doctors/{doctorID}/documents/{documentID} [get]
+func (app *application) downloadDoctorDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	doctor := getDoctorFromCtx(r)
+
+	allowed, err := app.canManageDoctor(r, doctor)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	documentID, err := uuid.Parse(chi.URLParam(r, "documentID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	doc, err := app.store.DoctorDocuments.GetByID(r.Context(), doctor.UserID, documentID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.writeAttachment(w, r, doc.StorageKey, doc.FileName, doc.ContentType)
+}
+
+// deleteDoctorDocumentHandler godoc
+//
+//	@Summary		Deletes a doctor credential document
+//	@Description	Deletes a credential document and its file
+//	@Tags			doctor
+//	@Param			doctorID	path		string	true	"Doctor ID"
+//	@Param			documentID	path		string	true	"Document ID"
+//	@Success		204			{string}	string	"Document deleted"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/doctors/{doctorID}/documents/{documentID} [delete]
+func (app *application) deleteDoctorDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	doctor := getDoctorFromCtx(r)
+	ctx := r.Context()
+
+	allowed, err := app.canManageDoctor(r, doctor)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	documentID, err := uuid.Parse(chi.URLParam(r, "documentID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	doc, err := app.store.DoctorDocuments.GetByID(ctx, doctor.UserID, documentID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.store.DoctorDocuments.Delete(ctx, doctor.UserID, documentID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.deleteBlobs(r, doc.StorageKey)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/MdHasib01/hms_server/internal/mailer"
 	"github.com/MdHasib01/hms_server/internal/store"
@@ -61,6 +62,8 @@ func (app *application) doctorContextMiddleware(next http.Handler) http.Handler
 			return
 		}
 
+		app.setDoctorPhotoURLs(doctor)
+
 		ctx = context.WithValue(ctx, doctorCtx, doctor)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -72,24 +75,24 @@ func getDoctorFromCtx(r *http.Request) *store.Doctor {
 }
 
 type CreateDoctorPayload struct {
-	Username       string `json:"username" validate:"required,max=100"`
-	Email          string `json:"email" validate:"required,email,max=255"`
-	Password       string `json:"password" validate:"required,min=3,max=72"`
-	FirstName      string `json:"firstname" validate:"required,max=100"`
-	LastName       string `json:"lastname" validate:"required,max=100"`
-	Age            string `json:"age" validate:"required"`
-	Gender         string `json:"gender" validate:"required"`
-	MaritalStatus  string `json:"marital_status" validate:"required"`
-	Designation    string `json:"designation" validate:"required"`
-	Qualification  string `json:"qualification" validate:"required"`
-	BloodGroup     string `json:"blood_group" validate:"required"`
-	Address        string `json:"address" validate:"required"`
-	Country        string `json:"country" validate:"required"`
-	State          string `json:"state" validate:"required"`
-	City           string `json:"city" validate:"required"`
-	PostalCode     string `json:"postal_code" validate:"required"`
-	Specialization string `json:"specialization" validate:"required"`
-	LicenseNumber  string `json:"license_number" validate:"required"`
+	Username       string              `json:"username" validate:"required,max=100"`
+	Email          string              `json:"email" validate:"required,email,max=255"`
+	Password       string              `json:"password" validate:"required,min=3,max=72"`
+	FirstName      string              `json:"firstname" validate:"required,max=100"`
+	LastName       string              `json:"lastname" validate:"required,max=100"`
+	DateOfBirth    string              `json:"date_of_birth" validate:"required,datetime=2006-01-02"`
+	Gender         store.Gender        `json:"gender" validate:"required,oneof=male female other"`
+	MaritalStatus  store.MaritalStatus `json:"marital_status" validate:"required,oneof=single married divorced widowed separated"`
+	Designation    string              `json:"designation" validate:"required"`
+	Qualification  string              `json:"qualification" validate:"required"`
+	BloodGroup     store.BloodGroup    `json:"blood_group" validate:"required,oneof=A+ A- B+ B- AB+ AB- O+ O-"`
+	Address        string              `json:"address" validate:"required"`
+	Country        string              `json:"country" validate:"required"`
+	State          string              `json:"state" validate:"required"`
+	City           string              `json:"city" validate:"required"`
+	PostalCode     string              `json:"postal_code" validate:"required"`
+	Specialization string              `json:"specialization" validate:"required"`
+	LicenseNumber  string              `json:"license_number" validate:"required"`
 }
 
 // CreateDoctorHandler godoc
@@ -119,6 +122,11 @@ func (app *application) CreateDoctorHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if err := validateDateOfBirth(payload.DateOfBirth); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
 	ctx := r.Context()
 
 	// Step 2: Create User object
@@ -157,7 +165,7 @@ func (app *application) CreateDoctorHandler(w http.ResponseWriter, r *http.Reque
 		Email:          user.Email,
 		FirstName:      payload.FirstName,
 		LastName:       payload.LastName,
-		Age:            payload.Age,
+		DateOfBirth:    payload.DateOfBirth,
 		Gender:         payload.Gender,
 		MaritalStatus:  payload.MaritalStatus,
 		Designation:    payload.Designation,
@@ -205,30 +213,32 @@ func (app *application) CreateDoctorHandler(w http.ResponseWriter, r *http.Reque
 
 	// Step 7: Return Full Doctor Profile Response (excluding password)
 	resp := struct {
-		UserID         uuid.UUID `json:"user_id"`
-		UserName       string    `json:"username"`
-		Email          string    `json:"email"`
-		FirstName      string    `json:"firstname"`
-		LastName       string    `json:"lastname"`
-		Age            string    `json:"age"`
-		Gender         string    `json:"gender"`
-		MaritalStatus  string    `json:"marital_status"`
-		Designation    string    `json:"designation"`
-		Qualification  string    `json:"qualification"`
-		BloodGroup     string    `json:"blood_group"`
-		Address        string    `json:"address"`
-		Country        string    `json:"country"`
-		State          string    `json:"state"`
-		City           string    `json:"city"`
-		PostalCode     string    `json:"postal_code"`
-		Specialization string    `json:"specialization"`
-		LicenseNumber  string    `json:"license_number"`
+		UserID         uuid.UUID           `json:"user_id"`
+		UserName       string              `json:"username"`
+		Email          string              `json:"email"`
+		FirstName      string              `json:"firstname"`
+		LastName       string              `json:"lastname"`
+		DateOfBirth    string              `json:"date_of_birth"`
+		Age            int                 `json:"age"`
+		Gender         store.Gender        `json:"gender"`
+		MaritalStatus  store.MaritalStatus `json:"marital_status"`
+		Designation    string              `json:"designation"`
+		Qualification  string              `json:"qualification"`
+		BloodGroup     store.BloodGroup    `json:"blood_group"`
+		Address        string              `json:"address"`
+		Country        string              `json:"country"`
+		State          string              `json:"state"`
+		City           string              `json:"city"`
+		PostalCode     string              `json:"postal_code"`
+		Specialization string              `json:"specialization"`
+		LicenseNumber  string              `json:"license_number"`
 	}{
 		UserID:         doctor.UserID,
 		UserName:       doctor.UserName,
 		Email:          doctor.Email,
 		FirstName:      doctor.FirstName,
 		LastName:       doctor.LastName,
+		DateOfBirth:    doctor.DateOfBirth,
 		Age:            doctor.Age,
 		Gender:         doctor.Gender,
 		MaritalStatus:  doctor.MaritalStatus,
@@ -270,7 +280,26 @@ func (app *application) getAllDoctorsHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	for _, doctor := range doctors {
+		app.setDoctorPhotoURLs(doctor)
+	}
+
 	if err := app.jsonResponse(w, http.StatusOK, doctors); err != nil {
 		app.internalServerError(w, r, err)
 	}
 }
+
+// validateDateOfBirth rejects dates of birth in the future. The format itself
+// is checked by the payload's validate tag.
+func validateDateOfBirth(value string) error {
+	dob, err := time.Parse(store.DateLayout, value)
+	if err != nil {
+		return err
+	}
+
+	if dob.After(time.Now()) {
+		return errors.New("date_of_birth cannot be in the future")
+	}
+
+	return nil
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type encounterKey string
+
+const encounterCtx encounterKey = "encounter"
+
+var (
+	errAppointmentNotScheduled = errors.New("appointment is not scheduled")
+	errAppointmentNotDue       = errors.New("appointment is not due yet")
+	errEncounterSigned         = errors.New("encounter is signed; add an addendum instead")
+	errEncounterNotSigned      = errors.New("encounter is not signed yet; edit the note instead")
+	errUnknownICD10Code        = errors.New("unknown ICD-10 code")
+)
+
+// completeAppointmentHandler godoc
+//
+//	@Summary		Completes an appointment
+//	@Description	Marks a scheduled appointment as completed and opens a draft encounter for its SOAP note. Only the appointment's doctor can complete it, from the day of the appointment.
+//	@Tags			appointment
+//	@Produce		json
+//	@Param			appointmentID	path		string	true	"Appointment ID"
+//	@Success		201				{object}	store.Encounter
+//	@Failure		403				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		409				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/appointments/{appointmentID}/complete [put]
+func (app *application) completeAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	appointment := getAppointmentFromCtx(r)
+
+	if appointment.DoctorID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if appointment.Status != store.AppointmentScheduled {
+		app.conflictResponse(w, r, errAppointmentNotScheduled)
+		return
+	}
+
+	// completing makes the doctor a treating doctor, so a booking for a
+	// later day cannot be used to read the record ahead of the visit
+	if appointment.AppointmentTime.UTC().Format(store.DateLayout) > time.Now().UTC().Format(store.DateLayout) {
+		app.conflictResponse(w, r, errAppointmentNotDue)
+		return
+	}
+
+	encounter, err := app.store.Encounters.CreateFromAppointment(r.Context(), appointment.ID, user.ID)
+	if err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errAppointmentNotScheduled)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.chargeConsultation(r.Context(), appointment, user.ID)
+
+	if err := app.jsonResponse(w, http.StatusCreated, encounter); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPatientEncountersHandler godoc
+//
+//	@Summary		Fetches a patient's encounters
+//	@Description	Fetches the encounters of a patient, newest first. Readable by the patient and their treating doctors; drafts are only listed for their author.
+//	@Tags			encounter
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Success		200			{array}		store.Encounter
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/encounters [get]
+func (app *application) getPatientEncountersHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+	ctx := r.Context()
+
+	if !user.ActsFor(patient.UserID) {
+		treating, err := app.store.Encounters.IsTreatingDoctor(ctx, user.ID, patient.UserID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !treating {
+			app.forbiddenResponse(w, r)
+			return
+		}
+	}
+
+	encounters, err := app.store.Encounters.GetByPatient(ctx, patient.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	visible := make([]*store.Encounter, 0, len(encounters))
+	for _, encounter := range encounters {
+		if encounter.Status == store.EncounterSigned || encounter.DoctorID == user.ID {
+			visible = append(visible, encounter)
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, visible); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getEncounterHandler godoc
+//
+//	@Summary		Fetches an encounter
+//	@Description	Fetches an encounter with its SOAP note and addenda
+//	@Tags			encounter
+//	@Produce		json
+//	@Param			encounterID	path		string	true	"Encounter ID"
+//	@Success		200			{object}	store.Encounter
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/encounters/{encounterID} [get]
+func (app *application) getEncounterHandler(w http.ResponseWriter, r *http.Request) {
+	encounter := getEncounterFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, encounter); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type UpdateEncounterNotePayload struct {
+	Subjective string `json:"subjective" validate:"max=20000"`
+	Objective  string `json:"objective" validate:"max=20000"`
+	Assessment string `json:"assessment" validate:"max=20000"`
+	Plan       string `json:"plan" validate:"max=20000"`
+}
+
+// updateEncounterNoteHandler godoc
+//
+//	@Summary		Updates an encounter's SOAP note
+//	@Description	Replaces the SOAP note of a draft encounter. Only the encounter's doctor can edit it, and only until it is signed.
+//	@Tags			encounter
+//	@Accept			json
+//	@Produce		json
+//	@Param			encounterID	path		string						true	"Encounter ID"
+//	@Param			payload		body		UpdateEncounterNotePayload	true	"SOAP note"
+//	@Success		200			{object}	store.Encounter
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/encounters/{encounterID} [put]
+func (app *application) updateEncounterNoteHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	encounter := getEncounterFromCtx(r)
+
+	if encounter.DoctorID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload UpdateEncounterNotePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	encounter.Subjective = payload.Subjective
+	encounter.Objective = payload.Objective
+	encounter.Assessment = payload.Assessment
+	encounter.Plan = payload.Plan
+
+	if err := app.store.Encounters.UpdateNote(r.Context(), encounter); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errEncounterSigned)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, encounter); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// signEncounterHandler godoc
+//
+//	@Summary		Signs an encounter
+//	@Description	Signs the encounter's SOAP note. Signed notes are locked; corrections are made with addenda.
+//	@Tags			encounter
+//	@Produce		json
+//	@Param			encounterID	path		string	true	"Encounter ID"
+//	@Success		200			{object}	store.Encounter
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/encounters/{encounterID}/sign [post]
+func (app *application) signEncounterHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	encounter := getEncounterFromCtx(r)
+
+	if encounter.DoctorID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Encounters.Sign(r.Context(), encounter); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errEncounterSigned)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, encounter); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type SetEncounterDiagnosesPayload struct {
+	Primary   string   `json:"primary" validate:"max=8"`
+	Secondary []string `json:"secondary" validate:"max=20,dive,required,max=8"`
+}
+
+// setEncounterDiagnosesHandler godoc
+//
+//	@Summary		Sets an encounter's diagnoses
+//	@Description	Replaces the primary and secondary ICD-10 diagnoses of a draft encounter. Only the encounter's doctor can set them, and only until it is signed.
+//	@Tags			encounter
+//	@Accept			json
+//	@Produce		json
+//	@Param			encounterID	path		string							true	"Encounter ID"
+//	@Param			payload		body		SetEncounterDiagnosesPayload	true	"Diagnoses"
+//	@Success		200			{object}	store.Encounter
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/encounters/{encounterID}/diagnoses [put]
+func (app *application) setEncounterDiagnosesHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	encounter := getEncounterFromCtx(r)
+
+	if encounter.DoctorID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload SetEncounterDiagnosesPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.Primary == "" && len(payload.Secondary) > 0 {
+		app.badRequestResponse(w, r, errors.New("secondary diagnoses need a primary diagnosis"))
+		return
+	}
+
+	diagnoses := []store.EncounterDiagnosis{}
+	seen := map[string]bool{}
+	add := func(code string, rank store.DiagnosisRank) error {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if seen[code] {
+			return fmt.Errorf("diagnosis %s is listed more than once", code)
+		}
+		seen[code] = true
+		diagnoses = append(diagnoses, store.EncounterDiagnosis{Code: code, Rank: rank})
+		return nil
+	}
+
+	if payload.Primary != "" {
+		_ = add(payload.Primary, store.DiagnosisPrimary)
+	}
+
+	for _, code := range payload.Secondary {
+		if err := add(code, store.DiagnosisSecondary); err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	if err := app.store.Encounters.SetDiagnoses(ctx, encounter.ID, diagnoses); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errEncounterSigned)
+		case store.ErrNotFound:
+			app.badRequestResponse(w, r, errUnknownICD10Code)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	updated, err := app.store.Encounters.GetByID(ctx, encounter.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, updated); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type CreateEncounterAddendumPayload struct {
+	Body string `json:"body" validate:"required,max=20000"`
+}
+
+// addEncounterAddendumHandler godoc
+//
+//	@Summary		Adds an addendum to an encounter
+//	@Description	Appends a correction to a signed encounter. Addenda cannot be edited or removed. Only the patient's treating doctors can add them.
+//	@Tags			encounter
+//	@Accept			json
+//	@Produce		json
+//	@Param			encounterID	path		string							true	"Encounter ID"
+//	@Param			payload		body		CreateEncounterAddendumPayload	true	"Addendum"
+//	@Success		201			{object}	store.EncounterAddendum
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/encounters/{encounterID}/addenda [post]
+func (app *application) addEncounterAddendumHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	encounter := getEncounterFromCtx(r)
+
+	// the patient can read the encounter but not write to it
+	if user.ActsFor(encounter.PatientID) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if encounter.Status != store.EncounterSigned {
+		app.conflictResponse(w, r, errEncounterNotSigned)
+		return
+	}
+
+	var payload CreateEncounterAddendumPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	addendum := &store.EncounterAddendum{
+		EncounterID: encounter.ID,
+		AuthorID:    user.ID,
+		Body:        payload.Body,
+	}
+
+	if err := app.store.Encounters.AddAddendum(r.Context(), addendum); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errEncounterNotSigned)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, addendum); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// canReadEncounter reports whether the authenticated user may read the
+// encounter: its doctor, or once signed, the patient and the patient's
+// other treating doctors.
+func (app *application) canReadEncounter(r *http.Request, encounter *store.Encounter) (bool, error) {
+	user := getUserFromContext(r)
+	if user == nil {
+		return false, nil
+	}
+
+	if user.ID == encounter.DoctorID {
+		return true, nil
+	}
+
+	if encounter.Status != store.EncounterSigned {
+		return false, nil
+	}
+
+	if user.ActsFor(encounter.PatientID) {
+		return true, nil
+	}
+
+	return app.store.Encounters.IsTreatingDoctor(r.Context(), user.ID, encounter.PatientID)
+}
+
+func (app *application) encounterContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "encounterID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		encounter, err := app.store.Encounters.GetByID(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		allowed, err := app.canReadEncounter(r, encounter)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		ctx = context.WithValue(ctx, encounterCtx, encounter)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getEncounterFromCtx(r *http.Request) *store.Encounter {
+	encounter, _ := r.Context().Value(encounterCtx).(*store.Encounter)
+	return encounter
+}
@@ -0,0 +1,646 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/mrn"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type erVisitKey string
+
+const erVisitCtx erVisitKey = "erVisit"
+
+const (
+	// erStreamRefresh is how often the board is re-sent on the live feed,
+	// which keeps wait times current and the connection alive.
+	erStreamRefresh = 15 * time.Second
+	// erStreamDuration ends each feed connection before the request
+	// timeout; the screen reconnects after erStreamRetry.
+	erStreamDuration = 50 * time.Second
+	erStreamRetry    = 2 * time.Second
+)
+
+var (
+	errAlreadyInER       = errors.New("patient is already in the emergency department")
+	errERVisitDeparted   = errors.New("patient has left the emergency department")
+	errERVisitNotWaiting = errors.New("patient is not waiting to be seen")
+)
+
+type RegisterERArrivalPayload struct {
+	// PatientID or PatientMRN link the arrival to a registered patient.
+	// Unidentified arrivals are registered by name and linked later.
+	PatientID      uuid.UUID `json:"patient_id"`
+	PatientMRN     string    `json:"patient_mrn" validate:"max=40"`
+	PatientName    string    `json:"patient_name" validate:"required_without_all=PatientID PatientMRN,max=200"`
+	Acuity         int       `json:"acuity" validate:"required,min=1,max=5"`
+	ChiefComplaint string    `json:"chief_complaint" validate:"required,max=1000"`
+}
+
+// registerERArrivalHandler godoc
+//
+//	@Summary		Registers an emergency arrival
+//	@Description	Adds an arrival to the emergency department queue with a triage acuity from 1, resuscitation, to 5, non-urgent. The patient is given by ID or MRN, or only by name when not yet identified. Nurses only.
+//	@Tags			er
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		RegisterERArrivalPayload	true	"Arrival"
+//	@Success		201		{object}	store.ERVisit
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/er/visits [post]
+func (app *application) registerERArrivalHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	var payload RegisterERArrivalPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.PatientName = strings.TrimSpace(payload.PatientName)
+	payload.ChiefComplaint = strings.TrimSpace(payload.ChiefComplaint)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	visit := &store.ERVisit{
+		PatientName:    payload.PatientName,
+		Acuity:         payload.Acuity,
+		ChiefComplaint: payload.ChiefComplaint,
+		TriagedBy:      &user.ID,
+	}
+
+	if err := app.linkERPatient(r.Context(), visit, payload.PatientID, payload.PatientMRN); err != nil {
+		switch {
+		case errors.Is(err, mrn.ErrCheckDigit), errors.Is(err, store.ErrNotFound):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.store.ER.Register(r.Context(), visit); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errAlreadyInER)
+		case store.ErrNotFound:
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.erFeed.publish(erEvent{Name: "board"})
+
+	if err := app.jsonResponse(w, http.StatusCreated, visit); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// linkERPatient links the visit to the patient given by ID or MRN, if any,
+// and names the visit after them unless a name was given.
+func (app *application) linkERPatient(ctx context.Context, visit *store.ERVisit, id uuid.UUID, patientMRN string) error {
+	ref := patientMRN
+	if id != uuid.Nil {
+		ref = id.String()
+	}
+	if ref == "" {
+		return nil
+	}
+
+	patient, err := app.admissionPatient(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	visit.PatientID = &patient.UserID
+	if visit.PatientName == "" {
+		visit.PatientName = strings.TrimSpace(patient.FirstName + " " + patient.LastName)
+	}
+
+	return nil
+}
+
+// getERBoardHandler godoc
+//
+//	@Summary		Shows the emergency department board
+//	@Description	Lists the patients waiting and in treatment, each ordered by acuity and then by arrival, with their wait and whether it is over the target for their level. Staff only.
+//	@Tags			er
+//	@Produce		json
+//	@Success		200	{object}	store.ERBoard
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/er/board [get]
+func (app *application) getERBoardHandler(w http.ResponseWriter, r *http.Request) {
+	board, err := app.store.ER.GetBoard(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, board); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// streamERBoardHandler godoc
+//
+//	@Summary		Streams the emergency department board
+//	@Description	Server-sent events for the ER screen. A "board" event carries the whole board, sent on connect, on every change and every 15 seconds. An "alert" event carries a visit that just went over its target wait. The stream ends after about a minute and asks the screen to reconnect. Staff only.
+//	@Tags			er
+//	@Produce		text/event-stream
+//	@Success		200	{object}	store.ERBoard
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/er/board/stream [get]
+func (app *application) streamERBoardHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+
+	// the server's write timeout would cut the stream short
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	events := app.erFeed.subscribe()
+	defer app.erFeed.unsubscribe(events)
+
+	board, err := app.store.ER.GetBoard(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", erStreamRetry.Milliseconds()); err != nil {
+		return
+	}
+	if err := writeSSE(rc, w, "board", board); err != nil {
+		return
+	}
+
+	refresh := time.NewTicker(erStreamRefresh)
+	defer refresh.Stop()
+
+	end := time.NewTimer(erStreamDuration)
+	defer end.Stop()
+
+	for {
+		var event erEvent
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-end.C:
+			return
+		case <-refresh.C:
+			event = erEvent{Name: "board"}
+		case event = <-events:
+		}
+
+		if event.Name == "board" {
+			board, err := app.store.ER.GetBoard(ctx)
+			if err != nil {
+				app.logger.Errorw("error refreshing er board", "error", err)
+				continue
+			}
+			event.Data = board
+		}
+
+		if err := writeSSE(rc, w, event.Name, event.Data); err != nil {
+			return
+		}
+	}
+}
+
+// writeSSE sends one server-sent event and flushes it to the client.
+func writeSSE(rc *http.ResponseController, w http.ResponseWriter, name string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, body); err != nil {
+		return err
+	}
+
+	return rc.Flush()
+}
+
+// getERAlertsHandler godoc
+//
+//	@Summary		Lists emergency wait breaches
+//	@Description	Lists the patients still waiting past the target wait for their acuity, most urgent first. Staff only.
+//	@Tags			er
+//	@Produce		json
+//	@Success		200	{array}		store.ERVisit
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/er/alerts [get]
+func (app *application) getERAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	board, err := app.store.ER.GetBoard(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	breaches := []store.ERVisit{}
+	for _, visit := range board.Waiting {
+		if visit.Breached {
+			breaches = append(breaches, visit)
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, breaches); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getERVisitHandler godoc
+//
+//	@Summary	Fetches an emergency visit
+//	@Tags		er
+//	@Produce	json
+//	@Param		visitID	path		string	true	"Visit ID"
+//	@Success	200		{object}	store.ERVisit
+//	@Failure	400		{object}	error
+//	@Failure	403		{object}	error
+//	@Failure	404		{object}	error
+//	@Failure	500		{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/er/visits/{visitID} [get]
+func (app *application) getERVisitHandler(w http.ResponseWriter, r *http.Request) {
+	visit := getERVisitFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, visit); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type RetriageERVisitPayload struct {
+	PatientID      uuid.UUID `json:"patient_id"`
+	PatientMRN     string    `json:"patient_mrn" validate:"max=40"`
+	PatientName    *string   `json:"patient_name" validate:"omitempty,max=200"`
+	Acuity         *int      `json:"acuity" validate:"omitempty,min=1,max=5"`
+	ChiefComplaint *string   `json:"chief_complaint" validate:"omitempty,max=1000"`
+}
+
+// retriageERVisitHandler godoc
+//
+//	@Summary		Re-triages an emergency visit
+//	@Description	Changes the acuity or chief complaint of a patient still in the department, or links an unidentified arrival to a patient by ID or MRN. A new acuity is held to its own target wait. Nurses only.
+//	@Tags			er
+//	@Accept			json
+//	@Produce		json
+//	@Param			visitID	path		string					true	"Visit ID"
+//	@Param			payload	body		RetriageERVisitPayload	true	"Triage"
+//	@Success		200		{object}	store.ERVisit
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/er/visits/{visitID} [put]
+func (app *application) retriageERVisitHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	visit := getERVisitFromCtx(r)
+
+	var payload RetriageERVisitPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if visit.Status == store.ERDeparted {
+		app.conflictResponse(w, r, errERVisitDeparted)
+		return
+	}
+
+	if payload.PatientName != nil {
+		visit.PatientName = strings.TrimSpace(*payload.PatientName)
+	}
+	if payload.Acuity != nil {
+		visit.Acuity = *payload.Acuity
+	}
+	if payload.ChiefComplaint != nil {
+		if strings.TrimSpace(*payload.ChiefComplaint) == "" {
+			app.badRequestResponse(w, r, errors.New("chief_complaint cannot be empty"))
+			return
+		}
+		visit.ChiefComplaint = strings.TrimSpace(*payload.ChiefComplaint)
+	}
+
+	if payload.PatientID != uuid.Nil || payload.PatientMRN != "" {
+		if payload.PatientName == nil {
+			visit.PatientName = ""
+		}
+
+		if err := app.linkERPatient(r.Context(), visit, payload.PatientID, payload.PatientMRN); err != nil {
+			switch {
+			case errors.Is(err, mrn.ErrCheckDigit), errors.Is(err, store.ErrNotFound):
+				app.badRequestResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+	}
+
+	if visit.PatientName == "" {
+		app.badRequestResponse(w, r, errors.New("patient_name cannot be empty"))
+		return
+	}
+
+	visit.TriagedBy = &user.ID
+
+	if err := app.store.ER.Retriage(r.Context(), visit); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errAlreadyInER)
+		case store.ErrNotFound:
+			app.badRequestResponse(w, r, err)
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errERVisitDeparted)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.erFeed.publish(erEvent{Name: "board"})
+
+	if err := app.jsonResponse(w, http.StatusOK, visit); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// startERTreatmentHandler godoc
+//
+//	@Summary		Starts treating an emergency patient
+//	@Description	Moves a waiting patient to treatment, which stops their wait. Doctors and nurses only.
+//	@Tags			er
+//	@Produce		json
+//	@Param			visitID	path		string	true	"Visit ID"
+//	@Success		200		{object}	store.ERVisit
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/er/visits/{visitID}/start [post]
+func (app *application) startERTreatmentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	visit := getERVisitFromCtx(r)
+
+	if !isClinician(user) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.ER.StartTreatment(r.Context(), visit, user.ID); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errERVisitNotWaiting)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.erFeed.publish(erEvent{Name: "board"})
+
+	if err := app.jsonResponse(w, http.StatusOK, visit); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type DepartERVisitPayload struct {
+	Disposition store.ERDisposition `json:"disposition" validate:"required,oneof=discharged admitted transferred left_without_being_seen deceased"`
+}
+
+// departERVisitHandler godoc
+//
+//	@Summary		Records an emergency departure
+//	@Description	Takes the patient off the board with how they left the department. Doctors and nurses only.
+//	@Tags			er
+//	@Accept			json
+//	@Produce		json
+//	@Param			visitID	path		string					true	"Visit ID"
+//	@Param			payload	body		DepartERVisitPayload	true	"Disposition"
+//	@Success		200		{object}	store.ERVisit
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/er/visits/{visitID}/depart [post]
+func (app *application) departERVisitHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	visit := getERVisitFromCtx(r)
+
+	if !isClinician(user) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload DepartERVisitPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.ER.Depart(r.Context(), visit, payload.Disposition); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errERVisitDeparted)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.erFeed.publish(erEvent{Name: "board"})
+
+	if err := app.jsonResponse(w, http.StatusOK, visit); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getERWaitTargetsHandler godoc
+//
+//	@Summary	Lists the emergency wait targets
+//	@Tags		er
+//	@Produce	json
+//	@Success	200	{array}		store.ERWaitTarget
+//	@Failure	403	{object}	error
+//	@Failure	500	{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/er/wait-targets [get]
+func (app *application) getERWaitTargetsHandler(w http.ResponseWriter, r *http.Request) {
+	targets, err := app.store.ER.GetWaitTargets(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, targets); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type SetERWaitTargetPayload struct {
+	MaxWaitMinutes *int `json:"max_wait_minutes" validate:"required,min=0,max=1440"`
+}
+
+// setERWaitTargetHandler godoc
+//
+//	@Summary		Sets an emergency wait target
+//	@Description	Sets the longest a patient at an acuity level may wait to be seen before an alert is raised. Admin only.
+//	@Tags			er
+//	@Accept			json
+//	@Produce		json
+//	@Param			acuity	path		int						true	"Acuity, 1 to 5"
+//	@Param			payload	body		SetERWaitTargetPayload	true	"Target"
+//	@Success		200		{object}	store.ERWaitTarget
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/er/wait-targets/{acuity} [put]
+func (app *application) setERWaitTargetHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	acuity, err := strconv.Atoi(chi.URLParam(r, "acuity"))
+	if err != nil || acuity < 1 || acuity > 5 {
+		app.badRequestResponse(w, r, errors.New("acuity must be from 1 to 5"))
+		return
+	}
+
+	var payload SetERWaitTargetPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	target := &store.ERWaitTarget{
+		Acuity:         acuity,
+		MaxWaitMinutes: *payload.MaxWaitMinutes,
+		UpdatedBy:      &user.ID,
+	}
+
+	if err := app.store.ER.SetWaitTarget(r.Context(), target); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.erFeed.publish(erEvent{Name: "board"})
+
+	if err := app.jsonResponse(w, http.StatusOK, target); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// runERBreachMonitor raises an alert for each patient who goes over the
+// target wait for their acuity. Alerts are logged and pushed to the ER
+// screens.
+func (app *application) runERBreachMonitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		breaches, err := app.store.ER.ClaimBreaches(context.Background())
+		if err != nil {
+			app.logger.Errorw("error checking er wait times", "error", err)
+			continue
+		}
+
+		for _, visit := range breaches {
+			app.logger.Warnw("er wait target breached",
+				"visit", visit.ID,
+				"acuity", visit.Acuity,
+				"wait_minutes", visit.WaitMinutes,
+				"max_wait_minutes", visit.MaxWaitMinutes,
+			)
+			app.erFeed.publish(erEvent{Name: "alert", Data: visit})
+		}
+
+		if len(breaches) > 0 {
+			app.erFeed.publish(erEvent{Name: "board"})
+		}
+	}
+}
+
+func (app *application) erVisitContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "visitID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		visit, err := app.store.ER.GetByID(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, erVisitCtx, visit)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getERVisitFromCtx(r *http.Request) *store.ERVisit {
+	visit, _ := r.Context().Value(erVisitCtx).(*store.ERVisit)
+	return visit
+}
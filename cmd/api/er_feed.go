@@ -0,0 +1,53 @@
+package main
+
+import "sync"
+
+// erEvent is pushed to the emergency department screens. A "board" event
+// tells them the board changed; an "alert" event carries a visit that went
+// over its target wait.
+type erEvent struct {
+	Name string
+	Data any
+}
+
+// erFeed fans emergency department events out to the connected screens.
+// It is in-process, so each server only feeds the screens connected to it;
+// screens on other servers catch up with the periodic board refresh.
+type erFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan erEvent]struct{}
+}
+
+func newERFeed() *erFeed {
+	return &erFeed{subscribers: make(map[chan erEvent]struct{})}
+}
+
+func (f *erFeed) subscribe() chan erEvent {
+	ch := make(chan erEvent, 16)
+
+	f.mu.Lock()
+	f.subscribers[ch] = struct{}{}
+	f.mu.Unlock()
+
+	return ch
+}
+
+func (f *erFeed) unsubscribe(ch chan erEvent) {
+	f.mu.Lock()
+	delete(f.subscribers, ch)
+	f.mu.Unlock()
+}
+
+// publish never blocks: a screen too slow to keep up misses the event and
+// picks the change up with the next board it is sent.
+func (f *erFeed) publish(event erEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
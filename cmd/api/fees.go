@@ -0,0 +1,163 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/store"
+)
+
+type CreateDoctorFeePayload struct {
+	VisitType        store.VisitType        `json:"visit_type" validate:"required,oneof=new_patient follow_up"`
+	ConsultationMode store.ConsultationMode `json:"consultation_mode" validate:"required,oneof=in_person online"`
+	Amount           int64                  `json:"amount" validate:"gte=0"`
+	Currency         string                 `json:"currency" validate:"required,iso4217"`
+	EffectiveFrom    string                 `json:"effective_from" validate:"required,datetime=2006-01-02"`
+}
+
+// createDoctorFeeHandler godoc
+//
+//	@Summary		Adds a doctor fee
+//	@Description	Adds a new fee version for a visit type and consultation mode. Earlier versions are kept as history.
+//	@Tags			doctor
+//	@Accept			json
+//	@Produce		json
+//	@Param			doctorID	path		string					true	"Doctor ID"
+//	@Param			payload		body		CreateDoctorFeePayload	true	"Fee details, amount in minor currency units"
+//	@Success		201			{object}	store.DoctorFee
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/doctors/{doctorID}/fees [post]
+func (app *application) createDoctorFeeHandler(w http.ResponseWriter, r *http.Request) {
+	doctor := getDoctorFromCtx(r)
+
+	var payload CreateDoctorFeePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	fee := &store.DoctorFee{
+		DoctorID:         doctor.UserID,
+		VisitType:        payload.VisitType,
+		ConsultationMode: payload.ConsultationMode,
+		Amount:           payload.Amount,
+		Currency:         payload.Currency,
+		EffectiveFrom:    payload.EffectiveFrom,
+	}
+
+	if err := app.store.Fees.Create(r.Context(), fee); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, fee); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getDoctorFeesHandler godoc
+//
+//	@Summary		Fetches a doctor's fee history
+//	@Description	Fetches every fee version of a doctor, newest first per visit type and consultation mode
+//	@Tags			doctor
+//	@Produce		json
+//	@Param			doctorID	path		string	true	"Doctor ID"
+//	@Success		200			{array}		store.DoctorFee
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/doctors/{doctorID}/fees [get]
+func (app *application) getDoctorFeesHandler(w http.ResponseWriter, r *http.Request) {
+	doctor := getDoctorFromCtx(r)
+
+	fees, err := app.store.Fees.GetByDoctor(r.Context(), doctor.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, fees); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getEffectiveDoctorFeeHandler godoc
+//
+//	@Summary		Fetches the fee for a visit
+//	@Description	Fetches the fee that applies to a visit of the given type and mode on a date (today by default)
+//	@Tags			doctor
+//	@Produce		json
+//	@Param			doctorID			path		string	true	"Doctor ID"
+//	@Param			visit_type			query		string	false	"new_patient (default) or follow_up"
+//	@Param			consultation_mode	query		string	false	"in_person (default) or online"
+//	@Param			date				query		string	false	"Visit date, YYYY-MM-DD"
+//	@Success		200					{object}	store.DoctorFee
+//	@Failure		400					{object}	error
+//	@Failure		404					{object}	error
+//	@Failure		500					{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/doctors/{doctorID}/fees/effective [get]
+func (app *application) getEffectiveDoctorFeeHandler(w http.ResponseWriter, r *http.Request) {
+	doctor := getDoctorFromCtx(r)
+	qs := r.URL.Query()
+
+	visitType := store.VisitType(qs.Get("visit_type"))
+	if visitType == "" {
+		visitType = store.VisitTypeNewPatient
+	}
+
+	mode := store.ConsultationMode(qs.Get("consultation_mode"))
+	if mode == "" {
+		mode = store.ConsultationModeInPerson
+	}
+
+	if err := Validate.Var(string(visitType), "oneof=new_patient follow_up"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Var(string(mode), "oneof=in_person online"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	at := time.Now()
+	if date := qs.Get("date"); date != "" {
+		parsed, err := time.Parse(store.DateLayout, date)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		at = parsed
+	}
+
+	fee, err := app.store.Fees.GetEffective(r.Context(), doctor.UserID, visitType, mode, at)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, fee); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
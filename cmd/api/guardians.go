@@ -0,0 +1,483 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/mailer"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	errNotDependent       = errors.New("patient already has an account of their own")
+	errUnderAgeOfMajority = errors.New("patient has not reached the age of majority")
+	errLastGuardian       = errors.New("a dependent must keep at least one guardian")
+	errNoPendingUpgrade   = errors.New("patient has no pending upgrade")
+)
+
+type CreateDependentPayload struct {
+	Relationship store.GuardianRelationship `json:"relationship" validate:"required,oneof=parent legal_guardian spouse child sibling caregiver other"`
+	// GuardianID lets staff register a dependent for someone else; it
+	// defaults to the caller
+	GuardianID *uuid.UUID `json:"guardian_id"`
+	PatientProfilePayload
+}
+
+// createDependentHandler godoc
+//
+//	@Summary		Registers a dependent
+//	@Description	Creates a patient profile without a login of its own, managed by the caller. Staff may name another guardian.
+//	@Tags			guardian
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateDependentPayload	true	"Dependent profile"
+//	@Success		201		{object}	store.Patient
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/dependents [post]
+func (app *application) createDependentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	var payload CreateDependentPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validateDateOfBirth(payload.DateOfBirth); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	guardianID := user.ID
+	if payload.GuardianID != nil && *payload.GuardianID != user.ID {
+		staff, err := app.checkRolePrecedence(ctx, user, "doctor")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !staff {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		if _, err := app.store.Users.GetByID(ctx, *payload.GuardianID); err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.badRequestResponse(w, r, errors.New("guardian not found"))
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		guardianID = *payload.GuardianID
+	}
+
+	patient := &store.Patient{
+		Username: newDependentUsername(),
+	}
+	payload.PatientProfilePayload.apply(patient)
+
+	issueMRN := func(seq int64) string {
+		return app.mrn.Format(time.Now().Year(), seq)
+	}
+
+	if err := app.store.Guardians.CreateDependent(ctx, patient, guardianID, payload.Relationship, issueMRN); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, patient); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getDependentsHandler godoc
+//
+//	@Summary		Lists the caller's dependents
+//	@Description	Lists the patients the caller is a guardian of
+//	@Tags			guardian
+//	@Produce		json
+//	@Success		200	{array}		store.Patient
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/dependents [get]
+func (app *application) getDependentsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	dependents, err := app.store.Guardians.GetDependents(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, dependents); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPatientGuardiansHandler godoc
+//
+//	@Summary	Lists a patient's guardians
+//	@Tags		guardian
+//	@Produce	json
+//	@Param		patientID	path		string	true	"Patient ID or MRN"
+//	@Success	200			{array}		store.Guardian
+//	@Failure	403			{object}	error
+//	@Failure	404			{object}	error
+//	@Failure	500			{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/patients/{patientID}/guardians [get]
+func (app *application) getPatientGuardiansHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	guardians, err := app.store.Guardians.GetGuardians(r.Context(), patient.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, guardians); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type AddGuardianPayload struct {
+	Email        string                     `json:"email" validate:"required,email,max=255"`
+	Relationship store.GuardianRelationship `json:"relationship" validate:"required,oneof=parent legal_guardian spouse child sibling caregiver other"`
+}
+
+// addPatientGuardianHandler godoc
+//
+//	@Summary		Adds a guardian
+//	@Description	Makes the active account with the given email a guardian of the patient, for example a second parent. An independent patient, a guardian of a dependent and admins may do this; admins cannot name themselves.
+//	@Tags			guardian
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string				true	"Patient ID or MRN"
+//	@Param			payload		body		AddGuardianPayload	true	"Guardian"
+//	@Success		201			{array}		store.Guardian
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/guardians [post]
+func (app *application) addPatientGuardianHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	// a guardian can read the record, so staff access alone is not enough
+	actsFor := user.ID == patient.UserID || (patient.Dependent && user.ActsFor(patient.UserID))
+	if !actsFor {
+		admin, err := app.checkRolePrecedence(ctx, user, "admin")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !admin {
+			app.forbiddenResponse(w, r)
+			return
+		}
+	}
+
+	var payload AddGuardianPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	guardian, err := app.store.Users.GetByEmail(ctx, payload.Email)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if guardian.ID == user.ID && !actsFor {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Guardians.AddGuardian(ctx, patient.UserID, guardian.ID, payload.Relationship); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	guardians, err := app.store.Guardians.GetGuardians(ctx, patient.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, guardians); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// removePatientGuardianHandler godoc
+//
+//	@Summary		Removes a guardian
+//	@Description	Unlinks a guardian from the patient. Staff, an independent patient and the guardian themselves may do this. A dependent keeps at least one guardian.
+//	@Tags			guardian
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Param			guardianID	path		string	true	"Guardian user ID"
+//	@Success		204			{string}	string	"Guardian removed"
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/guardians/{guardianID} [delete]
+func (app *application) removePatientGuardianHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+	user := getUserFromContext(r)
+
+	guardianID, err := uuid.Parse(chi.URLParam(r, "guardianID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	allowed := guardianID == user.ID || (user.ID == patient.UserID && !patient.Dependent)
+	if !allowed {
+		allowed, err = app.checkRolePrecedence(ctx, user, "doctor")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Guardians.RemoveGuardian(ctx, patient.UserID, guardianID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errLastGuardian)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newDependentUsername names a dependent's account. Dependents never sign
+// in, the username only has to be unique.
+func newDependentUsername() string {
+	return "dependent-" + strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+}
+
+// canManageDependent reports whether the user may upgrade the dependent:
+// one of their guardians or an admin.
+func (app *application) canManageDependent(r *http.Request, patient *store.Patient) (bool, error) {
+	user := getUserFromContext(r)
+	if user.ActsFor(patient.UserID) {
+		return true, nil
+	}
+
+	return app.checkRolePrecedence(r.Context(), user, "admin")
+}
+
+type UpgradeDependentPayload struct {
+	Username string `json:"username" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+}
+
+// upgradeDependentHandler godoc
+//
+//	@Summary		Upgrades a dependent to an independent account
+//	@Description	Invites a dependent who has reached the age of majority to a login of their own. The patient activates it from the email sent to them, setting their own password, which unlinks their guardians; until then they stay a dependent with upgrade_pending set. Upgrading a pending dependent again resends the invitation, to the email given. Their guardians and admins may do this.
+//	@Tags			guardian
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string					true	"Patient ID or MRN"
+//	@Param			payload		body		UpgradeDependentPayload	true	"Login details"
+//	@Success		200			{object}	store.Patient
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/upgrade [post]
+func (app *application) upgradeDependentHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+	ctx := r.Context()
+
+	allowed, err := app.canManageDependent(r, patient)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if !patient.Dependent {
+		app.conflictResponse(w, r, errNotDependent)
+		return
+	}
+
+	if patient.Age < app.config.patients.ageOfMajority {
+		app.conflictResponse(w, r, errUnderAgeOfMajority)
+		return
+	}
+
+	var payload UpgradeDependentPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	account := &store.User{
+		ID:       patient.UserID,
+		Username: payload.Username,
+		Email:    payload.Email,
+	}
+
+	plainToken := uuid.New().String()
+
+	// hash the token for storage but keep the plain token for email
+	hash := sha256.Sum256([]byte(plainToken))
+	hashToken := hex.EncodeToString(hash[:])
+
+	if err := app.store.Guardians.Upgrade(ctx, account, hashToken, app.config.mail.exp); err != nil {
+		switch err {
+		case store.ErrDuplicateEmail, store.ErrDuplicateUsername:
+			app.badRequestResponse(w, r, err)
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errNotDependent)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	isProdEnv := app.config.env == "production"
+	vars := struct {
+		Username      string
+		ActivationURL string
+	}{
+		Username:      account.Username,
+		ActivationURL: fmt.Sprintf("%s/confirm/%s", app.config.frontendURL, plainToken),
+	}
+
+	// the token only goes to the patient, so the guardian cannot activate
+	// the account themselves
+	if _, err := app.mailer.Send(mailer.UserWelcomeTemplate, account.Username, account.Email, vars, !isProdEnv); err != nil {
+		app.logger.Errorw("error sending upgrade invitation", "error", err)
+
+		if err := app.store.Guardians.CancelUpgrade(ctx, patient.UserID, newDependentUsername()); err != nil {
+			app.logger.Errorw("error cancelling dependent upgrade", "error", err)
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	patient.Username = account.Username
+	patient.Email = account.Email
+	patient.UpgradePending = true
+
+	if err := app.jsonResponse(w, http.StatusOK, patient); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// cancelUpgradeHandler godoc
+//
+//	@Summary		Cancels a dependent's upgrade
+//	@Description	Withdraws the invitation of a dependent who has not activated their login yet, for example when it went to the wrong email. They stay a dependent of their guardians. Their guardians and admins may do this.
+//	@Tags			guardian
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Success		200			{object}	store.Patient
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/upgrade [delete]
+func (app *application) cancelUpgradeHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	allowed, err := app.canManageDependent(r, patient)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if !patient.UpgradePending {
+		app.conflictResponse(w, r, errNoPendingUpgrade)
+		return
+	}
+
+	username := newDependentUsername()
+	if err := app.store.Guardians.CancelUpgrade(r.Context(), patient.UserID, username); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errNotDependent)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	patient.Username = username
+	patient.Email = ""
+	patient.UpgradePending = false
+
+	if err := app.jsonResponse(w, http.StatusOK, patient); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/mailer"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	errImmunizationRecorded = errors.New("this dose is already recorded for the patient")
+	errImmunizationInFuture = errors.New("administered_at cannot be in the future")
+	errBeforeBirth          = errors.New("administered_at is before the patient's date of birth")
+)
+
+// immunizationStatus returns the state of every active scheduled dose for
+// the patient as of today.
+func (app *application) immunizationStatus(ctx context.Context, patient *store.Patient, given []store.Immunization) ([]store.DoseStatus, error) {
+	dob, err := time.Parse(store.DateLayout, patient.DateOfBirth)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := app.store.Immunizations.GetSchedule(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.ImmunizationStatus(schedule, given, dob, time.Now().UTC()), nil
+}
+
+// overdueVaccines returns the patient's overdue doses.
+func (app *application) overdueVaccines(ctx context.Context, patient *store.Patient) ([]store.DoseStatus, error) {
+	given, err := app.store.Immunizations.GetByPatient(ctx, patient.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := app.immunizationStatus(ctx, patient, given)
+	if err != nil {
+		return nil, err
+	}
+
+	overdue := []store.DoseStatus{}
+	for _, status := range statuses {
+		if status.Status == store.DoseOverdue {
+			overdue = append(overdue, status)
+		}
+	}
+
+	return overdue, nil
+}
+
+type ImmunizationRecord struct {
+	Immunizations []store.Immunization `json:"immunizations"`
+	Schedule      []store.DoseStatus   `json:"schedule"`
+}
+
+// getPatientImmunizationsHandler godoc
+//
+//	@Summary		Lists a patient's immunizations
+//	@Description	Returns the administered doses and the state of every dose of the schedule: completed, upcoming, due, overdue or missed (past its catch-up age)
+//	@Tags			immunizations
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Success		200			{object}	ImmunizationRecord
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/immunizations [get]
+func (app *application) getPatientImmunizationsHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+	ctx := r.Context()
+
+	given, err := app.store.Immunizations.GetByPatient(ctx, patient.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	schedule, err := app.immunizationStatus(ctx, patient, given)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	record := ImmunizationRecord{
+		Immunizations: given,
+		Schedule:      schedule,
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, record); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type RecordImmunizationPayload struct {
+	Vaccine        string     `json:"vaccine" validate:"required,max=50"`
+	VaccineName    string     `json:"vaccine_name" validate:"max=255"`
+	DoseNumber     int        `json:"dose_number" validate:"required,gte=1,lte=20"`
+	LotNumber      string     `json:"lot_number" validate:"required,max=100"`
+	Site           string     `json:"site" validate:"required,oneof=left_arm right_arm left_thigh right_thigh oral intranasal other"`
+	AdministeredAt *time.Time `json:"administered_at"`
+	Notes          string     `json:"notes" validate:"max=1000"`
+}
+
+// recordImmunizationHandler godoc
+//
+//	@Summary		Records an administered vaccine dose
+//	@Description	Records a vaccine dose with its lot number and injection site; the administering staff member is the caller. Vaccines on the schedule take their name from it, others need vaccine_name. Nurses and doctors only.
+//	@Tags			immunizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string						true	"Patient ID or MRN"
+//	@Param			payload		body		RecordImmunizationPayload	true	"Dose"
+//	@Success		201			{object}	store.Immunization
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/immunizations [post]
+func (app *application) recordImmunizationHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+	ctx := r.Context()
+
+	if !isClinician(user) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload RecordImmunizationPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	im := &store.Immunization{
+		PatientID:      patient.UserID,
+		Vaccine:        strings.ToLower(strings.TrimSpace(payload.Vaccine)),
+		VaccineName:    strings.TrimSpace(payload.VaccineName),
+		DoseNumber:     payload.DoseNumber,
+		LotNumber:      strings.TrimSpace(payload.LotNumber),
+		Site:           store.ImmunizationSite(payload.Site),
+		AdministeredAt: time.Now().UTC().Truncate(time.Second),
+		AdministeredBy: user.ID,
+		Notes:          payload.Notes,
+	}
+
+	if payload.AdministeredAt != nil {
+		if payload.AdministeredAt.After(time.Now().Add(5 * time.Minute)) {
+			app.badRequestResponse(w, r, errImmunizationInFuture)
+			return
+		}
+		im.AdministeredAt = payload.AdministeredAt.UTC().Truncate(time.Second)
+	}
+
+	if im.AdministeredAt.Format(store.DateLayout) < patient.DateOfBirth {
+		app.badRequestResponse(w, r, errBeforeBirth)
+		return
+	}
+
+	schedule, err := app.store.Immunizations.GetSchedule(ctx, false)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for _, dose := range schedule {
+		if dose.Vaccine == im.Vaccine {
+			im.VaccineName = dose.VaccineName
+			break
+		}
+	}
+
+	if im.VaccineName == "" {
+		app.badRequestResponse(w, r, fmt.Errorf("vaccine_name is required for %q, which is not on the schedule", im.Vaccine))
+		return
+	}
+
+	if err := app.store.Immunizations.Create(ctx, im); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errImmunizationRecorded)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, im); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getImmunizationScheduleHandler godoc
+//
+//	@Summary	Lists the immunization schedule
+//	@Tags		immunizations
+//	@Produce	json
+//	@Success	200	{array}		store.ScheduledDose
+//	@Failure	500	{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/immunization-schedule [get]
+func (app *application) getImmunizationScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	schedule, err := app.store.Immunizations.GetSchedule(r.Context(), false)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, schedule); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type ScheduledDosePayload struct {
+	VaccineName      string `json:"vaccine_name" validate:"required,max=255"`
+	DueAgeDays       int    `json:"due_age_days" validate:"gte=0,lte=36500"`
+	MinIntervalDays  int    `json:"min_interval_days" validate:"gte=0,lte=36500"`
+	GraceDays        int    `json:"grace_days" validate:"gte=0,lte=3650"`
+	CatchUpUntilDays *int   `json:"catch_up_until_days" validate:"omitempty,gtfield=DueAgeDays"`
+	Active           *bool  `json:"active"`
+}
+
+// setScheduledDoseHandler godoc
+//
+//	@Summary		Sets a dose of the immunization schedule
+//	@Description	Creates or replaces a scheduled dose. Ages and intervals are in days; catch_up_until_days is the age after which the dose is no longer offered. Set active to false to take a dose off the schedule. Admin only.
+//	@Tags			immunizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			vaccine	path		string					true	"Vaccine code"
+//	@Param			dose	path		int						true	"Dose number"
+//	@Param			payload	body		ScheduledDosePayload	true	"Dose"
+//	@Success		200		{object}	store.ScheduledDose
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/immunization-schedule/{vaccine}/{dose} [put]
+func (app *application) setScheduledDoseHandler(w http.ResponseWriter, r *http.Request) {
+	vaccine := strings.ToLower(chi.URLParam(r, "vaccine"))
+	if err := Validate.Var(vaccine, "required,max=50"); err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("vaccine: %w", err))
+		return
+	}
+
+	doseNumber, err := strconv.Atoi(chi.URLParam(r, "dose"))
+	if err != nil || doseNumber < 1 || doseNumber > 20 {
+		app.badRequestResponse(w, r, errors.New("dose must be a number from 1 to 20"))
+		return
+	}
+
+	var payload ScheduledDosePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	dose := &store.ScheduledDose{
+		Vaccine:          vaccine,
+		DoseNumber:       doseNumber,
+		VaccineName:      strings.TrimSpace(payload.VaccineName),
+		DueAgeDays:       payload.DueAgeDays,
+		MinIntervalDays:  payload.MinIntervalDays,
+		GraceDays:        payload.GraceDays,
+		CatchUpUntilDays: payload.CatchUpUntilDays,
+		Active:           payload.Active == nil || *payload.Active,
+	}
+
+	if err := app.store.Immunizations.SetScheduledDose(r.Context(), dose); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, dose); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type ImmunizationReminderRun struct {
+	Patients int `json:"patients"`
+	Emails   int `json:"emails"`
+}
+
+// sendImmunizationRemindersHandler godoc
+//
+//	@Summary		Sends immunization reminders
+//	@Description	Emails patients with overdue vaccinations now instead of waiting for the next scheduled run. A dose is not reminded about again until IMMUNIZATION_REMINDER_RESEND_DAYS have passed. Admin only.
+//	@Tags			immunizations
+//	@Produce		json
+//	@Success		200	{object}	ImmunizationReminderRun
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/immunizations/reminders [post]
+func (app *application) sendImmunizationRemindersHandler(w http.ResponseWriter, r *http.Request) {
+	run, err := app.sendImmunizationReminders(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, run); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// runImmunizationReminders sends reminders every interval for as long as
+// the server runs.
+func (app *application) runImmunizationReminders(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		run, err := app.sendImmunizationReminders(context.Background())
+		if err != nil {
+			app.logger.Errorw("error sending immunization reminders", "error", err)
+			continue
+		}
+
+		app.logger.Infow("immunization reminders sent", "patients", run.Patients, "emails", run.Emails)
+	}
+}
+
+// sendImmunizationReminders emails every patient with overdue doses that
+// were not reminded about recently. Failures for one patient are logged and
+// do not stop the run.
+func (app *application) sendImmunizationReminders(ctx context.Context) (ImmunizationReminderRun, error) {
+	var run ImmunizationReminderRun
+
+	ids, err := app.store.Immunizations.GetOverduePatients(ctx, time.Now().UTC())
+	if err != nil {
+		return run, err
+	}
+
+	for _, id := range ids {
+		run.Patients++
+
+		sent, err := app.remindPatientOfImmunizations(ctx, id)
+		if err != nil {
+			app.logger.Errorw("error sending immunization reminder", "patient", id, "error", err)
+			continue
+		}
+		if sent {
+			run.Emails++
+		}
+	}
+
+	return run, nil
+}
+
+func (app *application) remindPatientOfImmunizations(ctx context.Context, patientID uuid.UUID) (bool, error) {
+	patient, err := app.store.Patients.GetByID(ctx, patientID)
+	if err != nil {
+		return false, err
+	}
+
+	overdue, err := app.overdueVaccines(ctx, patient)
+	if err != nil {
+		return false, err
+	}
+
+	doses := []store.DoseStatus{}
+	for _, dose := range overdue {
+		claimed, err := app.store.Immunizations.ClaimReminder(ctx, patient.UserID, dose.Vaccine, dose.DoseNumber, app.config.immunization.resendAfterDays)
+		if err != nil {
+			return false, err
+		}
+		if claimed {
+			doses = append(doses, dose)
+		}
+	}
+
+	if len(doses) == 0 {
+		return false, nil
+	}
+
+	// dependents have no email of their own, their guardians are reminded
+	recipients := []store.Guardian{{Username: patient.Username, Email: patient.Email}}
+	if patient.Dependent {
+		recipients, err = app.store.Guardians.GetGuardians(ctx, patient.UserID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	isProdEnv := app.config.env == "production"
+
+	for _, recipient := range recipients {
+		if recipient.Email == "" {
+			continue
+		}
+
+		vars := struct {
+			Username    string
+			PatientName string
+			Doses       []store.DoseStatus
+			ScheduleURL string
+		}{
+			Username:    recipient.Username,
+			PatientName: strings.TrimSpace(patient.FirstName + " " + patient.LastName),
+			Doses:       doses,
+			ScheduleURL: fmt.Sprintf("%s/patients/%s/immunizations", app.config.frontendURL, patient.UserID),
+		}
+
+		status, err := app.mailer.Send(mailer.ImmunizationReminderTemplate, recipient.Username, recipient.Email, vars, !isProdEnv)
+		if err != nil {
+			return false, err
+		}
+
+		app.logger.Infow("immunization reminder sent", "patient", patient.UserID, "to", recipient.Username, "doses", len(doses), "status", status)
+	}
+
+	return true, nil
+}
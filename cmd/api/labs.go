@@ -0,0 +1,684 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/mailer"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type labOrderKey string
+
+const labOrderCtx labOrderKey = "labOrder"
+
+var (
+	errUnknownLabTest    = errors.New("unknown or inactive lab test")
+	errLabOrderReleased  = errors.New("released results cannot be changed")
+	errLabOrderNotReady  = errors.New("only orders with all results entered can be released")
+	errInvalidRangeBound = errors.New("reference range bounds must satisfy critical_low <= low <= high <= critical_high")
+)
+
+type LabReferenceRangePayload struct {
+	Sex          *store.Gender `json:"sex" validate:"omitempty,oneof=male female other"`
+	AgeMin       int           `json:"age_min" validate:"gte=0,lte=150"`
+	AgeMax       *int          `json:"age_max" validate:"omitempty,gte=1,lte=150"`
+	Low          *float64      `json:"low"`
+	High         *float64      `json:"high"`
+	CriticalLow  *float64      `json:"critical_low"`
+	CriticalHigh *float64      `json:"critical_high"`
+}
+
+type CreateLabTestPayload struct {
+	Code     string                     `json:"code" validate:"required,max=20"`
+	Name     string                     `json:"name" validate:"required,max=255"`
+	Specimen string                     `json:"specimen" validate:"required,max=50"`
+	Unit     string                     `json:"unit" validate:"required,max=30"`
+	Ranges   []LabReferenceRangePayload `json:"ranges" validate:"max=20,dive"`
+}
+
+// getLabTestsHandler godoc
+//
+//	@Summary		Lists the lab test catalog
+//	@Description	Lists the active lab tests with their reference ranges by sex and age
+//	@Tags			lab
+//	@Produce		json
+//	@Success		200	{array}		store.LabTest
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/lab-tests [get]
+func (app *application) getLabTestsHandler(w http.ResponseWriter, r *http.Request) {
+	tests, err := app.store.Labs.GetTests(r.Context(), nil, true)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, tests); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// createLabTestHandler godoc
+//
+//	@Summary		Adds a lab test
+//	@Description	Adds a test to the catalog. Ranges apply to a sex, or everyone when sex is omitted, from age_min up to but not including age_max years.
+//	@Tags			lab
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateLabTestPayload	true	"Lab test"
+//	@Success		201		{object}	store.LabTest
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/lab-tests [post]
+func (app *application) createLabTestHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateLabTestPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	test := &store.LabTest{
+		Code:     strings.ToUpper(strings.TrimSpace(payload.Code)),
+		Name:     strings.TrimSpace(payload.Name),
+		Specimen: strings.TrimSpace(payload.Specimen),
+		Unit:     strings.TrimSpace(payload.Unit),
+		Active:   true,
+		Ranges:   make([]store.LabReferenceRange, 0, len(payload.Ranges)),
+	}
+
+	for _, p := range payload.Ranges {
+		if err := validateRangeBounds(p); err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		test.Ranges = append(test.Ranges, store.LabReferenceRange{
+			Sex:          p.Sex,
+			AgeMin:       p.AgeMin,
+			AgeMax:       p.AgeMax,
+			Low:          p.Low,
+			High:         p.High,
+			CriticalLow:  p.CriticalLow,
+			CriticalHigh: p.CriticalHigh,
+		})
+	}
+
+	if err := app.store.Labs.CreateTest(r.Context(), test); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, test); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func validateRangeBounds(p LabReferenceRangePayload) error {
+	if p.AgeMax != nil && *p.AgeMax <= p.AgeMin {
+		return errors.New("age_max must be greater than age_min")
+	}
+
+	bounds := []*float64{p.CriticalLow, p.Low, p.High, p.CriticalHigh}
+	var previous *float64
+	for _, bound := range bounds {
+		if bound == nil {
+			continue
+		}
+		if previous != nil && *bound < *previous {
+			return errInvalidRangeBound
+		}
+		previous = bound
+	}
+
+	return nil
+}
+
+type CreateLabOrderPayload struct {
+	Tests []string `json:"tests" validate:"required,min=1,max=30,unique,dive,required,max=20"`
+	Notes string   `json:"notes" validate:"max=2000"`
+}
+
+// createLabOrderHandler godoc
+//
+//	@Summary		Orders lab tests
+//	@Description	Orders tests from the catalog for the patient of an encounter. Only the encounter's doctor can order.
+//	@Tags			lab
+//	@Accept			json
+//	@Produce		json
+//	@Param			encounterID	path		string					true	"Encounter ID"
+//	@Param			payload		body		CreateLabOrderPayload	true	"Test codes"
+//	@Success		201			{object}	store.LabOrder
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/encounters/{encounterID}/lab-orders [post]
+func (app *application) createLabOrderHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	encounter := getEncounterFromCtx(r)
+
+	if encounter.DoctorID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload CreateLabOrderPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	for i, code := range payload.Tests {
+		payload.Tests[i] = strings.ToUpper(strings.TrimSpace(code))
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	order := &store.LabOrder{
+		EncounterID: encounter.ID,
+		PatientID:   encounter.PatientID,
+		OrderedBy:   user.ID,
+		Notes:       strings.TrimSpace(payload.Notes),
+	}
+
+	if err := app.store.Labs.CreateOrder(r.Context(), order, payload.Tests); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.badRequestResponse(w, r, errUnknownLabTest)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	for _, code := range payload.Tests {
+		sourceKey := fmt.Sprintf("lab_order:%s:%s", order.ID, code)
+		app.chargeFromPriceList(r.Context(), order.PatientID, store.ChargeLabTest, code, 1, sourceKey, user.ID)
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, order); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getLabOrdersHandler godoc
+//
+//	@Summary		Lists lab orders
+//	@Description	Lists lab orders oldest first, for working through the lab queue. Lab staff only.
+//	@Tags			lab
+//	@Produce		json
+//	@Param			status	query		string	false	"ordered, resulted or released"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Success		200		{array}		store.LabOrder
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/lab-orders [get]
+func (app *application) getLabOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	q := store.LabOrderQuery{
+		Limit:  20,
+		Offset: 0,
+	}
+
+	q, err := q.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(q); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	orders, err := app.store.Labs.ListOrders(r.Context(), q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, orders); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPatientLabOrdersHandler godoc
+//
+//	@Summary		Lists a patient's lab orders
+//	@Description	Lists a patient's lab orders, newest first. Patients only see orders whose results have been released.
+//	@Tags			lab
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Param			status		query		string	false	"ordered, resulted or released"
+//	@Param			limit		query		int		false	"Limit"
+//	@Param			offset		query		int		false	"Offset"
+//	@Success		200			{array}		store.LabOrder
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/lab-orders [get]
+func (app *application) getPatientLabOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+
+	q := store.LabOrderQuery{
+		PatientID: &patient.UserID,
+		Limit:     20,
+		Offset:    0,
+	}
+
+	if user.ActsFor(patient.UserID) {
+		q.ReleasedOnly = true
+	} else {
+		allowed, err := app.canSeeLabResults(r.Context(), user, patient.UserID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+	}
+
+	q, err := q.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(q); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	orders, err := app.store.Labs.ListOrders(r.Context(), q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, orders); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getLabOrderHandler godoc
+//
+//	@Summary	Fetches a lab order
+//	@Tags		lab
+//	@Produce	json
+//	@Param		labOrderID	path		string	true	"Lab order ID"
+//	@Success	200			{object}	store.LabOrder
+//	@Failure	403			{object}	error
+//	@Failure	404			{object}	error
+//	@Failure	500			{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/lab-orders/{labOrderID} [get]
+func (app *application) getLabOrderHandler(w http.ResponseWriter, r *http.Request) {
+	order := getLabOrderFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, order); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type LabResultPayload struct {
+	TestCode string   `json:"test_code" validate:"required,max=20"`
+	Value    *float64 `json:"value" validate:"required"`
+	Comment  string   `json:"comment" validate:"max=1000"`
+}
+
+type RecordLabResultsPayload struct {
+	Results []LabResultPayload `json:"results" validate:"required,min=1,max=30,dive"`
+}
+
+// recordLabResultsHandler godoc
+//
+//	@Summary		Enters lab results
+//	@Description	Enters or corrects results on an order until it is released. Each value is flagged against the reference range for the patient's sex and age at the time of the order, and critical results are emailed to the ordering doctor. Lab staff only.
+//	@Tags			lab
+//	@Accept			json
+//	@Produce		json
+//	@Param			labOrderID	path		string					true	"Lab order ID"
+//	@Param			payload		body		RecordLabResultsPayload	true	"Results"
+//	@Success		200			{object}	store.LabOrder
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/lab-orders/{labOrderID}/results [put]
+func (app *application) recordLabResultsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getLabOrderFromCtx(r)
+
+	var payload RecordLabResultsPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if order.Status == store.LabOrderReleased {
+		app.conflictResponse(w, r, errLabOrderReleased)
+		return
+	}
+
+	ctx := r.Context()
+
+	codes := make([]string, 0, len(payload.Results))
+	for i := range payload.Results {
+		payload.Results[i].TestCode = strings.ToUpper(strings.TrimSpace(payload.Results[i].TestCode))
+		codes = append(codes, payload.Results[i].TestCode)
+	}
+
+	tests, err := app.store.Labs.GetTests(ctx, codes, false)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	byCode := make(map[string]*store.LabTest, len(tests))
+	for _, test := range tests {
+		byCode[test.Code] = test
+	}
+
+	sex, age, err := app.patientSexAndAge(ctx, order.PatientID, order.CreatedAt)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	results := make([]store.LabResult, 0, len(payload.Results))
+	for _, p := range payload.Results {
+		test, ok := byCode[p.TestCode]
+		if !ok {
+			app.badRequestResponse(w, r, fmt.Errorf("%s is not on this order", p.TestCode))
+			return
+		}
+
+		result := store.LabResult{
+			TestCode: p.TestCode,
+			TestName: test.Name,
+			Value:    p.Value,
+			Unit:     test.Unit,
+			Comment:  strings.TrimSpace(p.Comment),
+		}
+
+		if rng := test.RangeFor(sex, age); rng != nil {
+			flag := rng.Flag(*p.Value)
+			result.Flag = &flag
+			result.Low = rng.Low
+			result.High = rng.High
+		}
+
+		results = append(results, result)
+	}
+
+	if err := app.store.Labs.RecordResults(ctx, order, results, user.ID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.badRequestResponse(w, r, errors.New("a result is for a test that is not on this order"))
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errLabOrderReleased)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	critical := []store.LabResult{}
+	for _, result := range results {
+		if result.Flag != nil && result.Flag.Critical() {
+			critical = append(critical, result)
+		}
+	}
+
+	if len(critical) > 0 {
+		app.notifyCriticalLabResults(ctx, order, critical)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, order); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// releaseLabOrderHandler godoc
+//
+//	@Summary		Releases lab results
+//	@Description	Makes the results of an order visible to the patient once all of them have been entered. Only the ordering doctor can release.
+//	@Tags			lab
+//	@Produce		json
+//	@Param			labOrderID	path		string	true	"Lab order ID"
+//	@Success		200			{object}	store.LabOrder
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/lab-orders/{labOrderID}/release [post]
+func (app *application) releaseLabOrderHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	order := getLabOrderFromCtx(r)
+
+	if order.OrderedBy != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Labs.Release(r.Context(), order); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errLabOrderNotReady)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, order); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// patientSexAndAge returns the patient's sex and age in years at the given
+// time. Both are unknown for accounts without a patient profile.
+func (app *application) patientSexAndAge(ctx context.Context, patientID uuid.UUID, at time.Time) (store.Gender, *int, error) {
+	patient, err := app.store.Patients.GetByID(ctx, patientID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "", nil, nil
+		}
+		return "", nil, err
+	}
+
+	dob, err := time.Parse(store.DateLayout, patient.DateOfBirth)
+	if err != nil {
+		return patient.Sex, nil, nil
+	}
+
+	age := store.AgeOn(dob, at)
+	return patient.Sex, &age, nil
+}
+
+type criticalLabResult struct {
+	Test  string
+	Value string
+	Unit  string
+	Flag  string
+	Range string
+}
+
+// notifyCriticalLabResults emails the ordering doctor about critical
+// results. The results are already saved, so a failed email is only logged.
+func (app *application) notifyCriticalLabResults(ctx context.Context, order *store.LabOrder, results []store.LabResult) {
+	doctor, err := app.store.Users.GetByID(ctx, order.OrderedBy)
+	if err != nil {
+		app.logger.Errorw("error loading doctor for critical lab result", "order", order.ID, "error", err)
+		return
+	}
+
+	patient, err := app.documentPatient(ctx, order.PatientID)
+	if err != nil {
+		app.logger.Errorw("error loading patient for critical lab result", "order", order.ID, "error", err)
+		return
+	}
+
+	rows := make([]criticalLabResult, 0, len(results))
+	for _, result := range results {
+		rows = append(rows, criticalLabResult{
+			Test:  result.TestName,
+			Value: strconv.FormatFloat(*result.Value, 'f', -1, 64),
+			Unit:  result.Unit,
+			Flag:  strings.ReplaceAll(string(*result.Flag), "_", " "),
+			Range: formatRange(result.Low, result.High),
+		})
+	}
+
+	vars := struct {
+		Username    string
+		PatientName string
+		PatientMRN  string
+		Results     []criticalLabResult
+		OrderURL    string
+	}{
+		Username:    doctor.Username,
+		PatientName: strings.TrimSpace(patient.FirstName + " " + patient.LastName),
+		PatientMRN:  patient.MRN,
+		Results:     rows,
+		OrderURL:    fmt.Sprintf("%s/lab-orders/%s", app.config.frontendURL, order.ID),
+	}
+
+	isProdEnv := app.config.env == "production"
+
+	status, err := app.mailer.Send(mailer.LabCriticalTemplate, doctor.Username, doctor.Email, vars, !isProdEnv)
+	if err != nil {
+		app.logger.Errorw("error sending critical lab result email", "order", order.ID, "doctor", doctor.ID, "error", err)
+		return
+	}
+
+	app.logger.Infow("critical lab result email sent", "order", order.ID, "doctor", doctor.ID, "status", status)
+}
+
+func formatRange(low, high *float64) string {
+	format := func(v *float64) string {
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+
+	switch {
+	case low != nil && high != nil:
+		return format(low) + " - " + format(high)
+	case low != nil:
+		return ">= " + format(low)
+	case high != nil:
+		return "<= " + format(high)
+	default:
+		return ""
+	}
+}
+
+// canSeeLabResults reports whether a staff member may see a patient's lab
+// results: lab staff and the patient's treating doctors.
+func (app *application) canSeeLabResults(ctx context.Context, user *store.User, patientID uuid.UUID) (bool, error) {
+	if user.Role.Name == "lab" {
+		return true, nil
+	}
+
+	return app.store.Encounters.IsTreatingDoctor(ctx, user.ID, patientID)
+}
+
+// canReadLabOrder reports whether the authenticated user may see the order:
+// the patient once it is released, the ordering doctor, treating doctors and
+// lab staff.
+func (app *application) canReadLabOrder(r *http.Request, order *store.LabOrder) (bool, error) {
+	user := getUserFromContext(r)
+	if user == nil {
+		return false, nil
+	}
+
+	if user.ActsFor(order.PatientID) {
+		return order.Status == store.LabOrderReleased, nil
+	}
+
+	if user.ID == order.OrderedBy {
+		return true, nil
+	}
+
+	return app.canSeeLabResults(r.Context(), user, order.PatientID)
+}
+
+func (app *application) labOrderContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "labOrderID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		order, err := app.store.Labs.GetOrder(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		allowed, err := app.canReadLabOrder(r, order)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		ctx = context.WithValue(ctx, labOrderCtx, order)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getLabOrderFromCtx(r *http.Request) *store.LabOrder {
+	order, _ := r.Context().Value(labOrderCtx).(*store.LabOrder)
+	return order
+}
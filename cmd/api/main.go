@@ -4,9 +4,18 @@ import (
 	"time"
 
 	"github.com/MdHasib01/hms_server/internal/auth"
+	"github.com/MdHasib01/hms_server/internal/blob"
+	"github.com/MdHasib01/hms_server/internal/claimfile"
 	"github.com/MdHasib01/hms_server/internal/db"
+	"github.com/MdHasib01/hms_server/internal/eligibility"
 	"github.com/MdHasib01/hms_server/internal/env"
+	"github.com/MdHasib01/hms_server/internal/interactions"
 	"github.com/MdHasib01/hms_server/internal/mailer"
+	"github.com/MdHasib01/hms_server/internal/mrn"
+	"github.com/MdHasib01/hms_server/internal/payments"
+	"github.com/MdHasib01/hms_server/internal/pdf"
+	"github.com/MdHasib01/hms_server/internal/scanner"
+	"github.com/MdHasib01/hms_server/internal/signing"
 	"github.com/MdHasib01/hms_server/internal/store"
 	_ "github.com/lib/pq"
 	"go.uber.org/zap"
@@ -61,12 +70,69 @@ func main() {
 				iss:    "gophersocial",
 			},
 		},
+		storage: storageConfig{
+			localDir:               env.GetString("STORAGE_LOCAL_DIR", "./uploads"),
+			publicURL:              env.GetString("STORAGE_PUBLIC_URL", "http://localhost:8080/v1/files"),
+			maxPhotoSize:           int64(env.GetInt("STORAGE_MAX_PHOTO_MB", 5)) << 20,
+			maxDocumentSize:        int64(env.GetInt("STORAGE_MAX_DOCUMENT_MB", 10)) << 20,
+			maxPatientDocumentSize: int64(env.GetInt("STORAGE_MAX_PATIENT_DOCUMENT_MB", 25)) << 20,
+			thumbnailSize:          env.GetInt("STORAGE_THUMBNAIL_SIZE", 256),
+			virusScanner:           env.GetString("VIRUS_SCANNER", "none"),
+			clamdAddr:              env.GetString("CLAMD_ADDR", "localhost:3310"),
+		},
+		mrn: mrn.Config{
+			Prefix:         env.GetString("MRN_PREFIX", "MRN"),
+			Separator:      env.GetString("MRN_SEPARATOR", "-"),
+			IncludeYear:    env.GetBool("MRN_INCLUDE_YEAR", true),
+			SequenceDigits: env.GetInt("MRN_SEQUENCE_DIGITS", 6),
+			CheckDigit:     env.GetBool("MRN_CHECK_DIGIT", true),
+		},
+		letterhead: pdf.Letterhead{
+			Name:    env.GetString("HOSPITAL_NAME", "Medicore Hospital"),
+			Address: env.GetString("HOSPITAL_ADDRESS", ""),
+			Phone:   env.GetString("HOSPITAL_PHONE", ""),
+			Email:   env.GetString("HOSPITAL_EMAIL", ""),
+		},
+		signingKey: env.GetString("DOCUMENT_SIGNING_KEY", ""),
+		immunization: immunizationConfig{
+			resendAfterDays: env.GetInt("IMMUNIZATION_REMINDER_RESEND_DAYS", 14),
+		},
+		patients: patientConfig{
+			ageOfMajority: env.GetInt("AGE_OF_MAJORITY", 18),
+		},
+		pharmacy: pharmacyConfig{
+			expiryWarningDays: env.GetInt("PHARMACY_EXPIRY_WARNING_DAYS", 90),
+		},
+		billing: billingConfig{
+			currency:            env.GetString("BILLING_CURRENCY", "USD"),
+			consultationTaxRate: env.GetInt("BILLING_CONSULTATION_TAX_RATE", 0),
+			paymentGateway:      env.GetString("PAYMENT_GATEWAY", "fake"),
+		},
+		claims: claimsConfig{
+			fileFormat:         env.GetString("CLAIM_FILE_FORMAT", "csv"),
+			eligibilityChecker: env.GetString("ELIGIBILITY_CHECKER", "stub"),
+		},
+		roster: rosterConfig{
+			minRest: time.Duration(env.GetInt("ROSTER_MIN_REST_HOURS", 11)) * time.Hour,
+		},
 	}
 
 	// Logger
 	logger := zap.Must(zap.NewProduction()).Sugar()
 	defer logger.Sync()
 
+	reminderInterval, err := time.ParseDuration(env.GetString("IMMUNIZATION_REMINDER_INTERVAL", "24h"))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	cfg.immunization.reminderInterval = reminderInterval
+
+	breachCheckInterval, err := time.ParseDuration(env.GetString("ER_BREACH_CHECK_INTERVAL", "1m"))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	cfg.er.breachCheckInterval = breachCheckInterval
+
 	// Database
 	db, err := db.New(
 		cfg.db.addr,
@@ -95,15 +161,98 @@ func main() {
 		cfg.auth.token.iss,
 	)
 
+	// Blob storage
+	blobStore, err := blob.NewLocalStore(cfg.storage.localDir, cfg.storage.publicURL)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	virusScanner, err := scanner.New(cfg.storage.virusScanner, cfg.storage.clamdAddr)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	if _, noop := virusScanner.(scanner.Noop); noop && !stubsAllowed(cfg.env) {
+		logger.Fatalf("VIRUS_SCANNER must name a scanner when ENV is %q", cfg.env)
+	}
+
+	paymentGateway, err := payments.New(cfg.billing.paymentGateway)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	// the fake gateway approves charges without moving any money
+	if _, fake := paymentGateway.(*payments.Fake); fake && !stubsAllowed(cfg.env) {
+		logger.Fatalf("PAYMENT_GATEWAY must name a real gateway when ENV is %q", cfg.env)
+	}
+
+	claimFormat, err := claimfile.New(cfg.claims.fileFormat)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	eligibilityChecker, err := eligibility.New(cfg.claims.eligibilityChecker)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	// the stub answers without asking the payer
+	if _, stub := eligibilityChecker.(eligibility.Stub); stub && !stubsAllowed(cfg.env) {
+		logger.Fatalf("ELIGIBILITY_CHECKER must name a payer integration when ENV is %q", cfg.env)
+	}
+
+	mrnGenerator, err := mrn.New(cfg.mrn)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// an unset key would let anyone forge prescriptions that verify, so it
+	// is only made up in development
+	if cfg.signingKey == "" && cfg.env == "development" {
+		logger.Warn("DOCUMENT_SIGNING_KEY is not set; signing documents with a development key")
+		cfg.signingKey = "development"
+	}
+
+	signer, err := signing.New(cfg.signingKey)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	drugData, err := interactions.Bundled()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
 	app := &application{
 		config:        cfg,
 		store:         store,
 		logger:        logger,
 		mailer:        mailtrap,
 		authenticator: jwtAuthenticator,
+		blob:          blobStore,
+		mrn:           mrnGenerator,
+		signer:        signer,
+		interactions:  drugData,
+		scanner:       virusScanner,
+		payments:      paymentGateway,
+		claimFormat:   claimFormat,
+		eligibility:   eligibilityChecker,
+		erFeed:        newERFeed(),
+	}
+
+	if cfg.immunization.reminderInterval > 0 {
+		go app.runImmunizationReminders(cfg.immunization.reminderInterval)
+	}
+
+	if cfg.er.breachCheckInterval > 0 {
+		go app.runERBreachMonitor(cfg.er.breachCheckInterval)
 	}
 
 	mux := app.mount()
 
 	logger.Fatal(app.run(mux))
 }
+
+// stubsAllowed reports whether the environment may stand in for outside
+// services with stubs that accept everything, which only development and
+// tests may.
+func stubsAllowed(env string) bool {
+	return env == "development" || env == "test"
+}
@@ -96,6 +96,10 @@ func (app *application) BasicAuthMiddleware() func(http.Handler) http.Handler {
 func (app *application) checkPostOwnership(requiredRole string, next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := getUserFromContext(r)
+		if user == nil {
+			app.unauthorizedErrorResponse(w, r, fmt.Errorf("missing authenticated user"))
+			return
+		}
 
 		allowed, err := app.checkRolePrecedence(r.Context(), user, requiredRole)
 		if err != nil {
@@ -112,6 +116,32 @@ func (app *application) checkPostOwnership(requiredRole string, next http.Handle
 	})
 }
 
+// checkRole only lets the request through when the authenticated user's role
+// is at least as senior as requiredRole.
+func (app *application) checkRole(requiredRole string, next http.HandlerFunc) http.HandlerFunc {
+	return app.checkPostOwnership(requiredRole, next)
+}
+
+// checkRoleName lets only users holding the named role through. Unlike
+// checkRole it ignores precedence, for work that belongs to one role such as
+// entering lab results.
+func (app *application) checkRoleName(role string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := getUserFromContext(r)
+		if user == nil {
+			app.unauthorizedErrorResponse(w, r, fmt.Errorf("missing authenticated user"))
+			return
+		}
+
+		if user.Role.Name != role {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (app *application) checkRolePrecedence(ctx context.Context, user *store.User, roleName string) (bool, error) {
 	role, err := app.store.Roles.GetByName(ctx, roleName)
 	if err != nil {
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/MdHasib01/hms_server/internal/blob"
+	"github.com/MdHasib01/hms_server/internal/scanner"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// scans and exports of paper reports arrive as TIFF as often as PDF
+var patientDocumentContentTypes = []string{"application/pdf", "image/jpeg", "image/png", "image/tiff"}
+
+const patientDocumentCategoryTag = "required,oneof=lab_report imaging referral_letter discharge_summary prescription consent_form insurance identity other"
+
+var (
+	errEncounterOfPatient     = errors.New("the encounter belongs to another patient")
+	errEncounterOfAppointment = errors.New("the encounter is not from the given appointment")
+	errVirusScanUnavailable   = errors.New("the file could not be checked for viruses, try again later")
+)
+
+// canDownloadPatientDocument reports whether the user may open a document's
+// file: the patient, their guardians, a clinician, or whoever uploaded it.
+// Other staff with access to the patient only see the document list.
+func canDownloadPatientDocument(user *store.User, doc *store.PatientDocument) bool {
+	return user.ActsFor(doc.PatientID) ||
+		isClinician(user) ||
+		(doc.UploadedBy != nil && *doc.UploadedBy == user.ID)
+}
+
+// parseOptionalUUID parses a form value that may be left empty.
+func parseOptionalUUID(field, value string) (*uuid.UUID, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", field, err)
+	}
+
+	return &id, nil
+}
+
+// uploadPatientDocumentHandler godoc
+//
+//	@Summary		Attaches a document to a patient's record
+//	@Description	Uploads a scanned report, referral letter or other document. PDF, JPEG, PNG and TIFF are accepted; the type is detected from the file content. Files are checked for viruses before they are stored. The document can be linked to one of the patient's encounters and/or appointments.
+//	@Tags			patients
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			patientID		path		string	true	"Patient ID or MRN"
+//	@Param			file			formData	file	true	"Document"
+//	@Param			category		formData	string	true	"lab_report, imaging, referral_letter, discharge_summary, prescription, consent_form, insurance, identity or other"
+//	@Param			title			formData	string	false	"Title, defaults to the file name"
+//	@Param			encounter_id	formData	string	false	"Encounter ID"
+//	@Param			appointment_id	formData	string	false	"Appointment ID"
+//	@Success		201				{object}	store.PatientDocument
+//	@Failure		400				{object}	error
+//	@Failure		403				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		413				{object}	error
+//	@Failure		415				{object}	error
+//	@Failure		422				{object}	error
+//	@Failure		500				{object}	error
+//	@Failure		503				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/documents [post]
+func (app *application) uploadPatientDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+	ctx := r.Context()
+
+	file, err := readUpload(w, r, "file", app.config.storage.maxPatientDocumentSize, patientDocumentContentTypes)
+	if err != nil {
+		app.uploadErrorResponse(w, r, err)
+		return
+	}
+
+	category := r.FormValue("category")
+	if err := Validate.Var(category, patientDocumentCategoryTag); err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("category: %w", err))
+		return
+	}
+
+	title := strings.TrimSpace(r.FormValue("title"))
+	if title == "" {
+		title = file.FileName
+	}
+	if err := Validate.Var(title, "max=255"); err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("title: %w", err))
+		return
+	}
+
+	encounterID, err := parseOptionalUUID("encounter_id", r.FormValue("encounter_id"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	appointmentID, err := parseOptionalUUID("appointment_id", r.FormValue("appointment_id"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if encounterID != nil {
+		encounter, err := app.store.Encounters.GetByID(ctx, *encounterID)
+		if err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.badRequestResponse(w, r, fmt.Errorf("encounter_id: %w", err))
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		if encounter.PatientID != patient.UserID {
+			app.badRequestResponse(w, r, errEncounterOfPatient)
+			return
+		}
+
+		if appointmentID != nil && encounter.AppointmentID != *appointmentID {
+			app.badRequestResponse(w, r, errEncounterOfAppointment)
+			return
+		}
+	}
+
+	if appointmentID != nil {
+		appointment, err := app.store.Appointments.GetByID(ctx, *appointmentID)
+		if err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.badRequestResponse(w, r, fmt.Errorf("appointment_id: %w", err))
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		if appointment.PatientID != patient.UserID {
+			app.badRequestResponse(w, r, errAppointmentOfPatient)
+			return
+		}
+	}
+
+	if err := app.scanner.Scan(ctx, file.Data); err != nil {
+		if errors.Is(err, scanner.ErrInfected) {
+			app.logger.Warnw("infected upload rejected", "patient", patient.UserID, "user", user.ID, "file", file.FileName, "error", err)
+			writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+
+		// never store a file that was not checked
+		app.logger.Errorw("virus scan failed", "scanner", app.scanner.Name(), "error", err)
+		writeJSONError(w, http.StatusServiceUnavailable, errVirusScanUnavailable.Error())
+		return
+	}
+
+	doc := &store.PatientDocument{
+		PatientID:     patient.UserID,
+		Category:      store.PatientDocumentCategory(category),
+		Title:         title,
+		EncounterID:   encounterID,
+		AppointmentID: appointmentID,
+		FileName:      file.FileName,
+		ContentType:   file.ContentType,
+		SizeBytes:     int64(len(file.Data)),
+		StorageKey:    fmt.Sprintf("%spatients/%s/documents/%s%s", blob.PrivatePrefix, patient.UserID, uuid.New(), file.Extension),
+		ScannedBy:     app.scanner.Name(),
+		UploadedBy:    &user.ID,
+	}
+
+	if err := app.blob.Put(ctx, doc.StorageKey, bytes.NewReader(file.Data)); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.store.PatientDocuments.Create(ctx, doc); err != nil {
+		app.deleteBlobs(r, doc.StorageKey)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, doc); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPatientDocumentsHandler godoc
+//
+//	@Summary		Lists a patient's documents
+//	@Description	Lists the documents attached to a patient's record, newest first, optionally filtered by category, encounter or appointment
+//	@Tags			patients
+//	@Produce		json
+//	@Param			patientID		path		string	true	"Patient ID or MRN"
+//	@Param			category		query		string	false	"Category"
+//	@Param			encounter_id	query		string	false	"Encounter ID"
+//	@Param			appointment_id	query		string	false	"Appointment ID"
+//	@Param			limit			query		int		false	"Page size, 1-100"
+//	@Param			offset			query		int		false	"Offset"
+//	@Success		200				{array}		store.PatientDocument
+//	@Failure		400				{object}	error
+//	@Failure		403				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/documents [get]
+func (app *application) getPatientDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	query, err := store.PatientDocumentQuery{
+		PatientID: &patient.UserID,
+		Limit:     50,
+		Offset:    0,
+	}.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(query); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	docs, err := app.store.PatientDocuments.List(r.Context(), query)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, docs); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) getPatientDocument(w http.ResponseWriter, r *http.Request) (*store.PatientDocument, bool) {
+	patient := getPatientFromCtx(r)
+
+	documentID, err := uuid.Parse(chi.URLParam(r, "documentID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return nil, false
+	}
+
+	doc, err := app.store.PatientDocuments.GetByID(r.Context(), patient.UserID, documentID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	return doc, true
+}
+
+// downloadPatientDocumentHandler godoc
+//
+//	@Summary		Downloads a patient document
+//	@Description	Downloads the file of a patient document. Available to the patient, doctors, nurses and the uploader.
+//	@Tags			patients
+//	@Produce		octet-stream
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Param			documentID	path		string	true	"Document ID"
+//	@Success		200			{file}		file
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/documents/{documentID} [get]
+func (app *application) downloadPatientDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	doc, ok := app.getPatientDocument(w, r)
+	if !ok {
+		return
+	}
+
+	if !canDownloadPatientDocument(getUserFromContext(r), doc) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	app.writeAttachment(w, r, doc.StorageKey, doc.FileName, doc.ContentType)
+}
+
+// deletePatientDocumentHandler godoc
+//
+//	@Summary		Deletes a patient document
+//	@Description	Deletes a document and its file. Only the uploader or an admin may delete a document.
+//	@Tags			patients
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Param			documentID	path		string	true	"Document ID"
+//	@Success		204			{string}	string	"Document deleted"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/documents/{documentID} [delete]
+func (app *application) deletePatientDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	doc, ok := app.getPatientDocument(w, r)
+	if !ok {
+		return
+	}
+
+	allowed := doc.UploadedBy != nil && *doc.UploadedBy == user.ID
+	if !allowed {
+		admin, err := app.checkRolePrecedence(ctx, user, "admin")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		allowed = admin
+	}
+	if !allowed {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.PatientDocuments.Delete(ctx, doc.PatientID, doc.ID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.deleteBlobs(r, doc.StorageKey)
+
+	w.WriteHeader(http.StatusNoContent)
+}
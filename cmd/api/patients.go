@@ -0,0 +1,676 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/mailer"
+	"github.com/MdHasib01/hms_server/internal/mrn"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type patientKey string
+
+const patientCtx patientKey = "patient"
+
+type PatientProfilePayload struct {
+	FirstName         string                   `json:"firstname" validate:"required,max=100"`
+	LastName          string                   `json:"lastname" validate:"required,max=100"`
+	DateOfBirth       string                   `json:"date_of_birth" validate:"required,datetime=2006-01-02"`
+	Sex               store.Gender             `json:"sex" validate:"required,oneof=male female other"`
+	Phone             string                   `json:"phone" validate:"required,max=30"`
+	Address           string                   `json:"address" validate:"max=500"`
+	Country           string                   `json:"country" validate:"max=50"`
+	State             string                   `json:"state" validate:"max=50"`
+	City              string                   `json:"city" validate:"max=50"`
+	PostalCode        string                   `json:"postal_code" validate:"max=20"`
+	BloodGroup        store.BloodGroup         `json:"blood_group" validate:"omitempty,oneof=A+ A- B+ B- AB+ AB- O+ O-"`
+	EmergencyContacts []store.EmergencyContact `json:"emergency_contacts" validate:"max=5,dive"`
+}
+
+func (p PatientProfilePayload) apply(patient *store.Patient) {
+	patient.FirstName = p.FirstName
+	patient.LastName = p.LastName
+	patient.DateOfBirth = p.DateOfBirth
+	patient.Sex = p.Sex
+	patient.Phone = p.Phone
+	patient.Address = p.Address
+	patient.Country = p.Country
+	patient.State = p.State
+	patient.City = p.City
+	patient.PostalCode = p.PostalCode
+	patient.BloodGroup = p.BloodGroup
+	patient.EmergencyContacts = p.EmergencyContacts
+}
+
+type CreatePatientPayload struct {
+	Username string `json:"username" validate:"required,max=100"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=3,max=72"`
+	PatientProfilePayload
+}
+
+// createPatientHandler godoc
+//
+//	@Summary		Registers a patient
+//	@Description	Creates a patient user account with a patient profile, assigns a new MRN and sends the activation email
+//	@Tags			patient
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreatePatientPayload	true	"Patient account and profile"
+//	@Success		201		{object}	store.Patient
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/patients [post]
+func (app *application) createPatientHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreatePatientPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validateDateOfBirth(payload.DateOfBirth); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	user := &store.User{
+		Username: payload.Username,
+		Email:    payload.Email,
+	}
+
+	if err := user.Password.Set(payload.Password); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	plainToken := uuid.New().String()
+	hash := sha256.Sum256([]byte(plainToken))
+	hashToken := hex.EncodeToString(hash[:])
+
+	err := app.store.Users.CreateAndInvite(ctx, user, hashToken, app.config.mail.exp)
+	if err != nil {
+		switch err {
+		case store.ErrDuplicateEmail, store.ErrDuplicateUsername:
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	patient := &store.Patient{
+		UserID:   user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+	}
+	payload.PatientProfilePayload.apply(patient)
+
+	issueMRN := func(seq int64) string {
+		return app.mrn.Format(time.Now().Year(), seq)
+	}
+
+	if err := app.store.Patients.Create(ctx, patient, issueMRN); err != nil {
+		// rollback user if the profile cannot be stored
+		_ = app.store.Users.Delete(ctx, user.ID)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	activationURL := fmt.Sprintf("%s/confirm/%s", app.config.frontendURL, plainToken)
+	isProdEnv := app.config.env == "production"
+
+	vars := struct {
+		Username      string
+		ActivationURL string
+	}{
+		Username:      user.Username,
+		ActivationURL: activationURL,
+	}
+
+	status, err := app.mailer.Send(mailer.UserWelcomeTemplate, user.Username, user.Email, vars, !isProdEnv)
+	if err != nil {
+		app.logger.Errorw("error sending welcome email", "error", err)
+		// deleting the user cascades to the patient profile
+		_ = app.store.Users.Delete(ctx, user.ID)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.logger.Infow("Email sent", "status", status)
+
+	if err := app.jsonResponse(w, http.StatusCreated, patient); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPatientHandler godoc
+//
+//	@Summary		Fetches a patient profile
+//	@Description	Fetches a patient profile with emergency contacts and insurance
+//	@Tags			patient
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Success		200			{object}	store.Patient
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID} [get]
+func (app *application) getPatientHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	overdue, err := app.overdueVaccines(r.Context(), patient)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	patient.OverdueVaccines = overdue
+
+	if err := app.jsonResponse(w, http.StatusOK, patient); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// updatePatientHandler godoc
+//
+//	@Summary		Updates a patient profile
+//	@Description	Replaces the profile fields and emergency contacts of a patient
+//	@Tags			patient
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string					true	"Patient ID or MRN"
+//	@Param			payload		body		PatientProfilePayload	true	"Patient profile"
+//	@Success		200			{object}	store.Patient
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID} [put]
+func (app *application) updatePatientHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	var payload PatientProfilePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validateDateOfBirth(payload.DateOfBirth); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.apply(patient)
+
+	if err := app.store.Patients.Update(r.Context(), patient); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, patient); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// searchPatientsHandler godoc
+//
+//	@Summary		Searches patients
+//	@Description	Searches patients by name, email, phone or MRN (q), date of birth and exact phone number. Reception and above only.
+//	@Tags			patient
+//	@Produce		json
+//	@Param			q		query		string	false	"Name, email or phone fragment, or an exact MRN"
+//	@Param			dob		query		string	false	"Date of birth, YYYY-MM-DD"
+//	@Param			phone	query		string	false	"Exact phone number"
+//	@Param			limit	query		int		false	"Page size, 1-50 (default 20)"
+//	@Param			offset	query		int		false	"Offset"
+//	@Success		200		{array}		store.Patient
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/search [get]
+func (app *application) searchPatientsHandler(w http.ResponseWriter, r *http.Request) {
+	q := store.PatientSearchQuery{
+		Limit:  20,
+		Offset: 0,
+	}
+
+	q, err := q.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(q); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	patients, err := app.store.Patients.Search(r.Context(), q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, patients); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type CreateInsurancePolicyPayload struct {
+	Payer        string `json:"payer" validate:"required,max=255"`
+	PlanName     string `json:"plan_name" validate:"max=255"`
+	PolicyNumber string `json:"policy_number" validate:"required,max=100"`
+	MemberID     string `json:"member_id" validate:"max=100"`
+	GroupNumber  string `json:"group_number" validate:"max=100"`
+	ValidFrom    string `json:"valid_from" validate:"omitempty,datetime=2006-01-02"`
+	ValidTo      string `json:"valid_to" validate:"omitempty,datetime=2006-01-02"`
+	IsPrimary    bool   `json:"is_primary"`
+}
+
+// addPatientInsuranceHandler godoc
+//
+//	@Summary		Adds an insurance policy
+//	@Description	Adds an insurance policy to a patient. A new primary policy replaces the previous primary one.
+//	@Tags			patient
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string							true	"Patient ID or MRN"
+//	@Param			payload		body		CreateInsurancePolicyPayload	true	"Insurance policy"
+//	@Success		201			{object}	store.InsurancePolicy
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/insurance [post]
+func (app *application) addPatientInsuranceHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	var payload CreateInsurancePolicyPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.ValidFrom != "" && payload.ValidTo != "" && payload.ValidTo < payload.ValidFrom {
+		app.badRequestResponse(w, r, errors.New("valid_to must not be before valid_from"))
+		return
+	}
+
+	policy := &store.InsurancePolicy{
+		PatientID:    patient.UserID,
+		Payer:        payload.Payer,
+		PlanName:     payload.PlanName,
+		PolicyNumber: payload.PolicyNumber,
+		MemberID:     payload.MemberID,
+		GroupNumber:  payload.GroupNumber,
+		ValidFrom:    payload.ValidFrom,
+		ValidTo:      payload.ValidTo,
+		IsPrimary:    payload.IsPrimary,
+	}
+
+	if err := app.store.Patients.AddInsurance(r.Context(), policy); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, policy); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPatientInsuranceHandler godoc
+//
+//	@Summary		Lists insurance policies
+//	@Description	Lists a patient's insurance policies, primary first
+//	@Tags			patient
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Success		200			{array}		store.InsurancePolicy
+//	@Failure		403			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/insurance [get]
+func (app *application) getPatientInsuranceHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, patient.Insurance); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deletePatientInsuranceHandler godoc
+//
+//	@Summary		Removes an insurance policy
+//	@Description	Removes an insurance policy from a patient
+//	@Tags			patient
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Param			policyID	path		string	true	"Policy ID"
+//	@Success		204			{string}	string	"Policy removed"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/insurance/{policyID} [delete]
+func (app *application) deletePatientInsuranceHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	policyID, err := uuid.Parse(chi.URLParam(r, "policyID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Patients.DeleteInsurance(r.Context(), patient.UserID, policyID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type CreatePatientIdentifierPayload struct {
+	Type   store.IdentifierType `json:"type" validate:"required,oneof=national_id passport insurance_member_id driver_license other"`
+	Value  string               `json:"value" validate:"required,max=100"`
+	Issuer string               `json:"issuer" validate:"max=100"`
+}
+
+// getPatientIdentifiersHandler godoc
+//
+//	@Summary		Fetches external identifiers
+//	@Description	Fetches the national IDs, insurance member IDs and other external identifiers of a patient
+//	@Tags			patient
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Success		200			{array}		store.PatientIdentifier
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/identifiers [get]
+func (app *application) getPatientIdentifiersHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, patient.Identifiers); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// addPatientIdentifierHandler godoc
+//
+//	@Summary		Adds an external identifier
+//	@Description	Adds an external identifier to a patient. A value can belong to one patient per type and issuer.
+//	@Tags			patient
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string							true	"Patient ID or MRN"
+//	@Param			payload		body		CreatePatientIdentifierPayload	true	"Identifier"
+//	@Success		201			{object}	store.PatientIdentifier
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/identifiers [post]
+func (app *application) addPatientIdentifierHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	var payload CreatePatientIdentifierPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	identifier := &store.PatientIdentifier{
+		PatientID: patient.UserID,
+		Type:      payload.Type,
+		Value:     strings.TrimSpace(payload.Value),
+		Issuer:    strings.TrimSpace(payload.Issuer),
+	}
+
+	if err := app.store.Patients.AddIdentifier(r.Context(), identifier); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, identifier); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deletePatientIdentifierHandler godoc
+//
+//	@Summary	Removes an external identifier
+//	@Tags		patient
+//	@Param		patientID		path		string	true	"Patient ID or MRN"
+//	@Param		identifierID	path		string	true	"Identifier ID"
+//	@Success	204				{object}	string
+//	@Failure	400				{object}	error
+//	@Failure	403				{object}	error
+//	@Failure	404				{object}	error
+//	@Failure	500				{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/patients/{patientID}/identifiers/{identifierID} [delete]
+func (app *application) deletePatientIdentifierHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	identifierID, err := uuid.Parse(chi.URLParam(r, "identifierID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Patients.DeleteIdentifier(r.Context(), patient.UserID, identifierID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookupPatientHandler godoc
+//
+//	@Summary		Looks a patient up by external identifier
+//	@Description	Finds the patient holding an external identifier, such as a national ID or insurance member ID. Reception and above only.
+//	@Tags			patient
+//	@Produce		json
+//	@Param			type	query		string	true	"Identifier type"
+//	@Param			value	query		string	true	"Identifier value"
+//	@Param			issuer	query		string	false	"Issuing country or payer"
+//	@Success		200		{object}	store.Patient
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/lookup [get]
+func (app *application) lookupPatientHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	idType := store.IdentifierType(qs.Get("type"))
+	value := strings.TrimSpace(qs.Get("value"))
+	issuer := strings.TrimSpace(qs.Get("issuer"))
+
+	if err := Validate.Var(string(idType), "required,oneof=national_id passport insurance_member_id driver_license other"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Var(value, "required,max=100"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	id, err := app.store.Patients.GetIDByIdentifier(ctx, idType, issuer, value)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	patient, err := app.store.Patients.GetByID(ctx, id)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, patient); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// resolvePatientID accepts either a patient's ID or MRN and returns the ID.
+func (app *application) resolvePatientID(ctx context.Context, ref string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return id, nil
+	}
+
+	if err := app.mrn.Check(ref); err != nil {
+		return uuid.Nil, err
+	}
+
+	return app.store.Patients.GetIDByMRN(ctx, strings.ToUpper(ref))
+}
+
+// canAccessPatient reports whether the authenticated user may see and change
+// the patient's record: the patient, their guardians or hospital staff.
+func (app *application) canAccessPatient(r *http.Request, patientID uuid.UUID) (bool, error) {
+	user := getUserFromContext(r)
+	if user == nil {
+		return false, nil
+	}
+
+	if user.ActsFor(patientID) {
+		return true, nil
+	}
+
+	return app.checkRolePrecedence(r.Context(), user, "doctor")
+}
+
+// patientContextMiddleware loads the patient named by {patientID}, which may
+// be either the patient's ID or MRN.
+func (app *application) patientContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, err := app.resolvePatientID(ctx, chi.URLParam(r, "patientID"))
+		if err != nil {
+			switch {
+			case errors.Is(err, mrn.ErrCheckDigit):
+				app.badRequestResponse(w, r, err)
+			case errors.Is(err, store.ErrNotFound):
+				// only staff learn whether an MRN exists
+				staff, serr := app.checkRolePrecedence(ctx, getUserFromContext(r), "doctor")
+				switch {
+				case serr != nil:
+					app.internalServerError(w, r, serr)
+				case staff:
+					app.notFoundResponse(w, r, err)
+				default:
+					app.forbiddenResponse(w, r)
+				}
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		allowed, err := app.canAccessPatient(r, id)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		patient, err := app.store.Patients.GetByID(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, patientCtx, patient)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getPatientFromCtx(r *http.Request) *store.Patient {
+	patient, _ := r.Context().Value(patientCtx).(*store.Patient)
+	return patient
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type drugKey string
+
+const drugCtx drugKey = "drug"
+
+var (
+	errBatchExpired     = errors.New("batch has already expired")
+	errAlreadyDispensed = errors.New("a prescription line was already dispensed")
+)
+
+type CreateDrugPayload struct {
+	Name         string `json:"name" validate:"required,max=255"`
+	Strength     string `json:"strength" validate:"required,max=100"`
+	Form         string `json:"form" validate:"required,max=50"`
+	Unit         string `json:"unit" validate:"required,max=30"`
+	ReorderLevel int    `json:"reorder_level" validate:"gte=0"`
+}
+
+// getDrugsHandler godoc
+//
+//	@Summary		Lists the drug catalog
+//	@Description	Lists drugs with their unexpired stock, optionally filtered by a name prefix
+//	@Tags			pharmacy
+//	@Produce		json
+//	@Param			q	query		string	false	"Name prefix"
+//	@Success		200	{array}		store.Drug
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/pharmacy/drugs [get]
+func (app *application) getDrugsHandler(w http.ResponseWriter, r *http.Request) {
+	drugs, err := app.store.Pharmacy.GetDrugs(r.Context(), strings.TrimSpace(r.URL.Query().Get("q")))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, drugs); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// createDrugHandler godoc
+//
+//	@Summary		Adds a drug to the catalog
+//	@Description	Adds a drug. Stock at or below the reorder level is reported as low.
+//	@Tags			pharmacy
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateDrugPayload	true	"Drug"
+//	@Success		201		{object}	store.Drug
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/pharmacy/drugs [post]
+func (app *application) createDrugHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateDrugPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	drug := &store.Drug{
+		Name:         strings.TrimSpace(payload.Name),
+		Strength:     strings.TrimSpace(payload.Strength),
+		Form:         strings.TrimSpace(payload.Form),
+		Unit:         strings.TrimSpace(payload.Unit),
+		ReorderLevel: payload.ReorderLevel,
+	}
+
+	if err := app.store.Pharmacy.CreateDrug(r.Context(), drug); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, drug); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getDrugHandler godoc
+//
+//	@Summary		Fetches a drug
+//	@Description	Fetches a drug with its batches in stock, earliest expiry first
+//	@Tags			pharmacy
+//	@Produce		json
+//	@Param			drugID	path		string	true	"Drug ID"
+//	@Success		200		{object}	store.Drug
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/pharmacy/drugs/{drugID} [get]
+func (app *application) getDrugHandler(w http.ResponseWriter, r *http.Request) {
+	drug := getDrugFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, drug); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type ReceiveBatchPayload struct {
+	LotNumber  string `json:"lot_number" validate:"required,max=100"`
+	ExpiryDate string `json:"expiry_date" validate:"required,datetime=2006-01-02"`
+	Quantity   int    `json:"quantity" validate:"required,gt=0"`
+	Supplier   string `json:"supplier" validate:"max=255"`
+}
+
+// receiveBatchHandler godoc
+//
+//	@Summary		Receives stock
+//	@Description	Records a goods receipt of one lot of a drug
+//	@Tags			pharmacy
+//	@Accept			json
+//	@Produce		json
+//	@Param			drugID	path		string				true	"Drug ID"
+//	@Param			payload	body		ReceiveBatchPayload	true	"Batch"
+//	@Success		201		{object}	store.DrugBatch
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/pharmacy/drugs/{drugID}/batches [post]
+func (app *application) receiveBatchHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	drug := getDrugFromCtx(r)
+
+	var payload ReceiveBatchPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.ExpiryDate <= time.Now().Format(store.DateLayout) {
+		app.badRequestResponse(w, r, errBatchExpired)
+		return
+	}
+
+	batch := &store.DrugBatch{
+		DrugID:           drug.ID,
+		LotNumber:        strings.TrimSpace(payload.LotNumber),
+		ExpiryDate:       payload.ExpiryDate,
+		QuantityReceived: payload.Quantity,
+		Supplier:         strings.TrimSpace(payload.Supplier),
+		ReceivedBy:       &user.ID,
+	}
+
+	if err := app.store.Pharmacy.ReceiveBatch(r.Context(), batch); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errors.New("lot was already received"))
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Infow("drug stock received", "drug", drug.ID, "lot", batch.LotNumber, "quantity", batch.QuantityReceived, "by", user.ID)
+
+	if err := app.jsonResponse(w, http.StatusCreated, batch); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getStockAlertsHandler godoc
+//
+//	@Summary		Lists stock alerts
+//	@Description	Lists drugs at or below their reorder level and batches in stock that expire within PHARMACY_EXPIRY_WARNING_DAYS or have expired
+//	@Tags			pharmacy
+//	@Produce		json
+//	@Success		200	{object}	store.StockAlerts
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/pharmacy/alerts [get]
+func (app *application) getStockAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	alerts, err := app.store.Pharmacy.GetAlerts(r.Context(), app.config.pharmacy.expiryWarningDays)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, alerts); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type DispenseLinePayload struct {
+	// Position is the index of the line on the prescription, from 0
+	Position int       `json:"position" validate:"gte=0"`
+	DrugID   uuid.UUID `json:"drug_id" validate:"required"`
+	Quantity int       `json:"quantity" validate:"required,gt=0"`
+}
+
+type DispensePayload struct {
+	Lines []DispenseLinePayload `json:"lines" validate:"required,min=1,max=30,unique=Position,dive"`
+	Notes string                `json:"notes" validate:"max=2000"`
+}
+
+// dispensePrescriptionHandler godoc
+//
+//	@Summary		Dispenses a prescription
+//	@Description	Hands out prescription lines as catalog drugs. Stock is taken from the unexpired batches with the earliest expiry first. Each line can be dispensed once; lines left out can be dispensed later.
+//	@Tags			pharmacy
+//	@Accept			json
+//	@Produce		json
+//	@Param			prescriptionID	path		string			true	"Prescription ID"
+//	@Param			payload			body		DispensePayload	true	"Lines to dispense"
+//	@Success		201				{object}	store.Dispensation
+//	@Failure		400				{object}	error
+//	@Failure		403				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		409				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/prescriptions/{prescriptionID}/dispensations [post]
+func (app *application) dispensePrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	prescription := getPrescriptionFromCtx(r)
+
+	var payload DispensePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if prescription.Status != store.PrescriptionIssued {
+		app.conflictResponse(w, r, errPrescriptionCancelled)
+		return
+	}
+
+	dispensation := &store.Dispensation{
+		PrescriptionID: prescription.ID,
+		PatientID:      prescription.PatientID,
+		DispensedBy:    &user.ID,
+		Notes:          payload.Notes,
+		Lines:          make([]store.DispensationLine, 0, len(payload.Lines)),
+	}
+
+	for _, line := range payload.Lines {
+		if line.Position >= len(prescription.Items) {
+			app.badRequestResponse(w, r, fmt.Errorf("prescription has no line %d", line.Position))
+			return
+		}
+
+		dispensation.Lines = append(dispensation.Lines, store.DispensationLine{
+			Position: line.Position,
+			DrugID:   line.DrugID,
+			Quantity: line.Quantity,
+		})
+	}
+
+	if err := app.store.Pharmacy.Dispense(r.Context(), dispensation); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.badRequestResponse(w, r, errors.New("drug not found"))
+		case errors.Is(err, store.ErrLocked):
+			app.conflictResponse(w, r, errPrescriptionCancelled)
+		case errors.Is(err, store.ErrConflict):
+			app.conflictResponse(w, r, errAlreadyDispensed)
+		case errors.Is(err, store.ErrInsufficientStock):
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	for _, line := range dispensation.Lines {
+		sourceKey := fmt.Sprintf("dispensation:%s:%d", prescription.ID, line.Position)
+		app.chargeFromPriceList(r.Context(), dispensation.PatientID, store.ChargeDrug, line.DrugID.String(), line.Quantity, sourceKey, user.ID)
+	}
+
+	for _, drug := range dispensation.LowStock {
+		app.logger.Warnw("drug stock low", "drug", drug.ID, "name", drug.Name, "on_hand", drug.OnHand, "reorder_level", drug.ReorderLevel)
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, dispensation); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getDispensationsHandler godoc
+//
+//	@Summary		Lists the dispensations of a prescription
+//	@Description	Lists what was handed out for a prescription and from which batches
+//	@Tags			pharmacy
+//	@Produce		json
+//	@Param			prescriptionID	path		string	true	"Prescription ID"
+//	@Success		200				{array}		store.Dispensation
+//	@Failure		403				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/prescriptions/{prescriptionID}/dispensations [get]
+func (app *application) getDispensationsHandler(w http.ResponseWriter, r *http.Request) {
+	prescription := getPrescriptionFromCtx(r)
+
+	dispensations, err := app.store.Pharmacy.GetDispensations(r.Context(), prescription.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, dispensations); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) drugContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "drugID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		drug, err := app.store.Pharmacy.GetDrug(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, drugCtx, drug)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getDrugFromCtx(r *http.Request) *store.Drug {
+	drug, _ := r.Context().Value(drugCtx).(*store.Drug)
+	return drug
+}
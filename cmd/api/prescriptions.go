@@ -0,0 +1,597 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/blob"
+	"github.com/MdHasib01/hms_server/internal/interactions"
+	"github.com/MdHasib01/hms_server/internal/pdf"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type prescriptionKey string
+
+const prescriptionCtx prescriptionKey = "prescription"
+
+// currentMedicationWindow is how far back issued prescriptions count as the
+// patient's current medication when checking for interactions.
+const currentMedicationWindow = 90 * 24 * time.Hour
+
+var (
+	errMissingLicenseNumber  = errors.New("the doctor has no license number on file")
+	errPrescriptionCancelled = errors.New("prescription is already cancelled")
+	errUnacknowledgedWarning = errors.New("the prescription has safety warnings that must be acknowledged")
+	errMissingOverrideReason = errors.New("override_reason is required to override safety warnings")
+)
+
+type PrescriptionItemPayload struct {
+	Drug         string `json:"drug" validate:"required,max=255"`
+	Strength     string `json:"strength" validate:"required,max=100"`
+	Dose         string `json:"dose" validate:"required,max=100"`
+	Frequency    string `json:"frequency" validate:"required,max=100"`
+	Duration     string `json:"duration" validate:"required,max=100"`
+	Quantity     string `json:"quantity" validate:"required,max=50"`
+	Instructions string `json:"instructions" validate:"max=1000"`
+}
+
+type CreatePrescriptionPayload struct {
+	Items                []PrescriptionItemPayload `json:"items" validate:"required,min=1,max=20,dive"`
+	AcknowledgedWarnings []string                  `json:"acknowledged_warnings" validate:"max=100,dive,max=255"`
+	OverrideReason       string                    `json:"override_reason" validate:"max=1000"`
+}
+
+type CheckPrescriptionPayload struct {
+	Items []PrescriptionItemPayload `json:"items" validate:"required,min=1,max=20,dive"`
+}
+
+type PrescriptionWarnings struct {
+	Error    string                 `json:"error,omitempty"`
+	Warnings []interactions.Warning `json:"warnings"`
+}
+
+// createPrescriptionHandler godoc
+//
+//	@Summary		Issues a prescription
+//	@Description	Issues a prescription for an encounter and renders it as a signed PDF with the hospital letterhead and the doctor's license number. Issued prescriptions cannot be changed, only cancelled.
+//	@Description	The drugs are checked against the patient's allergies and current medication. If there are warnings the request fails with 409 and the warnings; resend it with every warning code in acknowledged_warnings and an override_reason to issue the prescription anyway. Overrides are recorded with the prescription.
+//	@Tags			prescription
+//	@Accept			json
+//	@Produce		json
+//	@Param			encounterID	path		string						true	"Encounter ID"
+//	@Param			payload		body		CreatePrescriptionPayload	true	"Medication lines"
+//	@Success		201			{object}	store.Prescription
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	PrescriptionWarnings
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/encounters/{encounterID}/prescriptions [post]
+func (app *application) createPrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	encounter := getEncounterFromCtx(r)
+
+	if encounter.DoctorID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload CreatePrescriptionPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	doctor, err := app.store.Doctors.GetByID(ctx, encounter.DoctorID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if strings.TrimSpace(doctor.LicenseNumber) == "" {
+		app.conflictResponse(w, r, errMissingLicenseNumber)
+		return
+	}
+
+	warnings, err := app.checkPrescription(ctx, encounter.PatientID, payload.Items)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	acknowledged := make(map[string]bool, len(payload.AcknowledgedWarnings))
+	for _, code := range payload.AcknowledgedWarnings {
+		acknowledged[code] = true
+	}
+
+	for _, warning := range warnings {
+		if !acknowledged[warning.Code] {
+			app.prescriptionWarningsResponse(w, r, warnings)
+			return
+		}
+	}
+
+	overrideReason := strings.TrimSpace(payload.OverrideReason)
+	if len(warnings) > 0 && len(overrideReason) < 3 {
+		app.badRequestResponse(w, r, errMissingOverrideReason)
+		return
+	}
+
+	patient, err := app.documentPatient(ctx, encounter.PatientID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	prescription := &store.Prescription{
+		ID:                  uuid.New(),
+		EncounterID:         encounter.ID,
+		PatientID:           encounter.PatientID,
+		DoctorID:            doctor.UserID,
+		DoctorLicenseNumber: doctor.LicenseNumber,
+		IssuedAt:            time.Now().UTC().Truncate(time.Second),
+		Items:               make([]store.PrescriptionItem, 0, len(payload.Items)),
+	}
+
+	for _, item := range payload.Items {
+		prescription.Items = append(prescription.Items, store.PrescriptionItem(item))
+	}
+
+	prescription.Overrides = make([]store.PrescriptionOverride, 0, len(warnings))
+	for _, warning := range warnings {
+		prescription.Overrides = append(prescription.Overrides, store.PrescriptionOverride{
+			Code:         warning.Code,
+			Kind:         string(warning.Kind),
+			Severity:     string(warning.Severity),
+			Drug:         warning.Drug,
+			With:         warning.With,
+			Message:      warning.Message,
+			Reason:       overrideReason,
+			OverriddenBy: user.ID,
+		})
+	}
+
+	signed, err := prescription.SigningPayload()
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	prescription.Signature = app.signer.Sign(signed)
+
+	document, err := pdf.RenderPrescription(app.config.letterhead, app.prescriptionDocument(prescription, patient, doctor))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	prescription.PDFKey = fmt.Sprintf("%sprescriptions/%s.pdf", blob.PrivatePrefix, prescription.ID)
+
+	if err := app.blob.Put(ctx, prescription.PDFKey, bytes.NewReader(document)); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.store.Prescriptions.Create(ctx, prescription); err != nil {
+		app.deleteBlobs(r, prescription.PDFKey)
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	for _, override := range prescription.Overrides {
+		app.logger.Infow("prescription warning overridden",
+			"prescription", prescription.ID,
+			"doctor", user.ID,
+			"patient", prescription.PatientID,
+			"code", override.Code,
+			"severity", override.Severity,
+			"reason", override.Reason,
+		)
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, prescription); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// checkPrescriptionHandler godoc
+//
+//	@Summary		Checks a prescription for warnings
+//	@Description	Checks drugs against the patient's allergies and current medication without issuing anything, so the warnings can be shown while the prescription is written
+//	@Tags			prescription
+//	@Accept			json
+//	@Produce		json
+//	@Param			encounterID	path		string						true	"Encounter ID"
+//	@Param			payload		body		CheckPrescriptionPayload	true	"Medication lines"
+//	@Success		200			{object}	PrescriptionWarnings
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/encounters/{encounterID}/prescriptions/check [post]
+func (app *application) checkPrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	encounter := getEncounterFromCtx(r)
+
+	if encounter.DoctorID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload CheckPrescriptionPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	warnings, err := app.checkPrescription(r.Context(), encounter.PatientID, payload.Items)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, PrescriptionWarnings{Warnings: warnings}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// checkPrescription checks the drugs on the prescription lines against the
+// patient's allergies and recent prescriptions.
+func (app *application) checkPrescription(ctx context.Context, patientID uuid.UUID, items []PrescriptionItemPayload) ([]interactions.Warning, error) {
+	allergies, err := app.store.Allergies.GetByPatient(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := app.store.Prescriptions.GetCurrentDrugs(ctx, patientID, time.Now().Add(-currentMedicationWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	drugs := make([]string, 0, len(items))
+	for _, item := range items {
+		drugs = append(drugs, item.Drug)
+	}
+
+	known := make([]interactions.Allergy, 0, len(allergies))
+	for _, allergy := range allergies {
+		known = append(known, interactions.Allergy{
+			Substance:   allergy.Substance,
+			Intolerance: allergy.Type == store.AllergyTypeIntolerance,
+		})
+	}
+
+	return app.interactions.Check(drugs, current, known), nil
+}
+
+func (app *application) prescriptionWarningsResponse(w http.ResponseWriter, r *http.Request, warnings []interactions.Warning) {
+	app.logger.Warnw("unacknowledged prescription warnings", "method", r.Method, "path", r.URL.Path, "warnings", len(warnings))
+
+	body := PrescriptionWarnings{
+		Error:    errUnacknowledgedWarning.Error(),
+		Warnings: warnings,
+	}
+
+	if err := writeJSON(w, http.StatusConflict, body); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) prescriptionDocument(p *store.Prescription, patient *store.Patient, doctor *store.Doctor) pdf.Prescription {
+	doc := pdf.Prescription{
+		ID:                   p.ID.String(),
+		IssuedAt:             p.IssuedAt,
+		PatientName:          strings.TrimSpace(patient.FirstName + " " + patient.LastName),
+		PatientMRN:           patient.MRN,
+		PatientDOB:           patient.DateOfBirth,
+		PatientSex:           string(patient.Sex),
+		DoctorName:           strings.TrimSpace("Dr. " + doctor.FirstName + " " + doctor.LastName),
+		DoctorSpecialization: doctor.Specialization,
+		DoctorLicenseNumber:  p.DoctorLicenseNumber,
+		Signature:            p.Signature,
+		VerifyURL:            fmt.Sprintf("%s/v1/prescriptions/%s/verify", app.config.apiURL, p.ID),
+	}
+
+	for _, item := range p.Items {
+		doc.Items = append(doc.Items, pdf.PrescriptionItem(item))
+	}
+
+	return doc
+}
+
+// documentPatient loads the patient for printing on a document. Accounts
+// registered before patient profiles existed fall back to their username.
+func (app *application) documentPatient(ctx context.Context, patientID uuid.UUID) (*store.Patient, error) {
+	patient, err := app.store.Patients.GetByID(ctx, patientID)
+	if err == nil {
+		return patient, nil
+	}
+
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+
+	user, err := app.store.Users.GetByID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &store.Patient{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		FirstName: user.Username,
+	}, nil
+}
+
+// getEncounterPrescriptionsHandler godoc
+//
+//	@Summary	Lists an encounter's prescriptions
+//	@Tags		prescription
+//	@Produce	json
+//	@Param		encounterID	path		string	true	"Encounter ID"
+//	@Success	200			{array}		store.Prescription
+//	@Failure	403			{object}	error
+//	@Failure	404			{object}	error
+//	@Failure	500			{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/encounters/{encounterID}/prescriptions [get]
+func (app *application) getEncounterPrescriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	encounter := getEncounterFromCtx(r)
+
+	prescriptions, err := app.store.Prescriptions.GetByEncounter(r.Context(), encounter.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, prescriptions); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPrescriptionHandler godoc
+//
+//	@Summary	Fetches a prescription
+//	@Tags		prescription
+//	@Produce	json
+//	@Param		prescriptionID	path		string	true	"Prescription ID"
+//	@Success	200				{object}	store.Prescription
+//	@Failure	403				{object}	error
+//	@Failure	404				{object}	error
+//	@Failure	500				{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/prescriptions/{prescriptionID} [get]
+func (app *application) getPrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	prescription := getPrescriptionFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, prescription); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// downloadPrescriptionPDFHandler godoc
+//
+//	@Summary		Downloads a prescription PDF
+//	@Description	Downloads the signed PDF produced when the prescription was issued
+//	@Tags			prescription
+//	@Produce		application/pdf
+//	@Param			prescriptionID	path		string	true	"Prescription ID"
+//	@Success		200				{file}		file
+//	@Failure		403				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/prescriptions/{prescriptionID}/pdf [get]
+func (app *application) downloadPrescriptionPDFHandler(w http.ResponseWriter, r *http.Request) {
+	prescription := getPrescriptionFromCtx(r)
+
+	fileName := fmt.Sprintf("prescription-%s.pdf", prescription.ID)
+	app.writeAttachment(w, r, prescription.PDFKey, fileName, "application/pdf")
+}
+
+type CancelPrescriptionPayload struct {
+	Reason string `json:"reason" validate:"required,min=3,max=1000"`
+}
+
+// cancelPrescriptionHandler godoc
+//
+//	@Summary		Cancels a prescription
+//	@Description	Cancels an issued prescription. Only the prescribing doctor can cancel it, and a reason is required.
+//	@Tags			prescription
+//	@Accept			json
+//	@Produce		json
+//	@Param			prescriptionID	path		string						true	"Prescription ID"
+//	@Param			payload			body		CancelPrescriptionPayload	true	"Cancellation reason"
+//	@Success		200				{object}	store.Prescription
+//	@Failure		400				{object}	error
+//	@Failure		403				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		409				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/prescriptions/{prescriptionID}/cancel [post]
+func (app *application) cancelPrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	prescription := getPrescriptionFromCtx(r)
+
+	if prescription.DoctorID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload CancelPrescriptionPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err := app.store.Prescriptions.Cancel(r.Context(), prescription, user.ID, strings.TrimSpace(payload.Reason))
+	if err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errPrescriptionCancelled)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, prescription); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// PrescriptionVerification only carries the prescription's details when the
+// signature matches.
+type PrescriptionVerification struct {
+	ID                  uuid.UUID                `json:"id"`
+	Valid               bool                     `json:"valid"`
+	Status              store.PrescriptionStatus `json:"status,omitempty"`
+	IssuedAt            *time.Time               `json:"issued_at,omitempty"`
+	DoctorLicenseNumber string                   `json:"doctor_license_number,omitempty"`
+	CancelledAt         *time.Time               `json:"cancelled_at,omitempty"`
+}
+
+// verifyPrescriptionHandler godoc
+//
+//	@Summary		Verifies a prescription
+//	@Description	Checks a signature printed on a prescription against the server. Pharmacies can call it without an account; no patient details are returned, and nothing about the prescription unless the signature matches.
+//	@Tags			prescription
+//	@Produce		json
+//	@Param			prescriptionID	path		string	true	"Prescription ID"
+//	@Param			signature		query		string	true	"Signature printed on the prescription"
+//	@Success		200				{object}	PrescriptionVerification
+//	@Failure		400				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Router			/prescriptions/{prescriptionID}/verify [get]
+func (app *application) verifyPrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "prescriptionID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	signature := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("signature")))
+	if err := Validate.Var(signature, "required,hexadecimal,len=64"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	prescription, err := app.store.Prescriptions.GetByID(r.Context(), id)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	signed, err := prescription.SigningPayload()
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// the printed signature must sign the stored row as it is now, compared
+	// in constant time so it cannot be guessed byte by byte
+	verification := PrescriptionVerification{ID: prescription.ID}
+
+	if app.signer.Verify(signed, signature) {
+		verification.Valid = true
+		verification.Status = prescription.Status
+		verification.IssuedAt = &prescription.IssuedAt
+		verification.DoctorLicenseNumber = prescription.DoctorLicenseNumber
+		verification.CancelledAt = prescription.CancelledAt
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, verification); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// canReadPrescription reports whether the authenticated user may see the
+// prescription: the patient, the prescribing doctor or another treating
+// doctor of the patient.
+func (app *application) canReadPrescription(r *http.Request, prescription *store.Prescription) (bool, error) {
+	user := getUserFromContext(r)
+	if user == nil {
+		return false, nil
+	}
+
+	// pharmacists read prescriptions to dispense them
+	if user.ActsFor(prescription.PatientID) || user.ID == prescription.DoctorID || user.Role.Name == "pharmacist" {
+		return true, nil
+	}
+
+	return app.store.Encounters.IsTreatingDoctor(r.Context(), user.ID, prescription.PatientID)
+}
+
+func (app *application) prescriptionContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "prescriptionID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		prescription, err := app.store.Prescriptions.GetByID(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		allowed, err := app.canReadPrescription(r, prescription)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		ctx = context.WithValue(ctx, prescriptionCtx, prescription)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getPrescriptionFromCtx(r *http.Request) *store.Prescription {
+	prescription, _ := r.Context().Value(prescriptionCtx).(*store.Prescription)
+	return prescription
+}
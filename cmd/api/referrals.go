@@ -0,0 +1,698 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/mailer"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type referralKey string
+
+const referralCtx referralKey = "referral"
+
+var (
+	errSelfReferral          = errors.New("a doctor cannot refer a patient to themselves")
+	errReferralClosed        = errors.New("referral was declined or closed")
+	errReferralNotOpen       = errors.New("referral was already answered")
+	errReferralBooked        = errors.New("referral already has a scheduled appointment")
+	errReferralHeld          = errors.New("referral was taken on by another doctor")
+	errReferralDoctorMissing = errors.New("doctor_id is required until a doctor takes the referral on")
+	errNotInDepartment       = errors.New("doctor is not in the referral's department")
+)
+
+type CreateReferralPayload struct {
+	// ToDoctorID refers to a doctor, Department to any doctor of that
+	// specialization
+	ToDoctorID *uuid.UUID            `json:"to_doctor_id" validate:"required_without=Department"`
+	Department string                `json:"department" validate:"required_without=ToDoctorID,excluded_with=ToDoctorID,max=100"`
+	Reason     string                `json:"reason" validate:"required,max=2000"`
+	Urgency    store.ReferralUrgency `json:"urgency" validate:"omitempty,oneof=routine urgent emergency"`
+	Notes      string                `json:"notes" validate:"max=10000"`
+	// EncounterID attaches the consultation the referral came out of
+	EncounterID *uuid.UUID `json:"encounter_id"`
+}
+
+// createReferralHandler godoc
+//
+//	@Summary		Refers a patient
+//	@Description	Refers the patient to a doctor or to a department, with the reason, urgency and notes, optionally attaching the encounter it came out of. The referring and receiving doctors are emailed; a department referral goes to all of its doctors. Doctors only.
+//	@Tags			referrals
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string					true	"Patient ID or MRN"
+//	@Param			payload		body		CreateReferralPayload	true	"Referral"
+//	@Success		201			{object}	store.Referral
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/referrals [post]
+func (app *application) createReferralHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+	ctx := r.Context()
+
+	var payload CreateReferralPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Department = strings.TrimSpace(payload.Department)
+	payload.Reason = strings.TrimSpace(payload.Reason)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.ToDoctorID != nil && *payload.ToDoctorID == user.ID {
+		app.badRequestResponse(w, r, errSelfReferral)
+		return
+	}
+
+	if payload.EncounterID != nil {
+		encounter, err := app.store.Encounters.GetByID(ctx, *payload.EncounterID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if err != nil || encounter.PatientID != patient.UserID {
+			app.badRequestResponse(w, r, errors.New("encounter not found"))
+			return
+		}
+	}
+
+	referral := &store.Referral{
+		PatientID:         patient.UserID,
+		ReferringDoctorID: user.ID,
+		EncounterID:       payload.EncounterID,
+		ToDoctorID:        payload.ToDoctorID,
+		Department:        payload.Department,
+		Reason:            payload.Reason,
+		Urgency:           payload.Urgency,
+		Notes:             payload.Notes,
+	}
+
+	if referral.Urgency == "" {
+		referral.Urgency = store.ReferralRoutine
+	}
+
+	if err := app.store.Referrals.Create(ctx, referral); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			if payload.ToDoctorID != nil {
+				app.badRequestResponse(w, r, errors.New("doctor not found"))
+			} else {
+				app.badRequestResponse(w, r, errors.New("no doctor practises in that department"))
+			}
+		case store.ErrConflict:
+			app.badRequestResponse(w, r, errSelfReferral)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.notifyReferral(ctx, referral, "New referral",
+		fmt.Sprintf("%s referred a patient to %s.", referral.ReferringDoctorName, referralRecipientName(referral)))
+
+	if err := app.jsonResponse(w, http.StatusCreated, referral); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getPatientReferralsHandler godoc
+//
+//	@Summary		Lists a patient's referrals
+//	@Description	Lists the patient's referrals, the most urgent first and then the oldest.
+//	@Tags			referrals
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Param			status		query		string	false	"sent, accepted, declined, scheduled or closed"
+//	@Param			limit		query		int		false	"Limit"
+//	@Param			offset		query		int		false	"Offset"
+//	@Success		200			{array}		store.Referral
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/referrals [get]
+func (app *application) getPatientReferralsHandler(w http.ResponseWriter, r *http.Request) {
+	patient := getPatientFromCtx(r)
+
+	q := store.ReferralQuery{
+		PatientID: &patient.UserID,
+		Limit:     20,
+		Offset:    0,
+	}
+
+	q, err := q.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(q); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	referrals, err := app.store.Referrals.List(r.Context(), q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, referrals); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getReferralsHandler godoc
+//
+//	@Summary		Lists referrals
+//	@Description	Lists referrals, the most urgent first and then the oldest. Doctors see the referrals sent to them or to their department by default, or the ones they sent with direction=outgoing. Other staff see all referrals.
+//	@Tags			referrals
+//	@Produce		json
+//	@Param			direction	query		string	false	"incoming or outgoing, for doctors"
+//	@Param			department	query		string	false	"Department"
+//	@Param			status		query		string	false	"sent, accepted, declined, scheduled or closed"
+//	@Param			urgency		query		string	false	"routine, urgent or emergency"
+//	@Param			limit		query		int		false	"Limit"
+//	@Param			offset		query		int		false	"Offset"
+//	@Success		200			{array}		store.Referral
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/referrals [get]
+func (app *application) getReferralsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	q := store.ReferralQuery{
+		Limit:  20,
+		Offset: 0,
+	}
+
+	q, err := q.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(q); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if user.Role.Name == "doctor" {
+		switch q.Direction {
+		case "outgoing":
+			q.ReferringDoctorID = &user.ID
+		default:
+			doctor, err := app.store.Doctors.GetByID(ctx, user.ID)
+			if err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+			q.ReceivingDoctorID = &user.ID
+			q.ReceivingDepartment = doctor.Specialization
+		}
+	}
+
+	referrals, err := app.store.Referrals.List(ctx, q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, referrals); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getReferralHandler godoc
+//
+//	@Summary	Fetches a referral
+//	@Tags		referrals
+//	@Produce	json
+//	@Param		referralID	path		string	true	"Referral ID"
+//	@Success	200			{object}	store.Referral
+//	@Failure	400			{object}	error
+//	@Failure	403			{object}	error
+//	@Failure	404			{object}	error
+//	@Failure	500			{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/referrals/{referralID} [get]
+func (app *application) getReferralHandler(w http.ResponseWriter, r *http.Request) {
+	referral := getReferralFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, referral); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// acceptReferralHandler godoc
+//
+//	@Summary		Accepts a referral
+//	@Description	Takes the referral on. A department referral is assigned to the doctor who accepts it. Receiving doctor only.
+//	@Tags			referrals
+//	@Produce		json
+//	@Param			referralID	path		string	true	"Referral ID"
+//	@Success		200			{object}	store.Referral
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/referrals/{referralID}/accept [post]
+func (app *application) acceptReferralHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	referral := getReferralFromCtx(r)
+	ctx := r.Context()
+
+	receiver, err := app.isReferralReceiver(ctx, user, referral)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !receiver {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Referrals.Accept(ctx, referral, user.ID); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errReferralNotOpen)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.notifyReferral(ctx, referral, "Referral accepted",
+		fmt.Sprintf("%s accepted the referral from %s.", referral.ToDoctorName, referral.ReferringDoctorName))
+
+	if err := app.jsonResponse(w, http.StatusOK, referral); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type DeclineReferralPayload struct {
+	Reason string `json:"reason" validate:"required,max=2000"`
+}
+
+// declineReferralHandler godoc
+//
+//	@Summary		Declines a referral
+//	@Description	Turns down a referral that has not been booked, with the reason. Receiving doctor only.
+//	@Tags			referrals
+//	@Accept			json
+//	@Produce		json
+//	@Param			referralID	path		string					true	"Referral ID"
+//	@Param			payload		body		DeclineReferralPayload	true	"Reason"
+//	@Success		200			{object}	store.Referral
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/referrals/{referralID}/decline [post]
+func (app *application) declineReferralHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	referral := getReferralFromCtx(r)
+	ctx := r.Context()
+
+	var payload DeclineReferralPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Reason = strings.TrimSpace(payload.Reason)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	receiver, err := app.isReferralReceiver(ctx, user, referral)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !receiver {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Referrals.Decline(ctx, referral, user.ID, payload.Reason); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errReferralNotOpen)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.notifyReferral(ctx, referral, "Referral declined",
+		fmt.Sprintf("%s declined the referral from %s.", referral.ToDoctorName, referral.ReferringDoctorName))
+
+	if err := app.jsonResponse(w, http.StatusOK, referral); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type BookReferralPayload struct {
+	// DoctorID defaults to the doctor who took the referral on, or to the
+	// doctor booking it
+	DoctorID         *uuid.UUID             `json:"doctor_id"`
+	AppointmentTime  time.Time              `json:"appointment_time" validate:"required"`
+	VisitType        store.VisitType        `json:"visit_type" validate:"omitempty,oneof=new_patient follow_up"`
+	ConsultationMode store.ConsultationMode `json:"consultation_mode" validate:"omitempty,oneof=in_person online"`
+}
+
+// bookReferralHandler godoc
+//
+//	@Summary		Books the appointment of a referral
+//	@Description	Books an appointment with the receiving doctor and links it to the referral, which becomes scheduled. Reception must name a department doctor until one has taken the referral on. A referral whose appointment was cancelled can be booked again. Receiving doctor and reception only.
+//	@Tags			referrals
+//	@Accept			json
+//	@Produce		json
+//	@Param			referralID	path		string				true	"Referral ID"
+//	@Param			payload		body		BookReferralPayload	true	"Appointment"
+//	@Success		201			{object}	store.Appointment
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/referrals/{referralID}/appointment [post]
+func (app *application) bookReferralHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	referral := getReferralFromCtx(r)
+	ctx := r.Context()
+
+	var payload BookReferralPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var doctorID uuid.UUID
+
+	switch user.Role.Name {
+	case "doctor":
+		receiver, err := app.isReferralReceiver(ctx, user, referral)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !receiver || (payload.DoctorID != nil && *payload.DoctorID != user.ID) {
+			app.forbiddenResponse(w, r)
+			return
+		}
+		doctorID = user.ID
+	case "receptionist", "admin":
+		switch {
+		case referral.ToDoctorID != nil:
+			doctorID = *referral.ToDoctorID
+		case payload.DoctorID != nil:
+			doctorID = *payload.DoctorID
+		default:
+			app.badRequestResponse(w, r, errReferralDoctorMissing)
+			return
+		}
+	default:
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if referral.ToDoctorID != nil && *referral.ToDoctorID != doctorID {
+		app.conflictResponse(w, r, errReferralHeld)
+		return
+	}
+
+	if referral.ToDoctorID == nil {
+		doctor, err := app.store.Doctors.GetByID(ctx, doctorID)
+		if err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.badRequestResponse(w, r, errors.New("doctor not found"))
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		if !strings.EqualFold(doctor.Specialization, referral.Department) || doctorID == referral.ReferringDoctorID {
+			app.badRequestResponse(w, r, errNotInDepartment)
+			return
+		}
+	}
+
+	appointment := &store.Appointment{
+		PatientID:        referral.PatientID,
+		DoctorID:         doctorID,
+		AppointmentTime:  payload.AppointmentTime,
+		VisitType:        payload.VisitType,
+		ConsultationMode: payload.ConsultationMode,
+	}
+
+	if appointment.VisitType == "" {
+		appointment.VisitType = store.VisitTypeNewPatient
+	}
+	if appointment.ConsultationMode == "" {
+		appointment.ConsultationMode = store.ConsultationModeInPerson
+	}
+
+	if err := app.store.Referrals.BookAppointment(ctx, referral, appointment); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errReferralBooked)
+		case store.ErrLocked:
+			if referral.Status == store.ReferralDeclined || referral.Status == store.ReferralClosed {
+				app.conflictResponse(w, r, errReferralClosed)
+			} else {
+				app.conflictResponse(w, r, errReferralHeld)
+			}
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.notifyReferral(ctx, referral, "Referral appointment booked",
+		fmt.Sprintf("An appointment with %s was booked for the referral from %s.", referral.ToDoctorName, referral.ReferringDoctorName))
+
+	if err := app.jsonResponse(w, http.StatusCreated, appointment); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// closeReferralHandler godoc
+//
+//	@Summary		Closes a referral
+//	@Description	Ends a referral that was not declined, once the patient was seen or when it is no longer needed. Referring and receiving doctors only.
+//	@Tags			referrals
+//	@Produce		json
+//	@Param			referralID	path		string	true	"Referral ID"
+//	@Success		200			{object}	store.Referral
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/referrals/{referralID}/close [post]
+func (app *application) closeReferralHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	referral := getReferralFromCtx(r)
+	ctx := r.Context()
+
+	if user.ID != referral.ReferringDoctorID {
+		receiver, err := app.isReferralReceiver(ctx, user, referral)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !receiver {
+			app.forbiddenResponse(w, r)
+			return
+		}
+	}
+
+	if err := app.store.Referrals.Close(ctx, referral, user.ID); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errReferralClosed)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.notifyReferral(ctx, referral, "Referral closed",
+		fmt.Sprintf("The referral from %s to %s was closed.", referral.ReferringDoctorName, referralRecipientName(referral)))
+
+	if err := app.jsonResponse(w, http.StatusOK, referral); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// isReferralReceiver reports whether the user is on the receiving side of
+// the referral: the doctor it was sent to or taken on by, or while nobody
+// has, any doctor of its department other than the referring one.
+func (app *application) isReferralReceiver(ctx context.Context, user *store.User, referral *store.Referral) (bool, error) {
+	if user.Role.Name != "doctor" || user.ID == referral.ReferringDoctorID {
+		return false, nil
+	}
+
+	if referral.ToDoctorID != nil {
+		return *referral.ToDoctorID == user.ID, nil
+	}
+
+	doctor, err := app.store.Doctors.GetByID(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(doctor.Specialization, referral.Department), nil
+}
+
+// referralRecipientName names who the referral is with: the doctor, or the
+// department while nobody has taken it on.
+func referralRecipientName(referral *store.Referral) string {
+	if referral.ToDoctorID == nil {
+		return referral.Department
+	}
+	return referral.ToDoctorName
+}
+
+// notifyReferral emails the referring and receiving doctors about a change
+// to the referral. The change is already saved, so failed emails are only
+// logged.
+func (app *application) notifyReferral(ctx context.Context, referral *store.Referral, headline, message string) {
+	recipients, err := app.store.Referrals.GetRecipients(ctx, referral)
+	if err != nil {
+		app.logger.Errorw("error loading referral recipients", "referral", referral.ID, "error", err)
+		return
+	}
+
+	patient, err := app.documentPatient(ctx, referral.PatientID)
+	if err != nil {
+		app.logger.Errorw("error loading patient for referral email", "referral", referral.ID, "error", err)
+		return
+	}
+
+	var appointmentTime string
+	if referral.AppointmentTime != nil && referral.Status == store.ReferralScheduled {
+		appointmentTime = referral.AppointmentTime.Format("2006-01-02 15:04 MST")
+	}
+
+	isProdEnv := app.config.env == "production"
+
+	for _, recipient := range recipients {
+		vars := struct {
+			Username        string
+			Headline        string
+			Message         string
+			PatientName     string
+			PatientMRN      string
+			From            string
+			To              string
+			Urgency         string
+			Reason          string
+			AppointmentTime string
+			ResponseNote    string
+			ReferralURL     string
+		}{
+			Username:        recipient.Username,
+			Headline:        headline,
+			Message:         message,
+			PatientName:     strings.TrimSpace(patient.FirstName + " " + patient.LastName),
+			PatientMRN:      patient.MRN,
+			From:            referral.ReferringDoctorName,
+			To:              referralRecipientName(referral),
+			Urgency:         string(referral.Urgency),
+			Reason:          referral.Reason,
+			AppointmentTime: appointmentTime,
+			ResponseNote:    referral.ResponseNote,
+			ReferralURL:     fmt.Sprintf("%s/referrals/%s", app.config.frontendURL, referral.ID),
+		}
+
+		status, err := app.mailer.Send(mailer.ReferralTemplate, recipient.Username, recipient.Email, vars, !isProdEnv)
+		if err != nil {
+			app.logger.Errorw("error sending referral email", "referral", referral.ID, "user", recipient.ID, "error", err)
+			continue
+		}
+
+		app.logger.Infow("referral email sent", "referral", referral.ID, "user", recipient.ID, "status", status)
+	}
+}
+
+// referralContextMiddleware loads the referral named by {referralID} for
+// the patient, their guardians and staff.
+func (app *application) referralContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "referralID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		referral, err := app.store.Referrals.GetByID(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		allowed, err := app.canAccessPatient(r, referral.PatientID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !allowed {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		ctx = context.WithValue(ctx, referralCtx, referral)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getReferralFromCtx(r *http.Request) *store.Referral {
+	referral, _ := r.Context().Value(referralCtx).(*store.Referral)
+	return referral
+}
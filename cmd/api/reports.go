@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/store"
+)
+
+type DepartmentDiagnoses struct {
+	Department string                 `json:"department"`
+	Diagnoses  []store.DiagnosisCount `json:"diagnoses"`
+}
+
+// topDiagnosesReportHandler godoc
+//
+//	@Summary		Top diagnoses per department
+//	@Description	Counts diagnoses on encounters signed in the period, grouped by the specialization of the treating doctor. The period defaults to the last 30 days.
+//	@Tags			reports
+//	@Produce		json
+//	@Param			from			query		string	false	"First day, YYYY-MM-DD"
+//	@Param			to				query		string	false	"Last day, YYYY-MM-DD"
+//	@Param			limit			query		int		false	"Diagnoses per department, 1-50 (default 10)"
+//	@Param			primary_only	query		bool	false	"Count primary diagnoses only"
+//	@Success		200				{array}		DepartmentDiagnoses
+//	@Failure		400				{object}	error
+//	@Failure		403				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/reports/diagnoses/top [get]
+func (app *application) topDiagnosesReportHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -29)
+	to := today
+
+	if v := qs.Get("from"); v != "" {
+		parsed, err := time.Parse(store.DateLayout, v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		from = parsed
+	}
+
+	if v := qs.Get("to"); v != "" {
+		parsed, err := time.Parse(store.DateLayout, v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		to = parsed
+	}
+
+	if to.Before(from) {
+		app.badRequestResponse(w, r, errors.New("to must not be before from"))
+		return
+	}
+
+	limit := 10
+	if v := qs.Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		limit = parsed
+	}
+
+	if err := Validate.Var(limit, "gte=1,lte=50"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	primaryOnly := false
+	if v := qs.Get("primary_only"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		primaryOnly = parsed
+	}
+
+	// to is inclusive for callers; the store takes an exclusive bound
+	counts, err := app.store.Reports.TopDiagnoses(r.Context(), from, to.AddDate(0, 0, 1), limit, primaryOnly)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	departments := []DepartmentDiagnoses{}
+	for _, count := range counts {
+		n := len(departments)
+		if n == 0 || departments[n-1].Department != count.Department {
+			departments = append(departments, DepartmentDiagnoses{Department: count.Department})
+			n++
+		}
+		departments[n-1].Diagnoses = append(departments[n-1].Diagnoses, count)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, departments); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
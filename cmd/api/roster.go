@@ -0,0 +1,1063 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/roster"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type rosterShiftKey string
+
+const rosterShiftCtx rosterShiftKey = "rosterShift"
+
+type shiftSwapKey string
+
+const shiftSwapCtx shiftSwapKey = "shiftSwap"
+
+// maxRosterDays caps how many days one roster listing covers.
+const maxRosterDays = 92
+
+var (
+	errRosterRange        = errors.New("to must not be before from")
+	errRosterRangeTooLong = errors.New("date range is too long")
+	errCannotWorkShift    = errors.New("cannot work this shift")
+	errRosterClash        = errors.New("shift clashes with another shift or leaves less than the minimum rest")
+	errShiftStarted       = errors.New("shift has already started")
+	errSwapSelf           = errors.New("cannot swap a shift with yourself")
+	errSwapOpen           = errors.New("shift already has an open swap")
+	errSwapNotOpen        = errors.New("swap is no longer open")
+	errSwapNotApprovable  = errors.New("swap is not accepted or its shifts have started or changed hands")
+	errCounterpartShift   = errors.New("counterpart shift must be an upcoming shift of the colleague")
+	errDepartmentRequired = errors.New("department is required")
+)
+
+// parseRosterDays reads the from and to days, both inclusive, of a roster
+// query. It returns from at midnight UTC and the midnight after to, which
+// defaults to days after from; from defaults to today.
+func parseRosterDays(r *http.Request, days int) (time.Time, time.Time, error) {
+	qs := r.URL.Query()
+
+	y, m, d := time.Now().UTC().Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if v := qs.Get("from"); v != "" {
+		parsed, err := time.Parse(store.DateLayout, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = parsed
+	}
+
+	to := from.AddDate(0, 0, days)
+	if v := qs.Get("to"); v != "" {
+		parsed, err := time.Parse(store.DateLayout, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, errRosterRange
+	}
+
+	return from, to, nil
+}
+
+// canWorkShift reports whether the user may be rostered on the template's
+// shifts: only its role when it has one, otherwise anyone on staff.
+func canWorkShift(user *store.User, t *store.ShiftTemplate) bool {
+	if t.Role != nil {
+		return user.Role.Name == *t.Role
+	}
+	return user.Role.Name != "patient"
+}
+
+// rosterableUser fetches a user who may work the template's shifts. It
+// returns an error wrapping errCannotWorkShift for anyone else.
+func (app *application) rosterableUser(ctx context.Context, id uuid.UUID, t *store.ShiftTemplate) (*store.User, error) {
+	user, err := app.store.Users.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("%w: user %s not found", errCannotWorkShift, id)
+		}
+		return nil, err
+	}
+
+	if !canWorkShift(user, t) {
+		return nil, fmt.Errorf("%w: %s cannot work %s", errCannotWorkShift, user.Username, t.Name)
+	}
+
+	return user, nil
+}
+
+type CreateShiftTemplatePayload struct {
+	Name string `json:"name" validate:"required,max=100"`
+	// Role limits the shift to one role, such as nurse
+	Role       *string `json:"role" validate:"omitempty,oneof=doctor nurse receptionist lab pharmacist"`
+	Department string  `json:"department" validate:"required_if=OnCall true,max=100"`
+	StartsAt   string  `json:"starts_at" validate:"required,datetime=15:04"`
+	EndsAt     string  `json:"ends_at" validate:"required,datetime=15:04"`
+	OnCall     bool    `json:"on_call"`
+}
+
+// createShiftTemplateHandler godoc
+//
+//	@Summary		Adds a shift template
+//	@Description	Adds a recurring shift, times in UTC. A shift ending at or before its start runs overnight. On-call shifts need a department, which the on-call lookup matches.
+//	@Tags			roster
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateShiftTemplatePayload	true	"Shift template"
+//	@Success		201		{object}	store.ShiftTemplate
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/templates [post]
+func (app *application) createShiftTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateShiftTemplatePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Name = strings.TrimSpace(payload.Name)
+	payload.Department = strings.TrimSpace(payload.Department)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	template := &store.ShiftTemplate{
+		Name:       payload.Name,
+		Role:       payload.Role,
+		Department: payload.Department,
+		StartsAt:   payload.StartsAt,
+		EndsAt:     payload.EndsAt,
+		OnCall:     payload.OnCall,
+	}
+
+	if err := app.store.Roster.CreateTemplate(r.Context(), template); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		case store.ErrNotFound:
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, template); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getShiftTemplatesHandler godoc
+//
+//	@Summary	Lists shift templates
+//	@Tags		roster
+//	@Produce	json
+//	@Success	200	{array}		store.ShiftTemplate
+//	@Failure	403	{object}	error
+//	@Failure	500	{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/roster/templates [get]
+func (app *application) getShiftTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	templates, err := app.store.Roster.GetTemplates(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, templates); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type RosterAssignmentPayload struct {
+	TemplateID uuid.UUID `json:"template_id" validate:"required"`
+	// UserIDs are the staff who take turns on the shift
+	UserIDs []uuid.UUID `json:"user_ids" validate:"required,min=1,max=100,unique"`
+	// StaffPerShift defaults to 1
+	StaffPerShift int `json:"staff_per_shift" validate:"gte=0,lte=50"`
+	// Days limits the shift to some weekdays, every day by default
+	Days []string `json:"days" validate:"max=7,unique,dive,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+}
+
+type GenerateRosterPayload struct {
+	From        string                    `json:"from" validate:"required,datetime=2006-01-02"`
+	Period      string                    `json:"period" validate:"required,oneof=week month"`
+	Assignments []RosterAssignmentPayload `json:"assignments" validate:"required,min=1,max=50,dive"`
+}
+
+// RosterPlan is a generated roster: the shifts added and the ones that
+// could not be fully staffed.
+type RosterPlan struct {
+	Shifts []*store.RosterShift `json:"shifts"`
+	Gaps   []roster.Gap         `json:"gaps"`
+}
+
+// generateRosterHandler godoc
+//
+//	@Summary		Generates a roster
+//	@Description	Rosters a week or a calendar month from the given day. Each assignment rotates a shift template through its staff, skipping anyone who would work within the minimum rest of another shift, rostered or generated. Slots left short are returned as gaps. Admin only.
+//	@Tags			roster
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		GenerateRosterPayload	true	"Period and assignments"
+//	@Success		201		{object}	RosterPlan
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/generate [post]
+func (app *application) generateRosterHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	var payload GenerateRosterPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	for i := range payload.Assignments {
+		for j, day := range payload.Assignments[i].Days {
+			payload.Assignments[i].Days[j] = strings.ToLower(strings.TrimSpace(day))
+		}
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	from, err := time.Parse(store.DateLayout, payload.From)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	to := from.AddDate(0, 0, 7)
+	if payload.Period == "month" {
+		to = from.AddDate(0, 1, 0)
+	}
+
+	rest := app.config.roster.minRest
+	templates := make([]*store.ShiftTemplate, len(payload.Assignments))
+	staff := []uuid.UUID{}
+
+	for i, assignment := range payload.Assignments {
+		template, err := app.store.Roster.GetTemplate(ctx, assignment.TemplateID)
+		if err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+		templates[i] = template
+
+		for _, id := range assignment.UserIDs {
+			if _, err := app.rosterableUser(ctx, id, template); err != nil {
+				switch {
+				case errors.Is(err, errCannotWorkShift):
+					app.badRequestResponse(w, r, err)
+				default:
+					app.internalServerError(w, r, err)
+				}
+				return
+			}
+			staff = append(staff, id)
+		}
+	}
+
+	// overnight shifts run past to, and rest reaches either side
+	rostered, err := app.store.Roster.GetShifts(ctx, store.RosterQuery{
+		From:    from.Add(-rest),
+		To:      to.AddDate(0, 0, 1).Add(rest),
+		UserIDs: staff,
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	busy := make(map[uuid.UUID][]roster.Interval)
+	for _, shift := range rostered {
+		busy[shift.UserID] = append(busy[shift.UserID], roster.Interval{Start: shift.StartsAt, End: shift.EndsAt})
+	}
+
+	slots := []roster.Slot{}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		weekday := strings.ToLower(day.Weekday().String())
+
+		for i, assignment := range payload.Assignments {
+			if len(assignment.Days) > 0 && !containsString(assignment.Days, weekday) {
+				continue
+			}
+
+			window, err := roster.Window(day, templates[i].StartsAt, templates[i].EndsAt)
+			if err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+
+			needed := assignment.StaffPerShift
+			if needed == 0 {
+				needed = 1
+			}
+
+			slots = append(slots, roster.Slot{
+				TemplateID: templates[i].ID,
+				Day:        day,
+				Window:     window,
+				Staff:      assignment.UserIDs,
+				Needed:     needed,
+			})
+		}
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		return slots[i].Window.Start.Before(slots[j].Window.Start)
+	})
+
+	assignments, gaps := roster.Plan(slots, busy, rest)
+
+	shifts := make([]*store.RosterShift, len(assignments))
+	for i, a := range assignments {
+		shifts[i] = &store.RosterShift{
+			TemplateID: a.TemplateID,
+			UserID:     a.UserID,
+			ShiftDate:  a.Day.Format(store.DateLayout),
+			StartsAt:   a.Window.Start,
+			EndsAt:     a.Window.End,
+			CreatedBy:  &user.ID,
+		}
+	}
+
+	if len(shifts) > 0 {
+		if err := app.store.Roster.AddShifts(ctx, shifts, rest); err != nil {
+			switch err {
+			case store.ErrConflict:
+				app.conflictResponse(w, r, errRosterClash)
+			case store.ErrNotFound:
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, RosterPlan{Shifts: shifts, Gaps: gaps}); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// getRosterHandler godoc
+//
+//	@Summary		Lists rostered shifts
+//	@Description	Lists the shifts overlapping the days from from to to (UTC, the next week by default, at most 92 days) in order of start. Staff only.
+//	@Tags			roster
+//	@Produce		json
+//	@Param			from		query		string	false	"First day, YYYY-MM-DD"
+//	@Param			to			query		string	false	"Last day, YYYY-MM-DD"
+//	@Param			user_id		query		string	false	"Staff member"
+//	@Param			department	query		string	false	"Department"
+//	@Success		200			{array}		store.RosterShift
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster [get]
+func (app *application) getRosterHandler(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRosterDays(r, 7)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if to.Sub(from) > maxRosterDays*24*time.Hour {
+		app.badRequestResponse(w, r, errRosterRangeTooLong)
+		return
+	}
+
+	q := store.RosterQuery{
+		From:       from,
+		To:         to,
+		Department: strings.TrimSpace(r.URL.Query().Get("department")),
+	}
+
+	if v := r.URL.Query().Get("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		q.UserIDs = []uuid.UUID{id}
+	}
+
+	shifts, err := app.store.Roster.GetShifts(r.Context(), q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, shifts); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type AddRosterShiftPayload struct {
+	TemplateID uuid.UUID `json:"template_id" validate:"required"`
+	UserID     uuid.UUID `json:"user_id" validate:"required"`
+	Date       string    `json:"date" validate:"required,datetime=2006-01-02"`
+}
+
+// addRosterShiftHandler godoc
+//
+//	@Summary		Rosters a shift
+//	@Description	Rosters one staff member on a template's shift starting on the date. Admin only.
+//	@Tags			roster
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		AddRosterShiftPayload	true	"Shift"
+//	@Success		201		{object}	store.RosterShift
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/shifts [post]
+func (app *application) addRosterShiftHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	var payload AddRosterShiftPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	day, err := time.Parse(store.DateLayout, payload.Date)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	template, err := app.store.Roster.GetTemplate(ctx, payload.TemplateID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if _, err := app.rosterableUser(ctx, payload.UserID, template); err != nil {
+		switch {
+		case errors.Is(err, errCannotWorkShift):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	window, err := roster.Window(day, template.StartsAt, template.EndsAt)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	shift := &store.RosterShift{
+		TemplateID: template.ID,
+		UserID:     payload.UserID,
+		ShiftDate:  payload.Date,
+		StartsAt:   window.Start,
+		EndsAt:     window.End,
+		CreatedBy:  &user.ID,
+	}
+
+	if err := app.store.Roster.AddShifts(ctx, []*store.RosterShift{shift}, app.config.roster.minRest); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errRosterClash)
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, shift); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getRosterShiftHandler godoc
+//
+//	@Summary	Fetches a rostered shift
+//	@Tags		roster
+//	@Produce	json
+//	@Param		shiftID	path		string	true	"Shift ID"
+//	@Success	200		{object}	store.RosterShift
+//	@Failure	400		{object}	error
+//	@Failure	403		{object}	error
+//	@Failure	404		{object}	error
+//	@Failure	500		{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/roster/shifts/{shiftID} [get]
+func (app *application) getRosterShiftHandler(w http.ResponseWriter, r *http.Request) {
+	if err := app.jsonResponse(w, http.StatusOK, getRosterShiftFromCtx(r)); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// deleteRosterShiftHandler godoc
+//
+//	@Summary		Takes a shift off the roster
+//	@Description	Deletes a shift that is yet to start. Admin only.
+//	@Tags			roster
+//	@Param			shiftID	path	string	true	"Shift ID"
+//	@Success		204
+//	@Failure		400	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/shifts/{shiftID} [delete]
+func (app *application) deleteRosterShiftHandler(w http.ResponseWriter, r *http.Request) {
+	shift := getRosterShiftFromCtx(r)
+
+	if err := app.store.Roster.DeleteShift(r.Context(), shift.ID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errShiftStarted)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getOnCallHandler godoc
+//
+//	@Summary		Finds who is on call
+//	@Description	Lists who is on call for a department at the given time, now by default. Staff only.
+//	@Tags			roster
+//	@Produce		json
+//	@Param			department	query		string	true	"Department, such as cardiology"
+//	@Param			at			query		string	false	"Time, RFC 3339"
+//	@Success		200			{array}		store.RosterShift
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/on-call [get]
+func (app *application) getOnCallHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	department := strings.TrimSpace(qs.Get("department"))
+	if department == "" {
+		app.badRequestResponse(w, r, errDepartmentRequired)
+		return
+	}
+
+	at := time.Now()
+	if v := qs.Get("at"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		at = parsed
+	}
+
+	shifts, err := app.store.Roster.GetOnCall(r.Context(), department, at)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, shifts); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type RequestShiftSwapPayload struct {
+	WithUserID uuid.UUID `json:"with_user_id" validate:"required"`
+	// CounterpartShiftID is the colleague's shift taken in exchange, if any
+	CounterpartShiftID *uuid.UUID `json:"counterpart_shift_id"`
+	Reason             string     `json:"reason" validate:"max=1000"`
+}
+
+// requestShiftSwapHandler godoc
+//
+//	@Summary		Asks a colleague to swap a shift
+//	@Description	Asks a colleague to take over one of your upcoming shifts, in exchange for one of theirs when counterpart_shift_id is set. The colleague accepts it and an admin approves it. Only the shift's owner may ask.
+//	@Tags			roster
+//	@Accept			json
+//	@Produce		json
+//	@Param			shiftID	path		string					true	"Shift ID"
+//	@Param			payload	body		RequestShiftSwapPayload	true	"Swap"
+//	@Success		201		{object}	store.ShiftSwap
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/shifts/{shiftID}/swaps [post]
+func (app *application) requestShiftSwapHandler(w http.ResponseWriter, r *http.Request) {
+	shift := getRosterShiftFromCtx(r)
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	if shift.UserID != user.ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if !shift.StartsAt.After(time.Now()) {
+		app.conflictResponse(w, r, errShiftStarted)
+		return
+	}
+
+	var payload RequestShiftSwapPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Reason = strings.TrimSpace(payload.Reason)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if payload.WithUserID == user.ID {
+		app.badRequestResponse(w, r, errSwapSelf)
+		return
+	}
+
+	template, err := app.store.Roster.GetTemplate(ctx, shift.TemplateID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if _, err := app.rosterableUser(ctx, payload.WithUserID, template); err != nil {
+		switch {
+		case errors.Is(err, errCannotWorkShift):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if payload.CounterpartShiftID != nil {
+		counterpart, err := app.store.Roster.GetShift(ctx, *payload.CounterpartShiftID)
+		if err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.badRequestResponse(w, r, errCounterpartShift)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		if counterpart.UserID != payload.WithUserID || !counterpart.StartsAt.After(time.Now()) {
+			app.badRequestResponse(w, r, errCounterpartShift)
+			return
+		}
+
+		counterpartTemplate, err := app.store.Roster.GetTemplate(ctx, counterpart.TemplateID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if !canWorkShift(user, counterpartTemplate) {
+			app.badRequestResponse(w, r, fmt.Errorf("%w: %s cannot work %s", errCannotWorkShift, user.Username, counterpartTemplate.Name))
+			return
+		}
+	}
+
+	swap := &store.ShiftSwap{
+		ShiftID:            shift.ID,
+		RequestedBy:        user.ID,
+		WithUserID:         payload.WithUserID,
+		CounterpartShiftID: payload.CounterpartShiftID,
+		Reason:             payload.Reason,
+	}
+
+	if err := app.store.Roster.CreateSwap(ctx, swap); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errSwapOpen)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, swap); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getShiftSwapsHandler godoc
+//
+//	@Summary		Lists shift swaps
+//	@Description	Lists swaps newest first. Admins see every swap, other staff the swaps they asked for or were asked to take.
+//	@Tags			roster
+//	@Produce		json
+//	@Param			status	query		string	false	"pending, accepted, approved, declined, rejected or cancelled"
+//	@Success		200		{array}		store.ShiftSwap
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/swaps [get]
+func (app *application) getShiftSwapsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	status := store.ShiftSwapStatus(r.URL.Query().Get("status"))
+	if err := Validate.Var(status, "omitempty,oneof=pending accepted approved declined rejected cancelled"); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	isAdmin, err := app.checkRolePrecedence(ctx, user, "admin")
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	var userID *uuid.UUID
+	if !isAdmin {
+		userID = &user.ID
+	}
+
+	swaps, err := app.store.Roster.GetSwaps(ctx, userID, status)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, swaps); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getShiftSwapHandler godoc
+//
+//	@Summary	Fetches a shift swap
+//	@Tags		roster
+//	@Produce	json
+//	@Param		swapID	path		string	true	"Swap ID"
+//	@Success	200		{object}	store.ShiftSwap
+//	@Failure	400		{object}	error
+//	@Failure	403		{object}	error
+//	@Failure	404		{object}	error
+//	@Failure	500		{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/roster/swaps/{swapID} [get]
+func (app *application) getShiftSwapHandler(w http.ResponseWriter, r *http.Request) {
+	swap := getShiftSwapFromCtx(r)
+	user := getUserFromContext(r)
+
+	if swap.RequestedBy != user.ID && swap.WithUserID != user.ID {
+		isAdmin, err := app.checkRolePrecedence(r.Context(), user, "admin")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !isAdmin {
+			app.forbiddenResponse(w, r)
+			return
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, swap); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// acceptShiftSwapHandler godoc
+//
+//	@Summary		Accepts a shift swap
+//	@Description	Agrees to a pending swap, which then waits for an admin's approval. Only the colleague asked may accept.
+//	@Tags			roster
+//	@Produce		json
+//	@Param			swapID	path		string	true	"Swap ID"
+//	@Success		200		{object}	store.ShiftSwap
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/swaps/{swapID}/accept [post]
+func (app *application) acceptShiftSwapHandler(w http.ResponseWriter, r *http.Request) {
+	app.respondToShiftSwap(w, r, true)
+}
+
+// declineShiftSwapHandler godoc
+//
+//	@Summary		Declines a shift swap
+//	@Description	Turns down a pending swap. Only the colleague asked may decline.
+//	@Tags			roster
+//	@Produce		json
+//	@Param			swapID	path		string	true	"Swap ID"
+//	@Success		200		{object}	store.ShiftSwap
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/swaps/{swapID}/decline [post]
+func (app *application) declineShiftSwapHandler(w http.ResponseWriter, r *http.Request) {
+	app.respondToShiftSwap(w, r, false)
+}
+
+func (app *application) respondToShiftSwap(w http.ResponseWriter, r *http.Request, accept bool) {
+	swap := getShiftSwapFromCtx(r)
+
+	if swap.WithUserID != getUserFromContext(r).ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Roster.RespondToSwap(r.Context(), swap, accept); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errSwapNotOpen)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, swap); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// approveShiftSwapHandler godoc
+//
+//	@Summary		Approves a shift swap
+//	@Description	Reassigns the shifts of an accepted swap, provided neither of the two then works within the minimum rest of another shift. Admin only.
+//	@Tags			roster
+//	@Produce		json
+//	@Param			swapID	path		string	true	"Swap ID"
+//	@Success		200		{object}	store.ShiftSwap
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/swaps/{swapID}/approve [post]
+func (app *application) approveShiftSwapHandler(w http.ResponseWriter, r *http.Request) {
+	swap := getShiftSwapFromCtx(r)
+	user := getUserFromContext(r)
+
+	if err := app.store.Roster.ApproveSwap(r.Context(), swap, user.ID, app.config.roster.minRest); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errSwapNotApprovable)
+		case store.ErrConflict:
+			app.conflictResponse(w, r, errRosterClash)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, swap); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// rejectShiftSwapHandler godoc
+//
+//	@Summary		Rejects a shift swap
+//	@Description	Turns down a pending or accepted swap. Admin only.
+//	@Tags			roster
+//	@Produce		json
+//	@Param			swapID	path		string	true	"Swap ID"
+//	@Success		200		{object}	store.ShiftSwap
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/swaps/{swapID}/reject [post]
+func (app *application) rejectShiftSwapHandler(w http.ResponseWriter, r *http.Request) {
+	swap := getShiftSwapFromCtx(r)
+	user := getUserFromContext(r)
+
+	if err := app.store.Roster.RejectSwap(r.Context(), swap, user.ID); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errSwapNotOpen)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, swap); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// cancelShiftSwapHandler godoc
+//
+//	@Summary		Withdraws a shift swap
+//	@Description	Withdraws a pending or accepted swap. Only the staff member who asked may withdraw it.
+//	@Tags			roster
+//	@Produce		json
+//	@Param			swapID	path		string	true	"Swap ID"
+//	@Success		200		{object}	store.ShiftSwap
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/roster/swaps/{swapID}/cancel [post]
+func (app *application) cancelShiftSwapHandler(w http.ResponseWriter, r *http.Request) {
+	swap := getShiftSwapFromCtx(r)
+
+	if swap.RequestedBy != getUserFromContext(r).ID {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Roster.CancelSwap(r.Context(), swap); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errSwapNotOpen)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, swap); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) rosterShiftContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "shiftID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		shift, err := app.store.Roster.GetShift(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, rosterShiftCtx, shift)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getRosterShiftFromCtx(r *http.Request) *store.RosterShift {
+	shift, _ := r.Context().Value(rosterShiftCtx).(*store.RosterShift)
+	return shift
+}
+
+func (app *application) shiftSwapContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "swapID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		swap, err := app.store.Roster.GetSwap(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, shiftSwapCtx, swap)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getShiftSwapFromCtx(r *http.Request) *store.ShiftSwap {
+	swap, _ := r.Context().Value(shiftSwapCtx).(*store.ShiftSwap)
+	return swap
+}
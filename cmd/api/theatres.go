@@ -0,0 +1,712 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/mrn"
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type surgicalCaseKey string
+
+const surgicalCaseCtx surgicalCaseKey = "surgicalCase"
+
+var (
+	errInvalidTeamMember = errors.New("invalid surgical team")
+	errCaseNotScheduled  = errors.New("case has started or was cancelled")
+	errCaseNotInProgress = errors.New("case is not in progress")
+)
+
+type CreateTheatrePayload struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// createTheatreHandler godoc
+//
+//	@Summary	Adds an operating theatre
+//	@Tags		theatres
+//	@Accept		json
+//	@Produce	json
+//	@Param		payload	body		CreateTheatrePayload	true	"Theatre"
+//	@Success	201		{object}	store.Theatre
+//	@Failure	400		{object}	error
+//	@Failure	403		{object}	error
+//	@Failure	409		{object}	error
+//	@Failure	500		{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/theatres [post]
+func (app *application) createTheatreHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateTheatrePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Name = strings.TrimSpace(payload.Name)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	theatre := &store.Theatre{Name: payload.Name}
+
+	if err := app.store.Theatres.CreateTheatre(r.Context(), theatre); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, theatre); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getTheatresHandler godoc
+//
+//	@Summary	Lists operating theatres
+//	@Tags		theatres
+//	@Produce	json
+//	@Success	200	{array}		store.Theatre
+//	@Failure	403	{object}	error
+//	@Failure	500	{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/theatres [get]
+func (app *application) getTheatresHandler(w http.ResponseWriter, r *http.Request) {
+	theatres, err := app.store.Theatres.GetTheatres(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, theatres); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type CreateTheatreEquipmentPayload struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// createTheatreEquipmentHandler godoc
+//
+//	@Summary		Adds theatre equipment
+//	@Description	Adds one unit of equipment, such as "C-arm 2", which cases book one at a time.
+//	@Tags			theatres
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateTheatreEquipmentPayload	true	"Equipment"
+//	@Success		201		{object}	store.TheatreEquipment
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/theatre-equipment [post]
+func (app *application) createTheatreEquipmentHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateTheatreEquipmentPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Name = strings.TrimSpace(payload.Name)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	equipment := &store.TheatreEquipment{Name: payload.Name}
+
+	if err := app.store.Theatres.CreateEquipment(r.Context(), equipment); err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.conflictResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, equipment); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getTheatreEquipmentHandler godoc
+//
+//	@Summary	Lists theatre equipment
+//	@Tags		theatres
+//	@Produce	json
+//	@Success	200	{array}		store.TheatreEquipment
+//	@Failure	403	{object}	error
+//	@Failure	500	{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/theatre-equipment [get]
+func (app *application) getTheatreEquipmentHandler(w http.ResponseWriter, r *http.Request) {
+	equipment, err := app.store.Theatres.GetEquipment(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, equipment); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// TheatreList is one theatre's cases for a day.
+type TheatreList struct {
+	Theatre store.Theatre         `json:"theatre"`
+	Cases   []*store.SurgicalCase `json:"cases"`
+}
+
+// getTheatreListHandler godoc
+//
+//	@Summary		Shows the daily theatre list
+//	@Description	Lists each theatre with the cases starting on the day (UTC, today by default) in order, cancelled ones included. Staff only.
+//	@Tags			theatres
+//	@Produce		json
+//	@Param			date		query		string	false	"Day, YYYY-MM-DD"
+//	@Param			theatre_id	query		string	false	"Theatre ID"
+//	@Success		200			{array}		TheatreList
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/theatres/list [get]
+func (app *application) getTheatreListHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	ctx := r.Context()
+
+	day := time.Now().UTC()
+	if v := qs.Get("date"); v != "" {
+		parsed, err := time.Parse(store.DateLayout, v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		day = parsed
+	}
+
+	var theatreID *uuid.UUID
+	if v := qs.Get("theatre_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		theatreID = &id
+	}
+
+	theatres, err := app.store.Theatres.GetTheatres(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	cases, err := app.store.Theatres.GetDayList(ctx, day, theatreID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	lists := []TheatreList{}
+	for _, theatre := range theatres {
+		if theatreID != nil && theatre.ID != *theatreID {
+			continue
+		}
+
+		list := TheatreList{Theatre: theatre, Cases: []*store.SurgicalCase{}}
+		for _, c := range cases {
+			if c.TheatreID == theatre.ID {
+				list.Cases = append(list.Cases, c)
+			}
+		}
+		lists = append(lists, list)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, lists); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type SurgicalBookingPayload struct {
+	TheatreID       uuid.UUID `json:"theatre_id" validate:"required"`
+	Procedure       string    `json:"procedure" validate:"required,max=500"`
+	ScheduledStart  time.Time `json:"scheduled_start" validate:"required"`
+	ExpectedMinutes int       `json:"expected_minutes" validate:"required,min=1,max=1440"`
+	// SurgeonID defaults to the doctor booking the case, or to the current
+	// surgeon when rescheduling
+	SurgeonID     *uuid.UUID  `json:"surgeon_id"`
+	AnesthetistID *uuid.UUID  `json:"anesthetist_id"`
+	NurseIDs      []uuid.UUID `json:"nurse_ids" validate:"max=10,unique"`
+	EquipmentIDs  []uuid.UUID `json:"equipment_ids" validate:"max=20,unique"`
+	Notes         string      `json:"notes" validate:"max=5000"`
+}
+
+type ScheduleSurgicalCasePayload struct {
+	PatientID  uuid.UUID `json:"patient_id" validate:"required_without=PatientMRN"`
+	PatientMRN string    `json:"patient_mrn" validate:"max=40"`
+	SurgicalBookingPayload
+}
+
+// scheduleSurgicalCaseHandler godoc
+//
+//	@Summary		Books a theatre
+//	@Description	Books a case for a patient given by ID or MRN with its procedure, start, expected duration, team and equipment. The booking is refused if the theatre, anyone on the team or any equipment is already booked at an overlapping time. Staff only.
+//	@Tags			theatres
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ScheduleSurgicalCasePayload	true	"Case"
+//	@Success		201		{object}	store.SurgicalCase
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/theatre-cases [post]
+func (app *application) scheduleSurgicalCaseHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	ctx := r.Context()
+
+	var payload ScheduleSurgicalCasePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	c, ok := app.surgicalCaseFromPayload(w, r, payload)
+	if !ok {
+		return
+	}
+	c.BookedBy = &user.ID
+
+	if err := app.store.Theatres.Schedule(ctx, c); err != nil {
+		app.surgicalCaseBookingError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, c); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// checkSurgicalCaseConflictsHandler godoc
+//
+//	@Summary		Checks a theatre booking for conflicts
+//	@Description	Lists what a booking would overlap on the theatre, the team and the equipment, without booking it. Staff only.
+//	@Tags			theatres
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ScheduleSurgicalCasePayload	true	"Case"
+//	@Success		200		{array}		store.ScheduleConflict
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/theatre-cases/conflicts [post]
+func (app *application) checkSurgicalCaseConflictsHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ScheduleSurgicalCasePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	c, ok := app.surgicalCaseFromPayload(w, r, payload)
+	if !ok {
+		return
+	}
+
+	conflicts, err := app.store.Theatres.FindConflicts(r.Context(), c)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, conflicts); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// surgicalCaseFromPayload validates a new booking and builds its case. It
+// writes the error response and returns false when the booking is invalid.
+func (app *application) surgicalCaseFromPayload(w http.ResponseWriter, r *http.Request, payload ScheduleSurgicalCasePayload) (*store.SurgicalCase, bool) {
+	ctx := r.Context()
+
+	payload.Procedure = strings.TrimSpace(payload.Procedure)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return nil, false
+	}
+
+	ref := payload.PatientMRN
+	if payload.PatientID != uuid.Nil {
+		ref = payload.PatientID.String()
+	}
+
+	patient, err := app.admissionPatient(ctx, ref)
+	if err != nil {
+		switch {
+		case errors.Is(err, mrn.ErrCheckDigit), errors.Is(err, store.ErrNotFound):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	c := &store.SurgicalCase{PatientID: patient.UserID}
+
+	if err := app.applySurgicalBooking(ctx, getUserFromContext(r), c, payload.SurgicalBookingPayload); err != nil {
+		switch {
+		case errors.Is(err, errInvalidTeamMember):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	return c, true
+}
+
+// applySurgicalBooking copies the booking onto the case. The surgeon and
+// anesthetist must be doctors and the nurses nurses, each on the team once.
+func (app *application) applySurgicalBooking(ctx context.Context, user *store.User, c *store.SurgicalCase, p SurgicalBookingPayload) error {
+	c.TheatreID = p.TheatreID
+	c.Procedure = p.Procedure
+	c.ExpectedMinutes = p.ExpectedMinutes
+	c.ScheduledStart = p.ScheduledStart.UTC().Truncate(time.Minute)
+	c.ScheduledEnd = c.ScheduledStart.Add(time.Duration(p.ExpectedMinutes) * time.Minute)
+	c.Notes = p.Notes
+
+	surgeonID := p.SurgeonID
+	if surgeonID == nil {
+		if user.Role.Name != "doctor" {
+			return fmt.Errorf("%w: surgeon_id is required", errInvalidTeamMember)
+		}
+		surgeonID = &user.ID
+	}
+
+	type seat struct {
+		id       uuid.UUID
+		role     store.SurgicalRole
+		userRole string
+	}
+
+	seats := []seat{{*surgeonID, store.SurgicalRoleSurgeon, "doctor"}}
+	if p.AnesthetistID != nil {
+		seats = append(seats, seat{*p.AnesthetistID, store.SurgicalRoleAnesthetist, "doctor"})
+	}
+	for _, id := range p.NurseIDs {
+		seats = append(seats, seat{id, store.SurgicalRoleNurse, "nurse"})
+	}
+
+	c.Team = make([]store.SurgicalTeamMember, 0, len(seats))
+	seen := make(map[uuid.UUID]bool, len(seats))
+
+	for _, s := range seats {
+		if seen[s.id] {
+			return fmt.Errorf("%w: %s is on the team more than once", errInvalidTeamMember, s.id)
+		}
+		seen[s.id] = true
+
+		member, err := app.store.Users.GetByID(ctx, s.id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return fmt.Errorf("%w: %s %s not found", errInvalidTeamMember, s.role, s.id)
+			}
+			return err
+		}
+		if member.Role.Name != s.userRole {
+			return fmt.Errorf("%w: the %s must be a %s", errInvalidTeamMember, s.role, s.userRole)
+		}
+
+		c.Team = append(c.Team, store.SurgicalTeamMember{UserID: s.id, Username: member.Username, Role: s.role})
+	}
+
+	c.Equipment = make([]store.TheatreEquipment, 0, len(p.EquipmentIDs))
+	for _, id := range p.EquipmentIDs {
+		c.Equipment = append(c.Equipment, store.TheatreEquipment{ID: id})
+	}
+
+	return nil
+}
+
+func (app *application) surgicalCaseBookingError(w http.ResponseWriter, r *http.Request, err error) {
+	var conflict *store.ScheduleConflictError
+
+	switch {
+	case errors.As(err, &conflict):
+		app.conflictResponse(w, r, err)
+	case errors.Is(err, store.ErrNotFound):
+		app.badRequestResponse(w, r, errors.New("theatre or equipment not found"))
+	case errors.Is(err, store.ErrLocked):
+		app.conflictResponse(w, r, errCaseNotScheduled)
+	default:
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getSurgicalCaseHandler godoc
+//
+//	@Summary	Fetches a theatre case
+//	@Tags		theatres
+//	@Produce	json
+//	@Param		caseID	path		string	true	"Case ID"
+//	@Success	200		{object}	store.SurgicalCase
+//	@Failure	400		{object}	error
+//	@Failure	403		{object}	error
+//	@Failure	404		{object}	error
+//	@Failure	500		{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/theatre-cases/{caseID} [get]
+func (app *application) getSurgicalCaseHandler(w http.ResponseWriter, r *http.Request) {
+	c := getSurgicalCaseFromCtx(r)
+
+	if err := app.jsonResponse(w, http.StatusOK, c); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// rescheduleSurgicalCaseHandler godoc
+//
+//	@Summary		Reschedules a theatre case
+//	@Description	Replaces the theatre, time, procedure, team and equipment of a case that has not started, with the same conflict checks as booking. Staff only.
+//	@Tags			theatres
+//	@Accept			json
+//	@Produce		json
+//	@Param			caseID	path		string					true	"Case ID"
+//	@Param			payload	body		SurgicalBookingPayload	true	"Booking"
+//	@Success		200		{object}	store.SurgicalCase
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/theatre-cases/{caseID} [put]
+func (app *application) rescheduleSurgicalCaseHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	c := getSurgicalCaseFromCtx(r)
+	ctx := r.Context()
+
+	var payload SurgicalBookingPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Procedure = strings.TrimSpace(payload.Procedure)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if c.Status != store.SurgicalCaseScheduled {
+		app.conflictResponse(w, r, errCaseNotScheduled)
+		return
+	}
+
+	// the surgeon stays unless another is given
+	if payload.SurgeonID == nil {
+		for _, m := range c.Team {
+			if m.Role == store.SurgicalRoleSurgeon {
+				payload.SurgeonID = &m.UserID
+			}
+		}
+	}
+
+	if err := app.applySurgicalBooking(ctx, user, c, payload); err != nil {
+		switch {
+		case errors.Is(err, errInvalidTeamMember):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.store.Theatres.Reschedule(ctx, c); err != nil {
+		app.surgicalCaseBookingError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, c); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// startSurgicalCaseHandler godoc
+//
+//	@Summary		Starts a theatre case
+//	@Description	Marks a scheduled case in progress. Doctors and nurses only.
+//	@Tags			theatres
+//	@Produce		json
+//	@Param			caseID	path		string	true	"Case ID"
+//	@Success		200		{object}	store.SurgicalCase
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/theatre-cases/{caseID}/start [post]
+func (app *application) startSurgicalCaseHandler(w http.ResponseWriter, r *http.Request) {
+	c := getSurgicalCaseFromCtx(r)
+
+	if !isClinician(getUserFromContext(r)) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Theatres.Start(r.Context(), c); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errCaseNotScheduled)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, c); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// completeSurgicalCaseHandler godoc
+//
+//	@Summary		Completes a theatre case
+//	@Description	Marks a case in progress completed, which frees its theatre, team and equipment. Doctors and nurses only.
+//	@Tags			theatres
+//	@Produce		json
+//	@Param			caseID	path		string	true	"Case ID"
+//	@Success		200		{object}	store.SurgicalCase
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/theatre-cases/{caseID}/complete [post]
+func (app *application) completeSurgicalCaseHandler(w http.ResponseWriter, r *http.Request) {
+	c := getSurgicalCaseFromCtx(r)
+
+	if !isClinician(getUserFromContext(r)) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	if err := app.store.Theatres.Complete(r.Context(), c); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errCaseNotInProgress)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, c); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type CancelSurgicalCasePayload struct {
+	Reason string `json:"reason" validate:"required,max=2000"`
+}
+
+// cancelSurgicalCaseHandler godoc
+//
+//	@Summary		Cancels a theatre case
+//	@Description	Cancels a case that has not started, freeing its theatre, team and equipment. Staff only.
+//	@Tags			theatres
+//	@Accept			json
+//	@Produce		json
+//	@Param			caseID	path		string						true	"Case ID"
+//	@Param			payload	body		CancelSurgicalCasePayload	true	"Reason"
+//	@Success		200		{object}	store.SurgicalCase
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/theatre-cases/{caseID}/cancel [post]
+func (app *application) cancelSurgicalCaseHandler(w http.ResponseWriter, r *http.Request) {
+	c := getSurgicalCaseFromCtx(r)
+
+	var payload CancelSurgicalCasePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Reason = strings.TrimSpace(payload.Reason)
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Theatres.Cancel(r.Context(), c, payload.Reason); err != nil {
+		switch err {
+		case store.ErrLocked:
+			app.conflictResponse(w, r, errCaseNotScheduled)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, c); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+func (app *application) surgicalCaseContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(chi.URLParam(r, "caseID"))
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		c, err := app.store.Theatres.GetCase(ctx, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				app.notFoundResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, surgicalCaseCtx, c)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getSurgicalCaseFromCtx(r *http.Request) *store.SurgicalCase {
+	c, _ := r.Context().Value(surgicalCaseCtx).(*store.SurgicalCase)
+	return c
+}
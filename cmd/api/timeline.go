@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/MdHasib01/hms_server/internal/store"
+)
+
+// getPatientTimelineHandler godoc
+//
+//	@Summary		Fetches a patient's timeline
+//	@Description	Merges appointments, encounters, diagnoses, prescriptions, lab orders, vitals, documents and admissions into one list, newest first. Readable by the patient, their guardians and treating doctors. Patients only see released lab orders, and draft encounters are only shown to their author.
+//	@Tags			patient
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Param			types		query		string	false	"Comma-separated event types (default all)"
+//	@Param			limit		query		int		false	"Page size, 1-100 (default 50)"
+//	@Param			cursor		query		string	false	"next_cursor of the previous page"
+//	@Success		200			{object}	store.TimelinePage
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/timeline [get]
+func (app *application) getPatientTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+	ctx := r.Context()
+
+	q := store.TimelineQuery{
+		PatientID:   patient.UserID,
+		ViewerID:    user.ID,
+		PatientView: user.ActsFor(patient.UserID),
+		Types:       store.TimelineEventTypes,
+		Limit:       50,
+	}
+
+	if !q.PatientView {
+		treating, err := app.store.Encounters.IsTreatingDoctor(ctx, user.ID, patient.UserID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if !treating {
+			app.forbiddenResponse(w, r)
+			return
+		}
+	}
+
+	q, err := q.Parse(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(q); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	page, err := app.store.Timeline.Get(ctx, q)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, page); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/MdHasib01/hms_server/internal/blob"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	errFileTooLarge        = errors.New("file is too large")
+	errUnsupportedFileType = errors.New("unsupported file type")
+)
+
+var (
+	imageContentTypes    = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+	documentContentTypes = []string{"application/pdf", "image/jpeg", "image/png"}
+)
+
+type upload struct {
+	FileName    string
+	ContentType string
+	Extension   string
+	Data        []byte
+}
+
+// readUpload reads the file sent in a multipart form field. The content type
+// is sniffed from the file itself, never taken from the client, and has to be
+// one of allowed.
+func readUpload(w http.ResponseWriter, r *http.Request, field string, maxBytes int64, allowed []string) (*upload, error) {
+	// leave some room for the other form fields and multipart boundaries
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+1<<20)
+
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, errFileTooLarge
+		}
+		return nil, err
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile(field)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", field, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxBytes {
+		return nil, errFileTooLarge
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s: file is empty", field)
+	}
+
+	detected := mimetype.Detect(data)
+	if !mimetype.EqualsAny(detected.String(), allowed...) {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedFileType, detected.String())
+	}
+
+	contentType, _, _ := mime.ParseMediaType(detected.String())
+
+	return &upload{
+		FileName:    sanitizeFileName(header.Filename),
+		ContentType: contentType,
+		Extension:   detected.Extension(),
+		Data:        data,
+	}, nil
+}
+
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == '"' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+
+	if name == "." || name == "/" || name == "" {
+		return "file"
+	}
+
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+
+	return name
+}
+
+// serveFileHandler godoc
+//
+//	@Summary		Serves a public file
+//	@Description	Serves files from the public area of blob storage, such as doctor photos
+//	@Tags			files
+//	@Produce		octet-stream
+//	@Param			key	path		string	true	"Blob key"
+//	@Success		200	{file}		file
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Router			/files/{key} [get]
+func (app *application) serveFileHandler(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+	if !strings.HasPrefix(key, blob.PublicPrefix) {
+		app.notFoundResponse(w, r, blob.ErrNotFound)
+		return
+	}
+
+	f, err := app.blob.Open(r.Context(), key)
+	if err != nil {
+		switch {
+		case errors.Is(err, blob.ErrNotFound), errors.Is(err, blob.ErrInvalidKey):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	defer f.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+
+	if _, err := io.Copy(w, f); err != nil {
+		app.logger.Errorw("error serving file", "key", key, "error", err)
+	}
+}
+
+// writeAttachment streams a private blob to the client as a download.
+func (app *application) writeAttachment(w http.ResponseWriter, r *http.Request, key, fileName, contentType string) {
+	f, err := app.blob.Open(r.Context(), key)
+	if err != nil {
+		switch {
+		case errors.Is(err, blob.ErrNotFound):
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	if _, err := io.Copy(w, f); err != nil {
+		app.logger.Errorw("error serving attachment", "key", key, "error", err)
+	}
+}
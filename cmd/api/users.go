@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/MdHasib01/hms_server/internal/store"
@@ -35,14 +37,22 @@ func (app *application) getUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type ActivateUserPayload struct {
+	// Password is required for an account without one, an upgraded dependent
+	Password string `json:"password" validate:"omitempty,min=3,max=72"`
+}
+
 // ActivateUser godoc
 //
 //	@Summary		Activates/Register a user
-//	@Description	Activates/Register a user by invitation token
+//	@Description	Activates/Register a user by invitation token. An upgraded dependent sets their password here.
 //	@Tags			users
+//	@Accept			json
 //	@Produce		json
-//	@Param			token	path		string	true	"Invitation token"
-//	@Success		204		{string}	string	"User activated"
+//	@Param			token	path		string				true	"Invitation token"
+//	@Param			payload	body		ActivateUserPayload	false	"Password"
+//	@Success		204		{string}	string				"User activated"
+//	@Failure		400		{object}	error
 //	@Failure		404		{object}	error
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
@@ -50,9 +60,23 @@ func (app *application) getUserHandler(w http.ResponseWriter, r *http.Request) {
 func (app *application) activateUserHandler(w http.ResponseWriter, r *http.Request) {
 	token := chi.URLParam(r, "token")
 
-	err := app.store.Users.Activate(r.Context(), token)
+	// the body is optional for accounts that already have a password
+	var payload ActivateUserPayload
+	if err := readJSON(w, r, &payload); err != nil && !errors.Is(err, io.EOF) {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err := app.store.Users.Activate(r.Context(), token, payload.Password)
 	if err != nil {
 		switch err {
+		case store.ErrPasswordRequired:
+			app.badRequestResponse(w, r, err)
 		case store.ErrNotFound:
 			app.notFoundResponse(w, r, err)
 		default:
@@ -123,3 +147,62 @@ func (app *application) getPatientsByRoleHandler(w http.ResponseWriter, r *http.
 		app.internalServerError(w, r, err)
 	}
 }
+
+type SetUserRolePayload struct {
+	Role string `json:"role" validate:"required,max=255"`
+}
+
+// setUserRoleHandler godoc
+//
+//	@Summary		Changes a user's role
+//	@Description	Assigns a role such as doctor, receptionist or lab to a user. Admin only; admins cannot change their own role.
+//	@Tags			users
+//	@Accept			json
+//	@Param			userID	path		string				true	"User ID"
+//	@Param			payload	body		SetUserRolePayload	true	"Role name"
+//	@Success		204		{string}	string				"Role changed"
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/{userID}/role [put]
+func (app *application) setUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	admin := getUserFromContext(r)
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if userID == admin.ID {
+		app.badRequestResponse(w, r, errors.New("admins cannot change their own role"))
+		return
+	}
+
+	var payload SetUserRolePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.store.Users.SetRole(r.Context(), userID, payload.Role); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.notFoundResponse(w, r, err)
+		default:
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Infow("user role changed", "user", userID, "role", payload.Role, "by", admin.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
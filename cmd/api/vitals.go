@@ -0,0 +1,457 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MdHasib01/hms_server/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	errNoVitals             = errors.New("at least one measurement is required")
+	errBloodPressureOrder   = errors.New("systolic pressure must be higher than diastolic")
+	errVitalsInFuture       = errors.New("recorded_at cannot be in the future")
+	errAppointmentOfPatient = errors.New("the appointment belongs to another patient")
+)
+
+type RecordVitalsPayload struct {
+	AppointmentID   *uuid.UUID `json:"appointment_id"`
+	RecordedAt      *time.Time `json:"recorded_at"`
+	Systolic        *int       `json:"systolic" validate:"required_with=Diastolic,omitempty,gte=40,lte=300"`
+	Diastolic       *int       `json:"diastolic" validate:"required_with=Systolic,omitempty,gte=20,lte=200"`
+	Pulse           *int       `json:"pulse" validate:"omitempty,gte=20,lte=300"`
+	Temperature     *float64   `json:"temperature"`
+	TemperatureUnit string     `json:"temperature_unit" validate:"required_with=Temperature,omitempty,oneof=C F"`
+	SpO2            *int       `json:"spo2" validate:"omitempty,gte=50,lte=100"`
+	Weight          *float64   `json:"weight"`
+	WeightUnit      string     `json:"weight_unit" validate:"required_with=Weight,omitempty,oneof=kg lb"`
+	Height          *float64   `json:"height"`
+	HeightUnit      string     `json:"height_unit" validate:"required_with=Height,omitempty,oneof=cm in"`
+}
+
+type VitalAlert struct {
+	Type   store.VitalType `json:"type"`
+	Value  float64         `json:"value"`
+	Unit   string          `json:"unit"`
+	Breach string          `json:"breach"`
+	Low    *float64        `json:"low"`
+	High   *float64        `json:"high"`
+}
+
+type VitalsResponse struct {
+	*store.Vitals
+	Alerts []VitalAlert `json:"alerts"`
+}
+
+// canonical converts a measurement to the stored unit, rounded to the
+// precision it is stored with, and checks that it is plausible.
+func canonical(name string, value float64, unit string) (float64, error) {
+	var converted, min, max, scale float64
+
+	switch unit {
+	case "C":
+		converted, min, max, scale = value, 25, 45, 10
+	case "F":
+		converted, min, max, scale = (value-32)*5/9, 25, 45, 10
+	case "kg":
+		converted, min, max, scale = value, 0.2, 500, 100
+	case "lb":
+		converted, min, max, scale = value*0.45359237, 0.2, 500, 100
+	case "cm":
+		converted, min, max, scale = value, 20, 280, 10
+	case "in":
+		converted, min, max, scale = value*2.54, 20, 280, 10
+	default:
+		return 0, fmt.Errorf("%s: unknown unit %q", name, unit)
+	}
+
+	if converted < min || converted > max {
+		return 0, fmt.Errorf("%s of %g %s is outside the plausible range", name, value, unit)
+	}
+
+	return math.Round(converted*scale) / scale, nil
+}
+
+// recordVitalsHandler godoc
+//
+//	@Summary		Records vital signs
+//	@Description	Records a set of vital signs for a patient. Temperature, weight and height are given with their unit and stored as Celsius, kilograms and centimetres. BMI is computed from the weight and the latest height. Values outside the alert thresholds are returned as alerts. Nurses and doctors only.
+//	@Tags			vitals
+//	@Accept			json
+//	@Produce		json
+//	@Param			patientID	path		string				true	"Patient ID or MRN"
+//	@Param			payload		body		RecordVitalsPayload	true	"Measurements"
+//	@Success		201			{object}	VitalsResponse
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/vitals [post]
+func (app *application) recordVitalsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+
+	if !isClinician(user) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	var payload RecordVitalsPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	vitals := &store.Vitals{
+		PatientID:     patient.UserID,
+		AppointmentID: payload.AppointmentID,
+		Systolic:      payload.Systolic,
+		Diastolic:     payload.Diastolic,
+		Pulse:         payload.Pulse,
+		SpO2:          payload.SpO2,
+		RecordedBy:    &user.ID,
+		RecordedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+
+	if payload.RecordedAt != nil {
+		if payload.RecordedAt.After(time.Now().Add(5 * time.Minute)) {
+			app.badRequestResponse(w, r, errVitalsInFuture)
+			return
+		}
+		vitals.RecordedAt = payload.RecordedAt.UTC().Truncate(time.Second)
+	}
+
+	if vitals.Systolic != nil && *vitals.Systolic <= *vitals.Diastolic {
+		app.badRequestResponse(w, r, errBloodPressureOrder)
+		return
+	}
+
+	conversions := []struct {
+		name  string
+		value *float64
+		unit  string
+		dest  **float64
+	}{
+		{"temperature", payload.Temperature, payload.TemperatureUnit, &vitals.Temperature},
+		{"weight", payload.Weight, payload.WeightUnit, &vitals.Weight},
+		{"height", payload.Height, payload.HeightUnit, &vitals.Height},
+	}
+
+	for _, c := range conversions {
+		if c.value == nil {
+			continue
+		}
+
+		converted, err := canonical(c.name, *c.value, c.unit)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		*c.dest = &converted
+	}
+
+	measured := false
+	for _, t := range store.VitalTypes {
+		if _, ok := vitals.Value(t); ok {
+			measured = true
+			break
+		}
+	}
+	if !measured {
+		app.badRequestResponse(w, r, errNoVitals)
+		return
+	}
+
+	ctx := r.Context()
+
+	if vitals.AppointmentID != nil {
+		appointment, err := app.store.Appointments.GetByID(ctx, *vitals.AppointmentID)
+		if err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.badRequestResponse(w, r, err)
+			default:
+				app.internalServerError(w, r, err)
+			}
+			return
+		}
+
+		if appointment.PatientID != patient.UserID {
+			app.badRequestResponse(w, r, errAppointmentOfPatient)
+			return
+		}
+	}
+
+	if err := app.store.Vitals.Create(ctx, vitals); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	thresholds, err := app.store.Vitals.GetThresholds(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	response := VitalsResponse{Vitals: vitals, Alerts: []VitalAlert{}}
+	for _, t := range store.VitalTypes {
+		value, ok := vitals.Value(t)
+		threshold := thresholds[t]
+		if !ok || threshold == nil {
+			continue
+		}
+
+		if breach := threshold.Breached(value); breach != "" {
+			response.Alerts = append(response.Alerts, VitalAlert{
+				Type:   t,
+				Value:  value,
+				Unit:   t.Unit(),
+				Breach: breach,
+				Low:    threshold.Low,
+				High:   threshold.High,
+			})
+		}
+	}
+
+	if len(response.Alerts) > 0 {
+		app.logger.Warnw("vital signs outside thresholds", "patient", patient.UserID, "vitals", vitals.ID, "alerts", len(response.Alerts))
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, response); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type VitalPoint struct {
+	RecordedAt time.Time `json:"t"`
+	Value      float64   `json:"v"`
+	Alert      string    `json:"alert,omitempty"`
+}
+
+type VitalSeries struct {
+	Type   store.VitalType `json:"type"`
+	Unit   string          `json:"unit"`
+	Low    *float64        `json:"low"`
+	High   *float64        `json:"high"`
+	Points []VitalPoint    `json:"points"`
+}
+
+// getVitalsSeriesHandler godoc
+//
+//	@Summary		Vital signs time series
+//	@Description	Returns the patient's vital signs as one series per type, oldest point first, with the alert thresholds for drawing reference lines. type is a comma separated list of systolic, diastolic, blood_pressure (both), pulse, temperature, spo2, weight, height and bmi; all types are returned when omitted. The period defaults to the last 90 days.
+//	@Tags			vitals
+//	@Produce		json
+//	@Param			patientID	path		string	true	"Patient ID or MRN"
+//	@Param			type		query		string	false	"Vital types"
+//	@Param			from		query		string	false	"First day, YYYY-MM-DD"
+//	@Param			to			query		string	false	"Last day, YYYY-MM-DD"
+//	@Success		200			{array}		VitalSeries
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/patients/{patientID}/vitals [get]
+func (app *application) getVitalsSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	patient := getPatientFromCtx(r)
+
+	if !user.ActsFor(patient.UserID) && !isClinician(user) {
+		app.forbiddenResponse(w, r)
+		return
+	}
+
+	qs := r.URL.Query()
+
+	types := store.VitalTypes
+	if v := qs.Get("type"); v != "" {
+		types = []store.VitalType{}
+		seen := map[store.VitalType]bool{}
+		for _, name := range strings.Split(v, ",") {
+			requested := []store.VitalType{store.VitalType(strings.TrimSpace(name))}
+			if requested[0] == "blood_pressure" {
+				requested = []store.VitalType{store.VitalSystolic, store.VitalDiastolic}
+			}
+
+			for _, t := range requested {
+				if t.Unit() == "" {
+					app.badRequestResponse(w, r, fmt.Errorf("unknown vital type %q", t))
+					return
+				}
+				if !seen[t] {
+					seen[t] = true
+					types = append(types, t)
+				}
+			}
+		}
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -89)
+	to := today
+
+	if v := qs.Get("from"); v != "" {
+		parsed, err := time.Parse(store.DateLayout, v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		from = parsed
+	}
+
+	if v := qs.Get("to"); v != "" {
+		parsed, err := time.Parse(store.DateLayout, v)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		to = parsed
+	}
+
+	if to.Before(from) {
+		app.badRequestResponse(w, r, errors.New("to must not be before from"))
+		return
+	}
+
+	ctx := r.Context()
+
+	// to is inclusive for callers; the store takes an exclusive bound
+	vitals, err := app.store.Vitals.GetByPatient(ctx, patient.UserID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	thresholds, err := app.store.Vitals.GetThresholds(ctx)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	series := make([]VitalSeries, 0, len(types))
+	for _, t := range types {
+		s := VitalSeries{Type: t, Unit: t.Unit(), Points: []VitalPoint{}}
+
+		threshold := thresholds[t]
+		if threshold != nil {
+			s.Low, s.High = threshold.Low, threshold.High
+		}
+
+		for _, v := range vitals {
+			value, ok := v.Value(t)
+			if !ok {
+				continue
+			}
+
+			point := VitalPoint{RecordedAt: v.RecordedAt, Value: value}
+			if threshold != nil {
+				point.Alert = threshold.Breached(value)
+			}
+			s.Points = append(s.Points, point)
+		}
+
+		series = append(series, s)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, series); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// getVitalThresholdsHandler godoc
+//
+//	@Summary	Lists vital sign alert thresholds
+//	@Tags		vitals
+//	@Produce	json
+//	@Success	200	{array}		store.VitalThreshold
+//	@Failure	500	{object}	error
+//	@Security	ApiKeyAuth
+//	@Router		/vitals/thresholds [get]
+func (app *application) getVitalThresholdsHandler(w http.ResponseWriter, r *http.Request) {
+	thresholds, err := app.store.Vitals.GetThresholds(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	list := make([]*store.VitalThreshold, 0, len(thresholds))
+	for _, t := range store.VitalTypes {
+		if threshold, ok := thresholds[t]; ok {
+			list = append(list, threshold)
+		}
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, list); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+type SetVitalThresholdPayload struct {
+	Low  *float64 `json:"low"`
+	High *float64 `json:"high" validate:"omitempty,gtfield=Low"`
+}
+
+// setVitalThresholdHandler godoc
+//
+//	@Summary		Sets a vital sign alert threshold
+//	@Description	Sets the bounds outside which a vital sign raises an alert, in the type's stored unit. Omit both bounds to turn alerts off for the type. Admin only.
+//	@Tags			vitals
+//	@Accept			json
+//	@Produce		json
+//	@Param			type	path		string						true	"Vital type"
+//	@Param			payload	body		SetVitalThresholdPayload	true	"Bounds"
+//	@Success		200		{object}	store.VitalThreshold
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/vitals/thresholds/{type} [put]
+func (app *application) setVitalThresholdHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	vitalType := store.VitalType(chi.URLParam(r, "type"))
+	if vitalType.Unit() == "" {
+		app.badRequestResponse(w, r, fmt.Errorf("unknown vital type %q", vitalType))
+		return
+	}
+
+	var payload SetVitalThresholdPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	threshold := &store.VitalThreshold{
+		Type:      vitalType,
+		Low:       payload.Low,
+		High:      payload.High,
+		UpdatedBy: &user.ID,
+	}
+
+	if err := app.store.Vitals.SetThreshold(r.Context(), threshold); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, threshold); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
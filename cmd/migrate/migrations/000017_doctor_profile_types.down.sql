@@ -0,0 +1,28 @@
+ALTER TABLE
+  doctors
+ADD
+  COLUMN age VARCHAR(10),
+ALTER COLUMN
+  gender TYPE VARCHAR(20) USING gender::text,
+ALTER COLUMN
+  marital_status TYPE VARCHAR(20) USING marital_status::text,
+ALTER COLUMN
+  blood_group TYPE VARCHAR(10) USING blood_group::text;
+
+UPDATE
+  doctors
+SET
+  age = date_part('year', age(date_of_birth))::text
+WHERE
+  date_of_birth IS NOT NULL;
+
+ALTER TABLE
+  doctors DROP COLUMN date_of_birth;
+
+DROP TABLE IF EXISTS doctor_profile_migration_issues;
+
+DROP TYPE IF EXISTS blood_group;
+
+DROP TYPE IF EXISTS marital_status;
+
+DROP TYPE IF EXISTS gender;
@@ -0,0 +1,177 @@
+CREATE TYPE gender AS ENUM ('male', 'female', 'other');
+
+CREATE TYPE marital_status AS ENUM (
+  'single',
+  'married',
+  'divorced',
+  'widowed',
+  'separated'
+);
+
+CREATE TYPE blood_group AS ENUM ('A+', 'A-', 'B+', 'B-', 'AB+', 'AB-', 'O+', 'O-');
+
+-- Values the normalization below cannot parse are reported here together
+-- with the original text so they can be corrected by hand.
+CREATE TABLE IF NOT EXISTS doctor_profile_migration_issues (
+  id bigserial PRIMARY KEY,
+  doctor_id uuid NOT NULL REFERENCES doctors(user_id) ON DELETE CASCADE,
+  field varchar(50) NOT NULL,
+  raw_value text NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE
+  doctors
+ADD
+  COLUMN date_of_birth DATE,
+ADD
+  COLUMN gender_normalized gender,
+ADD
+  COLUMN marital_status_normalized marital_status,
+ADD
+  COLUMN blood_group_normalized blood_group;
+
+-- Only numeric ages can be turned into a date of birth. The exact day is
+-- unknown, so it is approximated as the 1st of January of the birth year.
+UPDATE
+  doctors
+SET
+  date_of_birth = make_date(
+    date_part('year', NOW())::int - trim(age)::int,
+    1,
+    1
+  )
+WHERE
+  -- the CASE keeps non-numeric ages from being cast, as PostgreSQL may
+  -- evaluate the conditions of a WHERE in any order
+  CASE
+    WHEN trim(age) ~ '^[0-9]{1,3}$' THEN trim(age)::int
+  END BETWEEN 18 AND 120;
+
+UPDATE
+  doctors
+SET
+  gender_normalized = (
+    CASE
+      lower(trim(gender))
+      WHEN 'm' THEN 'male'
+      WHEN 'male' THEN 'male'
+      WHEN 'man' THEN 'male'
+      WHEN 'f' THEN 'female'
+      WHEN 'female' THEN 'female'
+      WHEN 'woman' THEN 'female'
+      WHEN 'o' THEN 'other'
+      WHEN 'other' THEN 'other'
+      WHEN 'non-binary' THEN 'other'
+      WHEN 'nonbinary' THEN 'other'
+    END
+  )::gender;
+
+UPDATE
+  doctors
+SET
+  marital_status_normalized = (
+    CASE
+      lower(trim(marital_status))
+      WHEN 'single' THEN 'single'
+      WHEN 'unmarried' THEN 'single'
+      WHEN 'married' THEN 'married'
+      WHEN 'divorced' THEN 'divorced'
+      WHEN 'widowed' THEN 'widowed'
+      WHEN 'widow' THEN 'widowed'
+      WHEN 'widower' THEN 'widowed'
+      WHEN 'separated' THEN 'separated'
+    END
+  )::marital_status;
+
+-- Accepts spellings such as "o+ve", "O positive", "AB NEG" and "0+".
+UPDATE
+  doctors
+SET
+  blood_group_normalized = n.value::blood_group
+FROM
+  (
+    SELECT
+      user_id,
+      regexp_replace(
+        regexp_replace(
+          regexp_replace(
+            upper(regexp_replace(blood_group, '\s', '', 'g')),
+            '^0',
+            'O'
+          ),
+          '(\+VE|POSITIVE|POS)$',
+          '+'
+        ),
+        '(-VE|NEGATIVE|NEG)$',
+        '-'
+      ) AS value
+    FROM
+      doctors
+  ) n
+WHERE
+  n.user_id = doctors.user_id
+  AND n.value IN ('A+', 'A-', 'B+', 'B-', 'AB+', 'AB-', 'O+', 'O-');
+
+INSERT INTO
+  doctor_profile_migration_issues (doctor_id, field, raw_value)
+SELECT
+  user_id,
+  'age',
+  age
+FROM
+  doctors
+WHERE
+  trim(COALESCE(age, '')) <> ''
+  AND date_of_birth IS NULL;
+
+INSERT INTO
+  doctor_profile_migration_issues (doctor_id, field, raw_value)
+SELECT
+  user_id,
+  'gender',
+  gender
+FROM
+  doctors
+WHERE
+  trim(COALESCE(gender, '')) <> ''
+  AND gender_normalized IS NULL;
+
+INSERT INTO
+  doctor_profile_migration_issues (doctor_id, field, raw_value)
+SELECT
+  user_id,
+  'marital_status',
+  marital_status
+FROM
+  doctors
+WHERE
+  trim(COALESCE(marital_status, '')) <> ''
+  AND marital_status_normalized IS NULL;
+
+INSERT INTO
+  doctor_profile_migration_issues (doctor_id, field, raw_value)
+SELECT
+  user_id,
+  'blood_group',
+  blood_group
+FROM
+  doctors
+WHERE
+  trim(COALESCE(blood_group, '')) <> ''
+  AND blood_group_normalized IS NULL;
+
+ALTER TABLE
+  doctors DROP COLUMN age,
+  DROP COLUMN gender,
+  DROP COLUMN marital_status,
+  DROP COLUMN blood_group;
+
+ALTER TABLE
+  doctors RENAME COLUMN gender_normalized TO gender;
+
+ALTER TABLE
+  doctors RENAME COLUMN marital_status_normalized TO marital_status;
+
+ALTER TABLE
+  doctors RENAME COLUMN blood_group_normalized TO blood_group;
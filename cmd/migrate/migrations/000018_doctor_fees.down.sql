@@ -0,0 +1,11 @@
+ALTER TABLE
+  appointment DROP COLUMN IF EXISTS fee_currency,
+  DROP COLUMN IF EXISTS fee_amount,
+  DROP COLUMN IF EXISTS consultation_mode,
+  DROP COLUMN IF EXISTS visit_type;
+
+DROP TABLE IF EXISTS doctor_fees;
+
+DROP TYPE IF EXISTS consultation_mode;
+
+DROP TYPE IF EXISTS visit_type;
@@ -0,0 +1,49 @@
+-- The appointment table predates the migrations; make sure it exists before
+-- it is extended below.
+CREATE TABLE IF NOT EXISTS appointment (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  doctor_id uuid NOT NULL REFERENCES doctors(user_id) ON DELETE CASCADE,
+  patient_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  appointment_time timestamp(0) with time zone NOT NULL
+);
+
+CREATE TYPE visit_type AS ENUM ('new_patient', 'follow_up');
+
+CREATE TYPE consultation_mode AS ENUM ('in_person', 'online');
+
+-- Fees are never updated in place. A price change is a new row with a later
+-- effective_from, so the full history is kept.
+CREATE TABLE IF NOT EXISTS doctor_fees (
+  id bigserial PRIMARY KEY,
+  doctor_id uuid NOT NULL REFERENCES doctors(user_id) ON DELETE CASCADE,
+  visit_type visit_type NOT NULL,
+  consultation_mode consultation_mode NOT NULL,
+  amount bigint NOT NULL CHECK (amount >= 0),
+  currency char(3) NOT NULL,
+  effective_from date NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT doctor_fees_version_key UNIQUE (
+    doctor_id,
+    visit_type,
+    consultation_mode,
+    effective_from
+  )
+);
+
+CREATE INDEX IF NOT EXISTS idx_doctor_fees_lookup ON doctor_fees (
+  doctor_id,
+  visit_type,
+  consultation_mode,
+  effective_from DESC
+);
+
+ALTER TABLE
+  appointment
+ADD
+  COLUMN visit_type visit_type NOT NULL DEFAULT 'new_patient',
+ADD
+  COLUMN consultation_mode consultation_mode NOT NULL DEFAULT 'in_person',
+ADD
+  COLUMN fee_amount bigint,
+ADD
+  COLUMN fee_currency char(3);
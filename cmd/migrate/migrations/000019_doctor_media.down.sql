@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS doctor_documents;
+
+DROP TYPE IF EXISTS doctor_document_kind;
+
+ALTER TABLE
+  doctors DROP COLUMN IF EXISTS photo_thumbnail_key,
+  DROP COLUMN IF EXISTS photo_key;
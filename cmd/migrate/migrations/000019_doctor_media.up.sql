@@ -0,0 +1,27 @@
+ALTER TABLE
+  doctors
+ADD
+  COLUMN photo_key TEXT,
+ADD
+  COLUMN photo_thumbnail_key TEXT;
+
+CREATE TYPE doctor_document_kind AS ENUM (
+  'license',
+  'degree',
+  'certificate',
+  'other'
+);
+
+CREATE TABLE IF NOT EXISTS doctor_documents (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  doctor_id uuid NOT NULL REFERENCES doctors(user_id) ON DELETE CASCADE,
+  kind doctor_document_kind NOT NULL,
+  file_name varchar(255) NOT NULL,
+  content_type varchar(100) NOT NULL,
+  size_bytes bigint NOT NULL,
+  storage_key TEXT NOT NULL UNIQUE,
+  uploaded_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_doctor_documents_doctor_id ON doctor_documents (doctor_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS patient_insurance_policies;
+
+DROP TABLE IF EXISTS patients;
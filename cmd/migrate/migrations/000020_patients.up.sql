@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS patients (
+  user_id uuid PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  firstname varchar(100) NOT NULL,
+  lastname varchar(100) NOT NULL,
+  date_of_birth DATE NOT NULL,
+  sex gender NOT NULL,
+  phone varchar(30) NOT NULL,
+  address TEXT,
+  country varchar(50),
+  state varchar(50),
+  city varchar(50),
+  postal_code varchar(20),
+  blood_group blood_group,
+  emergency_contacts jsonb NOT NULL DEFAULT '[]',
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_patients_name ON patients USING gin ((firstname || ' ' || lastname) gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_patients_phone ON patients (phone);
+
+CREATE INDEX IF NOT EXISTS idx_patients_date_of_birth ON patients (date_of_birth);
+
+CREATE TABLE IF NOT EXISTS patient_insurance_policies (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE CASCADE,
+  payer varchar(255) NOT NULL,
+  plan_name varchar(255),
+  policy_number varchar(100) NOT NULL,
+  member_id varchar(100),
+  group_number varchar(100),
+  valid_from DATE,
+  valid_to DATE,
+  is_primary boolean NOT NULL DEFAULT false,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT patient_insurance_policy_number_key UNIQUE (patient_id, payer, policy_number)
+);
+
+-- A patient has at most one primary policy.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_patient_insurance_primary ON patient_insurance_policies (patient_id)
+WHERE
+  is_primary;
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS patient_identifiers;
+
+DROP TYPE IF EXISTS patient_identifier_type;
+
+ALTER TABLE
+  patients DROP COLUMN IF EXISTS mrn;
+
+DROP TABLE IF EXISTS mrn_registry;
+
+DROP SEQUENCE IF EXISTS patient_mrn_seq;
@@ -0,0 +1,103 @@
+-- MRN sequence values are never handed out twice, even when the insert that
+-- took them is rolled back.
+CREATE SEQUENCE IF NOT EXISTS patient_mrn_seq;
+
+-- Every MRN ever issued. Rows outlive the patient so an MRN is never reused.
+CREATE TABLE IF NOT EXISTS mrn_registry (
+  mrn varchar(40) PRIMARY KEY,
+  patient_id uuid REFERENCES users(id) ON DELETE SET NULL,
+  issued_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE
+  patients
+ADD
+  COLUMN mrn varchar(40);
+
+-- Existing patients get an MRN in the default format MRN-YYYY-NNNNNN-C,
+-- where C is a Luhn check digit over all preceding digits.
+CREATE FUNCTION pg_temp.luhn_check_digit(digits text) RETURNS int AS $$
+DECLARE
+  total int := 0;
+  n int;
+  double boolean := true;
+BEGIN
+  FOR i IN REVERSE length(digits)..1 LOOP
+    n := substr(digits, i, 1)::int;
+    IF double THEN
+      n := n * 2;
+      IF n > 9 THEN
+        n := n - 9;
+      END IF;
+    END IF;
+    total := total + n;
+    double := NOT double;
+  END LOOP;
+  RETURN (10 - total % 10) % 10;
+END;
+$$ LANGUAGE plpgsql;
+
+WITH numbered AS (
+  SELECT
+    user_id,
+    to_char(created_at, 'YYYY') AS year,
+    lpad(nextval('patient_mrn_seq')::text, 6, '0') AS seq
+  FROM
+    (
+      SELECT
+        user_id,
+        created_at
+      FROM
+        patients
+      ORDER BY
+        created_at,
+        user_id
+    ) p
+)
+UPDATE
+  patients
+SET
+  mrn = 'MRN-' || n.year || '-' || n.seq || '-' || pg_temp.luhn_check_digit(n.year || n.seq)
+FROM
+  numbered n
+WHERE
+  patients.user_id = n.user_id;
+
+INSERT INTO
+  mrn_registry (mrn, patient_id)
+SELECT
+  mrn,
+  user_id
+FROM
+  patients;
+
+ALTER TABLE
+  patients
+ALTER COLUMN
+  mrn
+SET
+  NOT NULL,
+ADD
+  CONSTRAINT patients_mrn_key UNIQUE (mrn);
+
+CREATE TYPE patient_identifier_type AS ENUM (
+  'national_id',
+  'passport',
+  'insurance_member_id',
+  'driver_license',
+  'other'
+);
+
+-- Issuer is the issuing country or payer. It is part of the key because the
+-- same number may be issued by different authorities.
+CREATE TABLE IF NOT EXISTS patient_identifiers (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE CASCADE,
+  type patient_identifier_type NOT NULL,
+  value varchar(100) NOT NULL,
+  issuer varchar(100) NOT NULL DEFAULT '',
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT patient_identifiers_value_key UNIQUE (type, issuer, value)
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_identifiers_patient_id ON patient_identifiers (patient_id);
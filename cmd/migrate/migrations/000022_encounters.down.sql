@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS encounter_addenda;
+
+DROP TABLE IF EXISTS encounters;
+
+DROP FUNCTION IF EXISTS prevent_addendum_change();
+
+DROP FUNCTION IF EXISTS prevent_signed_encounter_change();
+
+DROP TYPE IF EXISTS encounter_status;
+
+DROP INDEX IF EXISTS idx_appointment_patient_doctor;
+
+ALTER TABLE
+  appointment DROP COLUMN IF EXISTS status;
+
+DROP TYPE IF EXISTS appointment_status;
@@ -0,0 +1,73 @@
+CREATE TYPE appointment_status AS ENUM ('scheduled', 'completed', 'cancelled');
+
+ALTER TABLE
+  appointment
+ADD
+  COLUMN status appointment_status NOT NULL DEFAULT 'scheduled';
+
+CREATE INDEX IF NOT EXISTS idx_appointment_patient_doctor ON appointment (patient_id, doctor_id);
+
+CREATE TYPE encounter_status AS ENUM ('draft', 'signed');
+
+-- One encounter per completed appointment, holding the SOAP note of the
+-- visit. The note can be edited by its doctor while it is a draft and is
+-- locked once signed.
+CREATE TABLE IF NOT EXISTS encounters (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  appointment_id uuid NOT NULL REFERENCES appointment(id) ON DELETE RESTRICT,
+  patient_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  doctor_id uuid NOT NULL REFERENCES doctors(user_id) ON DELETE RESTRICT,
+  subjective text NOT NULL DEFAULT '',
+  objective text NOT NULL DEFAULT '',
+  assessment text NOT NULL DEFAULT '',
+  plan text NOT NULL DEFAULT '',
+  status encounter_status NOT NULL DEFAULT 'draft',
+  signed_at timestamp(0) with time zone,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT encounters_appointment_key UNIQUE (appointment_id),
+  CONSTRAINT encounters_signed_check CHECK ((status = 'signed') = (signed_at IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_encounters_patient_id ON encounters (patient_id, created_at DESC);
+
+-- Corrections to a signed encounter. Addenda are append only.
+CREATE TABLE IF NOT EXISTS encounter_addenda (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  encounter_id uuid NOT NULL REFERENCES encounters(id) ON DELETE CASCADE,
+  author_id uuid NOT NULL REFERENCES doctors(user_id) ON DELETE RESTRICT,
+  body text NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_encounter_addenda_encounter_id ON encounter_addenda (encounter_id, created_at);
+
+-- Signed notes are part of the medical record: refuse any change to them
+-- and to their addenda, whatever path the write comes from.
+CREATE OR REPLACE FUNCTION prevent_signed_encounter_change() RETURNS trigger AS $$
+BEGIN
+  IF OLD.status = 'signed' THEN
+    RAISE EXCEPTION 'encounter % is signed and cannot be changed', OLD.id
+      USING ERRCODE = 'check_violation', CONSTRAINT = 'encounters_locked';
+  END IF;
+  IF TG_OP = 'DELETE' THEN
+    RETURN OLD;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER encounters_locked
+BEFORE UPDATE OR DELETE ON encounters
+FOR EACH ROW EXECUTE FUNCTION prevent_signed_encounter_change();
+
+CREATE OR REPLACE FUNCTION prevent_addendum_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'addenda cannot be changed'
+    USING ERRCODE = 'check_violation', CONSTRAINT = 'encounter_addenda_locked';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER encounter_addenda_locked
+BEFORE UPDATE OR DELETE ON encounter_addenda
+FOR EACH ROW EXECUTE FUNCTION prevent_addendum_change();
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS encounter_diagnoses;
+
+DROP FUNCTION IF EXISTS prevent_signed_diagnosis_change();
+
+DROP TYPE IF EXISTS diagnosis_rank;
+
+DROP TABLE IF EXISTS icd10_codes;
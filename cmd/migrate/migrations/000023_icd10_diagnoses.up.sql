@@ -0,0 +1,49 @@
+-- Loaded by the ICD-10 import command (make import-icd10), not by migrations.
+CREATE TABLE IF NOT EXISTS icd10_codes (
+  code varchar(8) PRIMARY KEY,
+  description text NOT NULL,
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_icd10_codes_description ON icd10_codes USING gin (description gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_icd10_codes_code_pattern ON icd10_codes (code varchar_pattern_ops);
+
+CREATE TYPE diagnosis_rank AS ENUM ('primary', 'secondary');
+
+CREATE TABLE IF NOT EXISTS encounter_diagnoses (
+  encounter_id uuid NOT NULL REFERENCES encounters(id) ON DELETE CASCADE,
+  code varchar(8) NOT NULL REFERENCES icd10_codes(code) ON DELETE RESTRICT,
+  rank diagnosis_rank NOT NULL,
+  position int NOT NULL DEFAULT 0,
+  PRIMARY KEY (encounter_id, code)
+);
+
+-- An encounter has at most one primary diagnosis.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_encounter_diagnoses_primary ON encounter_diagnoses (encounter_id)
+WHERE
+  rank = 'primary';
+
+CREATE INDEX IF NOT EXISTS idx_encounter_diagnoses_code ON encounter_diagnoses (code);
+
+-- Diagnoses are part of the note and are locked with it when the encounter
+-- is signed.
+CREATE OR REPLACE FUNCTION prevent_signed_diagnosis_change() RETURNS trigger AS $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM encounters
+    WHERE id = COALESCE(NEW.encounter_id, OLD.encounter_id) AND status = 'signed'
+  ) THEN
+    RAISE EXCEPTION 'encounter is signed and its diagnoses cannot be changed'
+      USING ERRCODE = 'check_violation', CONSTRAINT = 'encounter_diagnoses_locked';
+  END IF;
+  IF TG_OP = 'DELETE' THEN
+    RETURN OLD;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER encounter_diagnoses_locked
+BEFORE INSERT OR UPDATE OR DELETE ON encounter_diagnoses
+FOR EACH ROW EXECUTE FUNCTION prevent_signed_diagnosis_change();
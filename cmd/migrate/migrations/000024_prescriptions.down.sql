@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS prescription_items;
+
+DROP TABLE IF EXISTS prescriptions;
+
+DROP FUNCTION IF EXISTS prevent_prescription_item_change();
+
+DROP FUNCTION IF EXISTS prevent_prescription_change();
+
+DROP TYPE IF EXISTS prescription_status;
@@ -0,0 +1,71 @@
+CREATE TYPE prescription_status AS ENUM ('issued', 'cancelled');
+
+-- A prescription is written once, when it is issued, together with its
+-- signature and PDF. Afterwards the only allowed change is cancellation.
+CREATE TABLE IF NOT EXISTS prescriptions (
+  id uuid PRIMARY KEY,
+  encounter_id uuid NOT NULL REFERENCES encounters(id) ON DELETE RESTRICT,
+  patient_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  doctor_id uuid NOT NULL REFERENCES doctors(user_id) ON DELETE RESTRICT,
+  doctor_license_number varchar(100) NOT NULL,
+  status prescription_status NOT NULL DEFAULT 'issued',
+  issued_at timestamp(0) with time zone NOT NULL,
+  signature char(64) NOT NULL,
+  pdf_key varchar(255) NOT NULL,
+  cancelled_at timestamp(0) with time zone,
+  cancelled_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  cancellation_reason text,
+  CONSTRAINT prescriptions_cancellation_check CHECK (
+    (status = 'cancelled') = (cancelled_at IS NOT NULL AND cancellation_reason IS NOT NULL)
+  )
+);
+
+CREATE INDEX IF NOT EXISTS idx_prescriptions_encounter_id ON prescriptions (encounter_id);
+
+CREATE INDEX IF NOT EXISTS idx_prescriptions_patient_id ON prescriptions (patient_id, issued_at DESC);
+
+CREATE TABLE IF NOT EXISTS prescription_items (
+  prescription_id uuid NOT NULL REFERENCES prescriptions(id) ON DELETE CASCADE,
+  position int NOT NULL,
+  drug varchar(255) NOT NULL,
+  strength varchar(100) NOT NULL,
+  dose varchar(100) NOT NULL,
+  frequency varchar(100) NOT NULL,
+  duration varchar(100) NOT NULL,
+  quantity varchar(50) NOT NULL,
+  instructions text NOT NULL DEFAULT '',
+  PRIMARY KEY (prescription_id, position)
+);
+
+CREATE OR REPLACE FUNCTION prevent_prescription_change() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'UPDATE'
+    AND OLD.status = 'issued'
+    AND NEW.status = 'cancelled'
+    AND (NEW.id, NEW.encounter_id, NEW.patient_id, NEW.doctor_id, NEW.doctor_license_number,
+         NEW.issued_at, NEW.signature, NEW.pdf_key)
+      IS NOT DISTINCT FROM
+        (OLD.id, OLD.encounter_id, OLD.patient_id, OLD.doctor_id, OLD.doctor_license_number,
+         OLD.issued_at, OLD.signature, OLD.pdf_key)
+  THEN
+    RETURN NEW;
+  END IF;
+  RAISE EXCEPTION 'prescription % is immutable', OLD.id
+    USING ERRCODE = 'check_violation', CONSTRAINT = 'prescriptions_immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prescriptions_immutable
+BEFORE UPDATE OR DELETE ON prescriptions
+FOR EACH ROW EXECUTE FUNCTION prevent_prescription_change();
+
+CREATE OR REPLACE FUNCTION prevent_prescription_item_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'prescription items are immutable'
+    USING ERRCODE = 'check_violation', CONSTRAINT = 'prescription_items_immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER prescription_items_immutable
+BEFORE UPDATE OR DELETE ON prescription_items
+FOR EACH ROW EXECUTE FUNCTION prevent_prescription_item_change();
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS prescription_overrides;
+
+DROP TABLE IF EXISTS patient_allergies;
+
+DROP TYPE IF EXISTS allergy_severity;
+
+DROP TYPE IF EXISTS allergy_type;
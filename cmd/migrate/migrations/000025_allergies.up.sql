@@ -0,0 +1,32 @@
+CREATE TYPE allergy_type AS ENUM ('allergy', 'intolerance');
+
+CREATE TYPE allergy_severity AS ENUM ('mild', 'moderate', 'severe', 'unknown');
+
+CREATE TABLE IF NOT EXISTS patient_allergies (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE CASCADE,
+  substance varchar(255) NOT NULL,
+  type allergy_type NOT NULL,
+  severity allergy_severity NOT NULL DEFAULT 'unknown',
+  reaction varchar(1000) NOT NULL DEFAULT '',
+  recorded_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS patient_allergies_substance_key ON patient_allergies (patient_id, lower(substance));
+
+-- Warnings the prescriber acknowledged and overrode when issuing a
+-- prescription. Rows are written with the prescription and never changed.
+CREATE TABLE IF NOT EXISTS prescription_overrides (
+  prescription_id uuid NOT NULL REFERENCES prescriptions(id) ON DELETE CASCADE,
+  code varchar(255) NOT NULL,
+  kind varchar(20) NOT NULL,
+  severity varchar(20) NOT NULL,
+  drug varchar(255) NOT NULL,
+  interacts_with varchar(255) NOT NULL,
+  message text NOT NULL,
+  reason text NOT NULL,
+  overridden_by uuid NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (prescription_id, code)
+);
@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS lab_results;
+
+DROP TABLE IF EXISTS lab_orders;
+
+DROP TYPE IF EXISTS lab_flag;
+
+DROP TYPE IF EXISTS lab_order_status;
+
+DROP TABLE IF EXISTS lab_reference_ranges;
+
+DROP TABLE IF EXISTS lab_tests;
+
+UPDATE users SET role_id = (SELECT id FROM roles WHERE name = 'patient')
+WHERE role_id = (SELECT id FROM roles WHERE name = 'lab');
+
+DELETE FROM roles WHERE name = 'lab';
@@ -0,0 +1,110 @@
+INSERT INTO
+  roles (name, description, level)
+VALUES
+  (
+    'lab',
+    'Lab staff enter the results of lab orders',
+    2
+  ) ON CONFLICT (name) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS lab_tests (
+  code varchar(20) PRIMARY KEY,
+  name varchar(255) NOT NULL,
+  specimen varchar(50) NOT NULL,
+  unit varchar(30) NOT NULL,
+  active boolean NOT NULL DEFAULT true,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+-- A test can have several ranges, by sex and by age in completed years
+-- [age_min, age_max). A NULL sex applies to everyone; the most specific
+-- matching range is used.
+CREATE TABLE IF NOT EXISTS lab_reference_ranges (
+  id bigserial PRIMARY KEY,
+  test_code varchar(20) NOT NULL REFERENCES lab_tests(code) ON DELETE CASCADE,
+  sex gender,
+  age_min int NOT NULL DEFAULT 0,
+  age_max int,
+  low numeric,
+  high numeric,
+  critical_low numeric,
+  critical_high numeric,
+  CONSTRAINT lab_reference_ranges_age_check CHECK (age_min >= 0 AND (age_max IS NULL OR age_max > age_min)),
+  CONSTRAINT lab_reference_ranges_bounds_check CHECK (
+    (low IS NULL OR high IS NULL OR low <= high)
+    AND (critical_low IS NULL OR low IS NULL OR critical_low <= low)
+    AND (critical_high IS NULL OR high IS NULL OR critical_high >= high)
+  )
+);
+
+CREATE INDEX IF NOT EXISTS idx_lab_reference_ranges_test_code ON lab_reference_ranges (test_code);
+
+CREATE TYPE lab_order_status AS ENUM ('ordered', 'resulted', 'released');
+
+CREATE TYPE lab_flag AS ENUM ('normal', 'low', 'high', 'critical_low', 'critical_high');
+
+CREATE TABLE IF NOT EXISTS lab_orders (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  encounter_id uuid NOT NULL REFERENCES encounters(id) ON DELETE RESTRICT,
+  patient_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  ordered_by uuid NOT NULL REFERENCES doctors(user_id) ON DELETE RESTRICT,
+  status lab_order_status NOT NULL DEFAULT 'ordered',
+  notes text NOT NULL DEFAULT '',
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  resulted_at timestamp(0) with time zone,
+  released_at timestamp(0) with time zone
+);
+
+CREATE INDEX IF NOT EXISTS idx_lab_orders_patient_id ON lab_orders (patient_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_lab_orders_status ON lab_orders (status, created_at);
+
+-- One row per ordered test. value is NULL until the lab enters it; the
+-- range the value was flagged against is copied onto the row.
+CREATE TABLE IF NOT EXISTS lab_results (
+  order_id uuid NOT NULL REFERENCES lab_orders(id) ON DELETE CASCADE,
+  test_code varchar(20) NOT NULL REFERENCES lab_tests(code) ON DELETE RESTRICT,
+  value numeric,
+  unit varchar(30) NOT NULL,
+  low numeric,
+  high numeric,
+  flag lab_flag,
+  comment text NOT NULL DEFAULT '',
+  entered_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  entered_at timestamp(0) with time zone,
+  PRIMARY KEY (order_id, test_code)
+);
+
+INSERT INTO
+  lab_tests (code, name, specimen, unit)
+VALUES
+  ('HGB', 'Haemoglobin', 'blood', 'g/dL'),
+  ('WBC', 'White blood cell count', 'blood', '10^9/L'),
+  ('PLT', 'Platelet count', 'blood', '10^9/L'),
+  ('NA', 'Sodium', 'serum', 'mmol/L'),
+  ('K', 'Potassium', 'serum', 'mmol/L'),
+  ('CREA', 'Creatinine', 'serum', 'umol/L'),
+  ('GLU-F', 'Glucose, fasting', 'plasma', 'mmol/L'),
+  ('HBA1C', 'Haemoglobin A1c', 'blood', '%'),
+  ('ALT', 'Alanine aminotransferase', 'serum', 'U/L'),
+  ('TSH', 'Thyroid stimulating hormone', 'serum', 'mIU/L'),
+  ('CRP', 'C-reactive protein', 'serum', 'mg/L') ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO
+  lab_reference_ranges (test_code, sex, age_min, age_max, low, high, critical_low, critical_high)
+VALUES
+  ('HGB', NULL, 0, 18, 11.0, 15.5, 7.0, 20.0),
+  ('HGB', 'male', 18, NULL, 13.5, 17.5, 7.0, 20.0),
+  ('HGB', 'female', 18, NULL, 12.0, 15.5, 7.0, 20.0),
+  ('WBC', NULL, 0, NULL, 4.0, 11.0, 2.0, 30.0),
+  ('PLT', NULL, 0, NULL, 150, 400, 50, 1000),
+  ('NA', NULL, 0, NULL, 135, 145, 120, 160),
+  ('K', NULL, 0, NULL, 3.5, 5.1, 2.8, 6.2),
+  ('CREA', NULL, 0, 18, 27, 62, NULL, 500),
+  ('CREA', 'male', 18, NULL, 62, 106, NULL, 500),
+  ('CREA', 'female', 18, NULL, 44, 80, NULL, 500),
+  ('GLU-F', NULL, 0, NULL, 3.9, 5.5, 2.5, 25.0),
+  ('HBA1C', NULL, 0, NULL, 4.0, 5.6, NULL, NULL),
+  ('ALT', NULL, 0, NULL, 7, 56, NULL, NULL),
+  ('TSH', NULL, 0, NULL, 0.4, 4.0, NULL, NULL),
+  ('CRP', NULL, 0, NULL, NULL, 5, NULL, NULL);
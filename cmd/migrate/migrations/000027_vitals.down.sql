@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS vital_thresholds;
+
+DROP TABLE IF EXISTS vitals;
+
+UPDATE users SET role_id = (SELECT id FROM roles WHERE name = 'patient')
+WHERE role_id = (SELECT id FROM roles WHERE name = 'nurse');
+
+DELETE FROM roles WHERE name = 'nurse';
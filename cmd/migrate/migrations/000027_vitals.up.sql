@@ -0,0 +1,55 @@
+INSERT INTO
+  roles (name, description, level)
+VALUES
+  (
+    'nurse',
+    'A nurse records vital signs and assists doctors with patient care',
+    2
+  ) ON CONFLICT (name) DO NOTHING;
+
+-- One row per set of measurements, stored in canonical units: mmHg, beats
+-- per minute, degrees Celsius, percent, kilograms and centimetres.
+CREATE TABLE IF NOT EXISTS vitals (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  patient_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  appointment_id uuid REFERENCES appointment(id) ON DELETE SET NULL,
+  systolic int,
+  diastolic int,
+  pulse int,
+  temperature numeric(4, 1),
+  spo2 int,
+  weight numeric(5, 2),
+  height numeric(4, 1),
+  bmi numeric(4, 1),
+  recorded_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  recorded_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT vitals_any_check CHECK (
+    num_nonnulls(systolic, diastolic, pulse, temperature, spo2, weight, height) > 0
+  ),
+  CONSTRAINT vitals_blood_pressure_check CHECK (
+    (systolic IS NULL) = (diastolic IS NULL) AND (systolic IS NULL OR systolic > diastolic)
+  )
+);
+
+CREATE INDEX IF NOT EXISTS idx_vitals_patient_id ON vitals (patient_id, recorded_at);
+
+-- Values outside [low, high] raise an alert. Either bound may be NULL.
+CREATE TABLE IF NOT EXISTS vital_thresholds (
+  type varchar(20) PRIMARY KEY,
+  low numeric,
+  high numeric,
+  updated_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT vital_thresholds_bounds_check CHECK (low IS NULL OR high IS NULL OR low < high)
+);
+
+INSERT INTO
+  vital_thresholds (type, low, high)
+VALUES
+  ('systolic', 90, 140),
+  ('diastolic', 60, 90),
+  ('pulse', 50, 100),
+  ('temperature', 35.5, 38.0),
+  ('spo2', 92, NULL),
+  ('bmi', 18.5, 30.0) ON CONFLICT (type) DO NOTHING;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS patient_documents;
+
+DROP TYPE IF EXISTS patient_document_category;
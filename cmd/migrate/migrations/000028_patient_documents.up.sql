@@ -0,0 +1,34 @@
+CREATE TYPE patient_document_category AS ENUM (
+  'lab_report',
+  'imaging',
+  'referral_letter',
+  'discharge_summary',
+  'prescription',
+  'consent_form',
+  'insurance',
+  'identity',
+  'other'
+);
+
+CREATE TABLE IF NOT EXISTS patient_documents (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE CASCADE,
+  category patient_document_category NOT NULL,
+  title varchar(255) NOT NULL,
+  encounter_id uuid REFERENCES encounters(id) ON DELETE SET NULL,
+  appointment_id uuid REFERENCES appointment(id) ON DELETE SET NULL,
+  file_name varchar(255) NOT NULL,
+  content_type varchar(100) NOT NULL,
+  size_bytes bigint NOT NULL,
+  storage_key TEXT NOT NULL UNIQUE,
+  -- the virus scanner that passed the file, "none" when scanning is off
+  scanned_by varchar(50) NOT NULL,
+  uploaded_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_documents_patient_id ON patient_documents (patient_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_patient_documents_encounter_id ON patient_documents (encounter_id);
+
+CREATE INDEX IF NOT EXISTS idx_patient_documents_appointment_id ON patient_documents (appointment_id);
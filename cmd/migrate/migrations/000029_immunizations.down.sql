@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS immunization_reminders;
+
+DROP TABLE IF EXISTS immunizations;
+
+DROP TYPE IF EXISTS immunization_site;
+
+DROP TABLE IF EXISTS immunization_schedule;
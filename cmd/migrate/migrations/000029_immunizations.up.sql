@@ -0,0 +1,78 @@
+-- Recommended doses by age. A dose is due due_age_days after birth, and no
+-- sooner than min_interval_days after the previous dose of the vaccine; it
+-- becomes overdue grace_days after that. Past catch_up_until_days of age the
+-- dose is no longer offered. Rows are configured by admins.
+CREATE TABLE IF NOT EXISTS immunization_schedule (
+  vaccine varchar(50) NOT NULL,
+  dose_number int NOT NULL CHECK (dose_number > 0),
+  vaccine_name varchar(255) NOT NULL,
+  due_age_days int NOT NULL CHECK (due_age_days >= 0),
+  min_interval_days int NOT NULL DEFAULT 0 CHECK (min_interval_days >= 0),
+  grace_days int NOT NULL DEFAULT 28 CHECK (grace_days >= 0),
+  catch_up_until_days int CHECK (catch_up_until_days > due_age_days),
+  active boolean NOT NULL DEFAULT true,
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (vaccine, dose_number)
+);
+
+CREATE TYPE immunization_site AS ENUM (
+  'left_arm',
+  'right_arm',
+  'left_thigh',
+  'right_thigh',
+  'oral',
+  'intranasal',
+  'other'
+);
+
+CREATE TABLE IF NOT EXISTS immunizations (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE CASCADE,
+  vaccine varchar(50) NOT NULL,
+  vaccine_name varchar(255) NOT NULL,
+  dose_number int NOT NULL CHECK (dose_number > 0),
+  lot_number varchar(100) NOT NULL,
+  site immunization_site NOT NULL,
+  administered_at timestamp(0) with time zone NOT NULL,
+  administered_by uuid NOT NULL REFERENCES users(id),
+  notes varchar(1000) NOT NULL DEFAULT '',
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT immunizations_dose_key UNIQUE (patient_id, vaccine, dose_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_immunizations_patient_id ON immunizations (patient_id, administered_at);
+
+-- The last reminder email sent for an overdue dose, so that reminders are
+-- not repeated on every run.
+CREATE TABLE IF NOT EXISTS immunization_reminders (
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE CASCADE,
+  vaccine varchar(50) NOT NULL,
+  dose_number int NOT NULL,
+  sent_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (patient_id, vaccine, dose_number)
+);
+
+INSERT INTO
+  immunization_schedule (
+    vaccine,
+    dose_number,
+    vaccine_name,
+    due_age_days,
+    min_interval_days,
+    grace_days,
+    catch_up_until_days
+  )
+VALUES
+  ('bcg', 1, 'BCG', 0, 0, 28, 365),
+  ('opv', 1, 'Oral polio', 42, 0, 28, 1825),
+  ('opv', 2, 'Oral polio', 70, 28, 28, 1825),
+  ('opv', 3, 'Oral polio', 98, 28, 28, 1825),
+  ('penta', 1, 'Pentavalent (DTP-HepB-Hib)', 42, 0, 28, 1825),
+  ('penta', 2, 'Pentavalent (DTP-HepB-Hib)', 70, 28, 28, 1825),
+  ('penta', 3, 'Pentavalent (DTP-HepB-Hib)', 98, 28, 28, 1825),
+  ('pcv', 1, 'Pneumococcal conjugate', 42, 0, 28, 730),
+  ('pcv', 2, 'Pneumococcal conjugate', 70, 28, 28, 730),
+  ('pcv', 3, 'Pneumococcal conjugate', 98, 28, 28, 730),
+  ('ipv', 1, 'Inactivated polio', 98, 0, 28, 1825),
+  ('mr', 1, 'Measles-rubella', 270, 0, 28, 5475),
+  ('mr', 2, 'Measles-rubella', 450, 28, 28, 5475) ON CONFLICT (vaccine, dose_number) DO NOTHING;
@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS patient_guardians;
+
+DROP TYPE IF EXISTS guardian_relationship;
+
+-- dependents have no login and cannot be kept once email and password are
+-- required again
+DELETE FROM users WHERE email IS NULL OR password IS NULL;
+
+ALTER TABLE
+  users
+ALTER COLUMN
+  email SET NOT NULL,
+ALTER COLUMN
+  password SET NOT NULL;
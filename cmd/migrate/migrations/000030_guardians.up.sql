@@ -0,0 +1,29 @@
+-- Dependents are patients without a login of their own, such as young
+-- children, managed by one or more guardian accounts.
+ALTER TABLE
+  users
+ALTER COLUMN
+  email DROP NOT NULL,
+ALTER COLUMN
+  password DROP NOT NULL;
+
+CREATE TYPE guardian_relationship AS ENUM (
+  'parent',
+  'legal_guardian',
+  'spouse',
+  'child',
+  'sibling',
+  'caregiver',
+  'other'
+);
+
+CREATE TABLE IF NOT EXISTS patient_guardians (
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE CASCADE,
+  guardian_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  relationship guardian_relationship NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT patient_guardians_pkey PRIMARY KEY (patient_id, guardian_id),
+  CONSTRAINT patient_guardians_self_check CHECK (patient_id <> guardian_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_patient_guardians_guardian_id ON patient_guardians (guardian_id);
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS adt_events;
+
+DROP TYPE IF EXISTS adt_event_type;
+
+DROP TABLE IF EXISTS admissions;
+
+DROP TYPE IF EXISTS admission_status;
+
+DROP TABLE IF EXISTS beds;
+
+DROP TYPE IF EXISTS bed_status;
+
+DROP TABLE IF EXISTS rooms;
+
+DROP TABLE IF EXISTS wards;
@@ -0,0 +1,72 @@
+CREATE TABLE IF NOT EXISTS wards (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name varchar(100) NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT wards_name_key UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS rooms (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  ward_id uuid NOT NULL REFERENCES wards(id) ON DELETE RESTRICT,
+  name varchar(100) NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT rooms_ward_name_key UNIQUE (ward_id, name)
+);
+
+CREATE TYPE bed_status AS ENUM ('available', 'occupied', 'cleaning', 'out_of_service');
+
+CREATE TABLE IF NOT EXISTS beds (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  room_id uuid NOT NULL REFERENCES rooms(id) ON DELETE RESTRICT,
+  label varchar(50) NOT NULL,
+  status bed_status NOT NULL DEFAULT 'available',
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT beds_room_label_key UNIQUE (room_id, label)
+);
+
+CREATE TYPE admission_status AS ENUM ('admitted', 'discharged');
+
+-- An inpatient stay. bed_id is the bed the patient is in now; earlier beds
+-- are kept in adt_events.
+CREATE TABLE IF NOT EXISTS admissions (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE RESTRICT,
+  bed_id uuid NOT NULL REFERENCES beds(id) ON DELETE RESTRICT,
+  attending_doctor_id uuid REFERENCES doctors(user_id) ON DELETE SET NULL,
+  reason text NOT NULL,
+  status admission_status NOT NULL DEFAULT 'admitted',
+  admitted_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  discharged_at timestamp(0) with time zone,
+  CONSTRAINT admissions_discharge_check CHECK ((status = 'discharged') = (discharged_at IS NOT NULL))
+);
+
+-- A patient has one open admission and a bed holds one patient.
+CREATE UNIQUE INDEX IF NOT EXISTS admissions_active_patient_key ON admissions (patient_id)
+WHERE
+  status = 'admitted';
+
+CREATE UNIQUE INDEX IF NOT EXISTS admissions_active_bed_key ON admissions (bed_id)
+WHERE
+  status = 'admitted';
+
+CREATE INDEX IF NOT EXISTS idx_admissions_patient_id ON admissions (patient_id, admitted_at DESC);
+
+CREATE TYPE adt_event_type AS ENUM ('admit', 'transfer', 'discharge');
+
+-- Every admission, transfer and discharge, with who did it. Append only.
+CREATE TABLE IF NOT EXISTS adt_events (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  admission_id uuid NOT NULL REFERENCES admissions(id) ON DELETE RESTRICT,
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE RESTRICT,
+  type adt_event_type NOT NULL,
+  from_bed_id uuid REFERENCES beds(id) ON DELETE RESTRICT,
+  to_bed_id uuid REFERENCES beds(id) ON DELETE RESTRICT,
+  notes text NOT NULL DEFAULT '',
+  performed_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  occurred_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_adt_events_admission_id ON adt_events (admission_id, occurred_at);
+
+CREATE INDEX IF NOT EXISTS idx_adt_events_patient_id ON adt_events (patient_id, occurred_at DESC);
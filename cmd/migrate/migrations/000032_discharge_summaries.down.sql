@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS discharge_summaries;
+
+DROP TYPE IF EXISTS discharge_summary_status;
@@ -0,0 +1,29 @@
+CREATE TYPE discharge_summary_status AS ENUM ('draft', 'final');
+
+-- One summary per admission, written by the attending doctor. It can be
+-- edited while it is a draft; finalizing renders the PDF and locks it.
+CREATE TABLE IF NOT EXISTS discharge_summaries (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  admission_id uuid NOT NULL REFERENCES admissions(id) ON DELETE RESTRICT,
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE RESTRICT,
+  author_id uuid NOT NULL REFERENCES doctors(user_id) ON DELETE RESTRICT,
+  admission_reason text NOT NULL DEFAULT '',
+  hospital_course text NOT NULL DEFAULT '',
+  procedures text NOT NULL DEFAULT '',
+  -- prescription items: drug, strength, dose, frequency, duration, quantity, instructions
+  discharge_medications jsonb NOT NULL DEFAULT '[]',
+  follow_up_instructions text NOT NULL DEFAULT '',
+  status discharge_summary_status NOT NULL DEFAULT 'draft',
+  pdf_key varchar(255),
+  finalized_at timestamp(0) with time zone,
+  emailed_at timestamp(0) with time zone,
+  follow_up_appointment_id uuid REFERENCES appointment(id) ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT discharge_summaries_admission_key UNIQUE (admission_id),
+  CONSTRAINT discharge_summaries_final_check CHECK (
+    (status = 'final') = (finalized_at IS NOT NULL AND pdf_key IS NOT NULL)
+  )
+);
+
+CREATE INDEX IF NOT EXISTS idx_discharge_summaries_patient_id ON discharge_summaries (patient_id, created_at DESC);
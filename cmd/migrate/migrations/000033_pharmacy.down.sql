@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS dispensation_batches;
+
+DROP TABLE IF EXISTS dispensation_lines;
+
+DROP TABLE IF EXISTS dispensations;
+
+DROP TABLE IF EXISTS drug_batches;
+
+DROP TABLE IF EXISTS drugs;
+
+UPDATE users SET role_id = (SELECT id FROM roles WHERE name = 'patient')
+WHERE role_id = (SELECT id FROM roles WHERE name = 'pharmacist');
+
+DELETE FROM roles WHERE name = 'pharmacist';
@@ -0,0 +1,80 @@
+INSERT INTO
+  roles (name, description, level)
+VALUES
+  (
+    'pharmacist',
+    'A pharmacist receives drug stock and dispenses prescriptions',
+    2
+  ) ON CONFLICT (name) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS drugs (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name varchar(255) NOT NULL,
+  strength varchar(100) NOT NULL,
+  form varchar(50) NOT NULL,
+  unit varchar(30) NOT NULL,
+  -- stock at or below this level is reported as low
+  reorder_level int NOT NULL DEFAULT 0,
+  active boolean NOT NULL DEFAULT true,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT drugs_name_strength_form_key UNIQUE (name, strength, form),
+  CONSTRAINT drugs_reorder_level_check CHECK (reorder_level >= 0)
+);
+
+-- One row per lot received. quantity_on_hand goes down as the lot is
+-- dispensed and never below zero.
+CREATE TABLE IF NOT EXISTS drug_batches (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  drug_id uuid NOT NULL REFERENCES drugs(id) ON DELETE RESTRICT,
+  lot_number varchar(100) NOT NULL,
+  expiry_date date NOT NULL,
+  quantity_received int NOT NULL,
+  quantity_on_hand int NOT NULL,
+  supplier varchar(255) NOT NULL DEFAULT '',
+  received_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  received_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT drug_batches_lot_key UNIQUE (drug_id, lot_number),
+  CONSTRAINT drug_batches_quantity_check CHECK (
+    quantity_received > 0 AND quantity_on_hand >= 0 AND quantity_on_hand <= quantity_received
+  )
+);
+
+CREATE INDEX IF NOT EXISTS idx_drug_batches_fifo ON drug_batches (drug_id, expiry_date, received_at)
+WHERE quantity_on_hand > 0;
+
+CREATE TABLE IF NOT EXISTS dispensations (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  prescription_id uuid NOT NULL REFERENCES prescriptions(id) ON DELETE RESTRICT,
+  patient_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  dispensed_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  notes text NOT NULL DEFAULT '',
+  dispensed_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispensations_prescription_id ON dispensations (prescription_id);
+
+-- Each prescription line is dispensed once, with the catalog drug chosen
+-- for it.
+CREATE TABLE IF NOT EXISTS dispensation_lines (
+  dispensation_id uuid NOT NULL REFERENCES dispensations(id) ON DELETE CASCADE,
+  prescription_id uuid NOT NULL,
+  position int NOT NULL,
+  drug_id uuid NOT NULL REFERENCES drugs(id) ON DELETE RESTRICT,
+  quantity int NOT NULL,
+  CONSTRAINT dispensation_lines_pkey PRIMARY KEY (prescription_id, position),
+  FOREIGN KEY (prescription_id, position) REFERENCES prescription_items(prescription_id, position) ON DELETE RESTRICT,
+  CONSTRAINT dispensation_lines_quantity_check CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispensation_lines_dispensation_id ON dispensation_lines (dispensation_id);
+
+-- The batches a line was taken from, earliest expiry first.
+CREATE TABLE IF NOT EXISTS dispensation_batches (
+  prescription_id uuid NOT NULL,
+  position int NOT NULL,
+  batch_id uuid NOT NULL REFERENCES drug_batches(id) ON DELETE RESTRICT,
+  quantity int NOT NULL,
+  PRIMARY KEY (prescription_id, position, batch_id),
+  FOREIGN KEY (prescription_id, position) REFERENCES dispensation_lines(prescription_id, position) ON DELETE CASCADE,
+  CONSTRAINT dispensation_batches_quantity_check CHECK (quantity > 0)
+);
@@ -0,0 +1,19 @@
+DROP TABLE IF EXISTS payments;
+
+DROP TABLE IF EXISTS invoice_items;
+
+DROP TABLE IF EXISTS invoices;
+
+DROP SEQUENCE IF EXISTS invoice_number_seq;
+
+DROP TABLE IF EXISTS price_list;
+
+DROP TYPE IF EXISTS payment_status;
+
+DROP TYPE IF EXISTS payment_method;
+
+DROP TYPE IF EXISTS payment_kind;
+
+DROP TYPE IF EXISTS invoice_status;
+
+DROP TYPE IF EXISTS charge_kind;
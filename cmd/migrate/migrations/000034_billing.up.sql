@@ -0,0 +1,95 @@
+CREATE TYPE charge_kind AS ENUM ('consultation', 'procedure', 'lab_test', 'drug', 'other');
+
+CREATE TYPE invoice_status AS ENUM ('draft', 'issued', 'void');
+
+CREATE TYPE payment_kind AS ENUM ('payment', 'refund');
+
+CREATE TYPE payment_method AS ENUM ('cash', 'card', 'bank_transfer', 'mobile_wallet');
+
+CREATE TYPE payment_status AS ENUM ('pending', 'succeeded', 'failed');
+
+-- Prices of billable services in minor units of the billing currency. Codes
+-- are lab test codes, drug IDs and procedure codes.
+CREATE TABLE IF NOT EXISTS price_list (
+  kind charge_kind NOT NULL,
+  code varchar(100) NOT NULL,
+  description varchar(255) NOT NULL,
+  unit_price bigint NOT NULL,
+  -- tax rate in basis points, 1500 is 15%
+  tax_rate int NOT NULL DEFAULT 0,
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (kind, code),
+  CONSTRAINT price_list_amount_check CHECK (unit_price >= 0 AND tax_rate BETWEEN 0 AND 10000)
+);
+
+CREATE SEQUENCE IF NOT EXISTS invoice_number_seq;
+
+-- A patient has at most one draft invoice, which collects charges as they
+-- are incurred. Issuing it assigns a number and locks its items.
+CREATE TABLE IF NOT EXISTS invoices (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  number varchar(20) UNIQUE,
+  patient_id uuid NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+  currency char(3) NOT NULL,
+  status invoice_status NOT NULL DEFAULT 'draft',
+  issued_at timestamp(0) with time zone,
+  issued_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  voided_at timestamp(0) with time zone,
+  void_reason text,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT invoices_issued_check CHECK ((status = 'draft') = (number IS NULL AND issued_at IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS invoices_draft_patient_key ON invoices (patient_id) WHERE status = 'draft';
+
+CREATE INDEX IF NOT EXISTS idx_invoices_patient_id ON invoices (patient_id, created_at DESC);
+
+-- source_key names what was charged, such as an appointment or a
+-- dispensed prescription line, so nothing is billed twice.
+CREATE TABLE IF NOT EXISTS invoice_items (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  invoice_id uuid NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+  kind charge_kind NOT NULL,
+  code varchar(100) NOT NULL DEFAULT '',
+  description varchar(255) NOT NULL,
+  quantity int NOT NULL,
+  unit_price bigint NOT NULL,
+  discount bigint NOT NULL DEFAULT 0,
+  tax_rate int NOT NULL DEFAULT 0,
+  net bigint GENERATED ALWAYS AS (quantity * unit_price - discount) STORED,
+  tax bigint GENERATED ALWAYS AS (((quantity * unit_price - discount) * tax_rate + 5000) / 10000) STORED,
+  source_key varchar(255),
+  created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT invoice_items_source_key UNIQUE (source_key),
+  CONSTRAINT invoice_items_amount_check CHECK (
+    quantity > 0 AND unit_price >= 0 AND discount >= 0 AND discount <= quantity * unit_price
+    AND tax_rate BETWEEN 0 AND 10000
+  )
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_items_invoice_id ON invoice_items (invoice_id);
+
+-- Payments and refunds. A refund names the payment it returns money from.
+-- Pending rows are gateway calls in flight and count against the balance.
+CREATE TABLE IF NOT EXISTS payments (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  invoice_id uuid NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+  kind payment_kind NOT NULL,
+  method payment_method NOT NULL,
+  amount bigint NOT NULL,
+  status payment_status NOT NULL DEFAULT 'pending',
+  refund_of uuid REFERENCES payments(id) ON DELETE RESTRICT,
+  reason text NOT NULL DEFAULT '',
+  gateway_ref varchar(255),
+  failure text,
+  receipt_key varchar(255),
+  created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT payments_amount_check CHECK (amount > 0),
+  CONSTRAINT payments_refund_check CHECK ((kind = 'refund') = (refund_of IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_invoice_id ON payments (invoice_id);
+
+CREATE INDEX IF NOT EXISTS idx_payments_refund_of ON payments (refund_of) WHERE refund_of IS NOT NULL;
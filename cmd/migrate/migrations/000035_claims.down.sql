@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS claim_remittances;
+
+DROP TABLE IF EXISTS claim_lines;
+
+DROP TABLE IF EXISTS claims;
+
+DROP SEQUENCE IF EXISTS claim_number_seq;
+
+DROP TYPE IF EXISTS claim_status;
+
+-- enum values cannot be dropped, so the type is rebuilt without 'insurance'
+DELETE FROM payments WHERE refund_of IN (SELECT id FROM payments WHERE method = 'insurance');
+
+DELETE FROM payments WHERE method = 'insurance';
+
+ALTER TYPE payment_method RENAME TO payment_method_old;
+
+CREATE TYPE payment_method AS ENUM ('cash', 'card', 'bank_transfer', 'mobile_wallet');
+
+ALTER TABLE payments ALTER COLUMN method TYPE payment_method USING method::text::payment_method;
+
+DROP TYPE payment_method_old;
@@ -0,0 +1,68 @@
+CREATE TYPE claim_status AS ENUM ('draft', 'submitted', 'approved', 'partially_paid', 'denied');
+
+-- Payments posted from insurance remittances.
+ALTER TYPE payment_method ADD VALUE IF NOT EXISTS 'insurance';
+
+CREATE SEQUENCE IF NOT EXISTS claim_number_seq;
+
+-- A claim bills an issued invoice to the payer of one of the patient's
+-- policies. The policy is copied so the claim survives changes to it.
+CREATE TABLE IF NOT EXISTS claims (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  number varchar(20) NOT NULL UNIQUE,
+  invoice_id uuid NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+  patient_id uuid NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+  policy_id uuid REFERENCES patient_insurance_policies(id) ON DELETE SET NULL,
+  payer varchar(255) NOT NULL,
+  plan_name varchar(255) NOT NULL DEFAULT '',
+  policy_number varchar(100) NOT NULL,
+  member_id varchar(100) NOT NULL DEFAULT '',
+  group_number varchar(100) NOT NULL DEFAULT '',
+  -- ICD-10 codes, the principal diagnosis first
+  diagnosis_codes text[] NOT NULL DEFAULT '{}',
+  status claim_status NOT NULL DEFAULT 'draft',
+  submitted_at timestamp(0) with time zone,
+  adjudicated_at timestamp(0) with time zone,
+  created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+-- One claim per invoice is in progress at a time; a new claim, for example
+-- to a secondary payer, can follow once the payer has answered.
+CREATE UNIQUE INDEX IF NOT EXISTS claims_invoice_open_key ON claims (invoice_id)
+WHERE
+  status IN ('draft', 'submitted');
+
+CREATE INDEX IF NOT EXISTS idx_claims_patient_id ON claims (patient_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_claims_status ON claims (status, created_at);
+
+CREATE TABLE IF NOT EXISTS claim_lines (
+  claim_id uuid NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+  position int NOT NULL,
+  invoice_item_id uuid REFERENCES invoice_items(id) ON DELETE SET NULL,
+  kind charge_kind NOT NULL,
+  code varchar(100) NOT NULL,
+  description varchar(255) NOT NULL,
+  quantity int NOT NULL,
+  -- amount billed for the line, tax included, in minor units
+  amount bigint NOT NULL,
+  service_date DATE NOT NULL,
+  PRIMARY KEY (claim_id, position)
+);
+
+-- What the payer paid on a claim. A denial is a remittance of zero.
+CREATE TABLE IF NOT EXISTS claim_remittances (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  claim_id uuid NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+  paid_amount bigint NOT NULL,
+  reference varchar(100) NOT NULL DEFAULT '',
+  denial_reason text NOT NULL DEFAULT '',
+  payment_id uuid REFERENCES payments(id) ON DELETE SET NULL,
+  recorded_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  remitted_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT claim_remittances_amount_check CHECK (paid_amount >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_claim_remittances_claim_id ON claim_remittances (claim_id);
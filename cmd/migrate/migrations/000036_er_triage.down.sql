@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS er_visits;
+
+DROP TABLE IF EXISTS er_wait_targets;
+
+DROP TYPE IF EXISTS er_disposition;
+
+DROP TYPE IF EXISTS er_visit_status;
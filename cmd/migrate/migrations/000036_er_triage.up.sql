@@ -0,0 +1,61 @@
+CREATE TYPE er_visit_status AS ENUM ('waiting', 'in_treatment', 'departed');
+
+CREATE TYPE er_disposition AS ENUM (
+  'discharged',
+  'admitted',
+  'transferred',
+  'left_without_being_seen',
+  'deceased'
+);
+
+-- Longest acceptable wait to be seen per triage level, 1 the most urgent.
+CREATE TABLE IF NOT EXISTS er_wait_targets (
+  acuity smallint PRIMARY KEY,
+  max_wait_minutes int NOT NULL,
+  updated_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT er_wait_targets_acuity_check CHECK (acuity BETWEEN 1 AND 5),
+  CONSTRAINT er_wait_targets_minutes_check CHECK (max_wait_minutes >= 0)
+);
+
+INSERT INTO
+  er_wait_targets (acuity, max_wait_minutes)
+VALUES
+  (1, 0),
+  (2, 10),
+  (3, 30),
+  (4, 60),
+  (5, 120) ON CONFLICT (acuity) DO NOTHING;
+
+-- An emergency attendance. Arrivals who cannot be identified yet are
+-- registered by name or description only and linked to a patient later.
+CREATE TABLE IF NOT EXISTS er_visits (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  patient_id uuid REFERENCES users(id) ON DELETE RESTRICT,
+  patient_name varchar(255) NOT NULL,
+  acuity smallint NOT NULL,
+  chief_complaint text NOT NULL,
+  status er_visit_status NOT NULL DEFAULT 'waiting',
+  arrived_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  triaged_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  seen_at timestamp(0) with time zone,
+  seen_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  departed_at timestamp(0) with time zone,
+  disposition er_disposition,
+  -- set once the wait breaches the target so the alert is raised once
+  breach_alerted_at timestamp(0) with time zone,
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT er_visits_acuity_check CHECK (acuity BETWEEN 1 AND 5),
+  CONSTRAINT er_visits_departed_check CHECK ((status = 'departed') = (departed_at IS NOT NULL AND disposition IS NOT NULL))
+);
+
+-- A patient is in the department at most once at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS er_visits_active_patient_key ON er_visits (patient_id)
+WHERE
+  status <> 'departed';
+
+CREATE INDEX IF NOT EXISTS idx_er_visits_board ON er_visits (acuity, arrived_at)
+WHERE
+  status <> 'departed';
+
+CREATE INDEX IF NOT EXISTS idx_er_visits_patient_id ON er_visits (patient_id, arrived_at DESC);
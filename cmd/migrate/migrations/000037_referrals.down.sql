@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS referrals;
+
+DROP TYPE IF EXISTS referral_urgency;
+
+DROP TYPE IF EXISTS referral_status;
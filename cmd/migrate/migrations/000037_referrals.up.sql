@@ -0,0 +1,36 @@
+CREATE TYPE referral_status AS ENUM ('sent', 'accepted', 'declined', 'scheduled', 'closed');
+
+CREATE TYPE referral_urgency AS ENUM ('routine', 'urgent', 'emergency');
+
+-- A referral goes to a doctor, or to a department (a specialization) until
+-- one of its doctors accepts it. Booking from the referral links the
+-- appointment it was booked into.
+CREATE TABLE IF NOT EXISTS referrals (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE RESTRICT,
+  referring_doctor_id uuid NOT NULL REFERENCES doctors(user_id) ON DELETE RESTRICT,
+  encounter_id uuid REFERENCES encounters(id) ON DELETE SET NULL,
+  to_doctor_id uuid REFERENCES doctors(user_id) ON DELETE RESTRICT,
+  department varchar(100) NOT NULL DEFAULT '',
+  reason text NOT NULL,
+  urgency referral_urgency NOT NULL DEFAULT 'routine',
+  notes text NOT NULL DEFAULT '',
+  status referral_status NOT NULL DEFAULT 'sent',
+  response_note text NOT NULL DEFAULT '',
+  responded_at timestamp(0) with time zone,
+  appointment_id uuid REFERENCES appointment(id) ON DELETE SET NULL,
+  closed_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  closed_at timestamp(0) with time zone,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT referrals_recipient_check CHECK (to_doctor_id IS NOT NULL OR department <> ''),
+  CONSTRAINT referrals_self_check CHECK (to_doctor_id <> referring_doctor_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_patient_id ON referrals (patient_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_referring_doctor_id ON referrals (referring_doctor_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_to_doctor_id ON referrals (to_doctor_id, status);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_department ON referrals (lower(department), status) WHERE to_doctor_id IS NULL;
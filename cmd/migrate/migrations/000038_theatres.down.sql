@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS surgical_case_equipment;
+
+DROP TABLE IF EXISTS surgical_case_staff;
+
+DROP TABLE IF EXISTS surgical_cases;
+
+DROP TABLE IF EXISTS theatre_equipment;
+
+DROP TABLE IF EXISTS theatres;
+
+DROP TYPE IF EXISTS surgical_team_role;
+
+DROP TYPE IF EXISTS surgical_case_status;
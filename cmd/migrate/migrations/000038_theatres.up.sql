@@ -0,0 +1,62 @@
+CREATE TYPE surgical_case_status AS ENUM ('scheduled', 'in_progress', 'completed', 'cancelled');
+
+CREATE TYPE surgical_team_role AS ENUM ('surgeon', 'anesthetist', 'nurse');
+
+CREATE TABLE IF NOT EXISTS theatres (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name varchar(100) NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT theatres_name_key UNIQUE (name)
+);
+
+-- Each row is one unit, such as "C-arm 2", booked by one case at a time.
+CREATE TABLE IF NOT EXISTS theatre_equipment (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name varchar(100) NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT theatre_equipment_name_key UNIQUE (name)
+);
+
+-- scheduled_end is scheduled_start plus the expected duration. Overlaps on
+-- the theatre, the team and the equipment are checked by the API under a
+-- single lock, since they span three tables.
+CREATE TABLE IF NOT EXISTS surgical_cases (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  theatre_id uuid NOT NULL REFERENCES theatres(id) ON DELETE RESTRICT,
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE RESTRICT,
+  procedure text NOT NULL,
+  expected_minutes int NOT NULL CHECK (expected_minutes > 0),
+  scheduled_start timestamp(0) with time zone NOT NULL,
+  scheduled_end timestamp(0) with time zone NOT NULL,
+  status surgical_case_status NOT NULL DEFAULT 'scheduled',
+  notes text NOT NULL DEFAULT '',
+  started_at timestamp(0) with time zone,
+  completed_at timestamp(0) with time zone,
+  cancelled_at timestamp(0) with time zone,
+  cancel_reason text NOT NULL DEFAULT '',
+  booked_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT surgical_cases_window_check CHECK (scheduled_end > scheduled_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_surgical_cases_theatre_id ON surgical_cases (theatre_id, scheduled_start);
+
+CREATE INDEX IF NOT EXISTS idx_surgical_cases_patient_id ON surgical_cases (patient_id, scheduled_start DESC);
+
+CREATE TABLE IF NOT EXISTS surgical_case_staff (
+  case_id uuid NOT NULL REFERENCES surgical_cases(id) ON DELETE CASCADE,
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+  role surgical_team_role NOT NULL,
+  PRIMARY KEY (case_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_surgical_case_staff_user_id ON surgical_case_staff (user_id);
+
+CREATE TABLE IF NOT EXISTS surgical_case_equipment (
+  case_id uuid NOT NULL REFERENCES surgical_cases(id) ON DELETE CASCADE,
+  equipment_id uuid NOT NULL REFERENCES theatre_equipment(id) ON DELETE RESTRICT,
+  PRIMARY KEY (case_id, equipment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_surgical_case_equipment_equipment_id ON surgical_case_equipment (equipment_id);
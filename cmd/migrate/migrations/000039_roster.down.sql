@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS shift_swaps;
+
+DROP TABLE IF EXISTS roster_shifts;
+
+DROP TABLE IF EXISTS shift_templates;
+
+DROP TYPE IF EXISTS shift_swap_status;
@@ -0,0 +1,58 @@
+CREATE TYPE shift_swap_status AS ENUM ('pending', 'accepted', 'approved', 'declined', 'rejected', 'cancelled');
+
+-- Times are UTC wall-clock times. A shift ending at or before its start
+-- runs overnight into the next day. role limits who can work the shift and
+-- department is what the on-call lookup matches.
+CREATE TABLE IF NOT EXISTS shift_templates (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name varchar(100) NOT NULL,
+  role varchar(255) REFERENCES roles(name) ON DELETE RESTRICT,
+  department varchar(100) NOT NULL DEFAULT '',
+  starts_at time NOT NULL,
+  ends_at time NOT NULL,
+  on_call boolean NOT NULL DEFAULT false,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT shift_templates_name_key UNIQUE (name),
+  CONSTRAINT shift_templates_on_call_check CHECK (NOT on_call OR department <> '')
+);
+
+-- A rostered shift copies its window from the template, so editing or
+-- adding templates never moves shifts already worked or published.
+CREATE TABLE IF NOT EXISTS roster_shifts (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  template_id uuid NOT NULL REFERENCES shift_templates(id) ON DELETE RESTRICT,
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  shift_date date NOT NULL,
+  starts_at timestamp(0) with time zone NOT NULL,
+  ends_at timestamp(0) with time zone NOT NULL,
+  created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT roster_shifts_user_template_date_key UNIQUE (user_id, template_id, shift_date),
+  CONSTRAINT roster_shifts_window_check CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_roster_shifts_user_id ON roster_shifts (user_id, starts_at);
+
+CREATE INDEX IF NOT EXISTS idx_roster_shifts_starts_at ON roster_shifts (starts_at);
+
+-- The colleague accepts a swap before a manager approves it. Without a
+-- counterpart shift the colleague simply covers the shift.
+CREATE TABLE IF NOT EXISTS shift_swaps (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  shift_id uuid NOT NULL REFERENCES roster_shifts(id) ON DELETE CASCADE,
+  requested_by uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  with_user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  counterpart_shift_id uuid REFERENCES roster_shifts(id) ON DELETE CASCADE,
+  reason text NOT NULL DEFAULT '',
+  status shift_swap_status NOT NULL DEFAULT 'pending',
+  responded_at timestamp(0) with time zone,
+  decided_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  decided_at timestamp(0) with time zone,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT shift_swaps_self_check CHECK (with_user_id <> requested_by)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS shift_swaps_open_shift_key ON shift_swaps (shift_id) WHERE status IN ('pending', 'accepted');
+
+CREATE INDEX IF NOT EXISTS idx_shift_swaps_with_user_id ON shift_swaps (with_user_id, status);
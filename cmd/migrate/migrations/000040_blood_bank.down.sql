@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS blood_issues;
+
+DROP TABLE IF EXISTS crossmatch_tests;
+
+DROP TABLE IF EXISTS blood_units;
+
+DROP TABLE IF EXISTS crossmatch_requests;
+
+DROP TABLE IF EXISTS blood_donations;
+
+DROP TABLE IF EXISTS blood_donors;
+
+DROP TYPE IF EXISTS crossmatch_result;
+
+DROP TYPE IF EXISTS crossmatch_urgency;
+
+DROP TYPE IF EXISTS crossmatch_status;
+
+DROP TYPE IF EXISTS blood_unit_status;
+
+DROP TYPE IF EXISTS blood_component;
@@ -0,0 +1,127 @@
+CREATE TYPE blood_component AS ENUM ('whole_blood', 'red_cells', 'platelets', 'plasma', 'cryoprecipitate');
+
+CREATE TYPE blood_unit_status AS ENUM ('available', 'reserved', 'issued', 'discarded');
+
+CREATE TYPE crossmatch_status AS ENUM ('pending', 'ready', 'closed');
+
+CREATE TYPE crossmatch_urgency AS ENUM ('routine', 'urgent', 'emergency');
+
+CREATE TYPE crossmatch_result AS ENUM ('compatible', 'incompatible');
+
+CREATE TABLE IF NOT EXISTS blood_donors (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name varchar(255) NOT NULL,
+  blood_group blood_group NOT NULL,
+  date_of_birth date NOT NULL,
+  phone varchar(30) NOT NULL DEFAULT '',
+  email varchar(255) NOT NULL DEFAULT '',
+  -- a deferred donor may not donate before this day
+  deferred_until date,
+  deferral_reason text NOT NULL DEFAULT '',
+  notes text NOT NULL DEFAULT '',
+  created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_blood_donors_name ON blood_donors (lower(name));
+
+CREATE TABLE IF NOT EXISTS blood_donations (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  donor_id uuid NOT NULL REFERENCES blood_donors(id) ON DELETE RESTRICT,
+  volume_ml int NOT NULL,
+  collected_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  notes text NOT NULL DEFAULT '',
+  donated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT blood_donations_volume_check CHECK (volume_ml > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_blood_donations_donor_id ON blood_donations (donor_id, donated_at DESC);
+
+-- A request for blood for a patient. The lab crossmatches units against
+-- it, reserving the compatible ones until they are issued or the request
+-- is closed.
+CREATE TABLE IF NOT EXISTS crossmatch_requests (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE RESTRICT,
+  -- the patient's group when the request was made
+  blood_group blood_group NOT NULL,
+  component blood_component NOT NULL,
+  units_requested int NOT NULL,
+  urgency crossmatch_urgency NOT NULL DEFAULT 'routine',
+  indication text NOT NULL,
+  required_by timestamp(0) with time zone,
+  requested_by uuid NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+  status crossmatch_status NOT NULL DEFAULT 'pending',
+  closed_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  closed_at timestamp(0) with time zone,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT crossmatch_requests_units_check CHECK (units_requested BETWEEN 1 AND 20)
+);
+
+CREATE INDEX IF NOT EXISTS idx_crossmatch_requests_patient_id ON crossmatch_requests (patient_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_crossmatch_requests_open ON crossmatch_requests (urgency, created_at) WHERE status <> 'closed';
+
+-- One row per bag, from a donation here or received from another bank. A
+-- unit can be used up to and including expires_on.
+CREATE TABLE IF NOT EXISTS blood_units (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  unit_number varchar(50) NOT NULL,
+  donation_id uuid REFERENCES blood_donations(id) ON DELETE RESTRICT,
+  source varchar(255) NOT NULL DEFAULT '',
+  component blood_component NOT NULL,
+  blood_group blood_group NOT NULL,
+  volume_ml int NOT NULL,
+  collected_on date NOT NULL,
+  expires_on date NOT NULL,
+  status blood_unit_status NOT NULL DEFAULT 'available',
+  reserved_for uuid REFERENCES crossmatch_requests(id) ON DELETE RESTRICT,
+  discard_reason text NOT NULL DEFAULT '',
+  received_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT blood_units_unit_number_key UNIQUE (unit_number),
+  CONSTRAINT blood_units_volume_check CHECK (volume_ml > 0),
+  CONSTRAINT blood_units_expiry_check CHECK (expires_on > collected_on),
+  CONSTRAINT blood_units_reserved_check CHECK ((status = 'reserved') = (reserved_for IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_blood_units_stock ON blood_units (component, blood_group, expires_on)
+WHERE status = 'available';
+
+CREATE INDEX IF NOT EXISTS idx_blood_units_reserved_for ON blood_units (reserved_for)
+WHERE reserved_for IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS crossmatch_tests (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  request_id uuid NOT NULL REFERENCES crossmatch_requests(id) ON DELETE CASCADE,
+  unit_id uuid NOT NULL REFERENCES blood_units(id) ON DELETE RESTRICT,
+  result crossmatch_result NOT NULL,
+  notes text NOT NULL DEFAULT '',
+  tested_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  tested_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  CONSTRAINT crossmatch_tests_request_unit_key UNIQUE (request_id, unit_id)
+);
+
+-- A unit handed out for a patient. A returned unit goes back into stock or
+-- is discarded.
+CREATE TABLE IF NOT EXISTS blood_issues (
+  id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+  unit_id uuid NOT NULL REFERENCES blood_units(id) ON DELETE RESTRICT,
+  request_id uuid NOT NULL REFERENCES crossmatch_requests(id) ON DELETE RESTRICT,
+  patient_id uuid NOT NULL REFERENCES patients(user_id) ON DELETE RESTRICT,
+  issued_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  -- who collected the unit from the bank
+  issued_to varchar(255) NOT NULL,
+  issued_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  returned_at timestamp(0) with time zone,
+  returned_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  reusable boolean,
+  return_reason text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_blood_issues_request_id ON blood_issues (request_id, issued_at);
+
+CREATE UNIQUE INDEX IF NOT EXISTS blood_issues_open_unit_key ON blood_issues (unit_id)
+WHERE returned_at IS NULL;
@@ -24,34 +24,65 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/appointments": {
+        "/admissions": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieves all appointments, showing patient and doctor names with appointment times",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists admissions, newest first, optionally only open or discharged ones in a ward",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "appointment"
+                    "adt"
+                ],
+                "summary": "Lists admissions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "admitted or discharged",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ward ID",
+                        "name": "ward_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all appointments with patient and doctor info",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/store.Appointment"
+                                "$ref": "#/definitions/store.Admission"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -64,7 +95,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new appointment",
+                "description": "Admits a patient given by ID or MRN to an available bed, which becomes occupied",
                 "consumes": [
                     "application/json"
                 ],
@@ -72,17 +103,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "appointment"
+                    "adt"
                 ],
-                "summary": "Create new appointment",
+                "summary": "Admits a patient",
                 "parameters": [
                     {
-                        "description": "Appointment Details",
+                        "description": "Admission",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.CreateAppointmentPayload"
+                            "$ref": "#/definitions/main.AdmitPatientPayload"
                         }
                     }
                 ],
@@ -90,13 +121,25 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/store.Appointment"
+                            "$ref": "#/definitions/store.Admission"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -104,43 +147,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/authentication/token": {
-            "post": {
-                "description": "Creates a token for a user",
-                "consumes": [
-                    "application/json"
+        "/admissions/{admissionID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "authentication"
+                    "adt"
                 ],
-                "summary": "Creates a token",
+                "summary": "Fetches an admission",
                 "parameters": [
                     {
-                        "description": "User credentials",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.CreateUserTokenPayload"
-                        }
+                        "type": "string",
+                        "description": "Admission ID",
+                        "name": "admissionID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Token",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/store.Admission"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {}
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {}
                     },
                     "500": {
@@ -150,9 +192,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/authentication/user": {
+        "/admissions/{admissionID}/discharge": {
             "post": {
-                "description": "Registers a user",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ends an admission. The bed is marked for cleaning.",
                 "consumes": [
                     "application/json"
                 ],
@@ -160,31 +207,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "authentication"
+                    "adt"
                 ],
-                "summary": "Registers a user",
+                "summary": "Discharges a patient",
                 "parameters": [
                     {
-                        "description": "User credentials",
+                        "type": "string",
+                        "description": "Admission ID",
+                        "name": "admissionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Discharge notes",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.RegisterUserPayload"
+                            "$ref": "#/definitions/main.DischargePatientPayload"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User registered",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.UserWithToken"
+                            "$ref": "#/definitions/store.Admission"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -192,54 +258,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/doctors": {
-            "get": {
+        "/admissions/{admissionID}/discharge-summary": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Fetches all doctors",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Creates a draft discharge summary for an admission, pre-filled with the assessments and plan of the signed encounters and the prescriptions issued during the stay. Only the attending doctor can write it; if the admission has none, any doctor can.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "doctor"
+                    "discharge-summary"
+                ],
+                "summary": "Starts a discharge summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Admission ID",
+                        "name": "admissionID",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Fetches All doctors",
                 "responses": {
-                    "204": {
-                        "description": "Doctors Found",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/store.Doctor"
-                                }
-                            }
+                            "$ref": "#/definitions/store.DischargeSummary"
                         }
                     },
-                    "400": {
-                        "description": "Doctor Id  missing",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {}
                     },
                     "404": {
-                        "description": "Dctor not found",
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {}
                     }
                 }
-            },
+            }
+        },
+        "/admissions/{admissionID}/transfer": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new doctor user and doctor profile",
+                "description": "Moves an admitted patient to an available bed. The bed they leave is marked for cleaning.",
                 "consumes": [
                     "application/json"
                 ],
@@ -247,31 +323,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "doctor"
+                    "adt"
                 ],
-                "summary": "Creates a doctor account",
+                "summary": "Transfers a patient to another bed",
                 "parameters": [
                     {
-                        "description": "Doctor user and profile information",
+                        "type": "string",
+                        "description": "Admission ID",
+                        "name": "admissionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New bed",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.CreateDoctorPayload"
+                            "$ref": "#/definitions/main.TransferPatientPayload"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/store.Doctor"
+                            "$ref": "#/definitions/store.Admission"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -279,14 +374,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/doctors/availability": {
+        "/appointments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieves all appointments, showing patient and doctor names with appointment times. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "appointment"
+                ],
+                "summary": "Get all appointments with patient and doctor info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Appointment"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates an availability entry for a doctor",
+                "description": "Creates a new appointment for a patient given by ID or MRN. The doctor's fee in effect for the visit is copied onto the appointment. Staff only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -294,17 +426,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "doctor"
+                    "appointment"
                 ],
-                "summary": "Creates a new availability entry",
+                "summary": "Create new appointment",
                 "parameters": [
                     {
-                        "description": "Availability details",
+                        "description": "Appointment Details",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.CreateAvailabilityPayload"
+                            "$ref": "#/definitions/main.CreateAppointmentPayload"
                         }
                     }
                 ],
@@ -312,13 +444,17 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/store.Availability"
+                            "$ref": "#/definitions/store.Appointment"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -326,106 +462,298 @@ const docTemplate = `{
                 }
             }
         },
-        "/doctors/{doctorID}": {
-            "get": {
+        "/appointments/{appointmentID}/cancel": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Fetches the doctor by id",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Cancels a scheduled appointment. Available to the patient, their guardians and staff.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "doctor"
+                    "appointment"
                 ],
-                "summary": "Fetches the doctor by id",
+                "summary": "Cancels an appointment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Doctor ID",
-                        "name": "doctorID",
+                        "description": "Appointment ID",
+                        "name": "appointmentID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Doctor Found",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/store.Appointment"
                         }
                     },
-                    "400": {
-                        "description": "Doctor Id  missing",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {}
                     },
                     "404": {
-                        "description": "Dctor not found",
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {}
                     }
                 }
             }
         },
-        "/health": {
-            "get": {
-                "description": "Healthcheck endpoint",
+        "/appointments/{appointmentID}/complete": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Marks a scheduled appointment as completed and opens a draft encounter for its SOAP note. Only the appointment's doctor can complete it, from the day of the appointment.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "ops"
+                    "appointment"
                 ],
-                "summary": "Healthcheck",
-                "responses": {
-                    "200": {
-                        "description": "ok",
-                        "schema": {
-                            "type": "string"
-                        }
+                "summary": "Completes an appointment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Appointment ID",
+                        "name": "appointmentID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Encounter"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
                     }
                 }
             }
         },
-        "/users/activate/{token}": {
+        "/authentication/token": {
+            "post": {
+                "description": "Creates a token for a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authentication"
+                ],
+                "summary": "Creates a token",
+                "parameters": [
+                    {
+                        "description": "User credentials",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateUserTokenPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/authentication/user": {
+            "post": {
+                "description": "Registers a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authentication"
+                ],
+                "summary": "Registers a user",
+                "parameters": [
+                    {
+                        "description": "User credentials",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.RegisterUserPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User registered",
+                        "schema": {
+                            "$ref": "#/definitions/main.UserWithToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/beds/board": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists wards, rooms and beds with their status and current patient, with bed counts by status per ward and overall",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "adt"
+                ],
+                "summary": "Fetches the bed board",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only this ward",
+                        "name": "ward_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.BedBoard"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/beds/{bedID}/status": {
             "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Activates/Register a user by invitation token",
+                "description": "Marks a free bed available, cleaning or out of service. Beds become occupied and free only through admissions, transfers and discharges.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "adt"
                 ],
-                "summary": "Activates/Register a user",
+                "summary": "Changes a bed's status",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Invitation token",
-                        "name": "token",
+                        "description": "Bed ID",
+                        "name": "bedID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Status",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetBedStatusPayload"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "User activated",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/store.Bed"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {}
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -433,31 +761,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/patients": {
+        "/billing/prices": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns a list of users with role_id = 1 (patients)",
+                "description": "Lists the price list, optionally of one kind",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "billing"
+                ],
+                "summary": "Lists the price list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "procedure, lab_test or drug",
+                        "name": "kind",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all patients",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/store.UserMinimal"
+                                "$ref": "#/definitions/store.Price"
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {}
@@ -465,14 +805,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
-            "get": {
+        "/billing/prices/{kind}/{code}": {
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Fetches a user profile by ID",
+                "description": "Creates or replaces a price list entry. Amounts are in minor units of the billing currency. Lab tests are priced by test code and drugs by drug ID; consultations are priced by the doctor's fee instead.",
                 "consumes": [
                     "application/json"
                 ],
@@ -480,29 +820,106 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "billing"
                 ],
-                "summary": "Fetches a user profile",
+                "summary": "Sets a price",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "procedure, lab_test or drug",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Code",
+                        "name": "code",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Price",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetPricePayload"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/store.User"
+                            "$ref": "#/definitions/store.Price"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/availability": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Counts the available unexpired units each component a recipient may receive, by donor group, their own group first. The recipient is a blood group, such as AB- (encode + as %2B), or a patient with a recorded blood group. Red cells follow ABO and RhD compatibility, whole blood must also be of the same ABO group, plasma and cryoprecipitate must carry no antibody against the recipient, and platelets follow RhD only. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Finds blood compatible with a recipient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipient blood group",
+                        "name": "blood_group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recipient patient",
+                        "name": "patient_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only this component",
+                        "name": "component",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.BloodAvailability"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {}
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {}
@@ -513,296 +930,15782 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
+        },
+        "/blood-bank/crossmatches": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "The blood bank's worklist: open requests by urgency and then the oldest first, closed ones after them newest first. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Lists blood requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, ready or closed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only pending and ready requests",
+                        "name": "open",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.CrossmatchRequest"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/crossmatches/{requestID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Fetches a blood request with its tests, reserved units and issues",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.CrossmatchRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/crossmatches/{requestID}/close": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Closes a request once it is met or no longer needed, putting the units still reserved for it back into stock. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Closes a blood request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.CrossmatchRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/crossmatches/{requestID}/issues": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Hands out a unit reserved for the request. Lab only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Issues a blood unit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Issue",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.IssueBloodUnitPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.BloodIssue"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/crossmatches/{requestID}/tests": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Records the result of crossmatching an available unit of the requested component and a group the patient may receive. A compatible unit is reserved for the request until it is issued or the request is closed. Lab only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Records a crossmatch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Result",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.RecordCrossmatchPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.CrossmatchRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/donors": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists donors by name, optionally those whose name starts with search or whose phone number is search. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Lists blood donors",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name prefix or phone number",
+                        "name": "search",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.BloodDonor"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Registers a blood donor",
+                "parameters": [
+                    {
+                        "description": "Donor",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateBloodDonorPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.BloodDonor"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/donors/{donorID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Fetches a blood donor with their donations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Donor ID",
+                        "name": "donorID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.BloodDonor"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/donors/{donorID}/deferral": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Keeps a donor from donating before a day, or lifts their deferral when deferred_until is null. Lab only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Defers a blood donor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Donor ID",
+                        "name": "donorID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Deferral",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.DeferBloodDonorPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.BloodDonor"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/donors/{donorID}/donations": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Records a donation and the units separated from it, which take the donor's blood group. Whole blood, red cells and platelets keep 35, 42 and 5 days and plasma and cryoprecipitate a year unless expires_on is given. Donors must be 18 to 65, not deferred, and donations 56 days apart. Lab only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Records a blood donation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Donor ID",
+                        "name": "donorID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Donation",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.RecordDonationPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.BloodDonation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/issues/{issueID}/return": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Records an issued unit coming back unused. A reusable unit that has not expired goes back into stock, any other is discarded. Lab only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Returns an issued blood unit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Issue ID",
+                        "name": "issueID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Return",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ReturnBloodUnitPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.BloodIssue"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/units": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists units, the soonest to expire first. compatible_with, with a component, keeps the units of the groups a recipient of that group may receive. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Lists blood units",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "available, reserved, issued or discarded",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "whole_blood, red_cells, platelets, plasma or cryoprecipitate",
+                        "name": "component",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit blood group",
+                        "name": "blood_group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recipient blood group",
+                        "name": "compatible_with",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Units expiring within this many days, expired ones included",
+                        "name": "expiring_within_days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.BloodUnit"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Receives a blood unit from another bank",
+                "parameters": [
+                    {
+                        "description": "Unit",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddBloodUnitPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.BloodUnit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/units/{unitID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Fetches a blood unit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unit ID",
+                        "name": "unitID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.BloodUnit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/blood-bank/units/{unitID}/discard": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Takes an available or reserved unit out of stock, for example when expired or damaged. Lab only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Discards a blood unit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Unit ID",
+                        "name": "unitID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.DiscardBloodUnitPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.BloodUnit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/claims": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists claims oldest first, optionally by status and payer. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "claims"
+                ],
+                "summary": "Lists claims",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "draft, submitted, approved, partially_paid or denied",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payer",
+                        "name": "payer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Claim"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/claims/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Downloads claims as a file for the payer in the format set by CLAIM_FILE_FORMAT. Exports submitted claims unless another status is given, up to 500 per file. Staff only.",
+                "produces": [
+                    "text/csv",
+                    "application/json"
+                ],
+                "tags": [
+                    "claims"
+                ],
+                "summary": "Exports a claim file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Status, submitted by default",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payer",
+                        "name": "payer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/claims/{claimID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches a claim with its lines and remittances",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "claims"
+                ],
+                "summary": "Fetches a claim",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Claim ID",
+                        "name": "claimID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Claim"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/claims/{claimID}/diagnoses": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the ICD-10 codes of a draft claim, the principal diagnosis first. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "claims"
+                ],
+                "summary": "Sets the diagnoses of a claim",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Claim ID",
+                        "name": "claimID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Diagnosis codes",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetClaimDiagnosesPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Claim"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/claims/{claimID}/remittances": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Records what the payer paid on a submitted or partially paid claim and posts it to the invoice as an insurance payment. The claim is approved once paid in full, partially paid while short, and denied when nothing was paid. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "claims"
+                ],
+                "summary": "Records a payer remittance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Claim ID",
+                        "name": "claimID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Remittance",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.RemitClaimPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Claim"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/claims/{claimID}/submit": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Marks a draft claim as sent to the payer; it is then included in claim file exports. A claim needs at least one diagnosis code. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "claims"
+                ],
+                "summary": "Submits a claim",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Claim ID",
+                        "name": "claimID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Claim"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/codes/icd10": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Typeahead search over ICD-10 codes by code prefix or description",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "codes"
+                ],
+                "summary": "Searches ICD-10 codes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code prefix or description text, at least 2 characters",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum results, 1-50 (default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.ICD10Code"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/dependents": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the patients the caller is a guardian of",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "guardian"
+                ],
+                "summary": "Lists the caller's dependents",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Patient"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a patient profile without a login of its own, managed by the caller. Staff may name another guardian.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "guardian"
+                ],
+                "summary": "Registers a dependent",
+                "parameters": [
+                    {
+                        "description": "Dependent profile",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateDependentPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Patient"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/discharge-summaries/{summaryID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Patients and their guardians can read final summaries; staff can also read drafts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "discharge-summary"
+                ],
+                "summary": "Fetches a discharge summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Discharge summary ID",
+                        "name": "summaryID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.DischargeSummary"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the content of a draft summary. Only its author can edit it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "discharge-summary"
+                ],
+                "summary": "Edits a discharge summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Discharge summary ID",
+                        "name": "summaryID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Summary",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UpdateDischargeSummaryPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.DischargeSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/discharge-summaries/{summaryID}/finalize": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Locks the summary once the patient has been discharged, renders it as a PDF and emails it to the patient, or to their guardians for a dependent. Only the author can finalize it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "discharge-summary"
+                ],
+                "summary": "Finalizes a discharge summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Discharge summary ID",
+                        "name": "summaryID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.DischargeSummary"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/discharge-summaries/{summaryID}/follow-up": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Books a follow-up appointment from a final discharge summary, with the author unless another doctor is given. Available to the patient, their guardians and staff. Only one follow-up can be scheduled at a time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "discharge-summary"
+                ],
+                "summary": "Books the follow-up visit of a discharge",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Discharge summary ID",
+                        "name": "summaryID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Appointment",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.BookFollowUpPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Appointment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/discharge-summaries/{summaryID}/pdf": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Downloads the PDF rendered when the summary was finalized",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "discharge-summary"
+                ],
+                "summary": "Downloads a discharge summary PDF",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Discharge summary ID",
+                        "name": "summaryID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/doctors": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches all doctors",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Fetches All doctors",
+                "responses": {
+                    "204": {
+                        "description": "Doctors Found",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/store.Doctor"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Doctor Id  missing",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Dctor not found",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new doctor user and doctor profile",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Creates a doctor account",
+                "parameters": [
+                    {
+                        "description": "Doctor user and profile information",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateDoctorPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Doctor"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/doctors/{doctorID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches the doctor by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Fetches the doctor by id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Doctor Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Doctor Id  missing",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Dctor not found",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/doctors/{doctorID}/availability": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the weekly slots, each with the date it next falls on (UTC, today included) and the fees for every visit type and consultation mode in effect on that date.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Lists a doctor's weekly outpatient slots",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.AvailabilitySlot"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a weekly outpatient slot for a doctor, times in UTC. Only the doctor or an admin may add one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Creates a new availability entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Availability details",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateAvailabilityPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Availability"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/doctors/{doctorID}/availability/conflicts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists each date from from to to (UTC, the next four weeks by default, at most 92 days) on which one of the doctor's weekly slots overlaps a rostered shift, night duty running over from the day before included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Checks a doctor's outpatient slots against their roster",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.AvailabilityConflict"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/doctors/{doctorID}/documents": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the credential documents uploaded for a doctor",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Lists a doctor's credential documents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.DoctorDocument"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Uploads a license, degree, certificate or other credential. PDF, JPEG and PNG are accepted.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Uploads a doctor credential document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "license, degree, certificate or other",
+                        "name": "kind",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Document",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.DoctorDocument"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {}
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/doctors/{doctorID}/documents/{documentID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Downloads the file of a credential document",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Downloads a doctor credential document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "documentID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a credential document and its file",
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Deletes a doctor credential document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "documentID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Document deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/doctors/{doctorID}/fees": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches every fee version of a doctor, newest first per visit type and consultation mode",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Fetches a doctor's fee history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.DoctorFee"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a new fee version for a visit type and consultation mode. Earlier versions are kept as history.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Adds a doctor fee",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fee details, amount in minor currency units",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateDoctorFeePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.DoctorFee"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/doctors/{doctorID}/fees/effective": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches the fee that applies to a visit of the given type and mode on a date (today by default)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Fetches the fee for a visit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "new_patient (default) or follow_up",
+                        "name": "visit_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "in_person (default) or online",
+                        "name": "consultation_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Visit date, YYYY-MM-DD",
+                        "name": "date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.DoctorFee"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/doctors/{doctorID}/photo": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the doctor's profile photo and generates a thumbnail. JPEG, PNG, GIF and WebP are accepted.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "doctor"
+                ],
+                "summary": "Uploads a doctor's profile photo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Doctor ID",
+                        "name": "doctorID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Profile photo",
+                        "name": "photo",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Doctor"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {}
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/encounters/{encounterID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches an encounter with its SOAP note and addenda",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "encounter"
+                ],
+                "summary": "Fetches an encounter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Encounter ID",
+                        "name": "encounterID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Encounter"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the SOAP note of a draft encounter. Only the encounter's doctor can edit it, and only until it is signed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "encounter"
+                ],
+                "summary": "Updates an encounter's SOAP note",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Encounter ID",
+                        "name": "encounterID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "SOAP note",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UpdateEncounterNotePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Encounter"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/encounters/{encounterID}/addenda": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Appends a correction to a signed encounter. Addenda cannot be edited or removed. Only the patient's treating doctors can add them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "encounter"
+                ],
+                "summary": "Adds an addendum to an encounter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Encounter ID",
+                        "name": "encounterID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Addendum",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateEncounterAddendumPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.EncounterAddendum"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/encounters/{encounterID}/diagnoses": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the primary and secondary ICD-10 diagnoses of a draft encounter. Only the encounter's doctor can set them, and only until it is signed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "encounter"
+                ],
+                "summary": "Sets an encounter's diagnoses",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Encounter ID",
+                        "name": "encounterID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Diagnoses",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetEncounterDiagnosesPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Encounter"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/encounters/{encounterID}/lab-orders": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Orders tests from the catalog for the patient of an encounter. Only the encounter's doctor can order.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab"
+                ],
+                "summary": "Orders lab tests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Encounter ID",
+                        "name": "encounterID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Test codes",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateLabOrderPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.LabOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/encounters/{encounterID}/prescriptions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "prescription"
+                ],
+                "summary": "Lists an encounter's prescriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Encounter ID",
+                        "name": "encounterID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Prescription"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Issues a prescription for an encounter and renders it as a signed PDF with the hospital letterhead and the doctor's license number. Issued prescriptions cannot be changed, only cancelled.\nThe drugs are checked against the patient's allergies and current medication. If there are warnings the request fails with 409 and the warnings; resend it with every warning code in acknowledged_warnings and an override_reason to issue the prescription anyway. Overrides are recorded with the prescription.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "prescription"
+                ],
+                "summary": "Issues a prescription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Encounter ID",
+                        "name": "encounterID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Medication lines",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreatePrescriptionPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Prescription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/main.PrescriptionWarnings"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/encounters/{encounterID}/prescriptions/check": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Checks drugs against the patient's allergies and current medication without issuing anything, so the warnings can be shown while the prescription is written",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "prescription"
+                ],
+                "summary": "Checks a prescription for warnings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Encounter ID",
+                        "name": "encounterID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Medication lines",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CheckPrescriptionPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.PrescriptionWarnings"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/encounters/{encounterID}/sign": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Signs the encounter's SOAP note. Signed notes are locked; corrections are made with addenda.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "encounter"
+                ],
+                "summary": "Signs an encounter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Encounter ID",
+                        "name": "encounterID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Encounter"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/er/alerts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the patients still waiting past the target wait for their acuity, most urgent first. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "er"
+                ],
+                "summary": "Lists emergency wait breaches",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.ERVisit"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/er/board": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the patients waiting and in treatment, each ordered by acuity and then by arrival, with their wait and whether it is over the target for their level. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "er"
+                ],
+                "summary": "Shows the emergency department board",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ERBoard"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/er/board/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Server-sent events for the ER screen. A \"board\" event carries the whole board, sent on connect, on every change and every 15 seconds. An \"alert\" event carries a visit that just went over its target wait. The stream ends after about a minute and asks the screen to reconnect. Staff only.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "er"
+                ],
+                "summary": "Streams the emergency department board",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ERBoard"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/er/visits": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds an arrival to the emergency department queue with a triage acuity from 1, resuscitation, to 5, non-urgent. The patient is given by ID or MRN, or only by name when not yet identified. Nurses only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "er"
+                ],
+                "summary": "Registers an emergency arrival",
+                "parameters": [
+                    {
+                        "description": "Arrival",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.RegisterERArrivalPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.ERVisit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/er/visits/{visitID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "er"
+                ],
+                "summary": "Fetches an emergency visit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Visit ID",
+                        "name": "visitID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ERVisit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Changes the acuity or chief complaint of a patient still in the department, or links an unidentified arrival to a patient by ID or MRN. A new acuity is held to its own target wait. Nurses only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "er"
+                ],
+                "summary": "Re-triages an emergency visit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Visit ID",
+                        "name": "visitID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Triage",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.RetriageERVisitPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ERVisit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/er/visits/{visitID}/depart": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Takes the patient off the board with how they left the department. Doctors and nurses only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "er"
+                ],
+                "summary": "Records an emergency departure",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Visit ID",
+                        "name": "visitID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Disposition",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.DepartERVisitPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ERVisit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/er/visits/{visitID}/start": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Moves a waiting patient to treatment, which stops their wait. Doctors and nurses only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "er"
+                ],
+                "summary": "Starts treating an emergency patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Visit ID",
+                        "name": "visitID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ERVisit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/er/wait-targets": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "er"
+                ],
+                "summary": "Lists the emergency wait targets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.ERWaitTarget"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/er/wait-targets/{acuity}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sets the longest a patient at an acuity level may wait to be seen before an alert is raised. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "er"
+                ],
+                "summary": "Sets an emergency wait target",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Acuity, 1 to 5",
+                        "name": "acuity",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Target",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetERWaitTargetPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ERWaitTarget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/files/{key}": {
+            "get": {
+                "description": "Serves files from the public area of blob storage, such as doctor photos",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "Serves a public file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Blob key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Healthcheck endpoint",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ops"
+                ],
+                "summary": "Healthcheck",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/immunization-schedule": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "immunizations"
+                ],
+                "summary": "Lists the immunization schedule",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.ScheduledDose"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/immunization-schedule/{vaccine}/{dose}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates or replaces a scheduled dose. Ages and intervals are in days; catch_up_until_days is the age after which the dose is no longer offered. Set active to false to take a dose off the schedule. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "immunizations"
+                ],
+                "summary": "Sets a dose of the immunization schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vaccine code",
+                        "name": "vaccine",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Dose number",
+                        "name": "dose",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dose",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ScheduledDosePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ScheduledDose"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/immunizations/reminders": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Emails patients with overdue vaccinations now instead of waiting for the next scheduled run. A dose is not reminded about again until IMMUNIZATION_REMINDER_RESEND_DAYS have passed. Admin only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "immunizations"
+                ],
+                "summary": "Sends immunization reminders",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ImmunizationReminderRun"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/invoices/{invoiceID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches an invoice with its items and payments",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Fetches an invoice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invoice ID",
+                        "name": "invoiceID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Invoice"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/invoices/{invoiceID}/claims": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Drafts a claim for an issued invoice against one of the patient's policies, the primary one unless given. Each invoice item becomes a claim line. Diagnosis codes default to those of the signed encounters billed on the invoice. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "claims"
+                ],
+                "summary": "Drafts an insurance claim",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invoice ID",
+                        "name": "invoiceID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Claim",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateClaimPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Claim"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/invoices/{invoiceID}/issue": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Numbers the draft invoice and makes it payable. Its items can no longer change; later charges open a new draft.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Issues an invoice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invoice ID",
+                        "name": "invoiceID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Invoice"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/invoices/{invoiceID}/items/{itemID}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sets the discount of an item of a draft invoice, in minor units. Tax is charged on the discounted price.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Discounts an invoice item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invoice ID",
+                        "name": "invoiceID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Item ID",
+                        "name": "itemID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Discount",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetDiscountPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.InvoiceItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes an item from a draft invoice",
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Removes an invoice item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invoice ID",
+                        "name": "invoiceID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Item ID",
+                        "name": "itemID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/invoices/{invoiceID}/payments": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Records a full or partial payment of an issued invoice. Card and wallet payments are charged through the payment gateway; cash and bank transfers are recorded by staff. Patients and their guardians can pay by card or wallet. A receipt is rendered for successful payments.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Pays an invoice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invoice ID",
+                        "name": "invoiceID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Payment",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreatePaymentPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Payment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/invoices/{invoiceID}/void": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cancels an issued invoice. Payments must be refunded first. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Voids an invoice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invoice ID",
+                        "name": "invoiceID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.VoidInvoicePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Invoice"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/lab-orders": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists lab orders oldest first, for working through the lab queue. Lab staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab"
+                ],
+                "summary": "Lists lab orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ordered, resulted or released",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.LabOrder"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/lab-orders/{labOrderID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab"
+                ],
+                "summary": "Fetches a lab order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Lab order ID",
+                        "name": "labOrderID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.LabOrder"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/lab-orders/{labOrderID}/release": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Makes the results of an order visible to the patient once all of them have been entered. Only the ordering doctor can release.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab"
+                ],
+                "summary": "Releases lab results",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Lab order ID",
+                        "name": "labOrderID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.LabOrder"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/lab-orders/{labOrderID}/results": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Enters or corrects results on an order until it is released. Each value is flagged against the reference range for the patient's sex and age at the time of the order, and critical results are emailed to the ordering doctor. Lab staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab"
+                ],
+                "summary": "Enters lab results",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Lab order ID",
+                        "name": "labOrderID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Results",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.RecordLabResultsPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.LabOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/lab-tests": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the active lab tests with their reference ranges by sex and age",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab"
+                ],
+                "summary": "Lists the lab test catalog",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.LabTest"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a test to the catalog. Ranges apply to a sex, or everyone when sex is omitted, from age_min up to but not including age_max years.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab"
+                ],
+                "summary": "Adds a lab test",
+                "parameters": [
+                    {
+                        "description": "Lab test",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateLabTestPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.LabTest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients": {
+            "post": {
+                "description": "Creates a patient user account with a patient profile, assigns a new MRN and sends the activation email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Registers a patient",
+                "parameters": [
+                    {
+                        "description": "Patient account and profile",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreatePatientPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Patient"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/lookup": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Finds the patient holding an external identifier, such as a national ID or insurance member ID. Reception and above only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Looks a patient up by external identifier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Identifier type",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Identifier value",
+                        "name": "value",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Issuing country or payer",
+                        "name": "issuer",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Patient"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/search": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Searches patients by name, email, phone or MRN (q), date of birth and exact phone number. Reception and above only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Searches patients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name, email or phone fragment, or an exact MRN",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date of birth, YYYY-MM-DD",
+                        "name": "dob",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact phone number",
+                        "name": "phone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 1-50 (default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Patient"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches a patient profile with emergency contacts and insurance",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Fetches a patient profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Patient"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the profile fields and emergency contacts of a patient",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Updates a patient profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Patient profile",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.PatientProfilePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Patient"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/admissions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "adt"
+                ],
+                "summary": "Lists a patient's admissions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "admitted or discharged",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Admission"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/allergies": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches the allergies and intolerances recorded for a patient",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Fetches allergies",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Allergy"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Records an allergy or intolerance for a patient. Substance may name a drug, a drug class such as penicillin, or anything else. Prescriptions are checked against the list. The patient and doctors can record allergies.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Records an allergy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Allergy",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateAllergyPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Allergy"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/allergies/{allergyID}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Removes an allergy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Allergy ID",
+                        "name": "allergyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/appointments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the appointments of a patient, latest first. Available to the patient, their guardians and staff.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "appointment"
+                ],
+                "summary": "Lists a patient's appointments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Appointment"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Books an appointment for the patient in the path. Patients book for themselves and guardians for their dependents.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "appointment"
+                ],
+                "summary": "Books an appointment for a patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Appointment",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.BookAppointmentPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Appointment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/balance": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns what the patient owes across issued invoices, with the invoices that are not settled",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Fetches a patient's outstanding balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.PatientBalance"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/charges": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds an item to the patient's draft invoice, opening one if needed. Priced items are charged at the price list; other charges carry their own description and price. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Charges a patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Charge",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateChargePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.InvoiceItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/claims": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the patient's insurance claims. Patients and their guardians do not see drafts.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "claims"
+                ],
+                "summary": "Lists a patient's claims",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Claim"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/crossmatch-requests": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Lists a patient's blood requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, ready or closed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.CrossmatchRequest"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Asks the blood bank to crossmatch units of a component for the patient, whose blood group must be recorded. Doctors only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "blood-bank"
+                ],
+                "summary": "Requests blood for a patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Request",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateCrossmatchPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.CrossmatchRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/discharge-summaries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists discharge summaries, newest first. Patients and their guardians only see final summaries.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Lists a patient's discharge summaries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.DischargeSummary"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/documents": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the documents attached to a patient's record, newest first, optionally filtered by category, encounter or appointment",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patients"
+                ],
+                "summary": "Lists a patient's documents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Encounter ID",
+                        "name": "encounter_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Appointment ID",
+                        "name": "appointment_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 1-100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.PatientDocument"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Uploads a scanned report, referral letter or other document. PDF, JPEG, PNG and TIFF are accepted; the type is detected from the file content. Files are checked for viruses before they are stored. The document can be linked to one of the patient's encounters and/or appointments.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patients"
+                ],
+                "summary": "Attaches a document to a patient's record",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Document",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "lab_report, imaging, referral_letter, discharge_summary, prescription, consent_form, insurance, identity or other",
+                        "name": "category",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Title, defaults to the file name",
+                        "name": "title",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Encounter ID",
+                        "name": "encounter_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Appointment ID",
+                        "name": "appointment_id",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.PatientDocument"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {}
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {}
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/documents/{documentID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Downloads the file of a patient document. Available to the patient, doctors, nurses and the uploader.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "patients"
+                ],
+                "summary": "Downloads a patient document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "documentID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a document and its file. Only the uploader or an admin may delete a document.",
+                "tags": [
+                    "patients"
+                ],
+                "summary": "Deletes a patient document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "documentID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Document deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/encounters": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches the encounters of a patient, newest first. Readable by the patient and their treating doctors; drafts are only listed for their author.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "encounter"
+                ],
+                "summary": "Fetches a patient's encounters",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Encounter"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/guardians": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "guardian"
+                ],
+                "summary": "Lists a patient's guardians",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Guardian"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Makes the active account with the given email a guardian of the patient, for example a second parent. An independent patient, a guardian of a dependent and admins may do this; admins cannot name themselves.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "guardian"
+                ],
+                "summary": "Adds a guardian",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Guardian",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddGuardianPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Guardian"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/guardians/{guardianID}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Unlinks a guardian from the patient. Staff, an independent patient and the guardian themselves may do this. A dependent keeps at least one guardian.",
+                "tags": [
+                    "guardian"
+                ],
+                "summary": "Removes a guardian",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Guardian user ID",
+                        "name": "guardianID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Guardian removed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/identifiers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches the national IDs, insurance member IDs and other external identifiers of a patient",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Fetches external identifiers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.PatientIdentifier"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds an external identifier to a patient. A value can belong to one patient per type and issuer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Adds an external identifier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Identifier",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreatePatientIdentifierPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.PatientIdentifier"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/identifiers/{identifierID}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Removes an external identifier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Identifier ID",
+                        "name": "identifierID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/immunizations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the administered doses and the state of every dose of the schedule: completed, upcoming, due, overdue or missed (past its catch-up age)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "immunizations"
+                ],
+                "summary": "Lists a patient's immunizations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ImmunizationRecord"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Records a vaccine dose with its lot number and injection site; the administering staff member is the caller. Vaccines on the schedule take their name from it, others need vaccine_name. Nurses and doctors only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "immunizations"
+                ],
+                "summary": "Records an administered vaccine dose",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dose",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.RecordImmunizationPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Immunization"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/insurance": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists a patient's insurance policies, primary first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Lists insurance policies",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.InsurancePolicy"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds an insurance policy to a patient. A new primary policy replaces the previous primary one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Adds an insurance policy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Insurance policy",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateInsurancePolicyPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.InsurancePolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/insurance/{policyID}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes an insurance policy from a patient",
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Removes an insurance policy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Policy ID",
+                        "name": "policyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Policy removed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/insurance/{policyID}/eligibility": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Asks the payer whether the policy covers care on the given day, today by default. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "claims"
+                ],
+                "summary": "Checks insurance eligibility",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Policy ID",
+                        "name": "policyID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service date (YYYY-MM-DD)",
+                        "name": "date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/eligibility.Result"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/invoices": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists invoices with their totals, newest first. Patients and their guardians do not see the draft.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Lists a patient's invoices",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Invoice"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/lab-orders": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists a patient's lab orders, newest first. Patients only see orders whose results have been released.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab"
+                ],
+                "summary": "Lists a patient's lab orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ordered, resulted or released",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.LabOrder"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/referrals": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the patient's referrals, the most urgent first and then the oldest.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "referrals"
+                ],
+                "summary": "Lists a patient's referrals",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "sent, accepted, declined, scheduled or closed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Referral"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Refers the patient to a doctor or to a department, with the reason, urgency and notes, optionally attaching the encounter it came out of. The referring and receiving doctors are emailed; a department referral goes to all of its doctors. Doctors only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "referrals"
+                ],
+                "summary": "Refers a patient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Referral",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateReferralPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Referral"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/timeline": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Merges appointments, encounters, diagnoses, prescriptions, lab orders, vitals, documents and admissions into one list, newest first. Readable by the patient, their guardians and treating doctors. Patients only see released lab orders, and draft encounters are only shown to their author.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "patient"
+                ],
+                "summary": "Fetches a patient's timeline",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated event types (default all)",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 1-100 (default 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.TimelinePage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/upgrade": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Invites a dependent who has reached the age of majority to a login of their own. The patient activates it from the email sent to them, setting their own password, which unlinks their guardians; until then they stay a dependent with upgrade_pending set. Upgrading a pending dependent again resends the invitation, to the email given. Their guardians and admins may do this.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "guardian"
+                ],
+                "summary": "Upgrades a dependent to an independent account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Login details",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UpgradeDependentPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Patient"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Withdraws the invitation of a dependent who has not activated their login yet, for example when it went to the wrong email. They stay a dependent of their guardians. Their guardians and admins may do this.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "guardian"
+                ],
+                "summary": "Cancels a dependent's upgrade",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Patient"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/patients/{patientID}/vitals": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the patient's vital signs as one series per type, oldest point first, with the alert thresholds for drawing reference lines. type is a comma separated list of systolic, diastolic, blood_pressure (both), pulse, temperature, spo2, weight, height and bmi; all types are returned when omitted. The period defaults to the last 90 days.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vitals"
+                ],
+                "summary": "Vital signs time series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Vital types",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.VitalSeries"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Records a set of vital signs for a patient. Temperature, weight and height are given with their unit and stored as Celsius, kilograms and centimetres. BMI is computed from the weight and the latest height. Values outside the alert thresholds are returned as alerts. Nurses and doctors only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vitals"
+                ],
+                "summary": "Records vital signs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Patient ID or MRN",
+                        "name": "patientID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Measurements",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.RecordVitalsPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.VitalsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/payments/{paymentID}/receipt": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Downloads the PDF receipt of a successful payment or refund",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Downloads a receipt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "paymentID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/payments/{paymentID}/refunds": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Refunds all or part of a successful payment by the method it was paid with. Card and wallet refunds go through the payment gateway. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "billing"
+                ],
+                "summary": "Refunds a payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payment ID",
+                        "name": "paymentID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Refund",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateRefundPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Payment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "402": {
+                        "description": "Payment Required",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/pharmacy/alerts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists drugs at or below their reorder level and batches in stock that expire within PHARMACY_EXPIRY_WARNING_DAYS or have expired",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pharmacy"
+                ],
+                "summary": "Lists stock alerts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.StockAlerts"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/pharmacy/drugs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists drugs with their unexpired stock, optionally filtered by a name prefix",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pharmacy"
+                ],
+                "summary": "Lists the drug catalog",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name prefix",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Drug"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a drug. Stock at or below the reorder level is reported as low.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pharmacy"
+                ],
+                "summary": "Adds a drug to the catalog",
+                "parameters": [
+                    {
+                        "description": "Drug",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateDrugPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Drug"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/pharmacy/drugs/{drugID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches a drug with its batches in stock, earliest expiry first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pharmacy"
+                ],
+                "summary": "Fetches a drug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Drug ID",
+                        "name": "drugID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Drug"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/pharmacy/drugs/{drugID}/batches": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Records a goods receipt of one lot of a drug",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pharmacy"
+                ],
+                "summary": "Receives stock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Drug ID",
+                        "name": "drugID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Batch",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ReceiveBatchPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.DrugBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/prescriptions/{prescriptionID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "prescription"
+                ],
+                "summary": "Fetches a prescription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Prescription ID",
+                        "name": "prescriptionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Prescription"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/prescriptions/{prescriptionID}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cancels an issued prescription. Only the prescribing doctor can cancel it, and a reason is required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "prescription"
+                ],
+                "summary": "Cancels a prescription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Prescription ID",
+                        "name": "prescriptionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cancellation reason",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CancelPrescriptionPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Prescription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/prescriptions/{prescriptionID}/dispensations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists what was handed out for a prescription and from which batches",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pharmacy"
+                ],
+                "summary": "Lists the dispensations of a prescription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Prescription ID",
+                        "name": "prescriptionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Dispensation"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Hands out prescription lines as catalog drugs. Stock is taken from the unexpired batches with the earliest expiry first. Each line can be dispensed once; lines left out can be dispensed later.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pharmacy"
+                ],
+                "summary": "Dispenses a prescription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Prescription ID",
+                        "name": "prescriptionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Lines to dispense",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.DispensePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Dispensation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/prescriptions/{prescriptionID}/pdf": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Downloads the signed PDF produced when the prescription was issued",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "prescription"
+                ],
+                "summary": "Downloads a prescription PDF",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Prescription ID",
+                        "name": "prescriptionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/prescriptions/{prescriptionID}/verify": {
+            "get": {
+                "description": "Checks a signature printed on a prescription against the server. Pharmacies can call it without an account; no patient details are returned, and nothing about the prescription unless the signature matches.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "prescription"
+                ],
+                "summary": "Verifies a prescription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Prescription ID",
+                        "name": "prescriptionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature printed on the prescription",
+                        "name": "signature",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.PrescriptionVerification"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/referrals": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists referrals, the most urgent first and then the oldest. Doctors see the referrals sent to them or to their department by default, or the ones they sent with direction=outgoing. Other staff see all referrals.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "referrals"
+                ],
+                "summary": "Lists referrals",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "incoming or outgoing, for doctors",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Department",
+                        "name": "department",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sent, accepted, declined, scheduled or closed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "routine, urgent or emergency",
+                        "name": "urgency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Referral"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/referrals/{referralID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "referrals"
+                ],
+                "summary": "Fetches a referral",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Referral ID",
+                        "name": "referralID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Referral"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/referrals/{referralID}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Takes the referral on. A department referral is assigned to the doctor who accepts it. Receiving doctor only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "referrals"
+                ],
+                "summary": "Accepts a referral",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Referral ID",
+                        "name": "referralID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Referral"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/referrals/{referralID}/appointment": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Books an appointment with the receiving doctor and links it to the referral, which becomes scheduled. Reception must name a department doctor until one has taken the referral on. A referral whose appointment was cancelled can be booked again. Receiving doctor and reception only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "referrals"
+                ],
+                "summary": "Books the appointment of a referral",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Referral ID",
+                        "name": "referralID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Appointment",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.BookReferralPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Appointment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/referrals/{referralID}/close": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ends a referral that was not declined, once the patient was seen or when it is no longer needed. Referring and receiving doctors only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "referrals"
+                ],
+                "summary": "Closes a referral",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Referral ID",
+                        "name": "referralID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Referral"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/referrals/{referralID}/decline": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Turns down a referral that has not been booked, with the reason. Receiving doctor only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "referrals"
+                ],
+                "summary": "Declines a referral",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Referral ID",
+                        "name": "referralID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.DeclineReferralPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Referral"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/reports/diagnoses/top": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Counts diagnoses on encounters signed in the period, grouped by the specialization of the treating doctor. The period defaults to the last 30 days.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Top diagnoses per department",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Diagnoses per department, 1-50 (default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Count primary diagnoses only",
+                        "name": "primary_only",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.DepartmentDiagnoses"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/rooms/{roomID}/beds": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a bed to a room. New beds are available.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "adt"
+                ],
+                "summary": "Adds a bed to a room",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Room ID",
+                        "name": "roomID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Bed",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateBedPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Bed"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the shifts overlapping the days from from to to (UTC, the next week by default, at most 92 days) in order of start. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Lists rostered shifts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Staff member",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Department",
+                        "name": "department",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.RosterShift"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/generate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rosters a week or a calendar month from the given day. Each assignment rotates a shift template through its staff, skipping anyone who would work within the minimum rest of another shift, rostered or generated. Slots left short are returned as gaps. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Generates a roster",
+                "parameters": [
+                    {
+                        "description": "Period and assignments",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.GenerateRosterPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.RosterPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/on-call": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists who is on call for a department at the given time, now by default. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Finds who is on call",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Department, such as cardiology",
+                        "name": "department",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time, RFC 3339",
+                        "name": "at",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.RosterShift"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/shifts": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rosters one staff member on a template's shift starting on the date. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Rosters a shift",
+                "parameters": [
+                    {
+                        "description": "Shift",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddRosterShiftPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.RosterShift"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/shifts/{shiftID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Fetches a rostered shift",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shift ID",
+                        "name": "shiftID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.RosterShift"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a shift that is yet to start. Admin only.",
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Takes a shift off the roster",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shift ID",
+                        "name": "shiftID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/shifts/{shiftID}/swaps": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Asks a colleague to take over one of your upcoming shifts, in exchange for one of theirs when counterpart_shift_id is set. The colleague accepts it and an admin approves it. Only the shift's owner may ask.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Asks a colleague to swap a shift",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shift ID",
+                        "name": "shiftID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Swap",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.RequestShiftSwapPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.ShiftSwap"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/swaps": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists swaps newest first. Admins see every swap, other staff the swaps they asked for or were asked to take.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Lists shift swaps",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, accepted, approved, declined, rejected or cancelled",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.ShiftSwap"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/swaps/{swapID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Fetches a shift swap",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Swap ID",
+                        "name": "swapID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ShiftSwap"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/swaps/{swapID}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Agrees to a pending swap, which then waits for an admin's approval. Only the colleague asked may accept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Accepts a shift swap",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Swap ID",
+                        "name": "swapID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ShiftSwap"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/swaps/{swapID}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reassigns the shifts of an accepted swap, provided neither of the two then works within the minimum rest of another shift. Admin only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Approves a shift swap",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Swap ID",
+                        "name": "swapID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ShiftSwap"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/swaps/{swapID}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Withdraws a pending or accepted swap. Only the staff member who asked may withdraw it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Withdraws a shift swap",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Swap ID",
+                        "name": "swapID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ShiftSwap"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/swaps/{swapID}/decline": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Turns down a pending swap. Only the colleague asked may decline.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Declines a shift swap",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Swap ID",
+                        "name": "swapID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ShiftSwap"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/swaps/{swapID}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Turns down a pending or accepted swap. Admin only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Rejects a shift swap",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Swap ID",
+                        "name": "swapID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.ShiftSwap"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/roster/templates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Lists shift templates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.ShiftTemplate"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a recurring shift, times in UTC. A shift ending at or before its start runs overnight. On-call shifts need a department, which the on-call lookup matches.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roster"
+                ],
+                "summary": "Adds a shift template",
+                "parameters": [
+                    {
+                        "description": "Shift template",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateShiftTemplatePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.ShiftTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/theatre-cases": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Books a case for a patient given by ID or MRN with its procedure, start, expected duration, team and equipment. The booking is refused if the theatre, anyone on the team or any equipment is already booked at an overlapping time. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Books a theatre",
+                "parameters": [
+                    {
+                        "description": "Case",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ScheduleSurgicalCasePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.SurgicalCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/theatre-cases/conflicts": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists what a booking would overlap on the theatre, the team and the equipment, without booking it. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Checks a theatre booking for conflicts",
+                "parameters": [
+                    {
+                        "description": "Case",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ScheduleSurgicalCasePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.ScheduleConflict"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/theatre-cases/{caseID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Fetches a theatre case",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Case ID",
+                        "name": "caseID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.SurgicalCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the theatre, time, procedure, team and equipment of a case that has not started, with the same conflict checks as booking. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Reschedules a theatre case",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Case ID",
+                        "name": "caseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Booking",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SurgicalBookingPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.SurgicalCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/theatre-cases/{caseID}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Cancels a case that has not started, freeing its theatre, team and equipment. Staff only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Cancels a theatre case",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Case ID",
+                        "name": "caseID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CancelSurgicalCasePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.SurgicalCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/theatre-cases/{caseID}/complete": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Marks a case in progress completed, which frees its theatre, team and equipment. Doctors and nurses only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Completes a theatre case",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Case ID",
+                        "name": "caseID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.SurgicalCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/theatre-cases/{caseID}/start": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Marks a scheduled case in progress. Doctors and nurses only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Starts a theatre case",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Case ID",
+                        "name": "caseID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.SurgicalCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/theatre-equipment": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Lists theatre equipment",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.TheatreEquipment"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds one unit of equipment, such as \"C-arm 2\", which cases book one at a time.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Adds theatre equipment",
+                "parameters": [
+                    {
+                        "description": "Equipment",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateTheatreEquipmentPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.TheatreEquipment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/theatres": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Lists operating theatres",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Theatre"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Adds an operating theatre",
+                "parameters": [
+                    {
+                        "description": "Theatre",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateTheatrePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Theatre"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/theatres/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists each theatre with the cases starting on the day (UTC, today by default) in order, cancelled ones included. Staff only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "theatres"
+                ],
+                "summary": "Shows the daily theatre list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Day, YYYY-MM-DD",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Theatre ID",
+                        "name": "theatre_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.TheatreList"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/activate/{token}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Activates/Register a user by invitation token. An upgraded dependent sets their password here.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Activates/Register a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invitation token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Password",
+                        "name": "payload",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.ActivateUserPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User activated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/patients": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a list of users with role_id = 1 (patients)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get all patients",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.UserMinimal"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches a user profile by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Fetches a user profile",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/users/{userID}/role": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Assigns a role such as doctor, receptionist or lab to a user. Admin only; admins cannot change their own role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Changes a user's role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role name",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetUserRolePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Role changed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/vitals/thresholds": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vitals"
+                ],
+                "summary": "Lists vital sign alert thresholds",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.VitalThreshold"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/vitals/thresholds/{type}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sets the bounds outside which a vital sign raises an alert, in the type's stored unit. Omit both bounds to turn alerts off for the type. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vitals"
+                ],
+                "summary": "Sets a vital sign alert threshold",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vital type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Bounds",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetVitalThresholdPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.VitalThreshold"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/wards": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "adt"
+                ],
+                "summary": "Lists wards",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Ward"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "adt"
+                ],
+                "summary": "Creates a ward",
+                "parameters": [
+                    {
+                        "description": "Ward",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateWardPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Ward"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        },
+        "/wards/{wardID}/rooms": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "adt"
+                ],
+                "summary": "Adds a room to a ward",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ward ID",
+                        "name": "wardID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Room",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateRoomPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Room"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {}
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {}
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {}
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {}
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {}
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "eligibility.Result": {
+            "type": "object",
+            "properties": {
+                "checker": {
+                    "description": "Checker names what answered, so a stub answer is never mistaken for\nthe payer's",
+                    "type": "string"
+                },
+                "eligible": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "interactions.Kind": {
+            "type": "string",
+            "enum": [
+                "allergy",
+                "interaction"
+            ],
+            "x-enum-varnames": [
+                "KindAllergy",
+                "KindInteraction"
+            ]
+        },
+        "interactions.Severity": {
+            "type": "string",
+            "enum": [
+                "minor",
+                "moderate",
+                "major",
+                "contraindicated"
+            ],
+            "x-enum-varnames": [
+                "SeverityMinor",
+                "SeverityModerate",
+                "SeverityMajor",
+                "SeverityContraindicated"
+            ]
+        },
+        "interactions.Warning": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "drug": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/interactions.Kind"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "severity": {
+                    "$ref": "#/definitions/interactions.Severity"
+                },
+                "with": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ActivateUserPayload": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "description": "Password is required for an account without one, an upgraded dependent",
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 3
+                }
+            }
+        },
+        "main.AddBloodUnitPayload": {
+            "type": "object",
+            "required": [
+                "blood_group",
+                "collected_on",
+                "component",
+                "expires_on",
+                "source",
+                "unit_number",
+                "volume_ml"
+            ],
+            "properties": {
+                "blood_group": {
+                    "enum": [
+                        "A+",
+                        "A-",
+                        "B+",
+                        "B-",
+                        "AB+",
+                        "AB-",
+                        "O+",
+                        "O-"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.BloodGroup"
+                        }
+                    ]
+                },
+                "collected_on": {
+                    "type": "string"
+                },
+                "component": {
+                    "enum": [
+                        "whole_blood",
+                        "red_cells",
+                        "platelets",
+                        "plasma",
+                        "cryoprecipitate"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.BloodComponent"
+                        }
+                    ]
+                },
+                "expires_on": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "unit_number": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "volume_ml": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 1
+                }
+            }
+        },
+        "main.AddGuardianPayload": {
+            "type": "object",
+            "required": [
+                "email",
+                "relationship"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "relationship": {
+                    "enum": [
+                        "parent",
+                        "legal_guardian",
+                        "spouse",
+                        "child",
+                        "sibling",
+                        "caregiver",
+                        "other"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.GuardianRelationship"
+                        }
+                    ]
+                }
+            }
+        },
+        "main.AddRosterShiftPayload": {
+            "type": "object",
+            "required": [
+                "date",
+                "template_id",
+                "user_id"
+            ],
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AdmitPatientPayload": {
+            "type": "object",
+            "required": [
+                "bed_id",
+                "reason"
+            ],
+            "properties": {
+                "attending_doctor_id": {
+                    "type": "string"
+                },
+                "bed_id": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "patient_mrn": {
+                    "type": "string",
+                    "maxLength": 40
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 2000
+                }
+            }
+        },
+        "main.AvailabilityConflict": {
+            "type": "object",
+            "properties": {
+                "availability": {
+                    "$ref": "#/definitions/store.Availability"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "shift": {
+                    "$ref": "#/definitions/store.RosterShift"
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AvailabilitySlot": {
+            "type": "object",
+            "properties": {
+                "available_day": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "fees": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.DoctorFee"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "next_date": {
+                    "type": "string"
+                },
+                "starts_from": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.BloodAvailability": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ComponentAvailability"
+                    }
+                },
+                "recipient": {
+                    "$ref": "#/definitions/store.BloodGroup"
+                }
+            }
+        },
+        "main.BookAppointmentPayload": {
+            "type": "object",
+            "required": [
+                "appointment_time",
+                "doctor_id"
+            ],
+            "properties": {
+                "appointment_time": {
+                    "type": "string"
+                },
+                "consultation_mode": {
+                    "enum": [
+                        "in_person",
+                        "online"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.ConsultationMode"
+                        }
+                    ]
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "visit_type": {
+                    "enum": [
+                        "new_patient",
+                        "follow_up"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.VisitType"
+                        }
+                    ]
+                }
+            }
+        },
+        "main.BookFollowUpPayload": {
+            "type": "object",
+            "required": [
+                "appointment_time"
+            ],
+            "properties": {
+                "appointment_time": {
+                    "type": "string"
+                },
+                "consultation_mode": {
+                    "enum": [
+                        "in_person",
+                        "online"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.ConsultationMode"
+                        }
+                    ]
+                },
+                "doctor_id": {
+                    "description": "DoctorID defaults to the author of the summary",
+                    "type": "string"
+                }
+            }
+        },
+        "main.BookReferralPayload": {
+            "type": "object",
+            "required": [
+                "appointment_time"
+            ],
+            "properties": {
+                "appointment_time": {
+                    "type": "string"
+                },
+                "consultation_mode": {
+                    "enum": [
+                        "in_person",
+                        "online"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.ConsultationMode"
+                        }
+                    ]
+                },
+                "doctor_id": {
+                    "description": "DoctorID defaults to the doctor who took the referral on, or to the\ndoctor booking it",
+                    "type": "string"
+                },
+                "visit_type": {
+                    "enum": [
+                        "new_patient",
+                        "follow_up"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.VisitType"
+                        }
+                    ]
+                }
+            }
+        },
+        "main.CancelPrescriptionPayload": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "minLength": 3
+                }
+            }
+        },
+        "main.CancelSurgicalCasePayload": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 2000
+                }
+            }
+        },
+        "main.CheckPrescriptionPayload": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/main.PrescriptionItemPayload"
+                    }
+                }
+            }
+        },
+        "main.ComponentAvailability": {
+            "type": "object",
+            "properties": {
+                "component": {
+                    "$ref": "#/definitions/store.BloodComponent"
+                },
+                "donor_groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.BloodGroup"
+                    }
+                },
+                "stock": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.BloodStock"
+                    }
+                },
+                "units": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.CreateAllergyPayload": {
+            "type": "object",
+            "required": [
+                "substance",
+                "type"
+            ],
+            "properties": {
+                "reaction": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "severity": {
+                    "enum": [
+                        "mild",
+                        "moderate",
+                        "severe",
+                        "unknown"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.AllergySeverity"
+                        }
+                    ]
+                },
+                "substance": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "type": {
+                    "enum": [
+                        "allergy",
+                        "intolerance"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.AllergyType"
+                        }
+                    ]
+                }
+            }
+        },
         "main.CreateAppointmentPayload": {
             "type": "object",
-            "required": [
-                "appointment_time",
-                "doctor_id",
-                "patient_id"
+            "required": [
+                "appointment_time",
+                "doctor_id"
+            ],
+            "properties": {
+                "appointment_time": {
+                    "type": "string"
+                },
+                "consultation_mode": {
+                    "enum": [
+                        "in_person",
+                        "online"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.ConsultationMode"
+                        }
+                    ]
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "patient_mrn": {
+                    "type": "string",
+                    "maxLength": 40
+                },
+                "visit_type": {
+                    "enum": [
+                        "new_patient",
+                        "follow_up"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.VisitType"
+                        }
+                    ]
+                }
+            }
+        },
+        "main.CreateAvailabilityPayload": {
+            "type": "object",
+            "required": [
+                "available_day",
+                "ends_at",
+                "starts_from"
+            ],
+            "properties": {
+                "available_day": {
+                    "type": "string",
+                    "enum": [
+                        "monday",
+                        "tuesday",
+                        "wednesday",
+                        "thursday",
+                        "friday",
+                        "saturday",
+                        "sunday"
+                    ]
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "starts_from": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.CreateBedPayload": {
+            "type": "object",
+            "required": [
+                "label"
+            ],
+            "properties": {
+                "label": {
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "main.CreateBloodDonorPayload": {
+            "type": "object",
+            "required": [
+                "blood_group",
+                "date_of_birth",
+                "name"
+            ],
+            "properties": {
+                "blood_group": {
+                    "enum": [
+                        "A+",
+                        "A-",
+                        "B+",
+                        "B-",
+                        "AB+",
+                        "AB-",
+                        "O+",
+                        "O-"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.BloodGroup"
+                        }
+                    ]
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30
+                }
+            }
+        },
+        "main.CreateChargePayload": {
+            "type": "object",
+            "required": [
+                "kind"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code names the price list entry; not used for other charges",
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "description": {
+                    "description": "Description, UnitPrice and TaxRate are only used for other charges",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "discount": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "kind": {
+                    "enum": [
+                        "procedure",
+                        "lab_test",
+                        "drug",
+                        "other"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.ChargeKind"
+                        }
+                    ]
+                },
+                "quantity": {
+                    "type": "integer",
+                    "maximum": 1000
+                },
+                "tax_rate": {
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 0
+                },
+                "unit_price": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "main.CreateClaimPayload": {
+            "type": "object",
+            "required": [
+                "diagnosis_codes"
+            ],
+            "properties": {
+                "diagnosis_codes": {
+                    "description": "DiagnosisCodes default to the diagnoses of the encounters billed on\nthe invoice",
+                    "type": "array",
+                    "maxItems": 12,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "policy_id": {
+                    "description": "PolicyID defaults to the patient's primary policy",
+                    "type": "string"
+                }
+            }
+        },
+        "main.CreateCrossmatchPayload": {
+            "type": "object",
+            "required": [
+                "component",
+                "indication",
+                "units_requested"
+            ],
+            "properties": {
+                "component": {
+                    "enum": [
+                        "whole_blood",
+                        "red_cells",
+                        "platelets",
+                        "plasma",
+                        "cryoprecipitate"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.BloodComponent"
+                        }
+                    ]
+                },
+                "indication": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "required_by": {
+                    "type": "string"
+                },
+                "units_requested": {
+                    "type": "integer",
+                    "maximum": 20,
+                    "minimum": 1
+                },
+                "urgency": {
+                    "enum": [
+                        "routine",
+                        "urgent",
+                        "emergency"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.CrossmatchUrgency"
+                        }
+                    ]
+                }
+            }
+        },
+        "main.CreateDependentPayload": {
+            "type": "object",
+            "required": [
+                "date_of_birth",
+                "firstname",
+                "lastname",
+                "phone",
+                "relationship",
+                "sex"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "blood_group": {
+                    "enum": [
+                        "A+",
+                        "A-",
+                        "B+",
+                        "B-",
+                        "AB+",
+                        "AB-",
+                        "O+",
+                        "O-"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.BloodGroup"
+                        }
+                    ]
+                },
+                "city": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "country": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "emergency_contacts": {
+                    "type": "array",
+                    "maxItems": 5,
+                    "items": {
+                        "$ref": "#/definitions/store.EmergencyContact"
+                    }
+                },
+                "firstname": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "guardian_id": {
+                    "description": "GuardianID lets staff register a dependent for someone else; it\ndefaults to the caller",
+                    "type": "string"
+                },
+                "lastname": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "postal_code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "relationship": {
+                    "enum": [
+                        "parent",
+                        "legal_guardian",
+                        "spouse",
+                        "child",
+                        "sibling",
+                        "caregiver",
+                        "other"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.GuardianRelationship"
+                        }
+                    ]
+                },
+                "sex": {
+                    "enum": [
+                        "male",
+                        "female",
+                        "other"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.Gender"
+                        }
+                    ]
+                },
+                "state": {
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "main.CreateDoctorFeePayload": {
+            "type": "object",
+            "required": [
+                "consultation_mode",
+                "currency",
+                "effective_from",
+                "visit_type"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "consultation_mode": {
+                    "enum": [
+                        "in_person",
+                        "online"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.ConsultationMode"
+                        }
+                    ]
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "effective_from": {
+                    "type": "string"
+                },
+                "visit_type": {
+                    "enum": [
+                        "new_patient",
+                        "follow_up"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.VisitType"
+                        }
+                    ]
+                }
+            }
+        },
+        "main.CreateDoctorPayload": {
+            "type": "object",
+            "required": [
+                "address",
+                "blood_group",
+                "city",
+                "country",
+                "date_of_birth",
+                "designation",
+                "email",
+                "firstname",
+                "gender",
+                "lastname",
+                "license_number",
+                "marital_status",
+                "password",
+                "postal_code",
+                "qualification",
+                "specialization",
+                "state",
+                "username"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "blood_group": {
+                    "enum": [
+                        "A+",
+                        "A-",
+                        "B+",
+                        "B-",
+                        "AB+",
+                        "AB-",
+                        "O+",
+                        "O-"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.BloodGroup"
+                        }
+                    ]
+                },
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "designation": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "firstname": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "gender": {
+                    "enum": [
+                        "male",
+                        "female",
+                        "other"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.Gender"
+                        }
+                    ]
+                },
+                "lastname": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "license_number": {
+                    "type": "string"
+                },
+                "marital_status": {
+                    "enum": [
+                        "single",
+                        "married",
+                        "divorced",
+                        "widowed",
+                        "separated"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.MaritalStatus"
+                        }
+                    ]
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 3
+                },
+                "postal_code": {
+                    "type": "string"
+                },
+                "qualification": {
+                    "type": "string"
+                },
+                "specialization": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.CreateDrugPayload": {
+            "type": "object",
+            "required": [
+                "form",
+                "name",
+                "strength",
+                "unit"
+            ],
+            "properties": {
+                "form": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "reorder_level": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "strength": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "unit": {
+                    "type": "string",
+                    "maxLength": 30
+                }
+            }
+        },
+        "main.CreateEncounterAddendumPayload": {
+            "type": "object",
+            "required": [
+                "body"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "maxLength": 20000
+                }
+            }
+        },
+        "main.CreateInsurancePolicyPayload": {
+            "type": "object",
+            "required": [
+                "payer",
+                "policy_number"
+            ],
+            "properties": {
+                "group_number": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "is_primary": {
+                    "type": "boolean"
+                },
+                "member_id": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "payer": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "plan_name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "policy_number": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_to": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.CreateLabOrderPayload": {
+            "type": "object",
+            "required": [
+                "tests"
+            ],
+            "properties": {
+                "notes": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "tests": {
+                    "type": "array",
+                    "maxItems": 30,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.CreateLabTestPayload": {
+            "type": "object",
+            "required": [
+                "code",
+                "name",
+                "specimen",
+                "unit"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "ranges": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "$ref": "#/definitions/main.LabReferenceRangePayload"
+                    }
+                },
+                "specimen": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "unit": {
+                    "type": "string",
+                    "maxLength": 30
+                }
+            }
+        },
+        "main.CreatePatientIdentifierPayload": {
+            "type": "object",
+            "required": [
+                "type",
+                "value"
+            ],
+            "properties": {
+                "issuer": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "type": {
+                    "enum": [
+                        "national_id",
+                        "passport",
+                        "insurance_member_id",
+                        "driver_license",
+                        "other"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.IdentifierType"
+                        }
+                    ]
+                },
+                "value": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.CreatePatientPayload": {
+            "type": "object",
+            "required": [
+                "date_of_birth",
+                "email",
+                "firstname",
+                "lastname",
+                "password",
+                "phone",
+                "sex",
+                "username"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "blood_group": {
+                    "enum": [
+                        "A+",
+                        "A-",
+                        "B+",
+                        "B-",
+                        "AB+",
+                        "AB-",
+                        "O+",
+                        "O-"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.BloodGroup"
+                        }
+                    ]
+                },
+                "city": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "country": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "emergency_contacts": {
+                    "type": "array",
+                    "maxItems": 5,
+                    "items": {
+                        "$ref": "#/definitions/store.EmergencyContact"
+                    }
+                },
+                "firstname": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "lastname": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 3
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "postal_code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "sex": {
+                    "enum": [
+                        "male",
+                        "female",
+                        "other"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.Gender"
+                        }
+                    ]
+                },
+                "state": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.CreatePaymentPayload": {
+            "type": "object",
+            "required": [
+                "amount",
+                "method"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "method": {
+                    "enum": [
+                        "cash",
+                        "card",
+                        "bank_transfer",
+                        "mobile_wallet"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.PaymentMethod"
+                        }
+                    ]
+                },
+                "token": {
+                    "description": "Token is the card or wallet token from the gateway's client library",
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "main.CreatePrescriptionPayload": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "acknowledged_warnings": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "items": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/main.PrescriptionItemPayload"
+                    }
+                },
+                "override_reason": {
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
+        "main.CreateReferralPayload": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "department": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "encounter_id": {
+                    "description": "EncounterID attaches the consultation the referral came out of",
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 10000
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "to_doctor_id": {
+                    "description": "ToDoctorID refers to a doctor, Department to any doctor of that\nspecialization",
+                    "type": "string"
+                },
+                "urgency": {
+                    "enum": [
+                        "routine",
+                        "urgent",
+                        "emergency"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.ReferralUrgency"
+                        }
+                    ]
+                }
+            }
+        },
+        "main.CreateRefundPayload": {
+            "type": "object",
+            "required": [
+                "amount",
+                "reason"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "main.CreateRoomPayload": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.CreateShiftTemplatePayload": {
+            "type": "object",
+            "required": [
+                "ends_at",
+                "name",
+                "starts_at"
+            ],
+            "properties": {
+                "department": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "on_call": {
+                    "type": "boolean"
+                },
+                "role": {
+                    "description": "Role limits the shift to one role, such as nurse",
+                    "type": "string",
+                    "enum": [
+                        "doctor",
+                        "nurse",
+                        "receptionist",
+                        "lab",
+                        "pharmacist"
+                    ]
+                },
+                "starts_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.CreateTheatreEquipmentPayload": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.CreateTheatrePayload": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.CreateUserTokenPayload": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 3
+                }
+            }
+        },
+        "main.CreateWardPayload": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.DeclineReferralPayload": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 2000
+                }
+            }
+        },
+        "main.DeferBloodDonorPayload": {
+            "type": "object",
+            "properties": {
+                "deferred_until": {
+                    "description": "DeferredUntil lifts the deferral when null",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
+        "main.DepartERVisitPayload": {
+            "type": "object",
+            "required": [
+                "disposition"
+            ],
+            "properties": {
+                "disposition": {
+                    "enum": [
+                        "discharged",
+                        "admitted",
+                        "transferred",
+                        "left_without_being_seen",
+                        "deceased"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.ERDisposition"
+                        }
+                    ]
+                }
+            }
+        },
+        "main.DepartmentDiagnoses": {
+            "type": "object",
+            "properties": {
+                "department": {
+                    "type": "string"
+                },
+                "diagnoses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.DiagnosisCount"
+                    }
+                }
+            }
+        },
+        "main.DiscardBloodUnitPayload": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
+        "main.DischargePatientPayload": {
+            "type": "object",
+            "properties": {
+                "notes": {
+                    "type": "string",
+                    "maxLength": 2000
+                }
+            }
+        },
+        "main.DispenseLinePayload": {
+            "type": "object",
+            "required": [
+                "drug_id",
+                "quantity"
+            ],
+            "properties": {
+                "drug_id": {
+                    "type": "string"
+                },
+                "position": {
+                    "description": "Position is the index of the line on the prescription, from 0",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.DispensePayload": {
+            "type": "object",
+            "required": [
+                "lines"
+            ],
+            "properties": {
+                "lines": {
+                    "type": "array",
+                    "maxItems": 30,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "$ref": "#/definitions/main.DispenseLinePayload"
+                    }
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 2000
+                }
+            }
+        },
+        "main.DonatedUnitPayload": {
+            "type": "object",
+            "required": [
+                "component",
+                "unit_number",
+                "volume_ml"
+            ],
+            "properties": {
+                "component": {
+                    "enum": [
+                        "whole_blood",
+                        "red_cells",
+                        "platelets",
+                        "plasma",
+                        "cryoprecipitate"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.BloodComponent"
+                        }
+                    ]
+                },
+                "expires_on": {
+                    "description": "ExpiresOn defaults to the component's shelf life from the donation",
+                    "type": "string"
+                },
+                "unit_number": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "volume_ml": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 1
+                }
+            }
+        },
+        "main.GenerateRosterPayload": {
+            "type": "object",
+            "required": [
+                "assignments",
+                "from",
+                "period"
+            ],
+            "properties": {
+                "assignments": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/main.RosterAssignmentPayload"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "period": {
+                    "type": "string",
+                    "enum": [
+                        "week",
+                        "month"
+                    ]
+                }
+            }
+        },
+        "main.ImmunizationRecord": {
+            "type": "object",
+            "properties": {
+                "immunizations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Immunization"
+                    }
+                },
+                "schedule": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.DoseStatus"
+                    }
+                }
+            }
+        },
+        "main.ImmunizationReminderRun": {
+            "type": "object",
+            "properties": {
+                "emails": {
+                    "type": "integer"
+                },
+                "patients": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.IssueBloodUnitPayload": {
+            "type": "object",
+            "required": [
+                "issued_to",
+                "unit_id"
+            ],
+            "properties": {
+                "issued_to": {
+                    "description": "IssuedTo is who collects the unit, such as the ward nurse",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "unit_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.LabReferenceRangePayload": {
+            "type": "object",
+            "properties": {
+                "age_max": {
+                    "type": "integer",
+                    "maximum": 150,
+                    "minimum": 1
+                },
+                "age_min": {
+                    "type": "integer",
+                    "maximum": 150,
+                    "minimum": 0
+                },
+                "critical_high": {
+                    "type": "number"
+                },
+                "critical_low": {
+                    "type": "number"
+                },
+                "high": {
+                    "type": "number"
+                },
+                "low": {
+                    "type": "number"
+                },
+                "sex": {
+                    "enum": [
+                        "male",
+                        "female",
+                        "other"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.Gender"
+                        }
+                    ]
+                }
+            }
+        },
+        "main.LabResultPayload": {
+            "type": "object",
+            "required": [
+                "test_code",
+                "value"
+            ],
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "test_code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.PatientProfilePayload": {
+            "type": "object",
+            "required": [
+                "date_of_birth",
+                "firstname",
+                "lastname",
+                "phone",
+                "sex"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "blood_group": {
+                    "enum": [
+                        "A+",
+                        "A-",
+                        "B+",
+                        "B-",
+                        "AB+",
+                        "AB-",
+                        "O+",
+                        "O-"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.BloodGroup"
+                        }
+                    ]
+                },
+                "city": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "country": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "emergency_contacts": {
+                    "type": "array",
+                    "maxItems": 5,
+                    "items": {
+                        "$ref": "#/definitions/store.EmergencyContact"
+                    }
+                },
+                "firstname": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "lastname": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "postal_code": {
+                    "type": "string",
+                    "maxLength": 20
+                },
+                "sex": {
+                    "enum": [
+                        "male",
+                        "female",
+                        "other"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.Gender"
+                        }
+                    ]
+                },
+                "state": {
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "main.PrescriptionItemPayload": {
+            "type": "object",
+            "required": [
+                "dose",
+                "drug",
+                "duration",
+                "frequency",
+                "quantity",
+                "strength"
+            ],
+            "properties": {
+                "dose": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "drug": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "duration": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "frequency": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "instructions": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "quantity": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "strength": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.PrescriptionVerification": {
+            "type": "object",
+            "properties": {
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "doctor_license_number": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issued_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.PrescriptionStatus"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "main.PrescriptionWarnings": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/interactions.Warning"
+                    }
+                }
+            }
+        },
+        "main.ReceiveBatchPayload": {
+            "type": "object",
+            "required": [
+                "expiry_date",
+                "lot_number",
+                "quantity"
+            ],
+            "properties": {
+                "expiry_date": {
+                    "type": "string"
+                },
+                "lot_number": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "supplier": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "main.RecordCrossmatchPayload": {
+            "type": "object",
+            "required": [
+                "result",
+                "unit_id"
+            ],
+            "properties": {
+                "notes": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "result": {
+                    "enum": [
+                        "compatible",
+                        "incompatible"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.CrossmatchResult"
+                        }
+                    ]
+                },
+                "unit_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.RecordDonationPayload": {
+            "type": "object",
+            "required": [
+                "units",
+                "volume_ml"
+            ],
+            "properties": {
+                "donated_at": {
+                    "description": "DonatedAt defaults to now",
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "units": {
+                    "type": "array",
+                    "maxItems": 5,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/main.DonatedUnitPayload"
+                    }
+                },
+                "volume_ml": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 1
+                }
+            }
+        },
+        "main.RecordImmunizationPayload": {
+            "type": "object",
+            "required": [
+                "dose_number",
+                "lot_number",
+                "site",
+                "vaccine"
+            ],
+            "properties": {
+                "administered_at": {
+                    "type": "string"
+                },
+                "dose_number": {
+                    "type": "integer",
+                    "maximum": 20,
+                    "minimum": 1
+                },
+                "lot_number": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "site": {
+                    "type": "string",
+                    "enum": [
+                        "left_arm",
+                        "right_arm",
+                        "left_thigh",
+                        "right_thigh",
+                        "oral",
+                        "intranasal",
+                        "other"
+                    ]
+                },
+                "vaccine": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "vaccine_name": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "main.RecordLabResultsPayload": {
+            "type": "object",
+            "required": [
+                "results"
+            ],
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "maxItems": 30,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/main.LabResultPayload"
+                    }
+                }
+            }
+        },
+        "main.RecordVitalsPayload": {
+            "type": "object",
+            "properties": {
+                "appointment_id": {
+                    "type": "string"
+                },
+                "diastolic": {
+                    "type": "integer",
+                    "maximum": 200,
+                    "minimum": 20
+                },
+                "height": {
+                    "type": "number"
+                },
+                "height_unit": {
+                    "type": "string",
+                    "enum": [
+                        "cm",
+                        "in"
+                    ]
+                },
+                "pulse": {
+                    "type": "integer",
+                    "maximum": 300,
+                    "minimum": 20
+                },
+                "recorded_at": {
+                    "type": "string"
+                },
+                "spo2": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 50
+                },
+                "systolic": {
+                    "type": "integer",
+                    "maximum": 300,
+                    "minimum": 40
+                },
+                "temperature": {
+                    "type": "number"
+                },
+                "temperature_unit": {
+                    "type": "string",
+                    "enum": [
+                        "C",
+                        "F"
+                    ]
+                },
+                "weight": {
+                    "type": "number"
+                },
+                "weight_unit": {
+                    "type": "string",
+                    "enum": [
+                        "kg",
+                        "lb"
+                    ]
+                }
+            }
+        },
+        "main.RegisterERArrivalPayload": {
+            "type": "object",
+            "required": [
+                "acuity",
+                "chief_complaint"
+            ],
+            "properties": {
+                "acuity": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                },
+                "chief_complaint": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "patient_id": {
+                    "description": "PatientID or PatientMRN link the arrival to a registered patient.\nUnidentified arrivals are registered by name and linked later.",
+                    "type": "string"
+                },
+                "patient_mrn": {
+                    "type": "string",
+                    "maxLength": 40
+                },
+                "patient_name": {
+                    "type": "string",
+                    "maxLength": 200
+                }
+            }
+        },
+        "main.RegisterUserPayload": {
+            "type": "object",
+            "required": [
+                "email",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 3
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.RemitClaimPayload": {
+            "type": "object",
+            "properties": {
+                "denial_reason": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "paid_amount": {
+                    "description": "PaidAmount is in minor units; zero records a denial",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "reference": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.RequestShiftSwapPayload": {
+            "type": "object",
+            "required": [
+                "with_user_id"
+            ],
+            "properties": {
+                "counterpart_shift_id": {
+                    "description": "CounterpartShiftID is the colleague's shift taken in exchange, if any",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "with_user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.RetriageERVisitPayload": {
+            "type": "object",
+            "properties": {
+                "acuity": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                },
+                "chief_complaint": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "patient_mrn": {
+                    "type": "string",
+                    "maxLength": 40
+                },
+                "patient_name": {
+                    "type": "string",
+                    "maxLength": 200
+                }
+            }
+        },
+        "main.ReturnBloodUnitPayload": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "reusable": {
+                    "description": "Reusable puts the unit back into stock, if it has not expired;\notherwise it is discarded",
+                    "type": "boolean"
+                }
+            }
+        },
+        "main.RosterAssignmentPayload": {
+            "type": "object",
+            "required": [
+                "template_id",
+                "user_ids"
+            ],
+            "properties": {
+                "days": {
+                    "description": "Days limits the shift to some weekdays, every day by default",
+                    "type": "array",
+                    "maxItems": 7,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "staff_per_shift": {
+                    "description": "StaffPerShift defaults to 1",
+                    "type": "integer",
+                    "maximum": 50,
+                    "minimum": 0
+                },
+                "template_id": {
+                    "type": "string"
+                },
+                "user_ids": {
+                    "description": "UserIDs are the staff who take turns on the shift",
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.RosterPlan": {
+            "type": "object",
+            "properties": {
+                "gaps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/roster.Gap"
+                    }
+                },
+                "shifts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.RosterShift"
+                    }
+                }
+            }
+        },
+        "main.ScheduleSurgicalCasePayload": {
+            "type": "object",
+            "required": [
+                "expected_minutes",
+                "procedure",
+                "scheduled_start",
+                "theatre_id"
+            ],
+            "properties": {
+                "anesthetist_id": {
+                    "type": "string"
+                },
+                "equipment_ids": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expected_minutes": {
+                    "type": "integer",
+                    "maximum": 1440,
+                    "minimum": 1
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "nurse_ids": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "patient_mrn": {
+                    "type": "string",
+                    "maxLength": 40
+                },
+                "procedure": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "scheduled_start": {
+                    "type": "string"
+                },
+                "surgeon_id": {
+                    "description": "SurgeonID defaults to the doctor booking the case, or to the current\nsurgeon when rescheduling",
+                    "type": "string"
+                },
+                "theatre_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ScheduledDosePayload": {
+            "type": "object",
+            "required": [
+                "vaccine_name"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "catch_up_until_days": {
+                    "type": "integer"
+                },
+                "due_age_days": {
+                    "type": "integer",
+                    "maximum": 36500,
+                    "minimum": 0
+                },
+                "grace_days": {
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 0
+                },
+                "min_interval_days": {
+                    "type": "integer",
+                    "maximum": 36500,
+                    "minimum": 0
+                },
+                "vaccine_name": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "main.SetBedStatusPayload": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "enum": [
+                        "available",
+                        "cleaning",
+                        "out_of_service"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.BedStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "main.SetClaimDiagnosesPayload": {
+            "type": "object",
+            "required": [
+                "diagnosis_codes"
+            ],
+            "properties": {
+                "diagnosis_codes": {
+                    "type": "array",
+                    "maxItems": 12,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.SetDiscountPayload": {
+            "type": "object",
+            "properties": {
+                "discount": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "main.SetERWaitTargetPayload": {
+            "type": "object",
+            "required": [
+                "max_wait_minutes"
+            ],
+            "properties": {
+                "max_wait_minutes": {
+                    "type": "integer",
+                    "maximum": 1440,
+                    "minimum": 0
+                }
+            }
+        },
+        "main.SetEncounterDiagnosesPayload": {
+            "type": "object",
+            "required": [
+                "secondary"
+            ],
+            "properties": {
+                "primary": {
+                    "type": "string",
+                    "maxLength": 8
+                },
+                "secondary": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.SetPricePayload": {
+            "type": "object",
+            "required": [
+                "description"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "tax_rate": {
+                    "description": "TaxRate is in basis points: 1500 is 15%",
+                    "type": "integer",
+                    "maximum": 10000,
+                    "minimum": 0
+                },
+                "unit_price": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "main.SetUserRolePayload": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "main.SetVitalThresholdPayload": {
+            "type": "object",
+            "properties": {
+                "high": {
+                    "type": "number"
+                },
+                "low": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.SurgicalBookingPayload": {
+            "type": "object",
+            "required": [
+                "expected_minutes",
+                "procedure",
+                "scheduled_start",
+                "theatre_id"
+            ],
+            "properties": {
+                "anesthetist_id": {
+                    "type": "string"
+                },
+                "equipment_ids": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expected_minutes": {
+                    "type": "integer",
+                    "maximum": 1440,
+                    "minimum": 1
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "nurse_ids": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "procedure": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "scheduled_start": {
+                    "type": "string"
+                },
+                "surgeon_id": {
+                    "description": "SurgeonID defaults to the doctor booking the case, or to the current\nsurgeon when rescheduling",
+                    "type": "string"
+                },
+                "theatre_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.TheatreList": {
+            "type": "object",
+            "properties": {
+                "cases": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.SurgicalCase"
+                    }
+                },
+                "theatre": {
+                    "$ref": "#/definitions/store.Theatre"
+                }
+            }
+        },
+        "main.TransferPatientPayload": {
+            "type": "object",
+            "required": [
+                "bed_id"
+            ],
+            "properties": {
+                "bed_id": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 2000
+                }
+            }
+        },
+        "main.UpdateDischargeSummaryPayload": {
+            "type": "object",
+            "required": [
+                "admission_reason"
+            ],
+            "properties": {
+                "admission_reason": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "discharge_medications": {
+                    "type": "array",
+                    "maxItems": 30,
+                    "items": {
+                        "$ref": "#/definitions/main.PrescriptionItemPayload"
+                    }
+                },
+                "follow_up_instructions": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "hospital_course": {
+                    "type": "string",
+                    "maxLength": 20000
+                },
+                "procedures": {
+                    "type": "string",
+                    "maxLength": 5000
+                }
+            }
+        },
+        "main.UpdateEncounterNotePayload": {
+            "type": "object",
+            "properties": {
+                "assessment": {
+                    "type": "string",
+                    "maxLength": 20000
+                },
+                "objective": {
+                    "type": "string",
+                    "maxLength": 20000
+                },
+                "plan": {
+                    "type": "string",
+                    "maxLength": 20000
+                },
+                "subjective": {
+                    "type": "string",
+                    "maxLength": 20000
+                }
+            }
+        },
+        "main.UpgradeDependentPayload": {
+            "type": "object",
+            "required": [
+                "email",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "main.UserWithToken": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "dependent_ids": {
+                    "description": "DependentIDs are the patients the user is a guardian of",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "role": {
+                    "$ref": "#/definitions/store.Role"
+                },
+                "role_id": {
+                    "type": "integer"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.VitalAlert": {
+            "type": "object",
+            "properties": {
+                "breach": {
+                    "type": "string"
+                },
+                "high": {
+                    "type": "number"
+                },
+                "low": {
+                    "type": "number"
+                },
+                "type": {
+                    "$ref": "#/definitions/store.VitalType"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.VitalPoint": {
+            "type": "object",
+            "properties": {
+                "alert": {
+                    "type": "string"
+                },
+                "t": {
+                    "type": "string"
+                },
+                "v": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.VitalSeries": {
+            "type": "object",
+            "properties": {
+                "high": {
+                    "type": "number"
+                },
+                "low": {
+                    "type": "number"
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.VitalPoint"
+                    }
+                },
+                "type": {
+                    "$ref": "#/definitions/store.VitalType"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.VitalsResponse": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.VitalAlert"
+                    }
+                },
+                "appointment_id": {
+                    "type": "string"
+                },
+                "bmi": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "diastolic": {
+                    "type": "integer"
+                },
+                "height": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "pulse": {
+                    "type": "integer"
+                },
+                "recorded_at": {
+                    "type": "string"
+                },
+                "recorded_by": {
+                    "type": "string"
+                },
+                "spo2": {
+                    "type": "integer"
+                },
+                "systolic": {
+                    "type": "integer"
+                },
+                "temperature": {
+                    "type": "number"
+                },
+                "weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.VoidInvoicePayload": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "roster.Gap": {
+            "type": "object",
+            "properties": {
+                "day": {
+                    "type": "string"
+                },
+                "missing": {
+                    "type": "integer"
+                },
+                "template_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.ADTEvent": {
+            "type": "object",
+            "properties": {
+                "admission_id": {
+                    "type": "string"
+                },
+                "from_bed_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "performed_by": {
+                    "type": "string"
+                },
+                "to_bed_id": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/store.ADTEventType"
+                }
+            }
+        },
+        "store.ADTEventType": {
+            "type": "string",
+            "enum": [
+                "admit",
+                "transfer",
+                "discharge"
+            ],
+            "x-enum-varnames": [
+                "ADTAdmit",
+                "ADTTransfer",
+                "ADTDischarge"
+            ]
+        },
+        "store.Admission": {
+            "type": "object",
+            "properties": {
+                "admitted_at": {
+                    "type": "string"
+                },
+                "attending_doctor_id": {
+                    "type": "string"
+                },
+                "bed_id": {
+                    "type": "string"
+                },
+                "discharged_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.ADTEvent"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.AdmissionStatus"
+                }
+            }
+        },
+        "store.AdmissionStatus": {
+            "type": "string",
+            "enum": [
+                "admitted",
+                "discharged"
+            ],
+            "x-enum-varnames": [
+                "AdmissionAdmitted",
+                "AdmissionDischarged"
+            ]
+        },
+        "store.Allergy": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "reaction": {
+                    "type": "string"
+                },
+                "recorded_by": {
+                    "type": "string"
+                },
+                "severity": {
+                    "$ref": "#/definitions/store.AllergySeverity"
+                },
+                "substance": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/store.AllergyType"
+                }
+            }
+        },
+        "store.AllergySeverity": {
+            "type": "string",
+            "enum": [
+                "mild",
+                "moderate",
+                "severe",
+                "unknown"
+            ],
+            "x-enum-varnames": [
+                "AllergySeverityMild",
+                "AllergySeverityModerate",
+                "AllergySeveritySevere",
+                "AllergySeverityUnknown"
+            ]
+        },
+        "store.AllergyType": {
+            "type": "string",
+            "enum": [
+                "allergy",
+                "intolerance"
+            ],
+            "x-enum-varnames": [
+                "AllergyTypeAllergy",
+                "AllergyTypeIntolerance"
+            ]
+        },
+        "store.Appointment": {
+            "type": "object",
+            "properties": {
+                "appointment_time": {
+                    "type": "string"
+                },
+                "consultation_mode": {
+                    "$ref": "#/definitions/store.ConsultationMode"
+                },
+                "doctor_first_name": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "fee_amount": {
+                    "description": "FeeAmount and FeeCurrency are copied from the doctor's fee in effect at\nbooking time; nil when the doctor had no fee configured.",
+                    "type": "integer"
+                },
+                "fee_currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "patient_email": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "patient_mrn": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.AppointmentStatus"
+                },
+                "visit_type": {
+                    "$ref": "#/definitions/store.VisitType"
+                }
+            }
+        },
+        "store.AppointmentStatus": {
+            "type": "string",
+            "enum": [
+                "scheduled",
+                "completed",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "AppointmentScheduled",
+                "AppointmentCompleted",
+                "AppointmentCancelled"
+            ]
+        },
+        "store.Availability": {
+            "type": "object",
+            "properties": {
+                "available_day": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "ends_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "starts_from": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.Bed": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "occupant": {
+                    "$ref": "#/definitions/store.BedOccupant"
+                },
+                "room_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.BedStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.BedBoard": {
+            "type": "object",
+            "properties": {
+                "totals": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "wards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Ward"
+                    }
+                }
+            }
+        },
+        "store.BedOccupant": {
+            "type": "object",
+            "properties": {
+                "admission_id": {
+                    "type": "string"
+                },
+                "admitted_at": {
+                    "type": "string"
+                },
+                "mrn": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.BedStatus": {
+            "type": "string",
+            "enum": [
+                "available",
+                "occupied",
+                "cleaning",
+                "out_of_service"
+            ],
+            "x-enum-varnames": [
+                "BedAvailable",
+                "BedOccupied",
+                "BedCleaning",
+                "BedOutOfService"
+            ]
+        },
+        "store.BloodComponent": {
+            "type": "string",
+            "enum": [
+                "whole_blood",
+                "red_cells",
+                "platelets",
+                "plasma",
+                "cryoprecipitate"
+            ],
+            "x-enum-varnames": [
+                "BloodWholeBlood",
+                "BloodRedCells",
+                "BloodPlatelets",
+                "BloodPlasma",
+                "BloodCryoprecipitate"
+            ]
+        },
+        "store.BloodDonation": {
+            "type": "object",
+            "properties": {
+                "collected_by": {
+                    "type": "string"
+                },
+                "donated_at": {
+                    "type": "string"
+                },
+                "donor_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "units": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.BloodUnit"
+                    }
+                },
+                "volume_ml": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.BloodDonor": {
+            "type": "object",
+            "properties": {
+                "blood_group": {
+                    "$ref": "#/definitions/store.BloodGroup"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "deferral_reason": {
+                    "type": "string"
+                },
+                "deferred_until": {
+                    "type": "string"
+                },
+                "donations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.BloodDonation"
+                    }
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_donated_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.BloodGroup": {
+            "type": "string",
+            "enum": [
+                "A+",
+                "A-",
+                "B+",
+                "B-",
+                "AB+",
+                "AB-",
+                "O+",
+                "O-"
+            ],
+            "x-enum-varnames": [
+                "BloodGroupAPos",
+                "BloodGroupANeg",
+                "BloodGroupBPos",
+                "BloodGroupBNeg",
+                "BloodGroupABPos",
+                "BloodGroupABNeg",
+                "BloodGroupOPos",
+                "BloodGroupONeg"
+            ]
+        },
+        "store.BloodIssue": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "issued_at": {
+                    "type": "string"
+                },
+                "issued_by": {
+                    "type": "string"
+                },
+                "issued_to": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "return_reason": {
+                    "type": "string"
+                },
+                "returned_at": {
+                    "type": "string"
+                },
+                "returned_by": {
+                    "type": "string"
+                },
+                "reusable": {
+                    "type": "boolean"
+                },
+                "unit_id": {
+                    "type": "string"
+                },
+                "unit_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.BloodStock": {
+            "type": "object",
+            "properties": {
+                "blood_group": {
+                    "$ref": "#/definitions/store.BloodGroup"
+                },
+                "component": {
+                    "$ref": "#/definitions/store.BloodComponent"
+                },
+                "earliest_expiry": {
+                    "type": "string"
+                },
+                "units": {
+                    "type": "integer"
+                },
+                "volume_ml": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.BloodUnit": {
+            "type": "object",
+            "properties": {
+                "blood_group": {
+                    "$ref": "#/definitions/store.BloodGroup"
+                },
+                "collected_on": {
+                    "type": "string"
+                },
+                "component": {
+                    "$ref": "#/definitions/store.BloodComponent"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "discard_reason": {
+                    "type": "string"
+                },
+                "donation_id": {
+                    "type": "string"
+                },
+                "expired": {
+                    "type": "boolean"
+                },
+                "expires_on": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "received_by": {
+                    "type": "string"
+                },
+                "reserved_for": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.BloodUnitStatus"
+                },
+                "unit_number": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "volume_ml": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.BloodUnitStatus": {
+            "type": "string",
+            "enum": [
+                "available",
+                "reserved",
+                "issued",
+                "discarded"
+            ],
+            "x-enum-varnames": [
+                "BloodUnitAvailable",
+                "BloodUnitReserved",
+                "BloodUnitIssued",
+                "BloodUnitDiscarded"
+            ]
+        },
+        "store.ChargeKind": {
+            "type": "string",
+            "enum": [
+                "consultation",
+                "procedure",
+                "lab_test",
+                "drug",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "ChargeConsultation",
+                "ChargeProcedure",
+                "ChargeLabTest",
+                "ChargeDrug",
+                "ChargeOther"
+            ]
+        },
+        "store.Claim": {
+            "type": "object",
+            "properties": {
+                "adjudicated_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "diagnosis_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "group_number": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invoice_id": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.ClaimLine"
+                    }
+                },
+                "member_id": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "paid_amount": {
+                    "type": "integer"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "payer": {
+                    "type": "string"
+                },
+                "plan_name": {
+                    "type": "string"
+                },
+                "policy_id": {
+                    "type": "string"
+                },
+                "policy_number": {
+                    "type": "string"
+                },
+                "remittances": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.ClaimRemittance"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/store.ClaimStatus"
+                },
+                "submitted_at": {
+                    "type": "string"
+                },
+                "total_amount": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.ClaimLine": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Amount is billed for the line, tax included",
+                    "type": "integer"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "invoice_item_id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/store.ChargeKind"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "service_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.ClaimRemittance": {
+            "type": "object",
+            "properties": {
+                "claim_id": {
+                    "type": "string"
+                },
+                "denial_reason": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "paid_amount": {
+                    "type": "integer"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "recorded_by": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "remitted_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.ClaimStatus": {
+            "type": "string",
+            "enum": [
+                "draft",
+                "submitted",
+                "approved",
+                "partially_paid",
+                "denied"
+            ],
+            "x-enum-varnames": [
+                "ClaimDraft",
+                "ClaimSubmitted",
+                "ClaimApproved",
+                "ClaimPartiallyPaid",
+                "ClaimDenied"
+            ]
+        },
+        "store.ConsultationMode": {
+            "type": "string",
+            "enum": [
+                "in_person",
+                "online"
+            ],
+            "x-enum-varnames": [
+                "ConsultationModeInPerson",
+                "ConsultationModeOnline"
+            ]
+        },
+        "store.CrossmatchRequest": {
+            "type": "object",
+            "properties": {
+                "blood_group": {
+                    "$ref": "#/definitions/store.BloodGroup"
+                },
+                "closed_at": {
+                    "type": "string"
+                },
+                "closed_by": {
+                    "type": "string"
+                },
+                "component": {
+                    "$ref": "#/definitions/store.BloodComponent"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "indication": {
+                    "type": "string"
+                },
+                "issues": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.BloodIssue"
+                    }
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "requested_by": {
+                    "type": "string"
+                },
+                "requested_by_name": {
+                    "type": "string"
+                },
+                "required_by": {
+                    "type": "string"
+                },
+                "reserved_units": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.BloodUnit"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/store.CrossmatchStatus"
+                },
+                "tests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.CrossmatchTest"
+                    }
+                },
+                "units_requested": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "urgency": {
+                    "$ref": "#/definitions/store.CrossmatchUrgency"
+                }
+            }
+        },
+        "store.CrossmatchResult": {
+            "type": "string",
+            "enum": [
+                "compatible",
+                "incompatible"
+            ],
+            "x-enum-varnames": [
+                "CrossmatchCompatible",
+                "CrossmatchIncompatible"
+            ]
+        },
+        "store.CrossmatchStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "ready",
+                "closed"
+            ],
+            "x-enum-varnames": [
+                "CrossmatchPending",
+                "CrossmatchReady",
+                "CrossmatchClosed"
+            ]
+        },
+        "store.CrossmatchTest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "result": {
+                    "$ref": "#/definitions/store.CrossmatchResult"
+                },
+                "tested_at": {
+                    "type": "string"
+                },
+                "tested_by": {
+                    "type": "string"
+                },
+                "unit_id": {
+                    "type": "string"
+                },
+                "unit_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.CrossmatchUrgency": {
+            "type": "string",
+            "enum": [
+                "routine",
+                "urgent",
+                "emergency"
+            ],
+            "x-enum-varnames": [
+                "CrossmatchRoutine",
+                "CrossmatchUrgent",
+                "CrossmatchEmergency"
+            ]
+        },
+        "store.DiagnosisCount": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "department": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "encounters": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.DiagnosisRank": {
+            "type": "string",
+            "enum": [
+                "primary",
+                "secondary"
+            ],
+            "x-enum-varnames": [
+                "DiagnosisPrimary",
+                "DiagnosisSecondary"
+            ]
+        },
+        "store.DischargeSummary": {
+            "type": "object",
+            "properties": {
+                "admission_id": {
+                    "type": "string"
+                },
+                "admission_reason": {
+                    "type": "string"
+                },
+                "author_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "discharge_medications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.PrescriptionItem"
+                    }
+                },
+                "emailed_at": {
+                    "type": "string"
+                },
+                "finalized_at": {
+                    "type": "string"
+                },
+                "follow_up_appointment_id": {
+                    "type": "string"
+                },
+                "follow_up_instructions": {
+                    "type": "string"
+                },
+                "hospital_course": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "procedures": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.DischargeSummaryStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.DischargeSummaryStatus": {
+            "type": "string",
+            "enum": [
+                "draft",
+                "final"
+            ],
+            "x-enum-varnames": [
+                "DischargeSummaryDraft",
+                "DischargeSummaryFinal"
+            ]
+        },
+        "store.Dispensation": {
+            "type": "object",
+            "properties": {
+                "dispensed_at": {
+                    "type": "string"
+                },
+                "dispensed_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.DispensationLine"
+                    }
+                },
+                "low_stock": {
+                    "description": "LowStock lists the dispensed drugs left at or below their reorder\nlevel; only set on the response to a dispensation",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Drug"
+                    }
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "prescription_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.DispensationLine": {
+            "type": "object",
+            "properties": {
+                "batches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.DispensedBatch"
+                    }
+                },
+                "drug_id": {
+                    "type": "string"
+                },
+                "drug_name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.DispensedBatch": {
+            "type": "object",
+            "properties": {
+                "batch_id": {
+                    "type": "string"
+                },
+                "expiry_date": {
+                    "type": "string"
+                },
+                "lot_number": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.Doctor": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "age": {
+                    "description": "Computed from DateOfBirth, 0 when unknown",
+                    "type": "integer"
+                },
+                "availability": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "blood_group": {
+                    "$ref": "#/definitions/store.BloodGroup"
+                },
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "designation": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "fees": {
+                    "description": "Fees in effect today",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.DoctorFee"
+                    }
+                },
+                "firstname": {
+                    "type": "string"
+                },
+                "gender": {
+                    "$ref": "#/definitions/store.Gender"
+                },
+                "lastname": {
+                    "type": "string"
+                },
+                "license_number": {
+                    "type": "string"
+                },
+                "marital_status": {
+                    "$ref": "#/definitions/store.MaritalStatus"
+                },
+                "photo_thumbnail_url": {
+                    "type": "string"
+                },
+                "photo_url": {
+                    "type": "string"
+                },
+                "postal_code": {
+                    "type": "string"
+                },
+                "qualification": {
+                    "type": "string"
+                },
+                "specialization": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.DoctorDocument": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/store.DoctorDocumentKind"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "uploaded_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.DoctorDocumentKind": {
+            "type": "string",
+            "enum": [
+                "license",
+                "degree",
+                "certificate",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "DoctorDocumentLicense",
+                "DoctorDocumentDegree",
+                "DoctorDocumentCertificate",
+                "DoctorDocumentOther"
+            ]
+        },
+        "store.DoctorFee": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "consultation_mode": {
+                    "$ref": "#/definitions/store.ConsultationMode"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "effective_from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "visit_type": {
+                    "$ref": "#/definitions/store.VisitType"
+                }
+            }
+        },
+        "store.DoseState": {
+            "type": "string",
+            "enum": [
+                "completed",
+                "upcoming",
+                "due",
+                "overdue",
+                "missed"
+            ],
+            "x-enum-varnames": [
+                "DoseCompleted",
+                "DoseUpcoming",
+                "DoseDue",
+                "DoseOverdue",
+                "DoseMissed"
+            ]
+        },
+        "store.DoseStatus": {
+            "type": "object",
+            "properties": {
+                "administered_at": {
+                    "type": "string"
+                },
+                "dose_number": {
+                    "type": "integer"
+                },
+                "due_date": {
+                    "type": "string"
+                },
+                "immunization_id": {
+                    "type": "string"
+                },
+                "overdue_date": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.DoseState"
+                },
+                "vaccine": {
+                    "type": "string"
+                },
+                "vaccine_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.Drug": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "batches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.DrugBatch"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "form": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "on_hand": {
+                    "type": "integer"
+                },
+                "reorder_level": {
+                    "type": "integer"
+                },
+                "strength": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.DrugBatch": {
+            "type": "object",
+            "properties": {
+                "drug_id": {
+                    "type": "string"
+                },
+                "expired": {
+                    "type": "boolean"
+                },
+                "expiry_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lot_number": {
+                    "type": "string"
+                },
+                "quantity_on_hand": {
+                    "type": "integer"
+                },
+                "quantity_received": {
+                    "type": "integer"
+                },
+                "received_at": {
+                    "type": "string"
+                },
+                "received_by": {
+                    "type": "string"
+                },
+                "supplier": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.ERBoard": {
+            "type": "object",
+            "properties": {
+                "breaches": {
+                    "type": "integer"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "in_treatment": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.ERVisit"
+                    }
+                },
+                "waiting": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.ERVisit"
+                    }
+                }
+            }
+        },
+        "store.ERDisposition": {
+            "type": "string",
+            "enum": [
+                "discharged",
+                "admitted",
+                "transferred",
+                "left_without_being_seen",
+                "deceased"
+            ],
+            "x-enum-varnames": [
+                "ERDischarged",
+                "ERAdmitted",
+                "ERTransferred",
+                "ERLeftWithoutBeingSeen",
+                "ERDeceased"
+            ]
+        },
+        "store.ERVisit": {
+            "type": "object",
+            "properties": {
+                "acuity": {
+                    "type": "integer"
+                },
+                "arrived_at": {
+                    "type": "string"
+                },
+                "breach_alerted_at": {
+                    "type": "string"
+                },
+                "breached": {
+                    "description": "Breached is set while a waiting patient is over the target wait",
+                    "type": "boolean"
+                },
+                "chief_complaint": {
+                    "type": "string"
+                },
+                "departed_at": {
+                    "type": "string"
+                },
+                "disposition": {
+                    "$ref": "#/definitions/store.ERDisposition"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_wait_minutes": {
+                    "type": "integer"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "patient_name": {
+                    "type": "string"
+                },
+                "seen_at": {
+                    "type": "string"
+                },
+                "seen_by": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.ERVisitStatus"
+                },
+                "triaged_by": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "wait_minutes": {
+                    "description": "WaitMinutes runs from arrival until the patient is seen",
+                    "type": "integer"
+                }
+            }
+        },
+        "store.ERVisitStatus": {
+            "type": "string",
+            "enum": [
+                "waiting",
+                "in_treatment",
+                "departed"
+            ],
+            "x-enum-varnames": [
+                "ERWaiting",
+                "ERInTreatment",
+                "ERDeparted"
+            ]
+        },
+        "store.ERWaitTarget": {
+            "type": "object",
+            "properties": {
+                "acuity": {
+                    "type": "integer"
+                },
+                "max_wait_minutes": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "updated_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.EmergencyContact": {
+            "type": "object",
+            "required": [
+                "name",
+                "phone",
+                "relationship"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "relationship": {
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "store.Encounter": {
+            "type": "object",
+            "properties": {
+                "addenda": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.EncounterAddendum"
+                    }
+                },
+                "appointment_id": {
+                    "type": "string"
+                },
+                "assessment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "diagnoses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.EncounterDiagnosis"
+                    }
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "objective": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "plan": {
+                    "type": "string"
+                },
+                "signed_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.EncounterStatus"
+                },
+                "subjective": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.EncounterAddendum": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "encounter_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.EncounterDiagnosis": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "rank": {
+                    "$ref": "#/definitions/store.DiagnosisRank"
+                }
+            }
+        },
+        "store.EncounterStatus": {
+            "type": "string",
+            "enum": [
+                "draft",
+                "signed"
+            ],
+            "x-enum-varnames": [
+                "EncounterDraft",
+                "EncounterSigned"
+            ]
+        },
+        "store.ExpiringBatch": {
+            "type": "object",
+            "properties": {
+                "drug_id": {
+                    "type": "string"
+                },
+                "drug_name": {
+                    "type": "string"
+                },
+                "expired": {
+                    "type": "boolean"
+                },
+                "expiry_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lot_number": {
+                    "type": "string"
+                },
+                "quantity_on_hand": {
+                    "type": "integer"
+                },
+                "quantity_received": {
+                    "type": "integer"
+                },
+                "received_at": {
+                    "type": "string"
+                },
+                "received_by": {
+                    "type": "string"
+                },
+                "strength": {
+                    "type": "string"
+                },
+                "supplier": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.Gender": {
+            "type": "string",
+            "enum": [
+                "male",
+                "female",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "GenderMale",
+                "GenderFemale",
+                "GenderOther"
+            ]
+        },
+        "store.Guardian": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "relationship": {
+                    "$ref": "#/definitions/store.GuardianRelationship"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.GuardianRelationship": {
+            "type": "string",
+            "enum": [
+                "parent",
+                "legal_guardian",
+                "spouse",
+                "child",
+                "sibling",
+                "caregiver",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "GuardianParent",
+                "GuardianLegalGuardian",
+                "GuardianSpouse",
+                "GuardianChild",
+                "GuardianSibling",
+                "GuardianCaregiver",
+                "GuardianOther"
+            ]
+        },
+        "store.ICD10Code": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.IdentifierType": {
+            "type": "string",
+            "enum": [
+                "national_id",
+                "passport",
+                "insurance_member_id",
+                "driver_license",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "IdentifierNationalID",
+                "IdentifierPassport",
+                "IdentifierInsuranceMemberID",
+                "IdentifierDriverLicense",
+                "IdentifierOther"
+            ]
+        },
+        "store.Immunization": {
+            "type": "object",
+            "properties": {
+                "administered_at": {
+                    "type": "string"
+                },
+                "administered_by": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dose_number": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lot_number": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "site": {
+                    "$ref": "#/definitions/store.ImmunizationSite"
+                },
+                "vaccine": {
+                    "type": "string"
+                },
+                "vaccine_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.ImmunizationSite": {
+            "type": "string",
+            "enum": [
+                "left_arm",
+                "right_arm",
+                "left_thigh",
+                "right_thigh",
+                "oral",
+                "intranasal",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "ImmunizationSiteLeftArm",
+                "ImmunizationSiteRightArm",
+                "ImmunizationSiteLeftThigh",
+                "ImmunizationSiteRightThigh",
+                "ImmunizationSiteOral",
+                "ImmunizationSiteIntranasal",
+                "ImmunizationSiteOther"
+            ]
+        },
+        "store.InsurancePolicy": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "group_number": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_primary": {
+                    "type": "boolean"
+                },
+                "member_id": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "payer": {
+                    "type": "string"
+                },
+                "plan_name": {
+                    "type": "string"
+                },
+                "policy_number": {
+                    "type": "string"
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_to": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.Invoice": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issued_at": {
+                    "type": "string"
+                },
+                "issued_by": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.InvoiceItem"
+                    }
+                },
+                "number": {
+                    "type": "string"
+                },
+                "paid": {
+                    "type": "integer"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Payment"
+                    }
+                },
+                "refunded": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.InvoiceStatus"
+                },
+                "subtotal": {
+                    "type": "integer"
+                },
+                "tax": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "void_reason": {
+                    "type": "string"
+                },
+                "voided_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.InvoiceItem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invoice_id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/store.ChargeKind"
+                },
+                "net": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "tax": {
+                    "type": "integer"
+                },
+                "tax_rate": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.InvoiceStatus": {
+            "type": "string",
+            "enum": [
+                "draft",
+                "issued",
+                "void"
+            ],
+            "x-enum-varnames": [
+                "InvoiceDraft",
+                "InvoiceIssued",
+                "InvoiceVoid"
+            ]
+        },
+        "store.LabFlag": {
+            "type": "string",
+            "enum": [
+                "normal",
+                "low",
+                "high",
+                "critical_low",
+                "critical_high"
+            ],
+            "x-enum-varnames": [
+                "LabFlagNormal",
+                "LabFlagLow",
+                "LabFlagHigh",
+                "LabFlagCriticalLow",
+                "LabFlagCriticalHigh"
+            ]
+        },
+        "store.LabOrder": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "encounter_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "ordered_by": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "released_at": {
+                    "type": "string"
+                },
+                "resulted_at": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.LabResult"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/store.LabOrderStatus"
+                }
+            }
+        },
+        "store.LabOrderStatus": {
+            "type": "string",
+            "enum": [
+                "ordered",
+                "resulted",
+                "released"
+            ],
+            "x-enum-varnames": [
+                "LabOrderOrdered",
+                "LabOrderResulted",
+                "LabOrderReleased"
+            ]
+        },
+        "store.LabReferenceRange": {
+            "type": "object",
+            "properties": {
+                "age_max": {
+                    "type": "integer"
+                },
+                "age_min": {
+                    "type": "integer"
+                },
+                "critical_high": {
+                    "type": "number"
+                },
+                "critical_low": {
+                    "type": "number"
+                },
+                "high": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "low": {
+                    "type": "number"
+                },
+                "sex": {
+                    "$ref": "#/definitions/store.Gender"
+                }
+            }
+        },
+        "store.LabResult": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "entered_at": {
+                    "type": "string"
+                },
+                "entered_by": {
+                    "type": "string"
+                },
+                "flag": {
+                    "$ref": "#/definitions/store.LabFlag"
+                },
+                "high": {
+                    "type": "number"
+                },
+                "low": {
+                    "type": "number"
+                },
+                "test_code": {
+                    "type": "string"
+                },
+                "test_name": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "store.LabTest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "ranges": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.LabReferenceRange"
+                    }
+                },
+                "specimen": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.MaritalStatus": {
+            "type": "string",
+            "enum": [
+                "single",
+                "married",
+                "divorced",
+                "widowed",
+                "separated"
+            ],
+            "x-enum-varnames": [
+                "MaritalStatusSingle",
+                "MaritalStatusMarried",
+                "MaritalStatusDivorced",
+                "MaritalStatusWidowed",
+                "MaritalStatusSeparated"
+            ]
+        },
+        "store.Patient": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "age": {
+                    "description": "Computed from DateOfBirth",
+                    "type": "integer"
+                },
+                "blood_group": {
+                    "description": "Empty when unknown",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/store.BloodGroup"
+                        }
+                    ]
+                },
+                "city": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "dependent": {
+                    "description": "No login of its own, managed by guardians",
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "emergency_contacts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.EmergencyContact"
+                    }
+                },
+                "firstname": {
+                    "type": "string"
+                },
+                "identifiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.PatientIdentifier"
+                    }
+                },
+                "insurance": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.InsurancePolicy"
+                    }
+                },
+                "lastname": {
+                    "type": "string"
+                },
+                "mrn": {
+                    "type": "string"
+                },
+                "overdue_vaccines": {
+                    "description": "Set when a single profile is fetched",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.DoseStatus"
+                    }
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "postal_code": {
+                    "type": "string"
+                },
+                "sex": {
+                    "$ref": "#/definitions/store.Gender"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "upgrade_pending": {
+                    "description": "Invited to a login of their own, not activated yet",
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.PatientBalance": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "invoices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Invoice"
+                    }
+                },
+                "outstanding": {
+                    "type": "integer"
+                },
+                "patient_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.PatientDocument": {
+            "type": "object",
+            "properties": {
+                "appointment_id": {
+                    "type": "string"
+                },
+                "category": {
+                    "$ref": "#/definitions/store.PatientDocumentCategory"
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "encounter_id": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "scanned_by": {
+                    "type": "string"
+                },
+                "size_bytes": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "uploaded_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.PatientDocumentCategory": {
+            "type": "string",
+            "enum": [
+                "lab_report",
+                "imaging",
+                "referral_letter",
+                "discharge_summary",
+                "prescription",
+                "consent_form",
+                "insurance",
+                "identity",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "PatientDocumentLabReport",
+                "PatientDocumentImaging",
+                "PatientDocumentReferralLetter",
+                "PatientDocumentDischargeSummary",
+                "PatientDocumentPrescription",
+                "PatientDocumentConsentForm",
+                "PatientDocumentInsurance",
+                "PatientDocumentIdentity",
+                "PatientDocumentOther"
+            ]
+        },
+        "store.PatientIdentifier": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issuer": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/store.IdentifierType"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.Payment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "failure": {
+                    "type": "string"
+                },
+                "gateway_ref": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invoice_id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/store.PaymentKind"
+                },
+                "method": {
+                    "$ref": "#/definitions/store.PaymentMethod"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "refund_of": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.PaymentStatus"
+                }
+            }
+        },
+        "store.PaymentKind": {
+            "type": "string",
+            "enum": [
+                "payment",
+                "refund"
+            ],
+            "x-enum-varnames": [
+                "PaymentKindPayment",
+                "PaymentKindRefund"
+            ]
+        },
+        "store.PaymentMethod": {
+            "type": "string",
+            "enum": [
+                "cash",
+                "card",
+                "bank_transfer",
+                "mobile_wallet",
+                "insurance"
+            ],
+            "x-enum-varnames": [
+                "PaymentCash",
+                "PaymentCard",
+                "PaymentBankTransfer",
+                "PaymentMobileWallet",
+                "PaymentInsurance"
+            ]
+        },
+        "store.PaymentStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "succeeded",
+                "failed"
             ],
+            "x-enum-varnames": [
+                "PaymentPending",
+                "PaymentSucceeded",
+                "PaymentFailed"
+            ]
+        },
+        "store.Prescription": {
+            "type": "object",
             "properties": {
-                "appointment_time": {
+                "cancellation_reason": {
+                    "type": "string"
+                },
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "cancelled_by": {
                     "type": "string"
                 },
                 "doctor_id": {
                     "type": "string"
                 },
+                "doctor_license_number": {
+                    "type": "string"
+                },
+                "encounter_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issued_at": {
+                    "type": "string"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.PrescriptionItem"
+                    }
+                },
+                "overrides": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.PrescriptionOverride"
+                    }
+                },
                 "patient_id": {
                     "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.PrescriptionStatus"
                 }
             }
         },
-        "main.CreateAvailabilityPayload": {
+        "store.PrescriptionItem": {
             "type": "object",
             "properties": {
-                "available_day": {
+                "dose": {
                     "type": "string"
                 },
-                "doctor_id": {
+                "drug": {
                     "type": "string"
                 },
-                "ends_at": {
+                "duration": {
                     "type": "string"
                 },
-                "starts_from": {
+                "frequency": {
+                    "type": "string"
+                },
+                "instructions": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "string"
+                },
+                "strength": {
                     "type": "string"
                 }
             }
         },
-        "main.CreateDoctorPayload": {
+        "store.PrescriptionOverride": {
             "type": "object",
-            "required": [
-                "address",
-                "age",
-                "blood_group",
-                "city",
-                "country",
-                "designation",
-                "email",
-                "firstname",
-                "gender",
-                "lastname",
-                "license_number",
-                "marital_status",
-                "password",
-                "postal_code",
-                "qualification",
-                "specialization",
-                "state",
-                "username"
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "drug": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "overridden_by": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string"
+                },
+                "with": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.PrescriptionStatus": {
+            "type": "string",
+            "enum": [
+                "issued",
+                "cancelled"
             ],
+            "x-enum-varnames": [
+                "PrescriptionIssued",
+                "PrescriptionCancelled"
+            ]
+        },
+        "store.Price": {
+            "type": "object",
             "properties": {
-                "address": {
+                "code": {
                     "type": "string"
                 },
-                "age": {
+                "description": {
                     "type": "string"
                 },
-                "blood_group": {
+                "kind": {
+                    "$ref": "#/definitions/store.ChargeKind"
+                },
+                "tax_rate": {
+                    "type": "integer"
+                },
+                "unit_price": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.Referral": {
+            "type": "object",
+            "properties": {
+                "appointment_id": {
                     "type": "string"
                 },
-                "city": {
+                "appointment_time": {
                     "type": "string"
                 },
-                "country": {
+                "closed_at": {
                     "type": "string"
                 },
-                "designation": {
+                "closed_by": {
                     "type": "string"
                 },
-                "email": {
-                    "type": "string",
-                    "maxLength": 255
+                "created_at": {
+                    "type": "string"
                 },
-                "firstname": {
-                    "type": "string",
-                    "maxLength": 100
+                "department": {
+                    "type": "string"
                 },
-                "gender": {
+                "encounter_id": {
                     "type": "string"
                 },
-                "lastname": {
-                    "type": "string",
-                    "maxLength": 100
+                "id": {
+                    "type": "string"
                 },
-                "license_number": {
+                "notes": {
+                    "type": "string"
+                },
+                "patient_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "referring_doctor_id": {
+                    "type": "string"
+                },
+                "referring_doctor_name": {
+                    "type": "string"
+                },
+                "responded_at": {
+                    "type": "string"
+                },
+                "response_note": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/store.ReferralStatus"
+                },
+                "to_doctor_id": {
+                    "type": "string"
+                },
+                "to_doctor_name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "urgency": {
+                    "$ref": "#/definitions/store.ReferralUrgency"
+                }
+            }
+        },
+        "store.ReferralStatus": {
+            "type": "string",
+            "enum": [
+                "sent",
+                "accepted",
+                "declined",
+                "scheduled",
+                "closed"
+            ],
+            "x-enum-varnames": [
+                "ReferralSent",
+                "ReferralAccepted",
+                "ReferralDeclined",
+                "ReferralScheduled",
+                "ReferralClosed"
+            ]
+        },
+        "store.ReferralUrgency": {
+            "type": "string",
+            "enum": [
+                "routine",
+                "urgent",
+                "emergency"
+            ],
+            "x-enum-varnames": [
+                "ReferralRoutine",
+                "ReferralUrgent",
+                "ReferralEmergency"
+            ]
+        },
+        "store.Role": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.Room": {
+            "type": "object",
+            "properties": {
+                "beds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Bed"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "ward_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.RosterShift": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "department": {
+                    "type": "string"
+                },
+                "email": {
                     "type": "string"
                 },
-                "marital_status": {
+                "ends_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "on_call": {
+                    "type": "boolean"
+                },
+                "role": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "maxLength": 72,
-                    "minLength": 3
+                "shift_date": {
+                    "type": "string"
                 },
-                "postal_code": {
+                "starts_at": {
                     "type": "string"
                 },
-                "qualification": {
+                "template_id": {
                     "type": "string"
                 },
-                "specialization": {
+                "template_name": {
                     "type": "string"
                 },
-                "state": {
+                "user_id": {
                     "type": "string"
                 },
                 "username": {
-                    "type": "string",
-                    "maxLength": 100
+                    "type": "string"
                 }
             }
         },
-        "main.CreateUserTokenPayload": {
+        "store.ScheduleConflict": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "maxLength": 255
+                "case_id": {
+                    "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "maxLength": 72,
-                    "minLength": 3
+                "end": {
+                    "type": "string"
+                },
+                "procedure": {
+                    "type": "string"
+                },
+                "resource": {
+                    "type": "string"
+                },
+                "resource_id": {
+                    "type": "string"
+                },
+                "resource_name": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
                 }
             }
         },
-        "main.RegisterUserPayload": {
+        "store.ScheduledDose": {
             "type": "object",
-            "required": [
-                "email",
-                "password",
-                "username"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "maxLength": 255
+                "active": {
+                    "type": "boolean"
                 },
-                "password": {
-                    "type": "string",
-                    "maxLength": 72,
-                    "minLength": 3
+                "catch_up_until_days": {
+                    "type": "integer"
                 },
-                "username": {
-                    "type": "string",
-                    "maxLength": 100
+                "dose_number": {
+                    "type": "integer"
+                },
+                "due_age_days": {
+                    "type": "integer"
+                },
+                "grace_days": {
+                    "type": "integer"
+                },
+                "min_interval_days": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "vaccine": {
+                    "type": "string"
+                },
+                "vaccine_name": {
+                    "type": "string"
                 }
             }
         },
-        "main.UserWithToken": {
+        "store.ShiftSwap": {
             "type": "object",
             "properties": {
+                "counterpart_shift_id": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
-                "email": {
+                "decided_at": {
+                    "type": "string"
+                },
+                "decided_by": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "is_active": {
-                    "type": "boolean"
+                "reason": {
+                    "type": "string"
                 },
-                "role": {
-                    "$ref": "#/definitions/store.Role"
+                "requested_by": {
+                    "type": "string"
                 },
-                "role_id": {
-                    "type": "integer"
+                "responded_at": {
+                    "type": "string"
                 },
-                "token": {
+                "shift_id": {
                     "type": "string"
                 },
-                "username": {
+                "status": {
+                    "$ref": "#/definitions/store.ShiftSwapStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "with_user_id": {
                     "type": "string"
                 }
             }
         },
-        "store.Appointment": {
+        "store.ShiftSwapStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "accepted",
+                "approved",
+                "declined",
+                "rejected",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "ShiftSwapPending",
+                "ShiftSwapAccepted",
+                "ShiftSwapApproved",
+                "ShiftSwapDeclined",
+                "ShiftSwapRejected",
+                "ShiftSwapCancelled"
+            ]
+        },
+        "store.ShiftTemplate": {
             "type": "object",
             "properties": {
-                "appointment_time": {
+                "created_at": {
                     "type": "string"
                 },
-                "doctor_first_name": {
+                "department": {
                     "type": "string"
                 },
-                "doctor_id": {
+                "ends_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "patient_email": {
+                "name": {
                     "type": "string"
                 },
-                "patient_id": {
+                "on_call": {
+                    "type": "boolean"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "starts_at": {
                     "type": "string"
                 }
             }
         },
-        "store.Availability": {
+        "store.StockAlerts": {
             "type": "object",
             "properties": {
-                "available_day": {
-                    "type": "string"
-                },
-                "doctor_id": {
-                    "type": "string"
-                },
-                "ends_at": {
-                    "type": "string"
+                "expiring": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.ExpiringBatch"
+                    }
                 },
-                "starts_from": {
-                    "type": "string"
+                "low_stock": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Drug"
+                    }
                 }
             }
         },
-        "store.Doctor": {
+        "store.SurgicalCase": {
             "type": "object",
             "properties": {
-                "address": {
+                "booked_by": {
                     "type": "string"
                 },
-                "age": {
+                "cancel_reason": {
                     "type": "string"
                 },
-                "availability": {
+                "cancelled_at": {
+                    "type": "string"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "equipment": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/store.TheatreEquipment"
                     }
                 },
-                "blood_group": {
-                    "type": "string"
+                "expected_minutes": {
+                    "type": "integer"
                 },
-                "city": {
+                "id": {
                     "type": "string"
                 },
-                "country": {
+                "notes": {
                     "type": "string"
                 },
-                "designation": {
+                "patient_id": {
                     "type": "string"
                 },
-                "email": {
+                "patient_name": {
                     "type": "string"
                 },
-                "firstname": {
+                "procedure": {
                     "type": "string"
                 },
-                "gender": {
+                "scheduled_end": {
                     "type": "string"
                 },
-                "lastname": {
+                "scheduled_start": {
                     "type": "string"
                 },
-                "license_number": {
+                "started_at": {
                     "type": "string"
                 },
-                "marital_status": {
-                    "type": "string"
+                "status": {
+                    "$ref": "#/definitions/store.SurgicalCaseStatus"
                 },
-                "postal_code": {
-                    "type": "string"
+                "team": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.SurgicalTeamMember"
+                    }
                 },
-                "qualification": {
+                "theatre_id": {
                     "type": "string"
                 },
-                "specialization": {
+                "theatre_name": {
                     "type": "string"
                 },
-                "state": {
+                "updated_at": {
                     "type": "string"
+                }
+            }
+        },
+        "store.SurgicalCaseStatus": {
+            "type": "string",
+            "enum": [
+                "scheduled",
+                "in_progress",
+                "completed",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "SurgicalCaseScheduled",
+                "SurgicalCaseInProgress",
+                "SurgicalCaseCompleted",
+                "SurgicalCaseCancelled"
+            ]
+        },
+        "store.SurgicalRole": {
+            "type": "string",
+            "enum": [
+                "surgeon",
+                "anesthetist",
+                "nurse"
+            ],
+            "x-enum-varnames": [
+                "SurgicalRoleSurgeon",
+                "SurgicalRoleAnesthetist",
+                "SurgicalRoleNurse"
+            ]
+        },
+        "store.SurgicalTeamMember": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "$ref": "#/definitions/store.SurgicalRole"
                 },
                 "user_id": {
                     "type": "string"
@@ -812,29 +16715,111 @@ const docTemplate = `{
                 }
             }
         },
-        "store.Role": {
+        "store.Theatre": {
             "type": "object",
             "properties": {
-                "description": {
+                "created_at": {
                     "type": "string"
                 },
                 "id": {
-                    "type": "integer"
+                    "type": "string"
                 },
-                "level": {
-                    "type": "integer"
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.TheatreEquipment": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
                 },
                 "name": {
                     "type": "string"
                 }
             }
         },
+        "store.TimelineEvent": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "type": "string"
+                },
+                "encounter_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/store.TimelineEventType"
+                }
+            }
+        },
+        "store.TimelineEventType": {
+            "type": "string",
+            "enum": [
+                "appointment",
+                "encounter",
+                "diagnosis",
+                "prescription",
+                "lab_order",
+                "vitals",
+                "document",
+                "admission"
+            ],
+            "x-enum-varnames": [
+                "TimelineAppointment",
+                "TimelineEncounter",
+                "TimelineDiagnosis",
+                "TimelinePrescription",
+                "TimelineLabOrder",
+                "TimelineVitals",
+                "TimelineDocument",
+                "TimelineAdmission"
+            ]
+        },
+        "store.TimelinePage": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.TimelineEvent"
+                    }
+                },
+                "next_cursor": {
+                    "description": "NextCursor fetches the next, older page; empty on the last page",
+                    "type": "string"
+                }
+            }
+        },
         "store.User": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
+                "dependent_ids": {
+                    "description": "DependentIDs are the patients the user is a guardian of",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "email": {
                     "type": "string"
                 },
@@ -865,6 +16850,87 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "store.VisitType": {
+            "type": "string",
+            "enum": [
+                "new_patient",
+                "follow_up"
+            ],
+            "x-enum-varnames": [
+                "VisitTypeNewPatient",
+                "VisitTypeFollowUp"
+            ]
+        },
+        "store.VitalThreshold": {
+            "type": "object",
+            "properties": {
+                "high": {
+                    "type": "number"
+                },
+                "low": {
+                    "type": "number"
+                },
+                "type": {
+                    "$ref": "#/definitions/store.VitalType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "updated_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.VitalType": {
+            "type": "string",
+            "enum": [
+                "systolic",
+                "diastolic",
+                "pulse",
+                "temperature",
+                "spo2",
+                "weight",
+                "height",
+                "bmi"
+            ],
+            "x-enum-varnames": [
+                "VitalSystolic",
+                "VitalDiastolic",
+                "VitalPulse",
+                "VitalTemperature",
+                "VitalSpO2",
+                "VitalWeight",
+                "VitalHeight",
+                "VitalBMI"
+            ]
+        },
+        "store.Ward": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rooms": {
+                    "description": "Rooms and Totals are filled in on the bed board",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Room"
+                    }
+                },
+                "totals": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
         }
     },
     "securityDefinitions": {
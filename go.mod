@@ -5,25 +5,26 @@ go 1.22.0
 require (
 	github.com/go-chi/chi/v5 v5.1.0
 	github.com/lib/pq v1.10.9
+	go.uber.org/zap v1.27.0
+	gopkg.in/mail.v2 v2.3.1
 )
 
 require (
 	github.com/sendgrid/rest v2.6.9+incompatible // indirect
 	github.com/swaggo/files/v2 v2.0.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
-	go.uber.org/zap v1.27.0 // indirect
 	gopkg.in/alexcesaro/quotedprintable.v3 v3.0.0-20150716171945-2caba252f4dc // indirect
-	gopkg.in/mail.v2 v2.3.1 // indirect
 )
 
 require (
 	github.com/KyleBanks/depth v1.2.1 // indirect
-	github.com/gabriel-vasile/mimetype v1.4.3 // indirect
+	github.com/gabriel-vasile/mimetype v1.4.3
 	github.com/go-chi/cors v1.2.1
 	github.com/go-openapi/jsonpointer v0.21.0 // indirect
 	github.com/go-openapi/jsonreference v0.21.0 // indirect
 	github.com/go-openapi/spec v0.21.0 // indirect
 	github.com/go-openapi/swag v0.23.0 // indirect
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/go-playground/locales v0.14.1 // indirect
 	github.com/go-playground/universal-translator v0.18.1 // indirect
 	github.com/go-playground/validator/v10 v10.22.0
@@ -34,8 +35,9 @@ require (
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/sendgrid/sendgrid-go v3.15.0+incompatible
 	github.com/swaggo/http-swagger/v2 v2.0.2
-	github.com/swaggo/swag v1.16.3 // indirect
-	golang.org/x/crypto v0.26.0 // indirect
+	github.com/swaggo/swag v1.16.3
+	golang.org/x/crypto v0.26.0
+	golang.org/x/image v0.18.0
 	golang.org/x/net v0.28.0 // indirect
 	golang.org/x/sys v0.23.0 // indirect
 	golang.org/x/text v0.17.0 // indirect
@@ -0,0 +1,28 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store keeps uploaded files. Keys are slash separated paths such as
+// "public/doctors/<id>/photo.jpg". Keys under PublicPrefix may be served
+// without authentication; everything else must go through a handler that
+// checks access first. URL returns an empty string for an empty key.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+const (
+	PublicPrefix  = "public/"
+	PrivatePrefix = "private/"
+)
@@ -0,0 +1,136 @@
+// Package bloodbank holds the ABO and RhD rules for which donor blood
+// groups a blood component may be transfused to a recipient from.
+package bloodbank
+
+import "strings"
+
+type Component string
+
+const (
+	WholeBlood      Component = "whole_blood"
+	RedCells        Component = "red_cells"
+	Platelets       Component = "platelets"
+	Plasma          Component = "plasma"
+	Cryoprecipitate Component = "cryoprecipitate"
+)
+
+// Components lists every component in display order.
+var Components = []Component{WholeBlood, RedCells, Platelets, Plasma, Cryoprecipitate}
+
+// Groups lists the blood groups, O negative last so that it is offered
+// after the groups that are less in demand.
+var Groups = []string{"A+", "A-", "B+", "B-", "AB+", "AB-", "O+", "O-"}
+
+// DonationIntervalDays is the least time between two whole blood donations.
+const DonationIntervalDays = 56
+
+// MinDonorAge and MaxDonorAge bound the age at which someone may donate.
+const (
+	MinDonorAge = 18
+	MaxDonorAge = 65
+)
+
+// ShelfLifeDays returns how long a component keeps from collection.
+func ShelfLifeDays(c Component) int {
+	switch c {
+	case WholeBlood:
+		return 35
+	case RedCells:
+		return 42
+	case Platelets:
+		return 5
+	case Plasma, Cryoprecipitate:
+		return 365
+	default:
+		return 0
+	}
+}
+
+// split returns the ABO letters and whether the group is RhD positive.
+func split(group string) (string, bool, bool) {
+	if len(group) < 2 {
+		return "", false, false
+	}
+
+	abo, rh := group[:len(group)-1], group[len(group)-1]
+	switch abo {
+	case "A", "B", "AB", "O":
+	default:
+		return "", false, false
+	}
+
+	switch rh {
+	case '+':
+		return abo, true, true
+	case '-':
+		return abo, false, true
+	default:
+		return "", false, false
+	}
+}
+
+// carries reports whether blood of group abo carries every ABO antigen of
+// group other.
+func carries(abo, other string) bool {
+	for _, antigen := range other {
+		if antigen != 'O' && !strings.ContainsRune(abo, antigen) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compatible reports whether a unit of the component from a donor of one
+// group may be given to a recipient of the other:
+//
+//   - whole blood must be of the recipient's ABO group
+//   - red cells must carry no ABO antigen the recipient lacks
+//   - plasma and cryoprecipitate must carry no antibody against the
+//     recipient's antigens, so AB plasma suits everyone
+//   - platelets may be of any ABO group
+//
+// RhD negative recipients get RhD negative cells, whole blood and
+// platelets; RhD does not matter for plasma and cryoprecipitate.
+func Compatible(c Component, donor, recipient string) bool {
+	donorABO, donorPositive, ok := split(donor)
+	if !ok {
+		return false
+	}
+
+	recipientABO, recipientPositive, ok := split(recipient)
+	if !ok {
+		return false
+	}
+
+	rhOK := recipientPositive || !donorPositive
+
+	switch c {
+	case WholeBlood:
+		return donorABO == recipientABO && rhOK
+	case RedCells:
+		return carries(recipientABO, donorABO) && rhOK
+	case Platelets:
+		return rhOK
+	case Plasma, Cryoprecipitate:
+		return carries(donorABO, recipientABO)
+	default:
+		return false
+	}
+}
+
+// DonorGroups returns the groups a recipient may receive the component
+// from, their own group first and then in the order of Groups.
+func DonorGroups(c Component, recipient string) []string {
+	groups := []string{}
+	if Compatible(c, recipient, recipient) {
+		groups = append(groups, recipient)
+	}
+
+	for _, group := range Groups {
+		if group != recipient && Compatible(c, group, recipient) {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
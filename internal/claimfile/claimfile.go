@@ -0,0 +1,55 @@
+package claimfile
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+type Claim struct {
+	Number       string
+	Payer        string
+	PlanName     string
+	PolicyNumber string
+	MemberID     string
+	GroupNumber  string
+	PatientName  string
+	PatientMRN   string
+	PatientDOB   string
+	PatientSex   string
+	// DiagnosisCodes are ICD-10 codes, the principal diagnosis first
+	DiagnosisCodes []string
+	Currency       string
+	TotalAmount    int64
+	Lines          []Line
+}
+
+type Line struct {
+	Position    int
+	Kind        string
+	Code        string
+	Description string
+	Quantity    int
+	// Amount is in minor units, tax included
+	Amount      int64
+	ServiceDate string
+}
+
+// Format writes a batch of claims as a file for submission to payers.
+type Format interface {
+	Write(w io.Writer, provider string, created time.Time, claims []Claim) error
+	ContentType() string
+	Extension() string
+}
+
+// New returns the claim file format of the given name: "csv" or "json".
+func New(name string) (Format, error) {
+	switch name {
+	case "", "csv":
+		return CSV{}, nil
+	case "json":
+		return JSON{}, nil
+	default:
+		return nil, fmt.Errorf("unknown claim file format %q", name)
+	}
+}
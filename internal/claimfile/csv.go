@@ -0,0 +1,65 @@
+package claimfile
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV writes one row per claim line, repeating the claim's details on each
+// row. Diagnosis codes are separated by semicolons.
+type CSV struct{}
+
+var csvHeader = []string{
+	"provider", "claim_number", "payer", "plan_name", "policy_number", "member_id", "group_number",
+	"patient_name", "patient_mrn", "patient_dob", "patient_sex", "diagnosis_codes", "currency",
+	"claim_total", "line", "service_date", "kind", "code", "description", "quantity", "amount",
+}
+
+func (CSV) Write(w io.Writer, provider string, _ time.Time, claims []Claim) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, c := range claims {
+		for _, l := range c.Lines {
+			err := cw.Write([]string{
+				provider,
+				c.Number,
+				c.Payer,
+				c.PlanName,
+				c.PolicyNumber,
+				c.MemberID,
+				c.GroupNumber,
+				c.PatientName,
+				c.PatientMRN,
+				c.PatientDOB,
+				c.PatientSex,
+				strings.Join(c.DiagnosisCodes, ";"),
+				c.Currency,
+				strconv.FormatInt(c.TotalAmount, 10),
+				strconv.Itoa(l.Position + 1),
+				l.ServiceDate,
+				l.Kind,
+				l.Code,
+				l.Description,
+				strconv.Itoa(l.Quantity),
+				strconv.FormatInt(l.Amount, 10),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func (CSV) ContentType() string { return "text/csv" }
+
+func (CSV) Extension() string { return "csv" }
@@ -0,0 +1,93 @@
+package claimfile
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// JSON writes the batch as a single document with the claims nested.
+type JSON struct{}
+
+type jsonBatch struct {
+	Provider string      `json:"provider"`
+	Created  time.Time   `json:"created"`
+	Claims   []jsonClaim `json:"claims"`
+}
+
+type jsonClaim struct {
+	Number         string     `json:"claim_number"`
+	Payer          string     `json:"payer"`
+	PlanName       string     `json:"plan_name,omitempty"`
+	PolicyNumber   string     `json:"policy_number"`
+	MemberID       string     `json:"member_id,omitempty"`
+	GroupNumber    string     `json:"group_number,omitempty"`
+	Patient        jsonPerson `json:"patient"`
+	DiagnosisCodes []string   `json:"diagnosis_codes"`
+	Currency       string     `json:"currency"`
+	TotalAmount    int64      `json:"total_amount"`
+	Lines          []jsonLine `json:"lines"`
+}
+
+type jsonPerson struct {
+	Name string `json:"name"`
+	MRN  string `json:"mrn"`
+	DOB  string `json:"date_of_birth,omitempty"`
+	Sex  string `json:"sex,omitempty"`
+}
+
+type jsonLine struct {
+	Line        int    `json:"line"`
+	ServiceDate string `json:"service_date"`
+	Kind        string `json:"kind"`
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	Amount      int64  `json:"amount"`
+}
+
+func (JSON) Write(w io.Writer, provider string, created time.Time, claims []Claim) error {
+	batch := jsonBatch{
+		Provider: provider,
+		Created:  created,
+		Claims:   make([]jsonClaim, 0, len(claims)),
+	}
+
+	for _, c := range claims {
+		claim := jsonClaim{
+			Number:         c.Number,
+			Payer:          c.Payer,
+			PlanName:       c.PlanName,
+			PolicyNumber:   c.PolicyNumber,
+			MemberID:       c.MemberID,
+			GroupNumber:    c.GroupNumber,
+			Patient:        jsonPerson{Name: c.PatientName, MRN: c.PatientMRN, DOB: c.PatientDOB, Sex: c.PatientSex},
+			DiagnosisCodes: c.DiagnosisCodes,
+			Currency:       c.Currency,
+			TotalAmount:    c.TotalAmount,
+			Lines:          make([]jsonLine, 0, len(c.Lines)),
+		}
+
+		for _, l := range c.Lines {
+			claim.Lines = append(claim.Lines, jsonLine{
+				Line:        l.Position + 1,
+				ServiceDate: l.ServiceDate,
+				Kind:        l.Kind,
+				Code:        l.Code,
+				Description: l.Description,
+				Quantity:    l.Quantity,
+				Amount:      l.Amount,
+			})
+		}
+
+		batch.Claims = append(batch.Claims, claim)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(batch)
+}
+
+func (JSON) ContentType() string { return "application/json" }
+
+func (JSON) Extension() string { return "json" }
@@ -0,0 +1,45 @@
+package eligibility
+
+import (
+	"context"
+	"fmt"
+)
+
+type Request struct {
+	Payer        string
+	PolicyNumber string
+	MemberID     string
+	GroupNumber  string
+	// ValidFrom and ValidTo are the policy dates on file, YYYY-MM-DD or empty
+	ValidFrom string
+	ValidTo   string
+	// ServiceDate is the day care is given, YYYY-MM-DD
+	ServiceDate string
+}
+
+type Result struct {
+	Eligible bool   `json:"eligible"`
+	Reason   string `json:"reason,omitempty"`
+	// Checker names what answered, so a stub answer is never mistaken for
+	// the payer's
+	Checker string `json:"checker"`
+}
+
+// Checker asks a payer whether a policy covers care on a given day. An
+// error means the payer could not be asked; a policy that is not covered is
+// a Result with Eligible false.
+type Checker interface {
+	Check(ctx context.Context, req Request) (*Result, error)
+	Name() string
+}
+
+// New returns the checker of the given kind. Only "stub" is available; payer
+// integrations are added here behind the same interface.
+func New(kind string) (Checker, error) {
+	switch kind {
+	case "", "stub":
+		return Stub{}, nil
+	default:
+		return nil, fmt.Errorf("unknown eligibility checker %q", kind)
+	}
+}
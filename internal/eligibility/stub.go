@@ -0,0 +1,32 @@
+package eligibility
+
+import (
+	"context"
+	"strings"
+)
+
+// IneligiblePrefix marks policy numbers the stub always reports as not
+// covered.
+const IneligiblePrefix = "INELIGIBLE"
+
+// Stub answers from the policy dates on file without asking the payer. It is
+// meant for development and for payers without an integration.
+type Stub struct{}
+
+func (Stub) Check(_ context.Context, req Request) (*Result, error) {
+	result := &Result{Eligible: true, Checker: "stub"}
+
+	// dates are YYYY-MM-DD, so they compare as strings
+	switch {
+	case strings.HasPrefix(strings.ToUpper(req.PolicyNumber), IneligiblePrefix):
+		result.Eligible, result.Reason = false, "policy is not active"
+	case req.ValidFrom != "" && req.ServiceDate < req.ValidFrom:
+		result.Eligible, result.Reason = false, "policy starts on "+req.ValidFrom
+	case req.ValidTo != "" && req.ServiceDate > req.ValidTo:
+		result.Eligible, result.Reason = false, "policy ended on "+req.ValidTo
+	}
+
+	return result, nil
+}
+
+func (Stub) Name() string { return "stub" }
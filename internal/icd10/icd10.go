@@ -0,0 +1,86 @@
+// Package icd10 reads ICD-10 code lists.
+//
+// A list is a CSV file with a header row followed by code,description rows.
+// A small list of common codes is bundled with the server; a full release
+// can be imported from a file in the same format.
+package icd10
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+//go:embed data/icd10.csv
+var bundled []byte
+
+var codePattern = regexp.MustCompile(`^[A-Z][0-9][0-9A-Z](\.[0-9A-Z]{1,4})?$`)
+
+type Code struct {
+	Code        string
+	Description string
+}
+
+// Bundled returns the code list shipped with the server.
+func Bundled() ([]Code, error) {
+	return Parse(bytes.NewReader(bundled))
+}
+
+// Parse reads a code list. Codes are upper-cased and checked against the
+// ICD-10 format; a malformed row fails the whole list with its line number.
+func Parse(r io.Reader) ([]Code, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("icd10: empty code list")
+		}
+		return nil, err
+	}
+
+	if !strings.EqualFold(header[0], "code") || !strings.EqualFold(header[1], "description") {
+		return nil, fmt.Errorf("icd10: unexpected header %q", header)
+	}
+
+	seen := map[string]bool{}
+	codes := []Code{}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		code := strings.ToUpper(strings.TrimSpace(record[0]))
+		if !codePattern.MatchString(code) {
+			return nil, fmt.Errorf("icd10: line %d: invalid code %q", line, record[0])
+		}
+
+		description := strings.TrimSpace(record[1])
+		if description == "" {
+			return nil, fmt.Errorf("icd10: line %d: missing description for %s", line, code)
+		}
+
+		if seen[code] {
+			return nil, fmt.Errorf("icd10: line %d: duplicate code %s", line, code)
+		}
+		seen[code] = true
+
+		codes = append(codes, Code{Code: code, Description: description})
+	}
+
+	return codes, nil
+}
@@ -0,0 +1,65 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const thumbnailQuality = 85
+
+var ErrImageTooLarge = errors.New("image dimensions are too large")
+
+// maxPixels guards against decompression bombs: a small file that decodes
+// into a huge bitmap.
+const maxPixels = 40_000_000
+
+// Thumbnail decodes a JPEG, PNG, GIF or WebP image and returns a JPEG whose
+// longest side is at most maxSide pixels. Smaller images are not upscaled.
+func Thumbnail(data []byte, maxSide int) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := fit(src.Bounds().Dx(), src.Bounds().Dy(), maxSide)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	// JPEG has no alpha channel, so transparent areas are flattened onto white
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func fit(width, height, maxSide int) (int, int) {
+	if width <= maxSide && height <= maxSide {
+		return width, height
+	}
+
+	if width >= height {
+		return maxSide, max(1, height*maxSide/width)
+	}
+
+	return max(1, width*maxSide/height), maxSide
+}
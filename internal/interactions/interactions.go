@@ -0,0 +1,490 @@
+// Package interactions checks prescribed drugs against a patient's allergies
+// and other medication using a dataset bundled with the server.
+//
+// The dataset is three CSV files: drugs with their brand names and drug
+// classes, class names with the classes they cross-react with, and
+// interactions between drugs or classes. Drugs are recognised by name
+// anywhere in free text, so "Amoxil 500mg caps" resolves to amoxicillin.
+// The bundled data covers common drugs only and is a safety net, not a
+// replacement for a full clinical knowledge base.
+package interactions
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	//go:embed data/drugs.csv
+	bundledDrugs []byte
+	//go:embed data/classes.csv
+	bundledClasses []byte
+	//go:embed data/interactions.csv
+	bundledInteractions []byte
+)
+
+type Severity string
+
+const (
+	SeverityMinor           Severity = "minor"
+	SeverityModerate        Severity = "moderate"
+	SeverityMajor           Severity = "major"
+	SeverityContraindicated Severity = "contraindicated"
+)
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityMinor:
+		return 1
+	case SeverityModerate:
+		return 2
+	case SeverityMajor:
+		return 3
+	case SeverityContraindicated:
+		return 4
+	default:
+		return 0
+	}
+}
+
+type Kind string
+
+const (
+	KindAllergy     Kind = "allergy"
+	KindInteraction Kind = "interaction"
+)
+
+// Warning is a problem found with a prescription. Code identifies the
+// warning across checks of the same prescription so that a prescriber can
+// acknowledge it.
+type Warning struct {
+	Code     string   `json:"code"`
+	Kind     Kind     `json:"kind"`
+	Severity Severity `json:"severity"`
+	Drug     string   `json:"drug"`
+	With     string   `json:"with"`
+	Message  string   `json:"message"`
+}
+
+// Allergy is a recorded allergy or intolerance. Substance may name a drug, a
+// drug class or anything else, such as latex.
+type Allergy struct {
+	Substance   string
+	Intolerance bool
+}
+
+type drug struct {
+	name    string
+	display string
+	classes []string
+}
+
+type interaction struct {
+	a, b        string
+	severity    Severity
+	description string
+}
+
+// Dataset is a loaded drug dataset. It is safe for concurrent use.
+type Dataset struct {
+	drugs        map[string]*drug
+	terms        map[string]string // normalised name or alias to drug name
+	classes      map[string]bool
+	classAliases map[string]string
+	crossReacts  map[string][]string
+	interactions []interaction
+}
+
+// Bundled returns the dataset shipped with the server.
+func Bundled() (*Dataset, error) {
+	return Load(bytes.NewReader(bundledDrugs), bytes.NewReader(bundledClasses), bytes.NewReader(bundledInteractions))
+}
+
+// Load reads a dataset from its three CSV files.
+func Load(drugs, classes, interactions io.Reader) (*Dataset, error) {
+	d := &Dataset{
+		drugs:        map[string]*drug{},
+		terms:        map[string]string{},
+		classes:      map[string]bool{},
+		classAliases: map[string]string{},
+		crossReacts:  map[string][]string{},
+	}
+
+	err := readCSV(drugs, []string{"drug", "aliases", "classes"}, func(line int, record []string) error {
+		name := normalize(record[0])
+		if name == "" {
+			return errors.New("missing drug name")
+		}
+		if _, ok := d.terms[name]; ok {
+			return fmt.Errorf("duplicate drug %q", name)
+		}
+
+		dr := &drug{
+			name:    name,
+			display: strings.ToLower(strings.TrimSpace(record[0])),
+			classes: splitList(record[2]),
+		}
+		d.drugs[name] = dr
+		d.terms[name] = name
+
+		for _, alias := range splitList(record[1]) {
+			if _, ok := d.terms[alias]; ok {
+				return fmt.Errorf("duplicate alias %q", alias)
+			}
+			d.terms[alias] = name
+		}
+
+		for _, class := range dr.classes {
+			d.classes[class] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("interactions: drugs: %w", err)
+	}
+
+	err = readCSV(classes, []string{"class", "aliases", "cross_reactive"}, func(line int, record []string) error {
+		class := normalize(record[0])
+		if !d.classes[class] {
+			return fmt.Errorf("unknown class %q", class)
+		}
+
+		d.classAliases[class] = class
+		for _, alias := range splitList(record[1]) {
+			d.classAliases[alias] = class
+		}
+
+		for _, other := range splitList(record[2]) {
+			if !d.classes[other] {
+				return fmt.Errorf("unknown class %q", other)
+			}
+			d.crossReacts[class] = append(d.crossReacts[class], other)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("interactions: classes: %w", err)
+	}
+
+	err = readCSV(interactions, []string{"a", "b", "severity", "description"}, func(line int, record []string) error {
+		in := interaction{
+			a:           normalize(record[0]),
+			b:           normalize(record[1]),
+			severity:    Severity(strings.ToLower(strings.TrimSpace(record[2]))),
+			description: strings.TrimSpace(record[3]),
+		}
+
+		for _, subject := range []string{in.a, in.b} {
+			if d.drugs[subject] == nil && !d.classes[subject] {
+				return fmt.Errorf("unknown drug or class %q", subject)
+			}
+		}
+
+		if in.severity.rank() == 0 {
+			return fmt.Errorf("invalid severity %q", record[2])
+		}
+
+		d.interactions = append(d.interactions, in)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("interactions: interactions: %w", err)
+	}
+
+	return d, nil
+}
+
+func readCSV(r io.Reader, header []string, row func(line int, record []string) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(header)
+	reader.TrimLeadingSpace = true
+
+	first, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("empty file")
+		}
+		return err
+	}
+
+	for i, name := range header {
+		if !strings.EqualFold(strings.TrimSpace(first[i]), name) {
+			return fmt.Errorf("unexpected header %q", first)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if err := row(line, record); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// Resolve returns the drugs named in text, such as a prescription line.
+func (d *Dataset) Resolve(text string) []string {
+	padded := " " + normalize(text) + " "
+
+	seen := map[string]bool{}
+	names := []string{}
+	for term, name := range d.terms {
+		if !seen[name] && strings.Contains(padded, " "+term+" ") {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Check returns the warnings for prescribing the drugs to a patient who takes
+// the current medication and has the allergies. Drugs are free text. The
+// warnings are ordered from most to least severe.
+func (d *Dataset) Check(prescribed, current []string, allergies []Allergy) []Warning {
+	newDrugs := d.resolveAll(prescribed)
+	currentDrugs := d.resolveAll(current)
+
+	warnings := map[string]Warning{}
+	add := func(w Warning) {
+		if existing, ok := warnings[w.Code]; ok && existing.Severity.rank() >= w.Severity.rank() {
+			return
+		}
+		warnings[w.Code] = w
+	}
+
+	for _, allergy := range allergies {
+		for _, text := range prescribed {
+			for _, w := range d.checkAllergy(text, allergy) {
+				add(w)
+			}
+		}
+	}
+
+	others := append(append([]string{}, newDrugs...), currentDrugs...)
+	for i, a := range newDrugs {
+		for _, b := range others[i+1:] {
+			if a == b {
+				continue
+			}
+			for _, w := range d.checkPair(a, b) {
+				add(w)
+			}
+		}
+	}
+
+	result := make([]Warning, 0, len(warnings))
+	for _, w := range warnings {
+		result = append(result, w)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if ri, rj := result[i].Severity.rank(), result[j].Severity.rank(); ri != rj {
+			return ri > rj
+		}
+		return result[i].Code < result[j].Code
+	})
+
+	return result
+}
+
+func (d *Dataset) resolveAll(texts []string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, text := range texts {
+		for _, name := range d.Resolve(text) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+func (d *Dataset) checkAllergy(text string, allergy Allergy) []Warning {
+	substance := normalize(allergy.Substance)
+	if substance == "" {
+		return nil
+	}
+
+	direct, cross := SeverityContraindicated, SeverityMajor
+	kind := "allergy"
+	if allergy.Intolerance {
+		direct, cross = SeverityModerate, SeverityMinor
+		kind = "intolerance"
+	}
+
+	warning := func(drug string, severity Severity, message string) Warning {
+		return Warning{
+			Code:     fmt.Sprintf("allergy:%s:%s", codePart(substance), codePart(drug)),
+			Kind:     KindAllergy,
+			Severity: severity,
+			Drug:     d.display(drug),
+			With:     allergy.Substance,
+			Message:  message,
+		}
+	}
+
+	drugs := d.Resolve(text)
+	if len(drugs) == 0 {
+		// not a drug we know; fall back to the wording of the line
+		if strings.Contains(" "+normalize(text)+" ", " "+substance+" ") {
+			return []Warning{warning(normalize(text), direct, fmt.Sprintf("Recorded %s to %s.", kind, allergy.Substance))}
+		}
+		return nil
+	}
+
+	allergicDrugs := d.Resolve(substance)
+	class, isClass := d.classAliases[substance]
+	if !isClass && d.classes[substance] {
+		class, isClass = substance, true
+	}
+
+	warnings := []Warning{}
+	for _, name := range drugs {
+		dr := d.drugs[name]
+
+		switch {
+		case contains(allergicDrugs, name):
+			warnings = append(warnings, warning(name, direct, fmt.Sprintf("Recorded %s to %s.", kind, allergy.Substance)))
+		case isClass && contains(dr.classes, class):
+			warnings = append(warnings, warning(name, direct, fmt.Sprintf("Recorded %s to %s, and %s is one of them.", kind, allergy.Substance, d.display(name))))
+		default:
+			for _, allergicClass := range d.allergyClasses(allergicDrugs, class, isClass) {
+				if related := d.crossReactive(allergicClass, dr.classes); related != "" {
+					warnings = append(warnings, warning(name, cross, fmt.Sprintf("Recorded %s to %s; %s may cross-react with %s.", kind, allergy.Substance, related, allergicClass)))
+					break
+				}
+			}
+		}
+	}
+
+	return warnings
+}
+
+// allergyClasses returns the classes a recorded allergy belongs to.
+func (d *Dataset) allergyClasses(allergicDrugs []string, class string, isClass bool) []string {
+	if isClass {
+		return []string{class}
+	}
+
+	classes := []string{}
+	for _, name := range allergicDrugs {
+		classes = append(classes, d.drugs[name].classes...)
+	}
+
+	return classes
+}
+
+func (d *Dataset) crossReactive(allergicClass string, classes []string) string {
+	for _, related := range d.crossReacts[allergicClass] {
+		if contains(classes, related) {
+			return related
+		}
+	}
+
+	return ""
+}
+
+func (d *Dataset) checkPair(a, b string) []Warning {
+	if a > b {
+		a, b = b, a
+	}
+
+	var found *interaction
+	for i := range d.interactions {
+		in := &d.interactions[i]
+		if (d.matches(a, in.a) && d.matches(b, in.b)) || (d.matches(a, in.b) && d.matches(b, in.a)) {
+			if found == nil || in.severity.rank() > found.severity.rank() {
+				found = in
+			}
+		}
+	}
+
+	if found == nil {
+		return nil
+	}
+
+	return []Warning{{
+		Code:     fmt.Sprintf("interaction:%s:%s", codePart(a), codePart(b)),
+		Kind:     KindInteraction,
+		Severity: found.severity,
+		Drug:     d.display(a),
+		With:     d.display(b),
+		Message:  found.description,
+	}}
+}
+
+// display returns the drug's name as written in the dataset. Names that are
+// not in the dataset are returned as they are.
+func (d *Dataset) display(name string) string {
+	if dr, ok := d.drugs[name]; ok {
+		return dr.display
+	}
+
+	return name
+}
+
+// matches reports whether the drug is subject, or belongs to the class
+// subject.
+func (d *Dataset) matches(name, subject string) bool {
+	if name == subject {
+		return true
+	}
+
+	return contains(d.drugs[name].classes, subject)
+}
+
+func codePart(s string) string {
+	return strings.ReplaceAll(s, " ", "-")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, "|") {
+		if item = normalize(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// normalize lower-cases text and reduces everything but letters and digits
+// to single spaces, so that names match regardless of punctuation.
+func normalize(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(fields, " ")
+}
@@ -3,14 +3,25 @@ package mailer
 import "embed"
 
 const (
-	FromName            = "GopherSocial"
-	maxRetires          = 3
-	UserWelcomeTemplate = "user_invitation.tmpl"
+	FromName                     = "GopherSocial"
+	maxRetires                   = 3
+	UserWelcomeTemplate          = "user_invitation.tmpl"
+	LabCriticalTemplate          = "lab_critical_result.tmpl"
+	ImmunizationReminderTemplate = "immunization_reminder.tmpl"
+	DischargeSummaryTemplate     = "discharge_summary.tmpl"
+	ReferralTemplate             = "referral.tmpl"
 )
 
 //go:embed "templates"
 var FS embed.FS
 
+// Attachment is a file sent along with an email, such as a PDF.
+type Attachment struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
 type Client interface {
-	Send(templateFile, username, email string, data any, isSandbox bool) (int, error)
+	Send(templateFile, username, email string, data any, isSandbox bool, attachments ...Attachment) (int, error)
 }
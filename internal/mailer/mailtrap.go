@@ -3,6 +3,7 @@ package mailer
 import (
 	"bytes"
 	"errors"
+	"io"
 	"text/template"
 
 	gomail "gopkg.in/mail.v2"
@@ -24,7 +25,7 @@ func NewMailtrapClient(apiKey, fromEmail string) (mailtrapClient, error) {
 	}, nil
 }
 
-func (m mailtrapClient) Send (templateFile, username, email string, data any, isSandbox bool) (int, error) {
+func (m mailtrapClient) Send (templateFile, username, email string, data any, isSandbox bool, attachments ...Attachment) (int, error) {
 	tmpl, err := template.ParseFS(FS, "templates/"+templateFile)
 	if err != nil {
 		return -1, err
@@ -48,6 +49,17 @@ func (m mailtrapClient) Send (templateFile, username, email string, data any, is
 	message.SetHeader("Subject", subject.String())
 	message.SetBody("text/html", body.String())
 
+	for _, a := range attachments {
+		data := a.Data
+		message.Attach(a.FileName,
+			gomail.SetHeader(map[string][]string{"Content-Type": {a.ContentType}}),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(data)
+				return err
+			}),
+		)
+	}
+
 	dailer := gomail.NewDialer("smtp.gmail.com", 587,"md.hasibuzzaman001@gmail.com", "ftcr rwcc uxbw woil")
 	if err := dailer.DialAndSend(message); err != nil {
 		return -1, err
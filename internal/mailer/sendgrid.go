@@ -2,6 +2,7 @@ package mailer
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"text/template"
 	"time"
@@ -26,7 +27,7 @@ func NewSendgrid(apiKey, fromEmail string) *SendGridMailer {
 	}
 }
 
-func (m *SendGridMailer) Send(templateFile, username, email string, data any, isSandbox bool) (int, error) {
+func (m *SendGridMailer) Send(templateFile, username, email string, data any, isSandbox bool, attachments ...Attachment) (int, error) {
 	from := mail.NewEmail(FromName, m.fromEmail)
 	to := mail.NewEmail(username, email)
 
@@ -50,6 +51,15 @@ func (m *SendGridMailer) Send(templateFile, username, email string, data any, is
 
 	message := mail.NewSingleEmail(from, subject.String(), to, "", body.String())
 
+	for _, a := range attachments {
+		attachment := mail.NewAttachment()
+		attachment.SetContent(base64.StdEncoding.EncodeToString(a.Data))
+		attachment.SetType(a.ContentType)
+		attachment.SetFilename(a.FileName)
+		attachment.SetDisposition("attachment")
+		message.AddAttachment(attachment)
+	}
+
 	message.SetMailSettings(&mail.MailSettings{
 		SandboxMode: &mail.Setting{
 			Enable: &isSandbox,
@@ -0,0 +1,122 @@
+// Package mrn formats and checks medical record numbers.
+//
+// An MRN is built from an optional prefix, an optional year, a zero padded
+// sequence number and an optional Luhn check digit, for example
+// MRN-2024-000042-7. The sequence itself is owned by the database; this
+// package only turns sequence values into identifiers.
+package mrn
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrInvalidConfig = errors.New("mrn: invalid configuration")
+	ErrCheckDigit    = errors.New("mrn: check digit does not match")
+)
+
+type Config struct {
+	Prefix         string
+	Separator      string
+	IncludeYear    bool
+	SequenceDigits int
+	CheckDigit     bool
+}
+
+type Generator struct {
+	cfg Config
+}
+
+func New(cfg Config) (*Generator, error) {
+	if cfg.SequenceDigits < 1 || cfg.SequenceDigits > 18 {
+		return nil, fmt.Errorf("%w: sequence digits must be between 1 and 18", ErrInvalidConfig)
+	}
+
+	for _, r := range cfg.Prefix {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return nil, fmt.Errorf("%w: prefix must be ASCII letters and digits", ErrInvalidConfig)
+		}
+	}
+
+	if len(cfg.Separator) > 1 || strings.ContainsAny(cfg.Separator, "/?#%") {
+		return nil, fmt.Errorf("%w: separator must be a single URL safe character", ErrInvalidConfig)
+	}
+
+	return &Generator{cfg: cfg}, nil
+}
+
+// Format builds the MRN for a sequence value issued in the given year.
+// Sequences longer than the configured width are kept in full rather than
+// truncated, so distinct sequence values always give distinct MRNs.
+func (g *Generator) Format(year int, seq int64) string {
+	parts := make([]string, 0, 4)
+
+	if g.cfg.Prefix != "" {
+		parts = append(parts, g.cfg.Prefix)
+	}
+
+	if g.cfg.IncludeYear {
+		parts = append(parts, fmt.Sprintf("%04d", year))
+	}
+
+	parts = append(parts, fmt.Sprintf("%0*d", g.cfg.SequenceDigits, seq))
+
+	if g.cfg.CheckDigit {
+		parts = append(parts, strconv.Itoa(luhn(digits(strings.Join(parts, "")))))
+	}
+
+	return strings.Join(parts, g.cfg.Separator)
+}
+
+// Check validates the check digit of an MRN. It only looks at the digits, so
+// MRNs issued under an older prefix or separator still verify. It always
+// passes when check digits are disabled.
+func (g *Generator) Check(mrn string) error {
+	if !g.cfg.CheckDigit {
+		return nil
+	}
+
+	d := digits(mrn)
+	if len(d) < 2 {
+		return ErrCheckDigit
+	}
+
+	if luhn(d[:len(d)-1]) != int(d[len(d)-1]-'0') {
+		return ErrCheckDigit
+	}
+
+	return nil
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// luhn returns the Luhn check digit for a string of decimal digits.
+func luhn(d string) int {
+	sum := 0
+	double := true
+	for i := len(d) - 1; i >= 0; i-- {
+		n := int(d[i] - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+
+	return (10 - sum%10) % 10
+}
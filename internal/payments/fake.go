@@ -0,0 +1,32 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DeclinedToken is the token the fake gateway always declines.
+const DeclinedToken = "tok_declined"
+
+// Fake approves every charge and refund except those made with
+// DeclinedToken. It is meant for development and tests.
+type Fake struct{}
+
+func (*Fake) Charge(_ context.Context, charge Charge) (string, error) {
+	if charge.Token == DeclinedToken {
+		return "", ErrDeclined
+	}
+	return "fake_ch_" + uuid.NewString(), nil
+}
+
+func (*Fake) Refund(_ context.Context, chargeRef string, _ int64, _ string) (string, error) {
+	if !strings.HasPrefix(chargeRef, "fake_ch_") {
+		return "", fmt.Errorf("%w: unknown charge %q", ErrDeclined, chargeRef)
+	}
+	return "fake_re_" + uuid.NewString(), nil
+}
+
+func (*Fake) Name() string { return "fake" }
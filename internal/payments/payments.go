@@ -0,0 +1,41 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrDeclined is returned when the gateway refuses a charge or a refund.
+// Other errors mean the outcome is unknown.
+var ErrDeclined = errors.New("payment declined")
+
+type Charge struct {
+	// Amount is in minor units of Currency
+	Amount   int64
+	Currency string
+	// Token is the card or wallet token produced by the gateway's client
+	// side library; card details never reach this server
+	Token string
+	// Reference is passed to the gateway to match its records to ours
+	Reference string
+}
+
+// Gateway processes card and wallet payments. Charge and Refund return the
+// gateway's reference for the transaction.
+type Gateway interface {
+	Charge(ctx context.Context, charge Charge) (string, error)
+	Refund(ctx context.Context, chargeRef string, amount int64, currency string) (string, error)
+	Name() string
+}
+
+// New returns the gateway of the given kind. Only "fake" is available; a
+// real processor is added here behind the same interface.
+func New(kind string) (Gateway, error) {
+	switch kind {
+	case "", "fake":
+		return &Fake{}, nil
+	default:
+		return nil, fmt.Errorf("unknown payment gateway %q", kind)
+	}
+}
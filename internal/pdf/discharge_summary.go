@@ -0,0 +1,103 @@
+package pdf
+
+import (
+	"fmt"
+	"time"
+)
+
+type DischargeSummary struct {
+	ID          string
+	FinalizedAt time.Time
+
+	PatientName string
+	PatientMRN  string
+	PatientDOB  string
+	PatientSex  string
+
+	AdmittedAt   time.Time
+	DischargedAt time.Time
+	Ward         string
+	Bed          string
+
+	AdmissionReason      string
+	HospitalCourse       string
+	Procedures           string
+	Medications          []PrescriptionItem
+	FollowUpInstructions string
+
+	DoctorName           string
+	DoctorSpecialization string
+}
+
+// RenderDischargeSummary renders a final discharge summary. Empty sections
+// are printed as "None" so a missing section is never mistaken for a
+// truncated document.
+func RenderDischargeSummary(letterhead Letterhead, s DischargeSummary) ([]byte, error) {
+	d := New(letterhead, "Discharge Summary", s.FinalizedAt)
+	d.SetFooter(fmt.Sprintf("Discharge summary %s", s.ID))
+
+	d.Heading("Patient")
+	d.Fields(
+		[2]string{"Name", s.PatientName},
+		[2]string{"MRN", s.PatientMRN},
+		[2]string{"Date of birth", s.PatientDOB},
+		[2]string{"Sex", s.PatientSex},
+	)
+
+	d.Heading("Admission")
+	d.Fields(
+		[2]string{"Admitted", s.AdmittedAt.Format("2006-01-02 15:04 MST")},
+		[2]string{"Discharged", s.DischargedAt.Format("2006-01-02 15:04 MST")},
+		[2]string{"Ward", s.Ward},
+		[2]string{"Bed", s.Bed},
+	)
+
+	d.Heading("Reason for admission")
+	d.Paragraph(orNone(s.AdmissionReason))
+
+	d.Heading("Course in hospital")
+	d.Paragraph(orNone(s.HospitalCourse))
+
+	d.Heading("Procedures")
+	d.Paragraph(orNone(s.Procedures))
+
+	d.Heading("Discharge medication")
+	if len(s.Medications) == 0 {
+		d.Paragraph("None")
+	} else {
+		rows := make([][]string, 0, len(s.Medications))
+		for i, item := range s.Medications {
+			rows = append(rows, []string{
+				fmt.Sprintf("%d", i+1),
+				item.Drug + "\n" + item.Strength,
+				item.Dose,
+				item.Frequency,
+				item.Duration,
+				item.Instructions,
+			})
+		}
+		d.Table(
+			[]string{"#", "Drug", "Dose", "Frequency", "Duration", "Instructions"},
+			[]float64{4, 28, 14, 18, 12, 32},
+			rows,
+		)
+	}
+
+	d.Heading("Follow-up")
+	d.Paragraph(orNone(s.FollowUpInstructions))
+
+	d.Space(12)
+	d.Fields(
+		[2]string{"Attending doctor", s.DoctorName},
+		[2]string{"Specialization", s.DoctorSpecialization},
+	)
+
+	return d.Bytes()
+}
+
+func orNone(text string) string {
+	if text == "" {
+		return "None"
+	}
+	return text
+}
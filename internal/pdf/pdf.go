@@ -0,0 +1,246 @@
+// Package pdf renders the printable documents the hospital hands out, such
+// as prescriptions, on A4 pages under the hospital letterhead.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	lineHeight = 5.5
+	fontFamily = "Helvetica"
+)
+
+// Letterhead is printed at the top of every page.
+type Letterhead struct {
+	Name    string
+	Address string
+	Phone   string
+	Email   string
+}
+
+// Document is an A4 page flow with the letterhead, a title and a footer with
+// page numbers. Text is UTF-8 and is converted to the PDF core fonts' code
+// page; characters outside it are dropped.
+type Document struct {
+	f      *fpdf.Fpdf
+	tr     func(string) string
+	footer string
+}
+
+func New(letterhead Letterhead, title string, created time.Time) *Document {
+	f := fpdf.New("P", "mm", "A4", "")
+	f.SetMargins(18, 15, 18)
+	f.SetAutoPageBreak(true, 22)
+	f.SetTitle(title, true)
+	f.SetAuthor(letterhead.Name, true)
+	f.SetCreator(letterhead.Name, true)
+	f.SetCreationDate(created)
+	f.SetModificationDate(created)
+	f.AliasNbPages("")
+
+	d := &Document{
+		f:  f,
+		tr: f.UnicodeTranslatorFromDescriptor(""),
+	}
+
+	f.SetHeaderFunc(func() {
+		d.letterhead(letterhead)
+	})
+
+	f.SetFooterFunc(func() {
+		f.SetY(-18)
+		f.SetFont(fontFamily, "", 7.5)
+		f.SetTextColor(110, 110, 110)
+		if d.footer != "" {
+			f.MultiCell(0, 3.5, d.tr(d.footer), "", "L", false)
+		}
+		f.CellFormat(0, 4, fmt.Sprintf("Page %d of {nb}", f.PageNo()), "", 0, "R", false, 0, "")
+		f.SetTextColor(0, 0, 0)
+	})
+
+	f.AddPage()
+
+	f.SetFont(fontFamily, "B", 14)
+	f.CellFormat(0, 8, d.tr(title), "", 1, "C", false, 0, "")
+	f.Ln(2)
+
+	return d
+}
+
+func (d *Document) letterhead(l Letterhead) {
+	f := d.f
+
+	f.SetFont(fontFamily, "B", 16)
+	f.CellFormat(0, 7, d.tr(l.Name), "", 1, "L", false, 0, "")
+
+	f.SetFont(fontFamily, "", 9)
+	f.SetTextColor(80, 80, 80)
+	if l.Address != "" {
+		f.CellFormat(0, 4.5, d.tr(l.Address), "", 1, "L", false, 0, "")
+	}
+
+	contact := l.Phone
+	if l.Email != "" {
+		if contact != "" {
+			contact += "  |  "
+		}
+		contact += l.Email
+	}
+	if contact != "" {
+		f.CellFormat(0, 4.5, d.tr(contact), "", 1, "L", false, 0, "")
+	}
+	f.SetTextColor(0, 0, 0)
+
+	left, _, right, _ := f.GetMargins()
+	width, _ := f.GetPageSize()
+	f.SetDrawColor(40, 90, 160)
+	f.SetLineWidth(0.6)
+	f.Line(left, f.GetY()+1.5, width-right, f.GetY()+1.5)
+	f.SetLineWidth(0.2)
+	f.SetDrawColor(0, 0, 0)
+	f.Ln(5)
+}
+
+// SetFooter sets text printed above the page number on every page, such as
+// a verification code. It applies to pages finished after the call.
+func (d *Document) SetFooter(text string) {
+	d.footer = text
+}
+
+func (d *Document) Heading(text string) {
+	d.f.Ln(2)
+	d.f.SetFont(fontFamily, "B", 11)
+	d.f.CellFormat(0, 7, d.tr(text), "B", 1, "L", false, 0, "")
+	d.f.Ln(1.5)
+}
+
+// Fields prints label/value pairs in two columns.
+func (d *Document) Fields(pairs ...[2]string) {
+	f := d.f
+	left, _, right, _ := f.GetMargins()
+	width, _ := f.GetPageSize()
+	column := (width - left - right) / 2
+
+	for i, pair := range pairs {
+		if i%2 == 0 {
+			f.SetX(left)
+		}
+
+		f.SetFont(fontFamily, "B", 9.5)
+		label := d.tr(pair[0] + ": ")
+		labelWidth := f.GetStringWidth(label) + 1
+		f.CellFormat(labelWidth, lineHeight, label, "", 0, "L", false, 0, "")
+
+		f.SetFont(fontFamily, "", 9.5)
+		ln := 0
+		if i%2 == 1 || i == len(pairs)-1 {
+			ln = 1
+		}
+		f.CellFormat(column-labelWidth, lineHeight, d.tr(pair[1]), "", ln, "L", false, 0, "")
+	}
+}
+
+func (d *Document) Paragraph(text string) {
+	d.f.SetFont(fontFamily, "", 9.5)
+	d.f.MultiCell(0, lineHeight, d.tr(text), "", "L", false)
+}
+
+// Table prints a table with a shaded header row. widths are relative and are
+// scaled to the printable width; cell text wraps within its column.
+func (d *Document) Table(headers []string, widths []float64, rows [][]string) {
+	f := d.f
+	left, _, right, _ := f.GetMargins()
+	pageWidth, pageHeight := f.GetPageSize()
+	_, _, _, bottom := f.GetMargins()
+
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	scaled := make([]float64, len(widths))
+	for i, w := range widths {
+		scaled[i] = w / total * (pageWidth - left - right)
+	}
+
+	header := func() {
+		f.SetFont(fontFamily, "B", 9)
+		f.SetFillColor(230, 236, 245)
+		for i, h := range headers {
+			f.CellFormat(scaled[i], 7, d.tr(h), "1", 0, "L", true, 0, "")
+		}
+		f.Ln(-1)
+	}
+
+	header()
+	f.SetFont(fontFamily, "", 9)
+
+	for _, row := range rows {
+		lines := make([][]string, len(row))
+		height := 0.0
+		for i, cell := range row {
+			lines[i] = d.split(cell, scaled[i]-2)
+			if len(lines[i]) == 0 {
+				lines[i] = []string{""}
+			}
+			height = max(height, float64(len(lines[i]))*lineHeight)
+		}
+
+		if f.GetY()+height > pageHeight-bottom-22 {
+			f.AddPage()
+			header()
+			f.SetFont(fontFamily, "", 9)
+		}
+
+		x, y := f.GetX(), f.GetY()
+		for i := range row {
+			f.Rect(x, y, scaled[i], height, "D")
+			for j, line := range lines[i] {
+				f.SetXY(x+1, y+float64(j)*lineHeight)
+				f.CellFormat(scaled[i]-2, lineHeight, line, "", 0, "L", false, 0, "")
+			}
+			x += scaled[i]
+		}
+		f.SetXY(left, y+height)
+	}
+}
+
+// split wraps text to width. The text is converted to the font's code page
+// first and handed to SplitText one byte per rune, since SplitText indexes
+// the 256 entry width table of the core fonts by rune.
+func (d *Document) split(text string, width float64) []string {
+	encoded := []byte(d.tr(text))
+	runes := make([]rune, len(encoded))
+	for i, b := range encoded {
+		runes[i] = rune(b)
+	}
+
+	lines := d.f.SplitText(string(runes), width)
+	for i, line := range lines {
+		raw := make([]byte, 0, len(line))
+		for _, r := range line {
+			raw = append(raw, byte(r))
+		}
+		lines[i] = string(raw)
+	}
+
+	return lines
+}
+
+func (d *Document) Space(h float64) {
+	d.f.Ln(h)
+}
+
+// Bytes finishes the document and returns the encoded PDF.
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.f.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
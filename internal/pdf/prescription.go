@@ -0,0 +1,84 @@
+package pdf
+
+import (
+	"fmt"
+	"time"
+)
+
+type Prescription struct {
+	ID       string
+	IssuedAt time.Time
+
+	PatientName string
+	PatientMRN  string
+	PatientDOB  string
+	PatientSex  string
+
+	DoctorName           string
+	DoctorSpecialization string
+	DoctorLicenseNumber  string
+
+	Items []PrescriptionItem
+
+	Signature string
+	VerifyURL string
+}
+
+type PrescriptionItem struct {
+	Drug         string
+	Strength     string
+	Dose         string
+	Frequency    string
+	Duration     string
+	Quantity     string
+	Instructions string
+}
+
+// RenderPrescription renders a prescription with the signature and the
+// address where it can be verified printed in the footer of every page.
+func RenderPrescription(letterhead Letterhead, p Prescription) ([]byte, error) {
+	d := New(letterhead, "Prescription", p.IssuedAt)
+	d.SetFooter(fmt.Sprintf("Prescription %s. Signature %s. Verify at %s", p.ID, p.Signature, p.VerifyURL))
+
+	d.Fields(
+		[2]string{"Prescription", p.ID},
+		[2]string{"Issued", p.IssuedAt.Format("2006-01-02 15:04 MST")},
+	)
+
+	d.Heading("Patient")
+	d.Fields(
+		[2]string{"Name", p.PatientName},
+		[2]string{"MRN", p.PatientMRN},
+		[2]string{"Date of birth", p.PatientDOB},
+		[2]string{"Sex", p.PatientSex},
+	)
+
+	d.Heading("Medication")
+	rows := make([][]string, 0, len(p.Items))
+	for i, item := range p.Items {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			item.Drug + "\n" + item.Strength,
+			item.Dose,
+			item.Frequency,
+			item.Duration,
+			item.Quantity,
+			item.Instructions,
+		})
+	}
+	d.Table(
+		[]string{"#", "Drug", "Dose", "Frequency", "Duration", "Qty", "Instructions"},
+		[]float64{4, 26, 12, 16, 12, 8, 28},
+		rows,
+	)
+
+	d.Space(12)
+	d.Fields(
+		[2]string{"Prescriber", p.DoctorName},
+		[2]string{"License no.", p.DoctorLicenseNumber},
+		[2]string{"Specialization", p.DoctorSpecialization},
+	)
+	d.Paragraph("Electronically signed. This prescription is valid without a handwritten signature.")
+
+	return d.Bytes()
+}
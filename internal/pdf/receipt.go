@@ -0,0 +1,74 @@
+package pdf
+
+import (
+	"fmt"
+	"time"
+)
+
+type Receipt struct {
+	ID       string
+	Refund   bool
+	PaidAt   time.Time
+	Method   string
+	Amount   int64
+	Currency string
+	// GatewayRef is the processor's reference, empty for cash and transfers
+	GatewayRef string
+
+	InvoiceNumber string
+	InvoiceTotal  int64
+	// Balance is what is still owed on the invoice after this payment
+	Balance int64
+
+	PatientName string
+	PatientMRN  string
+}
+
+// RenderReceipt renders the receipt of a payment, or of a refund when
+// r.Refund is set.
+func RenderReceipt(letterhead Letterhead, r Receipt) ([]byte, error) {
+	title := "Receipt"
+	if r.Refund {
+		title = "Refund Receipt"
+	}
+
+	d := New(letterhead, title, r.PaidAt)
+	d.SetFooter(fmt.Sprintf("%s %s", title, r.ID))
+
+	d.Heading("Patient")
+	d.Fields(
+		[2]string{"Name", r.PatientName},
+		[2]string{"MRN", r.PatientMRN},
+	)
+
+	d.Heading("Invoice")
+	d.Fields(
+		[2]string{"Number", r.InvoiceNumber},
+		[2]string{"Total", Money(r.InvoiceTotal, r.Currency)},
+		[2]string{"Balance due", Money(r.Balance, r.Currency)},
+	)
+
+	amountLabel := "Amount paid"
+	if r.Refund {
+		amountLabel = "Amount refunded"
+	}
+
+	d.Heading(title)
+	d.Fields(
+		[2]string{amountLabel, Money(r.Amount, r.Currency)},
+		[2]string{"Method", r.Method},
+		[2]string{"Reference", orNone(r.GatewayRef)},
+		[2]string{"Date", r.PaidAt.Format("2006-01-02 15:04 MST")},
+	)
+
+	return d.Bytes()
+}
+
+// Money formats an amount in minor units, assuming two decimal places.
+func Money(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%s %d.%02d", sign, currency, amount/100, amount%100)
+}
@@ -0,0 +1,134 @@
+// Package roster plans duty rosters: it spreads shifts over the staff
+// eligible for them in rotation, keeping everyone's shifts apart by a
+// minimum rest.
+package roster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClockLayout is how shift templates give their times of day.
+const ClockLayout = "15:04"
+
+// Interval is a span of time someone is working.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (i Interval) overlaps(start, end time.Time) bool {
+	return i.Start.Before(end) && i.End.After(start)
+}
+
+// Window returns when a shift running from startsAt to endsAt, both
+// ClockLayout times in UTC, falls on day. A shift ending at or before its
+// start runs overnight into the next day.
+func Window(day time.Time, startsAt, endsAt string) (Interval, error) {
+	from, err := time.Parse(ClockLayout, startsAt)
+	if err != nil {
+		return Interval{}, fmt.Errorf("invalid shift start %q: %w", startsAt, err)
+	}
+
+	to, err := time.Parse(ClockLayout, endsAt)
+	if err != nil {
+		return Interval{}, fmt.Errorf("invalid shift end %q: %w", endsAt, err)
+	}
+
+	y, m, d := day.UTC().Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	start := date.Add(time.Duration(from.Hour())*time.Hour + time.Duration(from.Minute())*time.Minute)
+	end := date.Add(time.Duration(to.Hour())*time.Hour + time.Duration(to.Minute())*time.Minute)
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	return Interval{Start: start, End: end}, nil
+}
+
+// Slot is one shift on one day to be filled by Needed of the Staff.
+type Slot struct {
+	TemplateID uuid.UUID
+	Day        time.Time
+	Window     Interval
+	Staff      []uuid.UUID
+	Needed     int
+}
+
+type Assignment struct {
+	TemplateID uuid.UUID
+	UserID     uuid.UUID
+	Day        time.Time
+	Window     Interval
+}
+
+// Gap is a slot that could not be fully staffed.
+type Gap struct {
+	TemplateID uuid.UUID `json:"template_id"`
+	Day        string    `json:"day"`
+	Missing    int       `json:"missing"`
+}
+
+// Plan fills the slots in order. Each template rotates through its staff,
+// so the shifts are shared out evenly, and skips anyone working within rest
+// of the shift, whether in busy, which holds the shifts already rostered,
+// or earlier in the plan.
+func Plan(slots []Slot, busy map[uuid.UUID][]Interval, rest time.Duration) ([]Assignment, []Gap) {
+	worked := make(map[uuid.UUID][]Interval, len(busy))
+	for id, intervals := range busy {
+		worked[id] = append([]Interval(nil), intervals...)
+	}
+
+	next := make(map[uuid.UUID]int)
+	assignments := []Assignment{}
+	gaps := []Gap{}
+
+	for _, slot := range slots {
+		from, to := slot.Window.Start.Add(-rest), slot.Window.End.Add(rest)
+		filled, last := 0, -1
+
+		for tried := 0; tried < len(slot.Staff) && filled < slot.Needed; tried++ {
+			i := (next[slot.TemplateID] + tried) % len(slot.Staff)
+			id := slot.Staff[i]
+
+			if isBusy(worked[id], from, to) {
+				continue
+			}
+
+			worked[id] = append(worked[id], slot.Window)
+			assignments = append(assignments, Assignment{
+				TemplateID: slot.TemplateID,
+				UserID:     id,
+				Day:        slot.Day,
+				Window:     slot.Window,
+			})
+			filled, last = filled+1, i
+		}
+
+		if last >= 0 {
+			next[slot.TemplateID] = last + 1
+		}
+
+		if filled < slot.Needed {
+			gaps = append(gaps, Gap{
+				TemplateID: slot.TemplateID,
+				Day:        slot.Day.Format("2006-01-02"),
+				Missing:    slot.Needed - filled,
+			})
+		}
+	}
+
+	return assignments, gaps
+}
+
+func isBusy(intervals []Interval, start, end time.Time) bool {
+	for _, interval := range intervals {
+		if interval.overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
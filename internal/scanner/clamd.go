@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	clamdChunkSize = 64 << 10
+	clamdTimeout   = 30 * time.Second
+)
+
+// Clamd scans files with a ClamAV daemon listening on Addr, a TCP address
+// such as "localhost:3310" or a unix socket path.
+type Clamd struct {
+	Addr string
+}
+
+func (c *Clamd) Name() string { return "clamd" }
+
+// Scan streams the file to clamd with the INSTREAM command. The daemon
+// rejects streams above its StreamMaxLength, which has to be at least the
+// upload size limit.
+func (c *Clamd) Scan(ctx context.Context, data []byte) error {
+	network := "tcp"
+	if strings.HasPrefix(c.Addr, "/") {
+		network = "unix"
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, c.Addr)
+	if err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(clamdTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+
+	w := bufio.NewWriter(conn)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+
+	for len(data) > 0 {
+		chunk := data
+		if len(chunk) > clamdChunkSize {
+			chunk = chunk[:clamdChunkSize]
+		}
+		data = data[len(chunk):]
+
+		if err := binary.Write(w, binary.BigEndian, uint32(len(chunk))); err != nil {
+			return fmt.Errorf("clamd: %w", err)
+		}
+		if _, err := w.Write(chunk); err != nil {
+			return fmt.Errorf("clamd: %w", err)
+		}
+	}
+
+	// a zero length chunk ends the stream
+	if err := binary.Write(w, binary.BigEndian, uint32(0)); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+
+	// replies look like "stream: OK" or "stream: <signature> FOUND"
+	result := strings.TrimPrefix(string(bytes.TrimRight(reply, "\x00\n")), "stream: ")
+	switch {
+	case result == "OK":
+		return nil
+	case strings.HasSuffix(result, " FOUND"):
+		return fmt.Errorf("%w: %s", ErrInfected, strings.TrimSuffix(result, " FOUND"))
+	default:
+		return fmt.Errorf("clamd: %s", result)
+	}
+}
@@ -0,0 +1,41 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrInfected = errors.New("file is infected")
+
+// Scanner checks uploaded files for malware before they are stored. Scan
+// returns an error wrapping ErrInfected when the file is infected, and any
+// other error when the file could not be scanned. Name identifies the scanner
+// in the record of what checked a file.
+type Scanner interface {
+	Scan(ctx context.Context, data []byte) error
+	Name() string
+}
+
+// New returns the scanner of the given kind: "none" or "clamd".
+func New(kind, clamdAddr string) (Scanner, error) {
+	switch kind {
+	case "", "none":
+		return Noop{}, nil
+	case "clamd":
+		if clamdAddr == "" {
+			return nil, errors.New("clamd address is required")
+		}
+		return &Clamd{Addr: clamdAddr}, nil
+	default:
+		return nil, fmt.Errorf("unknown virus scanner %q", kind)
+	}
+}
+
+// Noop accepts every file. It is meant for development, where no scanning
+// daemon runs.
+type Noop struct{}
+
+func (Noop) Scan(context.Context, []byte) error { return nil }
+
+func (Noop) Name() string { return "none" }
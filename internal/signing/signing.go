@@ -0,0 +1,48 @@
+// Package signing signs documents issued by the hospital so that a printed
+// or downloaded copy can be checked against the server.
+//
+// Signatures are HMAC-SHA256 over a canonical encoding of the document
+// content, keyed with a server secret. They prove the content was issued by
+// this server and has not changed; they are not PKI signatures embedded in
+// the PDF.
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+var ErrEmptyKey = errors.New("signing: key must not be empty")
+
+type Signer struct {
+	key []byte
+}
+
+func New(key string) (*Signer, error) {
+	if key == "" {
+		return nil, ErrEmptyKey
+	}
+
+	return &Signer{key: []byte(key)}, nil
+}
+
+// Sign returns the hex encoded signature of data.
+func (s *Signer) Sign(data []byte) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of data.
+func (s *Signer) Verify(data []byte, signature string) bool {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(data)
+	return hmac.Equal(decoded, mac.Sum(nil))
+}
@@ -0,0 +1,681 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BedStatus string
+
+const (
+	BedAvailable    BedStatus = "available"
+	BedOccupied     BedStatus = "occupied"
+	BedCleaning     BedStatus = "cleaning"
+	BedOutOfService BedStatus = "out_of_service"
+)
+
+type AdmissionStatus string
+
+const (
+	AdmissionAdmitted   AdmissionStatus = "admitted"
+	AdmissionDischarged AdmissionStatus = "discharged"
+)
+
+type ADTEventType string
+
+const (
+	ADTAdmit     ADTEventType = "admit"
+	ADTTransfer  ADTEventType = "transfer"
+	ADTDischarge ADTEventType = "discharge"
+)
+
+type Ward struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	// Rooms and Totals are filled in on the bed board
+	Rooms  []Room            `json:"rooms,omitempty"`
+	Totals map[BedStatus]int `json:"totals,omitempty"`
+}
+
+type Room struct {
+	ID        uuid.UUID `json:"id"`
+	WardID    uuid.UUID `json:"ward_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Beds      []Bed     `json:"beds,omitempty"`
+}
+
+type Bed struct {
+	ID        uuid.UUID    `json:"id"`
+	RoomID    uuid.UUID    `json:"room_id"`
+	Label     string       `json:"label"`
+	Status    BedStatus    `json:"status"`
+	Occupant  *BedOccupant `json:"occupant,omitempty"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// BedOccupant is the patient currently admitted to a bed.
+type BedOccupant struct {
+	AdmissionID uuid.UUID `json:"admission_id"`
+	PatientID   uuid.UUID `json:"patient_id"`
+	MRN         string    `json:"mrn"`
+	Name        string    `json:"name"`
+	AdmittedAt  time.Time `json:"admitted_at"`
+}
+
+type BedBoard struct {
+	Wards  []Ward            `json:"wards"`
+	Totals map[BedStatus]int `json:"totals"`
+}
+
+type Admission struct {
+	ID                uuid.UUID       `json:"id"`
+	PatientID         uuid.UUID       `json:"patient_id"`
+	BedID             uuid.UUID       `json:"bed_id"`
+	AttendingDoctorID *uuid.UUID      `json:"attending_doctor_id"`
+	Reason            string          `json:"reason"`
+	Status            AdmissionStatus `json:"status"`
+	AdmittedAt        time.Time       `json:"admitted_at"`
+	DischargedAt      *time.Time      `json:"discharged_at"`
+	Events            []ADTEvent      `json:"events,omitempty"`
+}
+
+// ADTEvent records an admission, transfer or discharge. FromBedID is unset
+// on admission and ToBedID on discharge.
+type ADTEvent struct {
+	ID          uuid.UUID    `json:"id"`
+	AdmissionID uuid.UUID    `json:"admission_id"`
+	PatientID   uuid.UUID    `json:"patient_id"`
+	Type        ADTEventType `json:"type"`
+	FromBedID   *uuid.UUID   `json:"from_bed_id"`
+	ToBedID     *uuid.UUID   `json:"to_bed_id"`
+	Notes       string       `json:"notes"`
+	PerformedBy *uuid.UUID   `json:"performed_by"`
+	OccurredAt  time.Time    `json:"occurred_at"`
+}
+
+type AdmissionQuery struct {
+	PatientID *uuid.UUID      `json:"-"`
+	WardID    *uuid.UUID      `json:"ward_id"`
+	Status    AdmissionStatus `json:"status" validate:"omitempty,oneof=admitted discharged"`
+	Limit     int             `json:"limit" validate:"gte=1,lte=100"`
+	Offset    int             `json:"offset" validate:"gte=0"`
+}
+
+func (q AdmissionQuery) Parse(r *http.Request) (AdmissionQuery, error) {
+	qs := r.URL.Query()
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return q, err
+		}
+
+		q.Limit = l
+	}
+
+	offset := qs.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return q, err
+		}
+
+		q.Offset = o
+	}
+
+	if status := qs.Get("status"); status != "" {
+		q.Status = AdmissionStatus(status)
+	}
+
+	if ward := qs.Get("ward_id"); ward != "" {
+		id, err := uuid.Parse(ward)
+		if err != nil {
+			return q, err
+		}
+
+		q.WardID = &id
+	}
+
+	return q, nil
+}
+
+const admissionColumns = `id, patient_id, bed_id, attending_doctor_id, reason, status, admitted_at, discharged_at`
+
+func scanAdmission(row rowScanner) (*Admission, error) {
+	a := &Admission{}
+	err := row.Scan(
+		&a.ID,
+		&a.PatientID,
+		&a.BedID,
+		&a.AttendingDoctorID,
+		&a.Reason,
+		&a.Status,
+		&a.AdmittedAt,
+		&a.DischargedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+type ADTStore struct {
+	db *sql.DB
+}
+
+func (s *ADTStore) CreateWard(ctx context.Context, ward *Ward) error {
+	query := `INSERT INTO wards (name) VALUES ($1) RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, ward.Name).Scan(&ward.ID, &ward.CreatedAt)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "wards_name_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ADTStore) GetWards(ctx context.Context) ([]Ward, error) {
+	query := `SELECT id, name, created_at FROM wards ORDER BY name`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wards := []Ward{}
+	for rows.Next() {
+		var w Ward
+		if err := rows.Scan(&w.ID, &w.Name, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		wards = append(wards, w)
+	}
+
+	return wards, rows.Err()
+}
+
+// CreateRoom adds a room to a ward. It returns ErrNotFound for an unknown
+// ward and ErrConflict if the ward already has a room of that name.
+func (s *ADTStore) CreateRoom(ctx context.Context, room *Room) error {
+	query := `INSERT INTO rooms (ward_id, name) VALUES ($1, $2) RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, room.WardID, room.Name).Scan(&room.ID, &room.CreatedAt)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "rooms_ward_id_fkey"):
+			return ErrNotFound
+		case strings.Contains(err.Error(), "rooms_ward_name_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateBed adds an available bed to a room. It returns ErrNotFound for an
+// unknown room and ErrConflict if the label is taken in the room.
+func (s *ADTStore) CreateBed(ctx context.Context, bed *Bed) error {
+	query := `
+		INSERT INTO beds (room_id, label)
+		VALUES ($1, $2)
+		RETURNING id, status, updated_at, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, bed.RoomID, bed.Label).Scan(&bed.ID, &bed.Status, &bed.UpdatedAt, &bed.CreatedAt)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "beds_room_id_fkey"):
+			return ErrNotFound
+		case strings.Contains(err.Error(), "beds_room_label_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetBedStatus marks a free bed available, cleaning or out of service.
+// Occupancy only changes through admissions, so an occupied bed, or a
+// request to occupy one, returns ErrLocked.
+func (s *ADTStore) SetBedStatus(ctx context.Context, bed *Bed) error {
+	if bed.Status == BedOccupied {
+		return ErrLocked
+	}
+
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var current BedStatus
+		err := tx.QueryRowContext(ctx, `SELECT status FROM beds WHERE id = $1 FOR UPDATE`, bed.ID).Scan(&current)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if current == BedOccupied {
+			return ErrLocked
+		}
+
+		query := `
+			UPDATE beds
+			SET status = $2, updated_at = NOW()
+			WHERE id = $1
+			RETURNING room_id, label, updated_at, created_at
+		`
+
+		return tx.QueryRowContext(ctx, query, bed.ID, bed.Status).Scan(&bed.RoomID, &bed.Label, &bed.UpdatedAt, &bed.CreatedAt)
+	})
+}
+
+// GetBoard returns every ward, or only the given one, with its rooms, beds
+// and current occupants, and bed counts by status.
+func (s *ADTStore) GetBoard(ctx context.Context, wardID *uuid.UUID) (*BedBoard, error) {
+	query := `
+		SELECT w.id, w.name, w.created_at,
+			r.id, r.name, r.created_at,
+			b.id, b.label, b.status, b.updated_at, b.created_at,
+			a.id, a.patient_id, p.mrn, p.firstname || ' ' || p.lastname, a.admitted_at
+		FROM wards w
+		JOIN rooms r ON r.ward_id = w.id
+		JOIN beds b ON b.room_id = r.id
+		LEFT JOIN admissions a ON a.bed_id = b.id AND a.status = 'admitted'
+		LEFT JOIN patients p ON p.user_id = a.patient_id
+		WHERE ($1::uuid IS NULL OR w.id = $1)
+		ORDER BY w.name, r.name, b.label
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, wardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	board := &BedBoard{Wards: []Ward{}, Totals: map[BedStatus]int{}}
+	for rows.Next() {
+		var w Ward
+		var r Room
+		var b Bed
+		var admissionID, patientID *uuid.UUID
+		var mrn, name sql.NullString
+		var admittedAt *time.Time
+
+		err := rows.Scan(
+			&w.ID, &w.Name, &w.CreatedAt,
+			&r.ID, &r.Name, &r.CreatedAt,
+			&b.ID, &b.Label, &b.Status, &b.UpdatedAt, &b.CreatedAt,
+			&admissionID, &patientID, &mrn, &name, &admittedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		r.WardID = w.ID
+		b.RoomID = r.ID
+		if admissionID != nil {
+			b.Occupant = &BedOccupant{
+				AdmissionID: *admissionID,
+				PatientID:   *patientID,
+				MRN:         mrn.String,
+				Name:        name.String,
+				AdmittedAt:  *admittedAt,
+			}
+		}
+
+		// rows arrive grouped by ward, then room
+		if n := len(board.Wards); n == 0 || board.Wards[n-1].ID != w.ID {
+			w.Totals = map[BedStatus]int{}
+			board.Wards = append(board.Wards, w)
+		}
+		ward := &board.Wards[len(board.Wards)-1]
+
+		if n := len(ward.Rooms); n == 0 || ward.Rooms[n-1].ID != r.ID {
+			ward.Rooms = append(ward.Rooms, r)
+		}
+		room := &ward.Rooms[len(ward.Rooms)-1]
+
+		room.Beds = append(room.Beds, b)
+		ward.Totals[b.Status]++
+		board.Totals[b.Status]++
+	}
+
+	return board, rows.Err()
+}
+
+// Admit admits the patient to an available bed, which becomes occupied. It
+// returns ErrNotFound for an unknown bed, ErrLocked if the bed is not
+// available and ErrConflict if the patient is already admitted.
+func (s *ADTStore) Admit(ctx context.Context, admission *Admission, performedBy uuid.UUID, notes string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if err := claimBed(ctx, tx, admission.BedID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO admissions (patient_id, bed_id, attending_doctor_id, reason)
+			VALUES ($1, $2, $3, $4)
+			RETURNING ` + admissionColumns
+
+		created, err := scanAdmission(tx.QueryRowContext(ctx, query,
+			admission.PatientID,
+			admission.BedID,
+			admission.AttendingDoctorID,
+			admission.Reason,
+		))
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "admissions_active_patient_key"):
+				return ErrConflict
+			default:
+				return err
+			}
+		}
+
+		event := ADTEvent{Type: ADTAdmit, ToBedID: &created.BedID, Notes: notes, PerformedBy: &performedBy}
+		if err := addADTEvent(ctx, tx, created, &event); err != nil {
+			return err
+		}
+
+		created.Events = []ADTEvent{event}
+		*admission = *created
+		return nil
+	})
+}
+
+// Transfer moves an admitted patient to another available bed. The bed
+// they leave needs cleaning. It returns ErrConflict if the admission is
+// discharged and ErrLocked if the new bed is not available.
+func (s *ADTStore) Transfer(ctx context.Context, admission *Admission, toBedID uuid.UUID, performedBy uuid.UUID, notes string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		current, err := lockAdmission(ctx, tx, admission.ID)
+		if err != nil {
+			return err
+		}
+
+		fromBedID := current.BedID
+		if err := claimBed(ctx, tx, toBedID); err != nil {
+			return err
+		}
+
+		if err := releaseBed(ctx, tx, fromBedID); err != nil {
+			return err
+		}
+
+		updated, err := scanAdmission(tx.QueryRowContext(ctx, `
+			UPDATE admissions SET bed_id = $2 WHERE id = $1
+			RETURNING `+admissionColumns, admission.ID, toBedID))
+		if err != nil {
+			return err
+		}
+
+		event := ADTEvent{Type: ADTTransfer, FromBedID: &fromBedID, ToBedID: &toBedID, Notes: notes, PerformedBy: &performedBy}
+		if err := addADTEvent(ctx, tx, updated, &event); err != nil {
+			return err
+		}
+
+		updated.Events, err = getADTEvents(ctx, tx, updated.ID)
+		if err != nil {
+			return err
+		}
+
+		*admission = *updated
+		return nil
+	})
+}
+
+// Discharge ends the admission and leaves the bed for cleaning. It returns
+// ErrConflict if the admission is already discharged.
+func (s *ADTStore) Discharge(ctx context.Context, admission *Admission, performedBy uuid.UUID, notes string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		current, err := lockAdmission(ctx, tx, admission.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := releaseBed(ctx, tx, current.BedID); err != nil {
+			return err
+		}
+
+		updated, err := scanAdmission(tx.QueryRowContext(ctx, `
+			UPDATE admissions SET status = 'discharged', discharged_at = NOW() WHERE id = $1
+			RETURNING `+admissionColumns, admission.ID))
+		if err != nil {
+			return err
+		}
+
+		event := ADTEvent{Type: ADTDischarge, FromBedID: &current.BedID, Notes: notes, PerformedBy: &performedBy}
+		if err := addADTEvent(ctx, tx, updated, &event); err != nil {
+			return err
+		}
+
+		updated.Events, err = getADTEvents(ctx, tx, updated.ID)
+		if err != nil {
+			return err
+		}
+
+		*admission = *updated
+		return nil
+	})
+}
+
+// lockAdmission locks an open admission for a bed move.
+func lockAdmission(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*Admission, error) {
+	admission, err := scanAdmission(tx.QueryRowContext(ctx, `SELECT `+admissionColumns+` FROM admissions WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if admission.Status != AdmissionAdmitted {
+		return nil, ErrConflict
+	}
+
+	return admission, nil
+}
+
+// claimBed occupies an available bed.
+func claimBed(ctx context.Context, tx *sql.Tx, bedID uuid.UUID) error {
+	var status BedStatus
+	err := tx.QueryRowContext(ctx, `SELECT status FROM beds WHERE id = $1 FOR UPDATE`, bedID).Scan(&status)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	if status != BedAvailable {
+		return ErrLocked
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE beds SET status = 'occupied', updated_at = NOW() WHERE id = $1`, bedID)
+	return err
+}
+
+// releaseBed marks a vacated bed for cleaning.
+func releaseBed(ctx context.Context, tx *sql.Tx, bedID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `UPDATE beds SET status = 'cleaning', updated_at = NOW() WHERE id = $1`, bedID)
+	return err
+}
+
+func addADTEvent(ctx context.Context, tx *sql.Tx, admission *Admission, event *ADTEvent) error {
+	query := `
+		INSERT INTO adt_events (admission_id, patient_id, type, from_bed_id, to_bed_id, notes, performed_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, occurred_at
+	`
+
+	event.AdmissionID = admission.ID
+	event.PatientID = admission.PatientID
+
+	return tx.QueryRowContext(ctx, query,
+		event.AdmissionID,
+		event.PatientID,
+		event.Type,
+		event.FromBedID,
+		event.ToBedID,
+		event.Notes,
+		event.PerformedBy,
+	).Scan(&event.ID, &event.OccurredAt)
+}
+
+func getADTEvents(ctx context.Context, q queryer, admissionID uuid.UUID) ([]ADTEvent, error) {
+	query := `
+		SELECT id, admission_id, patient_id, type, from_bed_id, to_bed_id, notes, performed_by, occurred_at
+		FROM adt_events
+		WHERE admission_id = $1
+		ORDER BY occurred_at, id
+	`
+
+	rows, err := q.QueryContext(ctx, query, admissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []ADTEvent{}
+	for rows.Next() {
+		var e ADTEvent
+		err := rows.Scan(&e.ID, &e.AdmissionID, &e.PatientID, &e.Type, &e.FromBedID, &e.ToBedID, &e.Notes, &e.PerformedBy, &e.OccurredAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// GetAdmission returns the admission with its ADT events.
+func (s *ADTStore) GetAdmission(ctx context.Context, id uuid.UUID) (*Admission, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	admission, err := scanAdmission(s.db.QueryRowContext(ctx, `SELECT `+admissionColumns+` FROM admissions WHERE id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	admission.Events, err = getADTEvents(ctx, s.db, admission.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return admission, nil
+}
+
+// GetBedLocation returns the names of the ward and room of a bed.
+func (s *ADTStore) GetBedLocation(ctx context.Context, bedID uuid.UUID) (ward, room, bed string, err error) {
+	query := `
+		SELECT w.name, r.name, b.label
+		FROM beds b
+		JOIN rooms r ON r.id = b.room_id
+		JOIN wards w ON w.id = r.ward_id
+		WHERE b.id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err = s.db.QueryRowContext(ctx, query, bedID).Scan(&ward, &room, &bed)
+	if err == sql.ErrNoRows {
+		err = ErrNotFound
+	}
+
+	return ward, room, bed, err
+}
+
+// ListAdmissions returns admissions matching the query, newest first.
+func (s *ADTStore) ListAdmissions(ctx context.Context, q AdmissionQuery) ([]*Admission, error) {
+	query := `
+		SELECT ` + admissionColumns + `
+		FROM admissions
+		WHERE ($1::uuid IS NULL OR patient_id = $1)
+			AND ($2::uuid IS NULL OR bed_id IN (
+				SELECT b.id FROM beds b JOIN rooms r ON r.id = b.room_id WHERE r.ward_id = $2
+			))
+			AND ($3 = '' OR status::text = $3)
+		ORDER BY admitted_at DESC, id
+		LIMIT $4 OFFSET $5
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, q.PatientID, q.WardID, string(q.Status), q.Limit, q.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	admissions := []*Admission{}
+	for rows.Next() {
+		admission, err := scanAdmission(rows)
+		if err != nil {
+			return nil, err
+		}
+		admissions = append(admissions, admission)
+	}
+
+	return admissions, rows.Err()
+}
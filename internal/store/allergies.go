@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AllergyType string
+
+const (
+	AllergyTypeAllergy     AllergyType = "allergy"
+	AllergyTypeIntolerance AllergyType = "intolerance"
+)
+
+type AllergySeverity string
+
+const (
+	AllergySeverityMild     AllergySeverity = "mild"
+	AllergySeverityModerate AllergySeverity = "moderate"
+	AllergySeveritySevere   AllergySeverity = "severe"
+	AllergySeverityUnknown  AllergySeverity = "unknown"
+)
+
+// Allergy is an allergy or intolerance recorded for a patient. Substance is
+// free text and may name a drug, a drug class or anything else.
+type Allergy struct {
+	ID         uuid.UUID       `json:"id"`
+	PatientID  uuid.UUID       `json:"patient_id"`
+	Substance  string          `json:"substance"`
+	Type       AllergyType     `json:"type"`
+	Severity   AllergySeverity `json:"severity"`
+	Reaction   string          `json:"reaction"`
+	RecordedBy *uuid.UUID      `json:"recorded_by"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AllergyStore struct {
+	db *sql.DB
+}
+
+func (s *AllergyStore) GetByPatient(ctx context.Context, patientID uuid.UUID) ([]Allergy, error) {
+	query := `
+		SELECT id, patient_id, substance, type, severity, reaction, recorded_by, created_at
+		FROM patient_allergies
+		WHERE patient_id = $1
+		ORDER BY created_at, id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	allergies := []Allergy{}
+	for rows.Next() {
+		var a Allergy
+		err := rows.Scan(
+			&a.ID,
+			&a.PatientID,
+			&a.Substance,
+			&a.Type,
+			&a.Severity,
+			&a.Reaction,
+			&a.RecordedBy,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		allergies = append(allergies, a)
+	}
+
+	return allergies, rows.Err()
+}
+
+// Create records an allergy. A patient has one entry per substance, compared
+// case-insensitively; a second one returns ErrConflict.
+func (s *AllergyStore) Create(ctx context.Context, allergy *Allergy) error {
+	query := `
+		INSERT INTO patient_allergies (patient_id, substance, type, severity, reaction, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		allergy.PatientID,
+		allergy.Substance,
+		allergy.Type,
+		allergy.Severity,
+		allergy.Reaction,
+		allergy.RecordedBy,
+	).Scan(
+		&allergy.ID,
+		&allergy.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "patient_allergies_substance_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *AllergyStore) Delete(ctx context.Context, patientID, allergyID uuid.UUID) error {
+	query := `DELETE FROM patient_allergies WHERE patient_id = $1 AND id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, patientID, allergyID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -8,13 +8,29 @@ import (
 	"github.com/google/uuid"
 )
 
+type AppointmentStatus string
+
+const (
+	AppointmentScheduled AppointmentStatus = "scheduled"
+	AppointmentCompleted AppointmentStatus = "completed"
+	AppointmentCancelled AppointmentStatus = "cancelled"
+)
+
 type Appointment struct {
-	ID              uuid.UUID `json:"id"`
-	DoctorID        uuid.UUID `json:"doctor_id"`
-	PatientID       uuid.UUID `json:"patient_id"`
-	AppointmentTime time.Time `json:"appointment_time"`
-	DoctorEmail     string    `json:"doctor_first_name"`
-	PatientEmail    string    `json:"patient_email"`
+	ID               uuid.UUID         `json:"id"`
+	DoctorID         uuid.UUID         `json:"doctor_id"`
+	PatientID        uuid.UUID         `json:"patient_id"`
+	PatientMRN       string            `json:"patient_mrn,omitempty"`
+	AppointmentTime  time.Time         `json:"appointment_time"`
+	VisitType        VisitType         `json:"visit_type"`
+	ConsultationMode ConsultationMode  `json:"consultation_mode"`
+	Status           AppointmentStatus `json:"status"`
+	// FeeAmount and FeeCurrency are copied from the doctor's fee in effect at
+	// booking time; nil when the doctor had no fee configured.
+	FeeAmount    *int64  `json:"fee_amount"`
+	FeeCurrency  *string `json:"fee_currency"`
+	DoctorEmail  string  `json:"doctor_first_name"`
+	PatientEmail string  `json:"patient_email"`
 }
 
 type AppointmentStore struct {
@@ -26,22 +42,48 @@ func NewAppointmentStore(db *sql.DB) *AppointmentStore {
 }
 
 func (s *AppointmentStore) Create(ctx context.Context, appointment *Appointment) error {
-	query := `
-		INSERT INTO appointment (doctor_id, patient_id, appointment_time)
-		VALUES ($1, $2, $3)
-		RETURNING id;
-	`
-
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	err := s.db.QueryRowContext(ctx, query,
+	return createAppointment(ctx, s.db, appointment)
+}
+
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func createAppointment(ctx context.Context, q rowQueryer, appointment *Appointment) error {
+	// The fee is resolved in the same statement so the snapshot always matches
+	// the price list at the moment of booking.
+	query := `
+		INSERT INTO appointment (doctor_id, patient_id, appointment_time, visit_type, consultation_mode, fee_amount, fee_currency)
+		SELECT $1::uuid, $2::uuid, $3::timestamptz, $4::visit_type, $5::consultation_mode, f.amount, f.currency
+		FROM (SELECT 1) AS booking
+		LEFT JOIN LATERAL (
+			SELECT amount, currency
+			FROM doctor_fees
+			WHERE doctor_id = $1::uuid
+				AND visit_type = $4::visit_type
+				AND consultation_mode = $5::consultation_mode
+				AND effective_from <= ($3::timestamptz AT TIME ZONE 'UTC')::date
+			ORDER BY effective_from DESC
+			LIMIT 1
+		) f ON true
+		RETURNING id, status, fee_amount, fee_currency;
+	`
+
+	return q.QueryRowContext(ctx, query,
 		appointment.DoctorID,
 		appointment.PatientID,
 		appointment.AppointmentTime,
-	).Scan(&appointment.ID)
-
-	return err
+		appointment.VisitType,
+		appointment.ConsultationMode,
+	).Scan(
+		&appointment.ID,
+		&appointment.Status,
+		&appointment.FeeAmount,
+		&appointment.FeeCurrency,
+	)
 }
 
 func (s *AppointmentStore) GetAllAppointments(ctx context.Context) ([]*Appointment, error) {
@@ -51,10 +93,17 @@ func (s *AppointmentStore) GetAllAppointments(ctx context.Context) ([]*Appointme
 			a.doctor_id,
 			a.patient_id,
 			a.appointment_time,
-			u_patient.email AS patient_email,
+			a.visit_type,
+			a.consultation_mode,
+			a.status,
+			a.fee_amount,
+			a.fee_currency,
+			COALESCE(p.mrn, '') AS patient_mrn,
+			COALESCE(u_patient.email, '') AS patient_email,
 			u_doctor.email AS doctor_email
 		FROM appointment a
 		JOIN users u_patient ON a.patient_id = u_patient.id
+		LEFT JOIN patients p ON p.user_id = a.patient_id
 		JOIN doctors d ON a.doctor_id = d.user_id
 		JOIN users u_doctor ON d.user_id = u_doctor.id;
 	`
@@ -77,6 +126,12 @@ func (s *AppointmentStore) GetAllAppointments(ctx context.Context) ([]*Appointme
 			&appointment.DoctorID,
 			&appointment.PatientID,
 			&appointment.AppointmentTime,
+			&appointment.VisitType,
+			&appointment.ConsultationMode,
+			&appointment.Status,
+			&appointment.FeeAmount,
+			&appointment.FeeCurrency,
+			&appointment.PatientMRN,
 			&appointment.PatientEmail,
 			&appointment.DoctorEmail,
 		)
@@ -88,3 +143,105 @@ func (s *AppointmentStore) GetAllAppointments(ctx context.Context) ([]*Appointme
 
 	return appointments, nil
 }
+
+func (s *AppointmentStore) GetByID(ctx context.Context, id uuid.UUID) (*Appointment, error) {
+	query := `
+		SELECT id, doctor_id, patient_id, appointment_time, visit_type, consultation_mode, status,
+			fee_amount, fee_currency
+		FROM appointment
+		WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	appointment := &Appointment{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&appointment.ID,
+		&appointment.DoctorID,
+		&appointment.PatientID,
+		&appointment.AppointmentTime,
+		&appointment.VisitType,
+		&appointment.ConsultationMode,
+		&appointment.Status,
+		&appointment.FeeAmount,
+		&appointment.FeeCurrency,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return appointment, nil
+}
+
+// GetByPatient returns the patient's appointments, latest first.
+func (s *AppointmentStore) GetByPatient(ctx context.Context, patientID uuid.UUID) ([]*Appointment, error) {
+	query := `
+		SELECT id, doctor_id, patient_id, appointment_time, visit_type, consultation_mode, status,
+			fee_amount, fee_currency
+		FROM appointment
+		WHERE patient_id = $1
+		ORDER BY appointment_time DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appointments := []*Appointment{}
+	for rows.Next() {
+		appointment := &Appointment{}
+		err := rows.Scan(
+			&appointment.ID,
+			&appointment.DoctorID,
+			&appointment.PatientID,
+			&appointment.AppointmentTime,
+			&appointment.VisitType,
+			&appointment.ConsultationMode,
+			&appointment.Status,
+			&appointment.FeeAmount,
+			&appointment.FeeCurrency,
+		)
+		if err != nil {
+			return nil, err
+		}
+		appointments = append(appointments, appointment)
+	}
+
+	return appointments, rows.Err()
+}
+
+// Cancel cancels a scheduled appointment. Appointments that are already
+// completed or cancelled return ErrConflict.
+func (s *AppointmentStore) Cancel(ctx context.Context, appointment *Appointment) error {
+	query := `
+		UPDATE appointment SET status = 'cancelled'
+		WHERE id = $1 AND status = 'scheduled'
+		RETURNING status
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, appointment.ID).Scan(&appointment.Status)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
@@ -7,7 +7,10 @@ import (
 	"github.com/google/uuid"
 )
 
+// Availability is a weekly outpatient slot. StartsFrom and EndsAt are UTC
+// times of day, HH:MM.
 type Availability struct {
+	ID           uuid.UUID `json:"id"`
 	DoctorID     uuid.UUID `json:"doctor_id"`
 	AvailableDay string    `json:"available_day"`
 	StartsFrom   string    `json:"starts_from"`
@@ -20,9 +23,10 @@ type AvailabilityStore struct {
 
 func (s *AvailabilityStore) Create(ctx context.Context, availability *Availability) error {
 	query := `
-        INSERT INTO availabilities (doctor_id, available_day, starts_from, ends_at) 
-        VALUES ($1, $2, $3, $4)
-    `
+		INSERT INTO availability (doctor_id, available_day, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
@@ -33,15 +37,40 @@ func (s *AvailabilityStore) Create(ctx context.Context, availability *Availabili
 		availability.AvailableDay,
 		availability.StartsFrom,
 		availability.EndsAt,
-	).Scan(
-		&availability.DoctorID,
-		&availability.AvailableDay,
-		&availability.StartsFrom,
-		&availability.EndsAt,
-	)
+	).Scan(&availability.ID)
 	if err != nil {
 		return err
 	}
 	return nil
 
 }
+
+// GetByDoctor returns a doctor's slots in weekday order.
+func (s *AvailabilityStore) GetByDoctor(ctx context.Context, doctorID uuid.UUID) ([]Availability, error) {
+	query := `
+		SELECT id, doctor_id, available_day, to_char(starts_at, 'HH24:MI'), to_char(ends_at, 'HH24:MI')
+		FROM availability
+		WHERE doctor_id = $1
+		ORDER BY array_position(ARRAY['monday', 'tuesday', 'wednesday', 'thursday', 'friday', 'saturday', 'sunday'], lower(available_day)), starts_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slots := []Availability{}
+	for rows.Next() {
+		var a Availability
+		if err := rows.Scan(&a.ID, &a.DoctorID, &a.AvailableDay, &a.StartsFrom, &a.EndsAt); err != nil {
+			return nil, err
+		}
+		slots = append(slots, a)
+	}
+
+	return slots, rows.Err()
+}
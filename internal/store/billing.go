@@ -0,0 +1,822 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrOverpayment is returned for a payment above the outstanding balance of
+// an invoice, or a refund above what is left of the payment.
+var ErrOverpayment = errors.New("amount exceeds what is outstanding")
+
+type ChargeKind string
+
+const (
+	ChargeConsultation ChargeKind = "consultation"
+	ChargeProcedure    ChargeKind = "procedure"
+	ChargeLabTest      ChargeKind = "lab_test"
+	ChargeDrug         ChargeKind = "drug"
+	ChargeOther        ChargeKind = "other"
+)
+
+type InvoiceStatus string
+
+const (
+	InvoiceDraft  InvoiceStatus = "draft"
+	InvoiceIssued InvoiceStatus = "issued"
+	InvoiceVoid   InvoiceStatus = "void"
+)
+
+type PaymentKind string
+
+const (
+	PaymentKindPayment PaymentKind = "payment"
+	PaymentKindRefund  PaymentKind = "refund"
+)
+
+type PaymentMethod string
+
+const (
+	PaymentCash         PaymentMethod = "cash"
+	PaymentCard         PaymentMethod = "card"
+	PaymentBankTransfer PaymentMethod = "bank_transfer"
+	PaymentMobileWallet PaymentMethod = "mobile_wallet"
+	// PaymentInsurance is posted from a claim remittance
+	PaymentInsurance PaymentMethod = "insurance"
+)
+
+// ThroughGateway reports whether payments by the method are processed by
+// the payment gateway rather than recorded by staff.
+func (m PaymentMethod) ThroughGateway() bool {
+	return m == PaymentCard || m == PaymentMobileWallet
+}
+
+type PaymentStatus string
+
+const (
+	PaymentPending   PaymentStatus = "pending"
+	PaymentSucceeded PaymentStatus = "succeeded"
+	PaymentFailed    PaymentStatus = "failed"
+)
+
+// Price is the price list entry of a billable service. Amounts are in minor
+// units of the billing currency and TaxRate is in basis points.
+type Price struct {
+	Kind        ChargeKind `json:"kind"`
+	Code        string     `json:"code"`
+	Description string     `json:"description"`
+	UnitPrice   int64      `json:"unit_price"`
+	TaxRate     int        `json:"tax_rate"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// InvoiceItem is one charge. Net is the price of the quantity less the
+// discount, and Tax is charged on Net.
+type InvoiceItem struct {
+	ID          uuid.UUID  `json:"id"`
+	InvoiceID   uuid.UUID  `json:"invoice_id"`
+	Kind        ChargeKind `json:"kind"`
+	Code        string     `json:"code"`
+	Description string     `json:"description"`
+	Quantity    int        `json:"quantity"`
+	UnitPrice   int64      `json:"unit_price"`
+	Discount    int64      `json:"discount"`
+	TaxRate     int        `json:"tax_rate"`
+	Net         int64      `json:"net"`
+	Tax         int64      `json:"tax"`
+	// SourceKey names what was charged so it is never billed twice
+	SourceKey *string    `json:"-"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Invoice totals are in minor units of Currency. Balance is what the
+// patient still owes; it is zero unless the invoice is issued.
+type Invoice struct {
+	ID         uuid.UUID     `json:"id"`
+	Number     *string       `json:"number"`
+	PatientID  uuid.UUID     `json:"patient_id"`
+	Currency   string        `json:"currency"`
+	Status     InvoiceStatus `json:"status"`
+	IssuedAt   *time.Time    `json:"issued_at"`
+	IssuedBy   *uuid.UUID    `json:"issued_by"`
+	VoidedAt   *time.Time    `json:"voided_at"`
+	VoidReason *string       `json:"void_reason"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Subtotal   int64         `json:"subtotal"`
+	Discount   int64         `json:"discount"`
+	Tax        int64         `json:"tax"`
+	Total      int64         `json:"total"`
+	Paid       int64         `json:"paid"`
+	Refunded   int64         `json:"refunded"`
+	Balance    int64         `json:"balance"`
+	Items      []InvoiceItem `json:"items,omitempty"`
+	Payments   []Payment     `json:"payments,omitempty"`
+}
+
+// Payment is a payment towards an invoice or a refund of one. Pending
+// payments are being processed by the gateway.
+type Payment struct {
+	ID         uuid.UUID     `json:"id"`
+	InvoiceID  uuid.UUID     `json:"invoice_id"`
+	PatientID  uuid.UUID     `json:"patient_id"`
+	Kind       PaymentKind   `json:"kind"`
+	Method     PaymentMethod `json:"method"`
+	Amount     int64         `json:"amount"`
+	Currency   string        `json:"currency"`
+	Status     PaymentStatus `json:"status"`
+	RefundOf   *uuid.UUID    `json:"refund_of"`
+	Reason     string        `json:"reason"`
+	GatewayRef *string       `json:"gateway_ref"`
+	Failure    *string       `json:"failure"`
+	ReceiptKey *string       `json:"-"`
+	CreatedBy  *uuid.UUID    `json:"created_by"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// PatientBalance is what a patient owes across their issued invoices.
+type PatientBalance struct {
+	PatientID   uuid.UUID  `json:"patient_id"`
+	Currency    string     `json:"currency"`
+	Outstanding int64      `json:"outstanding"`
+	Invoices    []*Invoice `json:"invoices"`
+}
+
+type BillingStore struct {
+	db *sql.DB
+}
+
+const invoiceQuery = `
+	SELECT i.id, i.number, i.patient_id, i.currency, i.status, i.issued_at, i.issued_by, i.voided_at,
+		i.void_reason, i.created_at, t.subtotal, t.discount, t.tax, p.paid, p.refunded
+	FROM invoices i
+	CROSS JOIN LATERAL (
+		SELECT COALESCE(SUM(quantity * unit_price), 0) AS subtotal,
+			COALESCE(SUM(discount), 0) AS discount,
+			COALESCE(SUM(tax), 0) AS tax
+		FROM invoice_items
+		WHERE invoice_id = i.id
+	) t
+	CROSS JOIN LATERAL (
+		SELECT COALESCE(SUM(amount) FILTER (WHERE kind = 'payment'), 0) AS paid,
+			COALESCE(SUM(amount) FILTER (WHERE kind = 'refund'), 0) AS refunded
+		FROM payments
+		WHERE invoice_id = i.id AND status = 'succeeded'
+	) p`
+
+func scanInvoice(row rowScanner) (*Invoice, error) {
+	i := &Invoice{}
+	err := row.Scan(
+		&i.ID,
+		&i.Number,
+		&i.PatientID,
+		&i.Currency,
+		&i.Status,
+		&i.IssuedAt,
+		&i.IssuedBy,
+		&i.VoidedAt,
+		&i.VoidReason,
+		&i.CreatedAt,
+		&i.Subtotal,
+		&i.Discount,
+		&i.Tax,
+		&i.Paid,
+		&i.Refunded,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	i.Total = i.Subtotal - i.Discount + i.Tax
+	if i.Status == InvoiceIssued {
+		i.Balance = i.Total - i.Paid + i.Refunded
+	}
+
+	return i, nil
+}
+
+const paymentColumns = `
+	p.id, p.invoice_id, i.patient_id, p.kind, p.method, p.amount, i.currency, p.status, p.refund_of,
+	p.reason, p.gateway_ref, p.failure, p.receipt_key, p.created_by, p.created_at`
+
+func scanPayment(row rowScanner) (*Payment, error) {
+	p := &Payment{}
+	err := row.Scan(
+		&p.ID,
+		&p.InvoiceID,
+		&p.PatientID,
+		&p.Kind,
+		&p.Method,
+		&p.Amount,
+		&p.Currency,
+		&p.Status,
+		&p.RefundOf,
+		&p.Reason,
+		&p.GatewayRef,
+		&p.Failure,
+		&p.ReceiptKey,
+		&p.CreatedBy,
+		&p.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+const invoiceItemColumns = `
+	id, invoice_id, kind, code, description, quantity, unit_price, discount, tax_rate, net, tax,
+	source_key, created_by, created_at`
+
+func scanInvoiceItem(row rowScanner) (*InvoiceItem, error) {
+	it := &InvoiceItem{}
+	err := row.Scan(
+		&it.ID,
+		&it.InvoiceID,
+		&it.Kind,
+		&it.Code,
+		&it.Description,
+		&it.Quantity,
+		&it.UnitPrice,
+		&it.Discount,
+		&it.TaxRate,
+		&it.Net,
+		&it.Tax,
+		&it.SourceKey,
+		&it.CreatedBy,
+		&it.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+func (s *BillingStore) SetPrice(ctx context.Context, price *Price) error {
+	query := `
+		INSERT INTO price_list (kind, code, description, unit_price, tax_rate)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (kind, code) DO UPDATE
+		SET description = EXCLUDED.description, unit_price = EXCLUDED.unit_price,
+			tax_rate = EXCLUDED.tax_rate, updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return s.db.QueryRowContext(ctx, query, price.Kind, price.Code, price.Description, price.UnitPrice, price.TaxRate).Scan(&price.UpdatedAt)
+}
+
+// GetPrices lists the price list, optionally of one kind only.
+func (s *BillingStore) GetPrices(ctx context.Context, kind ChargeKind) ([]Price, error) {
+	query := `
+		SELECT kind, code, description, unit_price, tax_rate, updated_at
+		FROM price_list
+		WHERE ($1 = '' OR kind::text = $1)
+		ORDER BY kind, description
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []Price{}
+	for rows.Next() {
+		var p Price
+		if err := rows.Scan(&p.Kind, &p.Code, &p.Description, &p.UnitPrice, &p.TaxRate, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+
+	return prices, rows.Err()
+}
+
+func (s *BillingStore) GetPrice(ctx context.Context, kind ChargeKind, code string) (*Price, error) {
+	query := `
+		SELECT kind, code, description, unit_price, tax_rate, updated_at
+		FROM price_list
+		WHERE kind = $1 AND code = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	p := &Price{}
+	err := s.db.QueryRowContext(ctx, query, kind, code).Scan(&p.Kind, &p.Code, &p.Description, &p.UnitPrice, &p.TaxRate, &p.UpdatedAt)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Charge adds an item to the patient's draft invoice, opening one in
+// currency if there is none. It returns ErrConflict if the item's source was
+// already charged.
+func (s *BillingStore) Charge(ctx context.Context, patientID uuid.UUID, currency string, item *InvoiceItem) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO invoices (patient_id, currency) VALUES ($1, $2)
+			ON CONFLICT (patient_id) WHERE status = 'draft' DO NOTHING
+		`, patientID, currency)
+		if err != nil {
+			return err
+		}
+
+		// the lock keeps the draft from being issued while the item is added
+		err = tx.QueryRowContext(ctx, `
+			SELECT id FROM invoices WHERE patient_id = $1 AND status = 'draft' FOR UPDATE
+		`, patientID).Scan(&item.InvoiceID)
+		if err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO invoice_items (invoice_id, kind, code, description, quantity, unit_price, discount,
+				tax_rate, source_key, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING ` + invoiceItemColumns
+
+		created, err := scanInvoiceItem(tx.QueryRowContext(ctx, query,
+			item.InvoiceID,
+			item.Kind,
+			item.Code,
+			item.Description,
+			item.Quantity,
+			item.UnitPrice,
+			item.Discount,
+			item.TaxRate,
+			item.SourceKey,
+			item.CreatedBy,
+		))
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "invoice_items_source_key"):
+				return ErrConflict
+			default:
+				return err
+			}
+		}
+
+		*item = *created
+		return nil
+	})
+}
+
+// GetInvoice returns an invoice with its items and payments.
+func (s *BillingStore) GetInvoice(ctx context.Context, id uuid.UUID) (*Invoice, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	invoice, err := scanInvoice(s.db.QueryRowContext(ctx, invoiceQuery+` WHERE i.id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	items, err := s.db.QueryContext(ctx, `SELECT `+invoiceItemColumns+` FROM invoice_items WHERE invoice_id = $1 ORDER BY created_at, id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer items.Close()
+
+	invoice.Items = []InvoiceItem{}
+	for items.Next() {
+		item, err := scanInvoiceItem(items)
+		if err != nil {
+			return nil, err
+		}
+		invoice.Items = append(invoice.Items, *item)
+	}
+
+	if err := items.Err(); err != nil {
+		return nil, err
+	}
+
+	payments, err := s.db.QueryContext(ctx, `
+		SELECT `+paymentColumns+`
+		FROM payments p
+		JOIN invoices i ON i.id = p.invoice_id
+		WHERE p.invoice_id = $1
+		ORDER BY p.created_at, p.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer payments.Close()
+
+	invoice.Payments = []Payment{}
+	for payments.Next() {
+		payment, err := scanPayment(payments)
+		if err != nil {
+			return nil, err
+		}
+		invoice.Payments = append(invoice.Payments, *payment)
+	}
+
+	return invoice, payments.Err()
+}
+
+// GetByPatient lists a patient's invoices without items, newest first.
+func (s *BillingStore) GetByPatient(ctx context.Context, patientID uuid.UUID, includeDrafts bool) ([]*Invoice, error) {
+	query := invoiceQuery + `
+		WHERE i.patient_id = $1 AND ($2 OR i.status <> 'draft')
+		ORDER BY i.created_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, patientID, includeDrafts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []*Invoice{}
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+
+	return invoices, rows.Err()
+}
+
+// GetBalance returns the issued invoices of a patient with money owing.
+func (s *BillingStore) GetBalance(ctx context.Context, patientID uuid.UUID, currency string) (*PatientBalance, error) {
+	invoices, err := s.GetByPatient(ctx, patientID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &PatientBalance{PatientID: patientID, Currency: currency, Invoices: []*Invoice{}}
+	for _, invoice := range invoices {
+		if invoice.Balance == 0 {
+			continue
+		}
+		balance.Outstanding += invoice.Balance
+		balance.Invoices = append(balance.Invoices, invoice)
+	}
+
+	return balance, nil
+}
+
+// lockDraft locks an invoice and returns ErrLocked unless it is a draft.
+func lockDraft(ctx context.Context, tx *sql.Tx, invoiceID uuid.UUID) error {
+	var status InvoiceStatus
+	err := tx.QueryRowContext(ctx, `SELECT status FROM invoices WHERE id = $1 FOR UPDATE`, invoiceID).Scan(&status)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	if status != InvoiceDraft {
+		return ErrLocked
+	}
+
+	return nil
+}
+
+// SetDiscount changes the discount of an item of a draft invoice.
+func (s *BillingStore) SetDiscount(ctx context.Context, item *InvoiceItem) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if err := lockDraft(ctx, tx, item.InvoiceID); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE invoice_items SET discount = $3
+			WHERE id = $1 AND invoice_id = $2
+			RETURNING ` + invoiceItemColumns
+
+		updated, err := scanInvoiceItem(tx.QueryRowContext(ctx, query, item.ID, item.InvoiceID, item.Discount))
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		*item = *updated
+		return nil
+	})
+}
+
+// DeleteItem removes an item from a draft invoice. Its source can be
+// charged again afterwards.
+func (s *BillingStore) DeleteItem(ctx context.Context, invoiceID, itemID uuid.UUID) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if err := lockDraft(ctx, tx, invoiceID); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM invoice_items WHERE id = $1 AND invoice_id = $2`, itemID, invoiceID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// Issue numbers a draft invoice and locks its items. It returns ErrLocked
+// if the invoice is no longer a draft.
+func (s *BillingStore) Issue(ctx context.Context, invoice *Invoice, issuedBy uuid.UUID) error {
+	query := `
+		UPDATE invoices
+		SET status = 'issued', issued_at = NOW(), issued_by = $2,
+			number = 'INV-' || lpad(nextval('invoice_number_seq')::text, 6, '0')
+		WHERE id = $1 AND status = 'draft'
+		RETURNING number, status, issued_at, issued_by
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, invoice.ID, issuedBy).Scan(
+		&invoice.Number,
+		&invoice.Status,
+		&invoice.IssuedAt,
+		&invoice.IssuedBy,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrLocked
+		default:
+			return err
+		}
+	}
+
+	invoice.Balance = invoice.Total - invoice.Paid + invoice.Refunded
+	return nil
+}
+
+// Void cancels an issued invoice. It returns ErrLocked if the invoice is
+// not issued and ErrConflict while it holds money that was not refunded.
+func (s *BillingStore) Void(ctx context.Context, invoice *Invoice, reason string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var held int64
+		err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE((
+				SELECT SUM(CASE WHEN p.kind = 'payment' THEN p.amount ELSE -p.amount END)
+				FROM payments p
+				WHERE p.invoice_id = i.id
+					AND (p.status = 'succeeded' OR (p.status = 'pending' AND p.kind = 'payment'))
+			), 0)
+			FROM invoices i
+			WHERE i.id = $1 AND i.status = 'issued'
+			FOR UPDATE OF i
+		`, invoice.ID).Scan(&held)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrLocked
+			default:
+				return err
+			}
+		}
+
+		if held != 0 {
+			return ErrConflict
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			UPDATE invoices SET status = 'void', voided_at = NOW(), void_reason = $2
+			WHERE id = $1
+			RETURNING status, voided_at, void_reason
+		`, invoice.ID, reason).Scan(&invoice.Status, &invoice.VoidedAt, &invoice.VoidReason)
+		if err != nil {
+			return err
+		}
+
+		invoice.Balance = 0
+		return nil
+	})
+}
+
+// CreatePayment records a payment or a refund in payment.Status, pending
+// when the gateway still has to process it. The invoice is locked while the
+// amount is checked against what is outstanding, counting pending payments,
+// so concurrent payments cannot overpay it.
+//
+// It returns ErrLocked if the invoice is not issued or the refunded payment
+// did not succeed, ErrNotFound if the refunded payment is not a payment of
+// the invoice, and ErrOverpayment if the amount is too high.
+func (s *BillingStore) CreatePayment(ctx context.Context, payment *Payment) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		return createPayment(ctx, tx, payment)
+	})
+}
+
+func createPayment(ctx context.Context, tx *sql.Tx, payment *Payment) error {
+	invoice, err := scanInvoice(tx.QueryRowContext(ctx, invoiceQuery+` WHERE i.id = $1 FOR UPDATE OF i`, payment.InvoiceID))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	if invoice.Status != InvoiceIssued {
+		return ErrLocked
+	}
+
+	switch payment.Kind {
+	case PaymentKindPayment:
+		var pending int64
+		err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(SUM(amount), 0) FROM payments
+			WHERE invoice_id = $1 AND kind = 'payment' AND status = 'pending'
+		`, invoice.ID).Scan(&pending)
+		if err != nil {
+			return err
+		}
+
+		if payment.Amount > invoice.Balance-pending {
+			return ErrOverpayment
+		}
+
+	case PaymentKindRefund:
+		var (
+			amount   int64
+			status   PaymentStatus
+			method   PaymentMethod
+			refunded int64
+		)
+		err := tx.QueryRowContext(ctx, `
+			SELECT p.amount, p.status, p.method, COALESCE((
+				SELECT SUM(r.amount) FROM payments r
+				WHERE r.refund_of = p.id AND r.status <> 'failed'
+			), 0)
+			FROM payments p
+			WHERE p.id = $1 AND p.invoice_id = $2 AND p.kind = 'payment'
+			FOR UPDATE OF p
+		`, payment.RefundOf, invoice.ID).Scan(&amount, &status, &method, &refunded)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if status != PaymentSucceeded {
+			return ErrLocked
+		}
+
+		if payment.Amount > amount-refunded {
+			return ErrOverpayment
+		}
+
+		// money goes back the way it came
+		payment.Method = method
+	}
+
+	query := `
+		INSERT INTO payments (invoice_id, kind, method, amount, status, refund_of, reason, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRowContext(ctx, query,
+		payment.InvoiceID,
+		payment.Kind,
+		payment.Method,
+		payment.Amount,
+		payment.Status,
+		payment.RefundOf,
+		payment.Reason,
+		payment.CreatedBy,
+	).Scan(&payment.ID, &payment.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	payment.PatientID = invoice.PatientID
+	payment.Currency = invoice.Currency
+	return nil
+}
+
+// SettlePayment records the gateway's answer for a pending payment or
+// refund: succeeded with its reference, or failed with the reason.
+func (s *BillingStore) SettlePayment(ctx context.Context, payment *Payment, status PaymentStatus, gatewayRef, failure *string) error {
+	query := `
+		UPDATE payments SET status = $2, gateway_ref = $3, failure = $4
+		WHERE id = $1 AND status = 'pending'
+		RETURNING status, gateway_ref, failure
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, payment.ID, status, gatewayRef, failure).Scan(
+		&payment.Status,
+		&payment.GatewayRef,
+		&payment.Failure,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrLocked
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *BillingStore) SetReceipt(ctx context.Context, payment *Payment, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `UPDATE payments SET receipt_key = $2 WHERE id = $1`, payment.ID, key)
+	if err != nil {
+		return err
+	}
+
+	payment.ReceiptKey = &key
+	return nil
+}
+
+func (s *BillingStore) GetPayment(ctx context.Context, id uuid.UUID) (*Payment, error) {
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments p
+		JOIN invoices i ON i.id = p.invoice_id
+		WHERE p.id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	payment, err := scanPayment(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return payment, nil
+}
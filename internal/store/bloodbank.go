@@ -0,0 +1,1176 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrDonorNotEligible is returned for a donation by a deferred donor or
+// one who donated too recently. It is wrapped with the reason.
+var ErrDonorNotEligible = errors.New("donor is not eligible to donate")
+
+// ErrIncompatibleUnit is returned when a unit does not suit a crossmatch
+// request's component or patient.
+var ErrIncompatibleUnit = errors.New("unit is not compatible with the request")
+
+type BloodComponent string
+
+const (
+	BloodWholeBlood      BloodComponent = "whole_blood"
+	BloodRedCells        BloodComponent = "red_cells"
+	BloodPlatelets       BloodComponent = "platelets"
+	BloodPlasma          BloodComponent = "plasma"
+	BloodCryoprecipitate BloodComponent = "cryoprecipitate"
+)
+
+type BloodUnitStatus string
+
+const (
+	BloodUnitAvailable BloodUnitStatus = "available"
+	BloodUnitReserved  BloodUnitStatus = "reserved"
+	BloodUnitIssued    BloodUnitStatus = "issued"
+	BloodUnitDiscarded BloodUnitStatus = "discarded"
+)
+
+type CrossmatchStatus string
+
+const (
+	CrossmatchPending CrossmatchStatus = "pending"
+	CrossmatchReady   CrossmatchStatus = "ready"
+	CrossmatchClosed  CrossmatchStatus = "closed"
+)
+
+type CrossmatchUrgency string
+
+const (
+	CrossmatchRoutine   CrossmatchUrgency = "routine"
+	CrossmatchUrgent    CrossmatchUrgency = "urgent"
+	CrossmatchEmergency CrossmatchUrgency = "emergency"
+)
+
+type CrossmatchResult string
+
+const (
+	CrossmatchCompatible   CrossmatchResult = "compatible"
+	CrossmatchIncompatible CrossmatchResult = "incompatible"
+)
+
+type BloodDonor struct {
+	ID             uuid.UUID       `json:"id"`
+	Name           string          `json:"name"`
+	BloodGroup     BloodGroup      `json:"blood_group"`
+	DateOfBirth    string          `json:"date_of_birth"`
+	Phone          string          `json:"phone"`
+	Email          string          `json:"email"`
+	DeferredUntil  *string         `json:"deferred_until"`
+	DeferralReason string          `json:"deferral_reason"`
+	Notes          string          `json:"notes"`
+	LastDonatedAt  *time.Time      `json:"last_donated_at"`
+	CreatedBy      *uuid.UUID      `json:"created_by"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Donations      []BloodDonation `json:"donations,omitempty"`
+}
+
+// BloodDonation is one collection from a donor, separated into Units.
+type BloodDonation struct {
+	ID          uuid.UUID   `json:"id"`
+	DonorID     uuid.UUID   `json:"donor_id"`
+	VolumeML    int         `json:"volume_ml"`
+	CollectedBy *uuid.UUID  `json:"collected_by"`
+	Notes       string      `json:"notes"`
+	DonatedAt   time.Time   `json:"donated_at"`
+	Units       []BloodUnit `json:"units"`
+}
+
+// BloodUnit is one bag of a blood component. Source names the bank it
+// was received from when it was not donated here.
+type BloodUnit struct {
+	ID            uuid.UUID       `json:"id"`
+	UnitNumber    string          `json:"unit_number"`
+	DonationID    *uuid.UUID      `json:"donation_id"`
+	Source        string          `json:"source"`
+	Component     BloodComponent  `json:"component"`
+	BloodGroup    BloodGroup      `json:"blood_group"`
+	VolumeML      int             `json:"volume_ml"`
+	CollectedOn   string          `json:"collected_on"`
+	ExpiresOn     string          `json:"expires_on"`
+	Status        BloodUnitStatus `json:"status"`
+	ReservedFor   *uuid.UUID      `json:"reserved_for"`
+	DiscardReason string          `json:"discard_reason"`
+	ReceivedBy    *uuid.UUID      `json:"received_by"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	Expired       bool            `json:"expired"`
+}
+
+// BloodUnitQuery filters unit lists. BloodGroups matches any of the groups
+// and ExpiringWithinDays the units expiring within that many days.
+type BloodUnitQuery struct {
+	Status             BloodUnitStatus
+	Component          BloodComponent
+	BloodGroups        []BloodGroup
+	ExpiringWithinDays *int
+}
+
+// BloodStock counts the usable units of one component and group.
+type BloodStock struct {
+	Component      BloodComponent `json:"component"`
+	BloodGroup     BloodGroup     `json:"blood_group"`
+	Units          int            `json:"units"`
+	VolumeML       int            `json:"volume_ml"`
+	EarliestExpiry string         `json:"earliest_expiry"`
+}
+
+// CrossmatchRequest asks the blood bank for units of a component for a
+// patient of BloodGroup.
+type CrossmatchRequest struct {
+	ID              uuid.UUID         `json:"id"`
+	PatientID       uuid.UUID         `json:"patient_id"`
+	BloodGroup      BloodGroup        `json:"blood_group"`
+	Component       BloodComponent    `json:"component"`
+	UnitsRequested  int               `json:"units_requested"`
+	Urgency         CrossmatchUrgency `json:"urgency"`
+	Indication      string            `json:"indication"`
+	RequiredBy      *time.Time        `json:"required_by"`
+	RequestedBy     uuid.UUID         `json:"requested_by"`
+	RequestedByName string            `json:"requested_by_name"`
+	Status          CrossmatchStatus  `json:"status"`
+	ClosedBy        *uuid.UUID        `json:"closed_by"`
+	ClosedAt        *time.Time        `json:"closed_at"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	Tests           []CrossmatchTest  `json:"tests,omitempty"`
+	ReservedUnits   []BloodUnit       `json:"reserved_units,omitempty"`
+	Issues          []BloodIssue      `json:"issues,omitempty"`
+}
+
+type CrossmatchTest struct {
+	ID         uuid.UUID        `json:"id"`
+	RequestID  uuid.UUID        `json:"request_id"`
+	UnitID     uuid.UUID        `json:"unit_id"`
+	UnitNumber string           `json:"unit_number"`
+	Result     CrossmatchResult `json:"result"`
+	Notes      string           `json:"notes"`
+	TestedBy   *uuid.UUID       `json:"tested_by"`
+	TestedAt   time.Time        `json:"tested_at"`
+}
+
+// BloodIssue is a unit handed out for a patient, and its return if it came
+// back unused. Reusable tells whether a returned unit went back into stock.
+type BloodIssue struct {
+	ID           uuid.UUID  `json:"id"`
+	UnitID       uuid.UUID  `json:"unit_id"`
+	UnitNumber   string     `json:"unit_number"`
+	RequestID    uuid.UUID  `json:"request_id"`
+	PatientID    uuid.UUID  `json:"patient_id"`
+	IssuedBy     *uuid.UUID `json:"issued_by"`
+	IssuedTo     string     `json:"issued_to"`
+	IssuedAt     time.Time  `json:"issued_at"`
+	ReturnedAt   *time.Time `json:"returned_at"`
+	ReturnedBy   *uuid.UUID `json:"returned_by"`
+	Reusable     *bool      `json:"reusable"`
+	ReturnReason string     `json:"return_reason"`
+}
+
+type CrossmatchQuery struct {
+	PatientID *uuid.UUID       `json:"-"`
+	Status    CrossmatchStatus `json:"status" validate:"omitempty,oneof=pending ready closed"`
+	Open      bool             `json:"open"`
+	Limit     int              `json:"limit" validate:"gte=1,lte=100"`
+	Offset    int              `json:"offset" validate:"gte=0"`
+}
+
+func (q CrossmatchQuery) Parse(r *http.Request) (CrossmatchQuery, error) {
+	qs := r.URL.Query()
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return q, err
+		}
+
+		q.Limit = l
+	}
+
+	offset := qs.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return q, err
+		}
+
+		q.Offset = o
+	}
+
+	if status := qs.Get("status"); status != "" {
+		q.Status = CrossmatchStatus(status)
+	}
+
+	if open := qs.Get("open"); open != "" {
+		o, err := strconv.ParseBool(open)
+		if err != nil {
+			return q, err
+		}
+
+		q.Open = o
+	}
+
+	return q, nil
+}
+
+type BloodBankStore struct {
+	db *sql.DB
+}
+
+const bloodDonorQuery = `
+	SELECT d.id, d.name, d.blood_group, to_char(d.date_of_birth, 'YYYY-MM-DD'), d.phone, d.email,
+		to_char(d.deferred_until, 'YYYY-MM-DD'), d.deferral_reason, d.notes,
+		(SELECT MAX(donated_at) FROM blood_donations WHERE donor_id = d.id), d.created_by, d.created_at
+	FROM blood_donors d`
+
+func scanBloodDonor(row rowScanner) (*BloodDonor, error) {
+	d := &BloodDonor{}
+
+	err := row.Scan(
+		&d.ID,
+		&d.Name,
+		&d.BloodGroup,
+		&d.DateOfBirth,
+		&d.Phone,
+		&d.Email,
+		&d.DeferredUntil,
+		&d.DeferralReason,
+		&d.Notes,
+		&d.LastDonatedAt,
+		&d.CreatedBy,
+		&d.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (s *BloodBankStore) CreateDonor(ctx context.Context, donor *BloodDonor) error {
+	query := `
+		INSERT INTO blood_donors (name, blood_group, date_of_birth, phone, email, notes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		donor.Name,
+		donor.BloodGroup,
+		donor.DateOfBirth,
+		donor.Phone,
+		donor.Email,
+		donor.Notes,
+		donor.CreatedBy,
+	).Scan(&donor.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.reloadDonor(ctx, donor)
+}
+
+func (s *BloodBankStore) reloadDonor(ctx context.Context, donor *BloodDonor) error {
+	fresh, err := scanBloodDonor(s.db.QueryRowContext(ctx, bloodDonorQuery+` WHERE d.id = $1`, donor.ID))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	*donor = *fresh
+	return nil
+}
+
+// GetDonors lists donors by name, optionally those whose name starts with
+// search or whose phone number is search.
+func (s *BloodBankStore) GetDonors(ctx context.Context, search string) ([]BloodDonor, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, bloodDonorQuery+`
+		WHERE $1 = '' OR d.name ILIKE $1 || '%' OR d.phone = $1
+		ORDER BY lower(d.name), d.id
+		LIMIT 200
+	`, search)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	donors := []BloodDonor{}
+	for rows.Next() {
+		donor, err := scanBloodDonor(rows)
+		if err != nil {
+			return nil, err
+		}
+		donors = append(donors, *donor)
+	}
+
+	return donors, rows.Err()
+}
+
+// GetDonor returns a donor with their donations, newest first.
+func (s *BloodBankStore) GetDonor(ctx context.Context, id uuid.UUID) (*BloodDonor, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	donor, err := scanBloodDonor(s.db.QueryRowContext(ctx, bloodDonorQuery+` WHERE d.id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, donor_id, volume_ml, collected_by, notes, donated_at
+		FROM blood_donations
+		WHERE donor_id = $1
+		ORDER BY donated_at DESC, id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	donor.Donations = []BloodDonation{}
+	ids := []string{}
+	for rows.Next() {
+		d := BloodDonation{Units: []BloodUnit{}}
+		if err := rows.Scan(&d.ID, &d.DonorID, &d.VolumeML, &d.CollectedBy, &d.Notes, &d.DonatedAt); err != nil {
+			return nil, err
+		}
+		donor.Donations = append(donor.Donations, d)
+		ids = append(ids, d.ID.String())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	units, err := getBloodUnits(ctx, s.db, bloodUnitQuery+`
+		WHERE u.donation_id = ANY($1::uuid[])
+		ORDER BY u.unit_number
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range donor.Donations {
+		for _, unit := range units {
+			if unit.DonationID != nil && *unit.DonationID == donor.Donations[i].ID {
+				donor.Donations[i].Units = append(donor.Donations[i].Units, unit)
+			}
+		}
+	}
+
+	return donor, nil
+}
+
+// DeferDonor sets or, with a nil DeferredUntil, lifts a donor's deferral.
+func (s *BloodBankStore) DeferDonor(ctx context.Context, donor *BloodDonor) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE blood_donors SET deferred_until = $2, deferral_reason = $3
+		WHERE id = $1
+	`, donor.ID, donor.DeferredUntil, donor.DeferralReason)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return s.reloadDonor(ctx, donor)
+}
+
+// RecordDonation records a donation and the units separated from it, which
+// take the donor's blood group. It returns an error wrapping
+// ErrDonorNotEligible if the donor is deferred on the day or donated less
+// than intervalDays before, and ErrConflict for a unit number in use.
+func (s *BloodBankStore) RecordDonation(ctx context.Context, donation *BloodDonation, intervalDays int) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		// the row lock keeps two donations of the donor from both passing
+		var (
+			group     BloodGroup
+			deferred  bool
+			tooRecent bool
+		)
+		err := tx.QueryRowContext(ctx, `
+			SELECT d.blood_group,
+				COALESCE(d.deferred_until > $2::timestamptz::date, false),
+				EXISTS (
+					SELECT 1 FROM blood_donations
+					WHERE donor_id = d.id AND donated_at > $2::timestamptz - $3 * interval '1 day'
+						AND donated_at < $2::timestamptz + $3 * interval '1 day'
+				)
+			FROM blood_donors d
+			WHERE d.id = $1
+			FOR UPDATE
+		`, donation.DonorID, donation.DonatedAt, intervalDays).Scan(&group, &deferred, &tooRecent)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		switch {
+		case deferred:
+			return fmt.Errorf("%w: deferred", ErrDonorNotEligible)
+		case tooRecent:
+			return fmt.Errorf("%w: donations must be %d days apart", ErrDonorNotEligible, intervalDays)
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO blood_donations (donor_id, volume_ml, collected_by, notes, donated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, donated_at
+		`, donation.DonorID, donation.VolumeML, donation.CollectedBy, donation.Notes, donation.DonatedAt).Scan(
+			&donation.ID,
+			&donation.DonatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		for i := range donation.Units {
+			unit := &donation.Units[i]
+			unit.DonationID = &donation.ID
+			unit.BloodGroup = group
+			unit.CollectedOn = donation.DonatedAt.UTC().Format(DateLayout)
+			unit.ReceivedBy = donation.CollectedBy
+
+			if err := insertBloodUnit(ctx, tx, unit); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+const bloodUnitQuery = `
+	SELECT u.id, u.unit_number, u.donation_id, u.source, u.component, u.blood_group, u.volume_ml,
+		to_char(u.collected_on, 'YYYY-MM-DD'), to_char(u.expires_on, 'YYYY-MM-DD'), u.status, u.reserved_for,
+		u.discard_reason, u.received_by, u.created_at, u.updated_at, u.expires_on < CURRENT_DATE
+	FROM blood_units u`
+
+func scanBloodUnit(row rowScanner) (*BloodUnit, error) {
+	u := &BloodUnit{}
+
+	err := row.Scan(
+		&u.ID,
+		&u.UnitNumber,
+		&u.DonationID,
+		&u.Source,
+		&u.Component,
+		&u.BloodGroup,
+		&u.VolumeML,
+		&u.CollectedOn,
+		&u.ExpiresOn,
+		&u.Status,
+		&u.ReservedFor,
+		&u.DiscardReason,
+		&u.ReceivedBy,
+		&u.CreatedAt,
+		&u.UpdatedAt,
+		&u.Expired,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func getBloodUnits(ctx context.Context, q queryer, query string, args ...any) ([]BloodUnit, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []BloodUnit{}
+	for rows.Next() {
+		unit, err := scanBloodUnit(rows)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, *unit)
+	}
+
+	return units, rows.Err()
+}
+
+func insertBloodUnit(ctx context.Context, q rowQueryer, unit *BloodUnit) error {
+	query := `
+		INSERT INTO blood_units (unit_number, donation_id, source, component, blood_group, volume_ml, collected_on, expires_on, received_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, status, created_at, updated_at, expires_on < CURRENT_DATE
+	`
+
+	err := q.QueryRowContext(ctx, query,
+		unit.UnitNumber,
+		unit.DonationID,
+		unit.Source,
+		unit.Component,
+		unit.BloodGroup,
+		unit.VolumeML,
+		unit.CollectedOn,
+		unit.ExpiresOn,
+		unit.ReceivedBy,
+	).Scan(&unit.ID, &unit.Status, &unit.CreatedAt, &unit.UpdatedAt, &unit.Expired)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "blood_units_unit_number_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddUnit records a unit received from another bank. It returns
+// ErrConflict for a unit number in use.
+func (s *BloodBankStore) AddUnit(ctx context.Context, unit *BloodUnit) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return insertBloodUnit(ctx, s.db, unit)
+}
+
+func (s *BloodBankStore) GetUnit(ctx context.Context, id uuid.UUID) (*BloodUnit, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	unit, err := scanBloodUnit(s.db.QueryRowContext(ctx, bloodUnitQuery+` WHERE u.id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return unit, nil
+}
+
+// GetUnits lists units, the soonest to expire first.
+func (s *BloodBankStore) GetUnits(ctx context.Context, q BloodUnitQuery) ([]BloodUnit, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var groups any
+	if q.BloodGroups != nil {
+		groups = pq.Array(bloodGroupStrings(q.BloodGroups))
+	}
+
+	return getBloodUnits(ctx, s.db, bloodUnitQuery+`
+		WHERE ($1 = '' OR u.status::text = $1)
+			AND ($2 = '' OR u.component::text = $2)
+			AND ($3::text[] IS NULL OR u.blood_group::text = ANY($3))
+			AND ($4::int IS NULL OR u.expires_on <= CURRENT_DATE + $4::int)
+		ORDER BY u.expires_on, u.unit_number
+		LIMIT 500
+	`, q.Status, q.Component, groups, q.ExpiringWithinDays)
+}
+
+// GetStock counts the available unexpired units of the component in each
+// of the groups.
+func (s *BloodBankStore) GetStock(ctx context.Context, component BloodComponent, groups []BloodGroup) ([]BloodStock, error) {
+	query := `
+		SELECT component, blood_group, COUNT(*), SUM(volume_ml), to_char(MIN(expires_on), 'YYYY-MM-DD')
+		FROM blood_units
+		WHERE status = 'available' AND expires_on >= CURRENT_DATE
+			AND component = $1 AND blood_group::text = ANY($2)
+		GROUP BY component, blood_group
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, component, pq.Array(bloodGroupStrings(groups)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stock := []BloodStock{}
+	for rows.Next() {
+		var st BloodStock
+		if err := rows.Scan(&st.Component, &st.BloodGroup, &st.Units, &st.VolumeML, &st.EarliestExpiry); err != nil {
+			return nil, err
+		}
+		stock = append(stock, st)
+	}
+
+	return stock, rows.Err()
+}
+
+// DiscardUnit takes an available or reserved unit out of stock. It returns
+// ErrLocked once the unit is issued or discarded.
+func (s *BloodBankStore) DiscardUnit(ctx context.Context, unit *BloodUnit, reason string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	fresh, err := scanBloodUnit(s.db.QueryRowContext(ctx, `
+		WITH u AS (
+			UPDATE blood_units SET status = 'discarded', reserved_for = NULL, discard_reason = $2, updated_at = NOW()
+			WHERE id = $1 AND status IN ('available', 'reserved')
+			RETURNING *
+		)
+		SELECT u.id, u.unit_number, u.donation_id, u.source, u.component, u.blood_group, u.volume_ml,
+			to_char(u.collected_on, 'YYYY-MM-DD'), to_char(u.expires_on, 'YYYY-MM-DD'), u.status, u.reserved_for,
+			u.discard_reason, u.received_by, u.created_at, u.updated_at, u.expires_on < CURRENT_DATE
+		FROM u
+	`, unit.ID, reason))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrLocked
+		default:
+			return err
+		}
+	}
+
+	*unit = *fresh
+	return nil
+}
+
+const crossmatchQuery = `
+	SELECT c.id, c.patient_id, c.blood_group, c.component, c.units_requested, c.urgency, c.indication,
+		c.required_by, c.requested_by, u.username, c.status, c.closed_by, c.closed_at, c.created_at, c.updated_at
+	FROM crossmatch_requests c
+	JOIN users u ON u.id = c.requested_by`
+
+func scanCrossmatch(row rowScanner) (*CrossmatchRequest, error) {
+	c := &CrossmatchRequest{}
+
+	err := row.Scan(
+		&c.ID,
+		&c.PatientID,
+		&c.BloodGroup,
+		&c.Component,
+		&c.UnitsRequested,
+		&c.Urgency,
+		&c.Indication,
+		&c.RequiredBy,
+		&c.RequestedBy,
+		&c.RequestedByName,
+		&c.Status,
+		&c.ClosedBy,
+		&c.ClosedAt,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *BloodBankStore) CreateCrossmatch(ctx context.Context, request *CrossmatchRequest) error {
+	query := `
+		INSERT INTO crossmatch_requests (patient_id, blood_group, component, units_requested, urgency, indication, required_by, requested_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		request.PatientID,
+		request.BloodGroup,
+		request.Component,
+		request.UnitsRequested,
+		request.Urgency,
+		request.Indication,
+		request.RequiredBy,
+		request.RequestedBy,
+	).Scan(&request.ID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "crossmatch_requests_patient_id_fkey"):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	fresh, err := s.GetCrossmatch(ctx, request.ID)
+	if err != nil {
+		return err
+	}
+
+	*request = *fresh
+	return nil
+}
+
+// GetCrossmatch returns a request with its tests, reserved units and
+// issues.
+func (s *BloodBankStore) GetCrossmatch(ctx context.Context, id uuid.UUID) (*CrossmatchRequest, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	request, err := scanCrossmatch(s.db.QueryRowContext(ctx, crossmatchQuery+` WHERE c.id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.id, t.request_id, t.unit_id, u.unit_number, t.result, t.notes, t.tested_by, t.tested_at
+		FROM crossmatch_tests t
+		JOIN blood_units u ON u.id = t.unit_id
+		WHERE t.request_id = $1
+		ORDER BY t.tested_at, t.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	request.Tests = []CrossmatchTest{}
+	for rows.Next() {
+		var t CrossmatchTest
+		if err := rows.Scan(&t.ID, &t.RequestID, &t.UnitID, &t.UnitNumber, &t.Result, &t.Notes, &t.TestedBy, &t.TestedAt); err != nil {
+			return nil, err
+		}
+		request.Tests = append(request.Tests, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	request.ReservedUnits, err = getBloodUnits(ctx, s.db, bloodUnitQuery+`
+		WHERE u.reserved_for = $1
+		ORDER BY u.expires_on, u.unit_number
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Issues, err = getBloodIssues(ctx, s.db, bloodIssueQuery+`
+		WHERE i.request_id = $1
+		ORDER BY i.issued_at, i.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// GetCrossmatches lists requests without their details, the open ones by
+// urgency and then the oldest first, the rest newest first.
+func (s *BloodBankStore) GetCrossmatches(ctx context.Context, q CrossmatchQuery) ([]*CrossmatchRequest, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, crossmatchQuery+`
+		WHERE ($1::uuid IS NULL OR c.patient_id = $1)
+			AND ($2 = '' OR c.status::text = $2)
+			AND (NOT $3 OR c.status <> 'closed')
+		ORDER BY c.status = 'closed',
+			CASE WHEN c.status <> 'closed' THEN array_position(ARRAY['emergency', 'urgent', 'routine'], c.urgency::text) END,
+			CASE WHEN c.status <> 'closed' THEN c.created_at END,
+			c.created_at DESC, c.id
+		LIMIT $4 OFFSET $5
+	`, q.PatientID, q.Status, q.Open, q.Limit, q.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*CrossmatchRequest{}
+	for rows.Next() {
+		request, err := scanCrossmatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// lockCrossmatch locks an open request for the rest of the transaction and
+// returns how many units it holds, reserved or issued and not returned. It
+// returns ErrLocked once the request is closed.
+func lockCrossmatch(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*CrossmatchRequest, int, error) {
+	request := &CrossmatchRequest{ID: id}
+
+	err := tx.QueryRowContext(ctx, `
+		SELECT patient_id, blood_group, component, units_requested, status
+		FROM crossmatch_requests
+		WHERE id = $1
+		FOR UPDATE
+	`, id).Scan(&request.PatientID, &request.BloodGroup, &request.Component, &request.UnitsRequested, &request.Status)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, 0, ErrNotFound
+		default:
+			return nil, 0, err
+		}
+	}
+
+	if request.Status == CrossmatchClosed {
+		return nil, 0, ErrLocked
+	}
+
+	var held int
+	err = tx.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM blood_units WHERE reserved_for = $1)
+			+ (SELECT COUNT(*) FROM blood_issues WHERE request_id = $1 AND returned_at IS NULL)
+	`, id).Scan(&held)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return request, held, nil
+}
+
+// RecordCrossmatch records the result of crossmatching a unit against a
+// request. A compatible unit is reserved for it. The unit must be
+// available, unexpired, of the requested component and of one of the
+// donor groups the patient may receive, otherwise it returns an error
+// wrapping ErrIncompatibleUnit. It returns ErrLocked once the request is
+// closed, ErrConflict if the request already holds all its units or the
+// unit was already tested for it.
+func (s *BloodBankStore) RecordCrossmatch(ctx context.Context, test *CrossmatchTest, groups []BloodGroup) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		request, held, err := lockCrossmatch(ctx, tx, test.RequestID)
+		if err != nil {
+			return err
+		}
+
+		unit, err := scanBloodUnit(tx.QueryRowContext(ctx, bloodUnitQuery+` WHERE u.id = $1 FOR UPDATE`, test.UnitID))
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		switch {
+		case unit.Status != BloodUnitAvailable:
+			return fmt.Errorf("%w: unit %s is %s", ErrIncompatibleUnit, unit.UnitNumber, unit.Status)
+		case unit.Expired:
+			return fmt.Errorf("%w: unit %s expired on %s", ErrIncompatibleUnit, unit.UnitNumber, unit.ExpiresOn)
+		case unit.Component != request.Component:
+			return fmt.Errorf("%w: unit %s is %s, not %s", ErrIncompatibleUnit, unit.UnitNumber, unit.Component, request.Component)
+		case !containsBloodGroup(groups, unit.BloodGroup):
+			return fmt.Errorf("%w: %s %s cannot be given to a %s patient", ErrIncompatibleUnit, unit.BloodGroup, unit.Component, request.BloodGroup)
+		}
+
+		if test.Result == CrossmatchCompatible && held >= request.UnitsRequested {
+			return ErrConflict
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO crossmatch_tests (request_id, unit_id, result, notes, tested_by)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, tested_at
+		`, test.RequestID, test.UnitID, test.Result, test.Notes, test.TestedBy).Scan(&test.ID, &test.TestedAt)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "crossmatch_tests_request_unit_key"):
+				return ErrConflict
+			default:
+				return err
+			}
+		}
+		test.UnitNumber = unit.UnitNumber
+
+		if test.Result != CrossmatchCompatible {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE blood_units SET status = 'reserved', reserved_for = $2, updated_at = NOW()
+			WHERE id = $1
+		`, unit.ID, request.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE crossmatch_requests SET status = 'ready', updated_at = NOW()
+			WHERE id = $1
+		`, request.ID)
+		return err
+	})
+}
+
+func bloodGroupStrings(groups []BloodGroup) []string {
+	strs := make([]string, len(groups))
+	for i, g := range groups {
+		strs[i] = string(g)
+	}
+	return strs
+}
+
+func containsBloodGroup(groups []BloodGroup, group BloodGroup) bool {
+	for _, g := range groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+const bloodIssueQuery = `
+	SELECT i.id, i.unit_id, u.unit_number, i.request_id, i.patient_id, i.issued_by, i.issued_to, i.issued_at,
+		i.returned_at, i.returned_by, i.reusable, i.return_reason
+	FROM blood_issues i
+	JOIN blood_units u ON u.id = i.unit_id`
+
+func scanBloodIssue(row rowScanner) (*BloodIssue, error) {
+	i := &BloodIssue{}
+
+	err := row.Scan(
+		&i.ID,
+		&i.UnitID,
+		&i.UnitNumber,
+		&i.RequestID,
+		&i.PatientID,
+		&i.IssuedBy,
+		&i.IssuedTo,
+		&i.IssuedAt,
+		&i.ReturnedAt,
+		&i.ReturnedBy,
+		&i.Reusable,
+		&i.ReturnReason,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func getBloodIssues(ctx context.Context, q queryer, query string, args ...any) ([]BloodIssue, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	issues := []BloodIssue{}
+	for rows.Next() {
+		issue, err := scanBloodIssue(rows)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, *issue)
+	}
+
+	return issues, rows.Err()
+}
+
+func (s *BloodBankStore) GetIssue(ctx context.Context, id uuid.UUID) (*BloodIssue, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	issue, err := scanBloodIssue(s.db.QueryRowContext(ctx, bloodIssueQuery+` WHERE i.id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return issue, nil
+}
+
+// IssueUnit hands out a unit reserved for the request. It returns
+// ErrLocked once the request is closed, and ErrConflict unless the unit is
+// reserved for the request and unexpired.
+func (s *BloodBankStore) IssueUnit(ctx context.Context, issue *BloodIssue) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		request, _, err := lockCrossmatch(ctx, tx, issue.RequestID)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			UPDATE blood_units SET status = 'issued', reserved_for = NULL, updated_at = NOW()
+			WHERE id = $1 AND reserved_for = $2 AND expires_on >= CURRENT_DATE
+		`, issue.UnitID, issue.RequestID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrConflict
+		}
+
+		issue.PatientID = request.PatientID
+
+		return tx.QueryRowContext(ctx, `
+			INSERT INTO blood_issues (unit_id, request_id, patient_id, issued_by, issued_to)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, issue.UnitID, issue.RequestID, issue.PatientID, issue.IssuedBy, issue.IssuedTo).Scan(&issue.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	fresh, err := s.GetIssue(ctx, issue.ID)
+	if err != nil {
+		return err
+	}
+
+	*issue = *fresh
+	return nil
+}
+
+// ReturnUnit records an issued unit coming back unused. A reusable unit
+// that has not expired goes back into stock, any other is discarded with
+// the reason. It returns ErrLocked if the unit was already returned.
+func (s *BloodBankStore) ReturnUnit(ctx context.Context, issue *BloodIssue) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		res, err := tx.ExecContext(ctx, `
+			UPDATE blood_issues SET returned_at = NOW(), returned_by = $2, reusable = $3, return_reason = $4
+			WHERE id = $1 AND returned_at IS NULL
+		`, issue.ID, issue.ReturnedBy, issue.Reusable, issue.ReturnReason)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrLocked
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE blood_units SET
+				status = CASE WHEN $2 AND expires_on >= CURRENT_DATE THEN 'available' ELSE 'discarded' END::blood_unit_status,
+				discard_reason = CASE WHEN $2 AND expires_on >= CURRENT_DATE THEN '' ELSE 'returned: ' || $3 END,
+				updated_at = NOW()
+			WHERE id = $1
+		`, issue.UnitID, issue.Reusable != nil && *issue.Reusable, issue.ReturnReason)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fresh, err := s.GetIssue(ctx, issue.ID)
+	if err != nil {
+		return err
+	}
+
+	*issue = *fresh
+	return nil
+}
+
+// CloseCrossmatch closes a request and puts the units still reserved for
+// it back into stock. It returns ErrLocked if it was already closed.
+func (s *BloodBankStore) CloseCrossmatch(ctx context.Context, request *CrossmatchRequest, closedBy uuid.UUID) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if _, _, err := lockCrossmatch(ctx, tx, request.ID); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			UPDATE blood_units SET status = 'available', reserved_for = NULL, updated_at = NOW()
+			WHERE reserved_for = $1
+		`, request.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE crossmatch_requests SET status = 'closed', closed_by = $2, closed_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+		`, request.ID, closedBy)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fresh, err := s.GetCrossmatch(ctx, request.ID)
+	if err != nil {
+		return err
+	}
+
+	*request = *fresh
+	return nil
+}
@@ -0,0 +1,610 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type ClaimStatus string
+
+const (
+	ClaimDraft         ClaimStatus = "draft"
+	ClaimSubmitted     ClaimStatus = "submitted"
+	ClaimApproved      ClaimStatus = "approved"
+	ClaimPartiallyPaid ClaimStatus = "partially_paid"
+	ClaimDenied        ClaimStatus = "denied"
+)
+
+// Claim bills an issued invoice to an insurer. The policy details are
+// copied from the patient's policy when the claim is created. Amounts are in
+// minor units of Currency.
+type Claim struct {
+	ID             uuid.UUID         `json:"id"`
+	Number         string            `json:"number"`
+	InvoiceID      uuid.UUID         `json:"invoice_id"`
+	PatientID      uuid.UUID         `json:"patient_id"`
+	PolicyID       *uuid.UUID        `json:"policy_id"`
+	Payer          string            `json:"payer"`
+	PlanName       string            `json:"plan_name"`
+	PolicyNumber   string            `json:"policy_number"`
+	MemberID       string            `json:"member_id"`
+	GroupNumber    string            `json:"group_number"`
+	DiagnosisCodes []string          `json:"diagnosis_codes"`
+	Status         ClaimStatus       `json:"status"`
+	Currency       string            `json:"currency"`
+	TotalAmount    int64             `json:"total_amount"`
+	PaidAmount     int64             `json:"paid_amount"`
+	SubmittedAt    *time.Time        `json:"submitted_at"`
+	AdjudicatedAt  *time.Time        `json:"adjudicated_at"`
+	CreatedBy      *uuid.UUID        `json:"created_by"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	Lines          []ClaimLine       `json:"lines,omitempty"`
+	Remittances    []ClaimRemittance `json:"remittances,omitempty"`
+}
+
+type ClaimLine struct {
+	Position      int        `json:"position"`
+	InvoiceItemID *uuid.UUID `json:"invoice_item_id"`
+	Kind          ChargeKind `json:"kind"`
+	Code          string     `json:"code"`
+	Description   string     `json:"description"`
+	Quantity      int        `json:"quantity"`
+	// Amount is billed for the line, tax included
+	Amount      int64  `json:"amount"`
+	ServiceDate string `json:"service_date"`
+}
+
+// ClaimRemittance is a payer's answer to a claim. A denial pays nothing;
+// other remittances are posted to the invoice as an insurance payment.
+type ClaimRemittance struct {
+	ID           uuid.UUID  `json:"id"`
+	ClaimID      uuid.UUID  `json:"claim_id"`
+	PaidAmount   int64      `json:"paid_amount"`
+	Reference    string     `json:"reference"`
+	DenialReason string     `json:"denial_reason"`
+	PaymentID    *uuid.UUID `json:"payment_id"`
+	RecordedBy   *uuid.UUID `json:"recorded_by"`
+	RemittedAt   time.Time  `json:"remitted_at"`
+}
+
+type ClaimQuery struct {
+	PatientID     *uuid.UUID  `json:"-"`
+	IncludeDrafts bool        `json:"-"`
+	Status        ClaimStatus `json:"status" validate:"omitempty,oneof=draft submitted approved partially_paid denied"`
+	Payer         string      `json:"payer" validate:"max=255"`
+	Limit         int         `json:"limit" validate:"gte=1,lte=500"`
+	Offset        int         `json:"offset" validate:"gte=0"`
+}
+
+func (q ClaimQuery) Parse(r *http.Request) (ClaimQuery, error) {
+	qs := r.URL.Query()
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return q, err
+		}
+
+		q.Limit = l
+	}
+
+	offset := qs.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return q, err
+		}
+
+		q.Offset = o
+	}
+
+	if status := qs.Get("status"); status != "" {
+		q.Status = ClaimStatus(status)
+	}
+
+	q.Payer = strings.TrimSpace(qs.Get("payer"))
+
+	return q, nil
+}
+
+type ClaimStore struct {
+	db *sql.DB
+}
+
+const claimQuery = `
+	SELECT c.id, c.number, c.invoice_id, c.patient_id, c.policy_id, c.payer, c.plan_name, c.policy_number,
+		c.member_id, c.group_number, c.diagnosis_codes, c.status, i.currency,
+		COALESCE((SELECT SUM(amount) FROM claim_lines WHERE claim_id = c.id), 0),
+		COALESCE((SELECT SUM(paid_amount) FROM claim_remittances WHERE claim_id = c.id), 0),
+		c.submitted_at, c.adjudicated_at, c.created_by, c.created_at, c.updated_at
+	FROM claims c
+	JOIN invoices i ON i.id = c.invoice_id`
+
+func scanClaim(row rowScanner) (*Claim, error) {
+	c := &Claim{}
+	err := row.Scan(
+		&c.ID,
+		&c.Number,
+		&c.InvoiceID,
+		&c.PatientID,
+		&c.PolicyID,
+		&c.Payer,
+		&c.PlanName,
+		&c.PolicyNumber,
+		&c.MemberID,
+		&c.GroupNumber,
+		pq.Array(&c.DiagnosisCodes),
+		&c.Status,
+		&c.Currency,
+		&c.TotalAmount,
+		&c.PaidAmount,
+		&c.SubmittedAt,
+		&c.AdjudicatedAt,
+		&c.CreatedBy,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.DiagnosisCodes == nil {
+		c.DiagnosisCodes = []string{}
+	}
+
+	return c, nil
+}
+
+// PrefillDiagnoses returns the diagnoses of the signed encounters behind the
+// invoice's consultations and lab orders, principal diagnoses first.
+func (s *ClaimStore) PrefillDiagnoses(ctx context.Context, invoiceID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT ed.code
+		FROM encounters e
+		JOIN encounter_diagnoses ed ON ed.encounter_id = e.id
+		WHERE e.status = 'signed' AND e.id IN (
+			SELECT e2.id
+			FROM invoice_items it
+			JOIN encounters e2 ON it.source_key = 'appointment:' || e2.appointment_id::text
+			WHERE it.invoice_id = $1
+			UNION
+			SELECT o.encounter_id
+			FROM invoice_items it
+			JOIN lab_orders o ON it.source_key LIKE 'lab_order:' || o.id::text || ':%'
+			WHERE it.invoice_id = $1
+		)
+		GROUP BY ed.code
+		ORDER BY MIN(CASE WHEN ed.rank = 'primary' THEN 0 ELSE 1 END), MIN(e.created_at), MIN(ed.position)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := []string{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, rows.Err()
+}
+
+// checkDiagnosisCodes returns ErrNotFound unless every code is in the ICD-10
+// table.
+func checkDiagnosisCodes(ctx context.Context, q rowQueryer, codes []string) error {
+	var known int
+	err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM icd10_codes WHERE code = ANY($1)`, pq.Array(codes)).Scan(&known)
+	if err != nil {
+		return err
+	}
+
+	if known != len(codes) {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Create drafts a claim with a line per invoice item. It returns ErrLocked
+// if the invoice is not issued, ErrNotFound for an unknown diagnosis code
+// and ErrConflict while another claim for the invoice is open.
+func (s *ClaimStore) Create(ctx context.Context, claim *Claim) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var status InvoiceStatus
+		err := tx.QueryRowContext(ctx, `SELECT status FROM invoices WHERE id = $1 FOR SHARE`, claim.InvoiceID).Scan(&status)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if status != InvoiceIssued {
+			return ErrLocked
+		}
+
+		if err := checkDiagnosisCodes(ctx, tx, claim.DiagnosisCodes); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO claims (number, invoice_id, patient_id, policy_id, payer, plan_name, policy_number,
+				member_id, group_number, diagnosis_codes, created_by)
+			VALUES ('CLM-' || lpad(nextval('claim_number_seq')::text, 6, '0'), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id
+		`
+
+		err = tx.QueryRowContext(ctx, query,
+			claim.InvoiceID,
+			claim.PatientID,
+			claim.PolicyID,
+			claim.Payer,
+			claim.PlanName,
+			claim.PolicyNumber,
+			claim.MemberID,
+			claim.GroupNumber,
+			pq.Array(claim.DiagnosisCodes),
+			claim.CreatedBy,
+		).Scan(&claim.ID)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "claims_invoice_open_key"):
+				return ErrConflict
+			default:
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO claim_lines (claim_id, position, invoice_item_id, kind, code, description, quantity, amount, service_date)
+			SELECT $1, row_number() OVER (ORDER BY created_at, id) - 1, id, kind, code, description, quantity,
+				net + tax, created_at::date
+			FROM invoice_items
+			WHERE invoice_id = $2
+		`, claim.ID, claim.InvoiceID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.reload(ctx, claim)
+}
+
+func (s *ClaimStore) reload(ctx context.Context, claim *Claim) error {
+	fresh, err := s.GetByID(ctx, claim.ID)
+	if err != nil {
+		return err
+	}
+
+	*claim = *fresh
+	return nil
+}
+
+// GetByID returns a claim with its lines and remittances.
+func (s *ClaimStore) GetByID(ctx context.Context, id uuid.UUID) (*Claim, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	claim, err := scanClaim(s.db.QueryRowContext(ctx, claimQuery+` WHERE c.id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	lines, err := s.db.QueryContext(ctx, `
+		SELECT position, invoice_item_id, kind, code, description, quantity, amount, to_char(service_date, 'YYYY-MM-DD')
+		FROM claim_lines
+		WHERE claim_id = $1
+		ORDER BY position
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	claim.Lines = []ClaimLine{}
+	for lines.Next() {
+		var line ClaimLine
+		err := lines.Scan(
+			&line.Position,
+			&line.InvoiceItemID,
+			&line.Kind,
+			&line.Code,
+			&line.Description,
+			&line.Quantity,
+			&line.Amount,
+			&line.ServiceDate,
+		)
+		if err != nil {
+			return nil, err
+		}
+		claim.Lines = append(claim.Lines, line)
+	}
+
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	remittances, err := s.db.QueryContext(ctx, `
+		SELECT id, claim_id, paid_amount, reference, denial_reason, payment_id, recorded_by, remitted_at
+		FROM claim_remittances
+		WHERE claim_id = $1
+		ORDER BY remitted_at, id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer remittances.Close()
+
+	claim.Remittances = []ClaimRemittance{}
+	for remittances.Next() {
+		var r ClaimRemittance
+		err := remittances.Scan(&r.ID, &r.ClaimID, &r.PaidAmount, &r.Reference, &r.DenialReason, &r.PaymentID, &r.RecordedBy, &r.RemittedAt)
+		if err != nil {
+			return nil, err
+		}
+		claim.Remittances = append(claim.Remittances, r)
+	}
+
+	return claim, remittances.Err()
+}
+
+// List returns claims without lines, oldest first so they can be worked
+// through as a queue.
+func (s *ClaimStore) List(ctx context.Context, q ClaimQuery) ([]*Claim, error) {
+	query := claimQuery + `
+		WHERE ($1::uuid IS NULL OR c.patient_id = $1)
+			AND ($2 OR c.status <> 'draft')
+			AND ($3 = '' OR c.status::text = $3)
+			AND ($4 = '' OR c.payer ILIKE $4)
+		ORDER BY c.created_at, c.id
+		LIMIT $5 OFFSET $6
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, q.PatientID, q.IncludeDrafts, q.Status, q.Payer, q.Limit, q.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	claims := []*Claim{}
+	for rows.Next() {
+		claim, err := scanClaim(rows)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+
+	return claims, rows.Err()
+}
+
+// GetLinesFor returns the lines of the given claims keyed by claim, for
+// export.
+func (s *ClaimStore) GetLinesFor(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]ClaimLine, error) {
+	query := `
+		SELECT claim_id, position, invoice_item_id, kind, code, description, quantity, amount,
+			to_char(service_date, 'YYYY-MM-DD')
+		FROM claim_lines
+		WHERE claim_id = ANY($1)
+		ORDER BY claim_id, position
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = id.String()
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make(map[uuid.UUID][]ClaimLine, len(ids))
+	for rows.Next() {
+		var (
+			claimID uuid.UUID
+			line    ClaimLine
+		)
+		err := rows.Scan(
+			&claimID,
+			&line.Position,
+			&line.InvoiceItemID,
+			&line.Kind,
+			&line.Code,
+			&line.Description,
+			&line.Quantity,
+			&line.Amount,
+			&line.ServiceDate,
+		)
+		if err != nil {
+			return nil, err
+		}
+		lines[claimID] = append(lines[claimID], line)
+	}
+
+	return lines, rows.Err()
+}
+
+// SetDiagnoses replaces the diagnosis codes of a draft claim. It returns
+// ErrLocked once the claim is submitted and ErrNotFound for an unknown code.
+func (s *ClaimStore) SetDiagnoses(ctx context.Context, claim *Claim, codes []string) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if err := checkDiagnosisCodes(ctx, tx, codes); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			UPDATE claims SET diagnosis_codes = $2, updated_at = NOW()
+			WHERE id = $1 AND status = 'draft'
+		`, claim.ID, pq.Array(codes))
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrLocked
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.reload(ctx, claim)
+}
+
+// Submit marks a draft claim as sent to the payer. It returns ErrLocked if
+// the claim is not a draft.
+func (s *ClaimStore) Submit(ctx context.Context, claim *Claim) error {
+	query := `
+		UPDATE claims SET status = 'submitted', submitted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'draft'
+		RETURNING status, submitted_at, updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, claim.ID).Scan(&claim.Status, &claim.SubmittedAt, &claim.UpdatedAt)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrLocked
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remit records a payer's remittance and moves the claim to approved,
+// partially paid or denied by what was paid in total. The amount paid is
+// posted to the invoice as an insurance payment in the same transaction.
+//
+// It returns ErrLocked unless the claim is submitted or partially paid, and
+// ErrOverpayment if the payer would pay more than was claimed or than the
+// invoice still owes.
+func (s *ClaimStore) Remit(ctx context.Context, claim *Claim, remittance *ClaimRemittance) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		current, err := scanClaim(tx.QueryRowContext(ctx, claimQuery+` WHERE c.id = $1 FOR UPDATE OF c`, claim.ID))
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if current.Status != ClaimSubmitted && current.Status != ClaimPartiallyPaid {
+			return ErrLocked
+		}
+
+		paid := current.PaidAmount + remittance.PaidAmount
+		if paid > current.TotalAmount {
+			return ErrOverpayment
+		}
+
+		if remittance.PaidAmount > 0 {
+			payment := &Payment{
+				InvoiceID: current.InvoiceID,
+				Kind:      PaymentKindPayment,
+				Method:    PaymentInsurance,
+				Amount:    remittance.PaidAmount,
+				Status:    PaymentSucceeded,
+				Reason:    "Claim " + current.Number,
+				CreatedBy: remittance.RecordedBy,
+			}
+
+			if err := createPayment(ctx, tx, payment); err != nil {
+				return err
+			}
+
+			remittance.PaymentID = &payment.ID
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO claim_remittances (claim_id, paid_amount, reference, denial_reason, payment_id, recorded_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, remitted_at
+		`,
+			claim.ID,
+			remittance.PaidAmount,
+			remittance.Reference,
+			remittance.DenialReason,
+			remittance.PaymentID,
+			remittance.RecordedBy,
+		).Scan(&remittance.ID, &remittance.RemittedAt)
+		if err != nil {
+			return err
+		}
+		remittance.ClaimID = claim.ID
+
+		status := ClaimApproved
+		switch {
+		case paid == 0:
+			status = ClaimDenied
+		case paid < current.TotalAmount:
+			status = ClaimPartiallyPaid
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE claims SET status = $2, adjudicated_at = NOW(), updated_at = NOW() WHERE id = $1
+		`, claim.ID, status)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.reload(ctx, claim)
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type ICD10Code struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+type CodeStore struct {
+	db *sql.DB
+}
+
+// icd10ImportBatchSize keeps each import statement well inside the query
+// timeout even for a full ICD-10 release.
+const icd10ImportBatchSize = 1000
+
+// UpsertICD10 inserts new codes and refreshes the descriptions of existing
+// ones. Codes missing from the list are kept, since encounters may still
+// refer to them. It returns the number of codes written.
+func (s *CodeStore) UpsertICD10(ctx context.Context, codes []ICD10Code) (int64, error) {
+	query := `
+		INSERT INTO icd10_codes (code, description)
+		SELECT * FROM unnest($1::text[], $2::text[])
+		ON CONFLICT (code) DO UPDATE
+		SET description = EXCLUDED.description, updated_at = NOW()
+		WHERE icd10_codes.description <> EXCLUDED.description
+	`
+
+	var written int64
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		for start := 0; start < len(codes); start += icd10ImportBatchSize {
+			end := min(start+icd10ImportBatchSize, len(codes))
+
+			values := make([]string, 0, end-start)
+			descriptions := make([]string, 0, end-start)
+			for _, c := range codes[start:end] {
+				values = append(values, c.Code)
+				descriptions = append(descriptions, c.Description)
+			}
+
+			n, err := execBatch(ctx, tx, query, pq.Array(values), pq.Array(descriptions))
+			if err != nil {
+				return err
+			}
+			written += n
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return written, nil
+}
+
+func execBatch(ctx context.Context, tx *sql.Tx, query string, args ...any) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// SearchICD10 is a typeahead lookup. Codes starting with q come first, then
+// descriptions ranked by trigram similarity to q.
+func (s *CodeStore) SearchICD10(ctx context.Context, q string, limit int) ([]ICD10Code, error) {
+	query := `
+		SELECT code, description
+		FROM icd10_codes
+		WHERE code LIKE upper($1) || '%'
+			OR description ILIKE '%' || $1 || '%'
+			OR description % $1
+		ORDER BY code LIKE upper($1) || '%' DESC, similarity(description, $1) DESC, code
+		LIMIT $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := []ICD10Code{}
+	for rows.Next() {
+		var code ICD10Code
+		if err := rows.Scan(&code.Code, &code.Description); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
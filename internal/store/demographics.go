@@ -0,0 +1,48 @@
+package store
+
+import "time"
+
+type Gender string
+
+const (
+	GenderMale   Gender = "male"
+	GenderFemale Gender = "female"
+	GenderOther  Gender = "other"
+)
+
+type MaritalStatus string
+
+const (
+	MaritalStatusSingle    MaritalStatus = "single"
+	MaritalStatusMarried   MaritalStatus = "married"
+	MaritalStatusDivorced  MaritalStatus = "divorced"
+	MaritalStatusWidowed   MaritalStatus = "widowed"
+	MaritalStatusSeparated MaritalStatus = "separated"
+)
+
+type BloodGroup string
+
+const (
+	BloodGroupAPos  BloodGroup = "A+"
+	BloodGroupANeg  BloodGroup = "A-"
+	BloodGroupBPos  BloodGroup = "B+"
+	BloodGroupBNeg  BloodGroup = "B-"
+	BloodGroupABPos BloodGroup = "AB+"
+	BloodGroupABNeg BloodGroup = "AB-"
+	BloodGroupOPos  BloodGroup = "O+"
+	BloodGroupONeg  BloodGroup = "O-"
+)
+
+// DateLayout is the format used for calendar dates such as date of birth.
+const DateLayout = "2006-01-02"
+
+// AgeOn returns the age in completed years of someone born on dob at the
+// given moment.
+func AgeOn(dob, at time.Time) int {
+	age := at.Year() - dob.Year()
+	if at.Month() < dob.Month() || (at.Month() == dob.Month() && at.Day() < dob.Day()) {
+		age--
+	}
+
+	return age
+}
@@ -0,0 +1,367 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DischargeSummaryStatus string
+
+const (
+	DischargeSummaryDraft DischargeSummaryStatus = "draft"
+	DischargeSummaryFinal DischargeSummaryStatus = "final"
+)
+
+// DischargeSummary is the attending doctor's account of an admission. It is
+// editable while a draft and immutable once final.
+type DischargeSummary struct {
+	ID                    uuid.UUID              `json:"id"`
+	AdmissionID           uuid.UUID              `json:"admission_id"`
+	PatientID             uuid.UUID              `json:"patient_id"`
+	AuthorID              uuid.UUID              `json:"author_id"`
+	AdmissionReason       string                 `json:"admission_reason"`
+	HospitalCourse        string                 `json:"hospital_course"`
+	Procedures            string                 `json:"procedures"`
+	DischargeMedications  []PrescriptionItem     `json:"discharge_medications"`
+	FollowUpInstructions  string                 `json:"follow_up_instructions"`
+	Status                DischargeSummaryStatus `json:"status"`
+	PDFKey                *string                `json:"-"`
+	FinalizedAt           *time.Time             `json:"finalized_at"`
+	EmailedAt             *time.Time             `json:"emailed_at"`
+	FollowUpAppointmentID *uuid.UUID             `json:"follow_up_appointment_id"`
+	CreatedAt             time.Time              `json:"created_at"`
+	UpdatedAt             time.Time              `json:"updated_at"`
+}
+
+const dischargeSummaryColumns = `
+	id, admission_id, patient_id, author_id, admission_reason, hospital_course, procedures,
+	discharge_medications, follow_up_instructions, status, pdf_key, finalized_at, emailed_at,
+	follow_up_appointment_id, created_at, updated_at`
+
+func scanDischargeSummary(row rowScanner) (*DischargeSummary, error) {
+	s := &DischargeSummary{}
+	var medications []byte
+
+	err := row.Scan(
+		&s.ID,
+		&s.AdmissionID,
+		&s.PatientID,
+		&s.AuthorID,
+		&s.AdmissionReason,
+		&s.HospitalCourse,
+		&s.Procedures,
+		&medications,
+		&s.FollowUpInstructions,
+		&s.Status,
+		&s.PDFKey,
+		&s.FinalizedAt,
+		&s.EmailedAt,
+		&s.FollowUpAppointmentID,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(medications, &s.DischargeMedications); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func medicationsOrEmpty(items []PrescriptionItem) []PrescriptionItem {
+	if items == nil {
+		return []PrescriptionItem{}
+	}
+	return items
+}
+
+type DischargeSummaryStore struct {
+	db *sql.DB
+}
+
+// Prefill returns an unsaved draft for the admission. The course in hospital
+// lists the assessments of the signed encounters during the stay, the
+// follow-up instructions start from the latest plan, and the medications are
+// the items of prescriptions issued during the stay and not cancelled.
+func (s *DischargeSummaryStore) Prefill(ctx context.Context, admission *Admission) (*DischargeSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	summary := &DischargeSummary{
+		AdmissionID:          admission.ID,
+		PatientID:            admission.PatientID,
+		AdmissionReason:      admission.Reason,
+		Status:               DischargeSummaryDraft,
+		DischargeMedications: []PrescriptionItem{},
+	}
+
+	encounters := `
+		SELECT to_char(created_at, 'YYYY-MM-DD'), assessment, plan
+		FROM encounters
+		WHERE patient_id = $1 AND status = 'signed'
+			AND created_at >= $2 AND ($3::timestamptz IS NULL OR created_at <= $3)
+		ORDER BY created_at
+	`
+
+	rows, err := s.db.QueryContext(ctx, encounters, admission.PatientID, admission.AdmittedAt, admission.DischargedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var course []string
+	for rows.Next() {
+		var day, assessment, plan string
+		if err := rows.Scan(&day, &assessment, &plan); err != nil {
+			return nil, err
+		}
+		if assessment = strings.TrimSpace(assessment); assessment != "" {
+			course = append(course, day+": "+assessment)
+		}
+		if plan = strings.TrimSpace(plan); plan != "" {
+			summary.FollowUpInstructions = plan
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	summary.HospitalCourse = strings.Join(course, "\n")
+
+	medications := `
+		SELECT i.drug, i.strength, i.dose, i.frequency, i.duration, i.quantity, i.instructions
+		FROM prescriptions p
+		JOIN prescription_items i ON i.prescription_id = p.id
+		WHERE p.patient_id = $1 AND p.status = 'issued'
+			AND p.issued_at >= $2 AND ($3::timestamptz IS NULL OR p.issued_at <= $3)
+		ORDER BY p.issued_at, i.position
+	`
+
+	items, err := s.db.QueryContext(ctx, medications, admission.PatientID, admission.AdmittedAt, admission.DischargedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer items.Close()
+
+	for items.Next() {
+		var item PrescriptionItem
+		err := items.Scan(&item.Drug, &item.Strength, &item.Dose, &item.Frequency, &item.Duration, &item.Quantity, &item.Instructions)
+		if err != nil {
+			return nil, err
+		}
+		summary.DischargeMedications = append(summary.DischargeMedications, item)
+	}
+
+	return summary, items.Err()
+}
+
+// Create saves a draft. It returns ErrConflict if the admission already has
+// a summary.
+func (s *DischargeSummaryStore) Create(ctx context.Context, summary *DischargeSummary) error {
+	medications, err := json.Marshal(medicationsOrEmpty(summary.DischargeMedications))
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO discharge_summaries (admission_id, patient_id, author_id, admission_reason, hospital_course,
+			procedures, discharge_medications, follow_up_instructions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + dischargeSummaryColumns
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	created, err := scanDischargeSummary(s.db.QueryRowContext(ctx, query,
+		summary.AdmissionID,
+		summary.PatientID,
+		summary.AuthorID,
+		summary.AdmissionReason,
+		summary.HospitalCourse,
+		summary.Procedures,
+		medications,
+		summary.FollowUpInstructions,
+	))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "discharge_summaries_admission_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	*summary = *created
+	return nil
+}
+
+func (s *DischargeSummaryStore) GetByID(ctx context.Context, id uuid.UUID) (*DischargeSummary, error) {
+	query := `SELECT ` + dischargeSummaryColumns + ` FROM discharge_summaries WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	summary, err := scanDischargeSummary(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return summary, nil
+}
+
+// GetByPatient lists the patient's summaries, newest first.
+func (s *DischargeSummaryStore) GetByPatient(ctx context.Context, patientID uuid.UUID, finalOnly bool) ([]*DischargeSummary, error) {
+	query := `
+		SELECT ` + dischargeSummaryColumns + `
+		FROM discharge_summaries
+		WHERE patient_id = $1 AND (NOT $2 OR status = 'final')
+		ORDER BY created_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, patientID, finalOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []*DischargeSummary{}
+	for rows.Next() {
+		summary, err := scanDischargeSummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
+
+// Update replaces the content of a draft. Final summaries return ErrLocked.
+func (s *DischargeSummaryStore) Update(ctx context.Context, summary *DischargeSummary) error {
+	medications, err := json.Marshal(medicationsOrEmpty(summary.DischargeMedications))
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE discharge_summaries
+		SET admission_reason = $2, hospital_course = $3, procedures = $4, discharge_medications = $5,
+			follow_up_instructions = $6, updated_at = NOW()
+		WHERE id = $1 AND status = 'draft'
+		RETURNING ` + dischargeSummaryColumns
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	updated, err := scanDischargeSummary(s.db.QueryRowContext(ctx, query,
+		summary.ID,
+		summary.AdmissionReason,
+		summary.HospitalCourse,
+		summary.Procedures,
+		medications,
+		summary.FollowUpInstructions,
+	))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrLocked
+		default:
+			return err
+		}
+	}
+
+	*summary = *updated
+	return nil
+}
+
+// Finalize locks a draft together with its rendered PDF. It returns
+// ErrLocked if the summary is already final.
+func (s *DischargeSummaryStore) Finalize(ctx context.Context, summary *DischargeSummary, pdfKey string) error {
+	query := `
+		UPDATE discharge_summaries
+		SET status = 'final', pdf_key = $2, finalized_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'draft'
+		RETURNING ` + dischargeSummaryColumns
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	updated, err := scanDischargeSummary(s.db.QueryRowContext(ctx, query, summary.ID, pdfKey))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrLocked
+		default:
+			return err
+		}
+	}
+
+	*summary = *updated
+	return nil
+}
+
+func (s *DischargeSummaryStore) MarkEmailed(ctx context.Context, summary *DischargeSummary) error {
+	query := `UPDATE discharge_summaries SET emailed_at = NOW() WHERE id = $1 RETURNING emailed_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return s.db.QueryRowContext(ctx, query, summary.ID).Scan(&summary.EmailedAt)
+}
+
+// BookFollowUp books the follow-up appointment of a summary. It returns
+// ErrConflict while an earlier follow-up is still scheduled.
+func (s *DischargeSummaryStore) BookFollowUp(ctx context.Context, summary *DischargeSummary, appointment *Appointment) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var booked bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM appointment a WHERE a.id = s.follow_up_appointment_id AND a.status = 'scheduled')
+			FROM discharge_summaries s
+			WHERE s.id = $1
+			FOR UPDATE OF s
+		`, summary.ID).Scan(&booked)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if booked {
+			return ErrConflict
+		}
+
+		if err := createAppointment(ctx, tx, appointment); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE discharge_summaries SET follow_up_appointment_id = $2 WHERE id = $1`, summary.ID, appointment.ID)
+		if err != nil {
+			return err
+		}
+
+		summary.FollowUpAppointmentID = &appointment.ID
+		return nil
+	})
+}
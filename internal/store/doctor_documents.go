@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type DoctorDocumentKind string
+
+const (
+	DoctorDocumentLicense     DoctorDocumentKind = "license"
+	DoctorDocumentDegree      DoctorDocumentKind = "degree"
+	DoctorDocumentCertificate DoctorDocumentKind = "certificate"
+	DoctorDocumentOther       DoctorDocumentKind = "other"
+)
+
+// DoctorDocument is a credential file uploaded for a doctor. The file itself
+// lives in blob storage under StorageKey.
+type DoctorDocument struct {
+	ID          uuid.UUID          `json:"id"`
+	DoctorID    uuid.UUID          `json:"doctor_id"`
+	Kind        DoctorDocumentKind `json:"kind"`
+	FileName    string             `json:"file_name"`
+	ContentType string             `json:"content_type"`
+	SizeBytes   int64              `json:"size_bytes"`
+	StorageKey  string             `json:"-"`
+	UploadedBy  *uuid.UUID         `json:"uploaded_by"`
+	CreatedAt   string             `json:"created_at"`
+}
+
+type DoctorDocumentStore struct {
+	db *sql.DB
+}
+
+func (s *DoctorDocumentStore) Create(ctx context.Context, doc *DoctorDocument) error {
+	query := `
+		INSERT INTO doctor_documents (doctor_id, kind, file_name, content_type, size_bytes, storage_key, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return s.db.QueryRowContext(
+		ctx,
+		query,
+		doc.DoctorID,
+		doc.Kind,
+		doc.FileName,
+		doc.ContentType,
+		doc.SizeBytes,
+		doc.StorageKey,
+		doc.UploadedBy,
+	).Scan(
+		&doc.ID,
+		&doc.CreatedAt,
+	)
+}
+
+func (s *DoctorDocumentStore) GetByDoctor(ctx context.Context, doctorID uuid.UUID) ([]DoctorDocument, error) {
+	query := `
+		SELECT id, doctor_id, kind, file_name, content_type, size_bytes, storage_key, uploaded_by, created_at
+		FROM doctor_documents
+		WHERE doctor_id = $1
+		ORDER BY created_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := []DoctorDocument{}
+	for rows.Next() {
+		var doc DoctorDocument
+		err := rows.Scan(
+			&doc.ID,
+			&doc.DoctorID,
+			&doc.Kind,
+			&doc.FileName,
+			&doc.ContentType,
+			&doc.SizeBytes,
+			&doc.StorageKey,
+			&doc.UploadedBy,
+			&doc.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+func (s *DoctorDocumentStore) GetByID(ctx context.Context, doctorID, documentID uuid.UUID) (*DoctorDocument, error) {
+	query := `
+		SELECT id, doctor_id, kind, file_name, content_type, size_bytes, storage_key, uploaded_by, created_at
+		FROM doctor_documents
+		WHERE doctor_id = $1 AND id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	doc := &DoctorDocument{}
+	err := s.db.QueryRowContext(ctx, query, doctorID, documentID).Scan(
+		&doc.ID,
+		&doc.DoctorID,
+		&doc.Kind,
+		&doc.FileName,
+		&doc.ContentType,
+		&doc.SizeBytes,
+		&doc.StorageKey,
+		&doc.UploadedBy,
+		&doc.CreatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+func (s *DoctorDocumentStore) Delete(ctx context.Context, doctorID, documentID uuid.UUID) error {
+	query := `DELETE FROM doctor_documents WHERE doctor_id = $1 AND id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, doctorID, documentID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -5,31 +5,49 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Doctor struct {
-	UserID         uuid.UUID `json:"user_id"`
-	UserName       string    `json:"username"`
-	Password       string    `json:"-"` // Typically not returned in queries
-	Email          string    `json:"email"`
-	FirstName      string    `json:"firstname"`
-	LastName       string    `json:"lastname"`
-	Age            string    `json:"age"`
-	Gender         string    `json:"gender"`
-	MaritalStatus  string    `json:"marital_status"`
-	Designation    string    `json:"designation"`
-	Qualification  string    `json:"qualification"`
-	BloodGroup     string    `json:"blood_group"`
-	Address        string    `json:"address"`
-	Country        string    `json:"country"`
-	State          string    `json:"state"`
-	City           string    `json:"city"`
-	PostalCode     string    `json:"postal_code"`
-	Specialization string    `json:"specialization"`
-	LicenseNumber  string    `json:"license_number"`
-	Availability   []string  `json:"availability"`
+	UserID         uuid.UUID     `json:"user_id"`
+	UserName       string        `json:"username"`
+	Password       string        `json:"-"` // Typically not returned in queries
+	Email          string        `json:"email"`
+	FirstName      string        `json:"firstname"`
+	LastName       string        `json:"lastname"`
+	DateOfBirth    string        `json:"date_of_birth"`
+	Age            int           `json:"age"` // Computed from DateOfBirth, 0 when unknown
+	Gender         Gender        `json:"gender"`
+	MaritalStatus  MaritalStatus `json:"marital_status"`
+	Designation    string        `json:"designation"`
+	Qualification  string        `json:"qualification"`
+	BloodGroup     BloodGroup    `json:"blood_group"`
+	Address        string        `json:"address"`
+	Country        string        `json:"country"`
+	State          string        `json:"state"`
+	City           string        `json:"city"`
+	PostalCode     string        `json:"postal_code"`
+	Specialization string        `json:"specialization"`
+	LicenseNumber  string        `json:"license_number"`
+	Availability   []string      `json:"availability"`
+	Fees           []DoctorFee   `json:"fees"` // Fees in effect today
+	// Photo keys point into blob storage; the URLs are filled in by the API.
+	PhotoKey          string `json:"-"`
+	PhotoThumbnailKey string `json:"-"`
+	PhotoURL          string `json:"photo_url"`
+	PhotoThumbnailURL string `json:"photo_thumbnail_url"`
+}
+
+func (d *Doctor) setAge(now time.Time) {
+	dob, err := time.Parse(DateLayout, d.DateOfBirth)
+	if err != nil {
+		d.Age = 0
+		return
+	}
+
+	d.Age = AgeOn(dob, now)
 }
 
 type DoctorStore struct {
@@ -45,12 +63,12 @@ SELECT
     u.email,
     d.firstname,
     d.lastname,
-    d.age,
-    d.gender,
-    d.marital_status,
+    COALESCE(to_char(d.date_of_birth, 'YYYY-MM-DD'), '') AS date_of_birth,
+    COALESCE(d.gender::text, '') AS gender,
+    COALESCE(d.marital_status::text, '') AS marital_status,
     d.designation,
     d.qualification,
-    d.blood_group,
+    COALESCE(d.blood_group::text, '') AS blood_group,
     d.address,
     d.country,
     d.state,
@@ -58,11 +76,33 @@ SELECT
     d.postal_code,
     d.specialization,
     d.license_number,
+    COALESCE(d.photo_key, '') AS photo_key,
+    COALESCE(d.photo_thumbnail_key, '') AS photo_thumbnail_key,
     COALESCE(
         json_agg(a.available_day) 
         FILTER (WHERE a.available_day IS NOT NULL), 
         '[]'
-    ) AS availability
+    ) AS availability,
+    COALESCE(
+        (
+            SELECT json_agg(json_build_object(
+                'id', f.id,
+                'doctor_id', f.doctor_id,
+                'visit_type', f.visit_type,
+                'consultation_mode', f.consultation_mode,
+                'amount', f.amount,
+                'currency', f.currency,
+                'effective_from', f.effective_from
+            ))
+            FROM (
+                SELECT DISTINCT ON (visit_type, consultation_mode) *
+                FROM doctor_fees
+                WHERE doctor_id = u.id AND effective_from <= CURRENT_DATE
+                ORDER BY visit_type, consultation_mode, effective_from DESC
+            ) f
+        ),
+        '[]'
+    ) AS fees
 FROM users u
 INNER JOIN doctors d ON d.user_id = u.id
 LEFT JOIN availability a ON a.doctor_id = d.user_id
@@ -73,7 +113,7 @@ GROUP BY
     u.email,
     d.firstname,
     d.lastname,
-    d.age,
+    d.date_of_birth,
     d.gender,
     d.marital_status,
     d.designation,
@@ -85,13 +125,15 @@ GROUP BY
     d.city,
     d.postal_code,
     d.specialization,
-    d.license_number;
+    d.license_number,
+    d.photo_key,
+    d.photo_thumbnail_key;
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
 
-	var availabilityJSON []byte
+	var availabilityJSON, feesJSON []byte
 	doctor := &Doctor{}
 
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
@@ -100,7 +142,7 @@ GROUP BY
 		&doctor.Email,
 		&doctor.FirstName,
 		&doctor.LastName,
-		&doctor.Age,
+		&doctor.DateOfBirth,
 		&doctor.Gender,
 		&doctor.MaritalStatus,
 		&doctor.Designation,
@@ -113,7 +155,10 @@ GROUP BY
 		&doctor.PostalCode,
 		&doctor.Specialization,
 		&doctor.LicenseNumber,
+		&doctor.PhotoKey,
+		&doctor.PhotoThumbnailKey,
 		&availabilityJSON,
+		&feesJSON,
 	)
 
 	if err != nil {
@@ -124,6 +169,8 @@ GROUP BY
 	}
 
 	_ = json.Unmarshal(availabilityJSON, &doctor.Availability)
+	_ = json.Unmarshal(feesJSON, &doctor.Fees)
+	doctor.setAge(time.Now())
 
 	return doctor, nil
 }
@@ -137,12 +184,12 @@ SELECT
     u.email,
     d.firstname,
     d.lastname,
-    d.age,
-    d.gender,
-    d.marital_status,
+    COALESCE(to_char(d.date_of_birth, 'YYYY-MM-DD'), '') AS date_of_birth,
+    COALESCE(d.gender::text, '') AS gender,
+    COALESCE(d.marital_status::text, '') AS marital_status,
     d.designation,
     d.qualification,
-    d.blood_group,
+    COALESCE(d.blood_group::text, '') AS blood_group,
     d.address,
     d.country,
     d.state,
@@ -150,11 +197,33 @@ SELECT
     d.postal_code,
     d.specialization,
     d.license_number,
+    COALESCE(d.photo_key, '') AS photo_key,
+    COALESCE(d.photo_thumbnail_key, '') AS photo_thumbnail_key,
     COALESCE(
         json_agg(a.available_day) 
         FILTER (WHERE a.available_day IS NOT NULL), 
         '[]'
-    ) AS availability
+    ) AS availability,
+    COALESCE(
+        (
+            SELECT json_agg(json_build_object(
+                'id', f.id,
+                'doctor_id', f.doctor_id,
+                'visit_type', f.visit_type,
+                'consultation_mode', f.consultation_mode,
+                'amount', f.amount,
+                'currency', f.currency,
+                'effective_from', f.effective_from
+            ))
+            FROM (
+                SELECT DISTINCT ON (visit_type, consultation_mode) *
+                FROM doctor_fees
+                WHERE doctor_id = u.id AND effective_from <= CURRENT_DATE
+                ORDER BY visit_type, consultation_mode, effective_from DESC
+            ) f
+        ),
+        '[]'
+    ) AS fees
 FROM users u
 INNER JOIN doctors d ON d.user_id = u.id
 LEFT JOIN availability a ON a.doctor_id = d.user_id
@@ -164,7 +233,7 @@ GROUP BY
     u.email,
     d.firstname,
     d.lastname,
-    d.age,
+    d.date_of_birth,
     d.gender,
     d.marital_status,
     d.designation,
@@ -176,7 +245,9 @@ GROUP BY
     d.city,
     d.postal_code,
     d.specialization,
-    d.license_number;
+    d.license_number,
+    d.photo_key,
+    d.photo_thumbnail_key;
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
@@ -191,7 +262,7 @@ GROUP BY
 	doctors := []*Doctor{}
 
 	for rows.Next() {
-		var availabilityJSON []byte
+		var availabilityJSON, feesJSON []byte
 		doctor := &Doctor{}
 		err := rows.Scan(
 			&doctor.UserID,
@@ -199,7 +270,7 @@ GROUP BY
 			&doctor.Email,
 			&doctor.FirstName,
 			&doctor.LastName,
-			&doctor.Age,
+			&doctor.DateOfBirth,
 			&doctor.Gender,
 			&doctor.MaritalStatus,
 			&doctor.Designation,
@@ -212,13 +283,18 @@ GROUP BY
 			&doctor.PostalCode,
 			&doctor.Specialization,
 			&doctor.LicenseNumber,
+			&doctor.PhotoKey,
+			&doctor.PhotoThumbnailKey,
 			&availabilityJSON,
+			&feesJSON,
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		_ = json.Unmarshal(availabilityJSON, &doctor.Availability)
+		_ = json.Unmarshal(feesJSON, &doctor.Fees)
+		doctor.setAge(time.Now())
 
 		doctors = append(doctors, doctor)
 	}
@@ -229,7 +305,7 @@ GROUP BY
 func (s *DoctorStore) Create(ctx context.Context, doctor *Doctor) error {
 	query := `
 		
-		INSERT INTO doctors (user_id,firstname, lastname, age, gender, marital_status,
+		INSERT INTO doctors (user_id,firstname, lastname, date_of_birth, gender, marital_status,
 			designation, qualification, blood_group, address, country, state, city, postal_code,specialization, license_number)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16);
 	`
@@ -241,7 +317,7 @@ func (s *DoctorStore) Create(ctx context.Context, doctor *Doctor) error {
 		doctor.UserID,
 		doctor.FirstName,
 		doctor.LastName,
-		doctor.Age,
+		doctor.DateOfBirth,
 		doctor.Gender,
 		doctor.MaritalStatus,
 		doctor.Designation,
@@ -255,8 +331,46 @@ func (s *DoctorStore) Create(ctx context.Context, doctor *Doctor) error {
 		doctor.Specialization,
 		doctor.LicenseNumber,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	doctor.setAge(time.Now())
+
+	return nil
+}
+
+// UpdatePhoto points the doctor at a new photo and thumbnail and returns the
+// keys they replaced so the caller can remove the old blobs.
+func (s *DoctorStore) UpdatePhoto(ctx context.Context, doctorID uuid.UUID, photoKey, thumbnailKey string) (string, string, error) {
+	query := `
+		UPDATE doctors d
+		SET photo_key = $2, photo_thumbnail_key = $3
+		FROM (
+			SELECT user_id, photo_key, photo_thumbnail_key
+			FROM doctors
+			WHERE user_id = $1
+			FOR UPDATE
+		) old
+		WHERE d.user_id = old.user_id
+		RETURNING COALESCE(old.photo_key, ''), COALESCE(old.photo_thumbnail_key, '')
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var oldPhotoKey, oldThumbnailKey string
+	err := s.db.QueryRowContext(ctx, query, doctorID, photoKey, thumbnailKey).Scan(&oldPhotoKey, &oldThumbnailKey)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return "", "", ErrNotFound
+		default:
+			return "", "", err
+		}
+	}
+
+	return oldPhotoKey, oldThumbnailKey, nil
 }
 
 func (s *DoctorStore) deleteUserInvitations(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
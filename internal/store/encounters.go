@@ -0,0 +1,452 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type EncounterStatus string
+
+const (
+	EncounterDraft  EncounterStatus = "draft"
+	EncounterSigned EncounterStatus = "signed"
+)
+
+// Encounter is the clinical record of a completed appointment. Its SOAP note
+// can only be edited while the encounter is a draft; once signed, changes
+// are made with addenda.
+type Encounter struct {
+	ID            uuid.UUID            `json:"id"`
+	AppointmentID uuid.UUID            `json:"appointment_id"`
+	PatientID     uuid.UUID            `json:"patient_id"`
+	DoctorID      uuid.UUID            `json:"doctor_id"`
+	Subjective    string               `json:"subjective"`
+	Objective     string               `json:"objective"`
+	Assessment    string               `json:"assessment"`
+	Plan          string               `json:"plan"`
+	Status        EncounterStatus      `json:"status"`
+	SignedAt      *string              `json:"signed_at"`
+	CreatedAt     string               `json:"created_at"`
+	UpdatedAt     string               `json:"updated_at"`
+	Diagnoses     []EncounterDiagnosis `json:"diagnoses,omitempty"`
+	Addenda       []EncounterAddendum  `json:"addenda,omitempty"`
+}
+
+type DiagnosisRank string
+
+const (
+	DiagnosisPrimary   DiagnosisRank = "primary"
+	DiagnosisSecondary DiagnosisRank = "secondary"
+)
+
+type EncounterDiagnosis struct {
+	Code        string        `json:"code"`
+	Description string        `json:"description"`
+	Rank        DiagnosisRank `json:"rank"`
+}
+
+type EncounterAddendum struct {
+	ID          uuid.UUID `json:"id"`
+	EncounterID uuid.UUID `json:"encounter_id"`
+	AuthorID    uuid.UUID `json:"author_id"`
+	Body        string    `json:"body"`
+	CreatedAt   string    `json:"created_at"`
+}
+
+type EncounterStore struct {
+	db *sql.DB
+}
+
+const encounterColumns = `
+	id, appointment_id, patient_id, doctor_id, subjective, objective, assessment, plan,
+	status, signed_at, created_at, updated_at
+`
+
+func scanEncounter(row rowScanner) (*Encounter, error) {
+	encounter := &Encounter{}
+	err := row.Scan(
+		&encounter.ID,
+		&encounter.AppointmentID,
+		&encounter.PatientID,
+		&encounter.DoctorID,
+		&encounter.Subjective,
+		&encounter.Objective,
+		&encounter.Assessment,
+		&encounter.Plan,
+		&encounter.Status,
+		&encounter.SignedAt,
+		&encounter.CreatedAt,
+		&encounter.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return encounter, nil
+}
+
+// CreateFromAppointment marks a scheduled appointment of the doctor as
+// completed and opens a draft encounter for it. It returns ErrConflict when
+// the appointment is not scheduled or already has an encounter.
+func (s *EncounterStore) CreateFromAppointment(ctx context.Context, appointmentID, doctorID uuid.UUID) (*Encounter, error) {
+	var encounter *Encounter
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var patientID uuid.UUID
+		err := tx.QueryRowContext(ctx, `
+			UPDATE appointment SET status = 'completed'
+			WHERE id = $1 AND doctor_id = $2 AND status = 'scheduled'
+			RETURNING patient_id
+		`, appointmentID, doctorID).Scan(&patientID)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrConflict
+			default:
+				return err
+			}
+		}
+
+		query := `
+			INSERT INTO encounters (appointment_id, patient_id, doctor_id)
+			VALUES ($1, $2, $3)
+			RETURNING ` + encounterColumns + `
+		`
+
+		encounter, err = scanEncounter(tx.QueryRowContext(ctx, query, appointmentID, patientID, doctorID))
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "encounters_appointment_key"):
+				return ErrConflict
+			default:
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return encounter, nil
+}
+
+func (s *EncounterStore) GetByID(ctx context.Context, id uuid.UUID) (*Encounter, error) {
+	query := `SELECT ` + encounterColumns + ` FROM encounters WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	encounter, err := scanEncounter(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	diagnoses, err := s.getDiagnoses(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	encounter.Diagnoses = diagnoses
+
+	addenda, err := s.getAddenda(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	encounter.Addenda = addenda
+
+	return encounter, nil
+}
+
+// GetByPatient returns the patient's encounters, newest first, without
+// their addenda.
+func (s *EncounterStore) GetByPatient(ctx context.Context, patientID uuid.UUID) ([]*Encounter, error) {
+	query := `
+		SELECT ` + encounterColumns + `
+		FROM encounters
+		WHERE patient_id = $1
+		ORDER BY created_at DESC, id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	encounters := []*Encounter{}
+	for rows.Next() {
+		encounter, err := scanEncounter(rows)
+		if err != nil {
+			return nil, err
+		}
+		encounters = append(encounters, encounter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return encounters, nil
+}
+
+// UpdateNote replaces the SOAP note of a draft encounter. It returns
+// ErrLocked when the encounter has been signed.
+func (s *EncounterStore) UpdateNote(ctx context.Context, encounter *Encounter) error {
+	query := `
+		UPDATE encounters
+		SET subjective = $2, objective = $3, assessment = $4, plan = $5, updated_at = NOW()
+		WHERE id = $1 AND status = 'draft'
+		RETURNING updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		encounter.ID,
+		encounter.Subjective,
+		encounter.Objective,
+		encounter.Assessment,
+		encounter.Plan,
+	).Scan(&encounter.UpdatedAt)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows, strings.Contains(err.Error(), "encounters_locked"):
+			return ErrLocked
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Sign locks a draft encounter. It returns ErrLocked when the encounter has
+// already been signed.
+func (s *EncounterStore) Sign(ctx context.Context, encounter *Encounter) error {
+	query := `
+		UPDATE encounters
+		SET status = 'signed', signed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'draft'
+		RETURNING status, signed_at, updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, encounter.ID).Scan(
+		&encounter.Status,
+		&encounter.SignedAt,
+		&encounter.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows, strings.Contains(err.Error(), "encounters_locked"):
+			return ErrLocked
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddAddendum appends a correction to a signed encounter. It returns
+// ErrConflict when the encounter is still a draft, which should be edited
+// directly instead.
+func (s *EncounterStore) AddAddendum(ctx context.Context, addendum *EncounterAddendum) error {
+	query := `
+		INSERT INTO encounter_addenda (encounter_id, author_id, body)
+		SELECT id, $2, $3
+		FROM encounters
+		WHERE id = $1 AND status = 'signed'
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, addendum.EncounterID, addendum.AuthorID, addendum.Body).Scan(
+		&addendum.ID,
+		&addendum.CreatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetDiagnoses replaces the diagnoses of a draft encounter, keeping the given
+// order. It returns ErrLocked when the encounter has been signed and
+// ErrNotFound when a code is not in the ICD-10 table.
+func (s *EncounterStore) SetDiagnoses(ctx context.Context, encounterID uuid.UUID, diagnoses []EncounterDiagnosis) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		// lock the encounter so signing waits for the new diagnoses
+		var status EncounterStatus
+		err := tx.QueryRowContext(ctx, `SELECT status FROM encounters WHERE id = $1 FOR UPDATE`, encounterID).Scan(&status)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if status != EncounterDraft {
+			return ErrLocked
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM encounter_diagnoses WHERE encounter_id = $1`, encounterID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO encounter_diagnoses (encounter_id, code, rank, position)
+			VALUES ($1, $2, $3, $4)
+		`
+
+		for i, diagnosis := range diagnoses {
+			_, err := tx.ExecContext(ctx, query, encounterID, diagnosis.Code, diagnosis.Rank, i)
+			if err != nil {
+				switch {
+				case strings.Contains(err.Error(), "encounter_diagnoses_code_fkey"):
+					return ErrNotFound
+				case strings.Contains(err.Error(), "encounter_diagnoses_locked"):
+					return ErrLocked
+				default:
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *EncounterStore) getDiagnoses(ctx context.Context, encounterID uuid.UUID) ([]EncounterDiagnosis, error) {
+	query := `
+		SELECT ed.code, c.description, ed.rank
+		FROM encounter_diagnoses ed
+		JOIN icd10_codes c ON c.code = ed.code
+		WHERE ed.encounter_id = $1
+		ORDER BY ed.rank, ed.position
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	diagnoses := []EncounterDiagnosis{}
+	for rows.Next() {
+		var diagnosis EncounterDiagnosis
+		if err := rows.Scan(&diagnosis.Code, &diagnosis.Description, &diagnosis.Rank); err != nil {
+			return nil, err
+		}
+		diagnoses = append(diagnoses, diagnosis)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return diagnoses, nil
+}
+
+func (s *EncounterStore) getAddenda(ctx context.Context, encounterID uuid.UUID) ([]EncounterAddendum, error) {
+	query := `
+		SELECT id, encounter_id, author_id, body, created_at
+		FROM encounter_addenda
+		WHERE encounter_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addenda := []EncounterAddendum{}
+	for rows.Next() {
+		var addendum EncounterAddendum
+		err := rows.Scan(
+			&addendum.ID,
+			&addendum.EncounterID,
+			&addendum.AuthorID,
+			&addendum.Body,
+			&addendum.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		addenda = append(addenda, addendum)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return addenda, nil
+}
+
+// IsTreatingDoctor reports whether the doctor has seen the patient: an
+// encounter or a completed appointment, an admission under them or a
+// referral they accepted. A booking alone does not count, as one can be
+// made for any doctor ahead of the visit.
+func (s *EncounterStore) IsTreatingDoctor(ctx context.Context, doctorID, patientID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM encounters
+			WHERE doctor_id = $1 AND patient_id = $2
+		) OR EXISTS (
+			SELECT 1 FROM appointment
+			WHERE doctor_id = $1 AND patient_id = $2 AND status = 'completed'
+		) OR EXISTS (
+			SELECT 1 FROM admissions
+			WHERE attending_doctor_id = $1 AND patient_id = $2
+		) OR EXISTS (
+			SELECT 1 FROM referrals
+			WHERE to_doctor_id = $1 AND patient_id = $2
+				AND responded_at IS NOT NULL AND status <> 'declined'
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var treating bool
+	if err := s.db.QueryRowContext(ctx, query, doctorID, patientID).Scan(&treating); err != nil {
+		return false, err
+	}
+
+	return treating, nil
+}
@@ -0,0 +1,398 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type ERVisitStatus string
+
+const (
+	ERWaiting     ERVisitStatus = "waiting"
+	ERInTreatment ERVisitStatus = "in_treatment"
+	ERDeparted    ERVisitStatus = "departed"
+)
+
+type ERDisposition string
+
+const (
+	ERDischarged           ERDisposition = "discharged"
+	ERAdmitted             ERDisposition = "admitted"
+	ERTransferred          ERDisposition = "transferred"
+	ERLeftWithoutBeingSeen ERDisposition = "left_without_being_seen"
+	ERDeceased             ERDisposition = "deceased"
+)
+
+// ERVisit is an emergency attendance. Acuity is the ESI-style triage level
+// from 1, resuscitation, to 5, non-urgent. PatientID is nil until an
+// unidentified arrival is linked to a patient.
+type ERVisit struct {
+	ID              uuid.UUID      `json:"id"`
+	PatientID       *uuid.UUID     `json:"patient_id"`
+	PatientName     string         `json:"patient_name"`
+	Acuity          int            `json:"acuity"`
+	ChiefComplaint  string         `json:"chief_complaint"`
+	Status          ERVisitStatus  `json:"status"`
+	ArrivedAt       time.Time      `json:"arrived_at"`
+	TriagedBy       *uuid.UUID     `json:"triaged_by"`
+	SeenAt          *time.Time     `json:"seen_at"`
+	SeenBy          *uuid.UUID     `json:"seen_by"`
+	DepartedAt      *time.Time     `json:"departed_at"`
+	Disposition     *ERDisposition `json:"disposition"`
+	BreachAlertedAt *time.Time     `json:"breach_alerted_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	// WaitMinutes runs from arrival until the patient is seen
+	WaitMinutes    int `json:"wait_minutes"`
+	MaxWaitMinutes int `json:"max_wait_minutes"`
+	// Breached is set while a waiting patient is over the target wait
+	Breached bool `json:"breached"`
+}
+
+// ERBoard is the department at a glance. Both lists are ordered by acuity
+// and then by arrival.
+type ERBoard struct {
+	Waiting     []ERVisit `json:"waiting"`
+	InTreatment []ERVisit `json:"in_treatment"`
+	Breaches    int       `json:"breaches"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// ERWaitTarget is the longest acceptable wait to be seen at a triage level.
+type ERWaitTarget struct {
+	Acuity         int        `json:"acuity"`
+	MaxWaitMinutes int        `json:"max_wait_minutes"`
+	UpdatedBy      *uuid.UUID `json:"updated_by"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type ERStore struct {
+	db *sql.DB
+}
+
+const erVisitQuery = `
+	SELECT v.id, v.patient_id, v.patient_name, v.acuity, v.chief_complaint, v.status, v.arrived_at,
+		v.triaged_by, v.seen_at, v.seen_by, v.departed_at, v.disposition, v.breach_alerted_at, v.updated_at,
+		t.max_wait_minutes,
+		floor(EXTRACT(EPOCH FROM COALESCE(v.seen_at, v.departed_at, NOW()) - v.arrived_at))::bigint
+	FROM er_visits v
+	JOIN er_wait_targets t ON t.acuity = v.acuity`
+
+func scanERVisit(row rowScanner) (*ERVisit, error) {
+	v := &ERVisit{}
+	var waitSeconds int64
+
+	err := row.Scan(
+		&v.ID,
+		&v.PatientID,
+		&v.PatientName,
+		&v.Acuity,
+		&v.ChiefComplaint,
+		&v.Status,
+		&v.ArrivedAt,
+		&v.TriagedBy,
+		&v.SeenAt,
+		&v.SeenBy,
+		&v.DepartedAt,
+		&v.Disposition,
+		&v.BreachAlertedAt,
+		&v.UpdatedAt,
+		&v.MaxWaitMinutes,
+		&waitSeconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	v.WaitMinutes = int(waitSeconds / 60)
+	v.Breached = v.Status == ERWaiting && waitSeconds > int64(v.MaxWaitMinutes)*60
+
+	return v, nil
+}
+
+func (s *ERStore) getVisits(ctx context.Context, query string, args ...any) ([]ERVisit, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	visits := []ERVisit{}
+	for rows.Next() {
+		visit, err := scanERVisit(rows)
+		if err != nil {
+			return nil, err
+		}
+		visits = append(visits, *visit)
+	}
+
+	return visits, rows.Err()
+}
+
+// Register adds an arrival to the queue. It returns ErrConflict if the
+// patient is already in the department and ErrNotFound for an unknown
+// patient.
+func (s *ERStore) Register(ctx context.Context, visit *ERVisit) error {
+	query := `
+		INSERT INTO er_visits (patient_id, patient_name, acuity, chief_complaint, triaged_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		visit.PatientID,
+		visit.PatientName,
+		visit.Acuity,
+		visit.ChiefComplaint,
+		visit.TriagedBy,
+	).Scan(&visit.ID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "er_visits_active_patient_key"):
+			return ErrConflict
+		case strings.Contains(err.Error(), "er_visits_patient_id_fkey"):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	return s.reload(ctx, visit)
+}
+
+func (s *ERStore) reload(ctx context.Context, visit *ERVisit) error {
+	fresh, err := s.GetByID(ctx, visit.ID)
+	if err != nil {
+		return err
+	}
+
+	*visit = *fresh
+	return nil
+}
+
+func (s *ERStore) GetByID(ctx context.Context, id uuid.UUID) (*ERVisit, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	visit, err := scanERVisit(s.db.QueryRowContext(ctx, erVisitQuery+` WHERE v.id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return visit, nil
+}
+
+// GetBoard returns the patients in the department.
+func (s *ERStore) GetBoard(ctx context.Context) (*ERBoard, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	visits, err := s.getVisits(ctx, erVisitQuery+` WHERE v.status <> 'departed' ORDER BY v.acuity, v.arrived_at, v.id`)
+	if err != nil {
+		return nil, err
+	}
+
+	board := &ERBoard{
+		Waiting:     []ERVisit{},
+		InTreatment: []ERVisit{},
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	for _, visit := range visits {
+		switch visit.Status {
+		case ERWaiting:
+			board.Waiting = append(board.Waiting, visit)
+			if visit.Breached {
+				board.Breaches++
+			}
+		case ERInTreatment:
+			board.InTreatment = append(board.InTreatment, visit)
+		}
+	}
+
+	return board, nil
+}
+
+// Retriage changes the triage of a visit still in the department, and may
+// link an unidentified arrival to a patient. A new acuity is held to its
+// own wait target, so a breach is alerted again. It returns ErrLocked once
+// the patient has left, and ErrConflict or ErrNotFound as Register does.
+func (s *ERStore) Retriage(ctx context.Context, visit *ERVisit) error {
+	query := `
+		UPDATE er_visits
+		SET patient_id = $2, patient_name = $3, chief_complaint = $4, triaged_by = $5,
+			breach_alerted_at = CASE WHEN acuity = $6 THEN breach_alerted_at END,
+			acuity = $6, updated_at = NOW()
+		WHERE id = $1 AND status <> 'departed'
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query,
+		visit.ID,
+		visit.PatientID,
+		visit.PatientName,
+		visit.ChiefComplaint,
+		visit.TriagedBy,
+		visit.Acuity,
+	)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "er_visits_active_patient_key"):
+			return ErrConflict
+		case strings.Contains(err.Error(), "er_visits_patient_id_fkey"):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrLocked
+	}
+
+	return s.reload(ctx, visit)
+}
+
+// StartTreatment records that a waiting patient was seen. It returns
+// ErrLocked unless the patient is waiting.
+func (s *ERStore) StartTreatment(ctx context.Context, visit *ERVisit, seenBy uuid.UUID) error {
+	query := `
+		UPDATE er_visits SET status = 'in_treatment', seen_at = NOW(), seen_by = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'waiting'
+	`
+
+	return s.transition(ctx, visit, query, visit.ID, seenBy)
+}
+
+// Depart records that the patient left the department. It returns
+// ErrLocked if they already left.
+func (s *ERStore) Depart(ctx context.Context, visit *ERVisit, disposition ERDisposition) error {
+	query := `
+		UPDATE er_visits SET status = 'departed', departed_at = NOW(), disposition = $2, updated_at = NOW()
+		WHERE id = $1 AND status <> 'departed'
+	`
+
+	return s.transition(ctx, visit, query, visit.ID, disposition)
+}
+
+func (s *ERStore) transition(ctx context.Context, visit *ERVisit, query string, args ...any) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrLocked
+	}
+
+	return s.reload(ctx, visit)
+}
+
+// ClaimBreaches marks the waiting visits that went over their target wait
+// since the last call and returns them, so each breach is alerted once even
+// with several servers running.
+func (s *ERStore) ClaimBreaches(ctx context.Context) ([]ERVisit, error) {
+	query := `
+		UPDATE er_visits v SET breach_alerted_at = NOW()
+		FROM er_wait_targets t
+		WHERE t.acuity = v.acuity AND v.status = 'waiting' AND v.breach_alerted_at IS NULL
+			AND v.arrived_at + t.max_wait_minutes * interval '1 minute' < NOW()
+		RETURNING v.id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return []ERVisit{}, nil
+	}
+
+	return s.getVisits(ctx, erVisitQuery+` WHERE v.id = ANY($1::uuid[]) ORDER BY v.acuity, v.arrived_at, v.id`, pq.Array(ids))
+}
+
+func (s *ERStore) GetWaitTargets(ctx context.Context) ([]ERWaitTarget, error) {
+	query := `SELECT acuity, max_wait_minutes, updated_by, updated_at FROM er_wait_targets ORDER BY acuity`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	targets := []ERWaitTarget{}
+	for rows.Next() {
+		var t ERWaitTarget
+		if err := rows.Scan(&t.Acuity, &t.MaxWaitMinutes, &t.UpdatedBy, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, rows.Err()
+}
+
+// SetWaitTarget changes the target wait of a level. Waiting visits already
+// alerted are not alerted again.
+func (s *ERStore) SetWaitTarget(ctx context.Context, t *ERWaitTarget) error {
+	query := `
+		INSERT INTO er_wait_targets (acuity, max_wait_minutes, updated_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (acuity) DO UPDATE
+		SET max_wait_minutes = EXCLUDED.max_wait_minutes, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return s.db.QueryRowContext(ctx, query, t.Acuity, t.MaxWaitMinutes, t.UpdatedBy).Scan(&t.UpdatedAt)
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type VisitType string
+
+const (
+	VisitTypeNewPatient VisitType = "new_patient"
+	VisitTypeFollowUp   VisitType = "follow_up"
+)
+
+type ConsultationMode string
+
+const (
+	ConsultationModeInPerson ConsultationMode = "in_person"
+	ConsultationModeOnline   ConsultationMode = "online"
+)
+
+// DoctorFee is the price of one visit type and consultation mode for a doctor
+// from EffectiveFrom onwards. Amount is in minor currency units.
+type DoctorFee struct {
+	ID               int64            `json:"id"`
+	DoctorID         uuid.UUID        `json:"doctor_id"`
+	VisitType        VisitType        `json:"visit_type"`
+	ConsultationMode ConsultationMode `json:"consultation_mode"`
+	Amount           int64            `json:"amount"`
+	Currency         string           `json:"currency"`
+	EffectiveFrom    string           `json:"effective_from"`
+	CreatedAt        string           `json:"created_at,omitempty"`
+}
+
+type FeeStore struct {
+	db *sql.DB
+}
+
+func (s *FeeStore) Create(ctx context.Context, fee *DoctorFee) error {
+	query := `
+		INSERT INTO doctor_fees (doctor_id, visit_type, consultation_mode, amount, currency, effective_from)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		fee.DoctorID,
+		fee.VisitType,
+		fee.ConsultationMode,
+		fee.Amount,
+		fee.Currency,
+		fee.EffectiveFrom,
+	).Scan(
+		&fee.ID,
+		&fee.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "doctor_fees_version_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetByDoctor returns the full fee history of a doctor, newest first.
+func (s *FeeStore) GetByDoctor(ctx context.Context, doctorID uuid.UUID) ([]DoctorFee, error) {
+	query := `
+		SELECT id, doctor_id, visit_type, consultation_mode, amount, currency,
+			to_char(effective_from, 'YYYY-MM-DD'), created_at
+		FROM doctor_fees
+		WHERE doctor_id = $1
+		ORDER BY visit_type, consultation_mode, effective_from DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, doctorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fees := []DoctorFee{}
+	for rows.Next() {
+		var fee DoctorFee
+		err := rows.Scan(
+			&fee.ID,
+			&fee.DoctorID,
+			&fee.VisitType,
+			&fee.ConsultationMode,
+			&fee.Amount,
+			&fee.Currency,
+			&fee.EffectiveFrom,
+			&fee.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		fees = append(fees, fee)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return fees, nil
+}
+
+// GetEffective returns the fee that applies to a visit on the given date,
+// taken in UTC as bookings do.
+func (s *FeeStore) GetEffective(ctx context.Context, doctorID uuid.UUID, visitType VisitType, mode ConsultationMode, at time.Time) (*DoctorFee, error) {
+	query := `
+		SELECT id, doctor_id, visit_type, consultation_mode, amount, currency,
+			to_char(effective_from, 'YYYY-MM-DD'), created_at
+		FROM doctor_fees
+		WHERE doctor_id = $1 AND visit_type = $2 AND consultation_mode = $3 AND effective_from <= $4::date
+		ORDER BY effective_from DESC
+		LIMIT 1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	fee := &DoctorFee{}
+	err := s.db.QueryRowContext(ctx, query, doctorID, visitType, mode, at.UTC().Format(DateLayout)).Scan(
+		&fee.ID,
+		&fee.DoctorID,
+		&fee.VisitType,
+		&fee.ConsultationMode,
+		&fee.Amount,
+		&fee.Currency,
+		&fee.EffectiveFrom,
+		&fee.CreatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return fee, nil
+}
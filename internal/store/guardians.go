@@ -0,0 +1,290 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GuardianRelationship string
+
+const (
+	GuardianParent        GuardianRelationship = "parent"
+	GuardianLegalGuardian GuardianRelationship = "legal_guardian"
+	GuardianSpouse        GuardianRelationship = "spouse"
+	GuardianChild         GuardianRelationship = "child"
+	GuardianSibling       GuardianRelationship = "sibling"
+	GuardianCaregiver     GuardianRelationship = "caregiver"
+	GuardianOther         GuardianRelationship = "other"
+)
+
+// Guardian is an account that manages a patient's record, appointments
+// included, on the patient's behalf.
+type Guardian struct {
+	UserID       uuid.UUID            `json:"user_id"`
+	Username     string               `json:"username"`
+	Email        string               `json:"email"`
+	Relationship GuardianRelationship `json:"relationship"`
+	CreatedAt    time.Time            `json:"created_at"`
+}
+
+type GuardianStore struct {
+	db *sql.DB
+}
+
+// CreateDependent creates a patient without a login of their own, managed by
+// the guardian. The patient's user account has no email or password and is
+// named by username.
+func (s *GuardianStore) CreateDependent(ctx context.Context, patient *Patient, guardianID uuid.UUID, relationship GuardianRelationship, issueMRN func(seq int64) string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		query := `
+			INSERT INTO users (username, role_id, is_active)
+			VALUES ($1, (SELECT id FROM roles WHERE name = 'patient'), true)
+			RETURNING id
+		`
+
+		err := tx.QueryRowContext(ctx, query, patient.Username).Scan(&patient.UserID)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "users_username_key"):
+				return ErrDuplicateUsername
+			default:
+				return err
+			}
+		}
+
+		if err := createPatient(ctx, tx, patient, issueMRN); err != nil {
+			return err
+		}
+		patient.Dependent = true
+
+		return addGuardian(ctx, tx, patient.UserID, guardianID, relationship)
+	})
+}
+
+// GetDependents returns the patients the user is a guardian of, whether or
+// not they have a login of their own.
+func (s *GuardianStore) GetDependents(ctx context.Context, guardianID uuid.UUID) ([]*Patient, error) {
+	query := `
+		SELECT ` + patientColumns + `
+		FROM patient_guardians g
+		JOIN patients p ON p.user_id = g.patient_id
+		JOIN users u ON u.id = p.user_id
+		WHERE g.guardian_id = $1
+		ORDER BY p.date_of_birth, p.firstname
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, guardianID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	patients := []*Patient{}
+	for rows.Next() {
+		patient, err := scanPatient(rows)
+		if err != nil {
+			return nil, err
+		}
+		patients = append(patients, patient)
+	}
+
+	return patients, rows.Err()
+}
+
+func (s *GuardianStore) GetGuardians(ctx context.Context, patientID uuid.UUID) ([]Guardian, error) {
+	query := `
+		SELECT u.id, u.username, COALESCE(u.email, ''), g.relationship, g.created_at
+		FROM patient_guardians g
+		JOIN users u ON u.id = g.guardian_id
+		WHERE g.patient_id = $1
+		ORDER BY g.created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guardians := []Guardian{}
+	for rows.Next() {
+		var g Guardian
+		if err := rows.Scan(&g.UserID, &g.Username, &g.Email, &g.Relationship, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		guardians = append(guardians, g)
+	}
+
+	return guardians, rows.Err()
+}
+
+// AddGuardian makes the user a guardian of the patient. It returns
+// ErrConflict if they already are.
+func (s *GuardianStore) AddGuardian(ctx context.Context, patientID, guardianID uuid.UUID, relationship GuardianRelationship) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		return addGuardian(ctx, tx, patientID, guardianID, relationship)
+	})
+}
+
+func addGuardian(ctx context.Context, tx *sql.Tx, patientID, guardianID uuid.UUID, relationship GuardianRelationship) error {
+	query := `
+		INSERT INTO patient_guardians (patient_id, guardian_id, relationship)
+		VALUES ($1, $2, $3)
+	`
+
+	_, err := tx.ExecContext(ctx, query, patientID, guardianID, relationship)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "patient_guardians_pkey"),
+			strings.Contains(err.Error(), "patient_guardians_self_check"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveGuardian unlinks a guardian from the patient. A dependent keeps at
+// least one guardian, so removing the last one returns ErrLocked.
+func (s *GuardianStore) RemoveGuardian(ctx context.Context, patientID, guardianID uuid.UUID) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		// lock the patient's guardians so two removals cannot both pass the check
+		var dependent bool
+		var guardians int
+		err := tx.QueryRowContext(ctx, `
+			SELECT u.password IS NULL, (SELECT count(*) FROM patient_guardians WHERE patient_id = $1)
+			FROM users u
+			WHERE u.id = $1
+			FOR UPDATE
+		`, patientID).Scan(&dependent, &guardians)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM patient_guardians WHERE patient_id = $1 AND guardian_id = $2`, patientID, guardianID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		if dependent && guardians <= 1 {
+			return ErrLocked
+		}
+
+		return nil
+	})
+}
+
+// Upgrade gives a dependent the username and email of user, whose ID names
+// the patient, and invites them to activate the account with a password of
+// their own. The patient stays a dependent, managed by their guardians,
+// until then. Upgrading again while the invitation is pending replaces it,
+// to resend it or correct the email. It returns ErrConflict if the patient
+// already has a login of their own.
+func (s *GuardianStore) Upgrade(ctx context.Context, user *User, token string, invitationExp time.Duration) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		query := `
+			UPDATE users
+			SET username = $2, email = $3, is_active = false
+			WHERE id = $1 AND password IS NULL
+			RETURNING created_at
+		`
+
+		err := tx.QueryRowContext(ctx, query, user.ID, user.Username, user.Email).Scan(&user.CreatedAt)
+		if err != nil {
+			switch {
+			case err == sql.ErrNoRows:
+				return ErrConflict
+			case strings.Contains(err.Error(), "users_email_key"):
+				return ErrDuplicateEmail
+			case strings.Contains(err.Error(), "users_username_key"):
+				return ErrDuplicateUsername
+			default:
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_invitations WHERE user_id = $1`, user.ID); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO user_invitations (token, user_id, expiry) VALUES ($1, $2, $3)`,
+			token,
+			user.ID,
+			time.Now().Add(invitationExp),
+		)
+		return err
+	})
+}
+
+// CancelUpgrade withdraws the invitation of a dependent who has not
+// activated their login, naming them username again. It returns ErrConflict
+// if the patient already has a login of their own.
+func (s *GuardianStore) CancelUpgrade(ctx context.Context, patientID uuid.UUID, username string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		query := `
+			UPDATE users
+			SET username = $2, email = NULL, is_active = true
+			WHERE id = $1 AND password IS NULL
+		`
+
+		res, err := tx.ExecContext(ctx, query, patientID, username)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return ErrConflict
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM user_invitations WHERE user_id = $1`, patientID)
+		return err
+	})
+}
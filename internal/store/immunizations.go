@@ -0,0 +1,382 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduledDose is one dose of the immunization schedule. The dose is due
+// DueAgeDays after birth, but no sooner than MinIntervalDays after the
+// previous dose of the same vaccine, and overdue GraceDays after it is due.
+// Past CatchUpUntilDays of age it is no longer offered.
+type ScheduledDose struct {
+	Vaccine          string    `json:"vaccine"`
+	DoseNumber       int       `json:"dose_number"`
+	VaccineName      string    `json:"vaccine_name"`
+	DueAgeDays       int       `json:"due_age_days"`
+	MinIntervalDays  int       `json:"min_interval_days"`
+	GraceDays        int       `json:"grace_days"`
+	CatchUpUntilDays *int      `json:"catch_up_until_days"`
+	Active           bool      `json:"active"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type ImmunizationSite string
+
+const (
+	ImmunizationSiteLeftArm    ImmunizationSite = "left_arm"
+	ImmunizationSiteRightArm   ImmunizationSite = "right_arm"
+	ImmunizationSiteLeftThigh  ImmunizationSite = "left_thigh"
+	ImmunizationSiteRightThigh ImmunizationSite = "right_thigh"
+	ImmunizationSiteOral       ImmunizationSite = "oral"
+	ImmunizationSiteIntranasal ImmunizationSite = "intranasal"
+	ImmunizationSiteOther      ImmunizationSite = "other"
+)
+
+// Immunization is an administered vaccine dose.
+type Immunization struct {
+	ID             uuid.UUID        `json:"id"`
+	PatientID      uuid.UUID        `json:"patient_id"`
+	Vaccine        string           `json:"vaccine"`
+	VaccineName    string           `json:"vaccine_name"`
+	DoseNumber     int              `json:"dose_number"`
+	LotNumber      string           `json:"lot_number"`
+	Site           ImmunizationSite `json:"site"`
+	AdministeredAt time.Time        `json:"administered_at"`
+	AdministeredBy uuid.UUID        `json:"administered_by"`
+	Notes          string           `json:"notes"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+type DoseState string
+
+const (
+	DoseCompleted DoseState = "completed"
+	DoseUpcoming  DoseState = "upcoming"
+	DoseDue       DoseState = "due"
+	DoseOverdue   DoseState = "overdue"
+	// DoseMissed doses were not given before the end of their catch-up age.
+	DoseMissed DoseState = "missed"
+)
+
+// DoseStatus is where a patient stands on one scheduled dose. Dates are
+// calendar dates; they are empty while the previous dose of the vaccine has
+// not been given, since the interval between doses is not known yet.
+type DoseStatus struct {
+	Vaccine        string     `json:"vaccine"`
+	VaccineName    string     `json:"vaccine_name"`
+	DoseNumber     int        `json:"dose_number"`
+	Status         DoseState  `json:"status"`
+	DueDate        string     `json:"due_date,omitempty"`
+	OverdueDate    string     `json:"overdue_date,omitempty"`
+	ImmunizationID *uuid.UUID `json:"immunization_id,omitempty"`
+	AdministeredAt *time.Time `json:"administered_at,omitempty"`
+}
+
+type doseKey struct {
+	vaccine string
+	dose    int
+}
+
+// ImmunizationStatus works out the state of each scheduled dose for a
+// patient born on dob, given the doses already administered, as of today.
+func ImmunizationStatus(schedule []ScheduledDose, given []Immunization, dob, today time.Time) []DoseStatus {
+	day := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	dob, today = day(dob), day(today)
+
+	administered := make(map[doseKey]*Immunization, len(given))
+	for i := range given {
+		administered[doseKey{given[i].Vaccine, given[i].DoseNumber}] = &given[i]
+	}
+
+	scheduled := make(map[doseKey]bool, len(schedule))
+	for _, dose := range schedule {
+		scheduled[doseKey{dose.Vaccine, dose.DoseNumber}] = true
+	}
+
+	statuses := make([]DoseStatus, 0, len(schedule))
+	for _, dose := range schedule {
+		status := DoseStatus{
+			Vaccine:     dose.Vaccine,
+			VaccineName: dose.VaccineName,
+			DoseNumber:  dose.DoseNumber,
+		}
+
+		if im, ok := administered[doseKey{dose.Vaccine, dose.DoseNumber}]; ok {
+			status.Status = DoseCompleted
+			status.ImmunizationID = &im.ID
+			status.AdministeredAt = &im.AdministeredAt
+			statuses = append(statuses, status)
+			continue
+		}
+
+		if dose.CatchUpUntilDays != nil && !today.Before(dob.AddDate(0, 0, *dose.CatchUpUntilDays)) {
+			status.Status = DoseMissed
+			statuses = append(statuses, status)
+			continue
+		}
+
+		due := dob.AddDate(0, 0, dose.DueAgeDays)
+
+		previous := doseKey{dose.Vaccine, dose.DoseNumber - 1}
+		if prev, ok := administered[previous]; ok {
+			if earliest := day(prev.AdministeredAt).AddDate(0, 0, dose.MinIntervalDays); earliest.After(due) {
+				due = earliest
+			}
+		} else if scheduled[previous] {
+			status.Status = DoseUpcoming
+			statuses = append(statuses, status)
+			continue
+		}
+
+		overdue := due.AddDate(0, 0, dose.GraceDays)
+		status.DueDate = due.Format(DateLayout)
+		status.OverdueDate = overdue.Format(DateLayout)
+
+		switch {
+		case today.Before(due):
+			status.Status = DoseUpcoming
+		case today.Before(overdue):
+			status.Status = DoseDue
+		default:
+			status.Status = DoseOverdue
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+type ImmunizationStore struct {
+	db *sql.DB
+}
+
+func (s *ImmunizationStore) GetSchedule(ctx context.Context, activeOnly bool) ([]ScheduledDose, error) {
+	query := `
+		SELECT vaccine, dose_number, vaccine_name, due_age_days, min_interval_days, grace_days,
+			catch_up_until_days, active, updated_at
+		FROM immunization_schedule
+		WHERE active OR NOT $1
+		ORDER BY due_age_days, vaccine, dose_number
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedule := []ScheduledDose{}
+	for rows.Next() {
+		var dose ScheduledDose
+		err := rows.Scan(
+			&dose.Vaccine,
+			&dose.DoseNumber,
+			&dose.VaccineName,
+			&dose.DueAgeDays,
+			&dose.MinIntervalDays,
+			&dose.GraceDays,
+			&dose.CatchUpUntilDays,
+			&dose.Active,
+			&dose.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, dose)
+	}
+
+	return schedule, rows.Err()
+}
+
+// SetScheduledDose creates or replaces a dose of the schedule.
+func (s *ImmunizationStore) SetScheduledDose(ctx context.Context, dose *ScheduledDose) error {
+	query := `
+		INSERT INTO immunization_schedule (vaccine, dose_number, vaccine_name, due_age_days, min_interval_days,
+			grace_days, catch_up_until_days, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (vaccine, dose_number) DO UPDATE
+		SET vaccine_name = EXCLUDED.vaccine_name, due_age_days = EXCLUDED.due_age_days,
+			min_interval_days = EXCLUDED.min_interval_days, grace_days = EXCLUDED.grace_days,
+			catch_up_until_days = EXCLUDED.catch_up_until_days, active = EXCLUDED.active, updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return s.db.QueryRowContext(
+		ctx,
+		query,
+		dose.Vaccine,
+		dose.DoseNumber,
+		dose.VaccineName,
+		dose.DueAgeDays,
+		dose.MinIntervalDays,
+		dose.GraceDays,
+		dose.CatchUpUntilDays,
+		dose.Active,
+	).Scan(&dose.UpdatedAt)
+}
+
+// Create records an administered dose. Recording the same dose of a vaccine
+// twice for a patient returns ErrConflict.
+func (s *ImmunizationStore) Create(ctx context.Context, im *Immunization) error {
+	query := `
+		INSERT INTO immunizations (patient_id, vaccine, vaccine_name, dose_number, lot_number, site,
+			administered_at, administered_by, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		im.PatientID,
+		im.Vaccine,
+		im.VaccineName,
+		im.DoseNumber,
+		im.LotNumber,
+		im.Site,
+		im.AdministeredAt,
+		im.AdministeredBy,
+		im.Notes,
+	).Scan(
+		&im.ID,
+		&im.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "immunizations_dose_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *ImmunizationStore) GetByPatient(ctx context.Context, patientID uuid.UUID) ([]Immunization, error) {
+	query := `
+		SELECT id, patient_id, vaccine, vaccine_name, dose_number, lot_number, site, administered_at,
+			administered_by, notes, created_at
+		FROM immunizations
+		WHERE patient_id = $1
+		ORDER BY administered_at, vaccine, dose_number
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	immunizations := []Immunization{}
+	for rows.Next() {
+		var im Immunization
+		err := rows.Scan(
+			&im.ID,
+			&im.PatientID,
+			&im.Vaccine,
+			&im.VaccineName,
+			&im.DoseNumber,
+			&im.LotNumber,
+			&im.Site,
+			&im.AdministeredAt,
+			&im.AdministeredBy,
+			&im.Notes,
+			&im.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		immunizations = append(immunizations, im)
+	}
+
+	return immunizations, rows.Err()
+}
+
+// GetOverduePatients returns the patients who, going by date of birth alone,
+// have an active scheduled dose past its grace period that was not given.
+// Intervals after earlier doses can push a dose later, so callers confirm
+// with ImmunizationStatus.
+func (s *ImmunizationStore) GetOverduePatients(ctx context.Context, today time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT p.user_id
+		FROM patients p
+		JOIN immunization_schedule s ON s.active
+		WHERE p.date_of_birth + s.due_age_days + s.grace_days <= $1::date
+			AND (s.catch_up_until_days IS NULL OR p.date_of_birth + s.catch_up_until_days > $1::date)
+			AND NOT EXISTS (
+				SELECT 1 FROM immunizations i
+				WHERE i.patient_id = p.user_id AND i.vaccine = s.vaccine AND i.dose_number = s.dose_number
+			)
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, today.Format(DateLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// ClaimReminder marks a reminder for an overdue dose as sent, unless one was
+// sent in the last resendAfterDays days. It reports whether the caller should
+// send the reminder, which keeps concurrent runs from emailing twice.
+func (s *ImmunizationStore) ClaimReminder(ctx context.Context, patientID uuid.UUID, vaccine string, doseNumber, resendAfterDays int) (bool, error) {
+	query := `
+		INSERT INTO immunization_reminders (patient_id, vaccine, dose_number)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (patient_id, vaccine, dose_number) DO UPDATE
+		SET sent_at = NOW()
+		WHERE immunization_reminders.sent_at <= NOW() - make_interval(days => $4)
+		RETURNING sent_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var sentAt time.Time
+	err := s.db.QueryRowContext(ctx, query, patientID, vaccine, doseNumber, resendAfterDays).Scan(&sentAt)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,637 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type LabOrderStatus string
+
+const (
+	LabOrderOrdered  LabOrderStatus = "ordered"
+	LabOrderResulted LabOrderStatus = "resulted"
+	LabOrderReleased LabOrderStatus = "released"
+)
+
+type LabFlag string
+
+const (
+	LabFlagNormal       LabFlag = "normal"
+	LabFlagLow          LabFlag = "low"
+	LabFlagHigh         LabFlag = "high"
+	LabFlagCriticalLow  LabFlag = "critical_low"
+	LabFlagCriticalHigh LabFlag = "critical_high"
+)
+
+func (f LabFlag) Critical() bool {
+	return f == LabFlagCriticalLow || f == LabFlagCriticalHigh
+}
+
+// LabReferenceRange applies to patients of Sex, or everyone when Sex is nil,
+// aged AgeMin up to but not including AgeMax completed years. Any bound may
+// be missing.
+type LabReferenceRange struct {
+	ID           int64    `json:"id"`
+	Sex          *Gender  `json:"sex"`
+	AgeMin       int      `json:"age_min"`
+	AgeMax       *int     `json:"age_max"`
+	Low          *float64 `json:"low"`
+	High         *float64 `json:"high"`
+	CriticalLow  *float64 `json:"critical_low"`
+	CriticalHigh *float64 `json:"critical_high"`
+}
+
+// Flag classifies a value against the range.
+func (r *LabReferenceRange) Flag(value float64) LabFlag {
+	switch {
+	case r.CriticalLow != nil && value < *r.CriticalLow:
+		return LabFlagCriticalLow
+	case r.CriticalHigh != nil && value > *r.CriticalHigh:
+		return LabFlagCriticalHigh
+	case r.Low != nil && value < *r.Low:
+		return LabFlagLow
+	case r.High != nil && value > *r.High:
+		return LabFlagHigh
+	default:
+		return LabFlagNormal
+	}
+}
+
+func (r *LabReferenceRange) matches(sex Gender, age *int) bool {
+	if r.Sex != nil && *r.Sex != sex {
+		return false
+	}
+
+	if age == nil {
+		// without an age only ranges covering every age apply
+		return r.AgeMin == 0 && r.AgeMax == nil
+	}
+
+	return *age >= r.AgeMin && (r.AgeMax == nil || *age < *r.AgeMax)
+}
+
+type LabTest struct {
+	Code      string              `json:"code"`
+	Name      string              `json:"name"`
+	Specimen  string              `json:"specimen"`
+	Unit      string              `json:"unit"`
+	Active    bool                `json:"active"`
+	Ranges    []LabReferenceRange `json:"ranges"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// RangeFor returns the most specific reference range for a patient of the
+// given sex and age, or nil if none applies. Sex may be empty and age nil
+// when they are not known.
+func (t *LabTest) RangeFor(sex Gender, age *int) *LabReferenceRange {
+	var best *LabReferenceRange
+	for i := range t.Ranges {
+		r := &t.Ranges[i]
+		if !r.matches(sex, age) {
+			continue
+		}
+
+		if best == nil || moreSpecific(r, best) {
+			best = r
+		}
+	}
+
+	return best
+}
+
+func moreSpecific(a, b *LabReferenceRange) bool {
+	if (a.Sex != nil) != (b.Sex != nil) {
+		return a.Sex != nil
+	}
+
+	if (a.AgeMax != nil) != (b.AgeMax != nil) {
+		return a.AgeMax != nil
+	}
+
+	return a.AgeMin > b.AgeMin
+}
+
+type LabResult struct {
+	TestCode  string     `json:"test_code"`
+	TestName  string     `json:"test_name"`
+	Value     *float64   `json:"value"`
+	Unit      string     `json:"unit"`
+	Low       *float64   `json:"low"`
+	High      *float64   `json:"high"`
+	Flag      *LabFlag   `json:"flag"`
+	Comment   string     `json:"comment"`
+	EnteredBy *uuid.UUID `json:"entered_by"`
+	EnteredAt *time.Time `json:"entered_at"`
+}
+
+type LabOrder struct {
+	ID          uuid.UUID      `json:"id"`
+	EncounterID uuid.UUID      `json:"encounter_id"`
+	PatientID   uuid.UUID      `json:"patient_id"`
+	OrderedBy   uuid.UUID      `json:"ordered_by"`
+	Status      LabOrderStatus `json:"status"`
+	Notes       string         `json:"notes"`
+	CreatedAt   time.Time      `json:"created_at"`
+	ResultedAt  *time.Time     `json:"resulted_at"`
+	ReleasedAt  *time.Time     `json:"released_at"`
+	Results     []LabResult    `json:"results"`
+}
+
+type LabOrderQuery struct {
+	PatientID    *uuid.UUID     `json:"-"`
+	ReleasedOnly bool           `json:"-"`
+	Status       LabOrderStatus `json:"status" validate:"omitempty,oneof=ordered resulted released"`
+	Limit        int            `json:"limit" validate:"gte=1,lte=100"`
+	Offset       int            `json:"offset" validate:"gte=0"`
+}
+
+func (q LabOrderQuery) Parse(r *http.Request) (LabOrderQuery, error) {
+	qs := r.URL.Query()
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return q, err
+		}
+
+		q.Limit = l
+	}
+
+	offset := qs.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return q, err
+		}
+
+		q.Offset = o
+	}
+
+	if status := qs.Get("status"); status != "" {
+		q.Status = LabOrderStatus(status)
+	}
+
+	return q, nil
+}
+
+type LabStore struct {
+	db *sql.DB
+}
+
+// GetTests returns the test catalog with reference ranges, optionally
+// limited to the given codes.
+func (s *LabStore) GetTests(ctx context.Context, codes []string, activeOnly bool) ([]*LabTest, error) {
+	query := `
+		SELECT code, name, specimen, unit, active, created_at
+		FROM lab_tests
+		WHERE ($1::varchar[] IS NULL OR code = ANY($1))
+			AND (NOT $2 OR active)
+		ORDER BY code
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var filter any
+	if codes != nil {
+		filter = pq.Array(codes)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, filter, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tests := []*LabTest{}
+	byCode := map[string]*LabTest{}
+	for rows.Next() {
+		t := &LabTest{Ranges: []LabReferenceRange{}}
+		if err := rows.Scan(&t.Code, &t.Name, &t.Specimen, &t.Unit, &t.Active, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		tests = append(tests, t)
+		byCode[t.Code] = t
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(tests) == 0 {
+		return tests, nil
+	}
+
+	rangeQuery := `
+		SELECT id, test_code, sex, age_min, age_max, low, high, critical_low, critical_high
+		FROM lab_reference_ranges
+		WHERE test_code = ANY($1)
+		ORDER BY test_code, sex NULLS FIRST, age_min
+	`
+
+	loaded := make([]string, 0, len(tests))
+	for _, t := range tests {
+		loaded = append(loaded, t.Code)
+	}
+
+	rangeRows, err := s.db.QueryContext(ctx, rangeQuery, pq.Array(loaded))
+	if err != nil {
+		return nil, err
+	}
+	defer rangeRows.Close()
+
+	for rangeRows.Next() {
+		var code string
+		var r LabReferenceRange
+		err := rangeRows.Scan(
+			&r.ID,
+			&code,
+			&r.Sex,
+			&r.AgeMin,
+			&r.AgeMax,
+			&r.Low,
+			&r.High,
+			&r.CriticalLow,
+			&r.CriticalHigh,
+		)
+		if err != nil {
+			return nil, err
+		}
+		byCode[code].Ranges = append(byCode[code].Ranges, r)
+	}
+
+	if err := rangeRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tests, nil
+}
+
+// CreateTest adds a test to the catalog with its reference ranges.
+func (s *LabStore) CreateTest(ctx context.Context, test *LabTest) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		query := `
+			INSERT INTO lab_tests (code, name, specimen, unit, active)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING created_at
+		`
+
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			test.Code,
+			test.Name,
+			test.Specimen,
+			test.Unit,
+			test.Active,
+		).Scan(&test.CreatedAt)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "lab_tests_pkey"):
+				return ErrConflict
+			default:
+				return err
+			}
+		}
+
+		rangeQuery := `
+			INSERT INTO lab_reference_ranges (test_code, sex, age_min, age_max, low, high,
+				critical_low, critical_high)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id
+		`
+
+		for i := range test.Ranges {
+			r := &test.Ranges[i]
+			err := tx.QueryRowContext(
+				ctx,
+				rangeQuery,
+				test.Code,
+				r.Sex,
+				r.AgeMin,
+				r.AgeMax,
+				r.Low,
+				r.High,
+				r.CriticalLow,
+				r.CriticalHigh,
+			).Scan(&r.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// CreateOrder creates an order for the tests with a pending result for each.
+// It returns ErrNotFound if a code is not an active test.
+func (s *LabStore) CreateOrder(ctx context.Context, order *LabOrder, codes []string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		query := `
+			INSERT INTO lab_orders (encounter_id, patient_id, ordered_by, notes)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, status, created_at
+		`
+
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			order.EncounterID,
+			order.PatientID,
+			order.OrderedBy,
+			order.Notes,
+		).Scan(
+			&order.ID,
+			&order.Status,
+			&order.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		resultQuery := `
+			INSERT INTO lab_results (order_id, test_code, unit)
+			SELECT $1, code, unit FROM lab_tests WHERE code = ANY($2) AND active
+		`
+
+		res, err := tx.ExecContext(ctx, resultQuery, order.ID, pq.Array(codes))
+		if err != nil {
+			return err
+		}
+
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if inserted != int64(len(codes)) {
+			return ErrNotFound
+		}
+
+		order.Results, err = getLabResults(ctx, tx, order.ID)
+		return err
+	})
+}
+
+const labOrderColumns = `
+	id, encounter_id, patient_id, ordered_by, status, notes, created_at, resulted_at, released_at
+`
+
+func scanLabOrder(row rowScanner) (*LabOrder, error) {
+	o := &LabOrder{}
+	err := row.Scan(
+		&o.ID,
+		&o.EncounterID,
+		&o.PatientID,
+		&o.OrderedBy,
+		&o.Status,
+		&o.Notes,
+		&o.CreatedAt,
+		&o.ResultedAt,
+		&o.ReleasedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func getLabResults(ctx context.Context, q queryer, orderID uuid.UUID) ([]LabResult, error) {
+	query := `
+		SELECT r.test_code, t.name, r.value, r.unit, r.low, r.high, r.flag, r.comment,
+			r.entered_by, r.entered_at
+		FROM lab_results r
+		JOIN lab_tests t ON t.code = r.test_code
+		WHERE r.order_id = $1
+		ORDER BY r.test_code
+	`
+
+	rows, err := q.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []LabResult{}
+	for rows.Next() {
+		var r LabResult
+		err := rows.Scan(
+			&r.TestCode,
+			&r.TestName,
+			&r.Value,
+			&r.Unit,
+			&r.Low,
+			&r.High,
+			&r.Flag,
+			&r.Comment,
+			&r.EnteredBy,
+			&r.EnteredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+func (s *LabStore) GetOrder(ctx context.Context, id uuid.UUID) (*LabOrder, error) {
+	query := `SELECT ` + labOrderColumns + ` FROM lab_orders WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	order, err := scanLabOrder(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	order.Results, err = getLabResults(ctx, s.db, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// ListOrders returns orders oldest first, so that the lab works through its
+// queue in order, or newest first for a patient's history.
+func (s *LabStore) ListOrders(ctx context.Context, q LabOrderQuery) ([]*LabOrder, error) {
+	query := `
+		SELECT ` + labOrderColumns + `
+		FROM lab_orders
+		WHERE ($1::uuid IS NULL OR patient_id = $1)
+			AND ($2 = '' OR status::text = $2)
+			AND (NOT $3 OR status = 'released')
+		ORDER BY
+			CASE WHEN $1::uuid IS NULL THEN created_at END ASC,
+			CASE WHEN $1::uuid IS NOT NULL THEN created_at END DESC,
+			id
+		LIMIT $4 OFFSET $5
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, q.PatientID, string(q.Status), q.ReleasedOnly, q.Limit, q.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []*LabOrder{}
+	for rows.Next() {
+		order, err := scanLabOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, order := range orders {
+		order.Results, err = getLabResults(ctx, s.db, order.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return orders, nil
+}
+
+// RecordResults enters or corrects results of an order. Once every test has
+// a value the order becomes resulted. Released orders return ErrLocked, and
+// a test that is not on the order returns ErrNotFound.
+func (s *LabStore) RecordResults(ctx context.Context, order *LabOrder, results []LabResult, enteredBy uuid.UUID) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var status LabOrderStatus
+		err := tx.QueryRowContext(ctx, `SELECT status FROM lab_orders WHERE id = $1 FOR UPDATE`, order.ID).Scan(&status)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if status == LabOrderReleased {
+			return ErrLocked
+		}
+
+		query := `
+			UPDATE lab_results
+			SET value = $3, low = $4, high = $5, flag = $6, comment = $7, entered_by = $8, entered_at = NOW()
+			WHERE order_id = $1 AND test_code = $2
+		`
+
+		for _, r := range results {
+			res, err := tx.ExecContext(
+				ctx,
+				query,
+				order.ID,
+				r.TestCode,
+				r.Value,
+				r.Low,
+				r.High,
+				r.Flag,
+				r.Comment,
+				enteredBy,
+			)
+			if err != nil {
+				return err
+			}
+
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if rows == 0 {
+				return ErrNotFound
+			}
+		}
+
+		statusQuery := `
+			UPDATE lab_orders
+			SET status = 'resulted', resulted_at = NOW()
+			WHERE id = $1 AND status = 'ordered'
+				AND NOT EXISTS (SELECT 1 FROM lab_results WHERE order_id = $1 AND value IS NULL)
+		`
+
+		if _, err := tx.ExecContext(ctx, statusQuery, order.ID); err != nil {
+			return err
+		}
+
+		updated, err := scanLabOrder(tx.QueryRowContext(ctx, `SELECT `+labOrderColumns+` FROM lab_orders WHERE id = $1`, order.ID))
+		if err != nil {
+			return err
+		}
+
+		updated.Results, err = getLabResults(ctx, tx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		*order = *updated
+		return nil
+	})
+}
+
+// Release makes the results of a resulted order visible to the patient. It
+// returns ErrLocked unless the order is resulted.
+func (s *LabStore) Release(ctx context.Context, order *LabOrder) error {
+	query := `
+		UPDATE lab_orders
+		SET status = 'released', released_at = NOW()
+		WHERE id = $1 AND status = 'resulted'
+		RETURNING status, released_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, order.ID).Scan(&order.Status, &order.ReleasedAt)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrLocked
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
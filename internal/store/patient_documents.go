@@ -0,0 +1,254 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PatientDocumentCategory string
+
+const (
+	PatientDocumentLabReport        PatientDocumentCategory = "lab_report"
+	PatientDocumentImaging          PatientDocumentCategory = "imaging"
+	PatientDocumentReferralLetter   PatientDocumentCategory = "referral_letter"
+	PatientDocumentDischargeSummary PatientDocumentCategory = "discharge_summary"
+	PatientDocumentPrescription     PatientDocumentCategory = "prescription"
+	PatientDocumentConsentForm      PatientDocumentCategory = "consent_form"
+	PatientDocumentInsurance        PatientDocumentCategory = "insurance"
+	PatientDocumentIdentity         PatientDocumentCategory = "identity"
+	PatientDocumentOther            PatientDocumentCategory = "other"
+)
+
+// PatientDocument is a file attached to a patient's record, such as a scanned
+// paper report, optionally linked to the encounter or appointment it belongs
+// to. The file itself lives in blob storage under StorageKey.
+type PatientDocument struct {
+	ID            uuid.UUID               `json:"id"`
+	PatientID     uuid.UUID               `json:"patient_id"`
+	Category      PatientDocumentCategory `json:"category"`
+	Title         string                  `json:"title"`
+	EncounterID   *uuid.UUID              `json:"encounter_id"`
+	AppointmentID *uuid.UUID              `json:"appointment_id"`
+	FileName      string                  `json:"file_name"`
+	ContentType   string                  `json:"content_type"`
+	SizeBytes     int64                   `json:"size_bytes"`
+	StorageKey    string                  `json:"-"`
+	ScannedBy     string                  `json:"scanned_by"`
+	UploadedBy    *uuid.UUID              `json:"uploaded_by"`
+	CreatedAt     time.Time               `json:"created_at"`
+}
+
+type PatientDocumentQuery struct {
+	PatientID     *uuid.UUID              `json:"-"`
+	Category      PatientDocumentCategory `json:"category" validate:"omitempty,oneof=lab_report imaging referral_letter discharge_summary prescription consent_form insurance identity other"`
+	EncounterID   *uuid.UUID              `json:"encounter_id"`
+	AppointmentID *uuid.UUID              `json:"appointment_id"`
+	Limit         int                     `json:"limit" validate:"gte=1,lte=100"`
+	Offset        int                     `json:"offset" validate:"gte=0"`
+}
+
+func (q PatientDocumentQuery) Parse(r *http.Request) (PatientDocumentQuery, error) {
+	qs := r.URL.Query()
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return q, err
+		}
+
+		q.Limit = l
+	}
+
+	offset := qs.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return q, err
+		}
+
+		q.Offset = o
+	}
+
+	if category := qs.Get("category"); category != "" {
+		q.Category = PatientDocumentCategory(category)
+	}
+
+	if encounterID := qs.Get("encounter_id"); encounterID != "" {
+		id, err := uuid.Parse(encounterID)
+		if err != nil {
+			return q, err
+		}
+
+		q.EncounterID = &id
+	}
+
+	if appointmentID := qs.Get("appointment_id"); appointmentID != "" {
+		id, err := uuid.Parse(appointmentID)
+		if err != nil {
+			return q, err
+		}
+
+		q.AppointmentID = &id
+	}
+
+	return q, nil
+}
+
+type PatientDocumentStore struct {
+	db *sql.DB
+}
+
+const patientDocumentColumns = `
+	id, patient_id, category, title, encounter_id, appointment_id, file_name, content_type, size_bytes,
+	storage_key, scanned_by, uploaded_by, created_at
+`
+
+func scanPatientDocument(row rowScanner) (*PatientDocument, error) {
+	doc := &PatientDocument{}
+	err := row.Scan(
+		&doc.ID,
+		&doc.PatientID,
+		&doc.Category,
+		&doc.Title,
+		&doc.EncounterID,
+		&doc.AppointmentID,
+		&doc.FileName,
+		&doc.ContentType,
+		&doc.SizeBytes,
+		&doc.StorageKey,
+		&doc.ScannedBy,
+		&doc.UploadedBy,
+		&doc.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func (s *PatientDocumentStore) Create(ctx context.Context, doc *PatientDocument) error {
+	query := `
+		INSERT INTO patient_documents (patient_id, category, title, encounter_id, appointment_id, file_name,
+			content_type, size_bytes, storage_key, scanned_by, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return s.db.QueryRowContext(
+		ctx,
+		query,
+		doc.PatientID,
+		doc.Category,
+		doc.Title,
+		doc.EncounterID,
+		doc.AppointmentID,
+		doc.FileName,
+		doc.ContentType,
+		doc.SizeBytes,
+		doc.StorageKey,
+		doc.ScannedBy,
+		doc.UploadedBy,
+	).Scan(
+		&doc.ID,
+		&doc.CreatedAt,
+	)
+}
+
+// List returns documents matching the query, newest first.
+func (s *PatientDocumentStore) List(ctx context.Context, q PatientDocumentQuery) ([]*PatientDocument, error) {
+	query := `
+		SELECT ` + patientDocumentColumns + `
+		FROM patient_documents
+		WHERE ($1::uuid IS NULL OR patient_id = $1)
+			AND ($2 = '' OR category::text = $2)
+			AND ($3::uuid IS NULL OR encounter_id = $3)
+			AND ($4::uuid IS NULL OR appointment_id = $4)
+		ORDER BY created_at DESC, id
+		LIMIT $5 OFFSET $6
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		q.PatientID,
+		string(q.Category),
+		q.EncounterID,
+		q.AppointmentID,
+		q.Limit,
+		q.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := []*PatientDocument{}
+	for rows.Next() {
+		doc, err := scanPatientDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
+func (s *PatientDocumentStore) GetByID(ctx context.Context, patientID, documentID uuid.UUID) (*PatientDocument, error) {
+	query := `
+		SELECT ` + patientDocumentColumns + `
+		FROM patient_documents
+		WHERE patient_id = $1 AND id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	doc, err := scanPatientDocument(s.db.QueryRowContext(ctx, query, patientID, documentID))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+func (s *PatientDocumentStore) Delete(ctx context.Context, patientID, documentID uuid.UUID) error {
+	query := `DELETE FROM patient_documents WHERE patient_id = $1 AND id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, patientID, documentID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
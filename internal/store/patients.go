@@ -0,0 +1,700 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmergencyContact struct {
+	Name         string `json:"name" validate:"required,max=200"`
+	Relationship string `json:"relationship" validate:"required,max=50"`
+	Phone        string `json:"phone" validate:"required,max=30"`
+}
+
+type InsurancePolicy struct {
+	ID           uuid.UUID `json:"id"`
+	PatientID    uuid.UUID `json:"patient_id"`
+	Payer        string    `json:"payer"`
+	PlanName     string    `json:"plan_name"`
+	PolicyNumber string    `json:"policy_number"`
+	MemberID     string    `json:"member_id"`
+	GroupNumber  string    `json:"group_number"`
+	ValidFrom    string    `json:"valid_from"`
+	ValidTo      string    `json:"valid_to"`
+	IsPrimary    bool      `json:"is_primary"`
+	CreatedAt    string    `json:"created_at"`
+}
+
+type IdentifierType string
+
+const (
+	IdentifierNationalID        IdentifierType = "national_id"
+	IdentifierPassport          IdentifierType = "passport"
+	IdentifierInsuranceMemberID IdentifierType = "insurance_member_id"
+	IdentifierDriverLicense     IdentifierType = "driver_license"
+	IdentifierOther             IdentifierType = "other"
+)
+
+// PatientIdentifier is an identifier issued to the patient outside the
+// hospital. Issuer is the issuing country or payer and may be empty.
+type PatientIdentifier struct {
+	ID        uuid.UUID      `json:"id"`
+	PatientID uuid.UUID      `json:"patient_id"`
+	Type      IdentifierType `json:"type"`
+	Value     string         `json:"value"`
+	Issuer    string         `json:"issuer"`
+	CreatedAt string         `json:"created_at"`
+}
+
+type Patient struct {
+	UserID            uuid.UUID           `json:"user_id"`
+	MRN               string              `json:"mrn"`
+	Username          string              `json:"username"`
+	Email             string              `json:"email"`
+	Dependent         bool                `json:"dependent"`       // No login of its own, managed by guardians
+	UpgradePending    bool                `json:"upgrade_pending"` // Invited to a login of their own, not activated yet
+	FirstName         string              `json:"firstname"`
+	LastName          string              `json:"lastname"`
+	DateOfBirth       string              `json:"date_of_birth"`
+	Age               int                 `json:"age"` // Computed from DateOfBirth
+	Sex               Gender              `json:"sex"`
+	Phone             string              `json:"phone"`
+	Address           string              `json:"address"`
+	Country           string              `json:"country"`
+	State             string              `json:"state"`
+	City              string              `json:"city"`
+	PostalCode        string              `json:"postal_code"`
+	BloodGroup        BloodGroup          `json:"blood_group"` // Empty when unknown
+	EmergencyContacts []EmergencyContact  `json:"emergency_contacts"`
+	Insurance         []InsurancePolicy   `json:"insurance,omitempty"`
+	Identifiers       []PatientIdentifier `json:"identifiers,omitempty"`
+	OverdueVaccines   []DoseStatus        `json:"overdue_vaccines,omitempty"` // Set when a single profile is fetched
+	CreatedAt         string              `json:"created_at"`
+	UpdatedAt         string              `json:"updated_at"`
+}
+
+func (p *Patient) setAge(now time.Time) {
+	dob, err := time.Parse(DateLayout, p.DateOfBirth)
+	if err != nil {
+		p.Age = 0
+		return
+	}
+
+	p.Age = AgeOn(dob, now)
+}
+
+type PatientSearchQuery struct {
+	Limit       int    `json:"limit" validate:"gte=1,lte=50"`
+	Offset      int    `json:"offset" validate:"gte=0"`
+	Search      string `json:"q" validate:"max=100"`
+	DateOfBirth string `json:"dob" validate:"omitempty,datetime=2006-01-02"`
+	Phone       string `json:"phone" validate:"max=30"`
+}
+
+func (q PatientSearchQuery) Parse(r *http.Request) (PatientSearchQuery, error) {
+	qs := r.URL.Query()
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return q, err
+		}
+
+		q.Limit = l
+	}
+
+	offset := qs.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return q, err
+		}
+
+		q.Offset = o
+	}
+
+	q.Search = strings.TrimSpace(qs.Get("q"))
+	q.DateOfBirth = qs.Get("dob")
+	q.Phone = strings.TrimSpace(qs.Get("phone"))
+
+	return q, nil
+}
+
+type PatientStore struct {
+	db *sql.DB
+}
+
+const patientColumns = `
+	p.user_id,
+	p.mrn,
+	u.username,
+	COALESCE(u.email, ''),
+	u.password IS NULL,
+	u.password IS NULL AND u.email IS NOT NULL,
+	p.firstname,
+	p.lastname,
+	to_char(p.date_of_birth, 'YYYY-MM-DD'),
+	p.sex,
+	p.phone,
+	COALESCE(p.address, ''),
+	COALESCE(p.country, ''),
+	COALESCE(p.state, ''),
+	COALESCE(p.city, ''),
+	COALESCE(p.postal_code, ''),
+	COALESCE(p.blood_group::text, ''),
+	p.emergency_contacts,
+	p.created_at,
+	p.updated_at
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPatient(row rowScanner) (*Patient, error) {
+	var contactsJSON []byte
+	patient := &Patient{}
+
+	err := row.Scan(
+		&patient.UserID,
+		&patient.MRN,
+		&patient.Username,
+		&patient.Email,
+		&patient.Dependent,
+		&patient.UpgradePending,
+		&patient.FirstName,
+		&patient.LastName,
+		&patient.DateOfBirth,
+		&patient.Sex,
+		&patient.Phone,
+		&patient.Address,
+		&patient.Country,
+		&patient.State,
+		&patient.City,
+		&patient.PostalCode,
+		&patient.BloodGroup,
+		&contactsJSON,
+		&patient.CreatedAt,
+		&patient.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(contactsJSON, &patient.EmergencyContacts); err != nil {
+		return nil, err
+	}
+
+	patient.setAge(time.Now())
+
+	return patient, nil
+}
+
+// mrnAttempts bounds how many sequence values Create tries when a formatted
+// MRN is already taken, which only happens after the MRN format changed.
+const mrnAttempts = 3
+
+// Create stores the patient profile and assigns it a new MRN. issueMRN turns
+// the next value of the MRN sequence into the identifier; every MRN handed
+// out is recorded in mrn_registry so it is never issued again.
+func (s *PatientStore) Create(ctx context.Context, patient *Patient, issueMRN func(seq int64) string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return createPatient(ctx, tx, patient, issueMRN)
+	})
+}
+
+func createPatient(ctx context.Context, tx *sql.Tx, patient *Patient, issueMRN func(seq int64) string) error {
+	contacts, err := json.Marshal(emergencyContactsOrEmpty(patient.EmergencyContacts))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	mrn, err := reserveMRN(ctx, tx, patient.UserID, issueMRN)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO patients (user_id, mrn, firstname, lastname, date_of_birth, sex, phone, address,
+			country, state, city, postal_code, blood_group, emergency_contacts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, '')::blood_group, $14)
+		RETURNING created_at, updated_at
+	`
+
+	err = tx.QueryRowContext(
+		ctx,
+		query,
+		patient.UserID,
+		mrn,
+		patient.FirstName,
+		patient.LastName,
+		patient.DateOfBirth,
+		patient.Sex,
+		patient.Phone,
+		patient.Address,
+		patient.Country,
+		patient.State,
+		patient.City,
+		patient.PostalCode,
+		patient.BloodGroup,
+		contacts,
+	).Scan(
+		&patient.CreatedAt,
+		&patient.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "patients_pkey"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	patient.MRN = mrn
+	patient.setAge(time.Now())
+
+	return nil
+}
+
+func reserveMRN(ctx context.Context, tx *sql.Tx, patientID uuid.UUID, issueMRN func(seq int64) string) (string, error) {
+	for i := 0; i < mrnAttempts; i++ {
+		var seq int64
+		if err := tx.QueryRowContext(ctx, `SELECT nextval('patient_mrn_seq')`).Scan(&seq); err != nil {
+			return "", err
+		}
+
+		mrn := issueMRN(seq)
+
+		var reserved string
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO mrn_registry (mrn, patient_id) VALUES ($1, $2)
+			ON CONFLICT (mrn) DO NOTHING
+			RETURNING mrn
+		`, mrn, patientID).Scan(&reserved)
+		switch err {
+		case nil:
+			return reserved, nil
+		case sql.ErrNoRows:
+			continue
+		default:
+			return "", err
+		}
+	}
+
+	return "", ErrConflict
+}
+
+func (s *PatientStore) Update(ctx context.Context, patient *Patient) error {
+	query := `
+		UPDATE patients
+		SET firstname = $2, lastname = $3, date_of_birth = $4, sex = $5, phone = $6, address = $7,
+			country = $8, state = $9, city = $10, postal_code = $11,
+			blood_group = NULLIF($12, '')::blood_group, emergency_contacts = $13, updated_at = NOW()
+		WHERE user_id = $1
+		RETURNING created_at, updated_at
+	`
+
+	contacts, err := json.Marshal(emergencyContactsOrEmpty(patient.EmergencyContacts))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err = s.db.QueryRowContext(
+		ctx,
+		query,
+		patient.UserID,
+		patient.FirstName,
+		patient.LastName,
+		patient.DateOfBirth,
+		patient.Sex,
+		patient.Phone,
+		patient.Address,
+		patient.Country,
+		patient.State,
+		patient.City,
+		patient.PostalCode,
+		patient.BloodGroup,
+		contacts,
+	).Scan(
+		&patient.CreatedAt,
+		&patient.UpdatedAt,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	patient.setAge(time.Now())
+
+	return nil
+}
+
+func (s *PatientStore) GetByID(ctx context.Context, id uuid.UUID) (*Patient, error) {
+	query := `
+		SELECT ` + patientColumns + `
+		FROM patients p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	patient, err := scanPatient(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	policies, err := s.GetInsurance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	patient.Insurance = policies
+
+	identifiers, err := s.GetIdentifiers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	patient.Identifiers = identifiers
+
+	return patient, nil
+}
+
+// GetIDByMRN resolves an MRN to the patient's ID.
+func (s *PatientStore) GetIDByMRN(ctx context.Context, mrn string) (uuid.UUID, error) {
+	query := `SELECT user_id FROM patients WHERE mrn = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var id uuid.UUID
+	err := s.db.QueryRowContext(ctx, query, mrn).Scan(&id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return uuid.Nil, ErrNotFound
+		default:
+			return uuid.Nil, err
+		}
+	}
+
+	return id, nil
+}
+
+// GetIDByIdentifier resolves an external identifier to the patient's ID.
+func (s *PatientStore) GetIDByIdentifier(ctx context.Context, idType IdentifierType, issuer, value string) (uuid.UUID, error) {
+	query := `
+		SELECT patient_id
+		FROM patient_identifiers
+		WHERE type = $1 AND issuer = $2 AND value = $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var id uuid.UUID
+	err := s.db.QueryRowContext(ctx, query, idType, issuer, value).Scan(&id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return uuid.Nil, ErrNotFound
+		default:
+			return uuid.Nil, err
+		}
+	}
+
+	return id, nil
+}
+
+// Search looks patients up by name, email, phone or exact MRN (q), date of birth and
+// exact phone number. Empty filters are ignored.
+func (s *PatientStore) Search(ctx context.Context, q PatientSearchQuery) ([]*Patient, error) {
+	query := `
+		SELECT ` + patientColumns + `
+		FROM patients p
+		JOIN users u ON u.id = p.user_id
+		WHERE ($1 = ''
+				OR (p.firstname || ' ' || p.lastname) ILIKE '%' || $1 || '%'
+				OR u.email ILIKE '%' || $1 || '%'
+				OR p.phone LIKE '%' || $1 || '%'
+				OR upper(p.mrn) = upper($1))
+			AND (NULLIF($2, '')::date IS NULL OR p.date_of_birth = NULLIF($2, '')::date)
+			AND ($3 = '' OR p.phone = $3)
+		ORDER BY p.lastname, p.firstname, p.user_id
+		LIMIT $4 OFFSET $5
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, q.Search, q.DateOfBirth, q.Phone, q.Limit, q.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	patients := []*Patient{}
+	for rows.Next() {
+		patient, err := scanPatient(rows)
+		if err != nil {
+			return nil, err
+		}
+		patients = append(patients, patient)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return patients, nil
+}
+
+func (s *PatientStore) GetInsurance(ctx context.Context, patientID uuid.UUID) ([]InsurancePolicy, error) {
+	query := `
+		SELECT id, patient_id, payer, COALESCE(plan_name, ''), policy_number, COALESCE(member_id, ''),
+			COALESCE(group_number, ''), COALESCE(to_char(valid_from, 'YYYY-MM-DD'), ''),
+			COALESCE(to_char(valid_to, 'YYYY-MM-DD'), ''), is_primary, created_at
+		FROM patient_insurance_policies
+		WHERE patient_id = $1
+		ORDER BY is_primary DESC, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []InsurancePolicy{}
+	for rows.Next() {
+		var policy InsurancePolicy
+		err := rows.Scan(
+			&policy.ID,
+			&policy.PatientID,
+			&policy.Payer,
+			&policy.PlanName,
+			&policy.PolicyNumber,
+			&policy.MemberID,
+			&policy.GroupNumber,
+			&policy.ValidFrom,
+			&policy.ValidTo,
+			&policy.IsPrimary,
+			&policy.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// AddInsurance stores a policy. Marking it primary demotes the patient's
+// previous primary policy in the same transaction.
+func (s *PatientStore) AddInsurance(ctx context.Context, policy *InsurancePolicy) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if policy.IsPrimary {
+			_, err := tx.ExecContext(ctx, `UPDATE patient_insurance_policies SET is_primary = false WHERE patient_id = $1`, policy.PatientID)
+			if err != nil {
+				return err
+			}
+		}
+
+		query := `
+			INSERT INTO patient_insurance_policies (patient_id, payer, plan_name, policy_number, member_id,
+				group_number, valid_from, valid_to, is_primary)
+			VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, '')::date, NULLIF($8, '')::date, $9)
+			RETURNING id, created_at
+		`
+
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			policy.PatientID,
+			policy.Payer,
+			policy.PlanName,
+			policy.PolicyNumber,
+			policy.MemberID,
+			policy.GroupNumber,
+			policy.ValidFrom,
+			policy.ValidTo,
+			policy.IsPrimary,
+		).Scan(
+			&policy.ID,
+			&policy.CreatedAt,
+		)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "patient_insurance_policy_number_key"):
+				return ErrConflict
+			default:
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *PatientStore) DeleteInsurance(ctx context.Context, patientID, policyID uuid.UUID) error {
+	query := `DELETE FROM patient_insurance_policies WHERE patient_id = $1 AND id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, patientID, policyID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *PatientStore) GetIdentifiers(ctx context.Context, patientID uuid.UUID) ([]PatientIdentifier, error) {
+	query := `
+		SELECT id, patient_id, type, value, issuer, created_at
+		FROM patient_identifiers
+		WHERE patient_id = $1
+		ORDER BY type, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, patientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identifiers := []PatientIdentifier{}
+	for rows.Next() {
+		var identifier PatientIdentifier
+		err := rows.Scan(
+			&identifier.ID,
+			&identifier.PatientID,
+			&identifier.Type,
+			&identifier.Value,
+			&identifier.Issuer,
+			&identifier.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		identifiers = append(identifiers, identifier)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return identifiers, nil
+}
+
+func (s *PatientStore) AddIdentifier(ctx context.Context, identifier *PatientIdentifier) error {
+	query := `
+		INSERT INTO patient_identifiers (patient_id, type, value, issuer)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		identifier.PatientID,
+		identifier.Type,
+		identifier.Value,
+		identifier.Issuer,
+	).Scan(
+		&identifier.ID,
+		&identifier.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "patient_identifiers_value_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *PatientStore) DeleteIdentifier(ctx context.Context, patientID, identifierID uuid.UUID) error {
+	query := `DELETE FROM patient_identifiers WHERE patient_id = $1 AND id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, patientID, identifierID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func emergencyContactsOrEmpty(contacts []EmergencyContact) []EmergencyContact {
+	if contacts == nil {
+		return []EmergencyContact{}
+	}
+
+	return contacts
+}
@@ -0,0 +1,562 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrInsufficientStock is returned when the unexpired stock of a drug does
+// not cover a dispensation. It is wrapped with the drug and the quantities.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// Drug is an entry of the pharmacy catalog. OnHand counts unexpired stock
+// only.
+type Drug struct {
+	ID           uuid.UUID   `json:"id"`
+	Name         string      `json:"name"`
+	Strength     string      `json:"strength"`
+	Form         string      `json:"form"`
+	Unit         string      `json:"unit"`
+	ReorderLevel int         `json:"reorder_level"`
+	Active       bool        `json:"active"`
+	OnHand       int         `json:"on_hand"`
+	CreatedAt    time.Time   `json:"created_at"`
+	Batches      []DrugBatch `json:"batches,omitempty"`
+}
+
+// DrugBatch is one lot of a drug as received from a supplier.
+type DrugBatch struct {
+	ID               uuid.UUID  `json:"id"`
+	DrugID           uuid.UUID  `json:"drug_id"`
+	LotNumber        string     `json:"lot_number"`
+	ExpiryDate       string     `json:"expiry_date"`
+	QuantityReceived int        `json:"quantity_received"`
+	QuantityOnHand   int        `json:"quantity_on_hand"`
+	Supplier         string     `json:"supplier"`
+	ReceivedBy       *uuid.UUID `json:"received_by"`
+	ReceivedAt       time.Time  `json:"received_at"`
+	Expired          bool       `json:"expired"`
+}
+
+// ExpiringBatch is a batch with stock left that expires within the warning
+// window, or has already expired.
+type ExpiringBatch struct {
+	DrugBatch
+	DrugName string `json:"drug_name"`
+	Strength string `json:"strength"`
+}
+
+type StockAlerts struct {
+	LowStock []Drug          `json:"low_stock"`
+	Expiring []ExpiringBatch `json:"expiring"`
+}
+
+type Dispensation struct {
+	ID             uuid.UUID          `json:"id"`
+	PrescriptionID uuid.UUID          `json:"prescription_id"`
+	PatientID      uuid.UUID          `json:"patient_id"`
+	DispensedBy    *uuid.UUID         `json:"dispensed_by"`
+	Notes          string             `json:"notes"`
+	DispensedAt    time.Time          `json:"dispensed_at"`
+	Lines          []DispensationLine `json:"lines"`
+	// LowStock lists the dispensed drugs left at or below their reorder
+	// level; only set on the response to a dispensation
+	LowStock []Drug `json:"low_stock,omitempty"`
+}
+
+// DispensationLine is a prescription line dispensed as a catalog drug.
+// Position is the line's position on the prescription.
+type DispensationLine struct {
+	Position int              `json:"position"`
+	DrugID   uuid.UUID        `json:"drug_id"`
+	DrugName string           `json:"drug_name"`
+	Quantity int              `json:"quantity"`
+	Batches  []DispensedBatch `json:"batches"`
+}
+
+type DispensedBatch struct {
+	BatchID    uuid.UUID `json:"batch_id"`
+	LotNumber  string    `json:"lot_number"`
+	ExpiryDate string    `json:"expiry_date"`
+	Quantity   int       `json:"quantity"`
+}
+
+type PharmacyStore struct {
+	db *sql.DB
+}
+
+const drugColumns = `
+	d.id, d.name, d.strength, d.form, d.unit, d.reorder_level, d.active, stock.on_hand, d.created_at`
+
+// drugsWithStock joins every drug d to its unexpired stock.
+const drugsWithStock = `
+	drugs d
+	CROSS JOIN LATERAL (
+		SELECT COALESCE(SUM(b.quantity_on_hand), 0) AS on_hand
+		FROM drug_batches b
+		WHERE b.drug_id = d.id AND b.expiry_date > CURRENT_DATE
+	) stock`
+
+func scanDrug(row rowScanner) (*Drug, error) {
+	d := &Drug{}
+	err := row.Scan(&d.ID, &d.Name, &d.Strength, &d.Form, &d.Unit, &d.ReorderLevel, &d.Active, &d.OnHand, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+const drugBatchColumns = `
+	b.id, b.drug_id, b.lot_number, to_char(b.expiry_date, 'YYYY-MM-DD'), b.quantity_received,
+	b.quantity_on_hand, b.supplier, b.received_by, b.received_at, b.expiry_date <= CURRENT_DATE`
+
+func scanDrugBatch(row rowScanner, b *DrugBatch, extra ...any) error {
+	dest := []any{
+		&b.ID,
+		&b.DrugID,
+		&b.LotNumber,
+		&b.ExpiryDate,
+		&b.QuantityReceived,
+		&b.QuantityOnHand,
+		&b.Supplier,
+		&b.ReceivedBy,
+		&b.ReceivedAt,
+		&b.Expired,
+	}
+	return row.Scan(append(dest, extra...)...)
+}
+
+func (s *PharmacyStore) CreateDrug(ctx context.Context, drug *Drug) error {
+	query := `
+		INSERT INTO drugs (name, strength, form, unit, reorder_level)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, active, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, drug.Name, drug.Strength, drug.Form, drug.Unit, drug.ReorderLevel).Scan(
+		&drug.ID,
+		&drug.Active,
+		&drug.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "drugs_name_strength_form_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetDrugs lists the catalog by name, optionally filtered by a name prefix.
+func (s *PharmacyStore) GetDrugs(ctx context.Context, search string) ([]Drug, error) {
+	query := `
+		SELECT ` + drugColumns + `
+		FROM ` + drugsWithStock + `
+		WHERE ($1 = '' OR d.name ILIKE $1 || '%')
+		ORDER BY d.name, d.strength, d.form
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, search)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drugs := []Drug{}
+	for rows.Next() {
+		drug, err := scanDrug(rows)
+		if err != nil {
+			return nil, err
+		}
+		drugs = append(drugs, *drug)
+	}
+
+	return drugs, rows.Err()
+}
+
+// GetDrug returns a drug with its batches in stock, in dispensing order.
+func (s *PharmacyStore) GetDrug(ctx context.Context, id uuid.UUID) (*Drug, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	drug, err := scanDrug(s.db.QueryRowContext(ctx, `SELECT `+drugColumns+` FROM `+drugsWithStock+` WHERE d.id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	query := `
+		SELECT ` + drugBatchColumns + `
+		FROM drug_batches b
+		WHERE b.drug_id = $1 AND b.quantity_on_hand > 0
+		ORDER BY b.expiry_date, b.received_at, b.id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drug.Batches = []DrugBatch{}
+	for rows.Next() {
+		var batch DrugBatch
+		if err := scanDrugBatch(rows, &batch); err != nil {
+			return nil, err
+		}
+		drug.Batches = append(drug.Batches, batch)
+	}
+
+	return drug, rows.Err()
+}
+
+// ReceiveBatch records a goods receipt. It returns ErrNotFound for an
+// unknown drug and ErrConflict if the lot was already received.
+func (s *PharmacyStore) ReceiveBatch(ctx context.Context, batch *DrugBatch) error {
+	query := `
+		INSERT INTO drug_batches (drug_id, lot_number, expiry_date, quantity_received, quantity_on_hand, supplier, received_by)
+		VALUES ($1, $2, $3, $4, $4, $5, $6)
+		RETURNING id, quantity_on_hand, received_at, expiry_date <= CURRENT_DATE
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		batch.DrugID,
+		batch.LotNumber,
+		batch.ExpiryDate,
+		batch.QuantityReceived,
+		batch.Supplier,
+		batch.ReceivedBy,
+	).Scan(&batch.ID, &batch.QuantityOnHand, &batch.ReceivedAt, &batch.Expired)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "drug_batches_lot_key"):
+			return ErrConflict
+		case strings.Contains(err.Error(), "drug_batches_drug_id_fkey"):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetAlerts returns the active drugs at or below their reorder level and
+// the batches with stock that expire within the given number of days.
+func (s *PharmacyStore) GetAlerts(ctx context.Context, expiringWithinDays int) (*StockAlerts, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	alerts := &StockAlerts{}
+
+	lowStock, err := s.getLowStock(ctx, s.db, nil)
+	if err != nil {
+		return nil, err
+	}
+	alerts.LowStock = lowStock
+
+	query := `
+		SELECT ` + drugBatchColumns + `, d.name, d.strength
+		FROM drug_batches b
+		JOIN drugs d ON d.id = b.drug_id
+		WHERE b.quantity_on_hand > 0 AND b.expiry_date <= CURRENT_DATE + $1::int
+		ORDER BY b.expiry_date, d.name
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, expiringWithinDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts.Expiring = []ExpiringBatch{}
+	for rows.Next() {
+		var batch ExpiringBatch
+		if err := scanDrugBatch(rows, &batch.DrugBatch, &batch.DrugName, &batch.Strength); err != nil {
+			return nil, err
+		}
+		alerts.Expiring = append(alerts.Expiring, batch)
+	}
+
+	return alerts, rows.Err()
+}
+
+// getLowStock returns the active drugs at or below their reorder level,
+// limited to drugIDs unless nil.
+func (s *PharmacyStore) getLowStock(ctx context.Context, q queryer, drugIDs []uuid.UUID) ([]Drug, error) {
+	var ids any
+	if drugIDs != nil {
+		ids = pq.Array(drugIDs)
+	}
+
+	query := `
+		SELECT ` + drugColumns + `
+		FROM ` + drugsWithStock + `
+		WHERE d.active AND stock.on_hand <= d.reorder_level
+			AND ($1::uuid[] IS NULL OR d.id = ANY($1))
+		ORDER BY d.name, d.strength, d.form
+	`
+
+	rows, err := q.QueryContext(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drugs := []Drug{}
+	for rows.Next() {
+		drug, err := scanDrug(rows)
+		if err != nil {
+			return nil, err
+		}
+		drugs = append(drugs, *drug)
+	}
+
+	return drugs, rows.Err()
+}
+
+// Dispense hands out the lines of a dispensation. Each line is taken from
+// the unexpired batches of its drug, earliest expiry first. The batches are
+// locked for the whole transaction, so concurrent dispensations of the same
+// drug queue up instead of both taking the last units.
+//
+// It returns ErrLocked if the prescription was cancelled, ErrConflict if a
+// line was already dispensed and ErrInsufficientStock if a drug runs short.
+func (s *PharmacyStore) Dispense(ctx context.Context, dispensation *Dispensation) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		// FOR SHARE keeps the prescription from being cancelled meanwhile
+		var status PrescriptionStatus
+		err := tx.QueryRowContext(ctx, `SELECT status FROM prescriptions WHERE id = $1 FOR SHARE`, dispensation.PrescriptionID).Scan(&status)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if status != PrescriptionIssued {
+			return ErrLocked
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO dispensations (prescription_id, patient_id, dispensed_by, notes)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, dispensed_at
+		`, dispensation.PrescriptionID, dispensation.PatientID, dispensation.DispensedBy, dispensation.Notes).Scan(
+			&dispensation.ID,
+			&dispensation.DispensedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		drugIDs := make([]uuid.UUID, 0, len(dispensation.Lines))
+		for _, line := range dispensation.Lines {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO dispensation_lines (dispensation_id, prescription_id, position, drug_id, quantity)
+				VALUES ($1, $2, $3, $4, $5)
+			`, dispensation.ID, dispensation.PrescriptionID, line.Position, line.DrugID, line.Quantity)
+			if err != nil {
+				switch {
+				case strings.Contains(err.Error(), "dispensation_lines_pkey"):
+					return ErrConflict
+				case strings.Contains(err.Error(), "dispensation_lines_drug_id_fkey"):
+					return ErrNotFound
+				default:
+					return err
+				}
+			}
+			drugIDs = append(drugIDs, line.DrugID)
+		}
+
+		batches, err := lockStock(ctx, tx, drugIDs)
+		if err != nil {
+			return err
+		}
+
+		for i := range dispensation.Lines {
+			line := &dispensation.Lines[i]
+			line.Batches = []DispensedBatch{}
+
+			needed := line.Quantity
+			for _, batch := range batches[line.DrugID] {
+				if needed == 0 {
+					break
+				}
+				if batch.QuantityOnHand == 0 {
+					continue
+				}
+
+				take := min(needed, batch.QuantityOnHand)
+				batch.QuantityOnHand -= take
+				needed -= take
+
+				line.Batches = append(line.Batches, DispensedBatch{
+					BatchID:    batch.ID,
+					LotNumber:  batch.LotNumber,
+					ExpiryDate: batch.ExpiryDate,
+					Quantity:   take,
+				})
+			}
+
+			if needed > 0 {
+				return fmt.Errorf("%w: %d of drug %s requested, %d available", ErrInsufficientStock, line.Quantity, line.DrugID, line.Quantity-needed)
+			}
+
+			for _, taken := range line.Batches {
+				_, err := tx.ExecContext(ctx, `UPDATE drug_batches SET quantity_on_hand = quantity_on_hand - $2 WHERE id = $1`, taken.BatchID, taken.Quantity)
+				if err != nil {
+					return err
+				}
+
+				_, err = tx.ExecContext(ctx, `
+					INSERT INTO dispensation_batches (prescription_id, position, batch_id, quantity)
+					VALUES ($1, $2, $3, $4)
+				`, dispensation.PrescriptionID, line.Position, taken.BatchID, taken.Quantity)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		dispensation.LowStock, err = s.getLowStock(ctx, tx, drugIDs)
+		return err
+	})
+}
+
+// lockStock locks the unexpired batches in stock of the given drugs and
+// returns them per drug in dispensing order. The rows are always locked in
+// the same order, so transactions dispensing overlapping drugs cannot
+// deadlock.
+func lockStock(ctx context.Context, tx *sql.Tx, drugIDs []uuid.UUID) (map[uuid.UUID][]*DrugBatch, error) {
+	query := `
+		SELECT ` + drugBatchColumns + `
+		FROM drug_batches b
+		WHERE b.drug_id = ANY($1) AND b.quantity_on_hand > 0 AND b.expiry_date > CURRENT_DATE
+		ORDER BY b.drug_id, b.expiry_date, b.received_at, b.id
+		FOR UPDATE
+	`
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(drugIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := map[uuid.UUID][]*DrugBatch{}
+	for rows.Next() {
+		batch := &DrugBatch{}
+		if err := scanDrugBatch(rows, batch); err != nil {
+			return nil, err
+		}
+		batches[batch.DrugID] = append(batches[batch.DrugID], batch)
+	}
+
+	return batches, rows.Err()
+}
+
+// GetDispensations returns the dispensations of a prescription, oldest
+// first.
+func (s *PharmacyStore) GetDispensations(ctx context.Context, prescriptionID uuid.UUID) ([]*Dispensation, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, prescription_id, patient_id, dispensed_by, notes, dispensed_at
+		FROM dispensations
+		WHERE prescription_id = $1
+		ORDER BY dispensed_at, id
+	`, prescriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dispensations := []*Dispensation{}
+	byID := map[uuid.UUID]*Dispensation{}
+	for rows.Next() {
+		d := &Dispensation{Lines: []DispensationLine{}}
+		if err := rows.Scan(&d.ID, &d.PrescriptionID, &d.PatientID, &d.DispensedBy, &d.Notes, &d.DispensedAt); err != nil {
+			return nil, err
+		}
+		dispensations = append(dispensations, d)
+		byID[d.ID] = d
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lines, err := s.db.QueryContext(ctx, `
+		SELECT l.dispensation_id, l.position, l.drug_id, d.name || ' ' || d.strength, l.quantity,
+			b.id, b.lot_number, to_char(b.expiry_date, 'YYYY-MM-DD'), db.quantity
+		FROM dispensation_lines l
+		JOIN drugs d ON d.id = l.drug_id
+		JOIN dispensation_batches db ON db.prescription_id = l.prescription_id AND db.position = l.position
+		JOIN drug_batches b ON b.id = db.batch_id
+		WHERE l.prescription_id = $1
+		ORDER BY l.position, b.expiry_date, b.received_at, b.id
+	`, prescriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var dispensationID uuid.UUID
+		var line DispensationLine
+		var batch DispensedBatch
+		err := lines.Scan(
+			&dispensationID,
+			&line.Position,
+			&line.DrugID,
+			&line.DrugName,
+			&line.Quantity,
+			&batch.BatchID,
+			&batch.LotNumber,
+			&batch.ExpiryDate,
+			&batch.Quantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		d := byID[dispensationID]
+		if n := len(d.Lines); n > 0 && d.Lines[n-1].Position == line.Position {
+			d.Lines[n-1].Batches = append(d.Lines[n-1].Batches, batch)
+			continue
+		}
+		line.Batches = []DispensedBatch{batch}
+		d.Lines = append(d.Lines, line)
+	}
+
+	return dispensations, lines.Err()
+}
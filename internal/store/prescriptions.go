@@ -0,0 +1,442 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PrescriptionStatus string
+
+const (
+	PrescriptionIssued    PrescriptionStatus = "issued"
+	PrescriptionCancelled PrescriptionStatus = "cancelled"
+)
+
+type PrescriptionItem struct {
+	Drug         string `json:"drug"`
+	Strength     string `json:"strength"`
+	Dose         string `json:"dose"`
+	Frequency    string `json:"frequency"`
+	Duration     string `json:"duration"`
+	Quantity     string `json:"quantity"`
+	Instructions string `json:"instructions"`
+}
+
+// Prescription is immutable once issued. The database only lets it move
+// from issued to cancelled, and the signature covers everything but the
+// cancellation.
+type Prescription struct {
+	ID                  uuid.UUID              `json:"id"`
+	EncounterID         uuid.UUID              `json:"encounter_id"`
+	PatientID           uuid.UUID              `json:"patient_id"`
+	DoctorID            uuid.UUID              `json:"doctor_id"`
+	DoctorLicenseNumber string                 `json:"doctor_license_number"`
+	Status              PrescriptionStatus     `json:"status"`
+	IssuedAt            time.Time              `json:"issued_at"`
+	Signature           string                 `json:"signature"`
+	PDFKey              string                 `json:"-"`
+	CancelledAt         *time.Time             `json:"cancelled_at"`
+	CancelledBy         *uuid.UUID             `json:"cancelled_by"`
+	CancellationReason  *string                `json:"cancellation_reason"`
+	Items               []PrescriptionItem     `json:"items"`
+	Overrides           []PrescriptionOverride `json:"overrides"`
+}
+
+// PrescriptionOverride records a safety warning that the prescriber
+// acknowledged and overrode when issuing the prescription.
+type PrescriptionOverride struct {
+	Code         string    `json:"code"`
+	Kind         string    `json:"kind"`
+	Severity     string    `json:"severity"`
+	Drug         string    `json:"drug"`
+	With         string    `json:"with"`
+	Message      string    `json:"message"`
+	Reason       string    `json:"reason"`
+	OverriddenBy uuid.UUID `json:"overridden_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SigningPayload is the canonical encoding of the signed part of the
+// prescription.
+func (p *Prescription) SigningPayload() ([]byte, error) {
+	return json.Marshal(struct {
+		ID                  uuid.UUID          `json:"id"`
+		EncounterID         uuid.UUID          `json:"encounter_id"`
+		PatientID           uuid.UUID          `json:"patient_id"`
+		DoctorID            uuid.UUID          `json:"doctor_id"`
+		DoctorLicenseNumber string             `json:"doctor_license_number"`
+		IssuedAt            string             `json:"issued_at"`
+		Items               []PrescriptionItem `json:"items"`
+	}{
+		ID:                  p.ID,
+		EncounterID:         p.EncounterID,
+		PatientID:           p.PatientID,
+		DoctorID:            p.DoctorID,
+		DoctorLicenseNumber: p.DoctorLicenseNumber,
+		IssuedAt:            p.IssuedAt.UTC().Format(time.RFC3339),
+		Items:               p.Items,
+	})
+}
+
+type PrescriptionStore struct {
+	db *sql.DB
+}
+
+const prescriptionColumns = `
+	id, encounter_id, patient_id, doctor_id, doctor_license_number, status, issued_at,
+	signature, pdf_key, cancelled_at, cancelled_by, cancellation_reason
+`
+
+func scanPrescription(row rowScanner) (*Prescription, error) {
+	p := &Prescription{}
+	err := row.Scan(
+		&p.ID,
+		&p.EncounterID,
+		&p.PatientID,
+		&p.DoctorID,
+		&p.DoctorLicenseNumber,
+		&p.Status,
+		&p.IssuedAt,
+		&p.Signature,
+		&p.PDFKey,
+		&p.CancelledAt,
+		&p.CancelledBy,
+		&p.CancellationReason,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Create stores an issued prescription with its items and overridden
+// warnings. ID, IssuedAt, Signature and PDFKey are set by the caller, since
+// they go into the signed PDF before the row is written.
+func (s *PrescriptionStore) Create(ctx context.Context, p *Prescription) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		query := `
+			INSERT INTO prescriptions (id, encounter_id, patient_id, doctor_id, doctor_license_number,
+				issued_at, signature, pdf_key)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING status
+		`
+
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			p.ID,
+			p.EncounterID,
+			p.PatientID,
+			p.DoctorID,
+			p.DoctorLicenseNumber,
+			p.IssuedAt,
+			p.Signature,
+			p.PDFKey,
+		).Scan(&p.Status)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "prescriptions_pkey"):
+				return ErrConflict
+			default:
+				return err
+			}
+		}
+
+		itemQuery := `
+			INSERT INTO prescription_items (prescription_id, position, drug, strength, dose, frequency,
+				duration, quantity, instructions)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`
+
+		for i, item := range p.Items {
+			_, err := tx.ExecContext(
+				ctx,
+				itemQuery,
+				p.ID,
+				i,
+				item.Drug,
+				item.Strength,
+				item.Dose,
+				item.Frequency,
+				item.Duration,
+				item.Quantity,
+				item.Instructions,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		overrideQuery := `
+			INSERT INTO prescription_overrides (prescription_id, code, kind, severity, drug, interacts_with,
+				message, reason, overridden_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING created_at
+		`
+
+		for i := range p.Overrides {
+			o := &p.Overrides[i]
+			err := tx.QueryRowContext(
+				ctx,
+				overrideQuery,
+				p.ID,
+				o.Code,
+				o.Kind,
+				o.Severity,
+				o.Drug,
+				o.With,
+				o.Message,
+				o.Reason,
+				o.OverriddenBy,
+			).Scan(&o.CreatedAt)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *PrescriptionStore) GetByID(ctx context.Context, id uuid.UUID) (*Prescription, error) {
+	query := `SELECT ` + prescriptionColumns + ` FROM prescriptions WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	p, err := scanPrescription(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if err := s.loadItems(ctx, []*Prescription{p}); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadOverrides(ctx, []*Prescription{p}); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// GetByEncounter returns the prescriptions of an encounter, oldest first.
+func (s *PrescriptionStore) GetByEncounter(ctx context.Context, encounterID uuid.UUID) ([]*Prescription, error) {
+	query := `
+		SELECT ` + prescriptionColumns + `
+		FROM prescriptions
+		WHERE encounter_id = $1
+		ORDER BY issued_at, id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prescriptions := []*Prescription{}
+	for rows.Next() {
+		p, err := scanPrescription(rows)
+		if err != nil {
+			return nil, err
+		}
+		prescriptions = append(prescriptions, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadItems(ctx, prescriptions); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadOverrides(ctx, prescriptions); err != nil {
+		return nil, err
+	}
+
+	return prescriptions, nil
+}
+
+func (s *PrescriptionStore) loadItems(ctx context.Context, prescriptions []*Prescription) error {
+	if len(prescriptions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(prescriptions))
+	byID := make(map[uuid.UUID]*Prescription, len(prescriptions))
+	for _, p := range prescriptions {
+		ids = append(ids, p.ID.String())
+		byID[p.ID] = p
+		p.Items = []PrescriptionItem{}
+	}
+
+	query := `
+		SELECT prescription_id, drug, strength, dose, frequency, duration, quantity, instructions
+		FROM prescription_items
+		WHERE prescription_id = ANY($1::uuid[])
+		ORDER BY prescription_id, position
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var prescriptionID uuid.UUID
+		var item PrescriptionItem
+		err := rows.Scan(
+			&prescriptionID,
+			&item.Drug,
+			&item.Strength,
+			&item.Dose,
+			&item.Frequency,
+			&item.Duration,
+			&item.Quantity,
+			&item.Instructions,
+		)
+		if err != nil {
+			return err
+		}
+		p := byID[prescriptionID]
+		p.Items = append(p.Items, item)
+	}
+
+	return rows.Err()
+}
+
+func (s *PrescriptionStore) loadOverrides(ctx context.Context, prescriptions []*Prescription) error {
+	if len(prescriptions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(prescriptions))
+	byID := make(map[uuid.UUID]*Prescription, len(prescriptions))
+	for _, p := range prescriptions {
+		ids = append(ids, p.ID.String())
+		byID[p.ID] = p
+		p.Overrides = []PrescriptionOverride{}
+	}
+
+	query := `
+		SELECT prescription_id, code, kind, severity, drug, interacts_with, message, reason,
+			overridden_by, created_at
+		FROM prescription_overrides
+		WHERE prescription_id = ANY($1::uuid[])
+		ORDER BY prescription_id, code
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var prescriptionID uuid.UUID
+		var o PrescriptionOverride
+		err := rows.Scan(
+			&prescriptionID,
+			&o.Code,
+			&o.Kind,
+			&o.Severity,
+			&o.Drug,
+			&o.With,
+			&o.Message,
+			&o.Reason,
+			&o.OverriddenBy,
+			&o.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		p := byID[prescriptionID]
+		p.Overrides = append(p.Overrides, o)
+	}
+
+	return rows.Err()
+}
+
+// GetCurrentDrugs returns the drugs on the patient's prescriptions issued
+// since the given time and not cancelled.
+func (s *PrescriptionStore) GetCurrentDrugs(ctx context.Context, patientID uuid.UUID, since time.Time) ([]string, error) {
+	query := `
+		SELECT DISTINCT i.drug
+		FROM prescription_items i
+		JOIN prescriptions p ON p.id = i.prescription_id
+		WHERE p.patient_id = $1 AND p.status = 'issued' AND p.issued_at >= $2
+		ORDER BY i.drug
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, patientID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drugs := []string{}
+	for rows.Next() {
+		var drug string
+		if err := rows.Scan(&drug); err != nil {
+			return nil, err
+		}
+		drugs = append(drugs, drug)
+	}
+
+	return drugs, rows.Err()
+}
+
+// Cancel cancels an issued prescription. It returns ErrLocked when the
+// prescription has already been cancelled.
+func (s *PrescriptionStore) Cancel(ctx context.Context, p *Prescription, cancelledBy uuid.UUID, reason string) error {
+	query := `
+		UPDATE prescriptions
+		SET status = 'cancelled', cancelled_at = NOW(), cancelled_by = $2, cancellation_reason = $3
+		WHERE id = $1 AND status = 'issued'
+		RETURNING status, cancelled_at, cancelled_by, cancellation_reason
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, p.ID, cancelledBy, reason).Scan(
+		&p.Status,
+		&p.CancelledAt,
+		&p.CancelledBy,
+		&p.CancellationReason,
+	)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrLocked
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
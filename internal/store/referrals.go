@@ -0,0 +1,437 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReferralStatus string
+
+const (
+	ReferralSent      ReferralStatus = "sent"
+	ReferralAccepted  ReferralStatus = "accepted"
+	ReferralDeclined  ReferralStatus = "declined"
+	ReferralScheduled ReferralStatus = "scheduled"
+	ReferralClosed    ReferralStatus = "closed"
+)
+
+type ReferralUrgency string
+
+const (
+	ReferralRoutine   ReferralUrgency = "routine"
+	ReferralUrgent    ReferralUrgency = "urgent"
+	ReferralEmergency ReferralUrgency = "emergency"
+)
+
+// Referral sends a patient from one doctor to another doctor or to a
+// department. A department referral has no ToDoctorID until one of the
+// department's doctors accepts or books it.
+type Referral struct {
+	ID                  uuid.UUID       `json:"id"`
+	PatientID           uuid.UUID       `json:"patient_id"`
+	ReferringDoctorID   uuid.UUID       `json:"referring_doctor_id"`
+	ReferringDoctorName string          `json:"referring_doctor_name"`
+	EncounterID         *uuid.UUID      `json:"encounter_id"`
+	ToDoctorID          *uuid.UUID      `json:"to_doctor_id"`
+	ToDoctorName        string          `json:"to_doctor_name"`
+	Department          string          `json:"department"`
+	Reason              string          `json:"reason"`
+	Urgency             ReferralUrgency `json:"urgency"`
+	Notes               string          `json:"notes"`
+	Status              ReferralStatus  `json:"status"`
+	ResponseNote        string          `json:"response_note"`
+	RespondedAt         *time.Time      `json:"responded_at"`
+	AppointmentID       *uuid.UUID      `json:"appointment_id"`
+	AppointmentTime     *time.Time      `json:"appointment_time"`
+	ClosedBy            *uuid.UUID      `json:"closed_by"`
+	ClosedAt            *time.Time      `json:"closed_at"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+}
+
+// ReferralRecipient is someone emailed about a referral.
+type ReferralRecipient struct {
+	ID       uuid.UUID
+	Username string
+	Email    string
+}
+
+// ReferralQuery filters referral lists. ReceivingDoctorID matches referrals
+// to that doctor and, with ReceivingDepartment, the unclaimed referrals to
+// their department.
+type ReferralQuery struct {
+	PatientID           *uuid.UUID      `json:"-"`
+	ReferringDoctorID   *uuid.UUID      `json:"-"`
+	ReceivingDoctorID   *uuid.UUID      `json:"-"`
+	ReceivingDepartment string          `json:"-"`
+	Direction           string          `json:"direction" validate:"omitempty,oneof=incoming outgoing"`
+	Department          string          `json:"department" validate:"max=100"`
+	Status              ReferralStatus  `json:"status" validate:"omitempty,oneof=sent accepted declined scheduled closed"`
+	Urgency             ReferralUrgency `json:"urgency" validate:"omitempty,oneof=routine urgent emergency"`
+	Limit               int             `json:"limit" validate:"gte=1,lte=100"`
+	Offset              int             `json:"offset" validate:"gte=0"`
+}
+
+func (q ReferralQuery) Parse(r *http.Request) (ReferralQuery, error) {
+	qs := r.URL.Query()
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return q, err
+		}
+
+		q.Limit = l
+	}
+
+	offset := qs.Get("offset")
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil {
+			return q, err
+		}
+
+		q.Offset = o
+	}
+
+	if direction := qs.Get("direction"); direction != "" {
+		q.Direction = direction
+	}
+
+	if status := qs.Get("status"); status != "" {
+		q.Status = ReferralStatus(status)
+	}
+
+	if urgency := qs.Get("urgency"); urgency != "" {
+		q.Urgency = ReferralUrgency(urgency)
+	}
+
+	q.Department = strings.TrimSpace(qs.Get("department"))
+
+	return q, nil
+}
+
+type ReferralStore struct {
+	db *sql.DB
+}
+
+const referralQuery = `
+	SELECT r.id, r.patient_id, r.referring_doctor_id, TRIM(COALESCE(fd.firstname, '') || ' ' || COALESCE(fd.lastname, '')),
+		r.encounter_id, r.to_doctor_id, TRIM(COALESCE(td.firstname, '') || ' ' || COALESCE(td.lastname, '')),
+		r.department, r.reason, r.urgency, r.notes, r.status, r.response_note, r.responded_at,
+		r.appointment_id, a.appointment_time, r.closed_by, r.closed_at, r.created_at, r.updated_at
+	FROM referrals r
+	JOIN doctors fd ON fd.user_id = r.referring_doctor_id
+	LEFT JOIN doctors td ON td.user_id = r.to_doctor_id
+	LEFT JOIN appointment a ON a.id = r.appointment_id`
+
+func scanReferral(row rowScanner) (*Referral, error) {
+	r := &Referral{}
+
+	err := row.Scan(
+		&r.ID,
+		&r.PatientID,
+		&r.ReferringDoctorID,
+		&r.ReferringDoctorName,
+		&r.EncounterID,
+		&r.ToDoctorID,
+		&r.ToDoctorName,
+		&r.Department,
+		&r.Reason,
+		&r.Urgency,
+		&r.Notes,
+		&r.Status,
+		&r.ResponseNote,
+		&r.RespondedAt,
+		&r.AppointmentID,
+		&r.AppointmentTime,
+		&r.ClosedBy,
+		&r.ClosedAt,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Create sends a referral. A referral to a doctor is filed under their
+// specialization. A department is matched case-insensitively against the
+// doctors' specializations and stored as they spell it. It returns
+// ErrNotFound for an unknown doctor or a department no doctor practises.
+func (s *ReferralStore) Create(ctx context.Context, referral *Referral) error {
+	query := `
+		INSERT INTO referrals (patient_id, referring_doctor_id, encounter_id, to_doctor_id, department, reason, urgency, notes)
+		SELECT $1, $2, $3, $4, COALESCE(d.specialization, ''), $6, $7, $8
+		FROM (SELECT 1) AS referral
+		LEFT JOIN LATERAL (
+			SELECT specialization FROM doctors
+			WHERE CASE WHEN $4::uuid IS NULL THEN lower(specialization) = lower($5) ELSE user_id = $4 END
+			ORDER BY specialization
+			LIMIT 1
+		) d ON true
+		WHERE $4::uuid IS NOT NULL OR d.specialization IS NOT NULL
+		RETURNING id, department
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		referral.PatientID,
+		referral.ReferringDoctorID,
+		referral.EncounterID,
+		referral.ToDoctorID,
+		referral.Department,
+		referral.Reason,
+		referral.Urgency,
+		referral.Notes,
+	).Scan(&referral.ID, &referral.Department)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			return ErrNotFound
+		case strings.Contains(err.Error(), "referrals_to_doctor_id_fkey"):
+			return ErrNotFound
+		case strings.Contains(err.Error(), "referrals_self_check"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return s.reload(ctx, referral)
+}
+
+func (s *ReferralStore) reload(ctx context.Context, referral *Referral) error {
+	fresh, err := s.GetByID(ctx, referral.ID)
+	if err != nil {
+		return err
+	}
+
+	*referral = *fresh
+	return nil
+}
+
+func (s *ReferralStore) GetByID(ctx context.Context, id uuid.UUID) (*Referral, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	referral, err := scanReferral(s.db.QueryRowContext(ctx, referralQuery+` WHERE r.id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return referral, nil
+}
+
+// List returns referrals, the most urgent first and then the oldest.
+func (s *ReferralStore) List(ctx context.Context, q ReferralQuery) ([]*Referral, error) {
+	query := referralQuery + `
+		WHERE ($1::uuid IS NULL OR r.patient_id = $1)
+			AND ($2::uuid IS NULL OR r.referring_doctor_id = $2)
+			AND ($3::uuid IS NULL OR r.to_doctor_id = $3
+				OR (r.to_doctor_id IS NULL AND $4 <> '' AND lower(r.department) = lower($4)))
+			AND ($5 = '' OR lower(r.department) = lower($5))
+			AND ($6 = '' OR r.status::text = $6)
+			AND ($7 = '' OR r.urgency::text = $7)
+		ORDER BY r.urgency DESC, r.created_at, r.id
+		LIMIT $8 OFFSET $9
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query,
+		q.PatientID,
+		q.ReferringDoctorID,
+		q.ReceivingDoctorID,
+		q.ReceivingDepartment,
+		q.Department,
+		q.Status,
+		q.Urgency,
+		q.Limit,
+		q.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	referrals := []*Referral{}
+	for rows.Next() {
+		referral, err := scanReferral(rows)
+		if err != nil {
+			return nil, err
+		}
+		referrals = append(referrals, referral)
+	}
+
+	return referrals, rows.Err()
+}
+
+// Accept records that the doctor takes the referral on. It returns
+// ErrLocked unless the referral is newly sent to them or their department.
+func (s *ReferralStore) Accept(ctx context.Context, referral *Referral, doctorID uuid.UUID) error {
+	query := `
+		UPDATE referrals
+		SET status = 'accepted', to_doctor_id = $2, responded_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'sent' AND (to_doctor_id IS NULL OR to_doctor_id = $2)
+	`
+
+	return s.transition(ctx, referral, query, referral.ID, doctorID)
+}
+
+// Decline turns the referral down with a reason. It returns ErrLocked once
+// the referral was booked, declined or closed.
+func (s *ReferralStore) Decline(ctx context.Context, referral *Referral, doctorID uuid.UUID, reason string) error {
+	query := `
+		UPDATE referrals
+		SET status = 'declined', to_doctor_id = $2, response_note = $3, responded_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status IN ('sent', 'accepted') AND (to_doctor_id IS NULL OR to_doctor_id = $2)
+	`
+
+	return s.transition(ctx, referral, query, referral.ID, doctorID, reason)
+}
+
+// Close ends the referral. It returns ErrLocked if it was declined or
+// already closed.
+func (s *ReferralStore) Close(ctx context.Context, referral *Referral, closedBy uuid.UUID) error {
+	query := `
+		UPDATE referrals
+		SET status = 'closed', closed_by = $2, closed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status IN ('sent', 'accepted', 'scheduled')
+	`
+
+	return s.transition(ctx, referral, query, referral.ID, closedBy)
+}
+
+func (s *ReferralStore) transition(ctx context.Context, referral *Referral, query string, args ...any) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrLocked
+	}
+
+	return s.reload(ctx, referral)
+}
+
+// BookAppointment books the appointment with the receiving doctor and links
+// it to the referral, which becomes scheduled. A referral whose appointment
+// was cancelled can be booked again. It returns ErrConflict while the
+// booked appointment is still scheduled and ErrLocked once the referral was
+// declined or closed, or is held by another doctor.
+func (s *ReferralStore) BookAppointment(ctx context.Context, referral *Referral, appointment *Appointment) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		var (
+			status     ReferralStatus
+			toDoctorID *uuid.UUID
+			booked     bool
+		)
+		err := tx.QueryRowContext(ctx, `
+			SELECT r.status, r.to_doctor_id,
+				EXISTS (SELECT 1 FROM appointment a WHERE a.id = r.appointment_id AND a.status = 'scheduled')
+			FROM referrals r
+			WHERE r.id = $1
+			FOR UPDATE OF r
+		`, referral.ID).Scan(&status, &toDoctorID, &booked)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		switch {
+		case booked:
+			return ErrConflict
+		case status == ReferralDeclined || status == ReferralClosed:
+			return ErrLocked
+		case toDoctorID != nil && *toDoctorID != appointment.DoctorID:
+			return ErrLocked
+		}
+
+		if err := createAppointment(ctx, tx, appointment); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE referrals
+			SET status = 'scheduled', to_doctor_id = $2, appointment_id = $3,
+				responded_at = COALESCE(responded_at, NOW()), updated_at = NOW()
+			WHERE id = $1
+		`, referral.ID, appointment.DoctorID, appointment.ID)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.reload(ctx, referral)
+}
+
+// GetRecipients returns who is told about changes to the referral: the
+// referring doctor and the receiving doctor, or every doctor of the
+// department while nobody has taken it on.
+func (s *ReferralStore) GetRecipients(ctx context.Context, referral *Referral) ([]ReferralRecipient, error) {
+	query := `
+		SELECT u.id, u.username, u.email
+		FROM users u
+		JOIN doctors d ON d.user_id = u.id
+		WHERE u.is_active
+			AND (u.id = $1 OR u.id = $2
+				OR ($2::uuid IS NULL AND lower(d.specialization) = lower($3)))
+		ORDER BY u.username
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, referral.ReferringDoctorID, referral.ToDoctorID, referral.Department)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipients := []ReferralRecipient{}
+	for rows.Next() {
+		var r ReferralRecipient
+		if err := rows.Scan(&r.ID, &r.Username, &r.Email); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+
+	return recipients, rows.Err()
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DiagnosisCount is how many signed encounters of a department carried a
+// diagnosis. Departments are the specializations of the treating doctors.
+type DiagnosisCount struct {
+	Department  string `json:"department"`
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Encounters  int64  `json:"encounters"`
+}
+
+type ReportStore struct {
+	db *sql.DB
+}
+
+// TopDiagnoses returns the most frequent diagnoses per department among
+// encounters signed in [from, to), at most limit per department. With
+// primaryOnly set, secondary diagnoses are not counted.
+func (s *ReportStore) TopDiagnoses(ctx context.Context, from, to time.Time, limit int, primaryOnly bool) ([]DiagnosisCount, error) {
+	query := `
+		WITH counts AS (
+			SELECT COALESCE(NULLIF(trim(d.specialization), ''), 'Unassigned') AS department,
+				ed.code, COUNT(*) AS encounters
+			FROM encounter_diagnoses ed
+			JOIN encounters e ON e.id = ed.encounter_id
+			JOIN doctors d ON d.user_id = e.doctor_id
+			WHERE e.status = 'signed'
+				AND e.signed_at >= $1 AND e.signed_at < $2
+				AND (NOT $4 OR ed.rank = 'primary')
+			GROUP BY 1, ed.code
+		), ranked AS (
+			SELECT *, row_number() OVER (PARTITION BY department ORDER BY encounters DESC, code) AS position
+			FROM counts
+		)
+		SELECT r.department, r.code, c.description, r.encounters
+		FROM ranked r
+		JOIN icd10_codes c ON c.code = r.code
+		WHERE r.position <= $3
+		ORDER BY r.department, r.position
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, from, to, limit, primaryOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []DiagnosisCount{}
+	for rows.Next() {
+		var count DiagnosisCount
+		err := rows.Scan(
+			&count.Department,
+			&count.Code,
+			&count.Description,
+			&count.Encounters,
+		)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -18,7 +18,7 @@ type RoleStore struct {
 
 func (s *RoleStore) GetByName(ctx context.Context, slug string) (*Role, error) {
 	query := `SELECT id, name, description, level FROM roles WHERE name = $1`
-	
+
 	role := &Role{}
 	err := s.db.QueryRowContext(ctx, query, slug).Scan(&role.ID, &role.Name, &role.Description, &role.Level)
 	if err != nil {
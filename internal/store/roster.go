@@ -0,0 +1,657 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ShiftTemplate is a recurring shift. StartsAt and EndsAt are UTC times of
+// day, HH:MM; a shift ending at or before its start runs overnight. Role,
+// when set, is the only role that may work it.
+type ShiftTemplate struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Role       *string   `json:"role"`
+	Department string    `json:"department"`
+	StartsAt   string    `json:"starts_at"`
+	EndsAt     string    `json:"ends_at"`
+	OnCall     bool      `json:"on_call"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RosterShift is a shift someone is rostered to work.
+type RosterShift struct {
+	ID           uuid.UUID  `json:"id"`
+	TemplateID   uuid.UUID  `json:"template_id"`
+	TemplateName string     `json:"template_name"`
+	Department   string     `json:"department"`
+	OnCall       bool       `json:"on_call"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Username     string     `json:"username"`
+	Email        string     `json:"email"`
+	Role         string     `json:"role"`
+	ShiftDate    string     `json:"shift_date"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       time.Time  `json:"ends_at"`
+	CreatedBy    *uuid.UUID `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type ShiftSwapStatus string
+
+const (
+	ShiftSwapPending   ShiftSwapStatus = "pending"
+	ShiftSwapAccepted  ShiftSwapStatus = "accepted"
+	ShiftSwapApproved  ShiftSwapStatus = "approved"
+	ShiftSwapDeclined  ShiftSwapStatus = "declined"
+	ShiftSwapRejected  ShiftSwapStatus = "rejected"
+	ShiftSwapCancelled ShiftSwapStatus = "cancelled"
+)
+
+// ShiftSwap asks a colleague to take over a shift, in exchange for one of
+// theirs when CounterpartShiftID is set. The colleague accepts it and a
+// manager approves it, which reassigns the shifts.
+type ShiftSwap struct {
+	ID                 uuid.UUID       `json:"id"`
+	ShiftID            uuid.UUID       `json:"shift_id"`
+	RequestedBy        uuid.UUID       `json:"requested_by"`
+	WithUserID         uuid.UUID       `json:"with_user_id"`
+	CounterpartShiftID *uuid.UUID      `json:"counterpart_shift_id"`
+	Reason             string          `json:"reason"`
+	Status             ShiftSwapStatus `json:"status"`
+	RespondedAt        *time.Time      `json:"responded_at"`
+	DecidedBy          *uuid.UUID      `json:"decided_by"`
+	DecidedAt          *time.Time      `json:"decided_at"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+// RosterQuery selects the shifts overlapping From to To.
+type RosterQuery struct {
+	From       time.Time
+	To         time.Time
+	UserIDs    []uuid.UUID
+	Department string
+}
+
+type RosterStore struct {
+	db *sql.DB
+}
+
+const shiftTemplateQuery = `
+	SELECT id, name, role, department, to_char(starts_at, 'HH24:MI'), to_char(ends_at, 'HH24:MI'), on_call, created_at
+	FROM shift_templates`
+
+func scanShiftTemplate(row rowScanner) (*ShiftTemplate, error) {
+	t := &ShiftTemplate{}
+
+	err := row.Scan(&t.ID, &t.Name, &t.Role, &t.Department, &t.StartsAt, &t.EndsAt, &t.OnCall, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// CreateTemplate adds a shift template. It returns ErrConflict for a name
+// in use and ErrNotFound for an unknown role.
+func (s *RosterStore) CreateTemplate(ctx context.Context, t *ShiftTemplate) error {
+	query := `
+		INSERT INTO shift_templates (name, role, department, starts_at, ends_at, on_call)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, t.Name, t.Role, t.Department, t.StartsAt, t.EndsAt, t.OnCall).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "shift_templates_name_key"):
+			return ErrConflict
+		case strings.Contains(err.Error(), "shift_templates_role_fkey"):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *RosterStore) GetTemplates(ctx context.Context) ([]ShiftTemplate, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, shiftTemplateQuery+` ORDER BY starts_at, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []ShiftTemplate{}
+	for rows.Next() {
+		t, err := scanShiftTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+
+	return templates, rows.Err()
+}
+
+func (s *RosterStore) GetTemplate(ctx context.Context, id uuid.UUID) (*ShiftTemplate, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	t, err := scanShiftTemplate(s.db.QueryRowContext(ctx, shiftTemplateQuery+` WHERE id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+const rosterShiftQuery = `
+	SELECT s.id, s.template_id, t.name, t.department, t.on_call, s.user_id, u.username, COALESCE(u.email, ''),
+		r.name, to_char(s.shift_date, 'YYYY-MM-DD'), s.starts_at, s.ends_at, s.created_by, s.created_at
+	FROM roster_shifts s
+	JOIN shift_templates t ON t.id = s.template_id
+	JOIN users u ON u.id = s.user_id
+	JOIN roles r ON r.id = u.role_id`
+
+func scanRosterShift(row rowScanner) (*RosterShift, error) {
+	s := &RosterShift{}
+
+	err := row.Scan(
+		&s.ID,
+		&s.TemplateID,
+		&s.TemplateName,
+		&s.Department,
+		&s.OnCall,
+		&s.UserID,
+		&s.Username,
+		&s.Email,
+		&s.Role,
+		&s.ShiftDate,
+		&s.StartsAt,
+		&s.EndsAt,
+		&s.CreatedBy,
+		&s.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *RosterStore) getShifts(ctx context.Context, query string, args ...any) ([]RosterShift, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := []RosterShift{}
+	for rows.Next() {
+		shift, err := scanRosterShift(rows)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, *shift)
+	}
+
+	return shifts, rows.Err()
+}
+
+func (s *RosterStore) GetShift(ctx context.Context, id uuid.UUID) (*RosterShift, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	shift, err := scanRosterShift(s.db.QueryRowContext(ctx, rosterShiftQuery+` WHERE s.id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return shift, nil
+}
+
+// GetShifts returns the shifts overlapping the query's window in order of
+// start.
+func (s *RosterStore) GetShifts(ctx context.Context, q RosterQuery) ([]RosterShift, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	users := make([]string, len(q.UserIDs))
+	for i, id := range q.UserIDs {
+		users[i] = id.String()
+	}
+
+	return s.getShifts(ctx, rosterShiftQuery+`
+		WHERE s.starts_at < $2 AND s.ends_at > $1
+			AND (cardinality($3::uuid[]) = 0 OR s.user_id = ANY($3::uuid[]))
+			AND ($4 = '' OR lower(t.department) = lower($4))
+		ORDER BY s.starts_at, t.name, u.username
+	`, q.From, q.To, pq.Array(users), q.Department)
+}
+
+// GetOnCall returns who is on call for the department at the given time.
+func (s *RosterStore) GetOnCall(ctx context.Context, department string, at time.Time) ([]RosterShift, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return s.getShifts(ctx, rosterShiftQuery+`
+		WHERE t.on_call AND lower(t.department) = lower($1)
+			AND s.starts_at <= $2 AND s.ends_at > $2
+		ORDER BY s.starts_at, u.username
+	`, department, at)
+}
+
+// lockRoster serializes changes to who works when for the rest of the
+// transaction, so the rest checks see every other change.
+func lockRoster(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('roster'))`)
+	return err
+}
+
+// checkRest returns ErrConflict if the user works another shift within rest
+// of the window.
+func checkRest(ctx context.Context, tx *sql.Tx, userID uuid.UUID, exclude []string, start, end time.Time, rest time.Duration) error {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM roster_shifts
+			WHERE user_id = $1 AND NOT (id = ANY($2::uuid[])) AND starts_at < $4 AND ends_at > $3
+		)
+	`
+
+	var busy bool
+	if err := tx.QueryRowContext(ctx, query, userID, pq.Array(exclude), start.Add(-rest), end.Add(rest)).Scan(&busy); err != nil {
+		return err
+	}
+
+	if busy {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+// AddShifts rosters the shifts all at once. It returns ErrConflict if
+// anyone would work two shifts less than rest apart, and ErrNotFound for an
+// unknown template or user.
+func (s *RosterStore) AddShifts(ctx context.Context, shifts []*RosterShift, rest time.Duration) error {
+	ids := make([]string, 0, len(shifts))
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if err := lockRoster(ctx, tx); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO roster_shifts (template_id, user_id, shift_date, starts_at, ends_at, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`
+
+		for _, shift := range shifts {
+			if err := checkRest(ctx, tx, shift.UserID, nil, shift.StartsAt, shift.EndsAt, rest); err != nil {
+				return err
+			}
+
+			err := tx.QueryRowContext(ctx, query,
+				shift.TemplateID,
+				shift.UserID,
+				shift.ShiftDate,
+				shift.StartsAt,
+				shift.EndsAt,
+				shift.CreatedBy,
+			).Scan(&shift.ID)
+			if err != nil {
+				switch {
+				case strings.Contains(err.Error(), "roster_shifts_user_template_date_key"):
+					return ErrConflict
+				case strings.Contains(err.Error(), "_fkey"):
+					return ErrNotFound
+				default:
+					return err
+				}
+			}
+
+			ids = append(ids, shift.ID.String())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	added, err := s.getShifts(ctx, rosterShiftQuery+` WHERE s.id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[uuid.UUID]RosterShift, len(added))
+	for _, shift := range added {
+		byID[shift.ID] = shift
+	}
+	for _, shift := range shifts {
+		*shift = byID[shift.ID]
+	}
+
+	return nil
+}
+
+// DeleteShift takes a shift off the roster. It returns ErrLocked once the
+// shift has started.
+func (s *RosterStore) DeleteShift(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM roster_shifts WHERE id = $1 AND starts_at > NOW()`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		if _, err := s.GetShift(ctx, id); err != nil {
+			return err
+		}
+		return ErrLocked
+	}
+
+	return nil
+}
+
+const shiftSwapColumns = `
+	id, shift_id, requested_by, with_user_id, counterpart_shift_id, reason, status,
+	responded_at, decided_by, decided_at, created_at, updated_at`
+
+func scanShiftSwap(row rowScanner) (*ShiftSwap, error) {
+	sw := &ShiftSwap{}
+
+	err := row.Scan(
+		&sw.ID,
+		&sw.ShiftID,
+		&sw.RequestedBy,
+		&sw.WithUserID,
+		&sw.CounterpartShiftID,
+		&sw.Reason,
+		&sw.Status,
+		&sw.RespondedAt,
+		&sw.DecidedBy,
+		&sw.DecidedAt,
+		&sw.CreatedAt,
+		&sw.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return sw, nil
+}
+
+// CreateSwap asks for a swap. It returns ErrConflict while another swap of
+// the shift is open.
+func (s *RosterStore) CreateSwap(ctx context.Context, swap *ShiftSwap) error {
+	query := `
+		INSERT INTO shift_swaps (shift_id, requested_by, with_user_id, counterpart_shift_id, reason)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + shiftSwapColumns
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	created, err := scanShiftSwap(s.db.QueryRowContext(ctx, query,
+		swap.ShiftID,
+		swap.RequestedBy,
+		swap.WithUserID,
+		swap.CounterpartShiftID,
+		swap.Reason,
+	))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "shift_swaps_open_shift_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	*swap = *created
+	return nil
+}
+
+func (s *RosterStore) GetSwap(ctx context.Context, id uuid.UUID) (*ShiftSwap, error) {
+	query := `SELECT ` + shiftSwapColumns + ` FROM shift_swaps WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	swap, err := scanShiftSwap(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return swap, nil
+}
+
+// GetSwaps lists swaps newest first, only those the user asked for or was
+// asked to take when userID is set.
+func (s *RosterStore) GetSwaps(ctx context.Context, userID *uuid.UUID, status ShiftSwapStatus) ([]ShiftSwap, error) {
+	query := `
+		SELECT ` + shiftSwapColumns + `
+		FROM shift_swaps
+		WHERE ($1::uuid IS NULL OR requested_by = $1 OR with_user_id = $1)
+			AND ($2 = '' OR status::text = $2)
+		ORDER BY created_at DESC, id
+		LIMIT 200
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	swaps := []ShiftSwap{}
+	for rows.Next() {
+		swap, err := scanShiftSwap(rows)
+		if err != nil {
+			return nil, err
+		}
+		swaps = append(swaps, *swap)
+	}
+
+	return swaps, rows.Err()
+}
+
+// RespondToSwap records the colleague's answer to a pending swap. It
+// returns ErrLocked unless the swap is pending.
+func (s *RosterStore) RespondToSwap(ctx context.Context, swap *ShiftSwap, accept bool) error {
+	status := ShiftSwapDeclined
+	if accept {
+		status = ShiftSwapAccepted
+	}
+
+	query := `
+		UPDATE shift_swaps SET status = $2, responded_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+		RETURNING ` + shiftSwapColumns
+
+	return s.swapTransition(ctx, swap, query, swap.ID, status)
+}
+
+// RejectSwap turns down an open swap. It returns ErrLocked once the swap
+// is settled.
+func (s *RosterStore) RejectSwap(ctx context.Context, swap *ShiftSwap, decidedBy uuid.UUID) error {
+	query := `
+		UPDATE shift_swaps SET status = 'rejected', decided_by = $2, decided_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status IN ('pending', 'accepted')
+		RETURNING ` + shiftSwapColumns
+
+	return s.swapTransition(ctx, swap, query, swap.ID, decidedBy)
+}
+
+// CancelSwap withdraws an open swap. It returns ErrLocked once the swap is
+// settled.
+func (s *RosterStore) CancelSwap(ctx context.Context, swap *ShiftSwap) error {
+	query := `
+		UPDATE shift_swaps SET status = 'cancelled', updated_at = NOW()
+		WHERE id = $1 AND status IN ('pending', 'accepted')
+		RETURNING ` + shiftSwapColumns
+
+	return s.swapTransition(ctx, swap, query, swap.ID)
+}
+
+func (s *RosterStore) swapTransition(ctx context.Context, swap *ShiftSwap, query string, args ...any) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	updated, err := scanShiftSwap(s.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return ErrLocked
+		default:
+			return err
+		}
+	}
+
+	*swap = *updated
+	return nil
+}
+
+// ApproveSwap reassigns the shifts of an accepted swap. It returns
+// ErrLocked unless the swap is accepted and both shifts are yet to start
+// and still with the people who agreed to swap them, and ErrConflict if
+// either of them would then work two shifts less than rest apart.
+func (s *RosterStore) ApproveSwap(ctx context.Context, swap *ShiftSwap, decidedBy uuid.UUID, rest time.Duration) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if err := lockRoster(ctx, tx); err != nil {
+			return err
+		}
+
+		current, err := scanShiftSwap(tx.QueryRowContext(ctx, `SELECT `+shiftSwapColumns+` FROM shift_swaps WHERE id = $1 FOR UPDATE`, swap.ID))
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if current.Status != ShiftSwapAccepted {
+			return ErrLocked
+		}
+
+		type move struct {
+			shiftID uuid.UUID
+			from    uuid.UUID
+			to      uuid.UUID
+		}
+
+		moves := []move{{current.ShiftID, current.RequestedBy, current.WithUserID}}
+		if current.CounterpartShiftID != nil {
+			moves = append(moves, move{*current.CounterpartShiftID, current.WithUserID, current.RequestedBy})
+		}
+
+		moved := make([]string, 0, len(moves))
+		for _, m := range moves {
+			moved = append(moved, m.shiftID.String())
+		}
+
+		for _, m := range moves {
+			var start, end time.Time
+			err := tx.QueryRowContext(ctx, `
+				UPDATE roster_shifts SET user_id = $3
+				WHERE id = $1 AND user_id = $2 AND starts_at > NOW()
+				RETURNING starts_at, ends_at
+			`, m.shiftID, m.from, m.to).Scan(&start, &end)
+			if err != nil {
+				switch {
+				case err == sql.ErrNoRows:
+					return ErrLocked
+				case strings.Contains(err.Error(), "roster_shifts_user_template_date_key"):
+					return ErrConflict
+				default:
+					return err
+				}
+			}
+
+			if err := checkRest(ctx, tx, m.to, moved, start, end, rest); err != nil {
+				return err
+			}
+		}
+
+		// the swapped shifts must also be rest apart from each other
+		if len(moves) == 2 {
+			var tooClose bool
+			err := tx.QueryRowContext(ctx, `
+				SELECT a.starts_at < b.ends_at + $3 * interval '1 second' AND b.starts_at < a.ends_at + $3 * interval '1 second'
+				FROM roster_shifts a, roster_shifts b
+				WHERE a.id = $1 AND b.id = $2 AND a.user_id = b.user_id
+			`, moves[0].shiftID, moves[1].shiftID, rest.Seconds()).Scan(&tooClose)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			if tooClose {
+				return ErrConflict
+			}
+		}
+
+		updated, err := scanShiftSwap(tx.QueryRowContext(ctx, `
+			UPDATE shift_swaps SET status = 'approved', decided_by = $2, decided_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+			RETURNING `+shiftSwapColumns, swap.ID, decidedBy))
+		if err != nil {
+			return err
+		}
+
+		*swap = *updated
+		return nil
+	})
+
+	return err
+}
@@ -12,6 +12,7 @@ import (
 var (
 	ErrNotFound          = errors.New("resource not found")
 	ErrConflict          = errors.New("resource already exists")
+	ErrLocked            = errors.New("resource is locked")
 	QueryTimeoutDuration = time.Second * 5
 )
 
@@ -21,24 +22,257 @@ type Storage struct {
 		Create(context.Context, *Doctor) error
 		Delete(context.Context, uuid.UUID) error
 		GetAllDoctors(context.Context) ([]*Doctor, error)
+		UpdatePhoto(ctx context.Context, doctorID uuid.UUID, photoKey, thumbnailKey string) (string, string, error)
+	}
+	DoctorDocuments interface {
+		Create(context.Context, *DoctorDocument) error
+		GetByDoctor(context.Context, uuid.UUID) ([]DoctorDocument, error)
+		GetByID(ctx context.Context, doctorID, documentID uuid.UUID) (*DoctorDocument, error)
+		Delete(ctx context.Context, doctorID, documentID uuid.UUID) error
 	}
 	Users interface {
 		GetByID(context.Context, uuid.UUID) (*User, error)
 		GetByEmail(context.Context, string) (*User, error)
 		Create(context.Context, *sql.Tx, *User) error
 		CreateAndInvite(ctx context.Context, user *User, token string, exp time.Duration) error
-		Activate(context.Context, string) error
+		Activate(context.Context, string, string) error
 		Delete(context.Context, uuid.UUID) error
 		CreateWithRole(context.Context, *User, int) error
+		SetRole(ctx context.Context, userID uuid.UUID, roleName string) error
 		GetByRole(context.Context, int) ([]UserMinimal, error)
 	}
 	Appointments interface {
 		Create(context.Context, *Appointment) error
 		GetAllAppointments(context.Context) ([]*Appointment, error)
+		GetByID(context.Context, uuid.UUID) (*Appointment, error)
+		GetByPatient(context.Context, uuid.UUID) ([]*Appointment, error)
+		Cancel(context.Context, *Appointment) error
 	}
 
 	Availability interface {
 		Create(context.Context, *Availability) error
+		GetByDoctor(context.Context, uuid.UUID) ([]Availability, error)
+	}
+	Fees interface {
+		Create(context.Context, *DoctorFee) error
+		GetByDoctor(context.Context, uuid.UUID) ([]DoctorFee, error)
+		GetEffective(context.Context, uuid.UUID, VisitType, ConsultationMode, time.Time) (*DoctorFee, error)
+	}
+	Encounters interface {
+		CreateFromAppointment(ctx context.Context, appointmentID, doctorID uuid.UUID) (*Encounter, error)
+		GetByID(context.Context, uuid.UUID) (*Encounter, error)
+		GetByPatient(context.Context, uuid.UUID) ([]*Encounter, error)
+		UpdateNote(context.Context, *Encounter) error
+		Sign(context.Context, *Encounter) error
+		AddAddendum(context.Context, *EncounterAddendum) error
+		SetDiagnoses(ctx context.Context, encounterID uuid.UUID, diagnoses []EncounterDiagnosis) error
+		IsTreatingDoctor(ctx context.Context, doctorID, patientID uuid.UUID) (bool, error)
+	}
+	Prescriptions interface {
+		Create(context.Context, *Prescription) error
+		GetByID(context.Context, uuid.UUID) (*Prescription, error)
+		GetByEncounter(context.Context, uuid.UUID) ([]*Prescription, error)
+		Cancel(ctx context.Context, prescription *Prescription, cancelledBy uuid.UUID, reason string) error
+		GetCurrentDrugs(ctx context.Context, patientID uuid.UUID, since time.Time) ([]string, error)
+	}
+	Labs interface {
+		GetTests(ctx context.Context, codes []string, activeOnly bool) ([]*LabTest, error)
+		CreateTest(context.Context, *LabTest) error
+		CreateOrder(ctx context.Context, order *LabOrder, codes []string) error
+		GetOrder(context.Context, uuid.UUID) (*LabOrder, error)
+		ListOrders(context.Context, LabOrderQuery) ([]*LabOrder, error)
+		RecordResults(ctx context.Context, order *LabOrder, results []LabResult, enteredBy uuid.UUID) error
+		Release(context.Context, *LabOrder) error
+	}
+	Vitals interface {
+		Create(context.Context, *Vitals) error
+		GetByPatient(ctx context.Context, patientID uuid.UUID, from, to time.Time) ([]*Vitals, error)
+		GetThresholds(context.Context) (map[VitalType]*VitalThreshold, error)
+		SetThreshold(context.Context, *VitalThreshold) error
+	}
+	Immunizations interface {
+		GetSchedule(ctx context.Context, activeOnly bool) ([]ScheduledDose, error)
+		SetScheduledDose(context.Context, *ScheduledDose) error
+		Create(context.Context, *Immunization) error
+		GetByPatient(context.Context, uuid.UUID) ([]Immunization, error)
+		GetOverduePatients(ctx context.Context, today time.Time) ([]uuid.UUID, error)
+		ClaimReminder(ctx context.Context, patientID uuid.UUID, vaccine string, doseNumber, resendAfterDays int) (bool, error)
+	}
+	Guardians interface {
+		CreateDependent(ctx context.Context, patient *Patient, guardianID uuid.UUID, relationship GuardianRelationship, issueMRN func(seq int64) string) error
+		GetDependents(context.Context, uuid.UUID) ([]*Patient, error)
+		GetGuardians(context.Context, uuid.UUID) ([]Guardian, error)
+		AddGuardian(ctx context.Context, patientID, guardianID uuid.UUID, relationship GuardianRelationship) error
+		RemoveGuardian(ctx context.Context, patientID, guardianID uuid.UUID) error
+		Upgrade(ctx context.Context, user *User, token string, invitationExp time.Duration) error
+		CancelUpgrade(ctx context.Context, patientID uuid.UUID, username string) error
+	}
+	ADT interface {
+		CreateWard(context.Context, *Ward) error
+		GetWards(context.Context) ([]Ward, error)
+		CreateRoom(context.Context, *Room) error
+		CreateBed(context.Context, *Bed) error
+		SetBedStatus(context.Context, *Bed) error
+		GetBoard(ctx context.Context, wardID *uuid.UUID) (*BedBoard, error)
+		Admit(ctx context.Context, admission *Admission, performedBy uuid.UUID, notes string) error
+		Transfer(ctx context.Context, admission *Admission, toBedID uuid.UUID, performedBy uuid.UUID, notes string) error
+		Discharge(ctx context.Context, admission *Admission, performedBy uuid.UUID, notes string) error
+		GetAdmission(context.Context, uuid.UUID) (*Admission, error)
+		ListAdmissions(context.Context, AdmissionQuery) ([]*Admission, error)
+		GetBedLocation(ctx context.Context, bedID uuid.UUID) (ward, room, bed string, err error)
+	}
+	Pharmacy interface {
+		CreateDrug(context.Context, *Drug) error
+		GetDrugs(ctx context.Context, search string) ([]Drug, error)
+		GetDrug(context.Context, uuid.UUID) (*Drug, error)
+		ReceiveBatch(context.Context, *DrugBatch) error
+		GetAlerts(ctx context.Context, expiringWithinDays int) (*StockAlerts, error)
+		Dispense(context.Context, *Dispensation) error
+		GetDispensations(ctx context.Context, prescriptionID uuid.UUID) ([]*Dispensation, error)
+	}
+	Billing interface {
+		SetPrice(context.Context, *Price) error
+		GetPrices(ctx context.Context, kind ChargeKind) ([]Price, error)
+		GetPrice(ctx context.Context, kind ChargeKind, code string) (*Price, error)
+		Charge(ctx context.Context, patientID uuid.UUID, currency string, item *InvoiceItem) error
+		GetInvoice(context.Context, uuid.UUID) (*Invoice, error)
+		GetByPatient(ctx context.Context, patientID uuid.UUID, includeDrafts bool) ([]*Invoice, error)
+		GetBalance(ctx context.Context, patientID uuid.UUID, currency string) (*PatientBalance, error)
+		SetDiscount(context.Context, *InvoiceItem) error
+		DeleteItem(ctx context.Context, invoiceID, itemID uuid.UUID) error
+		Issue(ctx context.Context, invoice *Invoice, issuedBy uuid.UUID) error
+		Void(ctx context.Context, invoice *Invoice, reason string) error
+		CreatePayment(context.Context, *Payment) error
+		SettlePayment(ctx context.Context, payment *Payment, status PaymentStatus, gatewayRef, failure *string) error
+		SetReceipt(ctx context.Context, payment *Payment, key string) error
+		GetPayment(context.Context, uuid.UUID) (*Payment, error)
+	}
+	Claims interface {
+		PrefillDiagnoses(ctx context.Context, invoiceID uuid.UUID) ([]string, error)
+		Create(context.Context, *Claim) error
+		GetByID(context.Context, uuid.UUID) (*Claim, error)
+		List(context.Context, ClaimQuery) ([]*Claim, error)
+		GetLinesFor(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]ClaimLine, error)
+		SetDiagnoses(ctx context.Context, claim *Claim, codes []string) error
+		Submit(context.Context, *Claim) error
+		Remit(ctx context.Context, claim *Claim, remittance *ClaimRemittance) error
+	}
+	ER interface {
+		Register(context.Context, *ERVisit) error
+		GetByID(context.Context, uuid.UUID) (*ERVisit, error)
+		GetBoard(context.Context) (*ERBoard, error)
+		Retriage(context.Context, *ERVisit) error
+		StartTreatment(ctx context.Context, visit *ERVisit, seenBy uuid.UUID) error
+		Depart(ctx context.Context, visit *ERVisit, disposition ERDisposition) error
+		ClaimBreaches(context.Context) ([]ERVisit, error)
+		GetWaitTargets(context.Context) ([]ERWaitTarget, error)
+		SetWaitTarget(context.Context, *ERWaitTarget) error
+	}
+	Referrals interface {
+		Create(context.Context, *Referral) error
+		GetByID(context.Context, uuid.UUID) (*Referral, error)
+		List(context.Context, ReferralQuery) ([]*Referral, error)
+		Accept(ctx context.Context, referral *Referral, doctorID uuid.UUID) error
+		Decline(ctx context.Context, referral *Referral, doctorID uuid.UUID, reason string) error
+		BookAppointment(context.Context, *Referral, *Appointment) error
+		Close(ctx context.Context, referral *Referral, closedBy uuid.UUID) error
+		GetRecipients(context.Context, *Referral) ([]ReferralRecipient, error)
+	}
+	Theatres interface {
+		CreateTheatre(context.Context, *Theatre) error
+		GetTheatres(context.Context) ([]Theatre, error)
+		CreateEquipment(context.Context, *TheatreEquipment) error
+		GetEquipment(context.Context) ([]TheatreEquipment, error)
+		GetCase(context.Context, uuid.UUID) (*SurgicalCase, error)
+		GetDayList(ctx context.Context, day time.Time, theatreID *uuid.UUID) ([]*SurgicalCase, error)
+		FindConflicts(context.Context, *SurgicalCase) ([]ScheduleConflict, error)
+		Schedule(context.Context, *SurgicalCase) error
+		Reschedule(context.Context, *SurgicalCase) error
+		Start(context.Context, *SurgicalCase) error
+		Complete(context.Context, *SurgicalCase) error
+		Cancel(ctx context.Context, c *SurgicalCase, reason string) error
+	}
+	Roster interface {
+		CreateTemplate(context.Context, *ShiftTemplate) error
+		GetTemplates(context.Context) ([]ShiftTemplate, error)
+		GetTemplate(context.Context, uuid.UUID) (*ShiftTemplate, error)
+		GetShift(context.Context, uuid.UUID) (*RosterShift, error)
+		GetShifts(context.Context, RosterQuery) ([]RosterShift, error)
+		GetOnCall(ctx context.Context, department string, at time.Time) ([]RosterShift, error)
+		AddShifts(ctx context.Context, shifts []*RosterShift, rest time.Duration) error
+		DeleteShift(context.Context, uuid.UUID) error
+		CreateSwap(context.Context, *ShiftSwap) error
+		GetSwap(context.Context, uuid.UUID) (*ShiftSwap, error)
+		GetSwaps(ctx context.Context, userID *uuid.UUID, status ShiftSwapStatus) ([]ShiftSwap, error)
+		RespondToSwap(ctx context.Context, swap *ShiftSwap, accept bool) error
+		ApproveSwap(ctx context.Context, swap *ShiftSwap, decidedBy uuid.UUID, rest time.Duration) error
+		RejectSwap(ctx context.Context, swap *ShiftSwap, decidedBy uuid.UUID) error
+		CancelSwap(context.Context, *ShiftSwap) error
+	}
+	BloodBank interface {
+		CreateDonor(context.Context, *BloodDonor) error
+		GetDonors(ctx context.Context, search string) ([]BloodDonor, error)
+		GetDonor(context.Context, uuid.UUID) (*BloodDonor, error)
+		DeferDonor(context.Context, *BloodDonor) error
+		RecordDonation(ctx context.Context, donation *BloodDonation, intervalDays int) error
+		AddUnit(context.Context, *BloodUnit) error
+		GetUnit(context.Context, uuid.UUID) (*BloodUnit, error)
+		GetUnits(context.Context, BloodUnitQuery) ([]BloodUnit, error)
+		GetStock(ctx context.Context, component BloodComponent, groups []BloodGroup) ([]BloodStock, error)
+		DiscardUnit(ctx context.Context, unit *BloodUnit, reason string) error
+		CreateCrossmatch(context.Context, *CrossmatchRequest) error
+		GetCrossmatch(context.Context, uuid.UUID) (*CrossmatchRequest, error)
+		GetCrossmatches(context.Context, CrossmatchQuery) ([]*CrossmatchRequest, error)
+		RecordCrossmatch(ctx context.Context, test *CrossmatchTest, groups []BloodGroup) error
+		GetIssue(context.Context, uuid.UUID) (*BloodIssue, error)
+		IssueUnit(context.Context, *BloodIssue) error
+		ReturnUnit(context.Context, *BloodIssue) error
+		CloseCrossmatch(ctx context.Context, request *CrossmatchRequest, closedBy uuid.UUID) error
+	}
+	DischargeSummaries interface {
+		Prefill(context.Context, *Admission) (*DischargeSummary, error)
+		Create(context.Context, *DischargeSummary) error
+		GetByID(context.Context, uuid.UUID) (*DischargeSummary, error)
+		GetByPatient(ctx context.Context, patientID uuid.UUID, finalOnly bool) ([]*DischargeSummary, error)
+		Update(context.Context, *DischargeSummary) error
+		Finalize(ctx context.Context, summary *DischargeSummary, pdfKey string) error
+		MarkEmailed(context.Context, *DischargeSummary) error
+		BookFollowUp(ctx context.Context, summary *DischargeSummary, appointment *Appointment) error
+	}
+	Timeline interface {
+		Get(context.Context, TimelineQuery) (*TimelinePage, error)
+	}
+	PatientDocuments interface {
+		Create(context.Context, *PatientDocument) error
+		List(context.Context, PatientDocumentQuery) ([]*PatientDocument, error)
+		GetByID(ctx context.Context, patientID, documentID uuid.UUID) (*PatientDocument, error)
+		Delete(ctx context.Context, patientID, documentID uuid.UUID) error
+	}
+	Allergies interface {
+		GetByPatient(context.Context, uuid.UUID) ([]Allergy, error)
+		Create(context.Context, *Allergy) error
+		Delete(ctx context.Context, patientID, allergyID uuid.UUID) error
+	}
+	Codes interface {
+		UpsertICD10(context.Context, []ICD10Code) (int64, error)
+		SearchICD10(ctx context.Context, q string, limit int) ([]ICD10Code, error)
+	}
+	Reports interface {
+		TopDiagnoses(ctx context.Context, from, to time.Time, limit int, primaryOnly bool) ([]DiagnosisCount, error)
+	}
+	Patients interface {
+		Create(ctx context.Context, patient *Patient, issueMRN func(seq int64) string) error
+		Update(context.Context, *Patient) error
+		GetByID(context.Context, uuid.UUID) (*Patient, error)
+		GetIDByMRN(context.Context, string) (uuid.UUID, error)
+		GetIDByIdentifier(ctx context.Context, idType IdentifierType, issuer, value string) (uuid.UUID, error)
+		Search(context.Context, PatientSearchQuery) ([]*Patient, error)
+		GetInsurance(context.Context, uuid.UUID) ([]InsurancePolicy, error)
+		AddInsurance(context.Context, *InsurancePolicy) error
+		DeleteInsurance(ctx context.Context, patientID, policyID uuid.UUID) error
+		GetIdentifiers(context.Context, uuid.UUID) ([]PatientIdentifier, error)
+		AddIdentifier(context.Context, *PatientIdentifier) error
+		DeleteIdentifier(ctx context.Context, patientID, identifierID uuid.UUID) error
 	}
 	Roles interface {
 		GetByName(context.Context, string) (*Role, error)
@@ -47,11 +281,35 @@ type Storage struct {
 
 func NewStorage(db *sql.DB) Storage {
 	return Storage{
-		Doctors:      &DoctorStore{db},
-		Users:        &UserStore{db},
-		Roles:        &RoleStore{db},
-		Appointments: &AppointmentStore{db},
-		Availability: &AvailabilityStore{db},
+		Doctors:            &DoctorStore{db},
+		DoctorDocuments:    &DoctorDocumentStore{db},
+		Users:              &UserStore{db},
+		Roles:              &RoleStore{db},
+		Appointments:       &AppointmentStore{db},
+		Availability:       &AvailabilityStore{db},
+		Fees:               &FeeStore{db},
+		Patients:           &PatientStore{db},
+		Encounters:         &EncounterStore{db},
+		Prescriptions:      &PrescriptionStore{db},
+		Allergies:          &AllergyStore{db},
+		Labs:               &LabStore{db},
+		Vitals:             &VitalStore{db},
+		PatientDocuments:   &PatientDocumentStore{db},
+		Immunizations:      &ImmunizationStore{db},
+		Guardians:          &GuardianStore{db},
+		Timeline:           &TimelineStore{db},
+		ADT:                &ADTStore{db},
+		DischargeSummaries: &DischargeSummaryStore{db},
+		Pharmacy:           &PharmacyStore{db},
+		Billing:            &BillingStore{db},
+		Claims:             &ClaimStore{db},
+		ER:                 &ERStore{db},
+		Referrals:          &ReferralStore{db},
+		Theatres:           &TheatreStore{db},
+		Roster:             &RosterStore{db},
+		BloodBank:          &BloodBankStore{db},
+		Codes:              &CodeStore{db},
+		Reports:            &ReportStore{db},
 	}
 }
 
@@ -0,0 +1,659 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type SurgicalCaseStatus string
+
+const (
+	SurgicalCaseScheduled  SurgicalCaseStatus = "scheduled"
+	SurgicalCaseInProgress SurgicalCaseStatus = "in_progress"
+	SurgicalCaseCompleted  SurgicalCaseStatus = "completed"
+	SurgicalCaseCancelled  SurgicalCaseStatus = "cancelled"
+)
+
+type SurgicalRole string
+
+const (
+	SurgicalRoleSurgeon     SurgicalRole = "surgeon"
+	SurgicalRoleAnesthetist SurgicalRole = "anesthetist"
+	SurgicalRoleNurse       SurgicalRole = "nurse"
+)
+
+type Theatre struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TheatreEquipment is one unit of equipment, booked by one case at a time.
+type TheatreEquipment struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SurgicalTeamMember struct {
+	UserID   uuid.UUID    `json:"user_id"`
+	Username string       `json:"username"`
+	Role     SurgicalRole `json:"role"`
+}
+
+// SurgicalCase is an operation booked in a theatre. ScheduledEnd is
+// ScheduledStart plus ExpectedMinutes.
+type SurgicalCase struct {
+	ID              uuid.UUID            `json:"id"`
+	TheatreID       uuid.UUID            `json:"theatre_id"`
+	TheatreName     string               `json:"theatre_name"`
+	PatientID       uuid.UUID            `json:"patient_id"`
+	PatientName     string               `json:"patient_name"`
+	Procedure       string               `json:"procedure"`
+	ExpectedMinutes int                  `json:"expected_minutes"`
+	ScheduledStart  time.Time            `json:"scheduled_start"`
+	ScheduledEnd    time.Time            `json:"scheduled_end"`
+	Status          SurgicalCaseStatus   `json:"status"`
+	Notes           string               `json:"notes"`
+	Team            []SurgicalTeamMember `json:"team"`
+	Equipment       []TheatreEquipment   `json:"equipment"`
+	StartedAt       *time.Time           `json:"started_at"`
+	CompletedAt     *time.Time           `json:"completed_at"`
+	CancelledAt     *time.Time           `json:"cancelled_at"`
+	CancelReason    string               `json:"cancel_reason"`
+	BookedBy        *uuid.UUID           `json:"booked_by"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+// ScheduleConflict is a booking that overlaps a case on one of its
+// resources: the theatre, a team member or a piece of equipment.
+type ScheduleConflict struct {
+	Resource     string    `json:"resource"`
+	ResourceID   uuid.UUID `json:"resource_id"`
+	ResourceName string    `json:"resource_name"`
+	CaseID       uuid.UUID `json:"case_id"`
+	Procedure    string    `json:"procedure"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+}
+
+// ScheduleConflictError is returned when a case cannot be booked because of
+// the conflicts it lists.
+type ScheduleConflictError struct {
+	Conflicts []ScheduleConflict
+}
+
+func (e *ScheduleConflictError) Error() string {
+	parts := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		parts = append(parts, fmt.Sprintf("%s %s is booked from %s to %s",
+			c.Resource, c.ResourceName, c.Start.UTC().Format(time.RFC3339), c.End.UTC().Format(time.RFC3339)))
+	}
+	return "scheduling conflict: " + strings.Join(parts, "; ")
+}
+
+type TheatreStore struct {
+	db *sql.DB
+}
+
+func (s *TheatreStore) CreateTheatre(ctx context.Context, theatre *Theatre) error {
+	query := `INSERT INTO theatres (name) VALUES ($1) RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, theatre.Name).Scan(&theatre.ID, &theatre.CreatedAt)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "theatres_name_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *TheatreStore) GetTheatres(ctx context.Context) ([]Theatre, error) {
+	query := `SELECT id, name, created_at FROM theatres ORDER BY name`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	theatres := []Theatre{}
+	for rows.Next() {
+		var t Theatre
+		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		theatres = append(theatres, t)
+	}
+
+	return theatres, rows.Err()
+}
+
+func (s *TheatreStore) CreateEquipment(ctx context.Context, equipment *TheatreEquipment) error {
+	query := `INSERT INTO theatre_equipment (name) VALUES ($1) RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, equipment.Name).Scan(&equipment.ID, &equipment.CreatedAt)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "theatre_equipment_name_key"):
+			return ErrConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *TheatreStore) GetEquipment(ctx context.Context) ([]TheatreEquipment, error) {
+	query := `SELECT id, name, created_at FROM theatre_equipment ORDER BY name`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	equipment := []TheatreEquipment{}
+	for rows.Next() {
+		var e TheatreEquipment
+		if err := rows.Scan(&e.ID, &e.Name, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		equipment = append(equipment, e)
+	}
+
+	return equipment, rows.Err()
+}
+
+const surgicalCaseQuery = `
+	SELECT c.id, c.theatre_id, t.name, c.patient_id, TRIM(p.firstname || ' ' || p.lastname), c.procedure,
+		c.expected_minutes, c.scheduled_start, c.scheduled_end, c.status, c.notes, c.started_at,
+		c.completed_at, c.cancelled_at, c.cancel_reason, c.booked_by, c.created_at, c.updated_at
+	FROM surgical_cases c
+	JOIN theatres t ON t.id = c.theatre_id
+	JOIN patients p ON p.user_id = c.patient_id`
+
+func scanSurgicalCase(row rowScanner) (*SurgicalCase, error) {
+	c := &SurgicalCase{
+		Team:      []SurgicalTeamMember{},
+		Equipment: []TheatreEquipment{},
+	}
+
+	err := row.Scan(
+		&c.ID,
+		&c.TheatreID,
+		&c.TheatreName,
+		&c.PatientID,
+		&c.PatientName,
+		&c.Procedure,
+		&c.ExpectedMinutes,
+		&c.ScheduledStart,
+		&c.ScheduledEnd,
+		&c.Status,
+		&c.Notes,
+		&c.StartedAt,
+		&c.CompletedAt,
+		&c.CancelledAt,
+		&c.CancelReason,
+		&c.BookedBy,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *TheatreStore) GetCase(ctx context.Context, id uuid.UUID) (*SurgicalCase, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	c, err := scanSurgicalCase(s.db.QueryRowContext(ctx, surgicalCaseQuery+` WHERE c.id = $1`, id))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if err := loadCaseResources(ctx, s.db, []*SurgicalCase{c}); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// GetDayList returns the cases starting on the UTC day of day, cancelled
+// ones included, by theatre and then by start.
+func (s *TheatreStore) GetDayList(ctx context.Context, day time.Time, theatreID *uuid.UUID) ([]*SurgicalCase, error) {
+	query := surgicalCaseQuery + `
+		WHERE c.scheduled_start >= $1 AND c.scheduled_start < $2
+			AND ($3::uuid IS NULL OR c.theatre_id = $3)
+		ORDER BY t.name, c.scheduled_start, c.id
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	from := day.UTC().Truncate(24 * time.Hour)
+
+	rows, err := s.db.QueryContext(ctx, query, from, from.AddDate(0, 0, 1), theatreID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cases := []*SurgicalCase{}
+	for rows.Next() {
+		c, err := scanSurgicalCase(rows)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadCaseResources(ctx, s.db, cases); err != nil {
+		return nil, err
+	}
+
+	return cases, nil
+}
+
+// loadCaseResources fills in the team and equipment of the cases.
+func loadCaseResources(ctx context.Context, q queryer, cases []*SurgicalCase) error {
+	if len(cases) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*SurgicalCase, len(cases))
+	ids := make([]string, len(cases))
+	for i, c := range cases {
+		byID[c.ID] = c
+		ids[i] = c.ID.String()
+	}
+
+	staff := `
+		SELECT s.case_id, s.user_id, u.username, s.role
+		FROM surgical_case_staff s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.case_id = ANY($1::uuid[])
+		ORDER BY s.case_id, s.role, u.username
+	`
+
+	rows, err := q.QueryContext(ctx, staff, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var caseID uuid.UUID
+		var m SurgicalTeamMember
+		if err := rows.Scan(&caseID, &m.UserID, &m.Username, &m.Role); err != nil {
+			return err
+		}
+		byID[caseID].Team = append(byID[caseID].Team, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	equipment := `
+		SELECT ce.case_id, e.id, e.name, e.created_at
+		FROM surgical_case_equipment ce
+		JOIN theatre_equipment e ON e.id = ce.equipment_id
+		WHERE ce.case_id = ANY($1::uuid[])
+		ORDER BY ce.case_id, e.name
+	`
+
+	rows, err = q.QueryContext(ctx, equipment, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var caseID uuid.UUID
+		var e TheatreEquipment
+		if err := rows.Scan(&caseID, &e.ID, &e.Name, &e.CreatedAt); err != nil {
+			return err
+		}
+		byID[caseID].Equipment = append(byID[caseID].Equipment, e)
+	}
+
+	return rows.Err()
+}
+
+// FindConflicts returns the active cases, other than c itself, that overlap
+// c in its theatre, on a member of its team or on a piece of its equipment.
+// A case in progress holds its resources until it is completed, even past
+// its expected end.
+func (s *TheatreStore) FindConflicts(ctx context.Context, c *SurgicalCase) ([]ScheduleConflict, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return findConflicts(ctx, s.db, c)
+}
+
+func findConflicts(ctx context.Context, q queryer, c *SurgicalCase) ([]ScheduleConflict, error) {
+	query := `
+		WITH active AS (
+			SELECT id, theatre_id, procedure, scheduled_start,
+				CASE WHEN status = 'in_progress' THEN GREATEST(scheduled_end, NOW()) ELSE scheduled_end END AS scheduled_end
+			FROM surgical_cases
+			WHERE status IN ('scheduled', 'in_progress') AND id <> $1
+		), overlapping AS (
+			SELECT * FROM active WHERE scheduled_start < $4 AND scheduled_end > $3
+		)
+		SELECT 'theatre', t.id, t.name, o.id, o.procedure, o.scheduled_start, o.scheduled_end
+		FROM overlapping o
+		JOIN theatres t ON t.id = o.theatre_id
+		WHERE o.theatre_id = $2
+		UNION ALL
+		SELECT 'staff', u.id, u.username, o.id, o.procedure, o.scheduled_start, o.scheduled_end
+		FROM overlapping o
+		JOIN surgical_case_staff s ON s.case_id = o.id
+		JOIN users u ON u.id = s.user_id
+		WHERE s.user_id = ANY($5::uuid[])
+		UNION ALL
+		SELECT 'equipment', e.id, e.name, o.id, o.procedure, o.scheduled_start, o.scheduled_end
+		FROM overlapping o
+		JOIN surgical_case_equipment ce ON ce.case_id = o.id
+		JOIN theatre_equipment e ON e.id = ce.equipment_id
+		WHERE ce.equipment_id = ANY($6::uuid[])
+		ORDER BY 6, 1, 3
+	`
+
+	staff := make([]string, len(c.Team))
+	for i, m := range c.Team {
+		staff[i] = m.UserID.String()
+	}
+
+	equipment := make([]string, len(c.Equipment))
+	for i, e := range c.Equipment {
+		equipment[i] = e.ID.String()
+	}
+
+	rows, err := q.QueryContext(ctx, query,
+		c.ID,
+		c.TheatreID,
+		c.ScheduledStart,
+		c.ScheduledEnd,
+		pq.Array(staff),
+		pq.Array(equipment),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conflicts := []ScheduleConflict{}
+	for rows.Next() {
+		var conflict ScheduleConflict
+		err := rows.Scan(
+			&conflict.Resource,
+			&conflict.ResourceID,
+			&conflict.ResourceName,
+			&conflict.CaseID,
+			&conflict.Procedure,
+			&conflict.Start,
+			&conflict.End,
+		)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	return conflicts, rows.Err()
+}
+
+// lockSchedule serializes bookings for the rest of the transaction, so two
+// cases cannot both pass the conflict check for the same theatre, person
+// or equipment.
+func lockSchedule(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('theatre_schedule'))`)
+	return err
+}
+
+// Schedule books the case with its team and equipment. It returns a
+// *ScheduleConflictError if anything overlaps, and ErrNotFound for an
+// unknown theatre, patient, team member or piece of equipment.
+func (s *TheatreStore) Schedule(ctx context.Context, c *SurgicalCase) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if err := lockSchedule(ctx, tx); err != nil {
+			return err
+		}
+
+		conflicts, err := findConflicts(ctx, tx, c)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return &ScheduleConflictError{Conflicts: conflicts}
+		}
+
+		query := `
+			INSERT INTO surgical_cases (theatre_id, patient_id, procedure, expected_minutes, scheduled_start, scheduled_end, notes, booked_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id
+		`
+
+		err = tx.QueryRowContext(ctx, query,
+			c.TheatreID,
+			c.PatientID,
+			c.Procedure,
+			c.ExpectedMinutes,
+			c.ScheduledStart,
+			c.ScheduledEnd,
+			c.Notes,
+			c.BookedBy,
+		).Scan(&c.ID)
+		if err != nil {
+			return caseReferenceError(err)
+		}
+
+		return insertCaseResources(ctx, tx, c)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.reload(ctx, c)
+}
+
+// Reschedule changes the time, theatre, team or equipment of a case that
+// has not started. It returns ErrLocked once the case started or was
+// cancelled, and the errors of Schedule.
+func (s *TheatreStore) Reschedule(ctx context.Context, c *SurgicalCase) error {
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		if err := lockSchedule(ctx, tx); err != nil {
+			return err
+		}
+
+		var status SurgicalCaseStatus
+		err := tx.QueryRowContext(ctx, `SELECT status FROM surgical_cases WHERE id = $1 FOR UPDATE`, c.ID).Scan(&status)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return ErrNotFound
+			default:
+				return err
+			}
+		}
+
+		if status != SurgicalCaseScheduled {
+			return ErrLocked
+		}
+
+		conflicts, err := findConflicts(ctx, tx, c)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return &ScheduleConflictError{Conflicts: conflicts}
+		}
+
+		query := `
+			UPDATE surgical_cases
+			SET theatre_id = $2, procedure = $3, expected_minutes = $4, scheduled_start = $5, scheduled_end = $6,
+				notes = $7, updated_at = NOW()
+			WHERE id = $1
+		`
+
+		_, err = tx.ExecContext(ctx, query,
+			c.ID,
+			c.TheatreID,
+			c.Procedure,
+			c.ExpectedMinutes,
+			c.ScheduledStart,
+			c.ScheduledEnd,
+			c.Notes,
+		)
+		if err != nil {
+			return caseReferenceError(err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM surgical_case_staff WHERE case_id = $1`, c.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM surgical_case_equipment WHERE case_id = $1`, c.ID); err != nil {
+			return err
+		}
+
+		return insertCaseResources(ctx, tx, c)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.reload(ctx, c)
+}
+
+func insertCaseResources(ctx context.Context, tx *sql.Tx, c *SurgicalCase) error {
+	for _, m := range c.Team {
+		_, err := tx.ExecContext(ctx, `INSERT INTO surgical_case_staff (case_id, user_id, role) VALUES ($1, $2, $3)`, c.ID, m.UserID, m.Role)
+		if err != nil {
+			return caseReferenceError(err)
+		}
+	}
+
+	for _, e := range c.Equipment {
+		_, err := tx.ExecContext(ctx, `INSERT INTO surgical_case_equipment (case_id, equipment_id) VALUES ($1, $2)`, c.ID, e.ID)
+		if err != nil {
+			return caseReferenceError(err)
+		}
+	}
+
+	return nil
+}
+
+func caseReferenceError(err error) error {
+	if strings.Contains(err.Error(), "_fkey") {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *TheatreStore) reload(ctx context.Context, c *SurgicalCase) error {
+	fresh, err := s.GetCase(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+
+	*c = *fresh
+	return nil
+}
+
+// Start records that the operation began. It returns ErrLocked unless the
+// case is scheduled.
+func (s *TheatreStore) Start(ctx context.Context, c *SurgicalCase) error {
+	query := `
+		UPDATE surgical_cases SET status = 'in_progress', started_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'scheduled'
+	`
+
+	return s.transition(ctx, c, query, c.ID)
+}
+
+// Complete records that the operation ended, which frees its theatre, team
+// and equipment. It returns ErrLocked unless the case is in progress.
+func (s *TheatreStore) Complete(ctx context.Context, c *SurgicalCase) error {
+	query := `
+		UPDATE surgical_cases SET status = 'completed', completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'in_progress'
+	`
+
+	return s.transition(ctx, c, query, c.ID)
+}
+
+// Cancel cancels a case that has not started. It returns ErrLocked
+// otherwise.
+func (s *TheatreStore) Cancel(ctx context.Context, c *SurgicalCase, reason string) error {
+	query := `
+		UPDATE surgical_cases SET status = 'cancelled', cancelled_at = NOW(), cancel_reason = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'scheduled'
+	`
+
+	return s.transition(ctx, c, query, c.ID, reason)
+}
+
+func (s *TheatreStore) transition(ctx context.Context, c *SurgicalCase, query string, args ...any) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrLocked
+	}
+
+	return s.reload(ctx, c)
+}
@@ -0,0 +1,290 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type TimelineEventType string
+
+const (
+	TimelineAppointment  TimelineEventType = "appointment"
+	TimelineEncounter    TimelineEventType = "encounter"
+	TimelineDiagnosis    TimelineEventType = "diagnosis"
+	TimelinePrescription TimelineEventType = "prescription"
+	TimelineLabOrder     TimelineEventType = "lab_order"
+	TimelineVitals       TimelineEventType = "vitals"
+	TimelineDocument     TimelineEventType = "document"
+	TimelineAdmission    TimelineEventType = "admission"
+)
+
+// TimelineEventTypes lists every event type, the default filter.
+var TimelineEventTypes = []TimelineEventType{
+	TimelineAppointment,
+	TimelineEncounter,
+	TimelineDiagnosis,
+	TimelinePrescription,
+	TimelineLabOrder,
+	TimelineVitals,
+	TimelineDocument,
+	TimelineAdmission,
+}
+
+// TimelineEvent is one entry of a patient's timeline. ID is the ID of the
+// underlying record; diagnoses are named by encounter ID and code, and
+// admissions have one event per admission, transfer and discharge.
+type TimelineEvent struct {
+	Type        TimelineEventType `json:"type"`
+	ID          string            `json:"id"`
+	OccurredAt  time.Time         `json:"occurred_at"`
+	Summary     string            `json:"summary"`
+	Status      string            `json:"status,omitempty"`
+	ActorID     *uuid.UUID        `json:"actor_id"`
+	EncounterID *uuid.UUID        `json:"encounter_id,omitempty"`
+}
+
+type TimelinePage struct {
+	Events []TimelineEvent `json:"events"`
+	// NextCursor fetches the next, older page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// TimelineCursor is the position of the last event of a page. Events are
+// ordered by time, type and ID, newest first.
+type TimelineCursor struct {
+	OccurredAt time.Time
+	Type       TimelineEventType
+	ID         string
+}
+
+func (c TimelineCursor) Encode() string {
+	raw := c.OccurredAt.UTC().Format(time.RFC3339Nano) + "|" + string(c.Type) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeTimelineCursor(s string) (*TimelineCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 {
+		return nil, ErrInvalidCursor
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &TimelineCursor{OccurredAt: at, Type: TimelineEventType(parts[1]), ID: parts[2]}, nil
+}
+
+type TimelineQuery struct {
+	PatientID uuid.UUID `json:"-"`
+	// ViewerID sees their own draft encounters
+	ViewerID uuid.UUID `json:"-"`
+	// PatientView hides what the patient may not see yet: unreleased lab
+	// orders and other doctors' drafts are always hidden
+	PatientView bool                `json:"-"`
+	Types       []TimelineEventType `json:"types" validate:"dive,oneof=appointment encounter diagnosis prescription lab_order vitals document admission"`
+	Limit       int                 `json:"limit" validate:"gte=1,lte=100"`
+	Cursor      *TimelineCursor     `json:"-"`
+}
+
+func (q TimelineQuery) Parse(r *http.Request) (TimelineQuery, error) {
+	qs := r.URL.Query()
+
+	limit := qs.Get("limit")
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return q, err
+		}
+
+		q.Limit = l
+	}
+
+	if types := qs.Get("types"); types != "" {
+		q.Types = nil
+		for _, t := range strings.Split(types, ",") {
+			q.Types = append(q.Types, TimelineEventType(strings.TrimSpace(t)))
+		}
+	}
+
+	if cursor := qs.Get("cursor"); cursor != "" {
+		c, err := DecodeTimelineCursor(cursor)
+		if err != nil {
+			return q, err
+		}
+
+		q.Cursor = c
+	}
+
+	return q, nil
+}
+
+type TimelineStore struct {
+	db *sql.DB
+}
+
+// Get returns a page of the patient's timeline, newest first. Every source
+// is read in one statement; branches whose type is filtered out are skipped
+// by the planner.
+func (s *TimelineStore) Get(ctx context.Context, q TimelineQuery) (*TimelinePage, error) {
+	query := `
+		SELECT type, id, occurred_at, summary, status, actor_id, encounter_id
+		FROM (
+			SELECT 'appointment'::text AS type, a.id::text AS id, a.appointment_time AS occurred_at,
+				a.visit_type::text || ', ' || a.consultation_mode::text AS summary,
+				a.status::text AS status, a.doctor_id AS actor_id, NULL::uuid AS encounter_id
+			FROM appointment a
+			WHERE a.patient_id = $1
+
+			UNION ALL
+
+			SELECT 'encounter', e.id::text, e.created_at,
+				COALESCE(NULLIF(left(e.assessment, 200), ''), 'Visit note'),
+				e.status::text, e.doctor_id, e.id
+			FROM encounters e
+			WHERE e.patient_id = $1 AND (e.status = 'signed' OR e.doctor_id = $2)
+
+			UNION ALL
+
+			SELECT 'diagnosis', e.id::text || '/' || d.code, e.created_at,
+				d.code || ' ' || c.description,
+				d.rank::text, e.doctor_id, e.id
+			FROM encounter_diagnoses d
+			JOIN encounters e ON e.id = d.encounter_id
+			JOIN icd10_codes c ON c.code = d.code
+			WHERE e.patient_id = $1 AND (e.status = 'signed' OR e.doctor_id = $2)
+
+			UNION ALL
+
+			SELECT 'prescription', p.id::text, p.issued_at,
+				(SELECT string_agg(i.drug || ' ' || i.strength, ', ' ORDER BY i.position)
+					FROM prescription_items i WHERE i.prescription_id = p.id),
+				p.status::text, p.doctor_id, p.encounter_id
+			FROM prescriptions p
+			WHERE p.patient_id = $1
+
+			UNION ALL
+
+			SELECT 'lab_order', o.id::text, o.created_at,
+				(SELECT string_agg(t.name, ', ' ORDER BY t.name)
+					FROM lab_results lr JOIN lab_tests t ON t.code = lr.test_code
+					WHERE lr.order_id = o.id),
+				o.status::text, o.ordered_by, o.encounter_id
+			FROM lab_orders o
+			WHERE o.patient_id = $1 AND (NOT $3 OR o.status = 'released')
+
+			UNION ALL
+
+			SELECT 'vitals', v.id::text, v.recorded_at,
+				concat_ws(', ',
+					'BP ' || v.systolic || '/' || v.diastolic,
+					'pulse ' || v.pulse,
+					'temperature ' || v.temperature,
+					'SpO2 ' || v.spo2 || '%',
+					'weight ' || v.weight || ' kg',
+					'BMI ' || v.bmi),
+				'', v.recorded_by, NULL::uuid
+			FROM vitals v
+			WHERE v.patient_id = $1
+
+			UNION ALL
+
+			SELECT 'document', pd.id::text, pd.created_at,
+				pd.title || ' (' || pd.category::text || ')',
+				'', pd.uploaded_by, pd.encounter_id
+			FROM patient_documents pd
+			WHERE pd.patient_id = $1
+
+			UNION ALL
+
+			SELECT 'admission', ev.id::text, ev.occurred_at,
+				CASE ev.type
+					WHEN 'admit' THEN 'Admitted to '
+					WHEN 'transfer' THEN 'Transferred to '
+					ELSE 'Discharged from '
+				END || w.name || ', ' || r.name || ', bed ' || b.label,
+				ev.type::text, ev.performed_by, NULL::uuid
+			FROM adt_events ev
+			JOIN beds b ON b.id = COALESCE(ev.to_bed_id, ev.from_bed_id)
+			JOIN rooms r ON r.id = b.room_id
+			JOIN wards w ON w.id = r.ward_id
+			WHERE ev.patient_id = $1
+		) events
+		WHERE type = ANY($4)
+			AND ($5::timestamptz IS NULL OR (occurred_at, type, id) < ($5, $6, $7))
+		ORDER BY occurred_at DESC, type DESC, id DESC
+		LIMIT $8
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	types := make([]string, len(q.Types))
+	for i, t := range q.Types {
+		types[i] = string(t)
+	}
+
+	var cursorAt *time.Time
+	var cursorType, cursorID string
+	if q.Cursor != nil {
+		cursorAt = &q.Cursor.OccurredAt
+		cursorType = string(q.Cursor.Type)
+		cursorID = q.Cursor.ID
+	}
+
+	// one extra row tells whether there is a next page
+	rows, err := s.db.QueryContext(ctx, query,
+		q.PatientID,
+		q.ViewerID,
+		q.PatientView,
+		pq.Array(types),
+		cursorAt,
+		cursorType,
+		cursorID,
+		q.Limit+1,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &TimelinePage{Events: []TimelineEvent{}}
+	for rows.Next() {
+		var e TimelineEvent
+		var summary sql.NullString
+		if err := rows.Scan(&e.Type, &e.ID, &e.OccurredAt, &summary, &e.Status, &e.ActorID, &e.EncounterID); err != nil {
+			return nil, err
+		}
+		e.Summary = summary.String
+		page.Events = append(page.Events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Events) > q.Limit {
+		page.Events = page.Events[:q.Limit]
+		last := page.Events[len(page.Events)-1]
+		page.NextCursor = TimelineCursor{OccurredAt: last.OccurredAt, Type: last.Type, ID: last.ID}.Encode()
+	}
+
+	return page, nil
+}
@@ -10,12 +10,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	ErrDuplicateEmail    = errors.New("a user with that email already exists")
 	ErrDuplicateUsername = errors.New("a user with that username already exists")
+	ErrPasswordRequired  = errors.New("a password is required to activate this account")
 )
 
 type User struct {
@@ -27,6 +29,25 @@ type User struct {
 	IsActive  bool      `json:"is_active"`
 	RoleID    int64     `json:"role_id"`
 	Role      Role      `json:"role"`
+
+	// DependentIDs are the patients the user is a guardian of
+	DependentIDs []uuid.UUID `json:"dependent_ids,omitempty"`
+}
+
+// ActsFor reports whether the user may act as the patient: the patient
+// themselves or one of their guardians.
+func (u *User) ActsFor(patientID uuid.UUID) bool {
+	if u.ID == patientID {
+		return true
+	}
+
+	for _, id := range u.DependentIDs {
+		if id == patientID {
+			return true
+		}
+	}
+
+	return false
 }
 
 type password struct {
@@ -92,7 +113,8 @@ func (s *UserStore) Create(ctx context.Context, tx *sql.Tx, user *User) error {
 
 func (s *UserStore) GetByID(ctx context.Context, userID uuid.UUID) (*User, error) {
 	query := `
-		SELECT users.id, username, email, password, created_at, roles.*
+		SELECT users.id, username, COALESCE(email, ''), password, created_at, roles.*,
+			ARRAY(SELECT patient_id::text FROM patient_guardians WHERE guardian_id = users.id)
 		FROM users
 		JOIN roles ON (users.role_id = roles.id)
 		WHERE users.id = $1 AND is_active = true
@@ -102,6 +124,7 @@ func (s *UserStore) GetByID(ctx context.Context, userID uuid.UUID) (*User, error
 	defer cancel()
 
 	user := &User{}
+	var dependentIDs []string
 	err := s.db.QueryRowContext(
 		ctx,
 		query,
@@ -116,6 +139,7 @@ func (s *UserStore) GetByID(ctx context.Context, userID uuid.UUID) (*User, error
 		&user.Role.Name,
 		&user.Role.Level,
 		&user.Role.Description,
+		pq.Array(&dependentIDs),
 	)
 	if err != nil {
 		switch err {
@@ -126,6 +150,14 @@ func (s *UserStore) GetByID(ctx context.Context, userID uuid.UUID) (*User, error
 		}
 	}
 
+	for _, id := range dependentIDs {
+		dependentID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		user.DependentIDs = append(user.DependentIDs, dependentID)
+	}
+
 	return user, nil
 }
 
@@ -143,7 +175,10 @@ func (s *UserStore) CreateAndInvite(ctx context.Context, user *User, token strin
 	})
 }
 
-func (s *UserStore) Activate(ctx context.Context, token string) error {
+// Activate activates the user the invitation token belongs to. An account
+// without a password, an upgraded dependent, is given newPassword and its
+// guardians are unlinked; it returns ErrPasswordRequired if none is given.
+func (s *UserStore) Activate(ctx context.Context, token, newPassword string) error {
 	return withTx(s.db, ctx, func(tx *sql.Tx) error {
 		// 1. find the user that this token belongs to
 		user, err := s.getUserFromInvitation(ctx, tx, token)
@@ -151,6 +186,10 @@ func (s *UserStore) Activate(ctx context.Context, token string) error {
 			return err
 		}
 
+		if err := s.setFirstPassword(ctx, tx, user, newPassword); err != nil {
+			return err
+		}
+
 		// 2. update the user
 		user.IsActive = true
 		if err := s.update(ctx, tx, user); err != nil {
@@ -166,9 +205,40 @@ func (s *UserStore) Activate(ctx context.Context, token string) error {
 	})
 }
 
+func (s *UserStore) setFirstPassword(ctx context.Context, tx *sql.Tx, user *User, newPassword string) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	var hasPassword bool
+	err := tx.QueryRowContext(ctx, `SELECT password IS NOT NULL FROM users WHERE id = $1`, user.ID).Scan(&hasPassword)
+	if err != nil {
+		return err
+	}
+
+	if hasPassword {
+		return nil
+	}
+
+	if newPassword == "" {
+		return ErrPasswordRequired
+	}
+
+	if err := user.Password.Set(newPassword); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET password = $2 WHERE id = $1`, user.ID, user.Password.hash); err != nil {
+		return err
+	}
+
+	// the guardians managed the record until the patient took it over
+	_, err = tx.ExecContext(ctx, `DELETE FROM patient_guardians WHERE patient_id = $1`, user.ID)
+	return err
+}
+
 func (s *UserStore) getUserFromInvitation(ctx context.Context, tx *sql.Tx, token string) (*User, error) {
 	query := `
-		SELECT u.id, u.username, u.email, u.created_at, u.is_active
+		SELECT u.id, u.username, COALESCE(u.email, ''), u.created_at, u.is_active
 		FROM users u
 		JOIN user_invitations ui ON u.id = ui.user_id
 		WHERE ui.token = $1 AND ui.expiry > $2
@@ -337,7 +407,7 @@ type UserMinimal struct {
 }
 
 func (s *UserStore) GetByRole(ctx context.Context, roleID int) ([]UserMinimal, error) {
-	query := `SELECT id, email FROM users WHERE role_id = $1 AND is_active = true`
+	query := `SELECT id, email FROM users WHERE role_id = $1 AND is_active = true AND email IS NOT NULL`
 
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
 	defer cancel()
@@ -363,3 +433,33 @@ func (s *UserStore) GetByRole(ctx context.Context, roleID int) ([]UserMinimal, e
 
 	return users, nil
 }
+
+// SetRole changes the user's role. It returns ErrNotFound if either the user
+// or the role does not exist.
+func (s *UserStore) SetRole(ctx context.Context, userID uuid.UUID, roleName string) error {
+	query := `
+		UPDATE users
+		SET role_id = roles.id
+		FROM roles
+		WHERE users.id = $1 AND roles.name = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctx, query, userID, roleName)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type VitalType string
+
+const (
+	VitalSystolic    VitalType = "systolic"
+	VitalDiastolic   VitalType = "diastolic"
+	VitalPulse       VitalType = "pulse"
+	VitalTemperature VitalType = "temperature"
+	VitalSpO2        VitalType = "spo2"
+	VitalWeight      VitalType = "weight"
+	VitalHeight      VitalType = "height"
+	VitalBMI         VitalType = "bmi"
+)
+
+// VitalTypes lists every vital type in display order.
+var VitalTypes = []VitalType{
+	VitalSystolic,
+	VitalDiastolic,
+	VitalPulse,
+	VitalTemperature,
+	VitalSpO2,
+	VitalWeight,
+	VitalHeight,
+	VitalBMI,
+}
+
+// Unit returns the canonical unit values of the type are stored in.
+func (t VitalType) Unit() string {
+	switch t {
+	case VitalSystolic, VitalDiastolic:
+		return "mmHg"
+	case VitalPulse:
+		return "bpm"
+	case VitalTemperature:
+		return "C"
+	case VitalSpO2:
+		return "%"
+	case VitalWeight:
+		return "kg"
+	case VitalHeight:
+		return "cm"
+	case VitalBMI:
+		return "kg/m2"
+	default:
+		return ""
+	}
+}
+
+// Vitals is one set of measurements. Every measurement is optional, but a
+// set has at least one, and blood pressure is recorded as a pair.
+type Vitals struct {
+	ID            uuid.UUID  `json:"id"`
+	PatientID     uuid.UUID  `json:"patient_id"`
+	AppointmentID *uuid.UUID `json:"appointment_id"`
+	Systolic      *int       `json:"systolic"`
+	Diastolic     *int       `json:"diastolic"`
+	Pulse         *int       `json:"pulse"`
+	Temperature   *float64   `json:"temperature"`
+	SpO2          *int       `json:"spo2"`
+	Weight        *float64   `json:"weight"`
+	Height        *float64   `json:"height"`
+	BMI           *float64   `json:"bmi"`
+	RecordedBy    *uuid.UUID `json:"recorded_by"`
+	RecordedAt    time.Time  `json:"recorded_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Value returns the measurement of the given type, if it was taken.
+func (v *Vitals) Value(t VitalType) (float64, bool) {
+	integer := func(p *int) (float64, bool) {
+		if p == nil {
+			return 0, false
+		}
+		return float64(*p), true
+	}
+	decimal := func(p *float64) (float64, bool) {
+		if p == nil {
+			return 0, false
+		}
+		return *p, true
+	}
+
+	switch t {
+	case VitalSystolic:
+		return integer(v.Systolic)
+	case VitalDiastolic:
+		return integer(v.Diastolic)
+	case VitalPulse:
+		return integer(v.Pulse)
+	case VitalTemperature:
+		return decimal(v.Temperature)
+	case VitalSpO2:
+		return integer(v.SpO2)
+	case VitalWeight:
+		return decimal(v.Weight)
+	case VitalHeight:
+		return decimal(v.Height)
+	case VitalBMI:
+		return decimal(v.BMI)
+	default:
+		return 0, false
+	}
+}
+
+// VitalThreshold raises an alert for values below Low or above High.
+type VitalThreshold struct {
+	Type      VitalType  `json:"type"`
+	Low       *float64   `json:"low"`
+	High      *float64   `json:"high"`
+	UpdatedBy *uuid.UUID `json:"updated_by"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Breached returns "low" or "high" when the value is outside the threshold,
+// and "" otherwise.
+func (t *VitalThreshold) Breached(value float64) string {
+	switch {
+	case t.Low != nil && value < *t.Low:
+		return "low"
+	case t.High != nil && value > *t.High:
+		return "high"
+	default:
+		return ""
+	}
+}
+
+type VitalStore struct {
+	db *sql.DB
+}
+
+const vitalsColumns = `
+	id, patient_id, appointment_id, systolic, diastolic, pulse, temperature, spo2, weight, height,
+	bmi, recorded_by, recorded_at, created_at
+`
+
+func scanVitals(row rowScanner) (*Vitals, error) {
+	v := &Vitals{}
+	err := row.Scan(
+		&v.ID,
+		&v.PatientID,
+		&v.AppointmentID,
+		&v.Systolic,
+		&v.Diastolic,
+		&v.Pulse,
+		&v.Temperature,
+		&v.SpO2,
+		&v.Weight,
+		&v.Height,
+		&v.BMI,
+		&v.RecordedBy,
+		&v.RecordedAt,
+		&v.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Create stores a set of measurements. When weight is recorded BMI is
+// computed from the height in the same set, or else from the patient's most
+// recent height recorded before it.
+func (s *VitalStore) Create(ctx context.Context, v *Vitals) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+		defer cancel()
+
+		height := v.Height
+		if v.Weight != nil && height == nil {
+			query := `
+				SELECT height FROM vitals
+				WHERE patient_id = $1 AND height IS NOT NULL AND recorded_at <= $2
+				ORDER BY recorded_at DESC
+				LIMIT 1
+			`
+
+			err := tx.QueryRowContext(ctx, query, v.PatientID, v.RecordedAt).Scan(&height)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+		}
+
+		v.BMI = nil
+		if v.Weight != nil && height != nil && *height > 0 {
+			metres := *height / 100
+			bmi := math.Round(*v.Weight/(metres*metres)*10) / 10
+			v.BMI = &bmi
+		}
+
+		query := `
+			INSERT INTO vitals (patient_id, appointment_id, systolic, diastolic, pulse, temperature, spo2,
+				weight, height, bmi, recorded_by, recorded_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			RETURNING id, created_at
+		`
+
+		return tx.QueryRowContext(
+			ctx,
+			query,
+			v.PatientID,
+			v.AppointmentID,
+			v.Systolic,
+			v.Diastolic,
+			v.Pulse,
+			v.Temperature,
+			v.SpO2,
+			v.Weight,
+			v.Height,
+			v.BMI,
+			v.RecordedBy,
+			v.RecordedAt,
+		).Scan(
+			&v.ID,
+			&v.CreatedAt,
+		)
+	})
+}
+
+// GetByPatient returns the patient's measurements recorded in [from, to),
+// oldest first.
+func (s *VitalStore) GetByPatient(ctx context.Context, patientID uuid.UUID, from, to time.Time) ([]*Vitals, error) {
+	query := `
+		SELECT ` + vitalsColumns + `
+		FROM vitals
+		WHERE patient_id = $1 AND recorded_at >= $2 AND recorded_at < $3
+		ORDER BY recorded_at, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, patientID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vitals := []*Vitals{}
+	for rows.Next() {
+		v, err := scanVitals(rows)
+		if err != nil {
+			return nil, err
+		}
+		vitals = append(vitals, v)
+	}
+
+	return vitals, rows.Err()
+}
+
+func (s *VitalStore) GetThresholds(ctx context.Context) (map[VitalType]*VitalThreshold, error) {
+	query := `SELECT type, low, high, updated_by, updated_at FROM vital_thresholds`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	thresholds := map[VitalType]*VitalThreshold{}
+	for rows.Next() {
+		t := &VitalThreshold{}
+		if err := rows.Scan(&t.Type, &t.Low, &t.High, &t.UpdatedBy, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		thresholds[t.Type] = t
+	}
+
+	return thresholds, rows.Err()
+}
+
+// SetThreshold creates or replaces the threshold for a type. Leaving both
+// bounds empty turns alerts for the type off.
+func (s *VitalStore) SetThreshold(ctx context.Context, t *VitalThreshold) error {
+	query := `
+		INSERT INTO vital_thresholds (type, low, high, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (type) DO UPDATE
+		SET low = EXCLUDED.low, high = EXCLUDED.high, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	return s.db.QueryRowContext(ctx, query, t.Type, t.Low, t.High, t.UpdatedBy).Scan(&t.UpdatedAt)
+}